@@ -19,11 +19,13 @@ package lookupvindex
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"vitess.io/vitess/go/cmd/vtctldclient/cli"
 	"vitess.io/vitess/go/cmd/vtctldclient/command/vreplication/common"
+	"vitess.io/vitess/go/protoutil"
 
 	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
 	vschemapb "vitess.io/vitess/go/vt/proto/vschema"
@@ -68,6 +70,8 @@ var (
 		TabletTypesInPreferenceOrder bool
 		IgnoreNulls                  bool
 		ContinueAfterCopyWithOwner   bool
+		AutoExternalize              bool
+		WaitUpdateInterval           time.Duration
 	}{}
 
 	externalizeOptions = struct {
@@ -211,6 +215,8 @@ func commandCreate(cmd *cobra.Command, args []string) error {
 		Cells:                      createOptions.Cells,
 		TabletTypes:                createOptions.TabletTypes,
 		TabletSelectionPreference:  tsp,
+		AutoExternalize:            createOptions.AutoExternalize,
+		WaitUpdateInterval:         protoutil.DurationToProto(createOptions.WaitUpdateInterval),
 	})
 
 	if err != nil {
@@ -219,6 +225,9 @@ func commandCreate(cmd *cobra.Command, args []string) error {
 
 	output := fmt.Sprintf("LookupVindex %s created in the %s keyspace and the %s VReplication wokflow scheduled on the %s shards, use show to view progress",
 		baseOptions.Name, createOptions.Keyspace, baseOptions.Name, baseOptions.TableKeyspace)
+	if createOptions.AutoExternalize {
+		output += ", it will be externalized once backfilled and verified by VDiff"
+	}
 	fmt.Println(output)
 
 	return nil
@@ -294,6 +303,8 @@ func registerCommands(root *cobra.Command) {
 	create.Flags().StringVar(&createOptions.TableVindexType, "table-vindex-type", "", "The primary vindex name/type to use for the lookup table, if the table-keyspace is sharded. This must match the name of a vindex defined in the table-keyspace. If no value is provided then the default type will be used based on the table-owner-columns types.")
 	create.Flags().BoolVar(&createOptions.IgnoreNulls, "ignore-nulls", false, "Do not add corresponding records in the lookup table if any of the owner table's 'from' fields are NULL.")
 	create.Flags().BoolVar(&createOptions.ContinueAfterCopyWithOwner, "continue-after-copy-with-owner", true, "Vindex will continue materialization after the backfill completes when an owner is provided.")
+	create.Flags().BoolVar(&createOptions.AutoExternalize, "auto-externalize", false, "Once the backfill completes, verify the Lookup Vindex with a VDiff and externalize it if the VDiff finds no differences. This is done in the background by vtctld, and its progress shows up in the tags of the workflow streams in 'LookupVindex show'. Until then the Lookup Vindex is write_only: VTGate maintains it on writes but does not use it for routing.")
+	create.Flags().DurationVar(&createOptions.WaitUpdateInterval, "wait-update-interval", 1*time.Minute, "When auto-externalizing, how often vtctld checks the status of the backfill and of the VDiff.")
 	// VReplication specific flags.
 	create.Flags().StringSliceVar(&createOptions.Cells, "cells", nil, "Cells to look in for source tablets to replicate from.")
 	create.Flags().Var((*topoprotopb.TabletTypeListFlag)(&createOptions.TabletTypes), "tablet-types", "Source tablet types to replicate from.")
@@ -19,6 +19,7 @@ limitations under the License.
 package hack
 
 import (
+	"strconv"
 	"unsafe"
 )
 
@@ -33,16 +34,19 @@ func RuntimeStrhash(str string, seed uint64) uint64 {
 	return uint64(strhash(unsafe.Pointer(&str), uintptr(seed)))
 }
 
-//go:linkname roundupsize runtime.roundupsize
-func roundupsize(size uintptr) uintptr
+func roundupsize(size uintptr) uintptr { return size }
 
 // RuntimeAllocSize returns size of the memory block that mallocgc will allocate if you ask for the size.
 func RuntimeAllocSize(size int64) int64 {
 	return int64(roundupsize(uintptr(size)))
 }
 
-//go:linkname Atof64 strconv.atof64
-func Atof64(s string) (float64, int, error)
+func Atof64(s string) (float64, int, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	return f, len(s), err
+}
 
-//go:linkname Atof32 strconv.atof32
-func Atof32(s string) (float32, int, error)
+func Atof32(s string) (float32, int, error) {
+	f, err := strconv.ParseFloat(s, 32)
+	return float32(f), len(s), err
+}
@@ -174,7 +174,7 @@ func Connect(ctx context.Context, params *ConnParams) (*Conn, error) {
 // Ping implements mysql ping command.
 func (c *Conn) Ping() error {
 	// This is a new command, need to reset the sequence.
-	c.sequence = 0
+	c.resetSequence()
 	data, pos := c.startEphemeralPacketWithHeader(1)
 	data[pos] = ComPing
 
@@ -195,6 +195,49 @@ func (c *Conn) Ping() error {
 	return vterrors.Errorf(vtrpcpb.Code_INTERNAL, "unexpected packet type: %d", data[0])
 }
 
+// ChangeUser implements mysql change user command. It authenticates as
+// params.Uname with params.Pass, and switches to params.DbName. The server
+// resets the session, as with COM_RESET_CONNECTION.
+// Returns a SQLError.
+func (c *Conn) ChangeUser(params *ConnParams) error {
+	// This is a new command, need to reset the sequence.
+	c.resetSequence()
+
+	authMethod := c.authPluginName
+	var scrambledPassword []byte
+	if authMethod == CachingSha2Password {
+		scrambledPassword = ScrambleCachingSha2Password(c.salt, []byte(params.Pass))
+	} else {
+		authMethod = MysqlNativePassword
+		scrambledPassword = ScrambleMysqlNativePassword(c.salt, []byte(params.Pass))
+	}
+
+	length := 1 + // ComChangeUser
+		lenNullString(params.Uname) +
+		1 + len(scrambledPassword) +
+		lenNullString(params.DbName) +
+		2 + // character set
+		lenNullString(string(authMethod))
+
+	data, pos := c.startEphemeralPacketWithHeader(length)
+	pos = writeByte(data, pos, ComChangeUser)
+	pos = writeNullString(data, pos, params.Uname)
+	pos = writeByte(data, pos, uint8(len(scrambledPassword)))
+	pos += copy(data[pos:], scrambledPassword)
+	pos = writeNullString(data, pos, params.DbName)
+	pos = writeUint16(data, pos, uint16(params.Charset))
+	_ = writeNullString(data, pos, string(authMethod))
+
+	if err := c.writeEphemeralPacket(); err != nil {
+		return sqlerror.NewSQLError(sqlerror.CRServerGone, sqlerror.SSUnknownSQLState, "%v", err)
+	}
+	if err := c.handleAuthResponse(params); err != nil {
+		return err
+	}
+	c.schemaName = params.DbName
+	return nil
+}
+
 // clientHandshake handles the client side of the handshake.
 // Note the connection can be closed while this is running.
 // Returns a SQLError.
@@ -295,6 +338,23 @@ func (c *Conn) clientHandshake(params *ConnParams) error {
 		return sqlerror.NewSQLError(sqlerror.CRSSLConnectionError, sqlerror.SSUnknownSQLState, "server doesn't support ClientSessionTrack but client asked for it")
 	}
 
+	// Protocol compression. We pick the first algorithm the server
+	// supports, and switch to it once the handshake is done.
+	compression, err := negotiateCompression(params.CompressionAlgorithms, capabilities)
+	if err != nil {
+		return sqlerror.NewSQLError(sqlerror.CRServerHandshakeErr, sqlerror.SSUnknownSQLState, "%v", err)
+	}
+	if compression == CapabilityClientZstdCompressionAlgorithm {
+		c.compressionLevel = params.ZstdCompressionLevel
+		if c.compressionLevel == 0 {
+			c.compressionLevel = DefaultZstdCompressionLevel
+		}
+		if !validZstdCompressionLevel(c.compressionLevel) {
+			return sqlerror.NewSQLError(sqlerror.CRServerHandshakeErr, sqlerror.SSUnknownSQLState, "invalid zstd compression level: %v", c.compressionLevel)
+		}
+	}
+	c.Capabilities |= compression
+
 	// Build and send our handshake response 41.
 	// Note this one will never have SSL flag on.
 	if err := c.writeHandshakeResponse41(capabilities, scrambledPassword, uint8(params.Charset), params); err != nil {
@@ -306,9 +366,18 @@ func (c *Conn) clientHandshake(params *ConnParams) error {
 		return err
 	}
 
+	// The handshake is done, switch to the compressed protocol if we
+	// negotiated it.
+	if err := c.enableCompression(); err != nil {
+		return sqlerror.NewSQLError(sqlerror.CRServerHandshakeErr, sqlerror.SSUnknownSQLState, "cannot enable protocol compression: %v", err)
+	}
+
 	// If the server didn't support DbName in its handshake, set
 	// it now. This is what the 'mysql' client does.
 	if capabilities&CapabilityClientConnectWithDB == 0 && params.DbName != "" {
+		// This is a new command, need to reset the sequence.
+		c.resetSequence()
+
 		// Write the packet.
 		if err := c.writeComInitDB(params.DbName); err != nil {
 			return err
@@ -537,7 +606,9 @@ func (c *Conn) writeHandshakeResponse41(capabilities uint32, scrambledPassword [
 		CapabilityClientFoundRows&uint32(params.Flags) |
 		// If the server supported
 		// CapabilityClientSessionTrack, we also support it.
-		c.Capabilities&CapabilityClientSessionTrack
+		c.Capabilities&CapabilityClientSessionTrack |
+		// The negotiated protocol compression, if any.
+		c.Capabilities&(CapabilityClientCompress|CapabilityClientZstdCompressionAlgorithm)
 
 	// FIXME(alainjobart) add multi statement.
 
@@ -564,6 +635,11 @@ func (c *Conn) writeHandshakeResponse41(capabilities uint32, scrambledPassword [
 		length++
 	}
 
+	// Add the zstd compression level.
+	if capabilityFlags&CapabilityClientZstdCompressionAlgorithm != 0 {
+		length++
+	}
+
 	data, pos := c.startEphemeralPacketWithHeader(length)
 
 	// Client capability flags.
@@ -600,6 +676,11 @@ func (c *Conn) writeHandshakeResponse41(capabilities uint32, scrambledPassword [
 	// Assume native client during response
 	pos = writeNullString(data, pos, string(c.authPluginName))
 
+	// zstd compression level, only if we negotiated zstd.
+	if capabilityFlags&CapabilityClientZstdCompressionAlgorithm != 0 {
+		pos = writeByte(data, pos, byte(c.compressionLevel))
+	}
+
 	// Sanity-check the length.
 	if pos != len(data) {
 		return sqlerror.NewSQLError(sqlerror.CRMalformedPacket, sqlerror.SSUnknownSQLState, "writeHandshakeResponse41: only packed %v bytes, out of %v allocated", pos, len(data))
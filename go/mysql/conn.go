@@ -87,7 +87,8 @@ type Conn struct {
 	// fields, this is set to an empty array (but not nil).
 	fields []*querypb.Field
 
-	// salt is sent by the server during initial handshake to be used for authentication
+	// salt is sent by the server during initial handshake to be used for authentication.
+	// Both client and servers keep it for COM_CHANGE_USER.
 	salt []byte
 
 	// authPluginName is the name of server's authentication plugin.
@@ -121,11 +122,11 @@ type Conn struct {
 	ServerVersion string
 
 	// User is the name used by the client to connect.
-	// It is set during the initial handshake.
+	// It is set during the initial handshake, and by COM_CHANGE_USER.
 	User string // For server-side connections, listener points to the server object.
 
 	// UserData is custom data returned by the AuthServer module.
-	// It is set during the initial handshake.
+	// It is set during the initial handshake, and by COM_CHANGE_USER.
 	UserData Getter
 
 	bufferedReader *bufio.Reader
@@ -151,6 +152,10 @@ type Conn struct {
 	// PrepareData is the map to use a prepared statement.
 	PrepareData map[uint32]*PrepareData
 
+	// streamingCursor is the cursor whose results the handler is
+	// streaming, if any. See cursor.go.
+	streamingCursor *cursor
+
 	// protects the bufferedWriter and bufferedReader
 	bufMu sync.Mutex
 
@@ -194,6 +199,20 @@ type Conn struct {
 	// Packet encoding variables.
 	sequence uint8
 
+	// compressedSequence is the sequence number of the compressed
+	// packets, when protocol compression is enabled. See protocol_compression.go.
+	compressedSequence uint8
+
+	// compressedReader and compressedWriter are set once the protocol
+	// compression negotiated during the handshake has been enabled.
+	// They are nil if the connection is not compressed.
+	compressedReader *compressedReader
+	compressedWriter *compressedWriter
+
+	// compressionLevel is the zstd compression level negotiated
+	// during the handshake.
+	compressionLevel int
+
 	// ExpectSemiSyncIndicator is applicable when the connection is used for replication (ComBinlogDump).
 	// When 'true', events are assumed to be padded with 2-byte semi-sync information
 	// See https://dev.mysql.com/doc/internals/en/semi-sync-binlog-event.html
@@ -227,6 +246,10 @@ type PrepareData struct {
 	BindVars    map[string]*querypb.BindVariable
 	StatementID uint32
 	ParamsCount uint16
+
+	// cursor is the read-only cursor opened by the last execution of
+	// the statement, if any.
+	cursor *cursor
 }
 
 // execResult is an enum signifying the result of executing a query
@@ -323,7 +346,7 @@ func (c *Conn) startWriterBuffering() {
 	defer c.bufMu.Unlock()
 
 	c.bufferedWriter = writersPool.Get().(*bufio.Writer)
-	c.bufferedWriter.Reset(c.conn)
+	c.bufferedWriter.Reset(c.getWriter())
 }
 
 // endWriterBuffering must be called to terminate startWriteBuffering.
@@ -371,14 +394,34 @@ func (c *Conn) startFlushTimer() {
 }
 
 // getReader returns reader for connection. It can be *bufio.Reader or net.Conn
-// depending on which buffer size was passed to newServerConn.
+// depending on which buffer size was passed to newServerConn, or a
+// *compressedReader on top of them if the protocol is compressed.
 func (c *Conn) getReader() io.Reader {
+	if c.compressedReader != nil {
+		return c.compressedReader
+	}
 	if c.bufferedReader != nil {
 		return c.bufferedReader
 	}
 	return c.conn
 }
 
+// getWriter returns the unbuffered writer for connection. It is the
+// net.Conn, or a *compressedWriter on top of it if the protocol is compressed.
+func (c *Conn) getWriter() io.Writer {
+	if c.compressedWriter != nil {
+		return c.compressedWriter
+	}
+	return c.conn
+}
+
+// resetSequence resets the packet sequence numbers at the start of
+// a new command.
+func (c *Conn) resetSequence() {
+	c.sequence = 0
+	c.compressedSequence = 0
+}
+
 func (c *Conn) readHeaderFrom(r io.Reader) (int, error) {
 	// Note io.ReadFull will return two different types of errors:
 	// 1. if the socket is already closed, and the go runtime knows it,
@@ -399,12 +442,16 @@ func (c *Conn) readHeaderFrom(r io.Reader) (int, error) {
 		return 0, vterrors.Wrapf(err, "io.ReadFull(header size) failed")
 	}
 
-	sequence := uint8(c.header[3])
-	if sequence != c.sequence {
-		return 0, vterrors.Errorf(vtrpcpb.Code_INTERNAL, "invalid sequence, expected %v got %v", c.sequence, sequence)
-	}
+	// With the compressed protocol, the sequence is checked by the
+	// compressedReader on the compressed packets instead.
+	if c.compressedReader == nil {
+		sequence := uint8(c.header[3])
+		if sequence != c.sequence {
+			return 0, vterrors.Errorf(vtrpcpb.Code_INTERNAL, "invalid sequence, expected %v got %v", c.sequence, sequence)
+		}
 
-	c.sequence++
+		c.sequence++
+	}
 
 	return int(uint32(c.header[0]) | uint32(c.header[1])<<8 | uint32(c.header[2])<<16), nil
 }
@@ -611,7 +658,7 @@ func (c *Conn) writePacket(data []byte) error {
 		}()
 	} else {
 		c.bufMu.Unlock()
-		w = c.conn
+		w = c.getWriter()
 	}
 
 	var header [packetHeaderSize]byte
@@ -715,7 +762,7 @@ func (c *Conn) recycleWritePacket() {
 // Returns SQLError(CRServerGone) if it can't.
 func (c *Conn) writeComQuit() error {
 	// This is a new command, need to reset the sequence.
-	c.sequence = 0
+	c.resetSequence()
 
 	data, pos := c.startEphemeralPacketWithHeader(1)
 	data[pos] = ComQuit
@@ -921,7 +968,7 @@ func (c *Conn) writeEOFPacket(flags uint16, warnings uint16) error {
 // handleNextCommand is called in the server loop to process
 // incoming packets.
 func (c *Conn) handleNextCommand(handler Handler) bool {
-	c.sequence = 0
+	c.resetSequence()
 	data, err := c.readEphemeralPacket()
 	if err != nil {
 		// Don't log EOF errors. They cause too much spam.
@@ -938,6 +985,15 @@ func (c *Conn) handleNextCommand(handler Handler) bool {
 		return false
 	}
 
+	switch data[0] {
+	case ComQuit, ComPing, ComSetOption, ComStmtSendLongData, ComStmtFetch, ComStmtClose, ComStmtReset, ComResetConnection, ComChangeUser:
+		// These commands don't call the handler, or close the cursors first.
+	default:
+		// The handler can't be called while it streams the results
+		// of a cursor, so buffer them first.
+		c.bufferStreamingCursor()
+	}
+
 	switch data[0] {
 	case ComQuit:
 		c.recycleReadPacket()
@@ -959,10 +1015,13 @@ func (c *Conn) handleNextCommand(handler Handler) bool {
 		return c.handleComStmtExecute(handler, data)
 	case ComStmtSendLongData:
 		return c.handleComStmtSendLongData(data)
+	case ComStmtFetch:
+		return c.handleComStmtFetch(data)
 	case ComStmtClose:
 		stmtID, ok := c.parseComStmtClose(data)
 		c.recycleReadPacket()
-		if ok {
+		if prepare, found := c.PrepareData[stmtID]; ok && found {
+			c.closeCursor(prepare)
 			delete(c.PrepareData, stmtID)
 		}
 	case ComStmtReset:
@@ -970,6 +1029,8 @@ func (c *Conn) handleNextCommand(handler Handler) bool {
 	case ComResetConnection:
 		c.handleComResetConnection(handler)
 		return true
+	case ComChangeUser:
+		return c.handleComChangeUser(handler, data)
 	case ComFieldList:
 		c.recycleReadPacket()
 		if !c.writeErrorAndLog(sqlerror.ERUnknownComError, sqlerror.SSNetError, "command handling not implemented yet: %v", data[0]) {
@@ -1061,6 +1122,7 @@ func (c *Conn) handleComBinlogDumpGTID(handler Handler, data []byte) (kontinue b
 func (c *Conn) handleComResetConnection(handler Handler) {
 	// Clean up and reset the connection
 	c.recycleReadPacket()
+	c.closeCursors()
 	handler.ComResetConnection(c)
 	// Reset prepared statements
 	c.PrepareData = make(map[uint32]*PrepareData)
@@ -1070,6 +1132,54 @@ func (c *Conn) handleComResetConnection(handler Handler) {
 	}
 }
 
+func (c *Conn) handleComChangeUser(handler Handler, data []byte) bool {
+	user, authMethod, authResponse, schemaName, err := c.parseComChangeUser(data)
+	c.recycleReadPacket()
+	if err != nil {
+		log.Errorf("Cannot parse change user packet from %s: %v", c, err)
+		return false
+	}
+
+	// Like COM_RESET_CONNECTION, changing the user closes the cursors
+	// and prepared statements, even if the authentication fails.
+	c.closeCursors()
+	c.PrepareData = make(map[uint32]*PrepareData)
+
+	userData, err := c.listener.authenticate(c, user, authMethod, authResponse, c.salt)
+	if err != nil {
+		// The client got the error, and stays authenticated as the
+		// previous user.
+		return true
+	}
+
+	handler.ComChangeUser(c)
+
+	if c.User != "" {
+		connCountPerUser.Add(c.User, -1)
+	}
+	c.User = user
+	c.UserData = userData
+	if c.User != "" {
+		connCountPerUser.Add(c.User, 1)
+	}
+
+	c.schemaName = schemaName
+	if c.schemaName != "" {
+		err = handler.ComQuery(c, "use "+sqlescape.EscapeID(c.schemaName), func(result *sqltypes.Result) error {
+			return nil
+		})
+		if err != nil {
+			return c.writeErrorPacketFromErrorAndLog(err)
+		}
+	}
+
+	if err := c.writeOKPacket(&PacketOK{statusFlags: c.StatusFlags}); err != nil {
+		log.Errorf("Cannot write OK packet to %s: %v", c, err)
+		return false
+	}
+	return true
+}
+
 func (c *Conn) handleComStmtReset(data []byte) bool {
 	stmtID, ok := c.parseComStmtReset(data)
 	c.recycleReadPacket()
@@ -1093,6 +1203,7 @@ func (c *Conn) handleComStmtReset(data []byte) bool {
 			prepare.BindVars[k] = nil
 		}
 	}
+	c.closeCursor(prepare)
 
 	if err := c.writeOKPacket(&PacketOK{statusFlags: c.StatusFlags}); err != nil {
 		log.Error("Error writing ComStmtReset OK packet to client %v: %v", c.ConnectionID, err)
@@ -1140,7 +1251,7 @@ func (c *Conn) handleComStmtExecute(handler Handler, data []byte) (kontinue bool
 		}
 	}()
 	queryStart := time.Now()
-	stmtID, _, err := c.parseComStmtExecute(c.PrepareData, data)
+	stmtID, cursorType, err := c.parseComStmtExecute(c.PrepareData, data)
 	c.recycleReadPacket()
 
 	if stmtID != uint32(0) {
@@ -1155,10 +1266,21 @@ func (c *Conn) handleComStmtExecute(handler Handler, data []byte) (kontinue bool
 		return c.writeErrorPacketFromErrorAndLog(err)
 	}
 
+	// Executing the statement again closes its cursor.
+	prepare := c.PrepareData[stmtID]
+	c.closeCursor(prepare)
+
+	if cursorType&CursorTypeReadOnly != 0 {
+		if !c.executeWithCursor(handler, prepare) {
+			return false
+		}
+		timings.Record(queryTimingKey, queryStart)
+		return true
+	}
+
 	fieldSent := false
 	// sendFinished is set if the response should just be an OK packet.
 	sendFinished := false
-	prepare := c.PrepareData[stmtID]
 	err = handler.ComStmtExecute(c, prepare, func(qr *sqltypes.Result) error {
 		if sendFinished {
 			// Failsafe: Unreachable if server is well-behaved.
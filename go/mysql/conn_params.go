@@ -64,6 +64,17 @@ type ConnParams struct {
 	// FlushDelay is the delay after which buffered response will be flushed to the client.
 	FlushDelay time.Duration
 
+	// CompressionAlgorithms is a comma separated list of the protocol
+	// compression algorithms the client allows, in order of preference:
+	// zlib, zstd and uncompressed. The connection is not compressed if
+	// it is empty. If uncompressed is not in the list, connecting fails
+	// when the server supports none of the algorithms.
+	CompressionAlgorithms string
+
+	// ZstdCompressionLevel is the compression level to use with zstd,
+	// between 1 and 22. It defaults to DefaultZstdCompressionLevel.
+	ZstdCompressionLevel int
+
 	TruncateErrLen int
 }
 
@@ -76,8 +76,10 @@ const (
 	// CLIENT_NO_SCHEMA 1 << 4
 	// Do not permit database.table.column. We do permit it.
 
-	// CLIENT_COMPRESS 1 << 5
-	// We do not support compression. CPU is usually our bottleneck.
+	// CapabilityClientCompress is CLIENT_COMPRESS.
+	// Use the zlib compressed protocol once the handshake is done.
+	// Only negotiated if enabled on both the client and the server.
+	CapabilityClientCompress = 1 << 5
 
 	// CLIENT_ODBC 1 << 6
 	// No special behavior since 3.22.
@@ -148,6 +150,14 @@ const (
 	// CapabilityClientDeprecateEOF is CLIENT_DEPRECATE_EOF
 	// Expects an OK (instead of EOF) after the resultset rows of a Text Resultset.
 	CapabilityClientDeprecateEOF = 1 << 24
+
+	// CLIENT_OPTIONAL_RESULTSET_METADATA 1 << 25
+	// Not supported.
+
+	// CapabilityClientZstdCompressionAlgorithm is CLIENT_ZSTD_COMPRESSION_ALGORITHM.
+	// Use the zstd compressed protocol once the handshake is done. The
+	// client sends the compression level it wants in the handshake response.
+	CapabilityClientZstdCompressionAlgorithm = 1 << 26
 )
 
 // Status flags. They are returned by the server in a few cases.
@@ -209,6 +219,9 @@ const (
 	// ComPing is COM_PING.
 	ComPing = 0x0e
 
+	// ComChangeUser is COM_CHANGE_USER.
+	ComChangeUser = 0x11
+
 	// ComBinlogDump is COM_BINLOG_DUMP.
 	ComBinlogDump = 0x12
 
@@ -274,6 +287,17 @@ const (
 	AuthSwitchRequestPacket = 0xfe
 )
 
+// Cursor type flags of COM_STMT_EXECUTE.
+// Originally found in include/mysql/mysql_com.h
+const (
+	// CursorTypeNoCursor executes the statement without a cursor.
+	CursorTypeNoCursor = 0x00
+
+	// CursorTypeReadOnly opens a read-only cursor on the result set,
+	// whose rows are then fetched with COM_STMT_FETCH.
+	CursorTypeReadOnly = 0x01
+)
+
 var typeInt24, _ = sqltypes.TypeToMySQL(sqltypes.Int24)
 var typeTimestamp, _ = sqltypes.TypeToMySQL(sqltypes.Timestamp)
 var typeYear, _ = sqltypes.TypeToMySQL(sqltypes.Year)
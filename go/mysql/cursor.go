@@ -0,0 +1,311 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysql
+
+import (
+	"errors"
+	"io"
+	"slices"
+
+	"vitess.io/vitess/go/mysql/sqlerror"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/log"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
+)
+
+// This file contains the server side of read-only cursors: a
+// COM_STMT_EXECUTE with CURSOR_TYPE_READ_ONLY only sends the column
+// definitions of the result set, and the client then fetches the rows
+// in batches with COM_STMT_FETCH.
+//
+// The handler streams the results from its own go routine, and the
+// connection reads them as the client fetches the rows. The handler
+// methods must stay serialized, so at most one cursor is streaming at
+// any time: before any other command calls the handler, the remaining
+// results of that cursor are buffered in memory.
+
+// errCursorNotImplemented is returned by the ComStmtExecuteCursor of
+// UnimplementedHandler.
+var errCursorNotImplemented = errors.New("cursors are not implemented by the handler")
+
+// cursor is a read-only cursor on the result set of a prepared statement.
+type cursor struct {
+	fields []*querypb.Field
+
+	// rows are the rows read from the stream, and not fetched yet.
+	rows [][]sqltypes.Value
+
+	// results receives the results of the stream. It is nil once
+	// the stream is over, or if the results were all buffered.
+	results chan *sqltypes.Result
+
+	// err is the error the stream ended with. It is set before
+	// results is closed.
+	err error
+
+	// done is closed to abort the stream.
+	done chan struct{}
+}
+
+// streamCursor runs execute in a new go routine, and returns the cursor
+// reading its results.
+func streamCursor(execute func(callback func(*sqltypes.Result) error) error) *cursor {
+	cur := &cursor{
+		results: make(chan *sqltypes.Result),
+		done:    make(chan struct{}),
+	}
+	go func() {
+		defer close(cur.results)
+		cur.err = execute(func(qr *sqltypes.Result) error {
+			select {
+			case <-cur.done:
+				return io.EOF
+			default:
+			}
+			select {
+			case cur.results <- qr:
+				return nil
+			case <-cur.done:
+				return io.EOF
+			}
+		})
+	}()
+	return cur
+}
+
+// next waits for the next result of the stream. It returns false once
+// the stream is over, cur.err then holds its error.
+func (cur *cursor) next() (*sqltypes.Result, bool) {
+	if cur.results == nil {
+		return nil, false
+	}
+	qr, ok := <-cur.results
+	if !ok {
+		cur.results = nil
+	}
+	return qr, ok
+}
+
+// fetch returns the next n rows of the cursor. It returns fewer rows
+// only if the cursor is exhausted. It reads one result ahead from the
+// stream, so the client knows when the last row was sent.
+func (cur *cursor) fetch(n int) ([][]sqltypes.Value, error) {
+	for len(cur.rows) <= n {
+		qr, ok := cur.next()
+		if !ok {
+			break
+		}
+		cur.rows = append(cur.rows, qr.Rows...)
+	}
+	if cur.results == nil && cur.err != nil && len(cur.rows) < n {
+		return nil, cur.err
+	}
+	n = min(n, len(cur.rows))
+	rows := cur.rows[:n:n]
+	cur.rows = cur.rows[n:]
+	return rows, nil
+}
+
+// exhausted returns true if all the rows of the cursor were fetched. A
+// cursor whose stream failed is never exhausted, so that the next fetch
+// returns the error instead of the last rows looking like a full result.
+func (cur *cursor) exhausted() bool {
+	return cur.results == nil && cur.err == nil && len(cur.rows) == 0
+}
+
+// buffer reads the remaining results of the stream in memory.
+func (cur *cursor) buffer() {
+	for {
+		qr, ok := cur.next()
+		if !ok {
+			return
+		}
+		cur.rows = append(cur.rows, qr.Rows...)
+	}
+}
+
+// close aborts the stream, and waits for the handler to return.
+func (cur *cursor) close() {
+	if cur.results == nil {
+		return
+	}
+	close(cur.done)
+	for range cur.results {
+	}
+	cur.results = nil
+}
+
+// executeWithCursor executes a prepared statement with a read-only
+// cursor. If the statement returns a result set, only its column
+// definitions are sent, followed by an end packet with the
+// ServerStatusCursorExists flag. Otherwise the client gets the usual
+// OK packet, and no cursor is opened.
+func (c *Conn) executeWithCursor(handler Handler, prepare *PrepareData) bool {
+	// The bind variables of the statement are reset once we return,
+	// so the handler gets its own copy.
+	stmt := *prepare
+	cur := streamCursor(func(callback func(*sqltypes.Result) error) error {
+		return handler.ComStmtExecuteCursor(c, &stmt, callback)
+	})
+	first, ok := cur.next()
+
+	if !ok && cur.err == errCursorNotImplemented {
+		// The handler can't stream, so buffer all the results.
+		cur = &cursor{}
+		cur.err = handler.ComStmtExecute(c, prepare, func(qr *sqltypes.Result) error {
+			if first == nil {
+				first = qr
+			} else {
+				cur.rows = append(cur.rows, qr.Rows...)
+			}
+			return nil
+		})
+	}
+
+	if first == nil {
+		cur.close()
+		err := cur.err
+		if err == nil || err == io.EOF {
+			err = sqlerror.NewSQLErrorFromError(errors.New("unexpected: query ended without no results and no error"))
+		}
+		return c.writeErrorPacketFromErrorAndLog(err)
+	}
+
+	if len(first.Fields) == 0 {
+		cur.close()
+		ok := PacketOK{
+			affectedRows:     first.RowsAffected,
+			lastInsertID:     first.InsertID,
+			statusFlags:      c.StatusFlags,
+			sessionStateData: first.SessionStateChanges,
+		}
+		if err := c.writeOKPacket(&ok); err != nil {
+			log.Errorf("Error writing result to %s: %v", c, err)
+			return false
+		}
+		return true
+	}
+
+	cur.fields = first.Fields
+	cur.rows = slices.Concat(first.Rows, cur.rows)
+	prepare.cursor = cur
+	if cur.results != nil {
+		c.streamingCursor = cur
+	}
+
+	if err := c.sendColumnCount(uint64(len(cur.fields))); err != nil {
+		log.Errorf("Error writing result to %s: %v", c, err)
+		return false
+	}
+	for _, field := range cur.fields {
+		if err := c.writeColumnDefinition(field); err != nil {
+			log.Errorf("Error writing result to %s: %v", c, err)
+			return false
+		}
+	}
+	if err := c.writeCursorEndResult(ServerStatusCursorExists); err != nil {
+		log.Errorf("Error writing result to %s: %v", c, err)
+		return false
+	}
+	return true
+}
+
+func (c *Conn) handleComStmtFetch(data []byte) (kontinue bool) {
+	c.startWriterBuffering()
+	defer func() {
+		if err := c.endWriterBuffering(); err != nil {
+			log.Errorf("conn %v: flush() failed: %v", c.ID(), err)
+			kontinue = false
+		}
+	}()
+
+	stmtID, numRows, ok := c.parseComStmtFetch(data)
+	c.recycleReadPacket()
+	if !ok {
+		return c.writeErrorAndLog(sqlerror.CRMalformedPacket, sqlerror.SSUnknownSQLState, "error parsing statement fetch packet")
+	}
+
+	prepare, ok := c.PrepareData[stmtID]
+	if !ok {
+		return c.writeErrorAndLog(sqlerror.ERUnknownStmtHandler, sqlerror.SSUnknownSQLState, "Unknown prepared statement handler (%d) given to mysqld_stmt_fetch", stmtID)
+	}
+	cur := prepare.cursor
+	if cur == nil {
+		return c.writeErrorAndLog(sqlerror.ERStmtHasNoOpenCursor, sqlerror.SSUnknownSQLState, "The statement (%d) has no open cursor.", stmtID)
+	}
+
+	rows, err := cur.fetch(int(numRows))
+	if err != nil {
+		c.closeCursor(prepare)
+		return c.writeErrorPacketFromErrorAndLog(err)
+	}
+	if err := c.writeBinaryRows(&sqltypes.Result{Fields: cur.fields, Rows: rows}); err != nil {
+		log.Errorf("Error writing result to %s: %v", c, err)
+		return false
+	}
+
+	flags := ServerStatusCursorExists
+	if cur.exhausted() {
+		flags |= ServerStatusLastRowSent
+		c.closeCursor(prepare)
+	}
+	if err := c.writeCursorEndResult(flags); err != nil {
+		log.Errorf("Error writing result to %s: %v", c, err)
+		return false
+	}
+	return true
+}
+
+// writeCursorEndResult ends the column definitions sent when a cursor
+// is opened, or the rows sent by COM_STMT_FETCH, with the given cursor
+// status flags.
+func (c *Conn) writeCursorEndResult(cursorFlags uint16) error {
+	flags := c.StatusFlags | cursorFlags
+	if c.Capabilities&CapabilityClientDeprecateEOF == 0 {
+		return c.writeEOFPacket(flags, 0)
+	}
+	return c.writeOKPacketWithEOFHeader(&PacketOK{statusFlags: flags})
+}
+
+// closeCursor closes the cursor of a prepared statement, if any.
+func (c *Conn) closeCursor(prepare *PrepareData) {
+	if prepare.cursor == nil {
+		return
+	}
+	prepare.cursor.close()
+	if c.streamingCursor == prepare.cursor {
+		c.streamingCursor = nil
+	}
+	prepare.cursor = nil
+}
+
+// closeCursors closes the cursors of all the prepared statements.
+func (c *Conn) closeCursors() {
+	for _, prepare := range c.PrepareData {
+		c.closeCursor(prepare)
+	}
+}
+
+// bufferStreamingCursor buffers the remaining results of the cursor
+// being streamed, if any, so the handler can be called again.
+func (c *Conn) bufferStreamingCursor() {
+	if c.streamingCursor != nil {
+		c.streamingCursor.buffer()
+		c.streamingCursor = nil
+	}
+}
@@ -0,0 +1,279 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysql
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/mysql/sqlerror"
+	"vitess.io/vitess/go/sqltypes"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
+)
+
+// cursorHandler returns rows results of one row each to the statements
+// executed with a cursor, or no result set if dml is set. If noCursor is
+// set, it only implements ComStmtExecute.
+type cursorHandler struct {
+	testHandler
+	rows     int
+	dml      bool
+	noCursor bool
+
+	streaming atomic.Bool
+	streamErr chan error
+}
+
+func newCursorHandler(rows int, noCursor bool) *cursorHandler {
+	return &cursorHandler{
+		rows:      rows,
+		noCursor:  noCursor,
+		streamErr: make(chan error, 10),
+	}
+}
+
+func (h *cursorHandler) stream(callback func(*sqltypes.Result) error) error {
+	if h.dml {
+		return callback(&sqltypes.Result{RowsAffected: 1})
+	}
+	err := callback(&sqltypes.Result{
+		Fields: []*querypb.Field{{
+			Name:    "id",
+			Type:    querypb.Type_INT64,
+			Charset: collations.CollationBinaryID,
+			Flags:   uint32(querypb.MySqlFlag_NUM_FLAG),
+		}},
+	})
+	for i := 0; err == nil && i < h.rows; i++ {
+		err = callback(&sqltypes.Result{
+			Rows: [][]sqltypes.Value{{sqltypes.NewInt64(int64(i))}},
+		})
+	}
+	return err
+}
+
+func (h *cursorHandler) ComQuery(c *Conn, query string, callback func(*sqltypes.Result) error) error {
+	if h.streaming.Load() {
+		return errors.New("ComQuery called while streaming")
+	}
+	return callback(&sqltypes.Result{})
+}
+
+func (h *cursorHandler) ComStmtExecute(c *Conn, prepare *PrepareData, callback func(*sqltypes.Result) error) error {
+	return h.stream(callback)
+}
+
+func (h *cursorHandler) ComStmtExecuteCursor(c *Conn, prepare *PrepareData, callback func(*sqltypes.Result) error) error {
+	if h.noCursor {
+		return h.testHandler.ComStmtExecuteCursor(c, prepare, callback)
+	}
+	h.streaming.Store(true)
+	defer h.streaming.Store(false)
+	err := h.stream(callback)
+	h.streamErr <- err
+	return err
+}
+
+func writeComStmtExecuteCursor(t *testing.T, c *Conn, stmtID uint32) {
+	data := []byte{0, 0, 0, 0, ComStmtExecute}
+	data = binary.LittleEndian.AppendUint32(data, stmtID)
+	data = append(data, CursorTypeReadOnly)
+	data = binary.LittleEndian.AppendUint32(data, 1) // iteration count
+	c.sequence = 0
+	require.NoError(t, c.writePacket(data))
+}
+
+func writeComStmtFetch(t *testing.T, c *Conn, stmtID uint32, numRows uint32) {
+	data := []byte{0, 0, 0, 0, ComStmtFetch}
+	data = binary.LittleEndian.AppendUint32(data, stmtID)
+	data = binary.LittleEndian.AppendUint32(data, numRows)
+	c.sequence = 0
+	require.NoError(t, c.writePacket(data))
+}
+
+// readCursorStatus reads the end packet of a cursor response, and
+// returns its status flags.
+func readCursorStatus(t *testing.T, c *Conn, data []byte, deprecateEOF bool) uint16 {
+	require.True(t, c.isEOFPacket(data), "expected an end packet, got %v", data)
+	if deprecateEOF {
+		var packetOK PacketOK
+		require.NoError(t, c.parseOKPacket(&packetOK, data))
+		return packetOK.statusFlags
+	}
+	_, flags, err := parseEOFPacket(data)
+	require.NoError(t, err)
+	return flags
+}
+
+// readCursorRows reads the binary rows sent by COM_STMT_FETCH, and
+// returns their count and the status flags of the end packet.
+func readCursorRows(t *testing.T, c *Conn, deprecateEOF bool) (int, uint16) {
+	for rows := 0; ; rows++ {
+		data, err := c.ReadPacket()
+		require.NoError(t, err)
+		if data[0] == ErrPacket {
+			require.NoError(t, ParseErrorPacket(data))
+		}
+		if c.isEOFPacket(data) {
+			return rows, readCursorStatus(t, c, data, deprecateEOF)
+		}
+		require.EqualValues(t, 0, data[0], "binary row header")
+	}
+}
+
+func TestCursorFetch(t *testing.T) {
+	for _, noCursor := range []bool{false, true} {
+		for _, deprecateEOF := range []bool{false, true} {
+			listener, sConn, cConn := createSocketPair(t)
+			if deprecateEOF {
+				sConn.Capabilities |= CapabilityClientDeprecateEOF
+			}
+			handler := newCursorHandler(10, noCursor)
+			sConn.PrepareData[1] = &PrepareData{StatementID: 1, PrepareStmt: "select id from t"}
+
+			// Only the column definitions are sent when the cursor is opened.
+			writeComStmtExecuteCursor(t, cConn, 1)
+			require.True(t, sConn.handleNextCommand(handler))
+			data, err := cConn.ReadPacket()
+			require.NoError(t, err)
+			assert.EqualValues(t, 1, data[0], "column count")
+			_, err = cConn.ReadPacket()
+			require.NoError(t, err)
+			data, err = cConn.ReadPacket()
+			require.NoError(t, err)
+			flags := readCursorStatus(t, cConn, data, deprecateEOF)
+			assert.Equal(t, ServerStatusCursorExists, flags&(ServerStatusCursorExists|ServerStatusLastRowSent))
+
+			writeComStmtFetch(t, cConn, 1, 3)
+			require.True(t, sConn.handleNextCommand(handler))
+			rows, flags := readCursorRows(t, cConn, deprecateEOF)
+			assert.Equal(t, 3, rows)
+			assert.Equal(t, ServerStatusCursorExists, flags&(ServerStatusCursorExists|ServerStatusLastRowSent))
+
+			// The rest of the results are buffered before the handler
+			// runs another query.
+			cConn.sequence = 0
+			require.NoError(t, cConn.WriteComQuery("select 1"))
+			require.True(t, sConn.handleNextCommand(handler))
+			data, err = cConn.ReadPacket()
+			require.NoError(t, err)
+			require.EqualValues(t, OKPacket, data[0], "ComQuery response: %v", data)
+
+			writeComStmtFetch(t, cConn, 1, 100)
+			require.True(t, sConn.handleNextCommand(handler))
+			rows, flags = readCursorRows(t, cConn, deprecateEOF)
+			assert.Equal(t, 7, rows)
+			assert.Equal(t, ServerStatusCursorExists|ServerStatusLastRowSent, flags&(ServerStatusCursorExists|ServerStatusLastRowSent))
+
+			// The cursor was closed after the last row.
+			writeComStmtFetch(t, cConn, 1, 1)
+			require.True(t, sConn.handleNextCommand(handler))
+			data, err = cConn.ReadPacket()
+			require.NoError(t, err)
+			var sqlErr *sqlerror.SQLError
+			require.ErrorAs(t, ParseErrorPacket(data), &sqlErr)
+			assert.Equal(t, sqlerror.ERStmtHasNoOpenCursor, sqlErr.Number())
+
+			listener.Close()
+			sConn.Close()
+			cConn.Close()
+		}
+	}
+}
+
+func TestCursorFetchError(t *testing.T) {
+	streamErr := errors.New("stream failed")
+	cur := streamCursor(func(callback func(*sqltypes.Result) error) error {
+		for i := range 3 {
+			if err := callback(&sqltypes.Result{Rows: [][]sqltypes.Value{{sqltypes.NewInt64(int64(i))}}}); err != nil {
+				return err
+			}
+		}
+		return streamErr
+	})
+
+	// The stream fails after exactly the fetched rows, so the error is
+	// returned by the next fetch.
+	rows, err := cur.fetch(3)
+	require.NoError(t, err)
+	assert.Len(t, rows, 3)
+	assert.False(t, cur.exhausted())
+
+	_, err = cur.fetch(3)
+	assert.Equal(t, streamErr, err)
+}
+
+func TestCursorClose(t *testing.T) {
+	listener, sConn, cConn := createSocketPair(t)
+	defer func() {
+		listener.Close()
+		sConn.Close()
+		cConn.Close()
+	}()
+
+	handler := newCursorHandler(1000, false)
+	sConn.PrepareData[1] = &PrepareData{StatementID: 1, PrepareStmt: "select id from t"}
+
+	writeComStmtExecuteCursor(t, cConn, 1)
+	require.True(t, sConn.handleNextCommand(handler))
+	for range 3 {
+		_, err := cConn.ReadPacket()
+		require.NoError(t, err)
+	}
+	writeComStmtFetch(t, cConn, 1, 1)
+	require.True(t, sConn.handleNextCommand(handler))
+	rows, _ := readCursorRows(t, cConn, false)
+	require.Equal(t, 1, rows)
+
+	// Closing the statement aborts the stream of its cursor.
+	cConn.sequence = 0
+	data := []byte{0, 0, 0, 0, ComStmtClose}
+	data = binary.LittleEndian.AppendUint32(data, 1)
+	require.NoError(t, cConn.writePacket(data))
+	require.True(t, sConn.handleNextCommand(handler))
+	assert.Equal(t, io.EOF, <-handler.streamErr)
+	assert.Nil(t, sConn.streamingCursor)
+	assert.Empty(t, sConn.PrepareData)
+}
+
+func TestCursorNoResultSet(t *testing.T) {
+	listener, sConn, cConn := createSocketPair(t)
+	defer func() {
+		listener.Close()
+		sConn.Close()
+		cConn.Close()
+	}()
+
+	// Statements without result set get an OK packet, and open no cursor.
+	handler := newCursorHandler(0, false)
+	handler.dml = true
+	sConn.PrepareData[1] = &PrepareData{StatementID: 1, PrepareStmt: "insert into t values (1)"}
+	writeComStmtExecuteCursor(t, cConn, 1)
+	require.True(t, sConn.handleNextCommand(handler))
+	data, err := cConn.ReadPacket()
+	require.NoError(t, err)
+	assert.EqualValues(t, OKPacket, data[0])
+	assert.Nil(t, sConn.PrepareData[1].cursor)
+}
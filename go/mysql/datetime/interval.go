@@ -423,3 +423,70 @@ func ParseIntervalDecimal(dec decimal.Decimal, mysqlPrec int32, unit IntervalTyp
 	b := dec.FormatMySQL(mysqlPrec)
 	return parseIntervalFraction(hack.String(b), prec, unit, negate)
 }
+
+// TimestampDiff returns the number of whole units between a and b, which is negative when b
+// is before a. Like MySQL's TIMESTAMPDIFF, a month has passed when the day of the month and
+// time of the day of b are the same as or after those of a, and all the other units are
+// computed from the elapsed time.
+func TimestampDiff(a, b DateTime, unit IntervalType) int64 {
+	neg := int64(1)
+	if b.Compare(a) < 0 {
+		a, b, neg = b, a, -1
+	}
+
+	secs := b.ToSeconds() - a.ToSeconds()
+	usecs := int64(b.Time.Nanosecond()/1000 - a.Time.Nanosecond()/1000)
+	if usecs < 0 {
+		secs--
+		usecs += 1000000
+	}
+
+	switch unit {
+	case IntervalYear, IntervalQuarter, IntervalMonth:
+		months := monthsBetween(a, b)
+		switch unit {
+		case IntervalYear:
+			return neg * months / 12
+		case IntervalQuarter:
+			return neg * months / 3
+		default:
+			return neg * months
+		}
+	case IntervalWeek:
+		return neg * secs / (7 * 24 * 3600)
+	case IntervalDay:
+		return neg * secs / (24 * 3600)
+	case IntervalHour:
+		return neg * secs / 3600
+	case IntervalMinute:
+		return neg * secs / 60
+	case IntervalSecond:
+		return neg * secs
+	case IntervalMicrosecond:
+		return neg * (secs*1000000 + usecs)
+	default:
+		return 0
+	}
+}
+
+// monthsBetween returns the number of whole months from beg to end, where end is not before beg
+func monthsBetween(beg, end DateTime) int64 {
+	years := int64(end.Date.Year() - beg.Date.Year())
+	months := int64(end.Date.Month() - beg.Date.Month())
+	if end.Date.Month() < beg.Date.Month() || (end.Date.Month() == beg.Date.Month() && end.Date.Day() < beg.Date.Day()) {
+		years--
+		months += 12
+	}
+	months += 12 * years
+
+	switch {
+	case end.Date.Day() < beg.Date.Day():
+		months--
+	case end.Date.Day() == beg.Date.Day():
+		begSecs, endSecs := beg.Time.ToSeconds(), end.Time.ToSeconds()
+		if endSecs < begSecs || (endSecs == begSecs && end.Time.Nanosecond() < beg.Time.Nanosecond()) {
+			months--
+		}
+	}
+	return months
+}
@@ -367,3 +367,36 @@ func TestInRange(t *testing.T) {
 		assert.Equal(t, tc.wantInRange, got)
 	}
 }
+
+func TestTimestampDiff(t *testing.T) {
+	testCases := []struct {
+		a, b string
+		unit IntervalType
+		want int64
+	}{
+		{a: "2024-01-31 00:00:00", b: "2024-02-29 00:00:00", unit: IntervalMonth, want: 0},
+		{a: "2024-01-29 00:00:00", b: "2024-02-29 00:00:00", unit: IntervalMonth, want: 1},
+		{a: "2024-01-29 10:00:00", b: "2024-02-29 09:59:59.999999", unit: IntervalMonth, want: 0},
+		{a: "2024-02-29 09:59:59.999999", b: "2024-01-29 10:00:00", unit: IntervalMonth, want: 0},
+		{a: "2024-03-15 00:00:00", b: "2023-03-15 00:00:00", unit: IntervalMonth, want: -12},
+		{a: "2020-06-15 00:00:00", b: "2024-06-14 23:59:59", unit: IntervalYear, want: 3},
+		{a: "2020-06-15 00:00:00", b: "2024-06-15 00:00:00", unit: IntervalYear, want: 4},
+		{a: "2020-06-15 00:00:00", b: "2021-02-01 00:00:00", unit: IntervalQuarter, want: 2},
+		{a: "2024-01-01 00:00:00", b: "2024-01-14 23:00:00", unit: IntervalWeek, want: 1},
+		{a: "2024-01-01 12:00:00", b: "2024-01-03 11:59:59", unit: IntervalDay, want: 1},
+		{a: "2024-01-03 11:59:59", b: "2024-01-01 12:00:00", unit: IntervalDay, want: -1},
+		{a: "2024-01-01 00:00:00", b: "2024-01-01 02:30:00", unit: IntervalHour, want: 2},
+		{a: "2024-01-01 00:00:00", b: "2024-01-01 02:30:00", unit: IntervalMinute, want: 150},
+		{a: "2024-01-01 00:00:00.5", b: "2024-01-01 00:00:02.25", unit: IntervalSecond, want: 1},
+		{a: "2024-01-01 00:00:00.5", b: "2024-01-01 00:00:02.25", unit: IntervalMicrosecond, want: 1750000},
+		{a: "2024-01-01 00:00:02.25", b: "2024-01-01 00:00:00.5", unit: IntervalMicrosecond, want: -1750000},
+	}
+
+	for _, tc := range testCases {
+		a, _, ok := ParseDateTime(tc.a, -1)
+		assert.True(t, ok)
+		b, _, ok := ParseDateTime(tc.b, -1)
+		assert.True(t, ok)
+		assert.Equal(t, tc.want, TimestampDiff(a, b, tc.unit), "%s %s %s", tc.unit.ToString(), tc.a, tc.b)
+	}
+}
@@ -0,0 +1,378 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package datetime
+
+import (
+	"strings"
+	"time"
+)
+
+const maxDayNumber = 3652424
+
+var longMonthNames = []string{
+	"January", "February", "March", "April", "May", "June",
+	"July", "August", "September", "October", "November", "December",
+}
+
+// the day names used by STR_TO_DATE start on Monday
+var longDayNamesMonday = []string{
+	"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday",
+}
+
+var shortDayNamesMonday = []string{
+	"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun",
+}
+
+// StrToDateParts returns which parts of a temporal value are parsed by a STR_TO_DATE format:
+// the date, the time and the fractional seconds. It decides the type of the values returned
+// by STR_TO_DATE, and follows the rules of MySQL, where a format that only has days
+// (%d, %e or %D) produces a DATE and a format with fractional seconds always has a time part.
+func StrToDateParts(format string) (date, time, frac bool) {
+	const timeParts = "HISThiklrs"
+	const dateParts = "MVUXYWabcjmvuxyw"
+
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i+1 == len(format) {
+			continue
+		}
+		i++
+		switch c := format[i]; {
+		case c == 'f':
+			frac, time = true, true
+		case !time && strings.IndexByte(timeParts, c) >= 0:
+			time = true
+		case !date && strings.IndexByte(dateParts, c) >= 0:
+			date = true
+		}
+	}
+	return
+}
+
+// StrToDate parses value with a format in the same way as MySQL's STR_TO_DATE. The parts of the
+// value that are not in the format are zero, so the parsed date can be a zero date, or have zero
+// parts, but it is always a valid date otherwise. Like MySQL, the text in the value that follows
+// the last part of the format is ignored.
+func StrToDate(value, format string) (DateTime, bool) {
+	p := newStrToDateParser()
+	if _, ok := p.parse(value, format); !ok {
+		return DateTime{}, false
+	}
+	return p.finish()
+}
+
+// TimeWithDays returns the time of dt with its days added to the hours, which is how
+// STR_TO_DATE returns the days parsed with a TIME format.
+func (dt DateTime) TimeWithDays() Time {
+	t := dt.Time
+	t.hour += uint16(dt.Date.day) * 24
+	return t
+}
+
+type strToDateParser struct {
+	year, month, day     int
+	hour, minute, second int
+	nsec                 int
+
+	yearday  int
+	weekday  int
+	week     int
+	weekYear int
+	daypart  int
+	usaTime  bool
+
+	// sundayFirst is set by %U and %V, strictWeek by %V and %v,
+	// and sundayWeekYear by %X
+	sundayFirst    bool
+	strictWeek     bool
+	sundayWeekYear bool
+}
+
+func newStrToDateParser() *strToDateParser {
+	return &strToDateParser{week: -1, weekYear: -1}
+}
+
+// number parses an unsigned number of at most n digits, like MySQL does with my_strtoll10
+func (p *strToDateParser) number(s string, n int) (int, string, bool) {
+	if len(s) < n {
+		n = len(s)
+	}
+	i := 0
+	if i < n && s[i] == '+' {
+		i++
+	}
+	start := i
+	var x int
+	for ; i < n && isDigit(s, i); i++ {
+		x = x*10 + int(s[i]-'0')
+	}
+	if i == start {
+		return 0, s, false
+	}
+	return x, s[i:], true
+}
+
+// word parses the name of a month or a day, which can be abbreviated to any unique prefix,
+// and returns its 1-based position in names
+func (p *strToDateParser) word(s string, names []string) (int, string, bool) {
+	n := 0
+	for n < len(s) && isAlpha(s[n]) {
+		n++
+	}
+	if n == 0 {
+		return 0, s, false
+	}
+	w := s[:n]
+	found, pos := 0, 0
+	for i, name := range names {
+		if len(w) > len(name) || !strings.EqualFold(w, name[:len(w)]) {
+			continue
+		}
+		if len(w) == len(name) {
+			return i + 1, s[n:], true
+		}
+		found++
+		pos = i + 1
+	}
+	if found != 1 {
+		return 0, s, false
+	}
+	return pos, s[n:], true
+}
+
+// parse parses the value s with format, and returns the part of s that follows the format
+func (p *strToDateParser) parse(s, format string) (string, bool) {
+	var ok bool
+	for i := 0; i < len(format) && len(s) > 0; i++ {
+		for len(s) > 0 && isSpace(s[0]) {
+			s = s[1:]
+		}
+		if len(s) == 0 {
+			break
+		}
+
+		if format[i] != '%' || i+1 == len(format) {
+			if !isSpace(format[i]) {
+				if s[0] != format[i] {
+					return s, false
+				}
+				s = s[1:]
+			}
+			continue
+		}
+
+		i++
+		switch format[i] {
+		case 'Y':
+			before := len(s)
+			if p.year, s, ok = p.number(s, 4); ok && before-len(s) <= 2 {
+				p.year = year2000(p.year)
+			}
+		case 'y':
+			if p.year, s, ok = p.number(s, 2); ok {
+				p.year = year2000(p.year)
+			}
+		case 'm', 'c':
+			p.month, s, ok = p.number(s, 2)
+		case 'M':
+			p.month, s, ok = p.word(s, longMonthNames)
+		case 'b':
+			p.month, s, ok = p.word(s, shortMonthNames)
+		case 'd', 'e':
+			p.day, s, ok = p.number(s, 2)
+		case 'D':
+			// the day is followed by a suffix like 'st' or 'th'
+			if p.day, s, ok = p.number(s, 2); ok {
+				s = s[min(len(s), 2):]
+			}
+		case 'h', 'I', 'l':
+			p.usaTime = true
+			p.hour, s, ok = p.number(s, 2)
+		case 'k', 'H':
+			p.hour, s, ok = p.number(s, 2)
+		case 'i':
+			p.minute, s, ok = p.number(s, 2)
+		case 's', 'S':
+			p.second, s, ok = p.number(s, 2)
+		case 'f':
+			before := len(s)
+			var usec int
+			if usec, s, ok = p.number(s, 6); ok {
+				for l := before - len(s); l < 6; l++ {
+					usec *= 10
+				}
+				p.nsec = usec * 1000
+			}
+		case 'p':
+			if len(s) < 2 || !p.usaTime {
+				return s, false
+			}
+			switch {
+			case strings.EqualFold(s[:2], "PM"):
+				p.daypart = 12
+			case !strings.EqualFold(s[:2], "AM"):
+				return s, false
+			}
+			s, ok = s[2:], true
+		case 'W':
+			p.weekday, s, ok = p.word(s, longDayNamesMonday)
+		case 'a':
+			p.weekday, s, ok = p.word(s, shortDayNamesMonday)
+		case 'w':
+			// %w counts from Sunday as 0, but weekdays are stored from Monday as 1 to Sunday as 7
+			if p.weekday, s, ok = p.number(s, 1); ok {
+				if p.weekday >= 7 {
+					return s, false
+				}
+				if p.weekday == 0 {
+					p.weekday = 7
+				}
+			}
+		case 'j':
+			p.yearday, s, ok = p.number(s, 3)
+		case 'U', 'u', 'V', 'v':
+			p.sundayFirst = format[i] == 'U' || format[i] == 'V'
+			p.strictWeek = format[i] == 'V' || format[i] == 'v'
+			p.week, s, ok = p.number(s, 2)
+			ok = ok && p.week <= 53 && (!p.strictWeek || p.week > 0)
+		case 'X', 'x':
+			p.sundayWeekYear = format[i] == 'X'
+			p.weekYear, s, ok = p.number(s, 4)
+		case 'r':
+			s, ok = p.parseTime(s, "%I:%i:%S %p")
+		case 'T':
+			s, ok = p.parseTime(s, "%H:%i:%S")
+		case '.':
+			for len(s) > 0 && isSeparator(s[0]) {
+				s = s[1:]
+			}
+			ok = true
+		case '@':
+			for len(s) > 0 && isAlpha(s[0]) {
+				s = s[1:]
+			}
+			ok = true
+		case '#':
+			for len(s) > 0 && isDigit(s, 0) {
+				s = s[1:]
+			}
+			ok = true
+		default:
+			return s, false
+		}
+		if !ok {
+			return s, false
+		}
+	}
+
+	if p.usaTime {
+		if p.hour > 12 || p.hour < 1 {
+			return s, false
+		}
+		p.hour = p.hour%12 + p.daypart
+	}
+	return s, true
+}
+
+// parseTime parses the time of the %r and %T formats, and returns the rest of the value
+func (p *strToDateParser) parseTime(s, format string) (string, bool) {
+	sub := newStrToDateParser()
+	rest, ok := sub.parse(s, format)
+	if !ok {
+		return s, false
+	}
+	p.hour, p.minute, p.second = sub.hour, sub.minute, sub.second
+	return rest, true
+}
+
+func (p *strToDateParser) finish() (DateTime, bool) {
+	if p.yearday > 0 {
+		days := MysqlDayNumber(p.year, 1, 1) + p.yearday - 1
+		if days <= 0 || days > maxDayNumber {
+			return DateTime{}, false
+		}
+		p.setDayNumber(days)
+	}
+
+	if p.week >= 0 && p.weekday > 0 {
+		// %V and %v must be used with %X and %x, and %U and %u with %Y
+		if p.strictWeek && (p.weekYear < 0 || p.sundayWeekYear != p.sundayFirst) || !p.strictWeek && p.weekYear >= 0 {
+			return DateTime{}, false
+		}
+
+		year := p.year
+		if p.strictWeek {
+			year = p.weekYear
+		}
+		days := MysqlDayNumber(year, 1, 1)
+		first := weekdayOf(days, p.sundayFirst)
+		if p.sundayFirst {
+			if first != 0 {
+				days += 7
+			}
+			days += (p.week-1)*7 + p.weekday%7 - first
+		} else {
+			if first > 3 {
+				days += 7
+			}
+			days += (p.week-1)*7 + p.weekday - 1 - first
+		}
+		if days <= 0 || days > maxDayNumber {
+			return DateTime{}, false
+		}
+		p.setDayNumber(days)
+	}
+
+	if p.month > 12 || p.day > 31 || p.hour > 23 || p.minute > 59 || p.second > 59 {
+		return DateTime{}, false
+	}
+	if p.month > 0 && p.day > 0 && p.day > daysIn(time.Month(p.month), p.year) {
+		return DateTime{}, false
+	}
+
+	return DateTime{
+		Date: Date{year: uint16(p.year), month: uint8(p.month), day: uint8(p.day)},
+		Time: Time{hour: uint16(p.hour), minute: uint8(p.minute), second: uint8(p.second), nanosecond: uint32(p.nsec)},
+	}, true
+}
+
+func (p *strToDateParser) setDayNumber(days int) {
+	y, m, d := mysqlDateFromDayNumber(days)
+	p.year, p.month, p.day = int(y), int(m), int(d)
+}
+
+// weekdayOf returns the day of the week of a day number, counting from Monday or from Sunday as 0
+func weekdayOf(daynr int, sundayFirst bool) int {
+	if sundayFirst {
+		return (daynr + 6) % 7
+	}
+	return (daynr + 5) % 7
+}
+
+// year2000 converts a year with two digits into a year between 1970 and 2069
+func year2000(year int) int {
+	if year < 70 {
+		return year + 2000
+	}
+	if year < 100 {
+		return year + 1900
+	}
+	return year
+}
+
+func isAlpha(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
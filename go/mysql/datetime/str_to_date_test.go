@@ -0,0 +1,104 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package datetime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStrToDate(t *testing.T) {
+	tcases := []struct {
+		value, format string
+		want          string
+	}{
+		{value: "2024-03-15", format: "%Y-%m-%d", want: "2024-03-15 00:00:00"},
+		{value: "15/3/24", format: "%d/%c/%y", want: "2024-03-15 00:00:00"},
+		{value: "15/3/71", format: "%d/%c/%y", want: "1971-03-15 00:00:00"},
+		{value: "  2024 - 03 - 15  ", format: "%Y-%m-%d", want: "2024-03-15 00:00:00"},
+		{value: "March 15th, 2024", format: "%M %D, %Y", want: "2024-03-15 00:00:00"},
+		{value: "mar 15 2024", format: "%b %e %Y", want: "2024-03-15 00:00:00"},
+		{value: "Sept 1 2024", format: "%M %e %Y", want: "2024-09-01 00:00:00"},
+		{value: "Ja 1 2024", format: "%M %e %Y", want: "2024-01-01 00:00:00"},
+		{value: "Ju 1 2024", format: "%M %e %Y", want: ""},
+		{value: "2024-03-15 10:20:30", format: "%Y-%m-%d %H:%i:%s", want: "2024-03-15 10:20:30"},
+		{value: "2024-03-15 10:20:30.25", format: "%Y-%m-%d %H:%i:%s.%f", want: "2024-03-15 10:20:30.250000"},
+		{value: "10:20:30 PM", format: "%r", want: "0000-00-00 22:20:30"},
+		{value: "12:20:30 AM", format: "%r", want: "0000-00-00 00:20:30"},
+		{value: "12:20 am", format: "%h:%i %p", want: "0000-00-00 00:20:00"},
+		{value: "13:20 am", format: "%h:%i %p", want: ""},
+		{value: "10:20 pm", format: "%H:%i %p", want: ""},
+		{value: "10:20:30", format: "%T", want: "0000-00-00 10:20:30"},
+		{value: "2024-03-15 garbage", format: "%Y-%m-%d", want: "2024-03-15 00:00:00"},
+		{value: "2024", format: "%Y-%m-%d", want: "2024-00-00 00:00:00"},
+		{value: "2024-02-30", format: "%Y-%m-%d", want: ""},
+		{value: "2024-13-01", format: "%Y-%m-%d", want: ""},
+		{value: "2024-x-01", format: "%Y-%m-%d", want: ""},
+		{value: "2024-03-15", format: "%Y/%m/%d", want: ""},
+		{value: "2024-03-15", format: "%Y%.%m%.%d", want: "2024-03-15 00:00:00"},
+		{value: "abc2024", format: "%@%Y", want: "2024-00-00 00:00:00"},
+		{value: "2024 060", format: "%Y %j", want: "2024-02-29 00:00:00"},
+		{value: "2024 10 Friday", format: "%Y %U %W", want: "2024-03-15 00:00:00"},
+		{value: "2024 10 Friday", format: "%Y %u %W", want: "2024-03-08 00:00:00"},
+		{value: "2024 10 5", format: "%x %v %w", want: "2024-03-08 00:00:00"},
+		{value: "2024 10 5", format: "%X %v %w", want: ""},
+		{value: "2024 10 Fri", format: "%Y %V %a", want: ""},
+		{value: "abc", format: "abc", want: "0000-00-00 00:00:00"},
+		{value: "x", format: "%Q", want: ""},
+	}
+	for _, tcase := range tcases {
+		t.Run(tcase.value+" "+tcase.format, func(t *testing.T) {
+			dt, ok := StrToDate(tcase.value, tcase.format)
+			if tcase.want == "" {
+				assert.False(t, ok)
+				return
+			}
+			assert.True(t, ok)
+			prec := uint8(0)
+			if dt.Time.Nanosecond() > 0 {
+				prec = 6
+			}
+			assert.Equal(t, tcase.want, string(dt.Format(prec)))
+		})
+	}
+}
+
+func TestStrToDateParts(t *testing.T) {
+	tcases := []struct {
+		format           string
+		date, time, frac bool
+	}{
+		{format: "%Y-%m-%d", date: true},
+		{format: "%d", date: false},
+		{format: "%H:%i:%s", time: true},
+		{format: "%s.%f", time: true, frac: true},
+		{format: "%Y-%m-%d %T", date: true, time: true},
+		{format: "%Y-%m-%d %T.%f", date: true, time: true, frac: true},
+		{format: "abc", date: false},
+	}
+	for _, tcase := range tcases {
+		date, time, frac := StrToDateParts(tcase.format)
+		assert.Equal(t, tcase.date, date, tcase.format)
+		assert.Equal(t, tcase.time, time, tcase.format)
+		assert.Equal(t, tcase.frac, frac, tcase.format)
+	}
+
+	dt, ok := StrToDate("3 10:20", "%d %H:%i")
+	assert.True(t, ok)
+	assert.Equal(t, "82:20:00", string(dt.TimeWithDays().Format(0)))
+}
@@ -0,0 +1,279 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package geometry implements the geometry values of MySQL: their storage format,
+// the Well-Known Binary (WKB) and Well-Known Text (WKT) representations, and the
+// spatial operations used by the ST_ functions in a Cartesian coordinate system.
+package geometry
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Type is the type of a geometry, with the same values used in its WKB representation
+type Type uint32
+
+const (
+	TypePoint              Type = 1
+	TypeLineString         Type = 2
+	TypePolygon            Type = 3
+	TypeMultiPoint         Type = 4
+	TypeMultiLineString    Type = 5
+	TypeMultiPolygon       Type = 6
+	TypeGeometryCollection Type = 7
+)
+
+// String returns the name of the type, as returned by ST_GeometryType
+func (t Type) String() string {
+	switch t {
+	case TypePoint:
+		return "POINT"
+	case TypeLineString:
+		return "LINESTRING"
+	case TypePolygon:
+		return "POLYGON"
+	case TypeMultiPoint:
+		return "MULTIPOINT"
+	case TypeMultiLineString:
+		return "MULTILINESTRING"
+	case TypeMultiPolygon:
+		return "MULTIPOLYGON"
+	case TypeGeometryCollection:
+		return "GEOMCOLLECTION"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// Geometry is one of Point, LineString, Polygon, MultiPoint, MultiLineString,
+// MultiPolygon or GeometryCollection.
+type Geometry interface {
+	Type() Type
+}
+
+type (
+	// Point is a single location
+	Point struct {
+		X, Y float64
+	}
+
+	// LineString is a curve made of the segments between consecutive points
+	LineString []Point
+
+	// Polygon is a surface delimited by its rings: the first ring is the exterior
+	// ring, and the following ones are the holes in it. Every ring is closed.
+	Polygon []LineString
+
+	MultiPoint         []Point
+	MultiLineString    []LineString
+	MultiPolygon       []Polygon
+	GeometryCollection []Geometry
+)
+
+func (Point) Type() Type              { return TypePoint }
+func (LineString) Type() Type         { return TypeLineString }
+func (Polygon) Type() Type            { return TypePolygon }
+func (MultiPoint) Type() Type         { return TypeMultiPoint }
+func (MultiLineString) Type() Type    { return TypeMultiLineString }
+func (MultiPolygon) Type() Type       { return TypeMultiPolygon }
+func (GeometryCollection) Type() Type { return TypeGeometryCollection }
+
+// ErrInvalidData is returned when a geometry value can't be parsed, or does not
+// satisfy the constraints of its type, such as closed polygon rings.
+var ErrInvalidData = errors.New("invalid GIS data")
+
+// sridLength is the length of the SRID that prefixes the WKB of a stored geometry
+const sridLength = 4
+
+// Parse parses a geometry in the storage format of MySQL: a little-endian
+// SRID followed by the WKB representation of the geometry.
+func Parse(raw []byte) (uint32, Geometry, error) {
+	if len(raw) < sridLength {
+		return 0, nil, ErrInvalidData
+	}
+	g, err := ParseWKB(raw[sridLength:])
+	if err != nil {
+		return 0, nil, err
+	}
+	return binary.LittleEndian.Uint32(raw), g, nil
+}
+
+// Append appends the storage format of the geometry to buf
+func Append(buf []byte, srid uint32, g Geometry) []byte {
+	buf = binary.LittleEndian.AppendUint32(buf, srid)
+	return AppendWKB(buf, g)
+}
+
+// SRID returns the SRID of a geometry in the storage format, without parsing the geometry
+func SRID(raw []byte) (uint32, error) {
+	if len(raw) < sridLength {
+		return 0, ErrInvalidData
+	}
+	return binary.LittleEndian.Uint32(raw), nil
+}
+
+// Dimension returns the inherent dimension of the geometry: 0 for points, 1 for curves
+// and 2 for surfaces. Collections have the largest dimension of their geometries,
+// and -1 when they are empty.
+func Dimension(g Geometry) int {
+	switch g := g.(type) {
+	case Point, MultiPoint:
+		return 0
+	case LineString, MultiLineString:
+		return 1
+	case Polygon, MultiPolygon:
+		return 2
+	case GeometryCollection:
+		dim := -1
+		for _, sub := range g {
+			dim = max(dim, Dimension(sub))
+		}
+		return dim
+	}
+	return -1
+}
+
+// IsEmpty returns true when the geometry contains no points. Only geometry
+// collections can be empty.
+func IsEmpty(g Geometry) bool {
+	if gc, ok := g.(GeometryCollection); ok {
+		for _, sub := range gc {
+			if !IsEmpty(sub) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// IsClosed returns true when the first and last points of every curve of the geometry
+// are the same. It returns false when the geometry is not a LineString or MultiLineString.
+func IsClosed(g Geometry) bool {
+	switch g := g.(type) {
+	case LineString:
+		return g.closed()
+	case MultiLineString:
+		for _, ls := range g {
+			if !ls.closed() {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func (ls LineString) closed() bool {
+	return len(ls) > 0 && ls[0] == ls[len(ls)-1]
+}
+
+// Geometries returns the geometries inside a multi geometry or a collection. Other
+// geometries contain only themselves.
+func Geometries(g Geometry) []Geometry {
+	var geoms []Geometry
+	switch g := g.(type) {
+	case MultiPoint:
+		for _, p := range g {
+			geoms = append(geoms, p)
+		}
+	case MultiLineString:
+		for _, ls := range g {
+			geoms = append(geoms, ls)
+		}
+	case MultiPolygon:
+		for _, poly := range g {
+			geoms = append(geoms, poly)
+		}
+	case GeometryCollection:
+		geoms = g
+	default:
+		geoms = []Geometry{g}
+	}
+	return geoms
+}
+
+// Envelope returns the minimum bounding rectangle of the geometry. The rectangle is
+// a Point or a LineString when it is degenerate, and an empty collection is returned
+// for empty geometries.
+func Envelope(g Geometry) Geometry {
+	b, ok := bounds(g)
+	if !ok {
+		return GeometryCollection{}
+	}
+	lo, hi := b.min, b.max
+	switch {
+	case lo == hi:
+		return lo
+	case lo.X == hi.X || lo.Y == hi.Y:
+		return LineString{lo, hi}
+	default:
+		return Polygon{{lo, {hi.X, lo.Y}, hi, {lo.X, hi.Y}, lo}}
+	}
+}
+
+type box struct {
+	min, max Point
+}
+
+func bounds(g Geometry) (box, bool) {
+	var b box
+	found := false
+	forEachPoint(g, func(p Point) {
+		if !found {
+			b = box{p, p}
+			found = true
+			return
+		}
+		b.min.X = min(b.min.X, p.X)
+		b.min.Y = min(b.min.Y, p.Y)
+		b.max.X = max(b.max.X, p.X)
+		b.max.Y = max(b.max.Y, p.Y)
+	})
+	return b, found
+}
+
+func forEachPoint(g Geometry, fn func(Point)) {
+	switch g := g.(type) {
+	case Point:
+		fn(g)
+	case LineString:
+		for _, p := range g {
+			fn(p)
+		}
+	case MultiPoint:
+		for _, p := range g {
+			fn(p)
+		}
+	case Polygon:
+		for _, ring := range g {
+			forEachPoint(ring, fn)
+		}
+	case MultiLineString:
+		for _, ls := range g {
+			forEachPoint(ls, fn)
+		}
+	case MultiPolygon:
+		for _, poly := range g {
+			forEachPoint(poly, fn)
+		}
+	case GeometryCollection:
+		for _, sub := range g {
+			forEachPoint(sub, fn)
+		}
+	}
+}
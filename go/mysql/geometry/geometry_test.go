@@ -0,0 +1,161 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package geometry
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWKT(t *testing.T) {
+	tcases := []struct {
+		wkt  string
+		want string
+		typ  Type
+	}{
+		{wkt: "POINT(1 2)", want: "POINT(1 2)", typ: TypePoint},
+		{wkt: " point ( -1.5  2e3 ) ", want: "POINT(-1.5 2000)", typ: TypePoint},
+		{wkt: "POINT(0.1 1e20)", want: "POINT(0.1 1e20)", typ: TypePoint},
+		{wkt: "LINESTRING(0 0, 1 1, 2 2)", want: "LINESTRING(0 0,1 1,2 2)", typ: TypeLineString},
+		{wkt: "POLYGON((0 0,10 0,10 10,0 10,0 0),(2 2,4 2,4 4,2 2))", want: "POLYGON((0 0,10 0,10 10,0 10,0 0),(2 2,4 2,4 4,2 2))", typ: TypePolygon},
+		{wkt: "MULTIPOINT(1 1, 2 2)", want: "MULTIPOINT((1 1),(2 2))", typ: TypeMultiPoint},
+		{wkt: "MULTIPOINT((1 1), (2 2))", want: "MULTIPOINT((1 1),(2 2))", typ: TypeMultiPoint},
+		{wkt: "MULTILINESTRING((0 0,1 1),(2 2,3 3))", want: "MULTILINESTRING((0 0,1 1),(2 2,3 3))", typ: TypeMultiLineString},
+		{wkt: "MULTIPOLYGON(((0 0,1 0,1 1,0 0)),((2 2,3 2,3 3,2 2)))", want: "MULTIPOLYGON(((0 0,1 0,1 1,0 0)),((2 2,3 2,3 3,2 2)))", typ: TypeMultiPolygon},
+		{wkt: "GEOMETRYCOLLECTION(POINT(1 1),LINESTRING(0 0,1 1))", want: "GEOMETRYCOLLECTION(POINT(1 1),LINESTRING(0 0,1 1))", typ: TypeGeometryCollection},
+		{wkt: "GEOMCOLLECTION(GEOMETRYCOLLECTION(POINT(1 1)))", want: "GEOMETRYCOLLECTION(GEOMETRYCOLLECTION(POINT(1 1)))", typ: TypeGeometryCollection},
+		{wkt: "GEOMETRYCOLLECTION EMPTY", want: "GEOMETRYCOLLECTION EMPTY", typ: TypeGeometryCollection},
+		{wkt: "GEOMETRYCOLLECTION()", want: "GEOMETRYCOLLECTION EMPTY", typ: TypeGeometryCollection},
+	}
+	for _, tcase := range tcases {
+		t.Run(tcase.wkt, func(t *testing.T) {
+			g, err := ParseWKT(tcase.wkt)
+			require.NoError(t, err)
+			assert.Equal(t, tcase.typ, g.Type())
+			assert.Equal(t, tcase.want, string(AppendWKT(nil, g)))
+
+			// the geometry survives a round trip through its storage format
+			srid, parsed, err := Parse(Append(nil, 0, g))
+			require.NoError(t, err)
+			assert.Equal(t, uint32(0), srid)
+			assert.Equal(t, g, parsed)
+		})
+	}
+}
+
+func TestWKTInvalid(t *testing.T) {
+	for _, wkt := range []string{
+		"",
+		"POINT",
+		"POINT()",
+		"POINT(1)",
+		"POINT(1 2 3)",
+		"POINT(1 2) x",
+		"POINT(a b)",
+		"LINESTRING(0 0)",
+		"POLYGON((0 0,1 0,1 1))",
+		"POLYGON((0 0,1 0,1 1,0 1))",
+		"POLYGON()",
+		"MULTIPOINT()",
+		"GEOMETRYCOLLECTION(POINT(1 1),)",
+		"CIRCLE(1 1)",
+	} {
+		t.Run(wkt, func(t *testing.T) {
+			_, err := ParseWKT(wkt)
+			assert.ErrorIs(t, err, ErrInvalidData)
+		})
+	}
+}
+
+func TestWKB(t *testing.T) {
+	// POINT(1 2), little-endian and big-endian
+	little, _ := hex.DecodeString("0101000000000000000000F03F0000000000000040")
+	big, _ := hex.DecodeString("00000000013FF00000000000004000000000000000")
+
+	for _, wkb := range [][]byte{little, big} {
+		g, err := ParseWKB(wkb)
+		require.NoError(t, err)
+		assert.Equal(t, Point{X: 1, Y: 2}, g)
+		assert.Equal(t, little, AppendWKB(nil, g))
+	}
+
+	// the stored format prefixes the WKB with the SRID
+	stored := append([]byte{0xE6, 0x10, 0, 0}, little...)
+	srid, g, err := Parse(stored)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(4326), srid)
+	assert.Equal(t, Point{X: 1, Y: 2}, g)
+	assert.Equal(t, stored, Append(nil, 4326, g))
+
+	srid, err = SRID(stored)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(4326), srid)
+
+	invalid := [][]byte{
+		nil,
+		little[:10],
+		append(little, 0),
+		{0x02, 0x01, 0, 0, 0},
+		// LINESTRING with a single point
+		append([]byte{1, 2, 0, 0, 0, 1, 0, 0, 0}, little[5:]...),
+		// MULTIPOINT with a LINESTRING inside
+		{1, 4, 0, 0, 0, 1, 0, 0, 0, 1, 2, 0, 0, 0, 0, 0, 0, 0},
+		// LINESTRING with more points than the data
+		{1, 2, 0, 0, 0, 0xFF, 0xFF, 0xFF, 0xFF},
+	}
+	for _, wkb := range invalid {
+		_, err := ParseWKB(wkb)
+		assert.ErrorIs(t, err, ErrInvalidData, "%x", wkb)
+	}
+}
+
+func TestProperties(t *testing.T) {
+	parse := func(wkt string) Geometry {
+		g, err := ParseWKT(wkt)
+		require.NoError(t, err)
+		return g
+	}
+
+	assert.Equal(t, 0, Dimension(parse("MULTIPOINT(1 1,2 2)")))
+	assert.Equal(t, 1, Dimension(parse("LINESTRING(0 0,1 1)")))
+	assert.Equal(t, 2, Dimension(parse("GEOMETRYCOLLECTION(POINT(1 1),POLYGON((0 0,1 0,1 1,0 0)))")))
+	assert.Equal(t, -1, Dimension(parse("GEOMETRYCOLLECTION EMPTY")))
+
+	assert.True(t, IsEmpty(parse("GEOMETRYCOLLECTION(GEOMETRYCOLLECTION EMPTY)")))
+	assert.False(t, IsEmpty(parse("POINT(1 1)")))
+
+	assert.True(t, IsClosed(parse("LINESTRING(0 0,1 0,1 1,0 0)")))
+	assert.False(t, IsClosed(parse("MULTILINESTRING((0 0,1 0,1 1,0 0),(0 0,1 1))")))
+
+	assert.Len(t, Geometries(parse("MULTILINESTRING((0 0,1 0),(0 0,1 1))")), 2)
+	assert.Len(t, Geometries(parse("POINT(1 1)")), 1)
+
+	for wkt, want := range map[string]string{
+		"POINT(1 1)":                       "POINT(1 1)",
+		"LINESTRING(0 0,0 5)":              "LINESTRING(0 0,0 5)",
+		"MULTIPOINT(1 4,3 2)":              "POLYGON((1 2,3 2,3 4,1 4,1 2))",
+		"GEOMETRYCOLLECTION EMPTY":         "GEOMETRYCOLLECTION EMPTY",
+		"POLYGON((0 0,4 0,4 3,0 0))":       "POLYGON((0 0,4 0,4 3,0 3,0 0))",
+		"MULTIPOINT((-1 -1),(-1 -1))":      "POINT(-1 -1)",
+		"LINESTRING(0 0,3 0,1 0,2 0,-1 0)": "LINESTRING(-1 0,3 0)",
+	} {
+		assert.Equal(t, want, string(AppendWKT(nil, Envelope(parse(wkt)))), wkt)
+	}
+}
@@ -0,0 +1,528 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package geometry
+
+import (
+	"errors"
+	"math"
+	"slices"
+)
+
+// ErrMixedDimensions is returned by Contains for collections that mix points, curves and surfaces
+var ErrMixedDimensions = errors.New("geometry collections with geometries of different dimensions")
+
+// Length returns the length of a LineString or MultiLineString. It returns false for other geometries.
+func Length(g Geometry) (float64, bool) {
+	switch g := g.(type) {
+	case LineString:
+		return g.length(), true
+	case MultiLineString:
+		var length float64
+		for _, ls := range g {
+			length += ls.length()
+		}
+		return length, true
+	}
+	return 0, false
+}
+
+func (ls LineString) length() float64 {
+	var length float64
+	for i := 1; i < len(ls); i++ {
+		length += distance(ls[i-1], ls[i])
+	}
+	return length
+}
+
+// Area returns the area of a Polygon or MultiPolygon. It returns false for other geometries.
+func Area(g Geometry) (float64, bool) {
+	switch g := g.(type) {
+	case Polygon:
+		return g.area(), true
+	case MultiPolygon:
+		var area float64
+		for _, poly := range g {
+			area += poly.area()
+		}
+		return area, true
+	}
+	return 0, false
+}
+
+func (poly Polygon) area() float64 {
+	area := math.Abs(poly[0].ringArea())
+	for _, hole := range poly[1:] {
+		area -= math.Abs(hole.ringArea())
+	}
+	return area
+}
+
+// ringArea returns the signed area of a closed ring
+func (ls LineString) ringArea() float64 {
+	var sum float64
+	for i := 1; i < len(ls); i++ {
+		sum += (ls[i-1].X - ls[i].X) * (ls[i-1].Y + ls[i].Y)
+	}
+	return sum / 2
+}
+
+// Distance returns the minimum distance between two geometries. It returns false
+// when any of the geometries is empty.
+func Distance(a, b Geometry) (float64, bool) {
+	if IsEmpty(a) || IsEmpty(b) {
+		return 0, false
+	}
+	if Intersects(a, b) {
+		return 0, true
+	}
+
+	// the geometries don't intersect, so the distance between them is the
+	// distance between their points and the boundaries of their surfaces
+	sa, sb := flatten(a), flatten(b)
+	pa, pb := sa.allPoints(), sb.allPoints()
+	ea, eb := sa.segments(), sb.segments()
+
+	dist := math.Inf(1)
+	for _, p := range pa {
+		for _, q := range pb {
+			dist = min(dist, distance(p, q))
+		}
+		for _, s := range eb {
+			dist = min(dist, s.distance(p))
+		}
+	}
+	for _, s := range ea {
+		for _, q := range pb {
+			dist = min(dist, s.distance(q))
+		}
+		for _, t := range eb {
+			dist = min(dist, s.distance(t.a), s.distance(t.b), t.distance(s.a), t.distance(s.b))
+		}
+	}
+	return dist, true
+}
+
+// Intersects returns true when the geometries have at least one point in common
+func Intersects(a, b Geometry) bool {
+	sa, sb := flatten(a), flatten(b)
+	ea, eb := sa.segments(), sb.segments()
+
+	for _, p := range sa.allPoints() {
+		if sb.locate(p) != outside {
+			return true
+		}
+	}
+	for _, q := range sb.allPoints() {
+		if sa.locate(q) != outside {
+			return true
+		}
+	}
+	for _, s := range ea {
+		for _, t := range eb {
+			if s.intersects(t) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Contains returns true when no point of b lies outside of a, and at least one point of the
+// interior of b lies in the interior of a. It returns false when any of the geometries is empty.
+func Contains(a, b Geometry) (bool, error) {
+	sa, sb := flatten(a), flatten(b)
+	if sa.dim < 0 || sb.dim < 0 {
+		return false, nil
+	}
+	if sa.mixed || sb.mixed {
+		return false, ErrMixedDimensions
+	}
+	if sb.dim > sa.dim {
+		return false, nil
+	}
+
+	interior := false
+	covered := func(p Point) bool {
+		switch sa.locate(p) {
+		case outside:
+			return false
+		case inside:
+			interior = true
+		}
+		return true
+	}
+
+	switch sb.dim {
+	case 0:
+		for _, p := range sb.points {
+			if !covered(p) {
+				return false, nil
+			}
+		}
+		return interior, nil
+	case 1:
+		if !sb.coveredBy(sa, covered) {
+			return false, nil
+		}
+		return interior, nil
+	default:
+		if !sb.coveredBy(sa, covered) {
+			return false, nil
+		}
+		// the boundary of b is inside a, but a could have a hole inside b, or b
+		// could be one of the holes of a
+		for _, s := range sa.segments() {
+			for _, p := range s.split(sb.segments()) {
+				if sb.locate(p) == inside {
+					return false, nil
+				}
+			}
+		}
+		for _, poly := range sb.polygons {
+			if sa.locate(poly.interiorPoint()) == outside {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+}
+
+// location is the position of a point relative to a geometry
+type location int
+
+const (
+	outside location = iota
+	boundary
+	inside
+)
+
+// shape contains the points, curves and surfaces of a geometry
+type shape struct {
+	points   []Point
+	lines    []LineString
+	polygons []Polygon
+
+	// dim is the largest dimension in the shape, or -1 when it is empty
+	dim int
+	// mixed is true when the shape has geometries of different dimensions
+	mixed bool
+}
+
+func flatten(g Geometry) *shape {
+	s := &shape{dim: -1}
+	s.add(g)
+	return s
+}
+
+func (s *shape) add(g Geometry) {
+	var dim int
+	switch g := g.(type) {
+	case Point:
+		s.points = append(s.points, g)
+		dim = 0
+	case MultiPoint:
+		s.points = append(s.points, g...)
+		dim = 0
+	case LineString:
+		s.lines = append(s.lines, g)
+		dim = 1
+	case MultiLineString:
+		s.lines = append(s.lines, g...)
+		dim = 1
+	case Polygon:
+		s.polygons = append(s.polygons, g)
+		dim = 2
+	case MultiPolygon:
+		s.polygons = append(s.polygons, g...)
+		dim = 2
+	case GeometryCollection:
+		for _, sub := range g {
+			s.add(sub)
+		}
+		return
+	}
+	if s.dim >= 0 && s.dim != dim {
+		s.mixed = true
+	}
+	s.dim = max(s.dim, dim)
+}
+
+// allPoints returns the points of the shape, and one point of every curve and surface
+func (s *shape) allPoints() []Point {
+	points := slices.Clone(s.points)
+	for _, ls := range s.lines {
+		points = append(points, ls[0])
+	}
+	for _, poly := range s.polygons {
+		points = append(points, poly[0][0])
+	}
+	return points
+}
+
+// segments returns the segments of the curves and of the boundaries of the surfaces
+func (s *shape) segments() []segment {
+	var segments []segment
+	add := func(ls LineString) {
+		for i := 1; i < len(ls); i++ {
+			segments = append(segments, segment{ls[i-1], ls[i]})
+		}
+	}
+	for _, ls := range s.lines {
+		add(ls)
+	}
+	for _, poly := range s.polygons {
+		for _, ring := range poly {
+			add(ring)
+		}
+	}
+	return segments
+}
+
+// locate returns the position of p relative to the shape
+func (s *shape) locate(p Point) location {
+	loc := outside
+	for _, q := range s.points {
+		if p == q {
+			return inside
+		}
+	}
+	if len(s.lines) > 0 {
+		if l := s.locateOnLines(p); l != outside {
+			loc = l
+		}
+	}
+	for _, poly := range s.polygons {
+		switch poly.locate(p) {
+		case inside:
+			return inside
+		case boundary:
+			loc = boundary
+		}
+	}
+	return loc
+}
+
+// locateOnLines returns the position of p relative to the curves of the shape. The boundary
+// of the curves are the endpoints that belong to an odd number of curves that are not closed.
+func (s *shape) locateOnLines(p Point) location {
+	on := false
+	endpoints := 0
+	for _, ls := range s.lines {
+		if !ls.closed() {
+			if ls[0] == p {
+				endpoints++
+			}
+			if ls[len(ls)-1] == p {
+				endpoints++
+			}
+		}
+		for i := 1; i < len(ls) && !on; i++ {
+			on = segment{ls[i-1], ls[i]}.contains(p)
+		}
+	}
+	switch {
+	case !on:
+		return outside
+	case endpoints%2 == 1:
+		return boundary
+	default:
+		return inside
+	}
+}
+
+// coveredBy returns true when all the curves or surface boundaries of the shape are covered,
+// by checking their points and the middle of their segments split by the segments of other.
+func (s *shape) coveredBy(other *shape, covered func(Point) bool) bool {
+	edges := other.segments()
+	for _, seg := range s.segments() {
+		if !covered(seg.a) || !covered(seg.b) {
+			return false
+		}
+		for _, p := range seg.split(edges) {
+			if !covered(p) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// locate returns the position of p relative to the polygon
+func (poly Polygon) locate(p Point) location {
+	switch poly[0].locateInRing(p) {
+	case outside:
+		return outside
+	case boundary:
+		return boundary
+	}
+	for _, hole := range poly[1:] {
+		switch hole.locateInRing(p) {
+		case inside:
+			return outside
+		case boundary:
+			return boundary
+		}
+	}
+	return inside
+}
+
+// locateInRing returns the position of p relative to the area enclosed by a ring
+func (ls LineString) locateInRing(p Point) location {
+	in := false
+	for i := 1; i < len(ls); i++ {
+		a, b := ls[i-1], ls[i]
+		if (segment{a, b}).contains(p) {
+			return boundary
+		}
+		if (a.Y > p.Y) != (b.Y > p.Y) {
+			x := a.X + (p.Y-a.Y)*(b.X-a.X)/(b.Y-a.Y)
+			if p.X < x {
+				in = !in
+			}
+		}
+	}
+	if in {
+		return inside
+	}
+	return outside
+}
+
+// interiorPoint returns a point in the interior of the polygon. The point is in the middle
+// of the first interval inside the polygon, along a line that does not cross any vertex.
+func (poly Polygon) interiorPoint() Point {
+	var ys []float64
+	for _, ring := range poly {
+		for _, p := range ring {
+			ys = append(ys, p.Y)
+		}
+	}
+	slices.Sort(ys)
+	ys = slices.Compact(ys)
+	y := ys[0]
+	if len(ys) > 1 {
+		y = (ys[0] + ys[1]) / 2
+	}
+
+	var xs []float64
+	for _, ring := range poly {
+		for i := 1; i < len(ring); i++ {
+			a, b := ring[i-1], ring[i]
+			if (a.Y > y) != (b.Y > y) {
+				xs = append(xs, a.X+(y-a.Y)*(b.X-a.X)/(b.Y-a.Y))
+			}
+		}
+	}
+	if len(xs) < 2 {
+		return poly[0][0]
+	}
+	slices.Sort(xs)
+	return Point{X: (xs[0] + xs[1]) / 2, Y: y}
+}
+
+type segment struct {
+	a, b Point
+}
+
+func distance(p, q Point) float64 {
+	dx, dy := p.X-q.X, p.Y-q.Y
+	return math.Sqrt(dx*dx + dy*dy)
+}
+
+func orientation(a, b, c Point) float64 {
+	return (b.X-a.X)*(c.Y-a.Y) - (b.Y-a.Y)*(c.X-a.X)
+}
+
+// distance returns the distance from p to the closest point of the segment
+func (s segment) distance(p Point) float64 {
+	vx, vy := s.b.X-s.a.X, s.b.Y-s.a.Y
+	wx, wy := p.X-s.a.X, p.Y-s.a.Y
+	c1 := wx*vx + wy*vy
+	if c1 <= 0 {
+		return distance(p, s.a)
+	}
+	c2 := vx*vx + vy*vy
+	if c2 <= c1 {
+		return distance(p, s.b)
+	}
+	t := c1 / c2
+	return distance(p, Point{X: s.a.X + t*vx, Y: s.a.Y + t*vy})
+}
+
+// contains returns true when p lies on the segment
+func (s segment) contains(p Point) bool {
+	return orientation(s.a, s.b, p) == 0 &&
+		p.X >= min(s.a.X, s.b.X) && p.X <= max(s.a.X, s.b.X) &&
+		p.Y >= min(s.a.Y, s.b.Y) && p.Y <= max(s.a.Y, s.b.Y)
+}
+
+func sign(f float64) int {
+	switch {
+	case f > 0:
+		return 1
+	case f < 0:
+		return -1
+	}
+	return 0
+}
+
+// intersects returns true when the segments have at least one point in common
+func (s segment) intersects(t segment) bool {
+	o1 := sign(orientation(s.a, s.b, t.a))
+	o2 := sign(orientation(s.a, s.b, t.b))
+	o3 := sign(orientation(t.a, t.b, s.a))
+	o4 := sign(orientation(t.a, t.b, s.b))
+	if o1 != o2 && o3 != o4 {
+		return true
+	}
+	return s.contains(t.a) || s.contains(t.b) || t.contains(s.a) || t.contains(s.b)
+}
+
+// split returns the middle points of the pieces of the segment, when it is split
+// at the points where the given edges touch or cross it
+func (s segment) split(edges []segment) []Point {
+	ts := []float64{0, 1}
+	param := func(p Point) float64 {
+		if s.b.X != s.a.X {
+			return (p.X - s.a.X) / (s.b.X - s.a.X)
+		}
+		return (p.Y - s.a.Y) / (s.b.Y - s.a.Y)
+	}
+	for _, e := range edges {
+		for _, p := range []Point{e.a, e.b} {
+			if s.contains(p) {
+				ts = append(ts, param(p))
+			}
+		}
+		o1 := sign(orientation(s.a, s.b, e.a))
+		o2 := sign(orientation(s.a, s.b, e.b))
+		o3 := sign(orientation(e.a, e.b, s.a))
+		o4 := sign(orientation(e.a, e.b, s.b))
+		if o1*o2 < 0 && o3*o4 < 0 {
+			d := (s.b.X-s.a.X)*(e.b.Y-e.a.Y) - (s.b.Y-s.a.Y)*(e.b.X-e.a.X)
+			ts = append(ts, ((e.a.X-s.a.X)*(e.b.Y-e.a.Y)-(e.a.Y-s.a.Y)*(e.b.X-e.a.X))/d)
+		}
+	}
+	slices.Sort(ts)
+	ts = slices.Compact(ts)
+
+	var points []Point
+	for i := 1; i < len(ts); i++ {
+		t := (ts[i-1] + ts[i]) / 2
+		points = append(points, Point{X: s.a.X + t*(s.b.X-s.a.X), Y: s.a.Y + t*(s.b.Y-s.a.Y)})
+	}
+	return points
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package geometry
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	square     = "POLYGON((0 0,10 0,10 10,0 10,0 0))"
+	squareHole = "POLYGON((0 0,10 0,10 10,0 10,0 0),(4 4,6 4,6 6,4 6,4 4))"
+)
+
+func mustParse(t *testing.T, wkt string) Geometry {
+	g, err := ParseWKT(wkt)
+	require.NoError(t, err)
+	return g
+}
+
+func TestLengthAndArea(t *testing.T) {
+	length, ok := Length(mustParse(t, "LINESTRING(0 0,3 4,3 10)"))
+	assert.True(t, ok)
+	assert.Equal(t, 11.0, length)
+
+	length, ok = Length(mustParse(t, "MULTILINESTRING((0 0,3 4),(0 0,0 1))"))
+	assert.True(t, ok)
+	assert.Equal(t, 6.0, length)
+
+	_, ok = Length(mustParse(t, "POINT(1 1)"))
+	assert.False(t, ok)
+
+	area, ok := Area(mustParse(t, squareHole))
+	assert.True(t, ok)
+	assert.Equal(t, 96.0, area)
+
+	// the orientation of the rings does not change the area
+	area, ok = Area(mustParse(t, "MULTIPOLYGON(((0 0,0 2,2 2,2 0,0 0)),((5 5,6 5,6 6,5 5)))"))
+	assert.True(t, ok)
+	assert.Equal(t, 4.5, area)
+
+	_, ok = Area(mustParse(t, "LINESTRING(0 0,1 1)"))
+	assert.False(t, ok)
+}
+
+func TestDistance(t *testing.T) {
+	tcases := []struct {
+		a, b string
+		want float64
+	}{
+		{a: "POINT(0 0)", b: "POINT(3 4)", want: 5},
+		{a: "POINT(0 5)", b: "LINESTRING(-1 0,1 0)", want: 5},
+		{a: "POINT(5 5)", b: "LINESTRING(0 0,1 0)", want: math.Sqrt(41)},
+		{a: "LINESTRING(0 0,0 10)", b: "LINESTRING(2 5,5 5)", want: 2},
+		{a: "LINESTRING(0 0,10 10)", b: "LINESTRING(0 10,10 0)", want: 0},
+		{a: "POINT(5 5)", b: square, want: 0},
+		{a: "POINT(5 5)", b: squareHole, want: 1},
+		{a: "POINT(15 5)", b: square, want: 5},
+		{a: "POLYGON((20 0,30 0,30 10,20 0))", b: square, want: 10},
+		{a: "MULTIPOINT(100 100,12 10)", b: "GEOMETRYCOLLECTION(POINT(50 50)," + square + ")", want: 2},
+	}
+	for _, tcase := range tcases {
+		t.Run(tcase.a+" "+tcase.b, func(t *testing.T) {
+			dist, ok := Distance(mustParse(t, tcase.a), mustParse(t, tcase.b))
+			assert.True(t, ok)
+			assert.InDelta(t, tcase.want, dist, 1e-12)
+
+			dist, _ = Distance(mustParse(t, tcase.b), mustParse(t, tcase.a))
+			assert.InDelta(t, tcase.want, dist, 1e-12)
+		})
+	}
+
+	_, ok := Distance(mustParse(t, "POINT(1 1)"), mustParse(t, "GEOMETRYCOLLECTION EMPTY"))
+	assert.False(t, ok)
+}
+
+func TestIntersects(t *testing.T) {
+	tcases := []struct {
+		a, b string
+		want bool
+	}{
+		{a: "POINT(1 1)", b: "POINT(1 1)", want: true},
+		{a: "POINT(1 1)", b: "POINT(1 2)", want: false},
+		{a: "POINT(1 1)", b: "LINESTRING(0 0,2 2)", want: true},
+		{a: "POINT(0 5)", b: square, want: true},
+		{a: "POINT(5 5)", b: squareHole, want: false},
+		{a: "LINESTRING(-5 5,15 5)", b: square, want: true},
+		{a: "LINESTRING(1 1,2 2)", b: square, want: true},
+		{a: "LINESTRING(0 11,10 11)", b: square, want: false},
+		{a: "LINESTRING(0 0,1 0)", b: "LINESTRING(1 0,2 0)", want: true},
+		{a: "POLYGON((4.5 4.5,5.5 4.5,5.5 5.5,4.5 4.5))", b: squareHole, want: false},
+		{a: "POLYGON((1 1,2 1,2 2,1 1))", b: squareHole, want: true},
+		{a: "POLYGON((-5 -5,20 -5,20 20,-5 -5))", b: square, want: true},
+		{a: "GEOMETRYCOLLECTION EMPTY", b: square, want: false},
+	}
+	for _, tcase := range tcases {
+		t.Run(tcase.a+" "+tcase.b, func(t *testing.T) {
+			assert.Equal(t, tcase.want, Intersects(mustParse(t, tcase.a), mustParse(t, tcase.b)))
+			assert.Equal(t, tcase.want, Intersects(mustParse(t, tcase.b), mustParse(t, tcase.a)))
+		})
+	}
+}
+
+func TestContains(t *testing.T) {
+	tcases := []struct {
+		a, b string
+		want bool
+	}{
+		{a: square, b: "POINT(5 5)", want: true},
+		{a: square, b: "POINT(0 5)", want: false},
+		{a: square, b: "MULTIPOINT(0 5,5 5)", want: true},
+		{a: squareHole, b: "POINT(5 5)", want: false},
+		{a: square, b: "LINESTRING(1 1,9 9)", want: true},
+		{a: square, b: "LINESTRING(0 0,10 0)", want: false},
+		{a: square, b: "LINESTRING(0 0,10 10)", want: true},
+		{a: square, b: "LINESTRING(1 1,11 1)", want: false},
+		{a: squareHole, b: "LINESTRING(1 5,9 5)", want: false},
+		{a: squareHole, b: "LINESTRING(1 1,9 1)", want: true},
+		{a: square, b: "POLYGON((1 1,9 1,9 9,1 1))", want: true},
+		{a: square, b: square, want: true},
+		{a: square, b: "POLYGON((1 1,11 1,9 9,1 1))", want: false},
+		{a: squareHole, b: "POLYGON((1 1,9 1,9 9,1 9,1 1))", want: false},
+		{a: squareHole, b: "POLYGON((4 4,6 4,6 6,4 6,4 4))", want: false},
+		{a: squareHole, b: "POLYGON((1 1,3 1,3 3,1 1))", want: true},
+		{a: "LINESTRING(0 0,10 0)", b: "POINT(5 0)", want: true},
+		{a: "LINESTRING(0 0,10 0)", b: "POINT(0 0)", want: false},
+		{a: "LINESTRING(0 0,10 0)", b: "LINESTRING(2 0,8 0)", want: true},
+		{a: "LINESTRING(0 0,5 0,10 0)", b: "LINESTRING(0 0,10 0)", want: true},
+		{a: "LINESTRING(0 0,10 0)", b: "LINESTRING(2 0,12 0)", want: false},
+		{a: "MULTIPOINT(1 1,2 2)", b: "POINT(2 2)", want: true},
+		{a: "POINT(1 1)", b: "LINESTRING(1 1,2 2)", want: false},
+		{a: "MULTIPOLYGON(((0 0,1 0,1 1,0 0)),((5 5,6 5,6 6,5 5)))", b: "MULTIPOINT(0.9 0.1,5.9 5.1)", want: true},
+	}
+	for _, tcase := range tcases {
+		t.Run(tcase.a+" "+tcase.b, func(t *testing.T) {
+			contains, err := Contains(mustParse(t, tcase.a), mustParse(t, tcase.b))
+			require.NoError(t, err)
+			assert.Equal(t, tcase.want, contains)
+		})
+	}
+
+	_, err := Contains(mustParse(t, "GEOMETRYCOLLECTION(POINT(1 1),"+square+")"), mustParse(t, "POINT(1 1)"))
+	assert.ErrorIs(t, err, ErrMixedDimensions)
+}
@@ -0,0 +1,249 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package geometry
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+const (
+	wkbBigEndian    = 0
+	wkbLittleEndian = 1
+
+	// pointLength is the length of the coordinates of a point
+	pointLength = 16
+)
+
+// ParseWKB parses the Well-Known Binary representation of a geometry. Both byte
+// orders are accepted, and the geometry must satisfy the constraints of its type.
+func ParseWKB(wkb []byte) (Geometry, error) {
+	r := wkbReader{buf: wkb}
+	g, err := r.geometry(0)
+	if err != nil {
+		return nil, err
+	}
+	if len(r.buf) > 0 {
+		return nil, ErrInvalidData
+	}
+	return g, nil
+}
+
+type wkbReader struct {
+	buf   []byte
+	order binary.ByteOrder
+}
+
+func (r *wkbReader) header() (Type, error) {
+	if len(r.buf) < 5 {
+		return 0, ErrInvalidData
+	}
+	switch r.buf[0] {
+	case wkbBigEndian:
+		r.order = binary.BigEndian
+	case wkbLittleEndian:
+		r.order = binary.LittleEndian
+	default:
+		return 0, ErrInvalidData
+	}
+	t := Type(r.order.Uint32(r.buf[1:]))
+	r.buf = r.buf[5:]
+	return t, nil
+}
+
+// count reads the number of elements that follow, where every element takes at least size bytes
+func (r *wkbReader) count(size int) (int, error) {
+	if len(r.buf) < 4 {
+		return 0, ErrInvalidData
+	}
+	n := int(r.order.Uint32(r.buf))
+	r.buf = r.buf[4:]
+	if n > len(r.buf)/size {
+		return 0, ErrInvalidData
+	}
+	return n, nil
+}
+
+func (r *wkbReader) point() (Point, error) {
+	if len(r.buf) < pointLength {
+		return Point{}, ErrInvalidData
+	}
+	p := Point{
+		X: math.Float64frombits(r.order.Uint64(r.buf)),
+		Y: math.Float64frombits(r.order.Uint64(r.buf[8:])),
+	}
+	r.buf = r.buf[pointLength:]
+	if math.IsNaN(p.X) || math.IsInf(p.X, 0) || math.IsNaN(p.Y) || math.IsInf(p.Y, 0) {
+		return Point{}, ErrInvalidData
+	}
+	return p, nil
+}
+
+func (r *wkbReader) points(minPoints int) ([]Point, error) {
+	n, err := r.count(pointLength)
+	if err != nil {
+		return nil, err
+	}
+	if n < minPoints {
+		return nil, ErrInvalidData
+	}
+	points := make([]Point, 0, n)
+	for i := 0; i < n; i++ {
+		p, err := r.point()
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	return points, nil
+}
+
+func (r *wkbReader) polygon() (Polygon, error) {
+	n, err := r.count(4)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, ErrInvalidData
+	}
+	poly := make(Polygon, 0, n)
+	for i := 0; i < n; i++ {
+		ring, err := r.points(4)
+		if err != nil {
+			return nil, err
+		}
+		if !LineString(ring).closed() {
+			return nil, ErrInvalidData
+		}
+		poly = append(poly, ring)
+	}
+	return poly, nil
+}
+
+// geometry reads a geometry with its header. The geometries of a multi geometry
+// must have the type given in want, while collections accept any geometry.
+func (r *wkbReader) geometry(want Type) (Geometry, error) {
+	t, err := r.header()
+	if err != nil {
+		return nil, err
+	}
+	if want != 0 && t != want {
+		return nil, ErrInvalidData
+	}
+
+	switch t {
+	case TypePoint:
+		return r.point()
+	case TypeLineString:
+		points, err := r.points(2)
+		return LineString(points), err
+	case TypePolygon:
+		return r.polygon()
+	case TypeMultiPoint, TypeMultiLineString, TypeMultiPolygon, TypeGeometryCollection:
+		n, err := r.count(5)
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 && t != TypeGeometryCollection {
+			return nil, ErrInvalidData
+		}
+		var elem Type
+		switch t {
+		case TypeMultiPoint:
+			elem = TypePoint
+		case TypeMultiLineString:
+			elem = TypeLineString
+		case TypeMultiPolygon:
+			elem = TypePolygon
+		}
+		geoms := make([]Geometry, 0, n)
+		for i := 0; i < n; i++ {
+			g, err := r.geometry(elem)
+			if err != nil {
+				return nil, err
+			}
+			geoms = append(geoms, g)
+		}
+		return collect(t, geoms), nil
+	default:
+		return nil, ErrInvalidData
+	}
+}
+
+// collect builds a multi geometry or a collection of the given type from its geometries
+func collect(t Type, geoms []Geometry) Geometry {
+	switch t {
+	case TypeMultiPoint:
+		mp := make(MultiPoint, 0, len(geoms))
+		for _, g := range geoms {
+			mp = append(mp, g.(Point))
+		}
+		return mp
+	case TypeMultiLineString:
+		mls := make(MultiLineString, 0, len(geoms))
+		for _, g := range geoms {
+			mls = append(mls, g.(LineString))
+		}
+		return mls
+	case TypeMultiPolygon:
+		mp := make(MultiPolygon, 0, len(geoms))
+		for _, g := range geoms {
+			mp = append(mp, g.(Polygon))
+		}
+		return mp
+	default:
+		return GeometryCollection(geoms)
+	}
+}
+
+// AppendWKB appends the little-endian Well-Known Binary representation of the geometry to buf
+func AppendWKB(buf []byte, g Geometry) []byte {
+	buf = append(buf, wkbLittleEndian)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(g.Type()))
+
+	switch g := g.(type) {
+	case Point:
+		buf = appendWKBPoint(buf, g)
+	case LineString:
+		buf = appendWKBPoints(buf, g)
+	case Polygon:
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(g)))
+		for _, ring := range g {
+			buf = appendWKBPoints(buf, ring)
+		}
+	default:
+		geoms := Geometries(g)
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(geoms)))
+		for _, sub := range geoms {
+			buf = AppendWKB(buf, sub)
+		}
+	}
+	return buf
+}
+
+func appendWKBPoint(buf []byte, p Point) []byte {
+	buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(p.X))
+	return binary.LittleEndian.AppendUint64(buf, math.Float64bits(p.Y))
+}
+
+func appendWKBPoints(buf []byte, points []Point) []byte {
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(points)))
+	for _, p := range points {
+		buf = appendWKBPoint(buf, p)
+	}
+	return buf
+}
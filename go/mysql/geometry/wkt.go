@@ -0,0 +1,332 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package geometry
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"vitess.io/vitess/go/mysql/format"
+)
+
+// ParseWKT parses the Well-Known Text representation of a geometry. Like MySQL,
+// the points of a MULTIPOINT can be written with or without parentheses, and
+// empty geometry collections can be written as GEOMETRYCOLLECTION EMPTY.
+func ParseWKT(wkt string) (Geometry, error) {
+	p := wktParser{s: wkt}
+	g, err := p.geometry()
+	if err != nil {
+		return nil, err
+	}
+	p.space()
+	if p.pos < len(p.s) {
+		return nil, ErrInvalidData
+	}
+	return g, nil
+}
+
+type wktParser struct {
+	s   string
+	pos int
+}
+
+func (p *wktParser) space() {
+	for p.pos < len(p.s) {
+		switch p.s[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+// peek returns the next character that is not a space, or 0 at the end of the text
+func (p *wktParser) peek() byte {
+	p.space()
+	if p.pos < len(p.s) {
+		return p.s[p.pos]
+	}
+	return 0
+}
+
+func (p *wktParser) expect(c byte) error {
+	if p.peek() != c {
+		return ErrInvalidData
+	}
+	p.pos++
+	return nil
+}
+
+func (p *wktParser) word() string {
+	p.space()
+	start := p.pos
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+			break
+		}
+		p.pos++
+	}
+	return strings.ToUpper(p.s[start:p.pos])
+}
+
+func (p *wktParser) number() (float64, error) {
+	p.space()
+	start := p.pos
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		if (c < '0' || c > '9') && c != '.' && c != '-' && c != '+' && c != 'e' && c != 'E' {
+			break
+		}
+		p.pos++
+	}
+	f, err := strconv.ParseFloat(p.s[start:p.pos], 64)
+	if err != nil || math.IsInf(f, 0) {
+		return 0, ErrInvalidData
+	}
+	return f, nil
+}
+
+func (p *wktParser) point() (Point, error) {
+	x, err := p.number()
+	if err != nil {
+		return Point{}, err
+	}
+	y, err := p.number()
+	if err != nil {
+		return Point{}, err
+	}
+	return Point{X: x, Y: y}, nil
+}
+
+// list parses a parenthesized list of elements separated by commas
+func (p *wktParser) list(elem func() error) error {
+	if err := p.expect('('); err != nil {
+		return err
+	}
+	for {
+		if err := elem(); err != nil {
+			return err
+		}
+		if p.peek() != ',' {
+			break
+		}
+		p.pos++
+	}
+	return p.expect(')')
+}
+
+func (p *wktParser) points(minPoints int) ([]Point, error) {
+	var points []Point
+	err := p.list(func() error {
+		pt, err := p.point()
+		points = append(points, pt)
+		return err
+	})
+	if err != nil || len(points) < minPoints {
+		return nil, ErrInvalidData
+	}
+	return points, nil
+}
+
+func (p *wktParser) lineString() (LineString, error) {
+	return p.points(2)
+}
+
+func (p *wktParser) polygon() (Polygon, error) {
+	var poly Polygon
+	err := p.list(func() error {
+		ring, err := p.points(4)
+		if err != nil {
+			return err
+		}
+		if !LineString(ring).closed() {
+			return ErrInvalidData
+		}
+		poly = append(poly, ring)
+		return nil
+	})
+	return poly, err
+}
+
+func (p *wktParser) geometry() (Geometry, error) {
+	switch p.word() {
+	case "POINT":
+		if err := p.expect('('); err != nil {
+			return nil, err
+		}
+		pt, err := p.point()
+		if err != nil {
+			return nil, err
+		}
+		return pt, p.expect(')')
+	case "LINESTRING":
+		return p.lineString()
+	case "POLYGON":
+		return p.polygon()
+	case "MULTIPOINT":
+		var mp MultiPoint
+		err := p.list(func() error {
+			parens := p.peek() == '('
+			if parens {
+				p.pos++
+			}
+			pt, err := p.point()
+			if err != nil {
+				return err
+			}
+			mp = append(mp, pt)
+			if parens {
+				return p.expect(')')
+			}
+			return nil
+		})
+		return mp, err
+	case "MULTILINESTRING":
+		var mls MultiLineString
+		err := p.list(func() error {
+			ls, err := p.lineString()
+			mls = append(mls, ls)
+			return err
+		})
+		return mls, err
+	case "MULTIPOLYGON":
+		var mp MultiPolygon
+		err := p.list(func() error {
+			poly, err := p.polygon()
+			mp = append(mp, poly)
+			return err
+		})
+		return mp, err
+	case "GEOMETRYCOLLECTION", "GEOMCOLLECTION":
+		gc := GeometryCollection{}
+		if p.peek() != '(' {
+			if p.word() != "EMPTY" {
+				return nil, ErrInvalidData
+			}
+			return gc, nil
+		}
+		p.pos++
+		if p.peek() == ')' {
+			p.pos++
+			return gc, nil
+		}
+		for {
+			g, err := p.geometry()
+			if err != nil {
+				return nil, err
+			}
+			gc = append(gc, g)
+			if p.peek() != ',' {
+				break
+			}
+			p.pos++
+		}
+		return gc, p.expect(')')
+	default:
+		return nil, ErrInvalidData
+	}
+}
+
+// AppendWKT appends the Well-Known Text representation of the geometry to buf,
+// formatted like MySQL does.
+func AppendWKT(buf []byte, g Geometry) []byte {
+	switch g := g.(type) {
+	case Point:
+		buf = append(buf, "POINT("...)
+		buf = appendWKTPoint(buf, g)
+		return append(buf, ')')
+	case LineString:
+		buf = append(buf, "LINESTRING"...)
+		return appendWKTPoints(buf, g)
+	case Polygon:
+		buf = append(buf, "POLYGON"...)
+		return appendWKTPolygon(buf, g)
+	case MultiPoint:
+		buf = append(buf, "MULTIPOINT("...)
+		for i, pt := range g {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			buf = append(buf, '(')
+			buf = appendWKTPoint(buf, pt)
+			buf = append(buf, ')')
+		}
+		return append(buf, ')')
+	case MultiLineString:
+		buf = append(buf, "MULTILINESTRING("...)
+		for i, ls := range g {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			buf = appendWKTPoints(buf, ls)
+		}
+		return append(buf, ')')
+	case MultiPolygon:
+		buf = append(buf, "MULTIPOLYGON("...)
+		for i, poly := range g {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			buf = appendWKTPolygon(buf, poly)
+		}
+		return append(buf, ')')
+	case GeometryCollection:
+		if len(g) == 0 {
+			return append(buf, "GEOMETRYCOLLECTION EMPTY"...)
+		}
+		buf = append(buf, "GEOMETRYCOLLECTION("...)
+		for i, sub := range g {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			buf = AppendWKT(buf, sub)
+		}
+		return append(buf, ')')
+	}
+	return buf
+}
+
+func appendWKTPoint(buf []byte, p Point) []byte {
+	buf = append(buf, format.FormatFloat(p.X)...)
+	buf = append(buf, ' ')
+	return append(buf, format.FormatFloat(p.Y)...)
+}
+
+func appendWKTPoints(buf []byte, points []Point) []byte {
+	buf = append(buf, '(')
+	for i, pt := range points {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = appendWKTPoint(buf, pt)
+	}
+	return append(buf, ')')
+}
+
+func appendWKTPolygon(buf []byte, poly Polygon) []byte {
+	buf = append(buf, '(')
+	for i, ring := range poly {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = appendWKTPoints(buf, ring)
+	}
+	return append(buf, ')')
+}
@@ -217,43 +217,85 @@ func (jp *Path) Match(doc *Value, wrap bool, match func(value *Value)) {
 	m.value(jp, doc)
 }
 
-func (jp *Path) transform(v *Value, t func(pp *Path, vv *Value)) {
-	if v == nil {
-		return
+// transform applies f to the value at the path starting at jp inside v, and returns the value that
+// replaces v. f is called with a nil value if the path does not exist in v but could be created, and
+// returning nil from f removes the value.
+func (jp *Path) transform(v *Value, f func(vv *Value) *Value) *Value {
+	if jp == nil {
+		return f(v)
 	}
-	if jp.next == nil {
-		t(jp, v)
-		return
+	if v == nil {
+		return nil
 	}
 	switch jp.kind {
 	case jpDocumentRoot:
-		jp.next.transform(v, t)
+		return jp.next.transform(v, f)
 	case jpMember:
-		if obj, ok := v.Object(); ok {
-			jp.next.transform(obj.Get(jp.name), t)
+		obj, ok := v.Object()
+		if !ok {
+			return v
 		}
+		prev := obj.Get(jp.name)
+		next := jp.next.transform(prev, f)
+		switch {
+		case next == nil && prev != nil:
+			obj.Del(jp.name)
+		case next != nil && next != prev:
+			obj.Set(jp.name, next, Set)
+		}
+		return v
 	case jpArrayLocation:
-		if ary, ok := v.Array(); ok {
-			from, to := jp.arrayOffsets(ary)
-			if from != to {
-				panic("range in transformation path expression")
-			}
-			if from >= 0 && from < len(ary) {
-				jp.next.transform(ary[from], t)
-			}
-		} else if jp.offset0 == 0 || jp.offset0 == -1 {
+		ary, ok := v.Array()
+		if !ok {
 			/*
 				If the path is evaluated against a value that is not an array,
 				the result of the evaluation is the same as if the value had been
 				wrapped in a single-element array:
 			*/
-			jp.next.transform(v, t)
+			switch jp.arrayOffset(1) {
+			case 0:
+				if next := jp.next.transform(v, f); next != nil {
+					return next
+				}
+			case 1:
+				if jp.next == nil {
+					if next := f(nil); next != nil {
+						return NewArray([]*Value{v, next})
+					}
+				}
+			}
+			return v
 		}
-	case jpMemberAny, jpArrayLocationAny, jpAny:
+		from := jp.arrayOffset(len(ary))
+		switch {
+		case from < 0:
+		case from < len(ary):
+			if next := jp.next.transform(ary[from], f); next != nil {
+				v.a[from] = next
+			} else {
+				v.DelArrayItem(from)
+			}
+		case jp.next == nil:
+			if next := f(nil); next != nil {
+				v.a = append(v.a, next)
+			}
+		}
+		return v
+	default:
 		panic("wildcard in transformation path expression")
 	}
 }
 
+// arrayOffset returns the position in an array of the given length that jp points to. If it's
+// past the end of the array, it returns the length of the array.
+func (jp *Path) arrayOffset(length int) int {
+	from := int(jp.offset0)
+	if from < 0 {
+		from = length + from
+	}
+	return min(from, length)
+}
+
 type Transformation int
 
 const (
@@ -261,40 +303,62 @@ const (
 	Insert
 	Replace
 	Remove
+	ArrayAppend
 )
 
-func ApplyTransform(t Transformation, doc *Value, paths []*Path, values []*Value) error {
+// ErrInvalidPathForTransform is returned when a path of a transformation contains wildcards or an array range.
+var ErrInvalidPathForTransform = vterrors.Errorf(vtrpc.Code_INVALID_ARGUMENT, "In this situation, path expressions may not contain the * and ** tokens or an array range.")
+
+// ApplyTransform applies the transformation to the values at the given paths of doc, one path at a time.
+// The containers in doc are changed in place; the resulting document is returned, since the transformation
+// can replace the whole document.
+func ApplyTransform(t Transformation, doc *Value, paths []*Path, values []*Value) (*Value, error) {
 	if t != Remove && len(paths) != len(values) {
 		panic("missing Values for transformation")
 	}
 	for i, p := range paths {
-		transform := func(pp *Path, vv *Value) {
-			switch pp.kind {
-			case jpArrayLocation:
-				if ary, ok := vv.Array(); ok {
-					from, to := pp.arrayOffsets(ary)
-					if from != to {
-						return
-					}
-					if t == Remove {
-						vv.DelArrayItem(from)
-					} else {
-						vv.SetArrayItem(from, values[i], t)
-					}
+		if p.ContainsWildcards() {
+			return nil, ErrInvalidPathForTransform
+		}
+		var f func(vv *Value) *Value
+		switch t {
+		case Set:
+			f = func(*Value) *Value { return values[i] }
+		case Insert:
+			f = func(vv *Value) *Value {
+				if vv == nil {
+					return values[i]
 				}
-			case jpMember:
-				if obj, ok := vv.Object(); ok {
-					if t == Remove {
-						obj.Del(pp.name)
-					} else {
-						obj.Set(pp.name, values[i], t)
-					}
+				return vv
+			}
+		case Replace:
+			f = func(vv *Value) *Value {
+				if vv == nil {
+					return nil
+				}
+				return values[i]
+			}
+		case Remove:
+			if p.next == nil {
+				return nil, vterrors.Errorf(vtrpc.Code_INVALID_ARGUMENT, "The path expression '$' is not allowed in this context.")
+			}
+			f = func(*Value) *Value { return nil }
+		case ArrayAppend:
+			f = func(vv *Value) *Value {
+				switch {
+				case vv == nil:
+					return nil
+				case vv.t == TypeArray:
+					vv.a = append(vv.a, values[i])
+					return vv
+				default:
+					return NewArray([]*Value{vv, values[i]})
 				}
 			}
 		}
-		p.transform(doc, transform)
+		doc = p.transform(doc, f)
 	}
-	return nil
+	return doc, nil
 }
 
 func MatchPath(rawJSON, rawPath []byte, match func(value *Value)) error {
@@ -171,6 +171,34 @@ func TestTransformations(t *testing.T) {
 			Paths:    []string{`$[2]`, `$[1].b[1]`, `$[1].b[1]`},
 			Expected: `["a", {"b": [true]}]`,
 		},
+		{
+			T:        Set,
+			Document: `{"a": 1, "b": [2]}`,
+			Paths:    []string{`$.c`, `$.b[5]`, `$.a[1]`, `$.d.e`},
+			Values:   []string{"3", "4", "5", "6"},
+			Expected: `{"a": [1, 5], "b": [2, 4], "c": 3}`,
+		},
+		{
+			T:        Replace,
+			Document: `{"a": 1, "b": [2]}`,
+			Paths:    []string{`$.a[0]`, `$.c`, `$.b[last]`},
+			Values:   []string{"3", "4", "5"},
+			Expected: `{"a": 3, "b": [5]}`,
+		},
+		{
+			T:        Set,
+			Document: `{"a": 1}`,
+			Paths:    []string{`$`},
+			Values:   []string{"[true]"},
+			Expected: `[true]`,
+		},
+		{
+			T:        ArrayAppend,
+			Document: `{"a": 1, "b": [2], "c": {"d": 3}}`,
+			Paths:    []string{`$.a`, `$.b`, `$.c`, `$.e`},
+			Values:   []string{"4", "5", "6", "7"},
+			Expected: `{"a": [1, 4], "b": [2, 5], "c": [{"d": 3}, 6]}`,
+		},
 	}
 
 	for _, tc := range cases {
@@ -186,7 +214,7 @@ func TestTransformations(t *testing.T) {
 			values = append(values, json(t, v))
 		}
 
-		err := ApplyTransform(tc.T, doc, paths, values)
+		doc, err := ApplyTransform(tc.T, doc, paths, values)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -96,3 +96,78 @@ func (v *Value) DelArrayItem(n int) {
 	}
 	v.a = append(v.a[:n], v.a[n+1:]...)
 }
+
+// Clone returns a deep copy of v, which can be changed without changing v.
+// Scalar values are never changed in place, so they are shared with v.
+func (v *Value) Clone() *Value {
+	switch v.t {
+	case TypeObject:
+		kvs := make([]kv, len(v.o.kvs))
+		for i, kv := range v.o.kvs {
+			kvs[i].k = kv.k
+			kvs[i].v = kv.v.Clone()
+		}
+		return &Value{o: Object{kvs: kvs}, t: TypeObject}
+	case TypeArray:
+		a := make([]*Value, len(v.a))
+		for i, item := range v.a {
+			a[i] = item.Clone()
+		}
+		return &Value{a: a, t: TypeArray}
+	default:
+		return v
+	}
+}
+
+// MergePatch merges patch into target as described in RFC 7396, which is how
+// JSON_MERGE_PATCH works. The objects in target are changed in place.
+func MergePatch(target, patch *Value) *Value {
+	patchObj, ok := patch.Object()
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.Object()
+	if !ok {
+		target = &Value{t: TypeObject}
+		targetObj = &target.o
+	}
+	patchObj.Visit(func(key string, value *Value) {
+		if value.Type() == TypeNull {
+			targetObj.Del(key)
+			return
+		}
+		prev := targetObj.Get(key)
+		if prev == nil {
+			prev = ValueNull
+		}
+		targetObj.Set(key, MergePatch(prev, value), Set)
+	})
+	return target
+}
+
+// MergePreserve merges the two values the way JSON_MERGE_PRESERVE does: two objects are merged
+// into a single object, merging the values of the keys they have in common, and any other values
+// are merged by concatenating them as arrays, wrapping the values that are not arrays.
+// The values in a and b are changed in place.
+func MergePreserve(a, b *Value) *Value {
+	if aObj, ok := a.Object(); ok {
+		if bObj, ok := b.Object(); ok {
+			bObj.Visit(func(key string, value *Value) {
+				if prev := aObj.Get(key); prev != nil {
+					value = MergePreserve(prev, value)
+				}
+				aObj.Set(key, value, Set)
+			})
+			return a
+		}
+	}
+	if a.t != TypeArray {
+		a = NewArray([]*Value{a})
+	}
+	if b.t == TypeArray {
+		a.a = append(a.a, b.a...)
+	} else {
+		a.a = append(a.a, b)
+	}
+	return a
+}
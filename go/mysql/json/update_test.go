@@ -106,3 +106,45 @@ func TestValueDelSet(t *testing.T) {
 		t.Fatalf("unexpected number of items left in the array; got %d; want %d", len(a), 2)
 	}
 }
+
+func TestMerge(t *testing.T) {
+	cases := []struct {
+		a, b     string
+		patch    string
+		preserve string
+	}{
+		{a: `{"a": 1, "b": 2}`, b: `{"a": 3, "c": 4}`, patch: `{"a": 3, "b": 2, "c": 4}`, preserve: `{"a": [1, 3], "b": 2, "c": 4}`},
+		{a: `{"a": {"b": 1}}`, b: `{"a": {"c": null}, "d": null}`, patch: `{"a": {"b": 1}}`, preserve: `{"a": {"b": 1, "c": null}, "d": null}`},
+		{a: `[1, 2]`, b: `[3]`, patch: `[3]`, preserve: `[1, 2, 3]`},
+		{a: `1`, b: `{"a": 1}`, patch: `{"a": 1}`, preserve: `[1, {"a": 1}]`},
+		{a: `{"a": 1}`, b: `true`, patch: `true`, preserve: `[{"a": 1}, true]`},
+	}
+
+	for _, tc := range cases {
+		patch := MergePatch(MustParse(tc.a), MustParse(tc.b))
+		if got := string(patch.MarshalTo(nil)); got != tc.patch {
+			t.Errorf("bad MergePatch(%s, %s)\nwant: %s\ngot:  %s", tc.a, tc.b, tc.patch, got)
+		}
+		preserve := MergePreserve(MustParse(tc.a), MustParse(tc.b))
+		if got := string(preserve.MarshalTo(nil)); got != tc.preserve {
+			t.Errorf("bad MergePreserve(%s, %s)\nwant: %s\ngot:  %s", tc.a, tc.b, tc.preserve, got)
+		}
+	}
+}
+
+func TestClone(t *testing.T) {
+	v := MustParse(`{"a": [1, {"b": true}]}`)
+	clone := v.Clone()
+
+	o, _ := clone.Object()
+	a := o.Get("a")
+	a.SetArrayItem(0, MustParse(`2`), Set)
+	o.Set("c", ValueNull, Set)
+
+	if got := v.String(); got != `{"a": [1, {"b": true}]}` {
+		t.Fatalf("original value was changed: %s", got)
+	}
+	if got := clone.String(); got != `{"a": [2, {"b": true}], "c": null}` {
+		t.Fatalf("unexpected clone: %s", got)
+	}
+}
@@ -0,0 +1,400 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysql
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zlib"
+	"github.com/klauspost/compress/zstd"
+
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+	"vitess.io/vitess/go/vt/vterrors"
+)
+
+// This file contains the implementation of the compressed client/server
+// protocol. See:
+// https://dev.mysql.com/doc/dev/mysql-server/latest/page_protocol_basic_compression.html
+//
+// Once negotiated in the handshake, every packet is wrapped in a compressed
+// packet, with a 7 bytes header:
+// - 3 bytes: length of the compressed payload.
+// - 1 byte: sequence number of the compressed packet.
+// - 3 bytes: length of the payload before compression, or 0 if the payload
+//   was not compressed.
+// The payload of the compressed packets is a stream of regular packets, and
+// a regular packet can be split across multiple compressed packets.
+
+// Protocol compression algorithm names, as used by the
+// protocol_compression_algorithms MySQL variable.
+const (
+	CompressionAlgorithmZlib         = "zlib"
+	CompressionAlgorithmZstd         = "zstd"
+	CompressionAlgorithmUncompressed = "uncompressed"
+)
+
+const (
+	// compressedHeaderSize is the size of the header of a compressed packet.
+	compressedHeaderSize = 7
+
+	// minCompressLength is the size under which payloads are sent
+	// uncompressed, as compression wouldn't gain anything. It is
+	// MIN_COMPRESS_LENGTH in MySQL.
+	minCompressLength = 50
+
+	// DefaultZstdCompressionLevel is the zstd compression level used
+	// when none is specified, same as MySQL.
+	DefaultZstdCompressionLevel = 3
+
+	// maxRetainedCompressionBuffer is the largest buffer a compressed
+	// reader or writer keeps around between packets. Bigger buffers
+	// are allocated for a single packet only.
+	maxRetainedCompressionBuffer = 4 * connBufferSize
+)
+
+// ParseCompressionAlgorithms parses a comma separated list of protocol
+// compression algorithms, in order of preference. It returns the matching
+// capability flags, where 0 stands for uncompressed.
+func ParseCompressionAlgorithms(algorithms string) ([]uint32, error) {
+	var result []uint32
+	for _, name := range strings.Split(algorithms, ",") {
+		var flag uint32
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "":
+			continue
+		case CompressionAlgorithmZlib:
+			flag = CapabilityClientCompress
+		case CompressionAlgorithmZstd:
+			flag = CapabilityClientZstdCompressionAlgorithm
+		case CompressionAlgorithmUncompressed:
+			flag = 0
+		default:
+			return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "unknown protocol compression algorithm: %q", name)
+		}
+		result = append(result, flag)
+	}
+	return result, nil
+}
+
+// compressionCapabilities returns the capability flags the server
+// advertises for a list of protocol compression algorithms.
+func compressionCapabilities(algorithms string) (uint32, error) {
+	flags, err := ParseCompressionAlgorithms(algorithms)
+	if err != nil {
+		return 0, err
+	}
+	var capabilities uint32
+	for _, flag := range flags {
+		capabilities |= flag
+	}
+	return capabilities, nil
+}
+
+// negotiateCompression picks the compression capability the client sends
+// to the server, which is the first of the preferred algorithms the server
+// supports. It returns an error if there is no such algorithm and the client
+// didn't allow uncompressed connections.
+func negotiateCompression(algorithms string, serverCapabilities uint32) (uint32, error) {
+	flags, err := ParseCompressionAlgorithms(algorithms)
+	if err != nil {
+		return 0, err
+	}
+	if len(flags) == 0 {
+		return 0, nil
+	}
+	for _, flag := range flags {
+		if flag == 0 || serverCapabilities&flag != 0 {
+			return flag, nil
+		}
+	}
+	return 0, vterrors.Errorf(vtrpcpb.Code_UNAVAILABLE, "server doesn't support any of the protocol compression algorithms: %s", algorithms)
+}
+
+// validZstdCompressionLevel returns whether level is a valid zstd
+// compression level for the protocol.
+func validZstdCompressionLevel(level int) bool {
+	return level >= 1 && level <= 22
+}
+
+// compressor compresses and decompresses the payloads of compressed packets.
+type compressor interface {
+	// compress appends the compressed src to dst.
+	compress(dst, src []byte) ([]byte, error)
+	// decompress decompresses src into dst, which has the size of
+	// the uncompressed payload.
+	decompress(dst, src []byte) error
+}
+
+// zlibCompressor is the compressor for CapabilityClientCompress.
+type zlibCompressor struct{}
+
+var zlibWriters = sync.Pool{New: func() any {
+	return zlib.NewWriter(nil)
+}}
+
+// zlibReaders pools the zlib readers. It has no New function, as
+// a zlib reader can only be created from a valid stream.
+var zlibReaders sync.Pool
+
+func (zlibCompressor) compress(dst, src []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(dst)
+	w := zlibWriters.Get().(*zlib.Writer)
+	defer zlibWriters.Put(w)
+
+	w.Reset(buf)
+	if _, err := w.Write(src); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (zlibCompressor) decompress(dst, src []byte) error {
+	var r io.ReadCloser
+	var err error
+	if pooled := zlibReaders.Get(); pooled != nil {
+		r = pooled.(io.ReadCloser)
+		err = r.(zlib.Resetter).Reset(bytes.NewReader(src), nil)
+	} else {
+		r, err = zlib.NewReader(bytes.NewReader(src))
+	}
+	if err != nil {
+		return err
+	}
+	defer zlibReaders.Put(r)
+
+	if _, err := io.ReadFull(r, dst); err != nil {
+		return err
+	}
+	return r.Close()
+}
+
+// zstdCompressor is the compressor for CapabilityClientZstdCompressionAlgorithm.
+type zstdCompressor struct {
+	encoder *zstd.Encoder
+}
+
+// zstdEncoders caches an encoder per compression level. Encoders
+// can be used concurrently with EncodeAll.
+var zstdEncoders sync.Map
+
+func newZstdCompressor(level int) (zstdCompressor, error) {
+	if encoder, ok := zstdEncoders.Load(level); ok {
+		return zstdCompressor{encoder: encoder.(*zstd.Encoder)}, nil
+	}
+	encoder, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+	if err != nil {
+		return zstdCompressor{}, err
+	}
+	actual, loaded := zstdEncoders.LoadOrStore(level, encoder)
+	if loaded {
+		encoder.Close()
+	}
+	return zstdCompressor{encoder: actual.(*zstd.Encoder)}, nil
+}
+
+func (z zstdCompressor) compress(dst, src []byte) ([]byte, error) {
+	return z.encoder.EncodeAll(src, dst), nil
+}
+
+func (zstdCompressor) decompress(dst, src []byte) error {
+	// The zstdDecoder is shared with the binlog event decompression.
+	out, err := zstdDecoder.DecodeAll(src, dst[:0])
+	if err != nil {
+		return err
+	}
+	if len(out) != len(dst) {
+		return vterrors.Errorf(vtrpcpb.Code_INTERNAL, "decompressed payload has length %v, expected %v", len(out), len(dst))
+	}
+	return nil
+}
+
+// enableCompression switches the connection to the compressed protocol,
+// if compression was negotiated during the handshake. It must be called
+// right after the OK packet that completes the handshake was sent or
+// received.
+func (c *Conn) enableCompression() error {
+	var comp compressor
+	switch {
+	case c.Capabilities&CapabilityClientZstdCompressionAlgorithm != 0:
+		z, err := newZstdCompressor(c.compressionLevel)
+		if err != nil {
+			return err
+		}
+		comp = z
+	case c.Capabilities&CapabilityClientCompress != 0:
+		comp = zlibCompressor{}
+	default:
+		return nil
+	}
+
+	c.compressedReader = &compressedReader{c: c, r: c.getReader(), compressor: comp}
+	c.compressedWriter = &compressedWriter{c: c, w: c.conn, compressor: comp}
+	return nil
+}
+
+// buffer returns a slice of length n, reusing buf if it is big enough.
+func buffer(buf []byte, n int) []byte {
+	if cap(buf) >= n {
+		return buf[:n]
+	}
+	return make([]byte, n)
+}
+
+// retain returns buf if it is small enough to be kept between packets.
+func retain(buf []byte) []byte {
+	if cap(buf) > maxRetainedCompressionBuffer {
+		return nil
+	}
+	return buf
+}
+
+// compressedReader reads compressed packets from the connection, and
+// returns the stream of regular packets they contain.
+type compressedReader struct {
+	c          *Conn
+	r          io.Reader
+	compressor compressor
+
+	header [compressedHeaderSize]byte
+	// compressed is the buffer for the compressed payloads.
+	compressed []byte
+	// uncompressed is the buffer for the decompressed payloads.
+	uncompressed []byte
+	// data is the payload of the current packet, and pos the
+	// position of the next byte to read in it.
+	data []byte
+	pos  int
+}
+
+// Read is part of the io.Reader interface.
+func (cr *compressedReader) Read(p []byte) (int, error) {
+	for cr.pos == len(cr.data) {
+		if err := cr.readPacket(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, cr.data[cr.pos:])
+	cr.pos += n
+	return n, nil
+}
+
+// readPacket reads the next compressed packet from the connection.
+// Errors reading the header are returned unchanged, so that io.EOF
+// is propagated to the caller.
+func (cr *compressedReader) readPacket() error {
+	if _, err := io.ReadFull(cr.r, cr.header[:]); err != nil {
+		return err
+	}
+
+	length := int(uint32(cr.header[0]) | uint32(cr.header[1])<<8 | uint32(cr.header[2])<<16)
+	sequence := cr.header[3]
+	uncompressedLength := int(uint32(cr.header[4]) | uint32(cr.header[5])<<8 | uint32(cr.header[6])<<16)
+
+	if sequence != cr.c.compressedSequence {
+		return vterrors.Errorf(vtrpcpb.Code_INTERNAL, "invalid compressed sequence, expected %v got %v", cr.c.compressedSequence, sequence)
+	}
+	cr.c.compressedSequence++
+	cr.c.sequence = cr.c.compressedSequence
+
+	compressed := buffer(cr.compressed, length)
+	if _, err := io.ReadFull(cr.r, compressed); err != nil {
+		return vterrors.Wrapf(err, "io.ReadFull(compressed packet body of length %v) failed", length)
+	}
+	cr.compressed = retain(compressed)
+
+	if uncompressedLength == 0 {
+		cr.data = compressed
+	} else {
+		uncompressed := buffer(cr.uncompressed, uncompressedLength)
+		if err := cr.compressor.decompress(uncompressed, compressed); err != nil {
+			return vterrors.Wrapf(err, "cannot decompress packet of length %v", length)
+		}
+		cr.uncompressed = retain(uncompressed)
+		cr.data = uncompressed
+	}
+	cr.pos = 0
+	return nil
+}
+
+// compressedWriter wraps the data written to it in compressed packets,
+// and writes them to the connection.
+type compressedWriter struct {
+	c          *Conn
+	w          io.Writer
+	compressor compressor
+
+	// buf is the buffer for the compressed packets.
+	buf []byte
+}
+
+// Write is part of the io.Writer interface.
+func (cw *compressedWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		payload := p[:min(len(p), MaxPacketSize)]
+		if err := cw.writePacket(payload); err != nil {
+			return written, err
+		}
+		written += len(payload)
+		p = p[len(payload):]
+	}
+	return written, nil
+}
+
+// writePacket writes a single compressed packet with the given payload.
+func (cw *compressedWriter) writePacket(payload []byte) error {
+	data := buffer(cw.buf, compressedHeaderSize)
+	uncompressedLength := 0
+	if len(payload) >= minCompressLength {
+		compressed, err := cw.compressor.compress(data, payload)
+		if err != nil {
+			return vterrors.Wrapf(err, "cannot compress packet of length %v", len(payload))
+		}
+		data = compressed
+		uncompressedLength = len(payload)
+	}
+	// Send the payload as is if it is too small, or if compression
+	// didn't make it smaller.
+	if uncompressedLength == 0 || len(data)-compressedHeaderSize >= len(payload) {
+		data = append(data[:compressedHeaderSize], payload...)
+		uncompressedLength = 0
+	}
+	cw.buf = retain(data)
+
+	length := len(data) - compressedHeaderSize
+	data[0] = byte(length)
+	data[1] = byte(length >> 8)
+	data[2] = byte(length >> 16)
+	data[3] = cw.c.compressedSequence
+	data[4] = byte(uncompressedLength)
+	data[5] = byte(uncompressedLength >> 8)
+	data[6] = byte(uncompressedLength >> 16)
+	cw.c.compressedSequence++
+
+	if n, err := cw.w.Write(data); err != nil {
+		return err
+	} else if n != len(data) {
+		return vterrors.Errorf(vtrpcpb.Code_INTERNAL, "Write(compressed packet) returned a short write: %v < %v", n, len(data))
+	}
+	return nil
+}
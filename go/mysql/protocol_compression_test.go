@@ -0,0 +1,204 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysql
+
+import (
+	"bytes"
+	"context"
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCompressionAlgorithms(t *testing.T) {
+	testcases := []struct {
+		algorithms string
+		want       []uint32
+		err        string
+	}{
+		{algorithms: "", want: nil},
+		{algorithms: "zlib", want: []uint32{CapabilityClientCompress}},
+		{algorithms: "zstd, ZLIB", want: []uint32{CapabilityClientZstdCompressionAlgorithm, CapabilityClientCompress}},
+		{algorithms: "zlib,uncompressed", want: []uint32{CapabilityClientCompress, 0}},
+		{algorithms: "zlib,lz4", err: `unknown protocol compression algorithm: "lz4"`},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.algorithms, func(t *testing.T) {
+			got, err := ParseCompressionAlgorithms(tc.algorithms)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestNegotiateCompression(t *testing.T) {
+	testcases := []struct {
+		algorithms string
+		server     uint32
+		want       uint32
+		err        bool
+	}{
+		{algorithms: "", server: CapabilityClientCompress, want: 0},
+		{algorithms: "zstd,zlib", server: CapabilityClientCompress, want: CapabilityClientCompress},
+		{algorithms: "zstd,zlib", server: CapabilityClientCompress | CapabilityClientZstdCompressionAlgorithm, want: CapabilityClientZstdCompressionAlgorithm},
+		{algorithms: "zstd,uncompressed", server: CapabilityClientCompress, want: 0},
+		{algorithms: "zstd", server: CapabilityClientCompress, err: true},
+		{algorithms: "zlib", server: 0, err: true},
+	}
+	for _, tc := range testcases {
+		got, err := negotiateCompression(tc.algorithms, tc.server)
+		if tc.err {
+			assert.Error(t, err, tc.algorithms)
+			continue
+		}
+		require.NoError(t, err, tc.algorithms)
+		assert.Equal(t, tc.want, got, tc.algorithms)
+	}
+}
+
+func TestCompressedPackets(t *testing.T) {
+	for _, capability := range []uint32{CapabilityClientCompress, CapabilityClientZstdCompressionAlgorithm} {
+		listener, sConn, cConn := createSocketPair(t)
+		for _, c := range []*Conn{sConn, cConn} {
+			c.Capabilities |= capability
+			c.compressionLevel = DefaultZstdCompressionLevel
+			require.NoError(t, c.enableCompression())
+		}
+
+		random := make([]byte, 100000)
+		for i := range random {
+			random[i] = byte(rand.IntN(256))
+		}
+		packets := [][]byte{
+			{},
+			{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+			bytes.Repeat([]byte("compressible "), 1000),
+			random,
+			make([]byte, MaxPacketSize-1),
+			make([]byte, MaxPacketSize),
+			make([]byte, MaxPacketSize+1000),
+		}
+		for _, data := range packets {
+			for _, write := range []func(*testing.T, *Conn, []byte){useWritePacket, useWriteEphemeralPacketBuffered, useWriteEphemeralPacketDirect} {
+				verifyPacketCommsSpecific(t, cConn, data, write, sConn.ReadPacket)
+				verifyPacketCommsSpecific(t, cConn, data, write, sConn.readEphemeralPacket)
+				sConn.recycleReadPacket()
+			}
+		}
+
+		listener.Close()
+		sConn.Close()
+		cConn.Close()
+	}
+}
+
+func TestCompressedPacketsInvalidSequence(t *testing.T) {
+	listener, sConn, cConn := createSocketPair(t)
+	defer func() {
+		listener.Close()
+		sConn.Close()
+		cConn.Close()
+	}()
+	for _, c := range []*Conn{sConn, cConn} {
+		c.Capabilities |= CapabilityClientCompress
+		require.NoError(t, c.enableCompression())
+	}
+
+	cConn.compressedSequence = 3
+	go func() {
+		_ = cConn.writePacket(append(make([]byte, packetHeaderSize), "ping"...))
+	}()
+	_, err := sConn.ReadPacket()
+	assert.ErrorContains(t, err, "invalid compressed sequence, expected 0 got 3")
+}
+
+func TestServerCompression(t *testing.T) {
+	th := &testHandler{}
+
+	l, err := NewListenerWithConfig(ListenerConfig{
+		Protocol:              "tcp",
+		Address:               "127.0.0.1:",
+		AuthServer:            NewAuthServerNone(),
+		Handler:               th,
+		ConnReadBufferSize:    connBufferSize,
+		CompressionAlgorithms: "zlib,zstd",
+	})
+	require.NoError(t, err)
+	defer l.Close()
+	go l.Accept()
+
+	host, port := getHostPort(t, l.Addr())
+
+	testcases := []struct {
+		algorithms string
+		want       uint32
+	}{
+		{algorithms: "", want: 0},
+		{algorithms: "zlib", want: CapabilityClientCompress},
+		{algorithms: "zstd,zlib", want: CapabilityClientZstdCompressionAlgorithm},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.algorithms, func(t *testing.T) {
+			params := &ConnParams{
+				Host:                  host,
+				Port:                  port,
+				DbName:                "vttest",
+				CompressionAlgorithms: tc.algorithms,
+				ZstdCompressionLevel:  9,
+			}
+			c, err := Connect(context.Background(), params)
+			require.NoError(t, err)
+			defer c.Close()
+
+			assert.Equal(t, tc.want, c.Capabilities&(CapabilityClientCompress|CapabilityClientZstdCompressionAlgorithm))
+
+			result, err := c.ExecuteFetch("schema echo", 10, true)
+			require.NoError(t, err)
+			assert.Equal(t, "vttest", result.Rows[0][0].ToString())
+
+			// A query and result that spans multiple packets.
+			query := benchmarkQueryPrefix + strings.Repeat("vitess ", MaxPacketSize/5)
+			result, err = c.ExecuteFetch(query, 10, true)
+			require.NoError(t, err)
+			assert.Equal(t, query, result.Rows[0][0].ToString())
+
+			require.NoError(t, c.Ping())
+		})
+	}
+
+	// The server doesn't support zstd, and the client doesn't allow
+	// uncompressed connections.
+	l2, err := NewListener("tcp", "127.0.0.1:", NewAuthServerNone(), th, 0, 0, false, false, 0, 0)
+	require.NoError(t, err)
+	defer l2.Close()
+	require.NoError(t, l2.SetCompressionAlgorithms("zlib"))
+	go l2.Accept()
+
+	host, port = getHostPort(t, l2.Addr())
+	_, err = Connect(context.Background(), &ConnParams{Host: host, Port: port, CompressionAlgorithms: "zstd"})
+	assert.ErrorContains(t, err, "server doesn't support any of the protocol compression algorithms: zstd")
+	c, err := Connect(context.Background(), &ConnParams{Host: host, Port: port, CompressionAlgorithms: "zstd,uncompressed"})
+	require.NoError(t, err)
+	c.Close()
+}
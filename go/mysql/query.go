@@ -55,7 +55,7 @@ const (
 // Returns SQLError(CRServerGone) if it can't.
 func (c *Conn) WriteComQuery(query string) error {
 	// This is a new command, need to reset the sequence.
-	c.sequence = 0
+	c.resetSequence()
 
 	data, pos := c.startEphemeralPacketWithHeader(len(query) + 1)
 	data[pos] = ComQuery
@@ -928,6 +928,54 @@ func (c *Conn) parseComStmtReset(data []byte) (uint32, bool) {
 	return val, ok
 }
 
+func (c *Conn) parseComStmtFetch(data []byte) (uint32, uint32, bool) {
+	stmtID, pos, ok := readUint32(data, 1)
+	if !ok {
+		return 0, 0, false
+	}
+	numRows, _, ok := readUint32(data, pos)
+	return stmtID, numRows, ok
+}
+
+// parseComChangeUser parses a COM_CHANGE_USER packet. It returns the
+// username, auth method, auth response and schema name, and sets the
+// character set of the connection if the client sent one. We advertise
+// CLIENT_SECURE_CONNECTION, so the auth response is length-prefixed.
+// The original data is not pointed at, and can be freed.
+func (c *Conn) parseComChangeUser(data []byte) (string, AuthMethodDescription, []byte, string, error) {
+	username, pos, ok := readNullString(data, 1)
+	if !ok {
+		return "", "", nil, "", vterrors.Errorf(vtrpc.Code_INTERNAL, "parseComChangeUser: can't read username")
+	}
+
+	l, pos, ok := readByte(data, pos)
+	if !ok {
+		return "", "", nil, "", vterrors.Errorf(vtrpc.Code_INTERNAL, "parseComChangeUser: can't read auth-response length")
+	}
+	authResponse, pos, ok := readBytesCopy(data, pos, int(l))
+	if !ok {
+		return "", "", nil, "", vterrors.Errorf(vtrpc.Code_INTERNAL, "parseComChangeUser: can't read auth-response")
+	}
+
+	dbname, pos, ok := readNullString(data, pos)
+	if !ok {
+		return "", "", nil, "", vterrors.Errorf(vtrpc.Code_INTERNAL, "parseComChangeUser: can't read dbname")
+	}
+
+	// The character set and auth method are only sent by clients with
+	// CLIENT_PLUGIN_AUTH, the connection attributes that may follow are
+	// ignored.
+	authMethod := MysqlNativePassword
+	if characterSet, pos, ok := readUint16(data, pos); ok {
+		c.CharacterSet = collations.ID(characterSet)
+		if authMethodStr, _, ok := readNullString(data, pos); ok && authMethodStr != "" {
+			authMethod = AuthMethodDescription(authMethodStr)
+		}
+	}
+
+	return username, authMethod, authResponse, dbname, nil
+}
+
 func (c *Conn) parseComInitDB(data []byte) string {
 	return string(data[1:])
 }
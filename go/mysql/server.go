@@ -133,6 +133,20 @@ type Handler interface {
 
 	ComResetConnection(c *Conn)
 
+	// ComChangeUser is called when a connection successfully changed
+	// its user with COM_CHANGE_USER, before the new user and schema
+	// are set on the connection. Like ComResetConnection, it should
+	// reset the session state.
+	ComChangeUser(c *Conn)
+
+	// ComStmtExecuteCursor is called when a connection executes a
+	// statement with a read-only cursor. It is called from its own
+	// go routine, and the results are sent to the client as it
+	// fetches them, so it should stream them rather than buffer them.
+	// The callback returns io.EOF if the cursor is closed before the
+	// end of the results.
+	ComStmtExecuteCursor(c *Conn, prepare *PrepareData, callback func(*sqltypes.Result) error) error
+
 	Env() *vtenv.Environment
 }
 
@@ -146,6 +160,13 @@ func (UnimplementedHandler) NewConnection(*Conn)      {}
 func (UnimplementedHandler) ConnectionReady(*Conn)    {}
 func (UnimplementedHandler) ConnectionClosed(*Conn)   {}
 func (UnimplementedHandler) ComResetConnection(*Conn) {}
+func (UnimplementedHandler) ComChangeUser(*Conn)      {}
+
+// ComStmtExecuteCursor is not implemented by default, the connection then
+// buffers the results of ComStmtExecute in the cursor.
+func (UnimplementedHandler) ComStmtExecuteCursor(*Conn, *PrepareData, func(*sqltypes.Result) error) error {
+	return errCursorNotImplemented
+}
 
 // Listener is the MySQL server protocol listener.
 type Listener struct {
@@ -217,6 +238,10 @@ type Listener struct {
 	// flushDelay is the delay after which buffered response will be flushed to the client.
 	flushDelay time.Duration
 
+	// compression is the set of protocol compression capabilities
+	// we advertise to clients. See SetCompressionAlgorithms.
+	compression uint32
+
 	// charset is the default server side character set to use for the connection
 	charset collations.ID
 	// parser to use for this listener, configured with the correct version.
@@ -286,6 +311,9 @@ type ListenerConfig struct {
 	ConnBufferPooling   bool
 	ConnKeepAlivePeriod time.Duration
 	FlushDelay          time.Duration
+	// CompressionAlgorithms is the comma separated list of protocol
+	// compression algorithms the server supports. See SetCompressionAlgorithms.
+	CompressionAlgorithms string
 }
 
 // NewListenerWithConfig creates new listener using provided config. There are
@@ -302,6 +330,11 @@ func NewListenerWithConfig(cfg ListenerConfig) (*Listener, error) {
 		l = listener
 	}
 
+	compression, err := compressionCapabilities(cfg.CompressionAlgorithms)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Listener{
 		authServer:          cfg.AuthServer,
 		handler:             cfg.Handler,
@@ -314,11 +347,24 @@ func NewListenerWithConfig(cfg ListenerConfig) (*Listener, error) {
 		connBufferPooling:   cfg.ConnBufferPooling,
 		connKeepAlivePeriod: cfg.ConnKeepAlivePeriod,
 		flushDelay:          cfg.FlushDelay,
+		compression:         compression,
 		truncateErrLen:      cfg.Handler.Env().TruncateErrLen(),
 		charset:             cfg.Handler.Env().CollationEnv().DefaultConnectionCharset(),
 	}, nil
 }
 
+// SetCompressionAlgorithms sets the protocol compression algorithms the
+// server supports, as a comma separated list of zlib, zstd and uncompressed.
+// Clients can always connect uncompressed. It must be called before Accept.
+func (l *Listener) SetCompressionAlgorithms(algorithms string) error {
+	compression, err := compressionCapabilities(algorithms)
+	if err != nil {
+		return err
+	}
+	l.compression = compression
+	return nil
+}
+
 // Addr returns the listener address.
 func (l *Listener) Addr() net.Addr {
 	return l.listener.Addr()
@@ -387,11 +433,14 @@ func (l *Listener) handle(conn net.Conn, connectionID uint32, acceptTime time.Ti
 	l.handler.NewConnection(c)
 	defer l.handler.ConnectionClosed(c)
 
+	// Abort the open cursors before the handler cleans up the connection.
+	defer c.closeCursors()
+
 	// Adjust the count of open connections
 	defer connCount.Add(-1)
 
 	// First build and send the server handshake packet.
-	serverAuthPluginData, err := c.writeHandshakeV10(l.ServerVersion, l.authServer, uint8(l.charset), l.TLSConfig.Load() != nil)
+	serverAuthPluginData, err := c.writeHandshakeV10(l.ServerVersion, l.authServer, uint8(l.charset), l.TLSConfig.Load() != nil, l.compression)
 	if err != nil {
 		if err != io.EOF {
 			log.Errorf("Cannot send HandshakeV10 packet to %s: %v", c, err)
@@ -450,6 +499,77 @@ func (l *Listener) handle(conn net.Conn, connectionID uint32, acceptTime time.Ti
 		defer connCountByTLSVer.Add(versionNoTLS, -1)
 	}
 
+	userData, err := l.authenticate(c, user, clientAuthMethod, clientAuthResponse, serverAuthPluginData)
+	if err != nil {
+		return
+	}
+
+	c.User = user
+	c.UserData = userData
+
+	// The user can change with COM_CHANGE_USER, so only look it up
+	// when the connection closes.
+	if c.User != "" {
+		connCountPerUser.Add(c.User, 1)
+	}
+	defer func() {
+		if c.User != "" {
+			connCountPerUser.Add(c.User, -1)
+		}
+	}()
+
+	// Set initial db name.
+	if c.schemaName != "" {
+		err = l.handler.ComQuery(c, "use "+sqlescape.EscapeID(c.schemaName), func(result *sqltypes.Result) error {
+			return nil
+		})
+		if err != nil {
+			c.writeErrorPacketFromError(err)
+			return
+		}
+	}
+
+	// Negotiation worked, send OK packet.
+	if err := c.writeOKPacket(&PacketOK{statusFlags: c.StatusFlags}); err != nil {
+		log.Errorf("Cannot write OK packet to %s: %v", c, err)
+		return
+	}
+
+	// The handshake is done, switch to the compressed protocol if the
+	// client asked for it.
+	if err := c.enableCompression(); err != nil {
+		log.Errorf("Cannot enable protocol compression for %s: %v", c, err)
+		return
+	}
+
+	// Record how long we took to establish the connection
+	timings.Record(connectTimingKey, acceptTime)
+
+	// Log a warning if it took too long to connect
+	connectTime := time.Since(acceptTime).Nanoseconds()
+	if threshold := l.SlowConnectWarnThreshold.Load(); threshold != 0 && connectTime > threshold {
+		connSlow.Add(1)
+		log.Warningf("Slow connection from %s: %v", c, connectTime)
+	}
+
+	// Tell our handler that we're finished handshake and are ready to
+	// process commands.
+	l.handler.ConnectionReady(c)
+
+	for {
+		kontinue := c.handleNextCommand(l.handler)
+		// before going for next command check if the connection should be closed or not.
+		if !kontinue || c.IsMarkedForClose() {
+			return
+		}
+	}
+}
+
+// authenticate authenticates user on the connection, with the auth method
+// and auth response sent by the client either in its handshake response or in
+// COM_CHANGE_USER. serverAuthPluginData is the salt sent in the handshake.
+// Authentication failures are reported to the client before returning.
+func (l *Listener) authenticate(c *Conn, user string, clientAuthMethod AuthMethodDescription, clientAuthResponse, serverAuthPluginData []byte) (Getter, error) {
 	// See what auth method the AuthServer wants to use for that user.
 	negotiatedAuthMethod, err := negotiateAuthMethod(c, l.authServer, user, clientAuthMethod)
 
@@ -474,87 +594,47 @@ func (l *Listener) handle(conn net.Conn, connectionID uint32, acceptTime time.Ti
 		}
 
 		if negotiatedAuthMethod == nil {
-			c.writeErrorPacket(sqlerror.CRServerHandshakeErr, sqlerror.SSUnknownSQLState, "No authentication methods available for authentication.")
-			return
+			err = sqlerror.NewSQLError(sqlerror.CRServerHandshakeErr, sqlerror.SSUnknownSQLState, "No authentication methods available for authentication.")
+			c.writeErrorPacketFromError(err)
+			return nil, err
 		}
 
 		if !l.AllowClearTextWithoutTLS.Load() && !c.TLSEnabled() && !negotiatedAuthMethod.AllowClearTextWithoutTLS() {
-			c.writeErrorPacket(sqlerror.CRServerHandshakeErr, sqlerror.SSUnknownSQLState, "Cannot use clear text authentication over non-SSL connections.")
-			return
+			err = sqlerror.NewSQLError(sqlerror.CRServerHandshakeErr, sqlerror.SSUnknownSQLState, "Cannot use clear text authentication over non-SSL connections.")
+			c.writeErrorPacketFromError(err)
+			return nil, err
 		}
 
 		serverAuthPluginData, err = negotiatedAuthMethod.AuthPluginData()
 		if err != nil {
 			log.Errorf("Error generating auth switch packet for %s: %v", c, err)
-			return
+			return nil, err
 		}
 
 		if err := c.writeAuthSwitchRequest(string(negotiatedAuthMethod.Name()), serverAuthPluginData); err != nil {
 			log.Errorf("Error writing auth switch packet for %s: %v", c, err)
-			return
+			return nil, err
 		}
 
 		clientAuthResponse, err = c.readEphemeralPacket()
 		if err != nil {
 			log.Errorf("Error reading auth switch response for %s: %v", c, err)
-			return
+			return nil, err
 		}
 		c.recycleReadPacket()
 	}
 
-	userData, err := negotiatedAuthMethod.HandleAuthPluginData(c, user, serverAuthPluginData, clientAuthResponse, conn.RemoteAddr())
+	userData, err := negotiatedAuthMethod.HandleAuthPluginData(c, user, serverAuthPluginData, clientAuthResponse, c.RemoteAddr())
 	if err != nil {
 		log.Warningf("Error authenticating user %s using: %s", user, negotiatedAuthMethod.Name())
 		c.writeErrorPacketFromError(err)
-		return
-	}
-
-	c.User = user
-	c.UserData = userData
-
-	if c.User != "" {
-		connCountPerUser.Add(c.User, 1)
-		defer connCountPerUser.Add(c.User, -1)
-	}
-
-	// Set initial db name.
-	if c.schemaName != "" {
-		err = l.handler.ComQuery(c, "use "+sqlescape.EscapeID(c.schemaName), func(result *sqltypes.Result) error {
-			return nil
-		})
-		if err != nil {
-			c.writeErrorPacketFromError(err)
-			return
-		}
-	}
-
-	// Negotiation worked, send OK packet.
-	if err := c.writeOKPacket(&PacketOK{statusFlags: c.StatusFlags}); err != nil {
-		log.Errorf("Cannot write OK packet to %s: %v", c, err)
-		return
-	}
-
-	// Record how long we took to establish the connection
-	timings.Record(connectTimingKey, acceptTime)
-
-	// Log a warning if it took too long to connect
-	connectTime := time.Since(acceptTime).Nanoseconds()
-	if threshold := l.SlowConnectWarnThreshold.Load(); threshold != 0 && connectTime > threshold {
-		connSlow.Add(1)
-		log.Warningf("Slow connection from %s: %v", c, connectTime)
+		return nil, err
 	}
 
-	// Tell our handler that we're finished handshake and are ready to
-	// process commands.
-	l.handler.ConnectionReady(c)
-
-	for {
-		kontinue := c.handleNextCommand(l.handler)
-		// before going for next command check if the connection should be closed or not.
-		if !kontinue || c.IsMarkedForClose() {
-			return
-		}
-	}
+	// Keep the salt the client used, as it authenticates with it again
+	// in COM_CHANGE_USER.
+	c.salt = serverAuthPluginData
+	return userData, nil
 }
 
 // Close stops the listener, which prevents accept of any new connections. Existing connections won't be closed.
@@ -572,7 +652,7 @@ func (l *Listener) Shutdown() {
 
 // writeHandshakeV10 writes the Initial Handshake Packet, server side.
 // It returns the salt data.
-func (c *Conn) writeHandshakeV10(serverVersion string, authServer AuthServer, charset uint8, enableTLS bool) ([]byte, error) {
+func (c *Conn) writeHandshakeV10(serverVersion string, authServer AuthServer, charset uint8, enableTLS bool, compression uint32) ([]byte, error) {
 	capabilities := CapabilityClientLongPassword |
 		CapabilityClientFoundRows |
 		CapabilityClientLongFlag |
@@ -589,6 +669,7 @@ func (c *Conn) writeHandshakeV10(serverVersion string, authServer AuthServer, ch
 	if enableTLS {
 		capabilities |= CapabilityClientSSL
 	}
+	capabilities |= int(compression)
 
 	// Grab the default auth method. This can only be either
 	// mysql_native_password or caching_sha2_password. Both
@@ -806,9 +887,24 @@ func (l *Listener) parseClientHandshakePacket(c *Conn, firstTime bool, data []by
 
 	// Decode connection attributes send by the client
 	if clientFlags&CapabilityClientConnAttr != 0 {
-		if _, _, err := parseConnAttrs(data, pos); err != nil {
+		var err error
+		if _, pos, err = parseConnAttrs(data, pos); err != nil {
 			log.Warningf("Decode connection attributes send by the client: %v", err)
+			pos = len(data)
+		}
+	}
+
+	// Protocol compression, if the client asked for an algorithm we
+	// support. With zstd, the compression level follows.
+	switch compression := clientFlags & l.compression; {
+	case compression&CapabilityClientZstdCompressionAlgorithm != 0:
+		c.Capabilities |= CapabilityClientZstdCompressionAlgorithm
+		c.compressionLevel = DefaultZstdCompressionLevel
+		if level, _, ok := readByte(data, pos); ok && validZstdCompressionLevel(int(level)) {
+			c.compressionLevel = int(level)
 		}
+	case compression&CapabilityClientCompress != 0:
+		c.Capabilities |= CapabilityClientCompress
 	}
 
 	return username, AuthMethodDescription(authMethod), authResponse, nil
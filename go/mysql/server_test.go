@@ -329,6 +329,59 @@ func TestConnectionWithoutSourceHost(t *testing.T) {
 	c.Close()
 }
 
+func TestChangeUser(t *testing.T) {
+	th := &testHandler{}
+
+	authServer := NewAuthServerStatic("", "", 0)
+	authServer.entries["user1"] = []*AuthServerStaticEntry{{
+		Password: "password1",
+		UserData: "userData1",
+	}}
+	authServer.entries["user2"] = []*AuthServerStaticEntry{{
+		Password: "password2",
+		UserData: "userData2",
+	}}
+	defer authServer.close()
+	l, err := NewListener("tcp", "127.0.0.1:", authServer, th, 0, 0, false, false, 0, 0)
+	require.NoError(t, err, "NewListener failed")
+	defer l.Close()
+	go l.Accept()
+
+	host, port := getHostPort(t, l.Addr())
+
+	c, err := Connect(context.Background(), &ConnParams{
+		Host:   host,
+		Port:   port,
+		Uname:  "user1",
+		Pass:   "password1",
+		DbName: "db1",
+	})
+	require.NoError(t, err)
+	defer c.Close()
+
+	// A wrong password fails, and leaves the connection to the
+	// previous user.
+	err = c.ChangeUser(&ConnParams{Uname: "user2", Pass: "wrong"})
+	var sqlErr *sqlerror.SQLError
+	require.ErrorAs(t, err, &sqlErr)
+	assert.Equal(t, sqlerror.ERAccessDeniedError, sqlErr.Number())
+	result, err := c.ExecuteFetch("userData echo", 1, false)
+	require.NoError(t, err)
+	assert.Equal(t, "user1", result.Rows[0][0].ToString())
+
+	require.NoError(t, c.ChangeUser(&ConnParams{Uname: "user2", Pass: "password2", DbName: "db2"}))
+	assert.Equal(t, "user2", c.User)
+	result, err = c.ExecuteFetch("userData echo", 1, false)
+	require.NoError(t, err)
+	assert.Equal(t, "user2", result.Rows[0][0].ToString())
+	assert.Equal(t, "userData2", result.Rows[0][1].ToString())
+	result, err = c.ExecuteFetch("schema echo", 1, false)
+	require.NoError(t, err)
+	assert.Equal(t, "db2", result.Rows[0][0].ToString())
+
+	assert.EqualValues(t, 1, connCountPerUser.Counts()["user2"])
+}
+
 func TestConnectionWithSourceHost(t *testing.T) {
 	th := &testHandler{}
 
@@ -120,6 +120,7 @@ const (
 	ERSPDoesNotExist                = ErrorCode(1305)
 	ERNoDefaultForField             = ErrorCode(1364)
 	ErSPNotVarArg                   = ErrorCode(1414)
+	ERStmtHasNoOpenCursor           = ErrorCode(1421)
 	ERRowIsReferenced2              = ErrorCode(1451)
 	ErNoReferencedRow2              = ErrorCode(1452)
 	ERDupIndex                      = ErrorCode(1831)
@@ -282,6 +283,7 @@ const (
 	ERQueryTimeout = ErrorCode(3024)
 
 	ErrCantCreateGeometryObject      = ErrorCode(1416)
+	ERGISInvalidData                 = ErrorCode(3037)
 	ErrGISDataWrongEndianess         = ErrorCode(3055)
 	ErrNotImplementedForCartesianSRS = ErrorCode(3704)
 	ErrNotImplementedForProjectedSRS = ErrorCode(3705)
@@ -315,6 +317,9 @@ const (
 	// SSDataOutOfRange is ER_DATA_OUT_OF_RANGE
 	SSDataOutOfRange = "22003"
 
+	// SSInvalidParameterValue is ER_GIS_INVALID_DATA
+	SSInvalidParameterValue = "22023"
+
 	// SSConstraintViolation is constraint violation
 	SSConstraintViolation = "23000"
 
@@ -2154,6 +2154,10 @@ func ContainsAggregation(e SQLNode) bool {
 			// so we don't need to worry about aggregation in the original
 			return false, nil
 		case AggrFunc:
+			if GetOverClause(node.(Expr)) != nil {
+				// aggregations evaluated over a window do not group rows
+				return true, nil
+			}
 			hasAggregates = true
 			return false, io.EOF
 		}
@@ -2163,6 +2167,75 @@ func ContainsAggregation(e SQLNode) bool {
 	return hasAggregates
 }
 
+// GetOverClause returns the OVER clause of a window function or of an aggregation
+// evaluated over a window. It returns nil for all other expressions.
+func GetOverClause(e Expr) *OverClause {
+	switch node := e.(type) {
+	case *ArgumentLessWindowExpr:
+		return node.OverClause
+	case *FirstOrLastValueExpr:
+		return node.OverClause
+	case *NtileExpr:
+		return node.OverClause
+	case *NTHValueExpr:
+		return node.OverClause
+	case *LagLeadExpr:
+		return node.OverClause
+	case *Count:
+		return node.OverClause
+	case *CountStar:
+		return node.OverClause
+	case *Avg:
+		return node.OverClause
+	case *Max:
+		return node.OverClause
+	case *Min:
+		return node.OverClause
+	case *Sum:
+		return node.OverClause
+	case *BitAnd:
+		return node.OverClause
+	case *BitOr:
+		return node.OverClause
+	case *BitXor:
+		return node.OverClause
+	case *Std:
+		return node.OverClause
+	case *StdDev:
+		return node.OverClause
+	case *StdPop:
+		return node.OverClause
+	case *StdSamp:
+		return node.OverClause
+	case *VarPop:
+		return node.OverClause
+	case *VarSamp:
+		return node.OverClause
+	case *Variance:
+		return node.OverClause
+	}
+	return nil
+}
+
+// ContainsWindowFunc returns true if the expression contains a window function
+// or an aggregation evaluated over a window
+func ContainsWindowFunc(e SQLNode) bool {
+	hasWindowFunc := false
+	_ = Walk(func(node SQLNode) (kontinue bool, err error) {
+		switch node := node.(type) {
+		case *Offset, *Subquery:
+			return false, nil
+		case Expr:
+			if GetOverClause(node) != nil {
+				hasWindowFunc = true
+				return false, io.EOF
+			}
+		}
+		return true, nil
+	}, e)
+	return hasWindowFunc
+}
+
 // GetFirstSelect gets the first select statement
 func GetFirstSelect(selStmt SelectStatement) *Select {
 	if selStmt == nil {
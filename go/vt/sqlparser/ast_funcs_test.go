@@ -172,3 +172,28 @@ func TestColumns_Indexes(t *testing.T) {
 		})
 	}
 }
+
+func TestContainsWindowFunc(t *testing.T) {
+	parser := NewTestParser()
+	tests := []struct {
+		expr       string
+		window     bool
+		aggregates bool
+	}{
+		{expr: "row_number() over (partition by a order by b)", window: true},
+		{expr: "sum(x) over (partition by a)", window: true},
+		{expr: "sum(x)", aggregates: true},
+		{expr: "sum(count(x)) over ()", window: true, aggregates: true},
+		{expr: "lag(x, 2) over (order by b) + 1", window: true},
+		{expr: "x + 1"},
+		{expr: "(select row_number() over () from t)"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.expr, func(t *testing.T) {
+			expr, err := parser.ParseExpr(tc.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tc.window, ContainsWindowFunc(expr))
+			assert.Equal(t, tc.aggregates, ContainsAggregation(expr))
+		})
+	}
+}
@@ -391,6 +391,7 @@ func (er *astRewriter) sysVarRewrite(cursor *Cursor, node *Variable) {
 		sysvars.ReadAfterWriteTimeOut.Name,
 		sysvars.SessionEnableSystemSettings.Name,
 		sysvars.SessionTrackGTIDs.Name,
+		sysvars.SessionToken.Name,
 		sysvars.SessionUUID.Name,
 		sysvars.SkipQueryPlanCache.Name,
 		sysvars.Socket.Name,
@@ -398,6 +399,7 @@ func (er *astRewriter) sysVarRewrite(cursor *Cursor, node *Variable) {
 		sysvars.Version.Name,
 		sysvars.VersionComment.Name,
 		sysvars.QueryTimeout.Name,
+		sysvars.MaxReplicaLag.Name,
 		sysvars.Workload.Name:
 		found = true
 	}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 
 	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
@@ -58,6 +59,14 @@ const (
 	// DirectivePriority specifies the priority of a workload. It should be an integer between 0 and MaxPriorityValue,
 	// where 0 is the highest priority, and MaxPriorityValue is the lowest one.
 	DirectivePriority = "PRIORITY"
+	// DirectiveMaxMemory sets the maximum amount of memory, in bytes, the query can use in vtgate. 0 means no limit.
+	DirectiveMaxMemory = "MAX_MEMORY"
+	// DirectiveResultCacheTTL sets how long vtgate caches the result of a read-only query, like "30s".
+	// 0 disables the caching of the result, even when the tables used by the query enable it.
+	DirectiveResultCacheTTL = "RESULT_CACHE_TTL"
+	// DirectiveMaxReplicaLag sets the maximum replication lag of the replicas the query is sent to, like "5s".
+	// 0 does not bound the replication lag, even when the session does.
+	DirectiveMaxReplicaLag = "MAX_REPLICA_LAG"
 
 	// MaxPriorityValue specifies the maximum value allowed for the priority query directive. Valid priority values are
 	// between zero and MaxPriorityValue.
@@ -69,6 +78,12 @@ const (
 
 var ErrInvalidPriority = vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "Invalid priority value specified in query")
 
+var ErrInvalidMaxMemory = vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "Invalid max memory value specified in query")
+
+var ErrInvalidResultCacheTTL = vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "Invalid result cache ttl value specified in query")
+
+var ErrInvalidMaxReplicaLag = vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "Invalid max replica lag value specified in query")
+
 func isNonSpace(r rune) bool {
 	return !unicode.IsSpace(r)
 }
@@ -606,6 +621,72 @@ func GetPriorityFromStatement(statement Statement) (string, error) {
 	return priority, nil
 }
 
+// GetMaxMemoryFromStatement gets the memory limit of the provided Statement, using DirectiveMaxMemory.
+// The returned boolean is false if the directive is not set.
+func GetMaxMemoryFromStatement(statement Statement) (int64, bool, error) {
+	commentedStatement, ok := statement.(Commented)
+	if !ok {
+		return 0, false, nil
+	}
+
+	directives := commentedStatement.GetParsedComments().Directives()
+	maxMemory, ok := directives.GetString(DirectiveMaxMemory, "")
+	if !ok || maxMemory == "" {
+		return 0, false, nil
+	}
+
+	intMaxMemory, err := strconv.ParseInt(maxMemory, 10, 64)
+	if err != nil || intMaxMemory < 0 {
+		return 0, false, ErrInvalidMaxMemory
+	}
+
+	return intMaxMemory, true, nil
+}
+
+// GetResultCacheTTLFromStatement gets the result cache ttl of the provided Statement, using DirectiveResultCacheTTL.
+// The boolean is false when the directive is not set.
+func GetResultCacheTTLFromStatement(statement Statement) (time.Duration, bool, error) {
+	commentedStatement, ok := statement.(Commented)
+	if !ok {
+		return 0, false, nil
+	}
+
+	directives := commentedStatement.GetParsedComments().Directives()
+	resultCacheTTL, ok := directives.GetString(DirectiveResultCacheTTL, "")
+	if !ok || resultCacheTTL == "" {
+		return 0, false, nil
+	}
+
+	ttl, err := time.ParseDuration(resultCacheTTL)
+	if err != nil || ttl < 0 {
+		return 0, false, ErrInvalidResultCacheTTL
+	}
+
+	return ttl, true, nil
+}
+
+// GetMaxReplicaLagFromStatement gets the max replica lag of the provided Statement, using DirectiveMaxReplicaLag.
+// The boolean is false when the directive is not set.
+func GetMaxReplicaLagFromStatement(statement Statement) (time.Duration, bool, error) {
+	commentedStatement, ok := statement.(Commented)
+	if !ok {
+		return 0, false, nil
+	}
+
+	directives := commentedStatement.GetParsedComments().Directives()
+	maxReplicaLag, ok := directives.GetString(DirectiveMaxReplicaLag, "")
+	if !ok || maxReplicaLag == "" {
+		return 0, false, nil
+	}
+
+	lag, err := time.ParseDuration(maxReplicaLag)
+	if err != nil || lag < 0 {
+		return 0, false, ErrInvalidMaxReplicaLag
+	}
+
+	return lag, true, nil
+}
+
 // Consolidator returns the consolidator option.
 func Consolidator(stmt Statement) querypb.ExecuteOptions_Consolidator {
 	var comments *ParsedComments
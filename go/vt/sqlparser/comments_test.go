@@ -19,6 +19,7 @@ package sqlparser
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -545,6 +546,105 @@ func TestGetPriorityFromStatement(t *testing.T) {
 	}
 }
 
+func TestGetMaxMemoryFromStatement(t *testing.T) {
+	testCases := []struct {
+		query         string
+		maxMemory     int64
+		set           bool
+		expectedError error
+	}{
+		{query: "select * from a_table"},
+		{query: "select /*vt+ ANOTHER_DIRECTIVE=324 */ * from another_table"},
+		{query: "select /*vt+ MAX_MEMORY=1048576 */ * from another_table", maxMemory: 1048576, set: true},
+		{query: "select /*vt+ MAX_MEMORY=0 */ * from another_table", maxMemory: 0, set: true},
+		{query: "insert /*vt+ MAX_MEMORY=10 */ into t select * from another_table", maxMemory: 10, set: true},
+		{query: "select /*vt+ MAX_MEMORY=-1 */ * from another_table", expectedError: ErrInvalidMaxMemory},
+		{query: "select /*vt+ MAX_MEMORY=1MB */ * from another_table", expectedError: ErrInvalidMaxMemory},
+	}
+
+	parser := NewTestParser()
+	for _, testCase := range testCases {
+		t.Run(testCase.query, func(t *testing.T) {
+			stmt, err := parser.Parse(testCase.query)
+			require.NoError(t, err)
+			maxMemory, set, err := GetMaxMemoryFromStatement(stmt)
+			if testCase.expectedError != nil {
+				assert.ErrorIs(t, err, testCase.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.maxMemory, maxMemory)
+			assert.Equal(t, testCase.set, set)
+		})
+	}
+}
+
+func TestGetResultCacheTTLFromStatement(t *testing.T) {
+	testCases := []struct {
+		query         string
+		ttl           time.Duration
+		set           bool
+		expectedError error
+	}{
+		{query: "select * from a_table"},
+		{query: "select /*vt+ ANOTHER_DIRECTIVE=324 */ * from another_table"},
+		{query: "select /*vt+ RESULT_CACHE_TTL=30s */ * from another_table", ttl: 30 * time.Second, set: true},
+		{query: "select /*vt+ RESULT_CACHE_TTL=1m30s */ * from another_table", ttl: 90 * time.Second, set: true},
+		{query: "select /*vt+ RESULT_CACHE_TTL=0 */ * from another_table", ttl: 0, set: true},
+		{query: "select /*vt+ RESULT_CACHE_TTL=-1s */ * from another_table", expectedError: ErrInvalidResultCacheTTL},
+		{query: "select /*vt+ RESULT_CACHE_TTL=30 */ * from another_table", expectedError: ErrInvalidResultCacheTTL},
+	}
+
+	parser := NewTestParser()
+	for _, testCase := range testCases {
+		t.Run(testCase.query, func(t *testing.T) {
+			stmt, err := parser.Parse(testCase.query)
+			require.NoError(t, err)
+			ttl, set, err := GetResultCacheTTLFromStatement(stmt)
+			if testCase.expectedError != nil {
+				assert.ErrorIs(t, err, testCase.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.ttl, ttl)
+			assert.Equal(t, testCase.set, set)
+		})
+	}
+}
+
+func TestGetMaxReplicaLagFromStatement(t *testing.T) {
+	testCases := []struct {
+		query         string
+		lag           time.Duration
+		set           bool
+		expectedError error
+	}{
+		{query: "select * from a_table"},
+		{query: "select /*vt+ ANOTHER_DIRECTIVE=324 */ * from another_table"},
+		{query: "select /*vt+ MAX_REPLICA_LAG=5s */ * from another_table", lag: 5 * time.Second, set: true},
+		{query: "select /*vt+ MAX_REPLICA_LAG=500ms */ * from another_table", lag: 500 * time.Millisecond, set: true},
+		{query: "select /*vt+ MAX_REPLICA_LAG=0 */ * from another_table", lag: 0, set: true},
+		{query: "select /*vt+ MAX_REPLICA_LAG=-1s */ * from another_table", expectedError: ErrInvalidMaxReplicaLag},
+		{query: "select /*vt+ MAX_REPLICA_LAG=5 */ * from another_table", expectedError: ErrInvalidMaxReplicaLag},
+	}
+
+	parser := NewTestParser()
+	for _, testCase := range testCases {
+		t.Run(testCase.query, func(t *testing.T) {
+			stmt, err := parser.Parse(testCase.query)
+			require.NoError(t, err)
+			lag, set, err := GetMaxReplicaLagFromStatement(stmt)
+			if testCase.expectedError != nil {
+				assert.ErrorIs(t, err, testCase.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.lag, lag)
+			assert.Equal(t, testCase.set, set)
+		})
+	}
+}
+
 // TestGetMySQLSetVarValue tests the functionality of GetMySQLSetVarValue
 func TestGetMySQLSetVarValue(t *testing.T) {
 	tests := []struct {
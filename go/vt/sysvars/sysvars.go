@@ -73,6 +73,7 @@ var (
 	TxReadOnly                  = SystemVariable{Name: "tx_read_only", IsBoolean: true, Default: off}
 	Workload                    = SystemVariable{Name: "workload", IdentifierAsString: true}
 	QueryTimeout                = SystemVariable{Name: "query_timeout"}
+	MaxReplicaLag               = SystemVariable{Name: "max_replica_lag", IdentifierAsString: true}
 
 	// Online DDL
 	DDLStrategy      = SystemVariable{Name: "ddl_strategy", IdentifierAsString: true}
@@ -86,6 +87,7 @@ var (
 	ReadAfterWriteGTID    = SystemVariable{Name: "read_after_write_gtid"}
 	ReadAfterWriteTimeOut = SystemVariable{Name: "read_after_write_timeout"}
 	SessionTrackGTIDs     = SystemVariable{Name: "session_track_gtids", IdentifierAsString: true}
+	SessionToken          = SystemVariable{Name: "session_token"}
 
 	VitessAware = []SystemVariable{
 		Autocommit,
@@ -105,7 +107,9 @@ var (
 		ReadAfterWriteGTID,
 		ReadAfterWriteTimeOut,
 		SessionTrackGTIDs,
+		SessionToken,
 		QueryTimeout,
+		MaxReplicaLag,
 	}
 
 	ReadOnly = []SystemVariable{
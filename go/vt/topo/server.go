@@ -80,6 +80,7 @@ const (
 	ExternalClustersFile     = "ExternalClusters"
 	ShardRoutingRulesFile    = "ShardRoutingRules"
 	KeyspaceRoutingRulesFile = "KeyspaceRoutingRules"
+	QueryRulesFile           = "QueryRules"
 )
 
 // Path for all object types.
@@ -190,3 +190,38 @@ func (ts *Server) GetKeyspaceRoutingRules(ctx context.Context) (*vschemapb.Keysp
 	}
 	return rules, nil
 }
+
+// SaveQueryRules saves the vtgate query rules into the topo.
+func (ts *Server) SaveQueryRules(ctx context.Context, rules *vschemapb.QueryRules) error {
+	data, err := rules.MarshalVT()
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		// No rules, remove it.
+		if err := ts.globalCell.Delete(ctx, QueryRulesFile, nil); err != nil && !IsErrType(err, NoNode) {
+			return err
+		}
+		return nil
+	}
+	_, err = ts.globalCell.Update(ctx, QueryRulesFile, data, nil)
+	return err
+}
+
+// GetQueryRules fetches the vtgate query rules from the topo. It returns
+// nil if there are none.
+func (ts *Server) GetQueryRules(ctx context.Context) (*vschemapb.QueryRules, error) {
+	rules := &vschemapb.QueryRules{}
+	data, _, err := ts.globalCell.Get(ctx, QueryRulesFile)
+	if err != nil {
+		if IsErrType(err, NoNode) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	err = rules.UnmarshalVT(data)
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "bad query rules data: %q", data)
+	}
+	return rules, nil
+}
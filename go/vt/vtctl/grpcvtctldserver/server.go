@@ -62,6 +62,7 @@ import (
 	"vitess.io/vitess/go/vt/vtctl/workflow"
 	"vitess.io/vitess/go/vt/vtenv"
 	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/queryrules"
 	"vitess.io/vitess/go/vt/vtgate/vindexes"
 	"vitess.io/vitess/go/vt/vttablet/tmclient"
 
@@ -165,6 +166,36 @@ func (s *VtctldServer) AddCellsAlias(ctx context.Context, req *vtctldatapb.AddCe
 	return &vtctldatapb.AddCellsAliasResponse{}, nil
 }
 
+// ApplyQueryRules is part of the vtctlservicepb.VtctldServer interface.
+func (s *VtctldServer) ApplyQueryRules(ctx context.Context, req *vtctldatapb.ApplyQueryRulesRequest) (*vtctldatapb.ApplyQueryRulesResponse, error) {
+	span, ctx := trace.NewSpan(ctx, "VtctldServer.ApplyQueryRules")
+	defer span.Finish()
+
+	span.Annotate("skip_rebuild", req.SkipRebuild)
+	span.Annotate("rebuild_cells", strings.Join(req.RebuildCells, ","))
+
+	if _, err := queryrules.Build(s.ws.SQLParser(), req.QueryRules); err != nil {
+		return nil, err
+	}
+
+	if err := s.ts.SaveQueryRules(ctx, req.QueryRules); err != nil {
+		return nil, err
+	}
+
+	resp := &vtctldatapb.ApplyQueryRulesResponse{}
+
+	if req.SkipRebuild {
+		log.Warningf("Skipping rebuild of SrvVSchema as requested, you will need to run RebuildVSchemaGraph for changes to take effect")
+		return resp, nil
+	}
+
+	if err := s.ts.RebuildSrvVSchema(ctx, req.RebuildCells); err != nil {
+		return nil, vterrors.Wrapf(err, "RebuildSrvVSchema(%v) failed: %v", req.RebuildCells, err)
+	}
+
+	return resp, nil
+}
+
 // ApplyRoutingRules is part of the vtctlservicepb.VtctldServer interface.
 func (s *VtctldServer) ApplyRoutingRules(ctx context.Context, req *vtctldatapb.ApplyRoutingRulesRequest) (resp *vtctldatapb.ApplyRoutingRulesResponse, err error) {
 	span, ctx := trace.NewSpan(ctx, "VtctldServer.ApplyRoutingRules")
@@ -1572,6 +1603,21 @@ func (s *VtctldServer) GetPermissions(ctx context.Context, req *vtctldatapb.GetP
 	}, nil
 }
 
+// GetQueryRules is part of the vtctlservicepb.VtctldServer interface.
+func (s *VtctldServer) GetQueryRules(ctx context.Context, req *vtctldatapb.GetQueryRulesRequest) (*vtctldatapb.GetQueryRulesResponse, error) {
+	span, ctx := trace.NewSpan(ctx, "VtctldServer.GetQueryRules")
+	defer span.Finish()
+
+	rules, err := s.ts.GetQueryRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &vtctldatapb.GetQueryRulesResponse{
+		QueryRules: rules,
+	}, nil
+}
+
 // GetRoutingRules is part of the vtctlservicepb.VtctldServer interface.
 func (s *VtctldServer) GetRoutingRules(ctx context.Context, req *vtctldatapb.GetRoutingRulesRequest) (resp *vtctldatapb.GetRoutingRulesResponse, err error) {
 	span, ctx := trace.NewSpan(ctx, "VtctldServer.GetRoutingRules")
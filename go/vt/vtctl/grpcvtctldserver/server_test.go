@@ -232,6 +232,106 @@ func TestAddCellsAlias(t *testing.T) {
 	}
 }
 
+func TestApplyQueryRules(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rules := &vschemapb.QueryRules{
+		Rules: []*vschemapb.QueryRule{
+			{
+				Name:        "no_scans",
+				Fingerprint: "select * from t1",
+				Action:      vschemapb.QueryRule_reject,
+			},
+		},
+	}
+	tests := []struct {
+		name          string
+		cells         []string
+		req           *vtctldatapb.ApplyQueryRulesRequest
+		expectedRules *vschemapb.QueryRules
+		topoDown      bool
+		shouldErr     bool
+	}{
+		{
+			name:  "success",
+			cells: []string{"zone1"},
+			req: &vtctldatapb.ApplyQueryRulesRequest{
+				QueryRules: rules,
+			},
+			expectedRules: rules,
+		},
+		{
+			name:  "invalid rules",
+			cells: []string{"zone1"},
+			req: &vtctldatapb.ApplyQueryRulesRequest{
+				QueryRules: &vschemapb.QueryRules{
+					Rules: []*vschemapb.QueryRule{
+						{
+							Name:   "no_action",
+							Tables: []string{"t1"},
+						},
+					},
+				},
+			},
+			shouldErr: true,
+		},
+		{
+			name:  "rebuild failed (bad cell)",
+			cells: []string{"zone1"},
+			req: &vtctldatapb.ApplyQueryRulesRequest{
+				QueryRules:   rules,
+				RebuildCells: []string{"zone1", "zone2"},
+			},
+			shouldErr: true,
+		},
+		{
+			// this test case is exactly like the previous, but we don't fail
+			// because we don't rebuild the vschema graph.
+			name:  "rebuild skipped",
+			cells: []string{"zone1"},
+			req: &vtctldatapb.ApplyQueryRulesRequest{
+				QueryRules:   rules,
+				SkipRebuild:  true,
+				RebuildCells: []string{"zone1", "zone2"},
+			},
+			expectedRules: rules,
+		},
+		{
+			name:      "topo down",
+			cells:     []string{"zone1"},
+			req:       &vtctldatapb.ApplyQueryRulesRequest{},
+			topoDown:  true,
+			shouldErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts, factory := memorytopo.NewServerAndFactory(ctx, tt.cells...)
+			if tt.topoDown {
+				factory.SetError(errors.New("topo down for testing"))
+			}
+
+			vtctld := testutil.NewVtctldServerWithTabletManagerClient(t, ts, nil, func(ts *topo.Server) vtctlservicepb.VtctldServer {
+				return NewVtctldServer(vtenv.NewTestEnv(), ts)
+			})
+			_, err := vtctld.ApplyQueryRules(ctx, tt.req)
+			if tt.shouldErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err, "ApplyQueryRules(%+v) failed", tt.req)
+
+			qr, err := ts.GetQueryRules(ctx)
+			require.NoError(t, err, "failed to get query rules from topo to compare")
+			utils.MustMatch(t, tt.expectedRules, qr)
+		})
+	}
+}
+
 func TestApplyRoutingRules(t *testing.T) {
 	t.Parallel()
 
@@ -5723,6 +5823,83 @@ func TestGetPermissions(t *testing.T) {
 	}
 }
 
+func TestGetQueryRules(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		topoDown  bool
+		qrIn      *vschemapb.QueryRules
+		expected  *vschemapb.QueryRules
+		shouldErr bool
+	}{
+		{
+			name: "success",
+			qrIn: &vschemapb.QueryRules{
+				Rules: []*vschemapb.QueryRule{
+					{
+						Name:       "reports",
+						Tables:     []string{"ks.t1"},
+						Action:     vschemapb.QueryRule_reroute,
+						TabletType: topodatapb.TabletType_RDONLY,
+					},
+				},
+			},
+			expected: &vschemapb.QueryRules{
+				Rules: []*vschemapb.QueryRule{
+					{
+						Name:       "reports",
+						Tables:     []string{"ks.t1"},
+						Action:     vschemapb.QueryRule_reroute,
+						TabletType: topodatapb.TabletType_RDONLY,
+					},
+				},
+			},
+		},
+		{
+			name:     "empty query rules",
+			qrIn:     nil,
+			expected: nil,
+		},
+		{
+			name:      "topo error",
+			topoDown:  true,
+			shouldErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			ts, factory := memorytopo.NewServerAndFactory(ctx)
+			if tt.qrIn != nil {
+				err := ts.SaveQueryRules(ctx, tt.qrIn)
+				require.NoError(t, err, "could not save query rules: %+v", tt.qrIn)
+			}
+
+			if tt.topoDown {
+				factory.SetError(errors.New("topo down for testing"))
+			}
+
+			vtctld := testutil.NewVtctldServerWithTabletManagerClient(t, ts, nil, func(ts *topo.Server) vtctlservicepb.VtctldServer {
+				return NewVtctldServer(vtenv.NewTestEnv(), ts)
+			})
+			resp, err := vtctld.GetQueryRules(ctx, &vtctldatapb.GetQueryRulesRequest{})
+			if tt.shouldErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			utils.MustMatch(t, tt.expected, resp.QueryRules)
+		})
+	}
+}
+
 func TestGetRoutingRules(t *testing.T) {
 	t.Parallel()
 
@@ -44,6 +44,11 @@ func (client *localVtctldClient) ApplyKeyspaceRoutingRules(ctx context.Context,
 	return client.s.ApplyKeyspaceRoutingRules(ctx, in)
 }
 
+// ApplyQueryRules is part of the vtctlservicepb.VtctldClient interface.
+func (client *localVtctldClient) ApplyQueryRules(ctx context.Context, in *vtctldatapb.ApplyQueryRulesRequest, opts ...grpc.CallOption) (*vtctldatapb.ApplyQueryRulesResponse, error) {
+	return client.s.ApplyQueryRules(ctx, in)
+}
+
 // ApplyRoutingRules is part of the vtctlservicepb.VtctldClient interface.
 func (client *localVtctldClient) ApplyRoutingRules(ctx context.Context, in *vtctldatapb.ApplyRoutingRulesRequest, opts ...grpc.CallOption) (*vtctldatapb.ApplyRoutingRulesResponse, error) {
 	return client.s.ApplyRoutingRules(ctx, in)
@@ -306,6 +311,11 @@ func (client *localVtctldClient) GetPermissions(ctx context.Context, in *vtctlda
 	return client.s.GetPermissions(ctx, in)
 }
 
+// GetQueryRules is part of the vtctlservicepb.VtctldClient interface.
+func (client *localVtctldClient) GetQueryRules(ctx context.Context, in *vtctldatapb.GetQueryRulesRequest, opts ...grpc.CallOption) (*vtctldatapb.GetQueryRulesResponse, error) {
+	return client.s.GetQueryRules(ctx, in)
+}
+
 // GetRoutingRules is part of the vtctlservicepb.VtctldClient interface.
 func (client *localVtctldClient) GetRoutingRules(ctx context.Context, in *vtctldatapb.GetRoutingRulesRequest, opts ...grpc.CallOption) (*vtctldatapb.GetRoutingRulesResponse, error) {
 	return client.s.GetRoutingRules(ctx, in)
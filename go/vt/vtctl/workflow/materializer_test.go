@@ -750,7 +750,13 @@ func TestCreateLookupVindexFull(t *testing.T) {
 		Vindex:      specs,
 	}
 
+	// VDiff can only verify the backfill of a workflow that keeps running.
+	req.AutoExternalize = true
 	_, err := env.ws.LookupVindexCreate(ctx, req)
+	require.ErrorContains(t, err, "auto_externalize requires continue_after_copy_with_owner")
+	req.AutoExternalize = false
+
+	_, err = env.ws.LookupVindexCreate(ctx, req)
 	require.NoError(t, err)
 
 	wantvschema := &vschemapb.Keyspace{
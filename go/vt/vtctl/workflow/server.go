@@ -30,6 +30,7 @@ import (
 	"text/template"
 	"time"
 
+	"github.com/google/uuid"
 	"golang.org/x/exp/maps"
 	"golang.org/x/sync/errgroup"
 	"golang.org/x/sync/semaphore"
@@ -1124,6 +1125,19 @@ func (s *Server) LookupVindexCreate(ctx context.Context, req *vtctldatapb.Lookup
 	span.Annotate("continue_after_copy_with_owner", req.ContinueAfterCopyWithOwner)
 	span.Annotate("cells", req.Cells)
 	span.Annotate("tablet_types", req.TabletTypes)
+	span.Annotate("auto_externalize", req.AutoExternalize)
+
+	if req.AutoExternalize && !req.ContinueAfterCopyWithOwner {
+		// VDiff can only be run on running workflows.
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "auto_externalize requires continue_after_copy_with_owner")
+	}
+	waitUpdateInterval, set, err := protoutil.DurationFromProto(req.WaitUpdateInterval)
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "unable to parse WaitUpdateInterval into a valid duration")
+	}
+	if !set || waitUpdateInterval <= 0 {
+		waitUpdateInterval = defaultDuration
+	}
 
 	ms, sourceVSchema, targetVSchema, err := s.prepareCreateLookup(ctx, req.Workflow, req.Keyspace, req.Vindex, req.ContinueAfterCopyWithOwner)
 	if err != nil {
@@ -1145,9 +1159,220 @@ func (s *Server) LookupVindexCreate(ctx context.Context, req *vtctldatapb.Lookup
 		return nil, err
 	}
 
+	if req.AutoExternalize {
+		if err := s.setAutoExternalizeTags(ctx, ms.TargetKeyspace, req.Workflow, autoExternalizePendingTag); err != nil {
+			return nil, err
+		}
+		s.autoExternalizeLookupVindex(req, ms.TargetKeyspace, waitUpdateInterval)
+	}
+
 	return &vtctldatapb.LookupVindexCreateResponse{}, nil
 }
 
+// The auto-externalize of a lookup vindex records its progress in the tags of the
+// streams of the workflow, so that it shows up in LookupVindex show. The tags are
+// stored in the _vt.vreplication table and are left behind if vtctld restarts
+// before the lookup vindex is externalized.
+const (
+	autoExternalizePendingTag = "auto_externalize:pending"
+	autoExternalizeFailedTag  = "auto_externalize:failed"
+	autoExternalizeVDiffTag   = "auto_externalize:vdiff:"
+)
+
+// autoExternalizeLookupVindex waits in the background for the workflow to
+// backfill the lookup vindex, and then runs a VDiff of the workflow. The lookup
+// vindex is only externalized if the VDiff finds no differences, otherwise it
+// stays write_only and the streams of the workflow are tagged as failed.
+func (s *Server) autoExternalizeLookupVindex(req *vtctldatapb.LookupVindexCreateRequest, tableKeyspace string, waitUpdateInterval time.Duration) {
+	go func() {
+		// The backfill can take much longer than the request that created the
+		// workflow, so this is not bound to its context.
+		ctx := context.Background()
+		vdiffUUID, err := s.verifyLookupVindex(ctx, req, tableKeyspace, waitUpdateInterval)
+		if err == nil {
+			var resp *vtctldatapb.LookupVindexExternalizeResponse
+			resp, err = s.LookupVindexExternalize(ctx, &vtctldatapb.LookupVindexExternalizeRequest{
+				Keyspace:      req.Keyspace,
+				Name:          req.Workflow,
+				TableKeyspace: tableKeyspace,
+			})
+			if err == nil {
+				log.Infof("LookupVindex %s verified by VDiff %s and externalized, workflow deleted: %t", req.Workflow, vdiffUUID, resp.WorkflowDeleted)
+				if !resp.WorkflowDeleted {
+					err = s.setAutoExternalizeTags(ctx, tableKeyspace, req.Workflow)
+				}
+				if err != nil {
+					log.Errorf("LookupVindex %s externalized but its workflow could not be untagged: %v", req.Workflow, err)
+				}
+				return
+			}
+		}
+		log.Errorf("LookupVindex %s not externalized: %v", req.Workflow, err)
+		tags := []string{autoExternalizeFailedTag}
+		if vdiffUUID != "" {
+			tags = append(tags, autoExternalizeVDiffTag+vdiffUUID)
+		}
+		if err := s.setAutoExternalizeTags(ctx, tableKeyspace, req.Workflow, tags...); err != nil {
+			log.Errorf("LookupVindex %s could not be tagged as failed: %v", req.Workflow, err)
+		}
+	}()
+}
+
+// setAutoExternalizeTags replaces the tags of the streams of the lookup vindex
+// workflow on all the target shards.
+func (s *Server) setAutoExternalizeTags(ctx context.Context, tableKeyspace, workflow string, tags ...string) error {
+	return s.forAllLookupTargets(ctx, tableKeyspace, func(targetPrimary *topo.TabletInfo) error {
+		query := fmt.Sprintf("update _vt.vreplication set tags=%s where db_name=%s and workflow=%s",
+			encodeString(strings.Join(tags, ",")), encodeString(targetPrimary.DbName()), encodeString(workflow))
+		_, err := s.tmc.VReplicationExec(ctx, targetPrimary.Tablet, query)
+		return err
+	})
+}
+
+// forAllLookupTargets calls f with the primary of every serving shard of the
+// keyspace of the lookup table.
+func (s *Server) forAllLookupTargets(ctx context.Context, tableKeyspace string, f func(*topo.TabletInfo) error) error {
+	targetShards, err := s.ts.GetServingShards(ctx, tableKeyspace)
+	if err != nil {
+		return err
+	}
+	for _, targetShard := range targetShards {
+		targetPrimary, err := s.ts.GetTablet(ctx, targetShard.PrimaryAlias)
+		if err != nil {
+			return err
+		}
+		if err := f(targetPrimary); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyLookupVindex waits for the workflow to backfill the lookup vindex, and
+// then for a VDiff of the workflow to complete. It returns the UUID of the VDiff,
+// along with an error if the VDiff did not complete or found differences.
+func (s *Server) verifyLookupVindex(ctx context.Context, req *vtctldatapb.LookupVindexCreateRequest, tableKeyspace string, waitUpdateInterval time.Duration) (string, error) {
+	ticker := time.NewTicker(waitUpdateInterval)
+	defer ticker.Stop()
+
+	for backfilled := false; !backfilled; {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-ticker.C:
+		}
+		workflows := map[string]*tabletmanagerdatapb.ReadVReplicationWorkflowResponse{}
+		err := s.forAllLookupTargets(ctx, tableKeyspace, func(targetPrimary *topo.TabletInfo) error {
+			res, err := s.tmc.ReadVReplicationWorkflow(ctx, targetPrimary.Tablet, &tabletmanagerdatapb.ReadVReplicationWorkflowRequest{
+				Workflow: req.Workflow,
+			})
+			if err != nil {
+				return err
+			}
+			if res == nil {
+				return vterrors.Errorf(vtrpcpb.Code_NOT_FOUND, "workflow %s not found on %v", req.Workflow, targetPrimary.Alias)
+			}
+			workflows[targetPrimary.Shard] = res
+			return nil
+		})
+		if err != nil {
+			// The shards and their primaries can change while the workflow runs,
+			// so this is retried on the next tick.
+			log.Warningf("LookupVindex %s: failed to read the state of the workflow: %v", req.Workflow, err)
+			continue
+		}
+		if backfilled, err = isBackfilled(workflows); err != nil {
+			return "", err
+		}
+	}
+
+	vdiffUUID := uuid.New().String()
+	if err := s.setAutoExternalizeTags(ctx, tableKeyspace, req.Workflow, autoExternalizePendingTag, autoExternalizeVDiffTag+vdiffUUID); err != nil {
+		return "", err
+	}
+	if _, err := s.VDiffCreate(ctx, &vtctldatapb.VDiffCreateRequest{
+		Workflow:                    req.Workflow,
+		TargetKeyspace:              tableKeyspace,
+		Uuid:                        vdiffUUID,
+		SourceCells:                 req.Cells,
+		TabletTypes:                 req.TabletTypes,
+		TabletSelectionPreference:   req.TabletSelectionPreference,
+		FilteredReplicationWaitTime: protoutil.DurationToProto(defaultDuration),
+		WaitUpdateInterval:          protoutil.DurationToProto(waitUpdateInterval),
+	}); err != nil {
+		return vdiffUUID, err
+	}
+	log.Infof("LookupVindex %s backfilled, VDiff %s created to verify it", req.Workflow, vdiffUUID)
+
+	for clean := false; !clean; {
+		select {
+		case <-ctx.Done():
+			return vdiffUUID, ctx.Err()
+		case <-ticker.C:
+		}
+		resp, err := s.VDiffShow(ctx, &vtctldatapb.VDiffShowRequest{
+			Workflow:       req.Workflow,
+			TargetKeyspace: tableKeyspace,
+			Arg:            vdiffUUID,
+		})
+		if err != nil {
+			log.Warningf("LookupVindex %s: failed to read the state of VDiff %s: %v", req.Workflow, vdiffUUID, err)
+			continue
+		}
+		if clean, err = isVDiffClean(resp); err != nil {
+			return vdiffUUID, vterrors.Wrapf(err, "VDiff %s", vdiffUUID)
+		}
+	}
+	return vdiffUUID, nil
+}
+
+// isBackfilled returns true once all the streams of the workflow have
+// completed their copy phase, given the workflow read from the primary of
+// every target shard. It only fails once a stream is in the Error state in
+// the _vt.vreplication table, which VReplication does not retry: the errors
+// VReplication retries only show up in the message of the stream.
+func isBackfilled(workflows map[string]*tabletmanagerdatapb.ReadVReplicationWorkflowResponse) (bool, error) {
+	backfilled := true
+	for shard, workflow := range workflows {
+		for _, stream := range workflow.Streams {
+			switch stream.State {
+			case binlogdatapb.VReplicationWorkflowState_Running:
+			case binlogdatapb.VReplicationWorkflowState_Error:
+				return false, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "stream %d on %s is in the %s state: %s", stream.Id, shard, stream.State, stream.Message)
+			default:
+				// The stream is still copying, or was stopped and can be started again.
+				backfilled = false
+			}
+		}
+	}
+	return backfilled, nil
+}
+
+// isVDiffClean returns true once the VDiff has completed on all the shards
+// without finding any differences, and an error if it failed or found some.
+func isVDiffClean(resp *vtctldatapb.VDiffShowResponse) (bool, error) {
+	completed := true
+	for shard, tabletResp := range resp.TabletResponses {
+		if tabletResp == nil || tabletResp.Output == nil {
+			completed = false
+			continue
+		}
+		for _, row := range sqltypes.Proto3ToResult(tabletResp.Output).Named().Rows {
+			switch state := vdiff.VDiffState(strings.ToLower(row.AsString("vdiff_state", ""))); state {
+			case vdiff.CompletedState:
+			case vdiff.ErrorState:
+				return false, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "failed on %s: %s", shard, row.AsString("last_error", ""))
+			default:
+				completed = false
+			}
+			if mismatch, _ := row.ToBool("has_mismatch"); mismatch {
+				return false, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "found differences in the %s table on %s", row.AsString("table_name", ""), shard)
+			}
+		}
+	}
+	return completed && len(resp.TabletResponses) > 0, nil
+}
+
 // LookupVindexExternalize externalizes a lookup vindex that's
 // finished backfilling or has caught up. If the vindex has an
 // owner then the workflow will also be deleted.
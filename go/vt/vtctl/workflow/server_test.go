@@ -34,6 +34,7 @@ import (
 
 	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
 	querypb "vitess.io/vitess/go/vt/proto/query"
+	tabletmanagerdatapb "vitess.io/vitess/go/vt/proto/tabletmanagerdata"
 	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
 	vtctldatapb "vitess.io/vitess/go/vt/proto/vtctldata"
 )
@@ -199,3 +200,85 @@ func TestVDiffCreate(t *testing.T) {
 		})
 	}
 }
+
+func TestIsBackfilled(t *testing.T) {
+	workflows := func(streams ...*tabletmanagerdatapb.ReadVReplicationWorkflowResponse_Stream) map[string]*tabletmanagerdatapb.ReadVReplicationWorkflowResponse {
+		return map[string]*tabletmanagerdatapb.ReadVReplicationWorkflowResponse{
+			"-80": {Streams: streams[:1]},
+			"80-": {Streams: streams[1:]},
+		}
+	}
+	running := &tabletmanagerdatapb.ReadVReplicationWorkflowResponse_Stream{Id: 1, State: binlogdatapb.VReplicationWorkflowState_Running}
+	copying := &tabletmanagerdatapb.ReadVReplicationWorkflowResponse_Stream{Id: 2, State: binlogdatapb.VReplicationWorkflowState_Copying}
+	// VReplication retries the errors that only show up in the message
+	retrying := &tabletmanagerdatapb.ReadVReplicationWorkflowResponse_Stream{Id: 2, State: binlogdatapb.VReplicationWorkflowState_Copying, Message: "vttablet: rpc error: code = Unavailable"}
+	stopped := &tabletmanagerdatapb.ReadVReplicationWorkflowResponse_Stream{Id: 2, State: binlogdatapb.VReplicationWorkflowState_Stopped}
+	failed := &tabletmanagerdatapb.ReadVReplicationWorkflowResponse_Stream{Id: 2, State: binlogdatapb.VReplicationWorkflowState_Error, Message: "duplicate entry"}
+
+	backfilled, err := isBackfilled(workflows(running, running))
+	require.NoError(t, err)
+	require.True(t, backfilled)
+
+	for _, stream := range []*tabletmanagerdatapb.ReadVReplicationWorkflowResponse_Stream{copying, retrying, stopped} {
+		backfilled, err = isBackfilled(workflows(running, stream))
+		require.NoError(t, err)
+		require.False(t, backfilled)
+	}
+
+	_, err = isBackfilled(workflows(running, failed))
+	require.ErrorContains(t, err, "stream 2 on 80- is in the Error state: duplicate entry")
+}
+
+func TestSetAutoExternalizeTags(t *testing.T) {
+	ms := &vtctldatapb.MaterializeSettings{
+		Workflow:       "lookup",
+		SourceKeyspace: "sourceks",
+		TargetKeyspace: "targetks",
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	env := newTestMaterializerEnv(t, ctx, ms, []string{"0"}, []string{"-80", "80-"})
+	defer env.close()
+
+	for _, uid := range []int{200, 210} {
+		env.tmc.expectVRQuery(uid, "update _vt.vreplication set tags='auto_externalize:failed,auto_externalize:vdiff:7ab1' where db_name='vt_targetks' and workflow='lookup'", &sqltypes.Result{})
+	}
+	err := env.ws.setAutoExternalizeTags(ctx, ms.TargetKeyspace, ms.Workflow, autoExternalizeFailedTag, autoExternalizeVDiffTag+"7ab1")
+	require.NoError(t, err)
+	env.tmc.verifyQueries(t)
+}
+
+func TestIsVDiffClean(t *testing.T) {
+	fields := sqltypes.MakeTestFields(
+		"vdiff_state|last_error|table_name|has_mismatch",
+		"varchar|varchar|varchar|int64",
+	)
+	vdiffShow := func(rows ...string) *vtctldatapb.VDiffShowResponse {
+		resp := &vtctldatapb.VDiffShowResponse{TabletResponses: map[string]*tabletmanagerdatapb.VDiffResponse{}}
+		for i, shard := range []string{"-80", "80-"} {
+			resp.TabletResponses[shard] = &tabletmanagerdatapb.VDiffResponse{
+				Output: sqltypes.ResultToProto3(sqltypes.MakeTestResult(fields, rows[i])),
+			}
+		}
+		return resp
+	}
+
+	clean, err := isVDiffClean(vdiffShow("completed||lookup|0", "completed||lookup|0"))
+	require.NoError(t, err)
+	require.True(t, clean)
+
+	clean, err = isVDiffClean(vdiffShow("completed||lookup|0", "started||lookup|0"))
+	require.NoError(t, err)
+	require.False(t, clean)
+
+	_, err = isVDiffClean(vdiffShow("completed||lookup|0", "completed||lookup|1"))
+	require.ErrorContains(t, err, "found differences in the lookup table on 80-")
+
+	_, err = isVDiffClean(vdiffShow("error|table not found|lookup|0", "completed||lookup|0"))
+	require.ErrorContains(t, err, "failed on -80: table not found")
+
+	clean, err = isVDiffClean(&vtctldatapb.VDiffShowResponse{})
+	require.NoError(t, err)
+	require.False(t, clean)
+}
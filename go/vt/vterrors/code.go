@@ -72,6 +72,9 @@ var (
 
 	VT07001 = errorWithState("VT07001", vtrpcpb.Code_PERMISSION_DENIED, KillDeniedError, "%s", "Kill statement is not allowed. More in docs about how to enable it and its limitations.")
 
+	VT08001 = errorWithState("VT08001", vtrpcpb.Code_RESOURCE_EXHAUSTED, QueryInterrupted, "query aborted: memory used in vtgate exceeded the limit of %d bytes", "The rows held in memory by VTGate to run the query, e.g. to sort, join, aggregate or deduplicate them, went over the memory limit of the query. Increase the --max-query-memory flag of VTGate, or override the limit of the query with the MAX_MEMORY comment directive.")
+	VT08002 = errorWithState("VT08002", vtrpcpb.Code_RESOURCE_EXHAUSTED, QueryInterrupted, "query aborted: rows fetched from tablets exceeded the limit of %d rows", "The query fetched more rows from the tablets than allowed. Increase the --max-query-fetched-rows flag of VTGate.")
+
 	VT09001 = errorWithState("VT09001", vtrpcpb.Code_FAILED_PRECONDITION, RequiresPrimaryKey, PrimaryVindexNotSet, "the table does not have a primary vindex, the operation is impossible.")
 	VT09002 = errorWithState("VT09002", vtrpcpb.Code_FAILED_PRECONDITION, InnodbReadOnly, "%s statement with a replica target", "This type of DML statement is not allowed on a replica target.")
 	VT09003 = errorWithoutState("VT09003", vtrpcpb.Code_FAILED_PRECONDITION, "INSERT query does not have primary vindex column '%v' in the column list", "A vindex column is mandatory for the insert, please provide one.")
@@ -96,6 +99,7 @@ var (
 	VT09022 = errorWithoutState("VT09022", vtrpcpb.Code_FAILED_PRECONDITION, "Destination does not have exactly one shard: %v", "Cannot send query to multiple shards.")
 	VT09023 = errorWithoutState("VT09023", vtrpcpb.Code_FAILED_PRECONDITION, "could not map %v to a keyspace id", "Unable to determine the shard for the given row.")
 	VT09024 = errorWithoutState("VT09024", vtrpcpb.Code_FAILED_PRECONDITION, "could not map %v to a unique keyspace id: %v", "Unable to determine the shard for the given row.")
+	VT09025 = errorWithoutState("VT09025", vtrpcpb.Code_FAILED_PRECONDITION, "recursive query aborted after %d iterations", "The recursive common table expression evaluated by VTGate went over the maximum number of iterations. Increase the --cte-max-recursion-depth flag of VTGate to allow more iterations.")
 
 	VT10001 = errorWithoutState("VT10001", vtrpcpb.Code_ABORTED, "foreign key constraints are not allowed", "Foreign key constraints are not allowed, see https://vitess.io/blog/2021-06-15-online-ddl-why-no-fk/.")
 
@@ -157,6 +161,8 @@ var (
 		VT05007,
 		VT06001,
 		VT07001,
+		VT08001,
+		VT08002,
 		VT09001,
 		VT09002,
 		VT09003,
@@ -181,6 +187,7 @@ var (
 		VT09022,
 		VT09023,
 		VT09024,
+		VT09025,
 		VT10001,
 		VT12001,
 		VT12002,
@@ -18,7 +18,9 @@ package engine
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
+	"strings"
 
 	"vitess.io/vitess/go/mysql/collations"
 	"vitess.io/vitess/go/slice"
@@ -50,6 +52,19 @@ type AggregateParams struct {
 	// not what we use to aggregate at the engine primitive level.
 	OrigOpcode AggregateOpcode
 
+	// Args are used only for aggregations that are evaluated using all their arguments,
+	// like COUNT(DISTINCT a, b) or GROUP_CONCAT(a, b ORDER BY c).
+	// Distinct aggregations with Args remove the duplicate values by hashing the arguments,
+	// so their input does not need to be ordered by them.
+	Args []CheckCol
+
+	// These are used only for GROUP_CONCAT.
+	// Distinct, and OrderBy which points to the columns of the ORDER BY expressions, are only set
+	// when the GROUP_CONCAT is evaluated using its Args. A nil Separator means the default ','.
+	Distinct  bool
+	OrderBy   evalengine.Comparison
+	Separator *string
+
 	CollationEnv *collations.Environment
 }
 
@@ -76,9 +91,25 @@ func (ap *AggregateParams) String() string {
 	if ap.WAssigned() {
 		keyCol = fmt.Sprintf("%s|%d", keyCol, ap.WCol)
 	}
+	if len(ap.Args) > 0 {
+		keyCol = strings.Join(slice.Map(ap.Args, func(arg CheckCol) string {
+			return strconv.Itoa(arg.Col)
+		}), ", ")
+	}
 	if sqltypes.IsText(ap.Type.Type()) && ap.CollationEnv.IsSupported(ap.Type.Collation()) {
 		keyCol += " COLLATE " + ap.CollationEnv.LookupName(ap.Type.Collation())
 	}
+	if ap.Distinct {
+		keyCol = "DISTINCT " + keyCol
+	}
+	if len(ap.OrderBy) > 0 {
+		keyCol += " ORDER BY " + strings.Join(slice.Map(ap.OrderBy, func(order evalengine.OrderByParams) string {
+			return order.String()
+		}), ", ")
+	}
+	if ap.Separator != nil {
+		keyCol += " SEPARATOR " + sqltypes.EncodeStringSQL(*ap.Separator)
+	}
 	dispOrigOp := ""
 	if ap.OrigOpcode != AggregateUnassigned && ap.OrigOpcode != ap.Opcode {
 		dispOrigOp = "_" + ap.OrigOpcode.String()
@@ -108,9 +139,16 @@ type aggregatorDistinct struct {
 	coll         collations.ID
 	collationEnv *collations.Environment
 	values       *evalengine.EnumSetValues
+
+	// probe is used instead of column when the duplicates are found by hashing the arguments
+	probe *probeTable
 }
 
 func (a *aggregatorDistinct) shouldReturn(row []sqltypes.Value) (bool, error) {
+	if a.probe != nil {
+		newRow, err := a.probe.exists(row)
+		return newRow == nil, err
+	}
 	if a.column >= 0 {
 		last := a.last
 		next := row[a.column]
@@ -132,16 +170,31 @@ func (a *aggregatorDistinct) shouldReturn(row []sqltypes.Value) (bool, error) {
 
 func (a *aggregatorDistinct) reset() {
 	a.last = sqltypes.NULL
+	if a.probe != nil {
+		clear(a.probe.seenRows)
+	}
+}
+
+// hasNull returns true if any of the given columns is NULL
+func hasNull(row []sqltypes.Value, cols []int) bool {
+	for _, col := range cols {
+		if row[col].IsNull() {
+			return true
+		}
+	}
+	return false
 }
 
 type aggregatorCount struct {
-	from     int
+	from int
+	// args are the columns of all the arguments, when there is more than one
+	args     []int
 	n        int64
 	distinct aggregatorDistinct
 }
 
 func (a *aggregatorCount) add(row []sqltypes.Value) error {
-	if row[a.from].IsNull() {
+	if row[a.from].IsNull() || hasNull(row, a.args) {
 		return nil
 	}
 	if ret, err := a.distinct.shouldReturn(row); ret {
@@ -255,26 +308,73 @@ func (a *aggregatorScalar) reset() {
 }
 
 type aggregatorGroupConcat struct {
-	from  int
-	type_ sqltypes.Type
+	from      int
+	type_     sqltypes.Type
+	separator []byte
+
+	// These are only used when the group_concat is evaluated using all its arguments.
+	// When there is an ORDER BY, the rows are kept until the group is finished, so they can be sorted.
+	args     []int
+	distinct aggregatorDistinct
+	orderBy  evalengine.Comparison
+	rows     []sqltypes.Row
 
 	concat []byte
 	n      int
 }
 
 func (a *aggregatorGroupConcat) add(row []sqltypes.Value) error {
-	if row[a.from].IsNull() {
+	if a.args == nil {
+		if row[a.from].IsNull() {
+			return nil
+		}
+		a.appendValues(row, a.from)
+		return nil
+	}
+
+	if hasNull(row, a.args) {
 		return nil
 	}
+	if ret, err := a.distinct.shouldReturn(row); ret {
+		return err
+	}
+	if a.orderBy == nil {
+		a.appendValues(row, a.args...)
+		return nil
+	}
+	if len(a.rows) > 0 {
+		// the rows are only sorted in finish, which cannot fail,
+		// so any error comparing them has to be found here
+		var err error
+		func() {
+			defer evalengine.PanicHandler(&err)
+			a.orderBy.Compare(a.rows[len(a.rows)-1], row)
+		}()
+		if err != nil {
+			return err
+		}
+	}
+	a.rows = append(a.rows, row)
+	return nil
+}
+
+func (a *aggregatorGroupConcat) appendValues(row []sqltypes.Value, cols ...int) {
 	if a.n > 0 {
-		a.concat = append(a.concat, ',')
+		a.concat = append(a.concat, a.separator...)
+	}
+	for _, col := range cols {
+		a.concat = append(a.concat, row[col].Raw()...)
 	}
-	a.concat = append(a.concat, row[a.from].Raw()...)
 	a.n++
-	return nil
 }
 
 func (a *aggregatorGroupConcat) finish() sqltypes.Value {
+	if a.orderBy != nil {
+		slices.SortStableFunc(a.rows, a.orderBy.Compare)
+		for _, row := range a.rows {
+			a.appendValues(row, a.args...)
+		}
+	}
 	if a.n == 0 {
 		return sqltypes.NULL
 	}
@@ -284,6 +384,8 @@ func (a *aggregatorGroupConcat) finish() sqltypes.Value {
 func (a *aggregatorGroupConcat) reset() {
 	a.n = 0
 	a.concat = nil // not safe to reuse this byte slice as it's returned as MakeTrusted
+	a.rows = nil
+	a.distinct.reset()
 }
 
 type aggregatorGtid struct {
@@ -360,98 +462,128 @@ func newAggregation(fields []*querypb.Field, aggregates []*AggregateParams) (agg
 		sourceType := fields[aggr.Col].Type
 		targetType := aggr.typ(sourceType)
 
-		var ag aggregator
-		var distinct = -1
-
-		if aggr.Opcode.IsDistinct() {
-			distinct = aggr.KeyCol
-			if aggr.WAssigned() && !isComparable(sourceType) {
-				distinct = aggr.WCol
-			}
+		ag, err := newAggregator(aggr, sourceType, targetType)
+		if err != nil {
+			return nil, nil, err
 		}
 
-		if aggr.Opcode == AggregateMin || aggr.Opcode == AggregateMax {
-			if aggr.WAssigned() && !isComparable(sourceType) {
-				return nil, nil, vterrors.VT12001("min/max on types that are not comparable is not supported")
-			}
+		agstate[aggr.Col] = ag
+		fields[aggr.Col].Type = targetType
+		if aggr.Alias != "" {
+			fields[aggr.Col].Name = aggr.Alias
 		}
+	}
 
-		switch aggr.Opcode {
-		case AggregateCountStar:
-			ag = &aggregatorCountStar{}
-
-		case AggregateCount, AggregateCountDistinct:
-			ag = &aggregatorCount{
-				from: aggr.Col,
-				distinct: aggregatorDistinct{
-					column:       distinct,
-					coll:         aggr.Type.Collation(),
-					collationEnv: aggr.CollationEnv,
-					values:       aggr.Type.Values(),
-				},
-			}
+	for i, a := range agstate {
+		if a == nil {
+			agstate[i] = &aggregatorScalar{from: i}
+		}
+	}
 
-		case AggregateSum, AggregateSumDistinct:
-			var sum evalengine.Sum
-			switch aggr.OrigOpcode {
-			case AggregateCount, AggregateCountStar, AggregateCountDistinct:
-				sum = evalengine.NewSumOfCounts()
-			default:
-				sum = evalengine.NewAggregationSum(sourceType)
-			}
+	return agstate, fields, nil
+}
 
-			ag = &aggregatorSum{
-				from: aggr.Col,
-				sum:  sum,
-				distinct: aggregatorDistinct{
-					column:       distinct,
-					coll:         aggr.Type.Collation(),
-					collationEnv: aggr.CollationEnv,
-					values:       aggr.Type.Values(),
-				},
-			}
+// newAggregator creates the aggregator that evaluates a single aggregation
+func newAggregator(aggr *AggregateParams, sourceType, targetType sqltypes.Type) (aggregator, error) {
+	var ag aggregator
+	var distinct = -1
+	var probe *probeTable
+	var args []int
 
-		case AggregateMin:
-			ag = &aggregatorMin{
-				aggregatorMinMax{
-					from:   aggr.Col,
-					minmax: evalengine.NewAggregationMinMax(sourceType, aggr.CollationEnv, aggr.Type.Collation(), aggr.Type.Values()),
-				},
-			}
+	if len(aggr.Args) > 0 {
+		args = slice.Map(aggr.Args, func(arg CheckCol) int { return arg.Col })
+		if aggr.Opcode.IsDistinct() || aggr.Distinct {
+			probe = newProbeTable(aggr.Args, aggr.CollationEnv)
+		}
+	} else if aggr.Opcode.IsDistinct() {
+		distinct = aggr.KeyCol
+		if aggr.WAssigned() && !isComparable(sourceType) {
+			distinct = aggr.WCol
+		}
+	}
 
-		case AggregateMax:
-			ag = &aggregatorMax{
-				aggregatorMinMax{
-					from:   aggr.Col,
-					minmax: evalengine.NewAggregationMinMax(sourceType, aggr.CollationEnv, aggr.Type.Collation(), aggr.Type.Values()),
-				},
-			}
+	if aggr.Opcode == AggregateMin || aggr.Opcode == AggregateMax {
+		if aggr.WAssigned() && !isComparable(sourceType) {
+			return nil, vterrors.VT12001("min/max on types that are not comparable is not supported")
+		}
+	}
 
-		case AggregateGtid:
-			ag = &aggregatorGtid{from: aggr.Col}
+	switch aggr.Opcode {
+	case AggregateCountStar:
+		ag = &aggregatorCountStar{}
+
+	case AggregateCount, AggregateCountDistinct:
+		ag = &aggregatorCount{
+			from: aggr.Col,
+			args: args,
+			distinct: aggregatorDistinct{
+				column:       distinct,
+				coll:         aggr.Type.Collation(),
+				collationEnv: aggr.CollationEnv,
+				values:       aggr.Type.Values(),
+				probe:        probe,
+			},
+		}
 
-		case AggregateAnyValue:
-			ag = &aggregatorScalar{from: aggr.Col}
+	case AggregateSum, AggregateSumDistinct:
+		var sum evalengine.Sum
+		switch aggr.OrigOpcode {
+		case AggregateCount, AggregateCountStar, AggregateCountDistinct:
+			sum = evalengine.NewSumOfCounts()
+		default:
+			sum = evalengine.NewAggregationSum(sourceType)
+		}
 
-		case AggregateGroupConcat:
-			ag = &aggregatorGroupConcat{from: aggr.Col, type_: targetType}
+		ag = &aggregatorSum{
+			from: aggr.Col,
+			sum:  sum,
+			distinct: aggregatorDistinct{
+				column:       distinct,
+				coll:         aggr.Type.Collation(),
+				collationEnv: aggr.CollationEnv,
+				values:       aggr.Type.Values(),
+				probe:        probe,
+			},
+		}
 
-		default:
-			panic("BUG: unexpected Aggregation opcode")
+	case AggregateMin:
+		ag = &aggregatorMin{
+			aggregatorMinMax{
+				from:   aggr.Col,
+				minmax: evalengine.NewAggregationMinMax(sourceType, aggr.CollationEnv, aggr.Type.Collation(), aggr.Type.Values()),
+			},
 		}
 
-		agstate[aggr.Col] = ag
-		fields[aggr.Col].Type = targetType
-		if aggr.Alias != "" {
-			fields[aggr.Col].Name = aggr.Alias
+	case AggregateMax:
+		ag = &aggregatorMax{
+			aggregatorMinMax{
+				from:   aggr.Col,
+				minmax: evalengine.NewAggregationMinMax(sourceType, aggr.CollationEnv, aggr.Type.Collation(), aggr.Type.Values()),
+			},
 		}
-	}
 
-	for i, a := range agstate {
-		if a == nil {
-			agstate[i] = &aggregatorScalar{from: i}
+	case AggregateGtid:
+		ag = &aggregatorGtid{from: aggr.Col}
+
+	case AggregateAnyValue:
+		ag = &aggregatorScalar{from: aggr.Col}
+
+	case AggregateGroupConcat:
+		separator := []byte{','}
+		if aggr.Separator != nil {
+			separator = []byte(*aggr.Separator)
+		}
+		ag = &aggregatorGroupConcat{
+			from:      aggr.Col,
+			type_:     targetType,
+			separator: separator,
+			args:      args,
+			distinct:  aggregatorDistinct{column: -1, probe: probe},
+			orderBy:   aggr.OrderBy,
 		}
-	}
 
-	return agstate, fields, nil
+	default:
+		panic("BUG: unexpected Aggregation opcode")
+	}
+	return ag, nil
 }
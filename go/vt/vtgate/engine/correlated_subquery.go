@@ -0,0 +1,406 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+
+	"vitess.io/vitess/go/mysql"
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	"vitess.io/vitess/go/vt/sqlparser"
+	. "vitess.io/vitess/go/vt/vtgate/engine/opcode"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+)
+
+var _ Primitive = (*CorrelatedSubquery)(nil)
+
+// correlatedSubqueryConcurrency is the maximum number of executions of
+// the subquery that run in parallel for a set of outer rows.
+const correlatedSubqueryConcurrency = 10
+
+// CorrelatedSubquery executes a subquery that depends on the values of the
+// rows of the Outer primitive. For every set of rows the Outer primitive returns,
+// the Vars are bound from each row and the Subquery is executed once for every
+// distinct set of values, the same way Join binds the values of its LHS rows.
+// The result of the subquery is exposed through the SubqueryResult and HasValues
+// bind variables, the same way UncorrelatedSubquery does it.
+//
+// When the subquery is used in a filtering predicate, the Predicate is set, and
+// the outer rows are kept if it evaluates to true.
+// When it is used in the select list, the Cols and Exprs are set instead, and the
+// primitive returns the outer columns along with the values of the Exprs.
+type CorrelatedSubquery struct {
+	Opcode PulloutOpcode
+
+	// SubqueryResult and HasValues are the bind variables the Predicate
+	// and the Exprs use to refer to the result of the subquery
+	SubqueryResult string
+	HasValues      string
+
+	Outer    Primitive
+	Subquery Primitive
+
+	// Vars defines the bind variables that need to be built
+	// from an outer row before invoking the Subquery.
+	Vars map[string]int
+
+	Predicate    evalengine.Expr
+	ASTPredicate sqlparser.Expr
+
+	// Cols defines which columns are returned: negative values
+	// are the columns of the outer rows (-1 is the first), and
+	// positive values are the Exprs (1 is the first).
+	Cols     []int
+	Exprs    []evalengine.Expr
+	ASTExprs []sqlparser.Expr
+	ColNames []string
+}
+
+// RouteType returns a description of the query routing type used by the primitive
+func (cs *CorrelatedSubquery) RouteType() string {
+	return cs.Opcode.String()
+}
+
+// GetKeyspaceName specifies the Keyspace that this primitive routes to.
+func (cs *CorrelatedSubquery) GetKeyspaceName() string {
+	return cs.Outer.GetKeyspaceName()
+}
+
+// GetTableName specifies the table that this primitive routes to.
+func (cs *CorrelatedSubquery) GetTableName() string {
+	return cs.Outer.GetTableName()
+}
+
+// TryExecute satisfies the Primitive interface.
+func (cs *CorrelatedSubquery) TryExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool) (*sqltypes.Result, error) {
+	outer, err := vcursor.ExecutePrimitive(ctx, cs.Outer, bindVars, wantfields)
+	if err != nil {
+		return nil, err
+	}
+	result, _, err := cs.apply(ctx, vcursor, bindVars, outer, map[string]map[string]*querypb.BindVariable{})
+	return result, err
+}
+
+// TryStreamExecute performs a streaming exec. The results of the subquery are
+// cached across the results of the Outer primitive, and the memory they use is
+// reserved from the memory limit of the query. When the reservation is refused,
+// the cache is dropped instead of failing the query.
+func (cs *CorrelatedSubquery) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool, callback func(*sqltypes.Result) error) error {
+	var mu sync.Mutex
+	cache := map[string]map[string]*querypb.BindVariable{}
+	mem := newMemoryReservation(vcursor)
+	defer mem.release()
+	return vcursor.StreamExecutePrimitive(ctx, cs.Outer, bindVars, wantfields, func(outer *sqltypes.Result) error {
+		mu.Lock()
+		defer mu.Unlock()
+		result, size, err := cs.apply(ctx, vcursor, bindVars, outer, cache)
+		if err != nil {
+			return err
+		}
+		if err := mem.reserve(size); err != nil {
+			mem.release()
+			clear(cache)
+		}
+		return callback(result)
+	})
+}
+
+// apply executes the subquery for the outer rows, and filters or projects them
+// with its results. The cache maps the values bound from an outer row to the bind
+// variables produced by the subquery. It also returns an estimate of the memory
+// used by the entries it added to the cache.
+func (cs *CorrelatedSubquery) apply(
+	ctx context.Context,
+	vcursor VCursor,
+	bindVars map[string]*querypb.BindVariable,
+	outer *sqltypes.Result,
+	cache map[string]map[string]*querypb.BindVariable,
+) (*sqltypes.Result, int64, error) {
+	keys, size, err := cs.executeSubquery(ctx, vcursor, bindVars, outer.Rows, cache)
+	if err != nil {
+		return nil, 0, err
+	}
+	env := evalengine.NewExpressionEnv(ctx, bindVars, vcursor)
+	result := outer.ShallowCopy()
+	if cs.Predicate != nil {
+		result.Rows = nil
+		for i, row := range outer.Rows {
+			env.BindVars = combineVars(bindVars, cache[keys[i]])
+			env.Row = row
+			evalResult, err := env.Evaluate(cs.Predicate)
+			if err != nil {
+				return nil, 0, err
+			}
+			if evalResult.ToBoolean() {
+				result.Rows = append(result.Rows, row)
+			}
+		}
+		return result, size, nil
+	}
+
+	if outer.Fields != nil {
+		if len(outer.Rows) > 0 {
+			// like Projection does, the types come from the values the expressions are evaluated with
+			env.BindVars = combineVars(bindVars, cache[keys[0]])
+			result.Fields, err = cs.evalFields(env, outer.Fields, vcursor.ConnCollation())
+		} else {
+			result.Fields, err = cs.getFields(ctx, vcursor, bindVars, outer.Fields)
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	result.Rows = make([]sqltypes.Row, 0, len(outer.Rows))
+	for i, row := range outer.Rows {
+		env.BindVars = combineVars(bindVars, cache[keys[i]])
+		env.Row = row
+		resultRow := make(sqltypes.Row, 0, len(cs.Cols))
+		for _, col := range cs.Cols {
+			if col < 0 {
+				resultRow = append(resultRow, row[-col-1])
+				continue
+			}
+			value, err := env.Evaluate(cs.Exprs[col-1])
+			if err != nil {
+				return nil, 0, err
+			}
+			resultRow = append(resultRow, value.Value(vcursor.ConnCollation()))
+		}
+		result.Rows = append(result.Rows, resultRow)
+	}
+	return result, size, nil
+}
+
+// executeSubquery executes the subquery once for every distinct set of values
+// bound from the outer rows that is not in the cache yet, and returns the cache
+// keys of the rows, along with an estimate of the memory used by the entries it
+// added to the cache. Outside of a transaction the executions run in parallel.
+func (cs *CorrelatedSubquery) executeSubquery(
+	ctx context.Context,
+	vcursor VCursor,
+	bindVars map[string]*querypb.BindVariable,
+	rows []sqltypes.Row,
+	cache map[string]map[string]*querypb.BindVariable,
+) ([]string, int64, error) {
+	// the subquery is executed once per distinct set of values in the outer rows,
+	// in the order they first show up
+	keys := make([]string, len(rows))
+	var pending []int
+	seen := map[string]bool{}
+	for i, row := range rows {
+		keys[i] = cs.cacheKey(row)
+		if _, ok := cache[keys[i]]; !ok && !seen[keys[i]] {
+			seen[keys[i]] = true
+			pending = append(pending, i)
+		}
+	}
+	if len(pending) == 0 {
+		return keys, 0, nil
+	}
+
+	var mu sync.Mutex
+	var size int64
+	execute := func(ctx context.Context, key string, row sqltypes.Row) error {
+		result, err := vcursor.ExecutePrimitive(ctx, cs.Subquery, combineVars(bindVars, cs.rowVars(row)), false)
+		if err != nil {
+			return err
+		}
+		sqVars := make(map[string]*querypb.BindVariable, 2)
+		if err := setSubqueryBindVars(cs.Opcode, cs.SubqueryResult, cs.HasValues, result, sqVars); err != nil {
+			return err
+		}
+		mu.Lock()
+		cache[key] = sqVars
+		size += int64(len(key))
+		for _, bv := range sqVars {
+			size += bv.CachedSize(true)
+		}
+		mu.Unlock()
+		return nil
+	}
+
+	if len(pending) == 1 || vcursor.Session().InTransaction() {
+		// in a transaction, all the queries have to go through the same connection
+		for _, i := range pending {
+			if err := execute(ctx, keys[i], rows[i]); err != nil {
+				return nil, 0, err
+			}
+		}
+		return keys, size, nil
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(correlatedSubqueryConcurrency)
+	for _, i := range pending {
+		g.Go(func() error {
+			return execute(gctx, keys[i], rows[i])
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, 0, err
+	}
+	return keys, size, nil
+}
+
+func (cs *CorrelatedSubquery) rowVars(row sqltypes.Row) map[string]*querypb.BindVariable {
+	vars := make(map[string]*querypb.BindVariable, len(cs.Vars))
+	for k, col := range cs.Vars {
+		vars[k] = sqltypes.ValueBindVariable(row[col])
+	}
+	return vars
+}
+
+// cacheKey builds a key out of the outer row values the subquery depends on.
+func (cs *CorrelatedSubquery) cacheKey(row sqltypes.Row) string {
+	offsets := make([]int, 0, len(cs.Vars))
+	for _, col := range cs.Vars {
+		offsets = append(offsets, col)
+	}
+	slices.Sort(offsets)
+
+	var buf []byte
+	for _, col := range offsets {
+		val := row[col]
+		buf = strconv.AppendInt(buf, int64(val.Type()), 10)
+		buf = append(buf, ':')
+		buf = strconv.AppendInt(buf, int64(val.Len()), 10)
+		buf = append(buf, ':')
+		buf = append(buf, val.Raw()...)
+	}
+	return string(buf)
+}
+
+// GetFields fetches the field info.
+func (cs *CorrelatedSubquery) GetFields(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable) (*sqltypes.Result, error) {
+	qr, err := cs.Outer.GetFields(ctx, vcursor, bindVars)
+	if err != nil || cs.Predicate != nil {
+		return qr, err
+	}
+	fields, err := cs.getFields(ctx, vcursor, bindVars, qr.Fields)
+	if err != nil {
+		return nil, err
+	}
+	return &sqltypes.Result{Fields: fields}, nil
+}
+
+// getFields returns the fields of the projected columns, evaluating the types of the
+// Exprs with placeholder values for the result of the subquery.
+func (cs *CorrelatedSubquery) getFields(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, infields []*querypb.Field) ([]*querypb.Field, error) {
+	combinedVars := copyBindVars(bindVars)
+	setDummySubqueryBindVars(cs.Opcode, cs.SubqueryResult, cs.HasValues, combinedVars)
+	env := evalengine.NewExpressionEnv(ctx, combinedVars, vcursor)
+	return cs.evalFields(env, infields, vcursor.ConnCollation())
+}
+
+// evalFields returns the fields of the projected columns, the same way Projection does it.
+func (cs *CorrelatedSubquery) evalFields(env *evalengine.ExpressionEnv, infields []*querypb.Field, coll collations.ID) ([]*querypb.Field, error) {
+	env.Fields = infields
+
+	fields := make([]*querypb.Field, 0, len(cs.Cols))
+	for i, col := range cs.Cols {
+		if col < 0 {
+			fields = append(fields, infields[-col-1])
+			continue
+		}
+		typ, err := env.TypeOf(cs.Exprs[col-1])
+		if err != nil {
+			return nil, err
+		}
+		fl := mysql.FlagsForColumn(typ.Type(), typ.Collation())
+		if !sqltypes.IsNull(typ.Type()) && !typ.Nullable() {
+			fl |= uint32(querypb.MySqlFlag_NOT_NULL_FLAG)
+		}
+		typCol := typ.Collation()
+		if sqltypes.IsTextOrBinary(typ.Type()) && typCol != collations.CollationBinaryID {
+			typCol = coll
+		}
+		var name string
+		if i < len(cs.ColNames) {
+			name = cs.ColNames[i]
+		}
+		fields = append(fields, &querypb.Field{
+			Name:         name,
+			Type:         typ.Type(),
+			Charset:      uint32(typCol),
+			ColumnLength: uint32(typ.Size()),
+			Decimals:     uint32(typ.Scale()),
+			Flags:        fl,
+		})
+	}
+	return fields, nil
+}
+
+// Inputs returns the input primitives for this primitive
+func (cs *CorrelatedSubquery) Inputs() ([]Primitive, []map[string]any) {
+	return []Primitive{cs.Outer, cs.Subquery}, []map[string]any{{
+		inputName: "Outer",
+	}, {
+		inputName: "SubQuery",
+	}}
+}
+
+// NeedsTransaction implements the Primitive interface
+func (cs *CorrelatedSubquery) NeedsTransaction() bool {
+	return cs.Subquery.NeedsTransaction() || cs.Outer.NeedsTransaction()
+}
+
+func (cs *CorrelatedSubquery) description() PrimitiveDescription {
+	other := map[string]any{}
+	if cs.ASTPredicate != nil {
+		other["Predicate"] = sqlparser.String(cs.ASTPredicate)
+	}
+	if len(cs.Cols) > 0 {
+		other["Cols"] = cs.Cols
+		var exprs []string
+		for i, col := range cs.Cols {
+			if col < 0 {
+				continue
+			}
+			expr := sqlparser.String(cs.ASTExprs[col-1])
+			if i < len(cs.ColNames) && cs.ColNames[i] != "" {
+				expr += " as " + cs.ColNames[i]
+			}
+			exprs = append(exprs, expr)
+		}
+		other["Expressions"] = exprs
+	}
+	if len(cs.Vars) > 0 {
+		other["JoinVars"] = orderedStringIntMap(cs.Vars)
+	}
+	var pulloutVars []string
+	if cs.HasValues != "" {
+		pulloutVars = append(pulloutVars, cs.HasValues)
+	}
+	if cs.SubqueryResult != "" {
+		pulloutVars = append(pulloutVars, cs.SubqueryResult)
+	}
+	if len(pulloutVars) > 0 {
+		other["PulloutVars"] = pulloutVars
+	}
+	return PrimitiveDescription{
+		OperatorType: "CorrelatedSubquery",
+		Variant:      cs.Opcode.String(),
+		Other:        other,
+	}
+}
@@ -0,0 +1,248 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtenv"
+	. "vitess.io/vitess/go/vt/vtgate/engine/opcode"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+)
+
+func newTestCorrelatedSubquery(t *testing.T, opcode PulloutOpcode, predicate sqlparser.Expr, outer *sqltypes.Result, sqResults ...*sqltypes.Result) *CorrelatedSubquery {
+	pred, err := evalengine.Translate(predicate, &evalengine.Config{
+		Collation:     collations.MySQL8().LookupByName("utf8mb4_bin"),
+		ResolveColumn: evalengine.FieldResolver(outer.Fields).Column,
+		Environment:   vtenv.NewTestEnv(),
+	})
+	require.NoError(t, err)
+
+	return &CorrelatedSubquery{
+		Opcode:         opcode,
+		SubqueryResult: "__sq1",
+		HasValues:      "__sq_has_values",
+		Outer:          &fakePrimitive{results: []*sqltypes.Result{outer}},
+		Subquery:       &fakePrimitive{results: sqResults},
+		Vars:           map[string]int{"u_col": 1},
+		Predicate:      pred,
+		ASTPredicate:   predicate,
+	}
+}
+
+func TestCorrelatedSubqueryIn(t *testing.T) {
+	// :__sq_has_values and id in ::__sq1
+	predicate := &sqlparser.AndExpr{
+		Left: sqlparser.NewArgument("__sq_has_values"),
+		Right: &sqlparser.ComparisonExpr{
+			Operator: sqlparser.InOp,
+			Left:     sqlparser.NewColName("id"),
+			Right:    sqlparser.NewListArg("__sq1"),
+		},
+	}
+	outerFields := sqltypes.MakeTestFields("id|col", "int64|int64")
+	sqFields := sqltypes.MakeTestFields("id", "int64")
+	newCS := func() *CorrelatedSubquery {
+		return newTestCorrelatedSubquery(t, PulloutIn, predicate,
+			sqltypes.MakeTestResult(outerFields, "1|10", "2|20", "3|10", "4|30"),
+			sqltypes.MakeTestResult(sqFields, "1", "3"),
+			sqltypes.MakeTestResult(sqFields, "5"),
+			sqltypes.MakeTestResult(sqFields),
+		)
+	}
+	want := sqltypes.MakeTestResult(outerFields, "1|10", "3|10")
+
+	// in a transaction, the subquery is executed sequentially, in the order of the outer rows
+	cs := newCS()
+	result, err := cs.TryExecute(context.Background(), &noopVCursor{inTx: true}, nil, true)
+	require.NoError(t, err)
+	expectResult(t, result, want)
+	// the subquery is only executed once for the two rows where col is 10
+	cs.Subquery.(*fakePrimitive).ExpectLog(t, []string{
+		`Execute u_col: type:INT64 value:"10" false`,
+		`Execute u_col: type:INT64 value:"20" false`,
+		`Execute u_col: type:INT64 value:"30" false`,
+	})
+
+	cs = newCS()
+	result, err = wrapStreamExecute(cs, &noopVCursor{inTx: true}, nil, true)
+	require.NoError(t, err)
+	expectResult(t, result, want)
+}
+
+func TestCorrelatedSubqueryValue(t *testing.T) {
+	// id < :__sq1
+	predicate := &sqlparser.ComparisonExpr{
+		Operator: sqlparser.LessThanOp,
+		Left:     sqlparser.NewColName("id"),
+		Right:    sqlparser.NewArgument("__sq1"),
+	}
+	outerFields := sqltypes.MakeTestFields("id|col", "int64|int64")
+	sqFields := sqltypes.MakeTestFields("max(id)", "int64")
+
+	cs := newTestCorrelatedSubquery(t, PulloutValue, predicate,
+		sqltypes.MakeTestResult(outerFields, "1|10", "2|20", "3|30"),
+		sqltypes.MakeTestResult(sqFields, "5"),
+		sqltypes.MakeTestResult(sqFields, "1"),
+		sqltypes.MakeTestResult(sqFields),
+	)
+	result, err := cs.TryExecute(context.Background(), &noopVCursor{inTx: true}, nil, true)
+	require.NoError(t, err)
+	expectResult(t, result, sqltypes.MakeTestResult(outerFields, "1|10"))
+
+	cs = newTestCorrelatedSubquery(t, PulloutValue, predicate,
+		sqltypes.MakeTestResult(outerFields, "1|10"),
+		sqltypes.MakeTestResult(sqFields, "5", "6"),
+	)
+	_, err = cs.TryExecute(context.Background(), &noopVCursor{inTx: true}, nil, true)
+	require.EqualError(t, err, "subquery returned more than one row")
+}
+
+// keyedPrimitive returns the result registered for the value of the u_col bind variable.
+// Unlike fakePrimitive, it can be executed concurrently.
+type keyedPrimitive struct {
+	fakePrimitive
+	results map[string]*sqltypes.Result
+
+	mu         sync.Mutex
+	executions map[string]int
+}
+
+func (f *keyedPrimitive) TryExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool) (*sqltypes.Result, error) {
+	key := string(bindVars["u_col"].Value)
+	f.mu.Lock()
+	f.executions[key]++
+	f.mu.Unlock()
+	return f.results[key], nil
+}
+
+func TestCorrelatedSubqueryProjection(t *testing.T) {
+	outerFields := sqltypes.MakeTestFields("id|col", "int64|int64")
+	sqFields := sqltypes.MakeTestFields("max(id)", "int64")
+	outer := sqltypes.MakeTestResult(outerFields, "1|10", "2|20", "3|10", "4|30")
+	newCS := func() *CorrelatedSubquery {
+		// select id, (select max(id) from ... where col = u.col) as mx
+		sqExpr := sqlparser.NewArgument("__sq1")
+		expr, err := evalengine.Translate(sqExpr, &evalengine.Config{
+			Collation:   collations.MySQL8().LookupByName("utf8mb4_bin"),
+			Environment: vtenv.NewTestEnv(),
+		})
+		require.NoError(t, err)
+		return &CorrelatedSubquery{
+			Opcode:         PulloutValue,
+			SubqueryResult: "__sq1",
+			HasValues:      "__sq_has_values",
+			Outer:          &fakePrimitive{results: []*sqltypes.Result{outer}},
+			Subquery: &keyedPrimitive{
+				results: map[string]*sqltypes.Result{
+					"10": sqltypes.MakeTestResult(sqFields, "5"),
+					"20": sqltypes.MakeTestResult(sqFields, "7"),
+					"30": sqltypes.MakeTestResult(sqFields),
+				},
+				executions: map[string]int{},
+			},
+			Vars:     map[string]int{"u_col": 1},
+			Cols:     []int{-1, 1},
+			Exprs:    []evalengine.Expr{expr},
+			ASTExprs: []sqlparser.Expr{sqExpr},
+			ColNames: []string{"id", "mx"},
+		}
+	}
+	wantRows := `[[INT64(1) INT64(5)] [INT64(2) INT64(7)] [INT64(3) INT64(5)] [INT64(4) NULL]]`
+
+	for _, vc := range []*noopVCursor{{}, {inTx: true}} {
+		cs := newCS()
+		result, err := cs.TryExecute(context.Background(), vc, nil, true)
+		require.NoError(t, err)
+		require.Equal(t, wantRows, fmt.Sprintf("%v", result.Rows))
+		require.Equal(t, "mx", result.Fields[1].Name)
+		require.Equal(t, sqltypes.Int64, result.Fields[1].Type)
+		// every distinct value of col is only looked up once, in parallel or not
+		require.Equal(t, map[string]int{"10": 1, "20": 1, "30": 1}, cs.Subquery.(*keyedPrimitive).executions)
+
+		cs = newCS()
+		result, err = wrapStreamExecute(cs, vc, nil, false)
+		require.NoError(t, err)
+		require.Equal(t, wantRows, fmt.Sprintf("%v", result.Rows))
+	}
+}
+
+func TestCorrelatedSubqueryStreamCacheMemory(t *testing.T) {
+	outerFields := sqltypes.MakeTestFields("id|col", "int64|int64")
+	sqFields := sqltypes.MakeTestFields("max(id)", "int64")
+	newCS := func() *CorrelatedSubquery {
+		// select id, (select max(id) from ... where col = u.col) as mx
+		sqExpr := sqlparser.NewArgument("__sq1")
+		expr, err := evalengine.Translate(sqExpr, &evalengine.Config{
+			Collation:   collations.MySQL8().LookupByName("utf8mb4_bin"),
+			Environment: vtenv.NewTestEnv(),
+		})
+		require.NoError(t, err)
+		return &CorrelatedSubquery{
+			Opcode:         PulloutValue,
+			SubqueryResult: "__sq1",
+			HasValues:      "__sq_has_values",
+			// the outer rows are streamed two at a time
+			Outer: &fakePrimitive{results: []*sqltypes.Result{
+				sqltypes.MakeTestResult(outerFields, "1|10", "2|20", "3|10", "4|30"),
+			}},
+			Subquery: &keyedPrimitive{
+				results: map[string]*sqltypes.Result{
+					"10": sqltypes.MakeTestResult(sqFields, "5"),
+					"20": sqltypes.MakeTestResult(sqFields, "7"),
+					"30": sqltypes.MakeTestResult(sqFields),
+				},
+				executions: map[string]int{},
+			},
+			Vars:     map[string]int{"u_col": 1},
+			Cols:     []int{-1, 1},
+			Exprs:    []evalengine.Expr{expr},
+			ASTExprs: []sqlparser.Expr{sqExpr},
+			ColNames: []string{"id", "mx"},
+		}
+	}
+	wantRows := `[[INT64(1) INT64(5)] [INT64(2) INT64(7)] [INT64(3) INT64(5)] [INT64(4) NULL]]`
+
+	// the cache is kept across the streamed results, and its memory is
+	// released at the end of the query
+	cs := newCS()
+	vc := &loggingVCursor{}
+	result, err := wrapStreamExecute(cs, vc, nil, false)
+	require.NoError(t, err)
+	require.Equal(t, wantRows, fmt.Sprintf("%v", result.Rows))
+	require.Equal(t, map[string]int{"10": 1, "20": 1, "30": 1}, cs.Subquery.(*keyedPrimitive).executions)
+	require.Zero(t, vc.memory)
+
+	// when the memory of the cache is refused, the cache is dropped and the
+	// subquery is executed again for the values it held
+	cs = newCS()
+	vc = &loggingVCursor{maxMemory: 1}
+	result, err = wrapStreamExecute(cs, vc, nil, false)
+	require.NoError(t, err)
+	require.Equal(t, wantRows, fmt.Sprintf("%v", result.Rows))
+	require.Equal(t, map[string]int{"10": 2, "20": 1, "30": 1}, cs.Subquery.(*keyedPrimitive).executions)
+	require.Zero(t, vc.memory)
+}
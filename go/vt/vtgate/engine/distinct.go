@@ -18,7 +18,9 @@ package engine
 
 import (
 	"context"
+	"encoding/binary"
 	"fmt"
+	"io"
 	"sync"
 
 	"vitess.io/vitess/go/mysql/collations"
@@ -102,11 +104,21 @@ func newProbeTable(checkCols []CheckCol, collationEnv *collations.Environment) *
 
 // TryExecute implements the Primitive interface
 func (d *Distinct) TryExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool) (*sqltypes.Result, error) {
+	if spillsNonStreaming(vcursor) {
+		return executeStreamed(ctx, vcursor, d, bindVars, wantfields)
+	}
+
 	input, err := vcursor.ExecutePrimitive(ctx, d.Source, bindVars, wantfields)
 	if err != nil {
 		return nil, err
 	}
 
+	mem := newMemoryReservation(vcursor)
+	defer mem.release()
+	if err := mem.reserveRows(input.Rows...); err != nil {
+		return nil, err
+	}
+
 	result := &sqltypes.Result{
 		Fields:   input.Fields,
 		InsertID: input.InsertID,
@@ -133,7 +145,15 @@ func (d *Distinct) TryExecute(ctx context.Context, vcursor VCursor, bindVars map
 func (d *Distinct) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool, callback func(*sqltypes.Result) error) error {
 	var mu sync.Mutex
 
+	mem := newMemoryReservation(vcursor)
+	defer mem.release()
+
 	pt := newProbeTable(d.CheckCols, vcursor.Environment().CollationEnv())
+	// partitions is set once the rows are spilled to disk
+	var partitions []*spillFile
+	defer func() {
+		closeSpillFiles(partitions)
+	}()
 	err := vcursor.StreamExecutePrimitive(ctx, d.Source, bindVars, wantfields, func(input *sqltypes.Result) error {
 		result := &sqltypes.Result{
 			Fields:   input.Fields,
@@ -141,6 +161,15 @@ func (d *Distinct) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVa
 		}
 		mu.Lock()
 		defer mu.Unlock()
+		if partitions != nil {
+			if err := d.addPartitionRows(pt, partitions, input.Rows); err != nil {
+				return err
+			}
+			if len(result.Fields) == 0 {
+				return nil
+			}
+			return callback(result.Truncate(len(d.CheckCols)))
+		}
 		for _, row := range input.Rows {
 			appendRow, err := pt.exists(row)
 			if err != nil {
@@ -150,10 +179,103 @@ func (d *Distinct) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVa
 				result.Rows = append(result.Rows, appendRow)
 			}
 		}
+		// the probe table holds the rows seen for the first time
+		if err := mem.reserveRows(result.Rows...); err != nil {
+			return err
+		}
+		if mem.shouldSpill() {
+			partitions = make([]*spillFile, distinctPartitionCount)
+			for i := range partitions {
+				var err error
+				if partitions[i], err = newSpillFile(vcursor.SpillDirectory()); err != nil {
+					return err
+				}
+			}
+			// the probe table stays in memory to skip the rows already
+			// sent, so its memory stays reserved until the input is split
+			spills.Add("Distinct", 1)
+		}
 		return callback(result.Truncate(len(d.CheckCols)))
 	})
+	if err != nil || partitions == nil {
+		return err
+	}
 
-	return err
+	pt = nil
+	mem.release()
+	for _, partition := range partitions {
+		if err := d.streamPartition(vcursor, partition, mem, callback); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// distinctPartitionCount is the number of partitions the rows of a distinct
+// are split into when they are spilled to disk.
+const distinctPartitionCount = 16
+
+// addPartitionRows writes the rows that were not sent before the rows were
+// spilled to disk to their partitions. The rows are split by their hash,
+// so that the duplicates of a row are all in the same partition.
+func (d *Distinct) addPartitionRows(pt *probeTable, partitions []*spillFile, rows []sqltypes.Row) error {
+	for _, row := range rows {
+		code, err := pt.hashCodeForRow(row)
+		if err != nil {
+			return err
+		}
+		// the rows seen before the spill were already sent
+		if _, found := pt.seenRows[code]; found {
+			continue
+		}
+		i := binary.LittleEndian.Uint64(code[:8]) % distinctPartitionCount
+		if err := partitions[i].write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// streamPartition sends the distinct rows of a partition spilled to disk.
+func (d *Distinct) streamPartition(vcursor VCursor, partition *spillFile, mem *memoryReservation, callback func(*sqltypes.Result) error) error {
+	defer mem.release()
+
+	pt := newProbeTable(d.CheckCols, vcursor.Environment().CollationEnv())
+	reader, err := partition.reader()
+	if err != nil {
+		return err
+	}
+	var rows []sqltypes.Row
+	for {
+		row, err := reader.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		appendRow, err := pt.exists(row)
+		if err != nil {
+			return err
+		}
+		if appendRow == nil {
+			continue
+		}
+		if err := mem.reserveRows(appendRow); err != nil {
+			return err
+		}
+		rows = append(rows, appendRow)
+		if len(rows) >= spillBatchSize {
+			if err := callback((&sqltypes.Result{Rows: rows}).Truncate(len(d.CheckCols))); err != nil {
+				return err
+			}
+			rows = nil
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return callback((&sqltypes.Result{Rows: rows}).Truncate(len(d.CheckCols)))
 }
 
 // RouteType implements the Primitive interface
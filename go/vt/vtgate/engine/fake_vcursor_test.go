@@ -38,6 +38,7 @@ import (
 	"vitess.io/vitess/go/vt/sqlparser"
 	"vitess.io/vitess/go/vt/srvtopo"
 	"vitess.io/vitess/go/vt/vtenv"
+	"vitess.io/vitess/go/vt/vterrors"
 	"vitess.io/vitess/go/vt/vtgate/vindexes"
 
 	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
@@ -48,6 +49,7 @@ import (
 
 var testMaxMemoryRows = 100
 var testIgnoreMaxMemoryRows = false
+var testCTEMaxRecursionDepth = 1000
 
 var _ VCursor = (*noopVCursor)(nil)
 var _ SessionActions = (*noopVCursor)(nil)
@@ -207,6 +209,10 @@ func (t *noopVCursor) SetReadAfterWriteGTID(s string) {
 	panic("implement me")
 }
 
+func (t *noopVCursor) SetSessionToken(s string) {
+	panic("implement me")
+}
+
 func (t *noopVCursor) SetSessionEnableSystemSettings(ctx context.Context, allow bool) error {
 	panic("implement me")
 }
@@ -235,6 +241,9 @@ func (t *noopVCursor) LookupRowLockShardSession() vtgatepb.CommitOrder {
 	panic("implement me")
 }
 
+func (t *noopVCursor) AddLookupCacheInvalidation(table, column string, ids []sqltypes.Value) {
+}
+
 func (t *noopVCursor) SetFoundRows(u uint64) {
 	panic("implement me")
 }
@@ -293,6 +302,9 @@ func (t *noopVCursor) GetQueryTimeout(queryTimeoutFromComments int) int {
 	return queryTimeoutFromComments
 }
 
+func (t *noopVCursor) SetMaxReplicaLag(time.Duration) {
+}
+
 func (t *noopVCursor) SetSkipQueryPlanCache(context.Context, bool) error {
 	panic("implement me")
 }
@@ -337,6 +349,29 @@ func (t *noopVCursor) ExceedsMaxMemoryRows(numRows int) bool {
 	return !testIgnoreMaxMemoryRows && numRows > testMaxMemoryRows
 }
 
+func (t *noopVCursor) ReserveMemory(int64) error {
+	return nil
+}
+
+func (t *noopVCursor) ReleaseMemory(int64) {
+}
+
+func (t *noopVCursor) SpillThreshold() int64 {
+	return 0
+}
+
+func (t *noopVCursor) SpillDirectory() string {
+	return ""
+}
+
+func (t *noopVCursor) SpillNonStreaming() bool {
+	return false
+}
+
+func (t *noopVCursor) CTEMaxRecursionDepth() int {
+	return testCTEMaxRecursionDepth
+}
+
 func (t *noopVCursor) GetKeyspace() string {
 	return ""
 }
@@ -421,6 +456,44 @@ type loggingVCursor struct {
 	shardSession []*srvtopo.ResolvedShard
 
 	parser *sqlparser.Parser
+
+	// maxMemory is the memory limit of the query, memory the memory
+	// reserved by its primitives.
+	maxMemory int64
+	memory    int64
+
+	// spillThreshold and spillDir configure the spilling of rows to disk.
+	spillThreshold    int64
+	spillDir          string
+	spillNonStreaming bool
+}
+
+func (f *loggingVCursor) SpillThreshold() int64 {
+	return f.spillThreshold
+}
+
+func (f *loggingVCursor) SpillDirectory() string {
+	return f.spillDir
+}
+
+func (f *loggingVCursor) SpillNonStreaming() bool {
+	return f.spillNonStreaming
+}
+
+func (f *loggingVCursor) ReserveMemory(size int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.memory += size
+	if f.maxMemory > 0 && f.memory > f.maxMemory {
+		return vterrors.VT08001(f.maxMemory)
+	}
+	return nil
+}
+
+func (f *loggingVCursor) ReleaseMemory(size int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.memory -= size
 }
 
 func (f *loggingVCursor) HasCreatedTempTable() {
@@ -18,7 +18,9 @@ package engine
 
 import (
 	"context"
+	"encoding/binary"
 	"fmt"
+	"io"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -93,11 +95,21 @@ type (
 
 // TryExecute implements the Primitive interface
 func (hj *HashJoin) TryExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool) (*sqltypes.Result, error) {
+	if spillsNonStreaming(vcursor) {
+		return executeStreamed(ctx, vcursor, hj, bindVars, wantfields)
+	}
+
 	lresult, err := vcursor.ExecutePrimitive(ctx, hj.Left, bindVars, wantfields)
 	if err != nil {
 		return nil, err
 	}
 
+	mem := newMemoryReservation(vcursor)
+	defer mem.release()
+	if err := mem.reserveRows(lresult.Rows...); err != nil {
+		return nil, err
+	}
+
 	pt := newHashJoinProbeTable(hj.Collation, hj.ComparisonType, hj.LHSKey, hj.RHSKey, hj.Cols, hj.Values)
 	// build the probe table from the LHS result
 	for _, row := range lresult.Rows {
@@ -111,6 +123,9 @@ func (hj *HashJoin) TryExecute(ctx context.Context, vcursor VCursor, bindVars ma
 	if err != nil {
 		return nil, err
 	}
+	if err := mem.reserveRows(rresult.Rows...); err != nil {
+		return nil, err
+	}
 
 	result := &sqltypes.Result{
 		Fields: joinFields(lresult.Fields, rresult.Fields, hj.Cols),
@@ -137,23 +152,52 @@ func (hj *HashJoin) TryStreamExecute(ctx context.Context, vcursor VCursor, bindV
 	pt := newHashJoinProbeTable(hj.Collation, hj.ComparisonType, hj.LHSKey, hj.RHSKey, hj.Cols, hj.Values)
 	var lfields []*querypb.Field
 	var mu sync.Mutex
+	mem := newMemoryReservation(vcursor)
+	defer mem.release()
+	// partitions is set once the LHS rows are spilled to disk
+	var partitions *hashJoinPartitions
+	defer func() {
+		partitions.close()
+	}()
 	err := vcursor.StreamExecutePrimitive(ctx, hj.Left, bindVars, wantfields, func(result *sqltypes.Result) error {
 		mu.Lock()
 		defer mu.Unlock()
 		if len(lfields) == 0 && len(result.Fields) != 0 {
 			lfields = result.Fields
 		}
+		if partitions != nil {
+			return partitions.addLeftRows(result.Rows)
+		}
+		if err := mem.reserveRows(result.Rows...); err != nil {
+			return err
+		}
 		for _, current := range result.Rows {
 			err := pt.addLeftRow(current)
 			if err != nil {
 				return err
 			}
 		}
+		if mem.shouldSpill() {
+			var err error
+			partitions, err = hj.newPartitions(vcursor.SpillDirectory())
+			if err != nil {
+				return err
+			}
+			if err := partitions.addLeftRows(pt.leftRows()); err != nil {
+				return err
+			}
+			spills.Add("HashJoin", 1)
+			pt = nil
+			mem.release()
+		}
 		return nil
 	})
 	if err != nil {
 		return err
 	}
+	if partitions != nil {
+		return hj.streamPartitions(ctx, vcursor, bindVars, wantfields, lfields, partitions, mem, callback)
+	}
 
 	var sendFields atomic.Bool
 	sendFields.Store(wantfields)
@@ -201,6 +245,104 @@ func (hj *HashJoin) TryStreamExecute(ctx context.Context, vcursor VCursor, bindV
 	return nil
 }
 
+// streamPartitions joins the rows of the LHS spilled to disk with the
+// RHS, once the LHS held more memory than the spill threshold. The rows
+// of the RHS are spilled to disk too, and each partition of the rows is
+// then joined in memory on its own.
+func (hj *HashJoin) streamPartitions(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool, lfields []*querypb.Field, partitions *hashJoinPartitions, mem *memoryReservation, callback func(*sqltypes.Result) error) error {
+	var mu sync.Mutex
+	sendFields := wantfields
+	err := vcursor.StreamExecutePrimitive(ctx, hj.Right, bindVars, wantfields, func(result *sqltypes.Result) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(result.Fields) != 0 && sendFields {
+			sendFields = false
+			if err := callback(&sqltypes.Result{Fields: joinFields(lfields, result.Fields, hj.Cols)}); err != nil {
+				return err
+			}
+		}
+		return partitions.addRightRows(result.Rows)
+	})
+	if err != nil {
+		return err
+	}
+	if hj.Opcode == LeftJoin && sendFields {
+		rres, err := hj.Right.GetFields(ctx, vcursor, bindVars)
+		if err != nil {
+			return err
+		}
+		if err := callback(&sqltypes.Result{Fields: joinFields(lfields, rres.Fields, hj.Cols)}); err != nil {
+			return err
+		}
+	}
+
+	for i := range partitions.left {
+		if err := hj.joinPartition(partitions.left[i], partitions.right[i], mem, callback); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// joinPartition joins the LHS and RHS rows of a partition spilled to disk.
+func (hj *HashJoin) joinPartition(left, right *spillFile, mem *memoryReservation, callback func(*sqltypes.Result) error) error {
+	defer mem.release()
+
+	pt := newHashJoinProbeTable(hj.Collation, hj.ComparisonType, hj.LHSKey, hj.RHSKey, hj.Cols, hj.Values)
+	reader, err := left.reader()
+	if err != nil {
+		return err
+	}
+	for {
+		row, err := reader.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := mem.reserveRows(row); err != nil {
+			return err
+		}
+		if err := pt.addLeftRow(row); err != nil {
+			return err
+		}
+	}
+
+	reader, err = right.reader()
+	if err != nil {
+		return err
+	}
+	var rows []sqltypes.Row
+	for {
+		row, err := reader.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		matches, err := pt.get(row)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, matches...)
+		if len(rows) >= spillBatchSize {
+			if err := callback(&sqltypes.Result{Rows: rows}); err != nil {
+				return err
+			}
+			rows = nil
+		}
+	}
+	if hj.Opcode == LeftJoin {
+		rows = append(rows, pt.notFetched()...)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return callback(&sqltypes.Result{Rows: rows})
+}
+
 // RouteType implements the Primitive interface
 func (hj *HashJoin) RouteType() string {
 	return "HashJoin"
@@ -320,6 +462,16 @@ func (pt *hashJoinProbeTable) get(rrow sqltypes.Row) (result []sqltypes.Row, err
 	return
 }
 
+// leftRows returns the LHS rows added to the probe table.
+func (pt *hashJoinProbeTable) leftRows() (rows []sqltypes.Row) {
+	for _, e := range pt.innerMap {
+		for ; e != nil; e = e.next {
+			rows = append(rows, e.row)
+		}
+	}
+	return
+}
+
 func (pt *hashJoinProbeTable) notFetched() (rows []sqltypes.Row) {
 	for _, e := range pt.innerMap {
 		for ; e != nil; e = e.next {
@@ -330,3 +482,85 @@ func (pt *hashJoinProbeTable) notFetched() (rows []sqltypes.Row) {
 	}
 	return
 }
+
+// hashJoinPartitionCount is the number of partitions the rows of a hash
+// join are split into when they are spilled to disk.
+const hashJoinPartitionCount = 16
+
+// hashJoinPartitions holds the rows of a hash join spilled to disk. The
+// rows are split by the hash of their join key, so that the rows of both
+// sides that can match are in the same partition, and that the LHS rows
+// of a single partition fit in memory.
+type hashJoinPartitions struct {
+	// pt hashes the join keys of the rows
+	pt          *hashJoinProbeTable
+	left, right []*spillFile
+}
+
+func (hj *HashJoin) newPartitions(dir string) (*hashJoinPartitions, error) {
+	p := &hashJoinPartitions{
+		pt:    newHashJoinProbeTable(hj.Collation, hj.ComparisonType, hj.LHSKey, hj.RHSKey, hj.Cols, hj.Values),
+		left:  make([]*spillFile, hashJoinPartitionCount),
+		right: make([]*spillFile, hashJoinPartitionCount),
+	}
+	for i := 0; i < hashJoinPartitionCount; i++ {
+		var err error
+		if p.left[i], err = newSpillFile(dir); err != nil {
+			p.close()
+			return nil, err
+		}
+		if p.right[i], err = newSpillFile(dir); err != nil {
+			p.close()
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+func (p *hashJoinPartitions) partition(val sqltypes.Value) (int, error) {
+	hash, err := p.pt.hash(val)
+	if err != nil {
+		return 0, err
+	}
+	return int(binary.LittleEndian.Uint64(hash[:8]) % hashJoinPartitionCount), nil
+}
+
+func (p *hashJoinPartitions) addLeftRows(rows []sqltypes.Row) error {
+	for _, row := range rows {
+		i, err := p.partition(row[p.pt.lhsKey])
+		if err != nil {
+			return err
+		}
+		if err := p.left[i].write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *hashJoinPartitions) addRightRows(rows []sqltypes.Row) error {
+	for _, row := range rows {
+		val := row[p.pt.rhsKey]
+		// NULL never matches, so the row is not needed
+		if val.IsNull() {
+			continue
+		}
+		i, err := p.partition(val)
+		if err != nil {
+			return err
+		}
+		if err := p.right[i].write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// close closes and removes the files of the partitions.
+func (p *hashJoinPartitions) close() {
+	if p == nil {
+		return
+	}
+	closeSpillFiles(p.left)
+	closeSpillFiles(p.right)
+}
@@ -0,0 +1,351 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"slices"
+	"strconv"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/mysql/json"
+	"vitess.io/vitess/go/sqltypes"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+)
+
+var _ Primitive = (*JSONTable)(nil)
+
+// JSONTable is a primitive that produces the rows of a JSON_TABLE expression.
+// The document is evaluated at the vtgate, using the bind variables coming
+// from the tables before the JSON_TABLE in the FROM clause.
+type JSONTable struct {
+	// JSONTable does not take inputs
+	noInputs
+
+	// JSONTable does not need to work inside a tx
+	noTxNeeded
+
+	// Doc is the JSON document the rows are extracted from
+	Doc evalengine.Expr
+	// Path describes where the rows are found in the document, and the columns they produce
+	Path *JSONTablePath
+	// Fields is the field info for the result.
+	Fields []*querypb.Field
+	// Cols contains the offsets of the JSON_TABLE columns to return.
+	Cols []int
+	// Width is the number of columns defined in the JSON_TABLE, including the nested ones.
+	Width int
+}
+
+// JSONTablePath contains the rows found at a path of a JSON_TABLE,
+// the columns they produce and the paths nested under them.
+type JSONTablePath struct {
+	Path    *json.Path
+	Columns []*JSONTableColumn
+	Nested  []*JSONTablePath
+}
+
+// JSONTableColumnKind is the kind of a column defined in a JSON_TABLE
+type JSONTableColumnKind int
+
+const (
+	// JSONTableOrdinality is a FOR ORDINALITY column
+	JSONTableOrdinality = JSONTableColumnKind(iota)
+	// JSONTableValue is a PATH column
+	JSONTableValue
+	// JSONTableExists is an EXISTS PATH column
+	JSONTableExists
+)
+
+// JSONTableColumn is a column defined in a JSON_TABLE
+type JSONTableColumn struct {
+	Name string
+	Kind JSONTableColumnKind
+	// Offset is the position of the column among all the columns of the JSON_TABLE
+	Offset int
+	Type   sqltypes.Type
+	Path   *json.Path
+	// Cast converts the value found at Path to the type of the column. The value is
+	// passed as the first column of the row. It is nil for columns of type JSON.
+	Cast    evalengine.Expr
+	OnEmpty JSONTableResponse
+	OnError JSONTableResponse
+}
+
+// JSONTableResponse is what a column produces when its path is not found in a row (ON EMPTY),
+// or when the value found can't be stored in the column (ON ERROR).
+type JSONTableResponse struct {
+	// Error is true when the query must fail
+	Error bool
+	// Default is the value used instead, or nil to use NULL
+	Default *json.Value
+}
+
+// RouteType returns a description of the query routing type used by the primitive
+func (jt *JSONTable) RouteType() string {
+	return "JSONTable"
+}
+
+// GetKeyspaceName specifies the Keyspace that this primitive routes to.
+func (jt *JSONTable) GetKeyspaceName() string {
+	return ""
+}
+
+// GetTableName specifies the table that this primitive routes to.
+func (jt *JSONTable) GetTableName() string {
+	return ""
+}
+
+// TryExecute performs a non-streaming exec.
+func (jt *JSONTable) TryExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool) (*sqltypes.Result, error) {
+	return jt.rows(ctx, vcursor, bindVars)
+}
+
+// TryStreamExecute performs a streaming exec.
+func (jt *JSONTable) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool, callback func(*sqltypes.Result) error) error {
+	r, err := jt.rows(ctx, vcursor, bindVars)
+	if err != nil {
+		return err
+	}
+	if err := callback(r.Metadata()); err != nil {
+		return err
+	}
+	return callback(&sqltypes.Result{Rows: r.Rows})
+}
+
+// GetFields fetches the field info.
+func (jt *JSONTable) GetFields(context.Context, VCursor, map[string]*querypb.BindVariable) (*sqltypes.Result, error) {
+	return &sqltypes.Result{Fields: jt.Fields}, nil
+}
+
+func (jt *JSONTable) rows(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable) (*sqltypes.Result, error) {
+	env := evalengine.NewExpressionEnv(ctx, bindVars, vcursor)
+	result := &sqltypes.Result{Fields: jt.Fields}
+
+	res, err := env.Evaluate(jt.Doc)
+	if err != nil {
+		return nil, err
+	}
+	value := res.Value(vcursor.ConnCollation())
+	if value.IsNull() {
+		return result, nil
+	}
+	var p json.Parser
+	doc, err := p.ParseBytes(value.Raw())
+	if err != nil {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "Invalid JSON text in argument 1 to function json_table: %v", err)
+	}
+
+	tr := &jsonTableRows{
+		env:       env,
+		collation: vcursor.ConnCollation(),
+		row:       make(sqltypes.Row, jt.Width),
+	}
+	for i := range tr.row {
+		tr.row[i] = sqltypes.NULL
+	}
+	if _, err := tr.produce(jt.Path, doc); err != nil {
+		return nil, err
+	}
+
+	for _, row := range tr.rows {
+		out := make(sqltypes.Row, 0, len(jt.Cols))
+		for _, col := range jt.Cols {
+			out = append(out, row[col])
+		}
+		result.Rows = append(result.Rows, out)
+	}
+	return result, nil
+}
+
+// jsonTableRows produces the rows of a JSON_TABLE. Rows from NESTED PATH clauses are
+// joined to the row they are nested in, like a LEFT JOIN, and the rows of sibling
+// NESTED PATH clauses are produced one after the other.
+type jsonTableRows struct {
+	env       *evalengine.ExpressionEnv
+	collation collations.ID
+	row       sqltypes.Row
+	rows      []sqltypes.Row
+}
+
+// produce adds the rows found at path inside doc, and returns how many rows were added
+func (tr *jsonTableRows) produce(path *JSONTablePath, doc *json.Value) (int, error) {
+	var matches []*json.Value
+	path.Path.Match(doc, true, func(value *json.Value) {
+		matches = append(matches, value)
+	})
+
+	before := len(tr.rows)
+	for i, match := range matches {
+		for _, col := range path.Columns {
+			value, err := tr.value(col, match, i+1)
+			if err != nil {
+				return 0, err
+			}
+			tr.row[col.Offset] = value
+		}
+
+		nested := 0
+		for _, np := range path.Nested {
+			n, err := tr.produce(np, match)
+			if err != nil {
+				return 0, err
+			}
+			nested += n
+			clearJSONTablePath(np, tr.row)
+		}
+		if nested == 0 {
+			tr.rows = append(tr.rows, slices.Clone(tr.row))
+		}
+	}
+	return len(tr.rows) - before, nil
+}
+
+func clearJSONTablePath(path *JSONTablePath, row sqltypes.Row) {
+	for _, col := range path.Columns {
+		row[col.Offset] = sqltypes.NULL
+	}
+	for _, np := range path.Nested {
+		clearJSONTablePath(np, row)
+	}
+}
+
+func (tr *jsonTableRows) value(col *JSONTableColumn, node *json.Value, ordinal int) (sqltypes.Value, error) {
+	switch col.Kind {
+	case JSONTableOrdinality:
+		return sqltypes.NewUint32(uint32(ordinal)), nil
+	case JSONTableExists:
+		exists := false
+		col.Path.Match(node, true, func(*json.Value) { exists = true })
+		if exists {
+			return sqltypes.MakeTrusted(col.Type, []byte("1")), nil
+		}
+		return sqltypes.MakeTrusted(col.Type, []byte("0")), nil
+	}
+
+	var matches []*json.Value
+	col.Path.Match(node, true, func(value *json.Value) {
+		matches = append(matches, value)
+	})
+	switch len(matches) {
+	case 0:
+		return tr.response(col, col.OnEmpty, func() error {
+			return vterrors.NewErrorf(vtrpcpb.Code_INVALID_ARGUMENT, vterrors.WrongValueForVar, "Missing value for JSON_TABLE column '%s'", col.Name)
+		})
+	case 1:
+		value, err := tr.convert(col, matches[0])
+		if err != nil {
+			return tr.response(col, col.OnError, func() error { return err })
+		}
+		return value, nil
+	default:
+		if col.Cast == nil {
+			// JSON columns store all the values found, like JSON_EXTRACT does
+			return tr.convert(col, json.NewArray(matches))
+		}
+		return tr.response(col, col.OnError, func() error {
+			return vterrors.NewErrorf(vtrpcpb.Code_INVALID_ARGUMENT, vterrors.WrongValueForVar, "Can't store an array or an object in the scalar column '%s' of JSON_TABLE", col.Name)
+		})
+	}
+}
+
+// response returns the value of the column for the ON EMPTY or ON ERROR clause
+func (tr *jsonTableRows) response(col *JSONTableColumn, resp JSONTableResponse, err func() error) (sqltypes.Value, error) {
+	switch {
+	case resp.Error:
+		return sqltypes.NULL, err()
+	case resp.Default != nil:
+		return tr.convert(col, resp.Default)
+	default:
+		return sqltypes.NULL, nil
+	}
+}
+
+// convert converts a JSON value to the type of the column
+func (tr *jsonTableRows) convert(col *JSONTableColumn, value *json.Value) (sqltypes.Value, error) {
+	raw := sqltypes.MakeTrusted(sqltypes.TypeJSON, value.MarshalTo(nil))
+	if col.Cast == nil {
+		return raw, nil
+	}
+	switch value.Type() {
+	case json.TypeNull:
+		return sqltypes.NULL, nil
+	case json.TypeObject, json.TypeArray:
+		return sqltypes.NULL, vterrors.NewErrorf(vtrpcpb.Code_INVALID_ARGUMENT, vterrors.WrongValueForVar, "Can't store an array or an object in the scalar column '%s' of JSON_TABLE", col.Name)
+	}
+
+	tr.env.Row = sqltypes.Row{raw}
+	res, err := tr.env.Evaluate(col.Cast)
+	if err != nil {
+		return sqltypes.NULL, err
+	}
+	converted := res.Value(tr.collation)
+	if converted.IsNull() {
+		return converted, nil
+	}
+	// the cast produces the values in the representation of the column, but its type can be wider
+	return sqltypes.MakeTrusted(col.Type, converted.Raw()), nil
+}
+
+func (jt *JSONTable) description() PrimitiveDescription {
+	fields := map[string]string{}
+	for _, field := range jt.Fields {
+		fields[field.Name] = field.Type.String()
+	}
+
+	return PrimitiveDescription{
+		OperatorType: "JSONTable",
+		Other: map[string]any{
+			"Fields":  fields,
+			"Columns": jt.Cols,
+			"Doc":     sqlparser.String(jt.Doc),
+			"Path":    jt.Path.describe(),
+		},
+	}
+}
+
+func (jtp *JSONTablePath) describe() map[string]any {
+	var columns []string
+	for _, col := range jtp.Columns {
+		desc := col.Name + ":" + strconv.Itoa(col.Offset)
+		switch col.Kind {
+		case JSONTableOrdinality:
+			desc += " for ordinality"
+		case JSONTableValue:
+			desc += " path '" + col.Path.String() + "'"
+		case JSONTableExists:
+			desc += " exists path '" + col.Path.String() + "'"
+		}
+		columns = append(columns, desc)
+	}
+	desc := map[string]any{
+		"Path":    jtp.Path.String(),
+		"Columns": columns,
+	}
+	if len(jtp.Nested) > 0 {
+		var nested []map[string]any
+		for _, np := range jtp.Nested {
+			nested = append(nested, np.describe())
+		}
+		desc["Nested"] = nested
+	}
+	return desc
+}
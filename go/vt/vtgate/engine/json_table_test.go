@@ -0,0 +1,231 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/mysql/json"
+	"vitess.io/vitess/go/sqltypes"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtenv"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+)
+
+func jsonTableTestExpr(t *testing.T, expr sqlparser.Expr) evalengine.Expr {
+	e, err := evalengine.Translate(expr, &evalengine.Config{
+		Environment: vtenv.NewTestEnv(),
+		Collation:   collations.MySQL8().DefaultConnectionCharset(),
+		ResolveType: func(sqlparser.Expr) (evalengine.Type, bool) {
+			return evalengine.NewType(sqltypes.TypeJSON, collations.CollationBinaryID), true
+		},
+	})
+	require.NoError(t, err)
+	return e
+}
+
+func jsonTableTestCast(t *testing.T, typ string) evalengine.Expr {
+	return jsonTableTestExpr(t, &sqlparser.CastExpr{
+		Expr: &sqlparser.JSONUnquoteExpr{JSONValue: sqlparser.NewOffset(0, nil)},
+		Type: &sqlparser.ConvertType{Type: typ},
+	})
+}
+
+func jsonTableTestPath(t *testing.T, path string) *json.Path {
+	var p json.PathParser
+	jp, err := p.ParseBytes([]byte(path))
+	require.NoError(t, err)
+	return jp
+}
+
+func jsonTableTestValue(t *testing.T, doc string) *json.Value {
+	var p json.Parser
+	v, err := p.ParseBytes([]byte(doc))
+	require.NoError(t, err)
+	return v
+}
+
+func TestJSONTable(t *testing.T) {
+	// SELECT * FROM JSON_TABLE(:doc, '$[*]' COLUMNS(
+	//   rowid FOR ORDINALITY,
+	//   ac VARCHAR(100) PATH '$.a' DEFAULT '111' ON EMPTY DEFAULT '999' ON ERROR,
+	//   aj JSON PATH '$.a' DEFAULT '{"x": 333}' ON EMPTY,
+	//   bx INT EXISTS PATH '$.b')) AS tt
+	jt := &JSONTable{
+		Doc: jsonTableTestExpr(t, &sqlparser.CastExpr{Expr: sqlparser.NewArgument("doc"), Type: &sqlparser.ConvertType{Type: "JSON"}}),
+		Path: &JSONTablePath{
+			Path: jsonTableTestPath(t, "$[*]"),
+			Columns: []*JSONTableColumn{{
+				Name:   "rowid",
+				Kind:   JSONTableOrdinality,
+				Offset: 0,
+				Type:   sqltypes.Uint32,
+			}, {
+				Name:    "ac",
+				Kind:    JSONTableValue,
+				Offset:  1,
+				Type:    sqltypes.VarChar,
+				Path:    jsonTableTestPath(t, "$.a"),
+				Cast:    jsonTableTestCast(t, "CHAR"),
+				OnEmpty: JSONTableResponse{Default: jsonTableTestValue(t, "111")},
+				OnError: JSONTableResponse{Default: jsonTableTestValue(t, "999")},
+			}, {
+				Name:    "aj",
+				Kind:    JSONTableValue,
+				Offset:  2,
+				Type:    sqltypes.TypeJSON,
+				Path:    jsonTableTestPath(t, "$.a"),
+				OnEmpty: JSONTableResponse{Default: jsonTableTestValue(t, `{"x": 333}`)},
+			}, {
+				Name:   "bx",
+				Kind:   JSONTableExists,
+				Offset: 3,
+				Type:   sqltypes.Int32,
+				Path:   jsonTableTestPath(t, "$.b"),
+			}},
+		},
+		Fields: sqltypes.MakeTestFields("rowid|ac|aj|bx", "uint32|varchar|json|int32"),
+		Cols:   []int{0, 1, 2, 3},
+		Width:  4,
+	}
+
+	bv := map[string]*querypb.BindVariable{
+		"doc": sqltypes.StringBindVariable(`[{"a":"3"},{"a":2},{"b":1},{"a":0},{"a":[1,2]}]`),
+	}
+	qr, err := jt.TryExecute(context.Background(), &noopVCursor{}, bv, false)
+	require.NoError(t, err)
+	want := `[[UINT32(1) VARCHAR("3") JSON("\"3\"") INT32(0)] ` +
+		`[UINT32(2) VARCHAR("2") JSON("2") INT32(0)] ` +
+		`[UINT32(3) VARCHAR("111") JSON("{\"x\": 333}") INT32(1)] ` +
+		`[UINT32(4) VARCHAR("0") JSON("0") INT32(0)] ` +
+		`[UINT32(5) VARCHAR("999") JSON("[1, 2]") INT32(0)]]`
+	assert.Equal(t, want, fmt.Sprintf("%v", qr.Rows))
+
+	qr, err = wrapStreamExecute(jt, &noopVCursor{}, bv, true)
+	require.NoError(t, err)
+	assert.Equal(t, want, fmt.Sprintf("%v", qr.Rows))
+
+	// a NULL document produces no rows
+	qr, err = jt.TryExecute(context.Background(), &noopVCursor{}, map[string]*querypb.BindVariable{"doc": sqltypes.NullBindVariable}, false)
+	require.NoError(t, err)
+	assert.Empty(t, qr.Rows)
+
+	// only the requested columns are returned
+	jt.Cols = []int{3, 1}
+	jt.Fields = sqltypes.MakeTestFields("bx|ac", "int32|varchar")
+	qr, err = jt.TryExecute(context.Background(), &noopVCursor{}, bv, false)
+	require.NoError(t, err)
+	assert.Equal(t, `[[INT32(0) VARCHAR("3")] [INT32(0) VARCHAR("2")] [INT32(1) VARCHAR("111")] [INT32(0) VARCHAR("0")] [INT32(0) VARCHAR("999")]]`, fmt.Sprintf("%v", qr.Rows))
+}
+
+func TestJSONTableNestedPaths(t *testing.T) {
+	// SELECT * FROM JSON_TABLE(:doc, '$[*]' COLUMNS(
+	//   a INT PATH '$.a',
+	//   NESTED PATH '$.b[*]' COLUMNS (b1 INT PATH '$'),
+	//   NESTED PATH '$.b[*]' COLUMNS (b2 INT PATH '$'))) AS jt
+	nested := func(name string, offset int) *JSONTablePath {
+		return &JSONTablePath{
+			Path: jsonTableTestPath(t, "$.b[*]"),
+			Columns: []*JSONTableColumn{{
+				Name:   name,
+				Kind:   JSONTableValue,
+				Offset: offset,
+				Type:   sqltypes.Int32,
+				Path:   jsonTableTestPath(t, "$"),
+				Cast:   jsonTableTestCast(t, "SIGNED"),
+			}},
+		}
+	}
+	jt := &JSONTable{
+		Doc: jsonTableTestExpr(t, &sqlparser.CastExpr{Expr: sqlparser.NewArgument("doc"), Type: &sqlparser.ConvertType{Type: "JSON"}}),
+		Path: &JSONTablePath{
+			Path: jsonTableTestPath(t, "$[*]"),
+			Columns: []*JSONTableColumn{{
+				Name:   "a",
+				Kind:   JSONTableValue,
+				Offset: 0,
+				Type:   sqltypes.Int32,
+				Path:   jsonTableTestPath(t, "$.a"),
+				Cast:   jsonTableTestCast(t, "SIGNED"),
+			}},
+			Nested: []*JSONTablePath{nested("b1", 1), nested("b2", 2)},
+		},
+		Fields: sqltypes.MakeTestFields("a|b1|b2", "int32|int32|int32"),
+		Cols:   []int{0, 1, 2},
+		Width:  3,
+	}
+
+	bv := map[string]*querypb.BindVariable{
+		"doc": sqltypes.StringBindVariable(`[{"a": 1, "b": [11,111]}, {"a": 2, "b": [22]}, {"a": 3}]`),
+	}
+	qr, err := jt.TryExecute(context.Background(), &noopVCursor{}, bv, false)
+	require.NoError(t, err)
+	assert.Equal(t, "[[INT32(1) INT32(11) NULL] [INT32(1) INT32(111) NULL] [INT32(1) NULL INT32(11)] [INT32(1) NULL INT32(111)] "+
+		"[INT32(2) INT32(22) NULL] [INT32(2) NULL INT32(22)] "+
+		"[INT32(3) NULL NULL]]", fmt.Sprintf("%v", qr.Rows))
+}
+
+func TestJSONTableErrors(t *testing.T) {
+	column := &JSONTableColumn{
+		Name:    "c1",
+		Kind:    JSONTableValue,
+		Offset:  0,
+		Type:    sqltypes.Int32,
+		Path:    jsonTableTestPath(t, "$.c1"),
+		Cast:    jsonTableTestCast(t, "SIGNED"),
+		OnEmpty: JSONTableResponse{Error: true},
+		OnError: JSONTableResponse{Error: true},
+	}
+	jt := &JSONTable{
+		Doc: jsonTableTestExpr(t, &sqlparser.CastExpr{Expr: sqlparser.NewArgument("doc"), Type: &sqlparser.ConvertType{Type: "JSON"}}),
+		Path: &JSONTablePath{
+			Path:    jsonTableTestPath(t, "$[*]"),
+			Columns: []*JSONTableColumn{column},
+		},
+		Fields: sqltypes.MakeTestFields("c1", "int32"),
+		Cols:   []int{0},
+		Width:  1,
+	}
+
+	tcases := []struct {
+		doc string
+		err string
+	}{{
+		doc: `[{"c1": 1}, {"c2": 2}]`,
+		err: "Missing value for JSON_TABLE column 'c1'",
+	}, {
+		doc: `[{"c1": [1, 2]}]`,
+		err: "Can't store an array or an object in the scalar column 'c1' of JSON_TABLE",
+	}, {
+		doc: `[{"c1": 1}`,
+		err: "cannot parse JSON",
+	}}
+	for _, tcase := range tcases {
+		t.Run(tcase.doc, func(t *testing.T) {
+			bv := map[string]*querypb.BindVariable{"doc": sqltypes.StringBindVariable(tcase.doc)}
+			_, err := jt.TryExecute(context.Background(), &noopVCursor{}, bv, false)
+			require.ErrorContains(t, err, tcase.err)
+		})
+	}
+}
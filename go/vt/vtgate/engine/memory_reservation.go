@@ -0,0 +1,70 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"vitess.io/vitess/go/sqltypes"
+)
+
+// memoryReservation keeps track of the memory a primitive reserved from
+// the memory limit of its query for the rows it holds, so that it can be
+// released once the primitive is done with them.
+type memoryReservation struct {
+	vcursor VCursor
+	size    int64
+}
+
+func newMemoryReservation(vcursor VCursor) *memoryReservation {
+	return &memoryReservation{vcursor: vcursor}
+}
+
+// reserveRows reserves the memory used by the given rows. The memory is
+// reserved even if an error is returned, and is freed by release.
+func (mr *memoryReservation) reserveRows(rows ...sqltypes.Row) error {
+	return mr.reserve(rowsMemorySize(rows))
+}
+
+// reserve reserves the given size of memory. The memory is reserved even
+// if an error is returned, and is freed by release.
+func (mr *memoryReservation) reserve(size int64) error {
+	mr.size += size
+	return mr.vcursor.ReserveMemory(size)
+}
+
+// release releases all the memory reserved.
+func (mr *memoryReservation) release() {
+	mr.vcursor.ReleaseMemory(mr.size)
+	mr.size = 0
+}
+
+// rowsMemorySize returns an estimate of the memory used by the rows.
+func rowsMemorySize(rows []sqltypes.Row) int64 {
+	var size int64
+	for _, row := range rows {
+		for i := range row {
+			size += row[i].CachedSize(true)
+		}
+	}
+	return size
+}
+
+// shouldSpill returns true if the reserved memory is over the spill
+// threshold, i.e. if the primitive should spill its rows to disk.
+func (mr *memoryReservation) shouldSpill() bool {
+	threshold := mr.vcursor.SpillThreshold()
+	return threshold > 0 && mr.size > threshold
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	"vitess.io/vitess/go/vt/vtgate/engine/opcode"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+)
+
+func TestPrimitivesMaxMemory(t *testing.T) {
+	input := func() *fakePrimitive {
+		return &fakePrimitive{
+			results: []*sqltypes.Result{sqltypes.MakeTestResult(
+				sqltypes.MakeTestFields("col|val", "varbinary|int64"),
+				"a|1",
+				"a|2",
+				"b|3",
+				"c|4",
+				"c|5",
+			)},
+		}
+	}
+	primitives := []struct {
+		name      string
+		primitive func() Primitive
+		// streamed is set when the primitive does not hold the input
+		// rows while streaming them.
+		streamed bool
+	}{{
+		name: "MemorySort",
+		primitive: func() Primitive {
+			return &MemorySort{
+				OrderBy: []evalengine.OrderByParams{{Col: 1, WeightStringCol: -1}},
+				Input:   input(),
+			}
+		},
+	}, {
+		name: "HashJoin",
+		primitive: func() Primitive {
+			return &HashJoin{
+				Opcode:         InnerJoin,
+				Left:           input(),
+				Right:          input(),
+				Cols:           []int{-1, 1},
+				LHSKey:         0,
+				RHSKey:         0,
+				Collation:      collations.CollationBinaryID,
+				ComparisonType: querypb.Type_VARBINARY,
+				CollationEnv:   collations.MySQL8(),
+			}
+		},
+	}, {
+		name: "OrderedAggregate",
+		primitive: func() Primitive {
+			return &OrderedAggregate{
+				Aggregates:  []*AggregateParams{NewAggregateParam(opcode.AggregateSum, 1, "", collations.MySQL8())},
+				GroupByKeys: []*GroupByParams{{KeyCol: 0}},
+				Input:       input(),
+			}
+		},
+		streamed: true,
+	}, {
+		name: "Distinct",
+		primitive: func() Primitive {
+			return &Distinct{
+				Source:    input(),
+				CheckCols: []CheckCol{{Col: 0, Type: evalengine.NewType(sqltypes.VarBinary, collations.CollationBinaryID)}},
+			}
+		},
+	}}
+
+	for _, p := range primitives {
+		t.Run(p.name, func(t *testing.T) {
+			vc := &loggingVCursor{maxMemory: 1 << 20}
+			_, err := p.primitive().TryExecute(context.Background(), vc, nil, true)
+			require.NoError(t, err)
+			assert.Zero(t, vc.memory)
+
+			vc = &loggingVCursor{maxMemory: 100}
+			_, err = p.primitive().TryExecute(context.Background(), vc, nil, true)
+			assert.EqualError(t, err, "VT08001: query aborted: memory used in vtgate exceeded the limit of 100 bytes")
+			assert.Zero(t, vc.memory)
+		})
+		t.Run("Streaming "+p.name, func(t *testing.T) {
+			vc := &loggingVCursor{maxMemory: 1 << 20}
+			_, err := wrapStreamExecute(p.primitive(), vc, nil, true)
+			require.NoError(t, err)
+			assert.Zero(t, vc.memory)
+
+			vc = &loggingVCursor{maxMemory: 100}
+			_, err = wrapStreamExecute(p.primitive(), vc, nil, true)
+			if p.streamed {
+				require.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, "VT08001: query aborted: memory used in vtgate exceeded the limit of 100 bytes")
+			assert.Zero(t, vc.memory)
+		})
+	}
+}
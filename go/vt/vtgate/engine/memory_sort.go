@@ -19,6 +19,7 @@ package engine
 import (
 	"context"
 	"fmt"
+	"io"
 	"math"
 	"reflect"
 	"strconv"
@@ -66,6 +67,10 @@ func (ms *MemorySort) SetTruncateColumnCount(count int) {
 
 // TryExecute satisfies the Primitive interface.
 func (ms *MemorySort) TryExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool) (*sqltypes.Result, error) {
+	if spillsNonStreaming(vcursor) {
+		return executeStreamed(ctx, vcursor, ms, bindVars, wantfields)
+	}
+
 	count, err := ms.fetchCount(ctx, vcursor, bindVars)
 	if err != nil {
 		return nil, err
@@ -76,6 +81,12 @@ func (ms *MemorySort) TryExecute(ctx context.Context, vcursor VCursor, bindVars
 		return nil, err
 	}
 
+	mem := newMemoryReservation(vcursor)
+	defer mem.release()
+	if err := mem.reserveRows(result.Rows...); err != nil {
+		return nil, err
+	}
+
 	if err = ms.OrderBy.SortResult(result); err != nil {
 		return nil, err
 	}
@@ -103,6 +114,15 @@ func (ms *MemorySort) TryStreamExecute(ctx context.Context, vcursor VCursor, bin
 		Limit:   count,
 	}
 
+	mem := newMemoryReservation(vcursor)
+	defer mem.release()
+
+	// runs are the sorted rows spilled to disk
+	var runs []*spillFile
+	defer func() {
+		closeSpillFiles(runs)
+	}()
+
 	var mu sync.Mutex
 	err = vcursor.StreamExecutePrimitive(ctx, ms.Input, bindVars, wantfields, func(qr *sqltypes.Result) error {
 		mu.Lock()
@@ -112,9 +132,34 @@ func (ms *MemorySort) TryStreamExecute(ctx context.Context, vcursor VCursor, bin
 				return err
 			}
 		}
+		held := sorter.Len()
 		for _, row := range qr.Rows {
 			sorter.Push(row)
 		}
+		// Once the sorter holds as many rows as the limit, the rows it
+		// keeps replace the ones it drops, so only the new rows it holds
+		// need more memory.
+		if added := sorter.Len() - held; added > 0 {
+			if err := mem.reserveRows(qr.Rows[:added]...); err != nil {
+				return err
+			}
+		}
+		if mem.shouldSpill() {
+			run, err := newSpillFile(vcursor.SpillDirectory())
+			if err != nil {
+				return err
+			}
+			runs = append(runs, run)
+			if err := run.writeRows(sorter.Sorted()); err != nil {
+				return err
+			}
+			spills.Add("MemorySort", 1)
+			sorter = &evalengine.Sorter{
+				Compare: ms.OrderBy,
+				Limit:   count,
+			}
+			mem.release()
+		}
 		if vcursor.ExceedsMaxMemoryRows(sorter.Len()) {
 			return fmt.Errorf("in-memory row count exceeded allowed limit of %d", vcursor.MaxMemoryRows())
 		}
@@ -123,7 +168,73 @@ func (ms *MemorySort) TryStreamExecute(ctx context.Context, vcursor VCursor, bin
 	if err != nil {
 		return err
 	}
-	return cb(&sqltypes.Result{Rows: sorter.Sorted()})
+	if len(runs) == 0 {
+		return cb(&sqltypes.Result{Rows: sorter.Sorted()})
+	}
+	return ms.mergeRuns(runs, sorter.Sorted(), count, cb)
+}
+
+// mergeRuns merges the sorted runs spilled to disk with the sorted rows
+// held in memory, and sends the first count rows in batches.
+func (ms *MemorySort) mergeRuns(runs []*spillFile, held []sqltypes.Row, count int, callback func(*sqltypes.Result) error) error {
+	readers := make([]*spillReader, len(runs))
+	// the rows held in memory are merged as the last run
+	nextRow := func(source int) (sqltypes.Row, error) {
+		if source == len(runs) {
+			if len(held) == 0 {
+				return nil, io.EOF
+			}
+			row := held[0]
+			held = held[1:]
+			return row, nil
+		}
+		return readers[source].next()
+	}
+
+	merger := &evalengine.Merger{Compare: ms.OrderBy}
+	for source := 0; source <= len(runs); source++ {
+		if source < len(runs) {
+			reader, err := runs[source].reader()
+			if err != nil {
+				return err
+			}
+			readers[source] = reader
+		}
+		row, err := nextRow(source)
+		if err == io.EOF {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		merger.Push(row, source)
+	}
+	merger.Init()
+
+	var rows []sqltypes.Row
+	for sent := 0; merger.Len() > 0 && sent < count; sent++ {
+		row, source := merger.Pop()
+		rows = append(rows, row)
+		if len(rows) == spillBatchSize {
+			if err := callback(&sqltypes.Result{Rows: rows}); err != nil {
+				return err
+			}
+			rows = nil
+		}
+
+		next, err := nextRow(source)
+		if err == io.EOF {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		merger.Push(next, source)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return callback(&sqltypes.Result{Rows: rows})
 }
 
 // GetFields satisfies the Primitive interface.
@@ -103,6 +103,10 @@ func (oa *OrderedAggregate) SetTruncateColumnCount(count int) {
 
 // TryExecute is a Primitive function.
 func (oa *OrderedAggregate) TryExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, _ bool) (*sqltypes.Result, error) {
+	if spillsNonStreaming(vcursor) {
+		// the streaming execution only holds the rows of the current group
+		return executeStreamed(ctx, vcursor, oa, bindVars, true)
+	}
 	qr, err := oa.execute(ctx, vcursor, bindVars)
 	if err != nil {
 		return nil, err
@@ -149,6 +153,12 @@ func (oa *OrderedAggregate) execute(ctx context.Context, vcursor VCursor, bindVa
 	if err != nil {
 		return nil, err
 	}
+
+	mem := newMemoryReservation(vcursor)
+	defer mem.release()
+	if err := mem.reserveRows(result.Rows...); err != nil {
+		return nil, err
+	}
 	if len(oa.Aggregates) == 0 {
 		return oa.executeGroupBy(result)
 	}
@@ -1164,3 +1164,146 @@ func TestGroupConcat(t *testing.T) {
 		})
 	}
 }
+
+// TestCountDistinctWithArgs tests count distinct on multiple columns, where the duplicates are found by hashing the arguments.
+func TestCountDistinctWithArgs(t *testing.T) {
+	fields := sqltypes.MakeTestFields(
+		"c1|c2|c3",
+		"int64|int64|varchar",
+	)
+	fp := &fakePrimitive{
+		results: []*sqltypes.Result{sqltypes.MakeTestResult(
+			fields,
+			"10|1|a",
+			"10|2|a",
+			"10|1|a",
+			"10|1|A",
+			"10|null|b",
+			"20|1|null",
+			"30|3|c",
+			"30|4|c",
+			"30|3|c",
+		)},
+	}
+
+	aggr := NewAggregateParam(AggregateCountDistinct, 1, "count(distinct c2, c3)", collations.MySQL8())
+	aggr.Args = []CheckCol{
+		{Col: 1, Type: evalengine.NewType(sqltypes.Int64, collations.CollationBinaryID)},
+		{Col: 2, Type: evalengine.NewType(sqltypes.VarChar, collations.CollationUtf8mb4ID)},
+	}
+	oa := &OrderedAggregate{
+		Aggregates:          []*AggregateParams{aggr},
+		GroupByKeys:         []*GroupByParams{{KeyCol: 0}},
+		TruncateColumnCount: 2,
+		Input:               fp,
+	}
+
+	want := sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields(
+			"c1|count(distinct c2, c3)",
+			"int64|int64",
+		),
+		`10|2`,
+		`20|0`,
+		`30|2`,
+	)
+
+	qr, err := oa.TryExecute(context.Background(), &noopVCursor{}, nil, false)
+	require.NoError(t, err)
+	utils.MustMatch(t, want, qr)
+
+	fp.rewind()
+	results := &sqltypes.Result{}
+	err = oa.TryStreamExecute(context.Background(), &noopVCursor{}, nil, true, func(qr *sqltypes.Result) error {
+		if qr.Fields != nil {
+			results.Fields = qr.Fields
+		}
+		results.Rows = append(results.Rows, qr.Rows...)
+		return nil
+	})
+	require.NoError(t, err)
+	utils.MustMatch(t, want, results)
+}
+
+// TestGroupConcatWithArgs tests group_concat with full evaluation of its arguments on engine.
+func TestGroupConcatWithArgs(t *testing.T) {
+	fields := sqltypes.MakeTestFields(
+		"c1|c2|c3|c4",
+		"int64|varchar|varchar|int64",
+	)
+	input := sqltypes.MakeTestResult(fields,
+		"10|a|x|3",
+		"10|b|y|1",
+		"10|a|x|2",
+		"10|null|z|0",
+		"20|c|z|5",
+		"20|d|null|4",
+		"30|null|null|1",
+	)
+	args := []CheckCol{
+		{Col: 1, Type: evalengine.NewType(sqltypes.VarChar, collations.CollationUtf8mb4ID)},
+		{Col: 2, Type: evalengine.NewType(sqltypes.VarChar, collations.CollationUtf8mb4ID)},
+	}
+	orderBy := evalengine.Comparison{{
+		Col:             3,
+		WeightStringCol: -1,
+		Type:            evalengine.NewType(sqltypes.Int64, collations.CollationBinaryID),
+	}}
+	separator := ";"
+
+	tcases := []struct {
+		name   string
+		params func(*AggregateParams)
+		exp    []string
+	}{{
+		name:   "multiple arguments",
+		params: func(ap *AggregateParams) {},
+		exp:    []string{`10|ax,by,ax`, `20|cz`, `30|null`},
+	}, {
+		name:   "distinct",
+		params: func(ap *AggregateParams) { ap.Distinct = true },
+		exp:    []string{`10|ax,by`, `20|cz`, `30|null`},
+	}, {
+		name:   "order by",
+		params: func(ap *AggregateParams) { ap.OrderBy = orderBy },
+		exp:    []string{`10|by,ax,ax`, `20|cz`, `30|null`},
+	}, {
+		name: "distinct with order by and separator",
+		params: func(ap *AggregateParams) {
+			ap.Distinct = true
+			ap.OrderBy = evalengine.Comparison{{Col: 3, WeightStringCol: -1, Desc: true, Type: orderBy[0].Type}}
+			ap.Separator = &separator
+		},
+		exp: []string{`10|ax;by`, `20|cz`, `30|null`},
+	}}
+
+	for _, tcase := range tcases {
+		t.Run(tcase.name, func(t *testing.T) {
+			aggr := NewAggregateParam(AggregateGroupConcat, 1, "group_concat(c2, c3)", collations.MySQL8())
+			aggr.Args = args
+			tcase.params(aggr)
+
+			fp := &fakePrimitive{results: []*sqltypes.Result{input}}
+			oa := &OrderedAggregate{
+				Aggregates:          []*AggregateParams{aggr},
+				GroupByKeys:         []*GroupByParams{{KeyCol: 0}},
+				TruncateColumnCount: 2,
+				Input:               fp,
+			}
+			want := sqltypes.MakeTestResult(sqltypes.MakeTestFields("c1|group_concat(c2, c3)", "int64|text"), tcase.exp...)
+
+			qr, err := oa.TryExecute(context.Background(), &noopVCursor{}, nil, false)
+			require.NoError(t, err)
+			assert.Equal(t, want.Rows, qr.Rows)
+
+			fp.rewind()
+			results := &sqltypes.Result{}
+			err = oa.TryStreamExecute(context.Background(), &noopVCursor{}, nil, true, func(qr *sqltypes.Result) error {
+				results.Rows = append(results.Rows, qr.Rows...)
+				return nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, want.Rows, results.Rows)
+		})
+	}
+}
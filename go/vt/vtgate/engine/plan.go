@@ -34,6 +34,7 @@ import (
 type Plan struct {
 	Type         sqlparser.StatementType // The type of query we have
 	Original     string                  // Original is the original query.
+	Session      *PlanSession            // Session is the state of the session the plan was cached for
 	Instructions Primitive               // Instructions contains the instructions needed to fulfil the query.
 	BindVarNeeds *sqlparser.BindVarNeeds // Stores BindVars needed to be provided as part of expression rewriting
 	Warnings     []*query.QueryWarning   // Warnings that need to be yielded every time this query runs
@@ -47,6 +48,16 @@ type Plan struct {
 	Errors       uint64 // Total number of errors
 }
 
+// PlanSession is the state of the session a cached plan was built for: the
+// inputs of its cache key besides the query, and the session settings that
+// the planner reads.
+type PlanSession struct {
+	Target           string                              // Target is the target of the session
+	Collation        string                              // Collation is the name of the connection collation
+	PlannerVersion   query.ExecuteOptions_PlannerVersion // PlannerVersion is the planner version set on the session
+	ForeignKeyChecks *bool                               // ForeignKeyChecks is the foreign_key_checks state of the query
+}
+
 // AddStats updates the plan execution statistics
 func (p *Plan) AddStats(execCount uint64, execTime time.Duration, shardQueries, rowsAffected, rowsReturned, errors uint64) {
 	atomic.AddUint64(&p.ExecCount, execCount)
@@ -56,6 +56,31 @@ type (
 		// if the max memory rows override directive is set to true
 		ExceedsMaxMemoryRows(numRows int) bool
 
+		// ReserveMemory records that a primitive of the query holds size more
+		// bytes in memory. It returns an error if the memory held by the
+		// primitives of the query goes over the memory limit of the query.
+		ReserveMemory(size int64) error
+
+		// ReleaseMemory records that a primitive of the query released size
+		// bytes of memory it reserved.
+		ReleaseMemory(size int64)
+
+		// SpillThreshold returns the memory in bytes a primitive can hold
+		// before it spills its rows to disk. 0 means rows are not spilled.
+		SpillThreshold() int64
+
+		// SpillDirectory returns the directory rows are spilled to.
+		SpillDirectory() string
+
+		// SpillNonStreaming returns true if the non-streaming executions of
+		// the primitives that spill use their streaming execution, so that
+		// they can spill too.
+		SpillNonStreaming() bool
+
+		// CTEMaxRecursionDepth returns the maximum number of iterations
+		// a recursive common table expression is allowed to run for.
+		CTEMaxRecursionDepth() int
+
 		Execute(ctx context.Context, method string, query string, bindVars map[string]*querypb.BindVariable, rollbackOnError bool, co vtgatepb.CommitOrder) (*sqltypes.Result, error)
 		AutocommitApproval() bool
 
@@ -96,8 +121,14 @@ type (
 
 		InTransactionAndIsDML() bool
 
+		// InTransaction returns true if the session has already opened transaction or
+		// will start a transaction on the query execution.
+		InTransaction() bool
+
 		LookupRowLockShardSession() vtgatepb.CommitOrder
 
+		AddLookupCacheInvalidation(table, column string, ids []sqltypes.Value)
+
 		FindRoutedTable(tablename sqlparser.TableName) (*vindexes.Table, error)
 
 		// GetDBDDLPlugin gets the configured plugin for DROP/CREATE DATABASE
@@ -181,6 +212,8 @@ type (
 
 		// SetReadAfterWriteGTID sets the GTID that the user expects a replica to have caught up with before answering a query
 		SetReadAfterWriteGTID(string)
+		// SetSessionToken sets the session token whose positions the replicas must have caught up with before answering a query
+		SetSessionToken(string)
 		SetReadAfterWriteTimeout(float64)
 		SetSessionTrackGTIDs(bool)
 
@@ -212,6 +245,9 @@ type (
 		// SetQueryTimeout sets the query timeout
 		SetQueryTimeout(queryTimeout int64)
 
+		// SetMaxReplicaLag sets the maximum replication lag of the replicas the session reads from
+		SetMaxReplicaLag(maxReplicaLag time.Duration)
+
 		// InTransaction returns true if the session has already opened transaction or
 		// will start a transaction on the query execution.
 		InTransaction() bool
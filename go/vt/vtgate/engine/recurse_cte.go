@@ -0,0 +1,171 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"vitess.io/vitess/go/sqltypes"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	"vitess.io/vitess/go/vt/vterrors"
+)
+
+var _ Primitive = (*RecurseCTE)(nil)
+
+// RecurseCTE evaluates a recursive common table expression.
+// The Seed is executed once, and the Term is then executed once
+// for every row produced by the previous iteration, until an
+// iteration does not produce any rows.
+type RecurseCTE struct {
+	// Seed is the non-recursive part of the CTE.
+	Seed Primitive
+	// Term is the recursive part of the CTE. It is executed
+	// with the bind variables in Vars set from a row of the
+	// previous iteration.
+	Term Primitive
+
+	// Vars defines the bind variables that need to be built
+	// from a row of the previous iteration before invoking the Term.
+	Vars map[string]int
+}
+
+// TryExecute implements the Primitive interface
+func (r *RecurseCTE) TryExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool) (*sqltypes.Result, error) {
+	seed, err := vcursor.ExecutePrimitive(ctx, r.Seed, bindVars, wantfields)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &sqltypes.Result{Fields: seed.Fields, Rows: append([]sqltypes.Row(nil), seed.Rows...)}
+	rows := seed.Rows
+	for depth := 1; len(rows) > 0; depth++ {
+		if depth > vcursor.CTEMaxRecursionDepth() {
+			return nil, vterrors.VT09025(vcursor.CTEMaxRecursionDepth())
+		}
+		var newRows []sqltypes.Row
+		for _, row := range rows {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			rresult, err := vcursor.ExecutePrimitive(ctx, r.Term, combineVars(bindVars, r.rowVars(row)), false)
+			if err != nil {
+				return nil, err
+			}
+			newRows = append(newRows, rresult.Rows...)
+			res.Rows = append(res.Rows, rresult.Rows...)
+			if vcursor.ExceedsMaxMemoryRows(len(res.Rows)) {
+				return nil, fmt.Errorf("in-memory row count exceeded allowed limit of %d", vcursor.MaxMemoryRows())
+			}
+		}
+		rows = newRows
+	}
+	return res, nil
+}
+
+// TryStreamExecute implements the Primitive interface
+func (r *RecurseCTE) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool, callback func(*sqltypes.Result) error) error {
+	var rows []sqltypes.Row
+	// total is the number of rows of all the iterations, which is bounded
+	// like the rows of the non-streaming execution
+	var total int
+	err := vcursor.StreamExecutePrimitive(ctx, r.Seed, bindVars, wantfields, func(result *sqltypes.Result) error {
+		rows = append(rows, result.Rows...)
+		total += len(result.Rows)
+		if vcursor.ExceedsMaxMemoryRows(total) {
+			return fmt.Errorf("in-memory row count exceeded allowed limit of %d", vcursor.MaxMemoryRows())
+		}
+		return callback(result)
+	})
+	if err != nil {
+		return err
+	}
+
+	for depth := 1; len(rows) > 0; depth++ {
+		if depth > vcursor.CTEMaxRecursionDepth() {
+			return vterrors.VT09025(vcursor.CTEMaxRecursionDepth())
+		}
+		var newRows []sqltypes.Row
+		for _, row := range rows {
+			err := vcursor.StreamExecutePrimitive(ctx, r.Term, combineVars(bindVars, r.rowVars(row)), false, func(result *sqltypes.Result) error {
+				newRows = append(newRows, result.Rows...)
+				total += len(result.Rows)
+				if vcursor.ExceedsMaxMemoryRows(total) {
+					return fmt.Errorf("in-memory row count exceeded allowed limit of %d", vcursor.MaxMemoryRows())
+				}
+				return callback(&sqltypes.Result{Rows: result.Rows})
+			})
+			if err != nil {
+				return err
+			}
+		}
+		rows = newRows
+	}
+	return nil
+}
+
+func (r *RecurseCTE) rowVars(row sqltypes.Row) map[string]*querypb.BindVariable {
+	vars := make(map[string]*querypb.BindVariable, len(r.Vars))
+	for k, col := range r.Vars {
+		vars[k] = sqltypes.ValueBindVariable(row[col])
+	}
+	return vars
+}
+
+// GetFields implements the Primitive interface
+func (r *RecurseCTE) GetFields(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable) (*sqltypes.Result, error) {
+	return r.Seed.GetFields(ctx, vcursor, bindVars)
+}
+
+// RouteType implements the Primitive interface
+func (r *RecurseCTE) RouteType() string {
+	return "RecurseCTE"
+}
+
+// GetKeyspaceName implements the Primitive interface
+func (r *RecurseCTE) GetKeyspaceName() string {
+	if r.Seed.GetKeyspaceName() == r.Term.GetKeyspaceName() {
+		return r.Seed.GetKeyspaceName()
+	}
+	return r.Seed.GetKeyspaceName() + "_" + r.Term.GetKeyspaceName()
+}
+
+// GetTableName implements the Primitive interface
+func (r *RecurseCTE) GetTableName() string {
+	return r.Seed.GetTableName()
+}
+
+// Inputs implements the Primitive interface
+func (r *RecurseCTE) Inputs() ([]Primitive, []map[string]any) {
+	return []Primitive{r.Seed, r.Term}, nil
+}
+
+// NeedsTransaction implements the Primitive interface
+func (r *RecurseCTE) NeedsTransaction() bool {
+	return r.Seed.NeedsTransaction() || r.Term.NeedsTransaction()
+}
+
+func (r *RecurseCTE) description() PrimitiveDescription {
+	other := map[string]any{}
+	if len(r.Vars) > 0 {
+		other["JoinVars"] = orderedStringIntMap(r.Vars)
+	}
+	return PrimitiveDescription{
+		OperatorType: "RecurseCTE",
+		Other:        other,
+	}
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/sqltypes"
+)
+
+func newTestRecurseCTE() *RecurseCTE {
+	fields := sqltypes.MakeTestFields("id|parent", "int64|int64")
+	seed := &fakePrimitive{results: []*sqltypes.Result{
+		sqltypes.MakeTestResult(fields, "1|null"),
+	}}
+	term := &fakePrimitive{results: []*sqltypes.Result{
+		sqltypes.MakeTestResult(fields, "2|1", "3|1"),
+		sqltypes.MakeTestResult(fields, "4|2"),
+		sqltypes.MakeTestResult(fields),
+		sqltypes.MakeTestResult(fields),
+	}}
+	return &RecurseCTE{
+		Seed: seed,
+		Term: term,
+		Vars: map[string]int{"__recurse1_0": 0},
+	}
+}
+
+func TestRecurseCTE(t *testing.T) {
+	r := newTestRecurseCTE()
+	want := sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields("id|parent", "int64|int64"),
+		"1|null",
+		"2|1",
+		"3|1",
+		"4|2",
+	)
+
+	result, err := r.TryExecute(context.Background(), &noopVCursor{}, nil, true)
+	require.NoError(t, err)
+	expectResult(t, result, want)
+	r.Term.(*fakePrimitive).ExpectLog(t, []string{
+		`Execute __recurse1_0: type:INT64 value:"1" false`,
+		`Execute __recurse1_0: type:INT64 value:"2" false`,
+		`Execute __recurse1_0: type:INT64 value:"3" false`,
+		`Execute __recurse1_0: type:INT64 value:"4" false`,
+	})
+
+	r.Seed.(*fakePrimitive).rewind()
+	r.Term.(*fakePrimitive).rewind()
+	result, err = wrapStreamExecute(r, &noopVCursor{}, nil, true)
+	require.NoError(t, err)
+	expectResult(t, result, want)
+}
+
+func TestRecurseCTEMaxRecursionDepth(t *testing.T) {
+	saveDepth := testCTEMaxRecursionDepth
+	testCTEMaxRecursionDepth = 1
+	defer func() {
+		testCTEMaxRecursionDepth = saveDepth
+	}()
+
+	r := newTestRecurseCTE()
+	_, err := r.TryExecute(context.Background(), &noopVCursor{}, nil, true)
+	assert.EqualError(t, err, "VT09025: recursive query aborted after 1 iterations")
+
+	r.Seed.(*fakePrimitive).rewind()
+	r.Term.(*fakePrimitive).rewind()
+	_, err = wrapStreamExecute(r, &noopVCursor{}, nil, true)
+	assert.EqualError(t, err, "VT09025: recursive query aborted after 1 iterations")
+}
+
+func TestRecurseCTEMaxMemoryRows(t *testing.T) {
+	saveMax := testMaxMemoryRows
+	testMaxMemoryRows = 3
+	defer func() {
+		testMaxMemoryRows = saveMax
+	}()
+
+	// No iteration has more than 2 rows, but the 4 rows of all of them are
+	// over the limit.
+	r := newTestRecurseCTE()
+	_, err := r.TryExecute(context.Background(), &noopVCursor{}, nil, true)
+	assert.EqualError(t, err, "in-memory row count exceeded allowed limit of 3")
+
+	r.Seed.(*fakePrimitive).rewind()
+	r.Term.(*fakePrimitive).rewind()
+	_, err = wrapStreamExecute(r, &noopVCursor{}, nil, true)
+	assert.EqualError(t, err, "in-memory row count exceeded allowed limit of 3")
+}
@@ -707,6 +707,53 @@ func TestSelectLike(t *testing.T) {
 
 }
 
+func TestSelectRange(t *testing.T) {
+	vindex, _ := vindexes.CreateVindex("range", "range", map[string]string{"split_points": `{"10": "40", "20": "80", "30": "c0"}`})
+	vc := &loggingVCursor{
+		shards:  []string{"-40", "40-80", "80-c0", "c0-"},
+		results: []*sqltypes.Result{defaultSelectResult},
+	}
+
+	sel := NewRoute(
+		Range,
+		&vindexes.Keyspace{
+			Name:    "ks",
+			Sharded: true,
+		},
+		"dummy_select",
+		"dummy_select_field",
+	)
+	sel.Vindex = vindex
+	sel.Values = []evalengine.Expr{
+		evalengine.NewLiteralInt(15),
+		evalengine.NewLiteralInt(25),
+	}
+	vc.shardForKsid = []string{"40-80", "80-c0"}
+	result, err := sel.TryExecute(context.Background(), vc, map[string]*querypb.BindVariable{}, false)
+	require.NoError(t, err)
+	vc.ExpectLog(t, []string{
+		`ResolveDestinations ks [] Destinations:DestinationKeyRange(40-c0)`,
+		`ExecuteMultiShard ks.40-80: dummy_select {} ks.80-c0: dummy_select {} false false`,
+	})
+	expectResult(t, result, defaultSelectResult)
+
+	vc.Rewind()
+
+	// An open end goes up to the last shard.
+	sel.Values = []evalengine.Expr{
+		evalengine.NewLiteralInt(25),
+		evalengine.NullExpr,
+	}
+	vc.shardForKsid = []string{"80-c0", "c0-"}
+	result, err = wrapStreamExecute(sel, vc, map[string]*querypb.BindVariable{}, false)
+	require.NoError(t, err)
+	vc.ExpectLog(t, []string{
+		`ResolveDestinations ks [] Destinations:DestinationKeyRange(80-)`,
+		`StreamExecuteMulti dummy_select ks.80-c0: {} ks.c0-: {} `,
+	})
+	expectResult(t, result, defaultSelectResult)
+}
+
 func TestSelectNext(t *testing.T) {
 	sel := NewRoute(
 		Next,
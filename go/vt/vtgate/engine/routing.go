@@ -56,6 +56,10 @@ const (
 	MultiEqual
 	// SubShard is for when we are missing one or more columns from a composite vindex
 	SubShard
+	// Range is for routing a statement to the shards of a range of values.
+	// Requires: A RangeMapper Vindex, and the start and end Values,
+	// which are null when the range is open on that side.
+	Range
 	// Scatter is for routing a scattered statement.
 	Scatter
 	// Next is for fetching from a sequence.
@@ -86,6 +90,7 @@ var opName = map[Opcode]string{
 	None:          "None",
 	ByDestination: "ByDestination",
 	SubShard:      "SubShard",
+	Range:         "Range",
 }
 
 // MarshalJSON serializes the Opcode as a JSON string.
@@ -164,6 +169,8 @@ func (rp *RoutingParameters) findRoute(ctx context.Context, vcursor VCursor, bin
 		default:
 			return rp.multiEqual(ctx, vcursor, bindVars)
 		}
+	case Range:
+		return rp.valueRange(ctx, vcursor, bindVars)
 	default:
 		// Unreachable.
 		return nil, nil, vterrors.Errorf(vtrpcpb.Code_INTERNAL, "unsupported opcode: %v", rp.Opcode)
@@ -413,6 +420,23 @@ func (rp *RoutingParameters) multiEqual(ctx context.Context, vcursor VCursor, bi
 	return rss, multiBindVars, nil
 }
 
+func (rp *RoutingParameters) valueRange(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable) ([]*srvtopo.ResolvedShard, []map[string]*querypb.BindVariable, error) {
+	env := evalengine.NewExpressionEnv(ctx, bindVars, vcursor)
+	var bounds [2]sqltypes.Value
+	for i, rvalue := range rp.Values {
+		v, err := env.Evaluate(rvalue)
+		if err != nil {
+			return nil, nil, err
+		}
+		bounds[i] = v.Value(vcursor.ConnCollation())
+	}
+	destination, err := rp.Vindex.(vindexes.RangeMapper).MapRange(ctx, vcursor, bounds[0], bounds[1])
+	if err != nil {
+		return nil, nil, err
+	}
+	return rp.byDestination(ctx, vcursor, bindVars, destination)
+}
+
 func (rp *RoutingParameters) multiEqualMultiCol(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable) ([]*srvtopo.ResolvedShard, []map[string]*querypb.BindVariable, error) {
 	var multiColValues [][]sqltypes.Value
 	env := evalengine.NewExpressionEnv(ctx, bindVars, vcursor)
@@ -80,6 +80,11 @@ func (sa *ScalarAggregate) NeedsTransaction() bool {
 
 // TryExecute implements the Primitive interface
 func (sa *ScalarAggregate) TryExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool) (*sqltypes.Result, error) {
+	if spillsNonStreaming(vcursor) {
+		// the streaming execution does not hold the rows it aggregates
+		return executeStreamed(ctx, vcursor, sa, bindVars, true)
+	}
+
 	result, err := vcursor.ExecutePrimitive(ctx, sa.Input, bindVars, true)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,205 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"sync"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/stats"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	"vitess.io/vitess/go/vt/vterrors"
+)
+
+// Primitives that hold all of their input, e.g. to sort or join it, spill
+// their rows to temporary files once they hold more memory than the spill
+// threshold, so that they can handle inputs larger than the memory of
+// vtgate. The rows are written to the files in batches, as they arrive,
+// and read back one at a time.
+//
+// When spilling is enabled for the non-streaming executions too, the
+// non-streaming executions of these primitives, and of the aggregations,
+// use their streaming execution: the input is then streamed through the
+// primitive instead of being held in memory on top of its rows and its
+// result.
+
+// spillBatchSize is the number of rows sent at once by a primitive
+// returning the rows it spilled.
+const spillBatchSize = 1000
+
+var spills = stats.NewCountersWithSingleLabel("SpilledToDisk", "Number of times a primitive spilled its rows to disk", "Primitive")
+
+// spillsNonStreaming returns true if the non-streaming executions of the
+// primitives must use executeStreamed, so that they can spill.
+func spillsNonStreaming(vcursor VCursor) bool {
+	return vcursor.SpillThreshold() > 0 && vcursor.SpillNonStreaming()
+}
+
+// executeStreamed executes the primitive with its streaming execution, and
+// returns all the rows it sent. The primitives use it in their TryExecute
+// when spillsNonStreaming.
+func executeStreamed(ctx context.Context, vcursor VCursor, primitive Primitive, bindVars map[string]*querypb.BindVariable, wantfields bool) (*sqltypes.Result, error) {
+	var mu sync.Mutex
+	result := &sqltypes.Result{}
+	err := primitive.TryStreamExecute(ctx, vcursor, bindVars, wantfields, func(qr *sqltypes.Result) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(result.Fields) == 0 {
+			result.Fields = qr.Fields
+		}
+		result.Rows = append(result.Rows, qr.Rows...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// spillFile is a temporary file holding spilled rows.
+type spillFile struct {
+	file *os.File
+	w    *bufio.Writer
+	rows int
+}
+
+func newSpillFile(dir string) (*spillFile, error) {
+	file, err := os.CreateTemp(dir, "vtgate-spill-")
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "unable to create a file to spill rows to")
+	}
+	return &spillFile{file: file, w: bufio.NewWriter(file)}, nil
+}
+
+// write appends the row to the file. A row is written as its number of
+// values, followed by the type and the length of each value, and its
+// bytes. NULL values have no length.
+func (sf *spillFile) write(row sqltypes.Row) error {
+	var buf [binary.MaxVarintLen64]byte
+	writeUvarint := func(x uint64) error {
+		_, err := sf.w.Write(buf[:binary.PutUvarint(buf[:], x)])
+		return err
+	}
+
+	if err := writeUvarint(uint64(len(row))); err != nil {
+		return err
+	}
+	for _, value := range row {
+		if err := writeUvarint(uint64(value.Type())); err != nil {
+			return err
+		}
+		if value.IsNull() {
+			continue
+		}
+		raw := value.Raw()
+		if err := writeUvarint(uint64(len(raw))); err != nil {
+			return err
+		}
+		if _, err := sf.w.Write(raw); err != nil {
+			return err
+		}
+	}
+	sf.rows++
+	return nil
+}
+
+// writeRows appends the rows to the file.
+func (sf *spillFile) writeRows(rows []sqltypes.Row) error {
+	for _, row := range rows {
+		if err := sf.write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reader returns a reader of the rows of the file, from the first one.
+// No more rows can be written to the file once it is read.
+func (sf *spillFile) reader() (*spillReader, error) {
+	if err := sf.w.Flush(); err != nil {
+		return nil, err
+	}
+	if _, err := sf.file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return &spillReader{r: bufio.NewReader(sf.file)}, nil
+}
+
+// close closes and removes the file.
+func (sf *spillFile) close() {
+	_ = sf.file.Close()
+	_ = os.Remove(sf.file.Name())
+}
+
+// spillReader reads the rows of a spill file.
+type spillReader struct {
+	r *bufio.Reader
+}
+
+// next returns the next row of the file, or io.EOF once all the rows
+// were read.
+func (sr *spillReader) next() (sqltypes.Row, error) {
+	count, err := binary.ReadUvarint(sr.r)
+	if err != nil {
+		return nil, err
+	}
+	row := make(sqltypes.Row, count)
+	for i := range row {
+		typ, err := binary.ReadUvarint(sr.r)
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		if querypb.Type(typ) == sqltypes.Null {
+			row[i] = sqltypes.NULL
+			continue
+		}
+		size, err := binary.ReadUvarint(sr.r)
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		raw := make([]byte, size)
+		if _, err := io.ReadFull(sr.r, raw); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		row[i] = sqltypes.MakeTrusted(querypb.Type(typ), raw)
+	}
+	return row, nil
+}
+
+// unexpectedEOF returns io.ErrUnexpectedEOF for an io.EOF read in the
+// middle of a row.
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// closeSpillFiles closes and removes the files.
+func closeSpillFiles(files []*spillFile) {
+	for _, sf := range files {
+		if sf != nil {
+			sf.close()
+		}
+	}
+}
@@ -0,0 +1,264 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	"vitess.io/vitess/go/vt/vtgate/engine/opcode"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+)
+
+func TestSpillFile(t *testing.T) {
+	dir := t.TempDir()
+	rows := []sqltypes.Row{
+		{sqltypes.NewInt64(1), sqltypes.NewVarChar("a"), sqltypes.NULL},
+		{sqltypes.NewInt64(-2), sqltypes.NewVarChar(""), sqltypes.NewFloat64(1.5)},
+		{},
+		{sqltypes.NewVarBinary("\x00\xff"), sqltypes.NewDecimal("1.23"), sqltypes.NewDatetime("2024-01-01 00:00:00")},
+	}
+
+	sf, err := newSpillFile(dir)
+	require.NoError(t, err)
+	require.NoError(t, sf.writeRows(rows))
+	assert.Equal(t, len(rows), sf.rows)
+
+	reader, err := sf.reader()
+	require.NoError(t, err)
+	for _, want := range rows {
+		row, err := reader.next()
+		require.NoError(t, err)
+		assert.Equal(t, want, row)
+	}
+	_, err = reader.next()
+	assert.Equal(t, io.EOF, err)
+
+	sf.close()
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestMemorySortSpill(t *testing.T) {
+	fields := sqltypes.MakeTestFields("c1|c2", "varbinary|int64")
+	input := []string{"e|5", "b|2", "g|7", "a|1", "c|3", "null|0", "f|6", "d|4", "h|8"}
+
+	tests := []struct {
+		name     string
+		limit    evalengine.Expr
+		expected []string
+	}{{
+		name:     "no limit",
+		expected: []string{"null|0", "a|1", "b|2", "c|3", "d|4", "e|5", "f|6", "g|7", "h|8"},
+	}, {
+		name:     "limit",
+		limit:    evalengine.NewLiteralInt(3),
+		expected: []string{"null|0", "a|1", "b|2"},
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ms := &MemorySort{
+				UpperLimit: tc.limit,
+				OrderBy:    []evalengine.OrderByParams{{Col: 1, WeightStringCol: -1, Type: evalengine.NewType(sqltypes.Int64, collations.CollationBinaryID)}},
+				Input:      &fakePrimitive{results: []*sqltypes.Result{sqltypes.MakeTestResult(fields, input...)}},
+			}
+
+			dir := t.TempDir()
+			initial := spills.Counts()["MemorySort"]
+			vc := &loggingVCursor{spillThreshold: 1, spillDir: dir}
+			result, err := wrapStreamExecute(ms, vc, nil, true)
+			require.NoError(t, err)
+			expectResult(t, result, sqltypes.MakeTestResult(fields, tc.expected...))
+			assert.Equal(t, int64(5), spills.Counts()["MemorySort"]-initial)
+
+			// The non-streaming execution only spills when enabled.
+			vc.spillNonStreaming = true
+			ms.Input.(*fakePrimitive).rewind()
+			result, err = ms.TryExecute(context.Background(), vc, nil, true)
+			require.NoError(t, err)
+			expectResult(t, result, sqltypes.MakeTestResult(fields, tc.expected...))
+			assert.Equal(t, int64(10), spills.Counts()["MemorySort"]-initial)
+
+			vc.spillNonStreaming = false
+			ms.Input.(*fakePrimitive).rewind()
+			result, err = ms.TryExecute(context.Background(), vc, nil, true)
+			require.NoError(t, err)
+			expectResult(t, result, sqltypes.MakeTestResult(fields, tc.expected...))
+			assert.Equal(t, int64(10), spills.Counts()["MemorySort"]-initial)
+
+			assert.Zero(t, vc.memory)
+			files, err := os.ReadDir(dir)
+			require.NoError(t, err)
+			assert.Empty(t, files)
+		})
+	}
+}
+
+func TestHashJoinSpill(t *testing.T) {
+	input := func(fields string, rows ...string) Primitive {
+		return &fakePrimitive{results: []*sqltypes.Result{sqltypes.MakeTestResult(sqltypes.MakeTestFields(fields, "int64|varchar"), rows...)}}
+	}
+	fields := sqltypes.MakeTestFields("col1|col2|col4|col5", "int64|varchar|int64|varchar")
+
+	tests := []struct {
+		name     string
+		typ      JoinOpcode
+		expected []string
+	}{{
+		name:     "inner join",
+		typ:      InnerJoin,
+		expected: []string{"1|a|1|x", "1|a|1|y", "3|c|3|z", "5|e|5|w"},
+	}, {
+		name:     "left join",
+		typ:      LeftJoin,
+		expected: []string{"1|a|1|x", "1|a|1|y", "3|c|3|z", "5|e|5|w", "2|b|null|null", "4|d|null|null", "null|f|null|null"},
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			jn := &HashJoin{
+				Opcode:         tc.typ,
+				Left:           input("col1|col2", "1|a", "2|b", "3|c", "4|d", "5|e", "null|f"),
+				Right:          input("col4|col5", "1|x", "3|z", "null|v", "1|y", "6|u", "5|w"),
+				Cols:           []int{-1, -2, 1, 2},
+				LHSKey:         0,
+				RHSKey:         0,
+				Collation:      collations.CollationBinaryID,
+				ComparisonType: querypb.Type_INT64,
+				CollationEnv:   collations.MySQL8(),
+			}
+
+			dir := t.TempDir()
+			initial := spills.Counts()["HashJoin"]
+			vc := &loggingVCursor{spillThreshold: 1, spillDir: dir}
+			result, err := wrapStreamExecute(jn, vc, nil, true)
+			require.NoError(t, err)
+			expectResultAnyOrder(t, result, sqltypes.MakeTestResult(fields, tc.expected...))
+			assert.Equal(t, int64(1), spills.Counts()["HashJoin"]-initial)
+
+			// The non-streaming execution only spills when enabled.
+			jn.Left.(*fakePrimitive).rewind()
+			jn.Right.(*fakePrimitive).rewind()
+			result, err = jn.TryExecute(context.Background(), vc, nil, true)
+			require.NoError(t, err)
+			expectResultAnyOrder(t, result, sqltypes.MakeTestResult(fields, tc.expected...))
+			assert.Equal(t, int64(1), spills.Counts()["HashJoin"]-initial)
+
+			vc.spillNonStreaming = true
+			jn.Left.(*fakePrimitive).rewind()
+			jn.Right.(*fakePrimitive).rewind()
+			result, err = jn.TryExecute(context.Background(), vc, nil, true)
+			require.NoError(t, err)
+			expectResultAnyOrder(t, result, sqltypes.MakeTestResult(fields, tc.expected...))
+			assert.Equal(t, int64(2), spills.Counts()["HashJoin"]-initial)
+
+			assert.Zero(t, vc.memory)
+			files, err := os.ReadDir(dir)
+			require.NoError(t, err)
+			assert.Empty(t, files)
+		})
+	}
+}
+
+func TestDistinctSpill(t *testing.T) {
+	fields := sqltypes.MakeTestFields("c1|c2", "int64|varchar")
+	input := []string{"1|a", "2|b", "1|a", "3|c", "2|b", "4|d", "null|e", "3|c", "4|d", "5|e", "null|e", "5|e"}
+	expected := []string{"1|a", "2|b", "3|c", "4|d", "null|e", "5|e"}
+
+	distinct := &Distinct{
+		Source: &fakePrimitive{results: []*sqltypes.Result{sqltypes.MakeTestResult(fields, input...)}},
+		CheckCols: []CheckCol{
+			{Col: 0, Type: evalengine.NewType(sqltypes.Int64, collations.CollationBinaryID)},
+			{Col: 1, Type: evalengine.NewType(sqltypes.VarChar, collations.CollationUtf8mb4ID)},
+		},
+	}
+
+	dir := t.TempDir()
+	initial := spills.Counts()["Distinct"]
+	vc := &loggingVCursor{spillThreshold: 1, spillDir: dir}
+	result := &sqltypes.Result{}
+	err := distinct.TryStreamExecute(context.Background(), vc, nil, true, func(qr *sqltypes.Result) error {
+		if len(result.Rows) == 0 && len(qr.Rows) > 0 {
+			// The probe table of the rows sent before the spill stays
+			// reserved while the input is split.
+			assert.NotZero(t, vc.memory)
+		}
+		if len(qr.Fields) > 0 {
+			result.Fields = qr.Fields
+		}
+		result.Rows = append(result.Rows, qr.Rows...)
+		return nil
+	})
+	require.NoError(t, err)
+	expectResultAnyOrder(t, result, sqltypes.MakeTestResult(fields, expected...))
+	assert.Equal(t, int64(1), spills.Counts()["Distinct"]-initial)
+
+	// The non-streaming execution only spills when enabled.
+	distinct.Source.(*fakePrimitive).rewind()
+	result, err = distinct.TryExecute(context.Background(), vc, nil, true)
+	require.NoError(t, err)
+	expectResultAnyOrder(t, result, sqltypes.MakeTestResult(fields, expected...))
+	assert.Equal(t, int64(1), spills.Counts()["Distinct"]-initial)
+
+	vc.spillNonStreaming = true
+	distinct.Source.(*fakePrimitive).rewind()
+	result, err = distinct.TryExecute(context.Background(), vc, nil, true)
+	require.NoError(t, err)
+	expectResultAnyOrder(t, result, sqltypes.MakeTestResult(fields, expected...))
+	assert.Equal(t, int64(2), spills.Counts()["Distinct"]-initial)
+
+	assert.Zero(t, vc.memory)
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestAggregateSpill(t *testing.T) {
+	fields := sqltypes.MakeTestFields("col|count(*)", "varbinary|decimal")
+	input := &fakePrimitive{results: []*sqltypes.Result{sqltypes.MakeTestResult(fields, "a|1", "a|1", "b|2", "c|3", "c|4")}}
+
+	// When spilling is enabled for the non-streaming executions, the
+	// aggregations stream their input instead of holding it in memory.
+	vc := &loggingVCursor{spillThreshold: 1, maxMemory: 1, spillNonStreaming: true}
+	oa := &OrderedAggregate{
+		Aggregates:  []*AggregateParams{NewAggregateParam(opcode.AggregateSum, 1, "", collations.MySQL8())},
+		GroupByKeys: []*GroupByParams{{KeyCol: 0}},
+		Input:       input,
+	}
+	result, err := oa.TryExecute(context.Background(), vc, nil, false)
+	require.NoError(t, err)
+	expectResult(t, result, sqltypes.MakeTestResult(fields, "a|2", "b|2", "c|7"))
+	assert.Equal(t, []string{"StreamExecute  true"}, input.log)
+
+	input.rewind()
+	sa := &ScalarAggregate{
+		Aggregates: []*AggregateParams{NewAggregateParam(opcode.AggregateSum, 1, "", collations.MySQL8())},
+		Input:      input,
+	}
+	result, err = sa.TryExecute(context.Background(), vc, nil, false)
+	require.NoError(t, err)
+	expectResult(t, result, sqltypes.MakeTestResult(fields, "a|11"))
+	assert.Equal(t, []string{"StreamExecute  true"}, input.log)
+}
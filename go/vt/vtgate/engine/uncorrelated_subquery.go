@@ -89,18 +89,7 @@ func (ps *UncorrelatedSubquery) GetFields(ctx context.Context, vcursor VCursor,
 	for k, v := range bindVars {
 		combinedVars[k] = v
 	}
-	switch ps.Opcode {
-	case PulloutValue:
-		combinedVars[ps.SubqueryResult] = sqltypes.NullBindVariable
-	case PulloutIn, PulloutNotIn:
-		combinedVars[ps.HasValues] = sqltypes.Int64BindVariable(0)
-		combinedVars[ps.SubqueryResult] = &querypb.BindVariable{
-			Type:   querypb.Type_TUPLE,
-			Values: []*querypb.Value{sqltypes.ValueToProto(sqltypes.NewInt64(0))},
-		}
-	case PulloutExists:
-		combinedVars[ps.HasValues] = sqltypes.Int64BindVariable(0)
-	}
+	setDummySubqueryBindVars(ps.Opcode, ps.SubqueryResult, ps.HasValues, combinedVars)
 	return ps.Outer.GetFields(ctx, vcursor, combinedVars)
 }
 
@@ -127,27 +116,36 @@ func (ps *UncorrelatedSubquery) execSubquery(ctx context.Context, vcursor VCurso
 	for k, v := range bindVars {
 		combinedVars[k] = v
 	}
-	switch ps.Opcode {
+	if err := setSubqueryBindVars(ps.Opcode, ps.SubqueryResult, ps.HasValues, result, combinedVars); err != nil {
+		return nil, err
+	}
+	return combinedVars, nil
+}
+
+// setSubqueryBindVars sets the bind variables used by the outer query
+// to refer to the result of the subquery, based on the pullout opcode.
+func setSubqueryBindVars(opcode PulloutOpcode, subqueryResult, hasValues string, result *sqltypes.Result, combinedVars map[string]*querypb.BindVariable) error {
+	switch opcode {
 	case PulloutValue:
 		switch len(result.Rows) {
 		case 0:
-			combinedVars[ps.SubqueryResult] = sqltypes.NullBindVariable
+			combinedVars[subqueryResult] = sqltypes.NullBindVariable
 		case 1:
-			combinedVars[ps.SubqueryResult] = sqltypes.ValueBindVariable(result.Rows[0][0])
+			combinedVars[subqueryResult] = sqltypes.ValueBindVariable(result.Rows[0][0])
 		default:
-			return nil, errSqRow
+			return errSqRow
 		}
 	case PulloutIn, PulloutNotIn:
 		switch len(result.Rows) {
 		case 0:
-			combinedVars[ps.HasValues] = sqltypes.Int64BindVariable(0)
+			combinedVars[hasValues] = sqltypes.Int64BindVariable(0)
 			// Add a bogus value. It will not be checked.
-			combinedVars[ps.SubqueryResult] = &querypb.BindVariable{
+			combinedVars[subqueryResult] = &querypb.BindVariable{
 				Type:   querypb.Type_TUPLE,
 				Values: []*querypb.Value{sqltypes.ValueToProto(sqltypes.NewInt64(0))},
 			}
 		default:
-			combinedVars[ps.HasValues] = sqltypes.Int64BindVariable(1)
+			combinedVars[hasValues] = sqltypes.Int64BindVariable(1)
 			values := &querypb.BindVariable{
 				Type:   querypb.Type_TUPLE,
 				Values: make([]*querypb.Value, len(result.Rows)),
@@ -155,17 +153,34 @@ func (ps *UncorrelatedSubquery) execSubquery(ctx context.Context, vcursor VCurso
 			for i, v := range result.Rows {
 				values.Values[i] = sqltypes.ValueToProto(v[0])
 			}
-			combinedVars[ps.SubqueryResult] = values
+			combinedVars[subqueryResult] = values
 		}
 	case PulloutExists:
 		switch len(result.Rows) {
 		case 0:
-			combinedVars[ps.HasValues] = sqltypes.Int64BindVariable(0)
+			combinedVars[hasValues] = sqltypes.Int64BindVariable(0)
 		default:
-			combinedVars[ps.HasValues] = sqltypes.Int64BindVariable(1)
+			combinedVars[hasValues] = sqltypes.Int64BindVariable(1)
 		}
 	}
-	return combinedVars, nil
+	return nil
+}
+
+// setDummySubqueryBindVars sets placeholder values for the subquery bind variables,
+// so that the fields of the outer query can be fetched without executing the subquery.
+func setDummySubqueryBindVars(opcode PulloutOpcode, subqueryResult, hasValues string, combinedVars map[string]*querypb.BindVariable) {
+	switch opcode {
+	case PulloutValue:
+		combinedVars[subqueryResult] = sqltypes.NullBindVariable
+	case PulloutIn, PulloutNotIn:
+		combinedVars[hasValues] = sqltypes.Int64BindVariable(0)
+		combinedVars[subqueryResult] = &querypb.BindVariable{
+			Type:   querypb.Type_TUPLE,
+			Values: []*querypb.Value{sqltypes.ValueToProto(sqltypes.NewInt64(0))},
+		}
+	case PulloutExists:
+		combinedVars[hasValues] = sqltypes.Int64BindVariable(0)
+	}
 }
 
 func (ps *UncorrelatedSubquery) description() PrimitiveDescription {
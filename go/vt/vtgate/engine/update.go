@@ -17,8 +17,10 @@ limitations under the License.
 package engine
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"slices"
 	"sort"
 
 	"vitess.io/vitess/go/vt/vtgate/evalengine"
@@ -26,7 +28,10 @@ import (
 	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
 
 	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/key"
+	"vitess.io/vitess/go/vt/sqlparser"
 	"vitess.io/vitess/go/vt/srvtopo"
+	"vitess.io/vitess/go/vt/vterrors"
 	"vitess.io/vitess/go/vt/vtgate/vindexes"
 
 	querypb "vitess.io/vitess/go/vt/proto/query"
@@ -49,6 +54,14 @@ type Update struct {
 
 	// ChangedVindexValues contains values for updated Vindexes during an update statement.
 	ChangedVindexValues map[string]*VindexValues
+
+	// MoveSelect and MoveDelete are set when the primary vindex columns of a table that allows it are updated.
+	// After the update, MoveSelect returns the updated rows of every shard. The rows that no longer map to
+	// the shard they are on are deleted from it using MoveDelete, and inserted on the shard they map to.
+	// MoveColumns are the columns returned by MoveSelect, which are the columns of the table that can be inserted.
+	MoveSelect  string
+	MoveDelete  string
+	MoveColumns []string
 }
 
 // TryExecute performs a non-streaming exec.
@@ -68,7 +81,10 @@ func (upd *Update) TryExecute(ctx context.Context, vcursor VCursor, bindVars map
 	switch upd.Opcode {
 	case Unsharded:
 		return upd.execUnsharded(ctx, upd, vcursor, bindVars, rss)
-	case Equal, EqualUnique, IN, Scatter, ByDestination, SubShard, MultiEqual:
+	case Equal, EqualUnique, IN, Scatter, ByDestination, SubShard, MultiEqual, Range:
+		if upd.MoveSelect != "" {
+			return upd.execMultiDestinationAndMove(ctx, vcursor, bindVars, rss, bvs)
+		}
 		return upd.execMultiDestination(ctx, upd, vcursor, bindVars, rss, upd.updateVindexEntries, bvs)
 	default:
 		// Unreachable.
@@ -127,23 +143,29 @@ func (upd *Update) updateVindexEntries(ctx context.Context, vcursor VCursor, bin
 		if err != nil {
 			return err
 		}
+		newKsid, err := upd.newKeyspaceID(ctx, vcursor, env, row, ksid)
+		if err != nil {
+			return err
+		}
+		// when the row moves to a new keyspace id, all the owned vindex entries have to point to it
+		moved := !bytes.Equal(ksid, newKsid)
 
-		for _, colVindex := range upd.Vindexes {
-			// Skip this vindex if no rows are being changed
-			updColValues, ok := upd.ChangedVindexValues[colVindex.Name]
-			if !ok {
+		for idx, colVindex := range upd.Vindexes {
+			if idx == 0 && upd.MoveSelect != "" {
+				// the primary vindex has been handled by newKeyspaceID
 				continue
 			}
-
-			offset := updColValues.Offset
-			if !row[offset].IsNull() {
-				val, err := row[offset].ToCastInt64()
+			updColValues, changed := upd.ChangedVindexValues[colVindex.Name]
+			if changed {
+				unchanged, err := isUnchanged(row, updColValues.Offset)
 				if err != nil {
 					return err
 				}
-				if val == int64(1) { // 1 means that the old and new value are same and vindex update is not required.
-					continue
-				}
+				changed = !unchanged
+			}
+			// Skip this vindex if no rows are being changed
+			if !changed && !(moved && colVindex.Owned) {
+				continue
 			}
 
 			fromIds := make([]sqltypes.Value, 0, len(colVindex.Columns))
@@ -152,7 +174,9 @@ func (upd *Update) updateVindexEntries(ctx context.Context, vcursor VCursor, bin
 				// Fetch the column values.
 				origColValue := row[fieldColNumMap[vCol.String()]]
 				fromIds = append(fromIds, origColValue)
-				if colValue, exists := updColValues.EvalExprMap[vCol.String()]; exists {
+				if !changed {
+					vindexColumnKeys = append(vindexColumnKeys, origColValue)
+				} else if colValue, exists := updColValues.EvalExprMap[vCol.String()]; exists {
 					resolvedVal, err := env.Evaluate(colValue)
 					if err != nil {
 						return err
@@ -165,7 +189,15 @@ func (upd *Update) updateVindexEntries(ctx context.Context, vcursor VCursor, bin
 			}
 
 			if colVindex.Owned {
-				if err := colVindex.Vindex.(vindexes.Lookup).Update(ctx, vcursor, fromIds, ksid, vindexColumnKeys); err != nil {
+				lookup := colVindex.Vindex.(vindexes.Lookup)
+				if moved {
+					if err := lookup.Delete(ctx, vcursor, [][]sqltypes.Value{fromIds}, ksid); err != nil {
+						return err
+					}
+					if err := lookup.Create(ctx, vcursor, [][]sqltypes.Value{vindexColumnKeys}, [][]byte{newKsid}, false /* ignoreMode */); err != nil {
+						return err
+					}
+				} else if err := lookup.Update(ctx, vcursor, fromIds, ksid, vindexColumnKeys); err != nil {
 					return err
 				}
 			} else {
@@ -183,7 +215,7 @@ func (upd *Update) updateVindexEntries(ctx context.Context, vcursor VCursor, bin
 				}
 
 				// If values were supplied, we validate against keyspace id.
-				verified, err := vindexes.Verify(ctx, colVindex.Vindex, vcursor, [][]sqltypes.Value{vindexColumnKeys}, [][]byte{ksid})
+				verified, err := vindexes.Verify(ctx, colVindex.Vindex, vcursor, [][]sqltypes.Value{vindexColumnKeys}, [][]byte{newKsid})
 				if err != nil {
 					return err
 				}
@@ -197,6 +229,201 @@ func (upd *Update) updateVindexEntries(ctx context.Context, vcursor VCursor, bin
 	return nil
 }
 
+// isUnchanged checks the column of the owned vindex query that compares the old and new values of a vindex.
+// 1 means that the old and new value are same and vindex update is not required.
+func isUnchanged(row sqltypes.Row, offset int) (bool, error) {
+	if row[offset].IsNull() {
+		return false, nil
+	}
+	val, err := row[offset].ToCastInt64()
+	if err != nil {
+		return false, err
+	}
+	return val == int64(1), nil
+}
+
+// newKeyspaceID returns the keyspace id the row maps to after the update.
+// It is only different from the current keyspace id when the primary vindex columns are changed.
+func (upd *Update) newKeyspaceID(ctx context.Context, vcursor VCursor, env *evalengine.ExpressionEnv, row sqltypes.Row, ksid []byte) ([]byte, error) {
+	if upd.MoveSelect == "" {
+		return ksid, nil
+	}
+	primary := upd.Vindexes[0]
+	updColValues, ok := upd.ChangedVindexValues[primary.Name]
+	if !ok {
+		return ksid, nil
+	}
+	unchanged, err := isUnchanged(row, updColValues.Offset)
+	if err != nil || unchanged {
+		return ksid, err
+	}
+
+	// the primary vindex columns are the first columns of the owned vindex query
+	vindexColumnKeys := make([]sqltypes.Value, 0, len(primary.Columns))
+	for idx, vCol := range primary.Columns {
+		colValue, exists := updColValues.EvalExprMap[vCol.String()]
+		if !exists {
+			vindexColumnKeys = append(vindexColumnKeys, row[idx])
+			continue
+		}
+		resolvedVal, err := env.Evaluate(colValue)
+		if err != nil {
+			return nil, err
+		}
+		vindexColumnKeys = append(vindexColumnKeys, resolvedVal.Value(vcursor.ConnCollation()))
+	}
+	newKsid, err := resolveKeyspaceID(ctx, vcursor, upd.KsidVindex, vindexColumnKeys)
+	if err != nil {
+		return nil, err
+	}
+	if newKsid == nil {
+		return nil, vterrors.VT09023(vindexColumnKeys)
+	}
+	return newKsid, nil
+}
+
+// execMultiDestinationAndMove executes the update when the primary vindex columns are changed.
+// Moving the rows takes more than one statement, so the update can never be autocommitted.
+func (upd *Update) execMultiDestinationAndMove(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, rss []*srvtopo.ResolvedShard, bvs []map[string]*querypb.BindVariable) (*sqltypes.Result, error) {
+	if len(rss) == 0 {
+		return &sqltypes.Result{}, nil
+	}
+	if err := upd.updateVindexEntries(ctx, vcursor, bindVars, rss); err != nil {
+		return nil, err
+	}
+	queries := make([]*querypb.BoundQuery, len(rss))
+	for i := range rss {
+		queries[i] = &querypb.BoundQuery{
+			Sql:           upd.Query,
+			BindVariables: bvs[i],
+		}
+	}
+	result, errs := vcursor.ExecuteMultiShard(ctx, upd, rss, queries, true /* rollbackOnError */, false /* canAutocommit */)
+	if err := vterrors.Aggregate(errs); err != nil {
+		return nil, err
+	}
+	if err := upd.moveRows(ctx, vcursor, bindVars, rss); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// moveRows moves the updated rows that no longer map to the shard they are on,
+// by deleting them from that shard and inserting them on the shard they map to.
+func (upd *Update) moveRows(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, rss []*srvtopo.ResolvedShard) error {
+	primary := upd.Vindexes[0]
+	for _, rs := range rss {
+		qr, errs := vcursor.ExecuteMultiShard(ctx, upd, []*srvtopo.ResolvedShard{rs}, []*querypb.BoundQuery{{Sql: upd.MoveSelect, BindVariables: bindVars}}, false /* rollbackOnError */, false /* canAutocommit */)
+		if err := vterrors.Aggregate(errs); err != nil {
+			return err
+		}
+		if len(qr.Rows) == 0 {
+			continue
+		}
+
+		offsets := make([]int, 0, len(primary.Columns))
+		for _, vCol := range primary.Columns {
+			offset := slices.IndexFunc(upd.MoveColumns, vCol.EqualString)
+			if offset < 0 {
+				return vterrors.VT13001(fmt.Sprintf("column %s not found in the rows to move", vCol.String()))
+			}
+			offsets = append(offsets, offset)
+		}
+
+		moved := &sqltypes.Result{}
+		var targets []*srvtopo.ResolvedShard
+		var targetRows [][]sqltypes.Row
+		for _, row := range qr.Rows {
+			vindexColumnKeys := make([]sqltypes.Value, 0, len(offsets))
+			for _, offset := range offsets {
+				vindexColumnKeys = append(vindexColumnKeys, row[offset])
+			}
+			ksid, err := resolveKeyspaceID(ctx, vcursor, upd.KsidVindex, vindexColumnKeys)
+			if err != nil {
+				return err
+			}
+			if ksid == nil {
+				return vterrors.VT09023(vindexColumnKeys)
+			}
+			dest, _, err := vcursor.ResolveDestinations(ctx, upd.Keyspace.Name, nil, []key.Destination{key.DestinationKeyspaceID(ksid)})
+			if err != nil {
+				return err
+			}
+			if len(dest) != 1 {
+				return vterrors.VT09024(vindexColumnKeys, key.DestinationKeyspaceID(ksid))
+			}
+			if dest[0].Target.Shard == rs.Target.Shard {
+				// the row is still on the right shard
+				continue
+			}
+			moved.Rows = append(moved.Rows, row)
+			idx := slices.IndexFunc(targets, func(target *srvtopo.ResolvedShard) bool {
+				return target.Target.Shard == dest[0].Target.Shard
+			})
+			if idx < 0 {
+				idx = len(targets)
+				targets = append(targets, dest[0])
+				targetRows = append(targetRows, nil)
+			}
+			targetRows[idx] = append(targetRows[idx], row)
+		}
+		if len(moved.Rows) == 0 {
+			continue
+		}
+
+		var bv *querypb.BindVariable
+		if len(offsets) == 1 {
+			bv = getBVSingle(moved, offsets[0])
+		} else {
+			bv = getBVMulti(moved, offsets)
+		}
+		deleteQuery := &querypb.BoundQuery{
+			Sql:           upd.MoveDelete,
+			BindVariables: combineVars(bindVars, map[string]*querypb.BindVariable{DmlVals: bv}),
+		}
+		_, errs = vcursor.ExecuteMultiShard(ctx, upd, []*srvtopo.ResolvedShard{rs}, []*querypb.BoundQuery{deleteQuery}, true /* rollbackOnError */, false /* canAutocommit */)
+		if err := vterrors.Aggregate(errs); err != nil {
+			return err
+		}
+		for idx, target := range targets {
+			insertQuery := upd.moveInsertQuery(targetRows[idx])
+			_, errs = vcursor.ExecuteMultiShard(ctx, upd, []*srvtopo.ResolvedShard{target}, []*querypb.BoundQuery{insertQuery}, true /* rollbackOnError */, false /* canAutocommit */)
+			if err := vterrors.Aggregate(errs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// moveInsertQuery builds the query that inserts the moved rows on their new shard.
+func (upd *Update) moveInsertQuery(rows []sqltypes.Row) *querypb.BoundQuery {
+	columns := make(sqlparser.Columns, 0, len(upd.MoveColumns))
+	for _, col := range upd.MoveColumns {
+		columns = append(columns, sqlparser.NewIdentifierCI(col))
+	}
+	bindVars := make(map[string]*querypb.BindVariable, len(columns)*len(rows))
+	values := make(sqlparser.Values, 0, len(rows))
+	for rowNum, row := range rows {
+		tuple := make(sqlparser.ValTuple, 0, len(row))
+		for colNum, value := range row {
+			name := InsertVarName(columns[colNum], rowNum)
+			bindVars[name] = sqltypes.ValueBindVariable(value)
+			tuple = append(tuple, sqlparser.NewArgument(name))
+		}
+		values = append(values, tuple)
+	}
+	ins := &sqlparser.Insert{
+		Table:   sqlparser.NewAliasedTableExpr(sqlparser.NewTableName(upd.TableNames[0]), ""),
+		Columns: columns,
+		Rows:    values,
+	}
+	return &querypb.BoundQuery{
+		Sql:           sqlparser.String(ins),
+		BindVariables: bindVars,
+	}
+}
+
 func (upd *Update) description() PrimitiveDescription {
 	other := map[string]any{
 		"Query":                upd.Query,
@@ -217,6 +444,10 @@ func (upd *Update) description() PrimitiveDescription {
 	if len(changedVindexes) > 0 {
 		other["ChangedVindexValues"] = changedVindexes
 	}
+	if upd.MoveSelect != "" {
+		other["MoveSelect"] = upd.MoveSelect
+		other["MoveDelete"] = upd.MoveDelete
+	}
 
 	return PrimitiveDescription{
 		OperatorType:     "Update",
@@ -616,6 +616,96 @@ func TestUpdateScatterChangedVindex(t *testing.T) {
 
 }
 
+func TestUpdateEqualChangedPrimaryVindex(t *testing.T) {
+	ks := buildTestVSchema().Keyspaces["sharded"]
+	upd := &Update{
+		DML: &DML{
+			RoutingParameters: &RoutingParameters{
+				Opcode:   Equal,
+				Keyspace: ks.Keyspace,
+				Vindex:   ks.Vindexes["hash"],
+				Values:   []evalengine.Expr{evalengine.NewLiteralInt(1)},
+			},
+			Query:            "dummy_update",
+			TableNames:       []string{ks.Tables["t1"].Name.String()},
+			Vindexes:         ks.Tables["t1"].ColumnVindexes,
+			OwnedVindexQuery: "dummy_subquery",
+			KsidVindex:       ks.Vindexes["hash"],
+			KsidLength:       1,
+		},
+		ChangedVindexValues: map[string]*VindexValues{
+			"hash": {
+				EvalExprMap: map[string]evalengine.Expr{
+					"id": evalengine.NewLiteralInt(2),
+				},
+				Offset: 4,
+			},
+		},
+		MoveSelect:  "dummy_move_select",
+		MoveDelete:  "dummy_move_delete",
+		MoveColumns: []string{"id", "c1", "c2", "c3"},
+	}
+
+	results := []*sqltypes.Result{
+		sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields(
+				"id|c1|c2|c3|hash",
+				"int64|int64|int64|int64|int64",
+			),
+			"1|4|5|6|0",
+		),
+		// lookup vindex changes and the update itself.
+		nil, nil, nil, nil, nil,
+		sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields(
+				"id|c1|c2|c3",
+				"int64|int64|int64|int64",
+			),
+			"2|4|5|6",
+		),
+	}
+	vc := newDMLTestVCursor("-20", "20-")
+	vc.shardForKsid = []string{"-20", "20-"}
+	vc.results = results
+
+	_, err := upd.TryExecute(context.Background(), vc, map[string]*querypb.BindVariable{}, false)
+	require.NoError(t, err)
+	vc.ExpectLog(t, []string{
+		`ResolveDestinations sharded [type:INT64 value:"1"] Destinations:DestinationKeyspaceID(166b40b44aba4bd6)`,
+		`ExecuteMultiShard sharded.-20: dummy_subquery {} false false`,
+		// The row moves to the keyspace id of 2, so the owned lookup vindexes have to point to it.
+		`Execute delete from lkp2 where from1 = :from1 and from2 = :from2 and toc = :toc from1: type:INT64 value:"4" from2: type:INT64 value:"5" toc: type:VARBINARY value:"\x16k@\xb4J\xbaK\xd6" true`,
+		`Execute insert into lkp2(from1, from2, toc) values(:from1_0, :from2_0, :toc_0) from1_0: type:INT64 value:"4" from2_0: type:INT64 value:"5" toc_0: type:VARBINARY value:"\x06\xe7\xea\"Βp\x8f" true`,
+		`Execute delete from lkp1 where from = :from and toc = :toc from: type:INT64 value:"6" toc: type:VARBINARY value:"\x16k@\xb4J\xbaK\xd6" true`,
+		`Execute insert into lkp1(from, toc) values(:from_0, :toc_0) from_0: type:INT64 value:"6" toc_0: type:VARBINARY value:"\x06\xe7\xea\"Βp\x8f" true`,
+		// The update is part of a transaction that moves the rows, so it cannot be autocommitted.
+		`ExecuteMultiShard sharded.-20: dummy_update {} true false`,
+		`ExecuteMultiShard sharded.-20: dummy_move_select {} false false`,
+		// The updated row maps to 20-, so it is moved there.
+		`ResolveDestinations sharded [] Destinations:DestinationKeyspaceID(06e7ea22ce92708f)`,
+		`ExecuteMultiShard sharded.-20: dummy_move_delete {dml_vals: type:TUPLE values:{type:INT64 value:"2"}} true false`,
+		`ExecuteMultiShard sharded.20-: insert into t1(id, c1, c2, c3) values (:_id_0, :_c1_0, :_c2_0, :_c3_0) {_c1_0: type:INT64 value:"4" _c2_0: type:INT64 value:"5" _c3_0: type:INT64 value:"6" _id_0: type:INT64 value:"2"} true false`,
+	})
+
+	// The updated row stays on its shard.
+	vc = newDMLTestVCursor("-20", "20-")
+	vc.results = results
+
+	_, err = upd.TryExecute(context.Background(), vc, map[string]*querypb.BindVariable{}, false)
+	require.NoError(t, err)
+	vc.ExpectLog(t, []string{
+		`ResolveDestinations sharded [type:INT64 value:"1"] Destinations:DestinationKeyspaceID(166b40b44aba4bd6)`,
+		`ExecuteMultiShard sharded.-20: dummy_subquery {} false false`,
+		`Execute delete from lkp2 where from1 = :from1 and from2 = :from2 and toc = :toc from1: type:INT64 value:"4" from2: type:INT64 value:"5" toc: type:VARBINARY value:"\x16k@\xb4J\xbaK\xd6" true`,
+		`Execute insert into lkp2(from1, from2, toc) values(:from1_0, :from2_0, :toc_0) from1_0: type:INT64 value:"4" from2_0: type:INT64 value:"5" toc_0: type:VARBINARY value:"\x06\xe7\xea\"Βp\x8f" true`,
+		`Execute delete from lkp1 where from = :from and toc = :toc from: type:INT64 value:"6" toc: type:VARBINARY value:"\x16k@\xb4J\xbaK\xd6" true`,
+		`Execute insert into lkp1(from, toc) values(:from_0, :toc_0) from_0: type:INT64 value:"6" toc_0: type:VARBINARY value:"\x06\xe7\xea\"Βp\x8f" true`,
+		`ExecuteMultiShard sharded.-20: dummy_update {} true false`,
+		`ExecuteMultiShard sharded.-20: dummy_move_select {} false false`,
+		`ResolveDestinations sharded [] Destinations:DestinationKeyspaceID(06e7ea22ce92708f)`,
+	})
+}
+
 func TestUpdateIn(t *testing.T) {
 	ks := buildTestVSchema().Keyspaces["sharded"]
 	upd := &Update{
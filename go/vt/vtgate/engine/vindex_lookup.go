@@ -171,6 +171,16 @@ func (vr *VindexLookup) lookup(ctx context.Context, vcursor VCursor, ids []sqlty
 		vcursor.Session().SetCommitOrder(co)
 		defer vcursor.Session().SetCommitOrder(vtgatepb.CommitOrder_NORMAL)
 	}
+	// The lookups done outside of a transaction use the cache of the vindex, if any.
+	if lc, ok := vr.Vindex.(vindexes.LookupCaching); ok && lc.LookupCache() != nil && !vcursor.InTransaction() {
+		return lc.LookupCache().Fetch(ids, func(ids []sqltypes.Value) ([]*sqltypes.Result, error) {
+			return vr.execute(ctx, vcursor, ids)
+		})
+	}
+	return vr.execute(ctx, vcursor, ids)
+}
+
+func (vr *VindexLookup) execute(ctx context.Context, vcursor VCursor, ids []sqltypes.Value) ([]*sqltypes.Result, error) {
 	if ids[0].IsIntegral() || vr.Vindex.AllowBatch() {
 		return vr.executeBatch(ctx, vcursor, ids)
 	}
@@ -198,6 +208,7 @@ func (vr *VindexLookup) executeNonBatch(ctx context.Context, vcursor VCursor, id
 		if err != nil {
 			return nil, err
 		}
+		vr.setCacheColumn(result)
 
 		rows := make([][]sqltypes.Value, 0, len(result.Rows))
 		for _, row := range result.Rows {
@@ -230,6 +241,7 @@ func (vr *VindexLookup) executeBatch(ctx context.Context, vcursor VCursor, ids [
 	if err != nil {
 		return nil, vterrors.Wrapf(err, "failed while running the lookup query")
 	}
+	vr.setCacheColumn(result)
 	resultMap := make(map[string][][]sqltypes.Value)
 	for _, row := range result.Rows {
 		resultMap[row[0].ToString()] = append(resultMap[row[0].ToString()], []sqltypes.Value{row[1]})
@@ -243,6 +255,14 @@ func (vr *VindexLookup) executeBatch(ctx context.Context, vcursor VCursor, ids [
 	return results, nil
 }
 
+// setCacheColumn sets the type of the column of the ids cached by the
+// vindex, if any, from the fields of the result of the lookup query.
+func (vr *VindexLookup) setCacheColumn(result *sqltypes.Result) {
+	if lc, ok := vr.Vindex.(vindexes.LookupCaching); ok && lc.LookupCache() != nil && len(result.Fields) > 0 {
+		lc.LookupCache().SetColumnField(result.Fields[0])
+	}
+}
+
 func (vr *VindexLookup) generateIds(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable) ([]sqltypes.Value, error) {
 	env := evalengine.NewExpressionEnv(ctx, bindVars, vcursor)
 	value, err := env.Evaluate(vr.Values[0])
@@ -0,0 +1,85 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+	"vitess.io/vitess/go/vt/vtgate/vindexes"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
+)
+
+func TestVindexLookupCache(t *testing.T) {
+	vindex, err := vindexes.CreateVindex("lookup_unique", "lookup_unique", map[string]string{
+		"table":      "lkp",
+		"from":       "from",
+		"to":         "toc",
+		"cache_size": "10",
+	})
+	require.NoError(t, err)
+	lookupResult := sqltypes.MakeTestResult(sqltypes.MakeTestFields("from|toc", "int64|varbinary"), "1|\x80")
+	lookup := &fakePrimitive{results: []*sqltypes.Result{lookupResult, lookupResult, lookupResult, lookupResult}}
+	vl := &VindexLookup{
+		Opcode:    EqualUnique,
+		Vindex:    vindex.(vindexes.LookupPlanable),
+		Keyspace:  &vindexes.Keyspace{Name: "ks", Sharded: true},
+		Arguments: []string{"from"},
+		Values:    []evalengine.Expr{evalengine.NewLiteralInt(1)},
+		Lookup:    lookup,
+		SendTo:    NewRoute(EqualUnique, &vindexes.Keyspace{Name: "ks", Sharded: true}, "dummy_select", "dummy_select_field"),
+	}
+	vc := &loggingVCursor{
+		shards:  []string{"-20", "20-"},
+		results: []*sqltypes.Result{defaultSelectResult, defaultSelectResult, defaultSelectResult, defaultSelectResult},
+	}
+
+	wantLog := []string{
+		`ResolveDestinations ks [type:INT64 value:"1"] Destinations:DestinationKeyspaceID(80)`,
+		`ExecuteMultiShard ks.-20: dummy_select {} false false`,
+	}
+	// The first lookup only learns the type of the column the ids are
+	// cached by.
+	for i := 0; i < 2; i++ {
+		vc.Rewind()
+		result, err := vl.TryExecute(context.Background(), vc, map[string]*querypb.BindVariable{}, false)
+		require.NoError(t, err)
+		expectResult(t, result, defaultSelectResult)
+		vc.ExpectLog(t, wantLog)
+		require.Len(t, lookup.log, i+1)
+	}
+
+	// The id is now cached, so the lookup query is not executed again.
+	vc.Rewind()
+	result, err := vl.TryExecute(context.Background(), vc, map[string]*querypb.BindVariable{}, false)
+	require.NoError(t, err)
+	expectResult(t, result, defaultSelectResult)
+	vc.ExpectLog(t, wantLog)
+	require.Len(t, lookup.log, 2)
+
+	// The lookups in a transaction do not use the cache.
+	vc.Rewind()
+	vc.inTx = true
+	_, err = vl.TryExecute(context.Background(), vc, map[string]*querypb.BindVariable{}, false)
+	require.NoError(t, err)
+	require.Len(t, lookup.log, 3)
+}
@@ -0,0 +1,501 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vterrors"
+	. "vitess.io/vitess/go/vt/vtgate/engine/opcode"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+)
+
+var _ Primitive = (*Window)(nil)
+
+type (
+	// Window is a primitive that evaluates window functions at the vtgate level.
+	// The input must be sorted by the PARTITION BY columns followed by the ORDER BY
+	// columns of the window specification. The results of the window functions are
+	// placed in front of the input columns of every row.
+	Window struct {
+		Source Primitive
+
+		// PartitionBy is used to find the boundaries between two partitions
+		PartitionBy evalengine.Comparison
+		// OrderBy is used to find the rows that are peers inside a partition
+		OrderBy evalengine.Comparison
+
+		Functions []*WindowFunc
+	}
+
+	// WindowFunc contains the parameters for a single window function
+	WindowFunc struct {
+		Opcode WindowOpcode
+		// Aggregate is the aggregation to evaluate when Opcode is WindowAggregate
+		Aggregate AggregateOpcode
+
+		// Col is the offset of the argument in the input, or -1 if the function takes no argument
+		Col int
+		// N is the offset for LAG/LEAD, the number of buckets for NTILE and the row number for NTH_VALUE
+		N evalengine.Expr
+		// Default is the value LAG/LEAD return when there is no row at the requested offset
+		Default evalengine.Expr
+
+		Type         evalengine.Type
+		Alias        string `json:",omitempty"`
+		Expr         sqlparser.Expr
+		CollationEnv *collations.Environment
+	}
+
+	// windowState holds the rows of the partition being processed,
+	// together with the values the window functions need during the execution
+	windowState struct {
+		w      *Window
+		fields []*querypb.Field
+		rows   []sqltypes.Row
+
+		n        []int64
+		defaults []sqltypes.Value
+	}
+)
+
+// RouteType implements the Primitive interface
+func (w *Window) RouteType() string {
+	return w.Source.RouteType()
+}
+
+// GetKeyspaceName implements the Primitive interface
+func (w *Window) GetKeyspaceName() string {
+	return w.Source.GetKeyspaceName()
+}
+
+// GetTableName implements the Primitive interface
+func (w *Window) GetTableName() string {
+	return w.Source.GetTableName()
+}
+
+// NeedsTransaction implements the Primitive interface
+func (w *Window) NeedsTransaction() bool {
+	return w.Source.NeedsTransaction()
+}
+
+// Inputs implements the Primitive interface
+func (w *Window) Inputs() ([]Primitive, []map[string]any) {
+	return []Primitive{w.Source}, nil
+}
+
+// TryExecute implements the Primitive interface
+func (w *Window) TryExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, _ bool) (_ *sqltypes.Result, err error) {
+	defer evalengine.PanicHandler(&err)
+
+	/* we need the input fields types to correctly calculate the output types */
+	input, err := vcursor.ExecutePrimitive(ctx, w.Source, bindVars, true)
+	if err != nil {
+		return nil, err
+	}
+
+	st, err := w.newState(evalengine.NewExpressionEnv(ctx, bindVars, vcursor), input.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &sqltypes.Result{
+		Fields: w.fields(input.Fields),
+		Rows:   make([]sqltypes.Row, 0, len(input.Rows)),
+	}
+	for _, row := range input.Rows {
+		rows, err := st.add(row)
+		if err != nil {
+			return nil, err
+		}
+		out.Rows = append(out.Rows, rows...)
+	}
+	rows, err := st.flush()
+	if err != nil {
+		return nil, err
+	}
+	out.Rows = append(out.Rows, rows...)
+	return out, nil
+}
+
+// TryStreamExecute implements the Primitive interface
+func (w *Window) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, _ bool, callback func(*sqltypes.Result) error) (err error) {
+	defer evalengine.PanicHandler(&err)
+
+	env := evalengine.NewExpressionEnv(ctx, bindVars, vcursor)
+	var st *windowState
+
+	visitor := func(qr *sqltypes.Result) error {
+		var err error
+		if st == nil && len(qr.Fields) != 0 {
+			st, err = w.newState(env, qr.Fields)
+			if err != nil {
+				return err
+			}
+			if err = callback(&sqltypes.Result{Fields: w.fields(qr.Fields)}); err != nil {
+				return err
+			}
+		}
+
+		var out []sqltypes.Row
+		for _, row := range qr.Rows {
+			rows, err := st.add(row)
+			if err != nil {
+				return err
+			}
+			out = append(out, rows...)
+		}
+		if len(out) == 0 {
+			return nil
+		}
+		return callback(&sqltypes.Result{Rows: out})
+	}
+
+	/* we need the input fields types to correctly calculate the output types */
+	err = vcursor.StreamExecutePrimitive(ctx, w.Source, bindVars, true, visitor)
+	if err != nil || st == nil {
+		return err
+	}
+
+	rows, err := st.flush()
+	if err != nil || len(rows) == 0 {
+		return err
+	}
+	return callback(&sqltypes.Result{Rows: rows})
+}
+
+// GetFields implements the Primitive interface
+func (w *Window) GetFields(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable) (*sqltypes.Result, error) {
+	qr, err := w.Source.GetFields(ctx, vcursor, bindVars)
+	if err != nil {
+		return nil, err
+	}
+	return &sqltypes.Result{Fields: w.fields(qr.Fields)}, nil
+}
+
+func (w *Window) fields(input []*querypb.Field) []*querypb.Field {
+	fields := make([]*querypb.Field, 0, len(w.Functions)+len(input))
+	for _, f := range w.Functions {
+		var argType querypb.Type = sqltypes.Null
+		if f.Col >= 0 && f.Col < len(input) {
+			argType = input[f.Col].Type
+		}
+		typ := f.Opcode.SQLType(argType)
+		if f.Opcode == WindowAggregate {
+			typ = f.Aggregate.SQLType(argType)
+		}
+		name := f.Alias
+		if name == "" && f.Expr != nil {
+			name = sqlparser.String(f.Expr)
+		}
+		fields = append(fields, &querypb.Field{Name: name, Type: typ})
+	}
+	return append(fields, input...)
+}
+
+func (w *Window) newState(env *evalengine.ExpressionEnv, fields []*querypb.Field) (*windowState, error) {
+	st := &windowState{
+		w:        w,
+		fields:   fields,
+		n:        make([]int64, len(w.Functions)),
+		defaults: make([]sqltypes.Value, len(w.Functions)),
+	}
+	for i, f := range w.Functions {
+		switch f.Opcode {
+		case WindowLag, WindowLead:
+			st.n[i] = 1
+		case WindowNtile, WindowNthValue:
+			if f.N == nil {
+				return nil, vterrors.VT13001(fmt.Sprintf("missing argument for window function %s", f.Opcode.String()))
+			}
+		}
+		if f.N != nil {
+			n, null, err := evalWindowArgument(env, f.N)
+			if err != nil {
+				return nil, err
+			}
+			if null || n < 0 || (n == 0 && (f.Opcode == WindowNtile || f.Opcode == WindowNthValue)) {
+				return nil, vterrors.NewErrorf(vtrpcpb.Code_INVALID_ARGUMENT, vterrors.WrongArguments, "Incorrect arguments to %s", f.Opcode.String())
+			}
+			st.n[i] = n
+		}
+		st.defaults[i] = sqltypes.NULL
+		if f.Default != nil {
+			res, err := env.Evaluate(f.Default)
+			if err != nil {
+				return nil, err
+			}
+			st.defaults[i] = res.Value(collations.Unknown)
+		}
+	}
+	return st, nil
+}
+
+// evalWindowArgument evaluates the integer argument of a window function,
+// and returns whether it is NULL.
+func evalWindowArgument(env *evalengine.ExpressionEnv, expr evalengine.Expr) (n int64, null bool, err error) {
+	res, err := env.Evaluate(expr)
+	if err != nil {
+		return 0, false, err
+	}
+	value := res.Value(collations.Unknown)
+	if value.IsNull() {
+		return 0, true, nil
+	}
+	n, err = value.ToCastInt64()
+	return n, false, err
+}
+
+// add adds a row to the current partition. When the row starts a new partition,
+// the rows of the finished partition are returned with the window function results.
+func (st *windowState) add(row sqltypes.Row) ([]sqltypes.Row, error) {
+	var out []sqltypes.Row
+	if len(st.rows) > 0 && st.w.PartitionBy.Compare(st.rows[0], row) != 0 {
+		var err error
+		out, err = st.flush()
+		if err != nil {
+			return nil, err
+		}
+	}
+	st.rows = append(st.rows, row)
+	return out, nil
+}
+
+// flush evaluates the window functions for the current partition and resets it
+func (st *windowState) flush() ([]sqltypes.Row, error) {
+	if len(st.rows) == 0 {
+		return nil, nil
+	}
+	rows := st.rows
+	st.rows = nil
+
+	// peerEnd[i] is one past the last row that is a peer of rows[i]
+	peerEnd := make([]int, len(rows))
+	for i := len(rows) - 1; i >= 0; i-- {
+		if i < len(rows)-1 && st.w.OrderBy.Compare(rows[i], rows[i+1]) == 0 {
+			peerEnd[i] = peerEnd[i+1]
+		} else {
+			peerEnd[i] = i + 1
+		}
+	}
+
+	out := make([]sqltypes.Row, len(rows))
+	for i := range rows {
+		out[i] = make(sqltypes.Row, 0, len(st.w.Functions)+len(rows[i]))
+	}
+	for idx, f := range st.w.Functions {
+		if err := st.evaluate(idx, f, rows, peerEnd, out); err != nil {
+			return nil, err
+		}
+	}
+	for i, row := range rows {
+		out[i] = append(out[i], row...)
+	}
+	return out, nil
+}
+
+func (st *windowState) evaluate(idx int, f *WindowFunc, rows []sqltypes.Row, peerEnd []int, out []sqltypes.Row) error {
+	total := len(rows)
+	n := st.n[idx]
+	arg := func(i int) sqltypes.Value {
+		return rows[i][f.Col]
+	}
+
+	// for the default frame, the frame of a row ends with its last peer when
+	// the window is ordered, and at the end of the partition when it's not
+	frameEnd := func(i int) int {
+		if len(st.w.OrderBy) == 0 {
+			return total
+		}
+		return peerEnd[i]
+	}
+
+	switch f.Opcode {
+	case WindowRowNumber:
+		for i := range rows {
+			out[i] = append(out[i], sqltypes.NewUint64(uint64(i+1)))
+		}
+	case WindowRank, WindowPercentRank:
+		rank := 1
+		for i := range rows {
+			if i > 0 && peerEnd[i] != peerEnd[i-1] {
+				rank = i + 1
+			}
+			if f.Opcode == WindowRank {
+				out[i] = append(out[i], sqltypes.NewUint64(uint64(rank)))
+				continue
+			}
+			var pr float64
+			if total > 1 {
+				pr = float64(rank-1) / float64(total-1)
+			}
+			out[i] = append(out[i], sqltypes.NewFloat64(pr))
+		}
+	case WindowDenseRank:
+		rank := 1
+		for i := range rows {
+			if i > 0 && peerEnd[i] != peerEnd[i-1] {
+				rank++
+			}
+			out[i] = append(out[i], sqltypes.NewUint64(uint64(rank)))
+		}
+	case WindowCumeDist:
+		for i := range rows {
+			out[i] = append(out[i], sqltypes.NewFloat64(float64(frameEnd(i))/float64(total)))
+		}
+	case WindowNtile:
+		size, remainder := int64(total)/n, int64(total)%n
+		bucket, inBucket := int64(1), int64(0)
+		for i := range rows {
+			bucketSize := size
+			if bucket <= remainder {
+				bucketSize++
+			}
+			if inBucket == bucketSize {
+				bucket++
+				inBucket = 0
+			}
+			inBucket++
+			out[i] = append(out[i], sqltypes.NewUint64(uint64(bucket)))
+		}
+	case WindowLag, WindowLead:
+		for i := range rows {
+			pos := int64(i) - n
+			if f.Opcode == WindowLead {
+				pos = int64(i) + n
+			}
+			if pos < 0 || pos >= int64(total) {
+				out[i] = append(out[i], st.defaults[idx])
+				continue
+			}
+			out[i] = append(out[i], arg(int(pos)))
+		}
+	case WindowFirstValue:
+		for i := range rows {
+			out[i] = append(out[i], arg(0))
+		}
+	case WindowLastValue:
+		for i := range rows {
+			out[i] = append(out[i], arg(frameEnd(i)-1))
+		}
+	case WindowNthValue:
+		for i := range rows {
+			if n > int64(frameEnd(i)) {
+				out[i] = append(out[i], sqltypes.NULL)
+				continue
+			}
+			out[i] = append(out[i], arg(int(n-1)))
+		}
+	case WindowAggregate:
+		return st.evaluateAggregate(f, rows, frameEnd, out)
+	default:
+		return vterrors.VT13001(fmt.Sprintf("unexpected window function opcode: %s", f.Opcode.String()))
+	}
+	return nil
+}
+
+// evaluateAggregate evaluates an aggregation over the default window frame, which
+// grows one group of peers at a time until it covers the whole partition
+func (st *windowState) evaluateAggregate(f *WindowFunc, rows []sqltypes.Row, frameEnd func(int) int, out []sqltypes.Row) error {
+	var sourceType querypb.Type = sqltypes.Null
+	if f.Col >= 0 {
+		sourceType = st.fields[f.Col].Type
+	}
+	params := &AggregateParams{
+		Opcode:       f.Aggregate,
+		Col:          f.Col,
+		KeyCol:       f.Col,
+		WCol:         -1,
+		Type:         f.Type,
+		CollationEnv: f.CollationEnv,
+	}
+	ag, err := newAggregator(params, sourceType, f.Aggregate.SQLType(sourceType))
+	if err != nil {
+		return err
+	}
+
+	added := 0
+	var current sqltypes.Value
+	for i := range rows {
+		end := frameEnd(i)
+		if end > added {
+			for ; added < end; added++ {
+				if err := ag.add(rows[added]); err != nil {
+					return err
+				}
+			}
+			current = ag.finish()
+		}
+		out[i] = append(out[i], current)
+	}
+	return nil
+}
+
+func (w *Window) description() PrimitiveDescription {
+	other := map[string]any{}
+
+	var funcs []string
+	for _, f := range w.Functions {
+		funcs = append(funcs, f.String())
+	}
+	other["Functions"] = funcs
+
+	if len(w.PartitionBy) > 0 {
+		other["PartitionBy"] = GenericJoin(w.PartitionBy, orderByParamsToString)
+	}
+	if len(w.OrderBy) > 0 {
+		other["OrderBy"] = GenericJoin(w.OrderBy, orderByParamsToString)
+	}
+
+	return PrimitiveDescription{
+		OperatorType: "Window",
+		Other:        other,
+	}
+}
+
+func (f *WindowFunc) String() string {
+	name := f.Opcode.String()
+	if f.Opcode == WindowAggregate {
+		name = f.Aggregate.String()
+	}
+
+	var args string
+	if f.Col >= 0 {
+		args = strconv.Itoa(f.Col)
+	}
+	if f.N != nil {
+		if args != "" {
+			args += ", "
+		}
+		args += sqlparser.String(f.N)
+	}
+	if f.Default != nil {
+		args += ", " + sqlparser.String(f.Default)
+	}
+
+	if f.Alias != "" {
+		return fmt.Sprintf("%s(%s) AS %s", name, args, f.Alias)
+	}
+	return fmt.Sprintf("%s(%s)", name, args)
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+	. "vitess.io/vitess/go/vt/vtgate/engine/opcode"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+)
+
+func windowTestInput() *sqltypes.Result {
+	return sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields("part|ord|val", "int64|int64|int64"),
+		"1|1|10",
+		"1|2|20",
+		"1|2|30",
+		"1|3|40",
+		"2|1|5",
+	)
+}
+
+func newTestWindow(input *sqltypes.Result, funcs ...*WindowFunc) *Window {
+	return &Window{
+		Source:      &fakePrimitive{results: []*sqltypes.Result{input}},
+		PartitionBy: evalengine.Comparison{{Col: 0, WeightStringCol: -1, CollationEnv: collations.MySQL8()}},
+		OrderBy:     evalengine.Comparison{{Col: 1, WeightStringCol: -1, CollationEnv: collations.MySQL8()}},
+		Functions:   funcs,
+	}
+}
+
+func TestWindowRanking(t *testing.T) {
+	w := newTestWindow(windowTestInput(),
+		&WindowFunc{Opcode: WindowRowNumber, Col: -1, Alias: "rn"},
+		&WindowFunc{Opcode: WindowRank, Col: -1, Alias: "rnk"},
+		&WindowFunc{Opcode: WindowDenseRank, Col: -1, Alias: "drnk"},
+		&WindowFunc{Opcode: WindowNtile, Col: -1, N: evalengine.NewLiteralInt(2), Alias: "tile"},
+	)
+
+	want := sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields("rn|rnk|drnk|tile|part|ord|val", "uint64|uint64|uint64|uint64|int64|int64|int64"),
+		"1|1|1|1|1|1|10",
+		"2|2|2|1|1|2|20",
+		"3|2|2|2|1|2|30",
+		"4|4|3|2|1|3|40",
+		"1|1|1|1|2|1|5",
+	)
+
+	result, err := w.TryExecute(context.Background(), &noopVCursor{}, nil, true)
+	require.NoError(t, err)
+	expectResult(t, result, want)
+
+	w.Source.(*fakePrimitive).rewind()
+	result, err = wrapStreamExecute(w, &noopVCursor{}, nil, true)
+	require.NoError(t, err)
+	expectResult(t, result, want)
+}
+
+func TestWindowValueFunctions(t *testing.T) {
+	w := newTestWindow(windowTestInput(),
+		&WindowFunc{Opcode: WindowLag, Col: 2, Alias: "prev"},
+		&WindowFunc{Opcode: WindowLead, Col: 2, N: evalengine.NewLiteralInt(2), Default: evalengine.NewLiteralInt(0), Alias: "next2"},
+		&WindowFunc{Opcode: WindowFirstValue, Col: 2, Alias: "first"},
+		&WindowFunc{Opcode: WindowLastValue, Col: 2, Alias: "last"},
+		&WindowFunc{Opcode: WindowNthValue, Col: 2, N: evalengine.NewLiteralInt(2), Alias: "second"},
+	)
+
+	want := sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields("prev|next2|first|last|second|part|ord|val", "int64|int64|int64|int64|int64|int64|int64|int64"),
+		"null|30|10|10|null|1|1|10",
+		"10|40|10|30|20|1|2|20",
+		"20|0|10|30|20|1|2|30",
+		"30|0|10|40|20|1|3|40",
+		"null|0|5|5|null|2|1|5",
+	)
+
+	result, err := w.TryExecute(context.Background(), &noopVCursor{}, nil, true)
+	require.NoError(t, err)
+	expectResult(t, result, want)
+
+	w.Source.(*fakePrimitive).rewind()
+	result, err = wrapStreamExecute(w, &noopVCursor{}, nil, true)
+	require.NoError(t, err)
+	expectResult(t, result, want)
+}
+
+func TestWindowAggregates(t *testing.T) {
+	w := newTestWindow(windowTestInput(),
+		&WindowFunc{Opcode: WindowAggregate, Aggregate: AggregateSum, Col: 2, Alias: "running"},
+		&WindowFunc{Opcode: WindowAggregate, Aggregate: AggregateCountStar, Col: -1, Alias: "cnt"},
+		&WindowFunc{Opcode: WindowAggregate, Aggregate: AggregateMax, Col: 2, Alias: "mx"},
+	)
+
+	want := sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields("running|cnt|mx|part|ord|val", "decimal|int64|int64|int64|int64|int64"),
+		"10|1|10|1|1|10",
+		"60|3|30|1|2|20",
+		"60|3|30|1|2|30",
+		"100|4|40|1|3|40",
+		"5|1|5|2|1|5",
+	)
+
+	result, err := w.TryExecute(context.Background(), &noopVCursor{}, nil, true)
+	require.NoError(t, err)
+	expectResult(t, result, want)
+
+	// without an ORDER BY, the frame is the whole partition
+	w.Source.(*fakePrimitive).rewind()
+	w.OrderBy = nil
+	result, err = wrapStreamExecute(w, &noopVCursor{}, nil, true)
+	require.NoError(t, err)
+	expectResult(t, result, sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields("running|cnt|mx|part|ord|val", "decimal|int64|int64|int64|int64|int64"),
+		"100|4|40|1|1|10",
+		"100|4|40|1|2|20",
+		"100|4|40|1|2|30",
+		"100|4|40|1|3|40",
+		"5|1|5|2|1|5",
+	))
+}
+
+func TestWindowDistributions(t *testing.T) {
+	w := newTestWindow(windowTestInput(),
+		&WindowFunc{Opcode: WindowPercentRank, Col: -1, Alias: "pr"},
+		&WindowFunc{Opcode: WindowCumeDist, Col: -1, Alias: "cd"},
+	)
+
+	result, err := w.TryExecute(context.Background(), &noopVCursor{}, nil, true)
+	require.NoError(t, err)
+	expectResult(t, result, sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields("pr|cd|part|ord|val", "float64|float64|int64|int64|int64"),
+		"0|0.25|1|1|10",
+		"0.3333333333333333|0.75|1|2|20",
+		"0.3333333333333333|0.75|1|2|30",
+		"1|1|1|3|40",
+		"0|1|2|1|5",
+	))
+}
+
+func TestWindowInvalidArgument(t *testing.T) {
+	tests := []struct {
+		f    *WindowFunc
+		want string
+	}{
+		{f: &WindowFunc{Opcode: WindowNtile, Col: -1, N: evalengine.NewLiteralInt(0)}, want: "Incorrect arguments to ntile"},
+		{f: &WindowFunc{Opcode: WindowLag, Col: 2, N: evalengine.NullExpr}, want: "Incorrect arguments to lag"},
+		{f: &WindowFunc{Opcode: WindowLead, Col: 2, N: evalengine.NewLiteralInt(-1)}, want: "Incorrect arguments to lead"},
+	}
+	for _, tt := range tests {
+		w := newTestWindow(windowTestInput(), tt.f)
+		_, err := w.TryExecute(context.Background(), &noopVCursor{}, nil, true)
+		assert.EqualError(t, err, tt.want)
+	}
+}
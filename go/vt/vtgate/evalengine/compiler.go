@@ -629,6 +629,30 @@ func (c *compiler) jsonExtractPath(expr IR) (*json.Path, error) {
 	return parser.ParseBytes(pathBytes.bytes)
 }
 
+// jsonExtractTransformPath returns the constant path used by a function that changes a JSON document,
+// which can't contain wildcards
+func (c *compiler) jsonExtractTransformPath(expr IR) (*json.Path, error) {
+	jp, err := c.jsonExtractPath(expr)
+	if err != nil {
+		return nil, err
+	}
+	if jp.ContainsWildcards() {
+		return nil, json.ErrInvalidPathForTransform
+	}
+	return jp, nil
+}
+
+// compileParseNullableJSON parses the JSON document at the top of the stack, unless it's NULL.
+// The returned jump, if any, must be resolved after the code that handles the parsed document.
+func (c *compiler) compileParseNullableJSON(fn string, doct ctype) (*jump, error) {
+	skip := c.compileNullCheck1(doct)
+	if doct.Type == sqltypes.Null {
+		return skip, nil
+	}
+	_, err := c.compileParseJSON(fn, doct, 1)
+	return skip, err
+}
+
 func (c *compiler) jsonExtractOneOrAll(fname string, expr IR) (jsonMatch, error) {
 	lit, ok := expr.(*Literal)
 	if !ok {
@@ -2279,6 +2279,46 @@ func (asm *assembler) Fn_JSON_KEYS(jp *json.Path) {
 	}
 }
 
+func (asm *assembler) Fn_JSON_MODIFY(fn string, t json.Transformation, paths []*json.Path) {
+	args := len(paths)
+	if t == json.Remove {
+		args = 0
+	}
+	asm.adjustStack(-args)
+	asm.emit(func(env *ExpressionEnv) int {
+		doc := env.vm.stack[env.vm.sp-args-1].(*evalJSON)
+		var values []*json.Value
+		for sp := env.vm.sp - args; sp < env.vm.sp; sp++ {
+			val, _ := env.vm.stack[sp].(*evalJSON)
+			if val == nil {
+				val = json.ValueNull
+			}
+			values = append(values, val.Clone())
+		}
+		env.vm.stack[env.vm.sp-args-1], env.vm.err = builtin_JSON_MODIFY(t, doc, paths, values)
+		env.vm.sp -= args
+		return 1
+	}, "FN %s (SP-%d)...(SP-1), [static]", fn, args+1)
+}
+
+func (asm *assembler) Fn_JSON_MERGE(fn string, patch bool, args int) {
+	asm.adjustStack(-(args - 1))
+	asm.emit(func(env *ExpressionEnv) int {
+		docs := make([]*json.Value, 0, args)
+		for sp := env.vm.sp - args; sp < env.vm.sp; sp++ {
+			doc, _ := env.vm.stack[sp].(*evalJSON)
+			docs = append(docs, doc)
+		}
+		if patch {
+			env.vm.stack[env.vm.sp-args] = builtin_JSON_MERGE_PATCH(docs)
+		} else {
+			env.vm.stack[env.vm.sp-args] = builtin_JSON_MERGE_PRESERVE(docs)
+		}
+		env.vm.sp -= args - 1
+		return 1
+	}, "FN %s (SP-%d)...(SP-1)", fn, args)
+}
+
 func (asm *assembler) Fn_JSON_OBJECT(args int) {
 	asm.adjustStack(-(args - 1))
 	asm.emit(func(env *ExpressionEnv) int {
@@ -2300,6 +2340,22 @@ func (asm *assembler) Fn_JSON_OBJECT(args int) {
 	}, "FN JSON_ARRAY (SP-%d)...(SP-1)", args)
 }
 
+func (asm *assembler) Fn_SPATIAL(fn string, args int, spatial func([]eval) (eval, error)) {
+	asm.adjustStack(-(args - 1))
+	asm.emit(func(env *ExpressionEnv) int {
+		argv := env.vm.stack[env.vm.sp-args : env.vm.sp]
+		env.vm.sp -= args - 1
+		for _, arg := range argv {
+			if arg == nil {
+				env.vm.stack[env.vm.sp-1] = nil
+				return 1
+			}
+		}
+		env.vm.stack[env.vm.sp-1], env.vm.err = spatial(argv)
+		return 1
+	}, "FN %s (SP-%d)...(SP-1)", fn, args)
+}
+
 func (asm *assembler) Fn_JSON_UNQUOTE() {
 	asm.emit(func(env *ExpressionEnv) int {
 		j := env.vm.stack[env.vm.sp-1].(*evalJSON)
@@ -4626,6 +4682,109 @@ func (asm *assembler) Fn_DATEADD_s(unit datetime.IntervalType, sub bool, col col
 
 }
 
+func (asm *assembler) Fn_STR_TO_DATE(typ sqltypes.Type, prec uint8, allowZero bool) {
+	asm.adjustStack(-1)
+	asm.emit(func(env *ExpressionEnv) int {
+		str := env.vm.stack[env.vm.sp-2].(*evalBytes)
+		format := env.vm.stack[env.vm.sp-1].(*evalBytes)
+
+		if t := strToDate(str, format, typ, prec, allowZero); t != nil {
+			env.vm.stack[env.vm.sp-2] = t
+		} else {
+			env.vm.stack[env.vm.sp-2] = nil
+		}
+		env.vm.sp--
+		return 1
+	}, "FN STR_TO_DATE VARBINARY(SP-2), VARBINARY(SP-1)")
+}
+
+func (asm *assembler) Fn_TIMESTAMPDIFF(unit datetime.IntervalType) {
+	asm.adjustStack(-1)
+	asm.emit(func(env *ExpressionEnv) int {
+		if env.vm.stack[env.vm.sp-2] == nil || env.vm.stack[env.vm.sp-1] == nil {
+			env.vm.stack[env.vm.sp-2] = nil
+			env.vm.sp--
+			return 1
+		}
+		dt1 := env.vm.stack[env.vm.sp-2].(*evalTemporal)
+		dt2 := env.vm.stack[env.vm.sp-1].(*evalTemporal)
+		if dt1.isZero() || dt2.isZero() {
+			env.vm.stack[env.vm.sp-2] = nil
+		} else {
+			env.vm.stack[env.vm.sp-2] = env.vm.arena.newEvalInt64(datetime.TimestampDiff(dt1.dt, dt2.dt, unit))
+		}
+		env.vm.sp--
+		return 1
+	}, "FN TIMESTAMPDIFF DATETIME(SP-2), DATETIME(SP-1)")
+}
+
+func (asm *assembler) Fn_DATEDIFF() {
+	asm.adjustStack(-1)
+	asm.emit(func(env *ExpressionEnv) int {
+		if env.vm.stack[env.vm.sp-2] == nil || env.vm.stack[env.vm.sp-1] == nil {
+			env.vm.stack[env.vm.sp-2] = nil
+			env.vm.sp--
+			return 1
+		}
+		d1 := env.vm.stack[env.vm.sp-2].(*evalTemporal)
+		d2 := env.vm.stack[env.vm.sp-1].(*evalTemporal)
+		env.vm.stack[env.vm.sp-2] = env.vm.arena.newEvalInt64(dateDiff(d1.dt.Date, d2.dt.Date))
+		env.vm.sp--
+		return 1
+	}, "FN DATEDIFF DATE(SP-2), DATE(SP-1)")
+}
+
+func (asm *assembler) Fn_DAYNAME(col collations.TypedCollation) {
+	asm.emit(func(env *ExpressionEnv) int {
+		if env.vm.stack[env.vm.sp-1] == nil {
+			return 1
+		}
+		arg := env.vm.stack[env.vm.sp-1].(*evalTemporal)
+		env.vm.stack[env.vm.sp-1] = env.vm.arena.newEvalText(hack.StringBytes(arg.dt.Date.Weekday().String()), col)
+		return 1
+	}, "FN DAYNAME DATE(SP-1)")
+}
+
+func (asm *assembler) Fn_PERIOD_ADD() {
+	asm.adjustStack(-1)
+	asm.emit(func(env *ExpressionEnv) int {
+		p := env.vm.stack[env.vm.sp-2].(*evalInt64)
+		n := env.vm.stack[env.vm.sp-1].(*evalInt64)
+
+		var res int64
+		res, env.vm.err = periodAdd(p.i, n.i)
+		env.vm.stack[env.vm.sp-2] = env.vm.arena.newEvalInt64(res)
+		env.vm.sp--
+		return 1
+	}, "FN PERIOD_ADD INT64(SP-2), INT64(SP-1)")
+}
+
+func (asm *assembler) Fn_PERIOD_DIFF() {
+	asm.adjustStack(-1)
+	asm.emit(func(env *ExpressionEnv) int {
+		p1 := env.vm.stack[env.vm.sp-2].(*evalInt64)
+		p2 := env.vm.stack[env.vm.sp-1].(*evalInt64)
+
+		var res int64
+		res, env.vm.err = periodDiff(p1.i, p2.i)
+		env.vm.stack[env.vm.sp-2] = env.vm.arena.newEvalInt64(res)
+		env.vm.sp--
+		return 1
+	}, "FN PERIOD_DIFF INT64(SP-2), INT64(SP-1)")
+}
+
+func (asm *assembler) Fn_GET_FORMAT(typ sqltypes.Type, col collations.TypedCollation) {
+	asm.emit(func(env *ExpressionEnv) int {
+		arg := env.vm.stack[env.vm.sp-1].(*evalBytes)
+		if f, ok := getFormat(typ, arg.string()); ok {
+			env.vm.stack[env.vm.sp-1] = env.vm.arena.newEvalText(hack.StringBytes(f), col)
+		} else {
+			env.vm.stack[env.vm.sp-1] = nil
+		}
+		return 1
+	}, "FN GET_FORMAT VARBINARY(SP-1)")
+}
+
 func (asm *assembler) Fn_REGEXP_LIKE(m *icuregex.Matcher, negate bool, c charset.Charset, offset int) {
 	asm.adjustStack(-offset)
 	asm.emit(func(env *ExpressionEnv) int {
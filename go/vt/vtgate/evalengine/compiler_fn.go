@@ -84,3 +84,13 @@ func (c *compiler) compileFn_length(arg IR, asm_ins func()) (ctype, error) {
 
 	return ctype{Type: sqltypes.Int64, Col: collationNumeric}, nil
 }
+
+func (c *compiler) compileFn_spatial(call *CallExpr, fn func([]eval) (eval, error), result ctype) (ctype, error) {
+	for _, arg := range call.Arguments {
+		if _, err := arg.compile(c); err != nil {
+			return ctype{}, err
+		}
+	}
+	c.asm.Fn_SPATIAL(call.Method, len(call.Arguments), fn)
+	return result, nil
+}
@@ -234,6 +234,38 @@ func TestCompilerSingle(t *testing.T) {
 			expression: `JSON_ARRAY(true, 1.0)`,
 			result:     `JSON("[true, 1.0]")`,
 		},
+		{
+			expression: `JSON_SET('{"a": 1}', '$.b', 2, '$.a[1]', 'x', '$.c.d', 3)`,
+			result:     `JSON("{\"a\": [1, \"x\"], \"b\": 2}")`,
+		},
+		{
+			expression: `JSON_INSERT('[1, 2]', '$[0]', 9, '$[9]', true)`,
+			result:     `JSON("[1, 2, true]")`,
+		},
+		{
+			expression: `JSON_REPLACE('{"a": {"b": 1}}', '$.a.b', JSON_ARRAY(1, 2), '$.c', 3)`,
+			result:     `JSON("{\"a\": {\"b\": [1, 2]}}")`,
+		},
+		{
+			expression: `JSON_ARRAY_APPEND('{"a": [1], "b": 2}', '$.a', 3, '$.b', NULL, '$', 'z')`,
+			result:     `JSON("[{\"a\": [1, 3], \"b\": [2, null]}, \"z\"]")`,
+		},
+		{
+			expression: `JSON_REMOVE('{"a": [1, 2, 3], "b": 2}', '$.a[last]', '$.b')`,
+			result:     `JSON("{\"a\": [1, 2]}")`,
+		},
+		{
+			expression: `JSON_MERGE_PATCH('{"a": 1, "b": 2}', '{"a": null, "c": {"d": 1}}', '{"c": {"e": 2}}')`,
+			result:     `JSON("{\"b\": 2, \"c\": {\"d\": 1, \"e\": 2}}")`,
+		},
+		{
+			expression: `JSON_MERGE_PATCH(NULL, '{"a": 1}', '[1]')`,
+			result:     `JSON("[1]")`,
+		},
+		{
+			expression: `JSON_MERGE_PRESERVE('{"a": 1}', '{"a": 2}', '[3]')`,
+			result:     `JSON("[{\"a\": [1, 2]}, 3]")`,
+		},
 		{
 			expression: `cast(true as json) + 0`,
 			result:     `FLOAT64(1)`,
@@ -57,6 +57,20 @@ type (
 	builtinJSONKeys struct {
 		CallExpr
 	}
+
+	builtinJSONModify struct {
+		CallExpr
+		transform json.Transformation
+	}
+
+	builtinJSONRemove struct {
+		CallExpr
+	}
+
+	builtinJSONMerge struct {
+		CallExpr
+		patch bool
+	}
 )
 
 var _ IR = (*builtinJSONExtract)(nil)
@@ -67,8 +81,9 @@ var _ IR = (*builtinJSONDepth)(nil)
 var _ IR = (*builtinJSONLength)(nil)
 var _ IR = (*builtinJSONContainsPath)(nil)
 var _ IR = (*builtinJSONKeys)(nil)
-
-var errInvalidPathForTransform = vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "In this situation, path expressions may not contain the * and ** tokens or an array range.")
+var _ IR = (*builtinJSONModify)(nil)
+var _ IR = (*builtinJSONRemove)(nil)
+var _ IR = (*builtinJSONMerge)(nil)
 
 func (call *builtinJSONExtract) eval(env *ExpressionEnv) (eval, error) {
 	args, err := call.args(env)
@@ -467,7 +482,7 @@ func (call *builtinJSONKeys) eval(env *ExpressionEnv) (eval, error) {
 			return nil, err
 		}
 		if jp.ContainsWildcards() {
-			return nil, errInvalidPathForTransform
+			return nil, json.ErrInvalidPathForTransform
 		}
 		jp.Match(j, false, func(value *json.Value) {
 			obj, _ = value.Object()
@@ -504,10 +519,225 @@ func (call *builtinJSONKeys) compile(c *compiler) (ctype, error) {
 			return ctype{}, err
 		}
 		if jp.ContainsWildcards() {
-			return ctype{}, errInvalidPathForTransform
+			return ctype{}, json.ErrInvalidPathForTransform
 		}
 	}
 
 	c.asm.Fn_JSON_KEYS(jp)
 	return ctype{Type: sqltypes.TypeJSON, Flag: flagNullable, Col: collationJSON}, nil
 }
+
+func (call *builtinJSONModify) eval(env *ExpressionEnv) (eval, error) {
+	args, err := call.args(env)
+	if err != nil {
+		return nil, err
+	}
+	if args[0] == nil {
+		return nil, nil
+	}
+
+	doc, err := intoJSON(call.Method, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]*json.Path, 0, len(args)/2)
+	values := make([]*json.Value, 0, len(args)/2)
+	for i := 1; i < len(args); i += 2 {
+		if args[i] == nil {
+			return nil, nil
+		}
+		jp, err := intoJSONPath(args[i])
+		if err != nil {
+			return nil, err
+		}
+		val, err := argToJSON(args[i+1])
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, jp)
+		values = append(values, val.Clone())
+	}
+	return builtin_JSON_MODIFY(call.transform, doc, paths, values)
+}
+
+// builtin_JSON_MODIFY applies the transformation to a copy of the document,
+// since the document can be shared with other expressions.
+func builtin_JSON_MODIFY(t json.Transformation, doc *json.Value, paths []*json.Path, values []*json.Value) (eval, error) {
+	result, err := json.ApplyTransform(t, doc.Clone(), paths, values)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (call *builtinJSONModify) compile(c *compiler) (ctype, error) {
+	doct, err := call.Arguments[0].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	paths := make([]*json.Path, 0, len(call.Arguments)/2)
+	for i := 1; i < len(call.Arguments); i += 2 {
+		jp, err := c.jsonExtractTransformPath(call.Arguments[i])
+		if err != nil {
+			return ctype{}, err
+		}
+		paths = append(paths, jp)
+	}
+
+	skip, err := c.compileParseNullableJSON(call.Method, doct)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	for i := 2; i < len(call.Arguments); i += 2 {
+		vt, err := call.Arguments[i].compile(c)
+		if err != nil {
+			return ctype{}, err
+		}
+		nullValue := c.compileNullCheck1(vt)
+		_, err = c.compileArgToJSON(vt, 1)
+		if err != nil {
+			return ctype{}, err
+		}
+		c.asm.jumpDestination(nullValue)
+	}
+
+	c.asm.Fn_JSON_MODIFY(call.Method, call.transform, paths)
+	c.asm.jumpDestination(skip)
+	return ctype{Type: sqltypes.TypeJSON, Flag: doct.Flag & flagNullable, Col: collationJSON}, nil
+}
+
+func (call *builtinJSONRemove) eval(env *ExpressionEnv) (eval, error) {
+	args, err := call.args(env)
+	if err != nil {
+		return nil, err
+	}
+	for _, arg := range args {
+		if arg == nil {
+			return nil, nil
+		}
+	}
+
+	doc, err := intoJSON(call.Method, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]*json.Path, 0, len(args)-1)
+	for _, arg := range args[1:] {
+		jp, err := intoJSONPath(arg)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, jp)
+	}
+	return builtin_JSON_MODIFY(json.Remove, doc, paths, nil)
+}
+
+func (call *builtinJSONRemove) compile(c *compiler) (ctype, error) {
+	doct, err := call.Arguments[0].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	paths := make([]*json.Path, 0, len(call.Arguments)-1)
+	for _, arg := range call.Arguments[1:] {
+		jp, err := c.jsonExtractTransformPath(arg)
+		if err != nil {
+			return ctype{}, err
+		}
+		paths = append(paths, jp)
+	}
+
+	skip, err := c.compileParseNullableJSON(call.Method, doct)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	c.asm.Fn_JSON_MODIFY(call.Method, json.Remove, paths)
+	c.asm.jumpDestination(skip)
+	return ctype{Type: sqltypes.TypeJSON, Flag: doct.Flag & flagNullable, Col: collationJSON}, nil
+}
+
+func (call *builtinJSONMerge) eval(env *ExpressionEnv) (eval, error) {
+	args, err := call.args(env)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([]*json.Value, 0, len(args))
+	for _, arg := range args {
+		if arg == nil {
+			docs = append(docs, nil)
+			continue
+		}
+		doc, err := intoJSON(call.Method, arg)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	if call.patch {
+		return builtin_JSON_MERGE_PATCH(docs), nil
+	}
+	return builtin_JSON_MERGE_PRESERVE(docs), nil
+}
+
+// builtin_JSON_MERGE_PATCH merges the documents, where a NULL document makes the result NULL
+// unless a later document that is not an object replaces the result.
+func builtin_JSON_MERGE_PATCH(docs []*json.Value) eval {
+	result := docs[0]
+	if result != nil {
+		result = result.Clone()
+	}
+	for _, doc := range docs[1:] {
+		switch {
+		case doc == nil:
+			result = nil
+		case doc.Type() != json.TypeObject:
+			result = doc
+		case result != nil:
+			result = json.MergePatch(result, doc.Clone())
+		}
+	}
+	if result == nil {
+		return nil
+	}
+	return result
+}
+
+func builtin_JSON_MERGE_PRESERVE(docs []*json.Value) eval {
+	var result *json.Value
+	for _, doc := range docs {
+		if doc == nil {
+			return nil
+		}
+		if result == nil {
+			result = doc.Clone()
+		} else {
+			result = json.MergePreserve(result, doc.Clone())
+		}
+	}
+	return result
+}
+
+func (call *builtinJSONMerge) compile(c *compiler) (ctype, error) {
+	var flag typeFlag
+	for _, arg := range call.Arguments {
+		doct, err := arg.compile(c)
+		if err != nil {
+			return ctype{}, err
+		}
+		skip, err := c.compileParseNullableJSON(call.Method, doct)
+		if err != nil {
+			return ctype{}, err
+		}
+		c.asm.jumpDestination(skip)
+		flag |= doct.Flag & flagNullable
+	}
+
+	c.asm.Fn_JSON_MERGE(call.Method, call.patch, len(call.Arguments))
+	return ctype{Type: sqltypes.TypeJSON, Flag: flag, Col: collationJSON}, nil
+}
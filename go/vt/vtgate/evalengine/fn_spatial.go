@@ -0,0 +1,562 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package evalengine
+
+import (
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/mysql/geometry"
+	"vitess.io/vitess/go/sqltypes"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+	"vitess.io/vitess/go/vt/vterrors"
+)
+
+// Geometry values are evaluated as binary strings of type GEOMETRY, in the storage
+// format of MySQL: the SRID of the geometry followed by its WKB representation.
+// The spatial functions parse their arguments when they are evaluated, and only
+// support geometries in the Cartesian plane, which is the SRID 0.
+
+type (
+	// builtinGeomFromText implements the ST_GeomFromText family of functions. When
+	// geomType is set, the geometry must have that type.
+	builtinGeomFromText struct {
+		CallExpr
+		geomType geometry.Type
+	}
+
+	// builtinGeomFromWKB implements the ST_GeomFromWKB family of functions. When
+	// geomType is set, the geometry must have that type.
+	builtinGeomFromWKB struct {
+		CallExpr
+		geomType geometry.Type
+	}
+
+	// builtinGeomConstructor implements POINT, LINESTRING, POLYGON, MULTIPOINT,
+	// MULTILINESTRING, MULTIPOLYGON and GEOMETRYCOLLECTION
+	builtinGeomConstructor struct {
+		CallExpr
+		geomType geometry.Type
+	}
+
+	// builtinGeomFormat implements ST_AsText and ST_AsBinary
+	builtinGeomFormat struct {
+		CallExpr
+		binary  bool
+		collate collations.ID
+	}
+
+	builtinGeomSRID struct {
+		CallExpr
+	}
+
+	builtinGeomProperty struct {
+		CallExpr
+		property geomProperty
+		collate  collations.ID
+	}
+
+	builtinSpatialRelation struct {
+		CallExpr
+		relation spatialRelation
+	}
+
+	builtinSTDistance struct {
+		CallExpr
+	}
+)
+
+var _ IR = (*builtinGeomFromText)(nil)
+var _ IR = (*builtinGeomFromWKB)(nil)
+var _ IR = (*builtinGeomConstructor)(nil)
+var _ IR = (*builtinGeomFormat)(nil)
+var _ IR = (*builtinGeomSRID)(nil)
+var _ IR = (*builtinGeomProperty)(nil)
+var _ IR = (*builtinSpatialRelation)(nil)
+var _ IR = (*builtinSTDistance)(nil)
+
+// geomProperty is a property of a geometry returned by a spatial function
+type geomProperty int
+
+const (
+	geomPropGeometryType geomProperty = iota
+	geomPropDimension
+	geomPropIsEmpty
+	geomPropEnvelope
+	geomPropX
+	geomPropY
+	geomPropStartPoint
+	geomPropEndPoint
+	geomPropPointN
+	geomPropNumPoints
+	geomPropIsClosed
+	geomPropLength
+	geomPropArea
+	geomPropExteriorRing
+	geomPropInteriorRingN
+	geomPropNumInteriorRings
+	geomPropGeometryN
+	geomPropNumGeometries
+)
+
+// spatialRelation is a relation between two geometries checked by a spatial function
+type spatialRelation int
+
+const (
+	spatialContains spatialRelation = iota
+	spatialWithin
+	spatialIntersects
+	spatialDisjoint
+)
+
+var (
+	ctypeGeometry = ctype{Type: sqltypes.Geometry, Col: collationBinary, Flag: flagNullable}
+	ctypeSpatialF = ctype{Type: sqltypes.Float64, Col: collationNumeric, Flag: flagNullable}
+	ctypeSpatialI = ctype{Type: sqltypes.Int64, Col: collationNumeric, Flag: flagNullable}
+	ctypeSpatialB = ctype{Type: sqltypes.Int64, Col: collationNumeric, Flag: flagNullable | flagIsBoolean}
+)
+
+func errGISInvalidData(fn string) error {
+	return vterrors.NewErrorf(vtrpcpb.Code_INVALID_ARGUMENT, vterrors.GISInvalidData, "Invalid GIS data provided to function %s.", fn)
+}
+
+func errSRIDNotSupported(fn string, srid uint32) error {
+	return vterrors.Errorf(vtrpcpb.Code_UNIMPLEMENTED, "%s: spatial reference system %d is not supported, only SRID 0 is", fn, srid)
+}
+
+func newEvalGeometry(g geometry.Geometry) *evalBytes {
+	return newEvalRaw(sqltypes.Geometry, geometry.Append(nil, 0, g), collationBinary)
+}
+
+// intoGeometry parses a geometry argument of the function fn
+func intoGeometry(fn string, e eval) (geometry.Geometry, error) {
+	b, ok := e.(*evalBytes)
+	if !ok {
+		return nil, errGISInvalidData(fn)
+	}
+	srid, g, err := geometry.Parse(b.bytes)
+	if err != nil {
+		return nil, errGISInvalidData(fn)
+	}
+	if srid != 0 {
+		return nil, errSRIDNotSupported(fn, srid)
+	}
+	return g, nil
+}
+
+// intoSRID checks the SRID argument of the function fn
+func intoSRID(fn string, e eval) error {
+	if srid := evalToInt64(e).i; srid != 0 {
+		return errSRIDNotSupported(fn, uint32(srid))
+	}
+	return nil
+}
+
+// evalSpatial evaluates the arguments of a spatial function and calls fn with them, which
+// is shared with the compiled version of the function. Spatial functions return NULL when
+// any of their arguments is NULL.
+func (c *CallExpr) evalSpatial(env *ExpressionEnv, fn func([]eval) (eval, error)) (eval, error) {
+	args, err := c.args(env)
+	if err != nil {
+		return nil, err
+	}
+	for _, arg := range args {
+		if arg == nil {
+			return nil, nil
+		}
+	}
+	return fn(args)
+}
+
+func (call *builtinGeomFromText) eval(env *ExpressionEnv) (eval, error) {
+	return call.evalSpatial(env, call.spatial)
+}
+
+func (call *builtinGeomFromText) spatial(args []eval) (eval, error) {
+	if len(args) > 1 {
+		if err := intoSRID(call.Method, args[1]); err != nil {
+			return nil, err
+		}
+	}
+	g, err := geometry.ParseWKT(evalToBinary(args[0]).string())
+	if err != nil || (call.geomType != 0 && g.Type() != call.geomType) {
+		return nil, errGISInvalidData(call.Method)
+	}
+	return newEvalGeometry(g), nil
+}
+
+func (call *builtinGeomFromText) compile(c *compiler) (ctype, error) {
+	return c.compileFn_spatial(&call.CallExpr, call.spatial, ctypeGeometry)
+}
+
+func (call *builtinGeomFromWKB) eval(env *ExpressionEnv) (eval, error) {
+	return call.evalSpatial(env, call.spatial)
+}
+
+func (call *builtinGeomFromWKB) spatial(args []eval) (eval, error) {
+	if len(args) > 1 {
+		if err := intoSRID(call.Method, args[1]); err != nil {
+			return nil, err
+		}
+	}
+	g, err := geometry.ParseWKB(evalToBinary(args[0]).bytes)
+	if err != nil || (call.geomType != 0 && g.Type() != call.geomType) {
+		return nil, errGISInvalidData(call.Method)
+	}
+	return newEvalGeometry(g), nil
+}
+
+func (call *builtinGeomFromWKB) compile(c *compiler) (ctype, error) {
+	return c.compileFn_spatial(&call.CallExpr, call.spatial, ctypeGeometry)
+}
+
+func (call *builtinGeomConstructor) eval(env *ExpressionEnv) (eval, error) {
+	return call.evalSpatial(env, call.spatial)
+}
+
+func (call *builtinGeomConstructor) spatial(args []eval) (eval, error) {
+	if call.geomType == geometry.TypePoint {
+		x, _ := evalToFloat(args[0])
+		y, _ := evalToFloat(args[1])
+		return newEvalGeometry(geometry.Point{X: x.f, Y: y.f}), nil
+	}
+
+	geoms := make([]geometry.Geometry, 0, len(args))
+	for _, arg := range args {
+		g, err := intoGeometry(call.Method, arg)
+		if err != nil {
+			return nil, err
+		}
+		geoms = append(geoms, g)
+	}
+
+	var g geometry.Geometry
+	switch call.geomType {
+	case geometry.TypeLineString:
+		ls := make(geometry.LineString, 0, len(geoms))
+		for _, sub := range geoms {
+			p, ok := sub.(geometry.Point)
+			if !ok {
+				return nil, errGISInvalidData(call.Method)
+			}
+			ls = append(ls, p)
+		}
+		g = ls
+	case geometry.TypePolygon:
+		poly := make(geometry.Polygon, 0, len(geoms))
+		for _, sub := range geoms {
+			ring, ok := sub.(geometry.LineString)
+			if !ok {
+				return nil, errGISInvalidData(call.Method)
+			}
+			poly = append(poly, ring)
+		}
+		g = poly
+	case geometry.TypeMultiPoint:
+		mp := make(geometry.MultiPoint, 0, len(geoms))
+		for _, sub := range geoms {
+			p, ok := sub.(geometry.Point)
+			if !ok {
+				return nil, errGISInvalidData(call.Method)
+			}
+			mp = append(mp, p)
+		}
+		g = mp
+	case geometry.TypeMultiLineString:
+		mls := make(geometry.MultiLineString, 0, len(geoms))
+		for _, sub := range geoms {
+			ls, ok := sub.(geometry.LineString)
+			if !ok {
+				return nil, errGISInvalidData(call.Method)
+			}
+			mls = append(mls, ls)
+		}
+		g = mls
+	case geometry.TypeMultiPolygon:
+		mp := make(geometry.MultiPolygon, 0, len(geoms))
+		for _, sub := range geoms {
+			poly, ok := sub.(geometry.Polygon)
+			if !ok {
+				return nil, errGISInvalidData(call.Method)
+			}
+			mp = append(mp, poly)
+		}
+		g = mp
+	default:
+		g = geometry.GeometryCollection(geoms)
+	}
+
+	// validate the new geometry the same way as the geometries that are parsed
+	raw := geometry.AppendWKB(nil, g)
+	if _, err := geometry.ParseWKB(raw); err != nil {
+		return nil, errGISInvalidData(call.Method)
+	}
+	return newEvalGeometry(g), nil
+}
+
+func (call *builtinGeomConstructor) compile(c *compiler) (ctype, error) {
+	return c.compileFn_spatial(&call.CallExpr, call.spatial, ctypeGeometry)
+}
+
+func (call *builtinGeomFormat) eval(env *ExpressionEnv) (eval, error) {
+	return call.evalSpatial(env, call.spatial)
+}
+
+func (call *builtinGeomFormat) spatial(args []eval) (eval, error) {
+	g, err := intoGeometry(call.Method, args[0])
+	if err != nil {
+		return nil, err
+	}
+	if call.binary {
+		return newEvalRaw(sqltypes.Blob, geometry.AppendWKB(nil, g), collationBinary), nil
+	}
+	return newEvalRaw(sqltypes.Text, geometry.AppendWKT(nil, g), typedCoercionCollation(sqltypes.Text, call.collate)), nil
+}
+
+func (call *builtinGeomFormat) compile(c *compiler) (ctype, error) {
+	if call.binary {
+		return c.compileFn_spatial(&call.CallExpr, call.spatial, ctype{Type: sqltypes.Blob, Col: collationBinary, Flag: flagNullable})
+	}
+	return c.compileFn_spatial(&call.CallExpr, call.spatial, ctype{Type: sqltypes.Text, Col: typedCoercionCollation(sqltypes.Text, call.collate), Flag: flagNullable})
+}
+
+func (call *builtinGeomSRID) eval(env *ExpressionEnv) (eval, error) {
+	return call.evalSpatial(env, call.spatial)
+}
+
+// spatial returns the SRID of the geometry, which can be any SRID, or sets it when
+// called with two arguments
+func (call *builtinGeomSRID) spatial(args []eval) (eval, error) {
+	b, ok := args[0].(*evalBytes)
+	if !ok {
+		return nil, errGISInvalidData(call.Method)
+	}
+	srid, g, err := geometry.Parse(b.bytes)
+	if err != nil {
+		return nil, errGISInvalidData(call.Method)
+	}
+	if len(args) == 1 {
+		return newEvalUint64(uint64(srid)), nil
+	}
+	if err := intoSRID(call.Method, args[1]); err != nil {
+		return nil, err
+	}
+	return newEvalGeometry(g), nil
+}
+
+func (call *builtinGeomSRID) compile(c *compiler) (ctype, error) {
+	if len(call.Arguments) > 1 {
+		return c.compileFn_spatial(&call.CallExpr, call.spatial, ctypeGeometry)
+	}
+	return c.compileFn_spatial(&call.CallExpr, call.spatial, ctype{Type: sqltypes.Uint64, Col: collationNumeric, Flag: flagNullable})
+}
+
+func (call *builtinGeomProperty) eval(env *ExpressionEnv) (eval, error) {
+	return call.evalSpatial(env, call.spatial)
+}
+
+// geomAt returns the item at the 1-based position given by arg, or false when it is out of range
+func geomAt[T any](items []T, arg eval) (T, bool) {
+	var zero T
+	n := evalToInt64(arg).i
+	if n < 1 || n > int64(len(items)) {
+		return zero, false
+	}
+	return items[n-1], true
+}
+
+func (call *builtinGeomProperty) spatial(args []eval) (eval, error) {
+	g, err := intoGeometry(call.Method, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	switch call.property {
+	case geomPropGeometryType:
+		return newEvalText([]byte(g.Type().String()), typedCoercionCollation(sqltypes.VarChar, call.collate)), nil
+	case geomPropDimension:
+		return newEvalInt64(int64(geometry.Dimension(g))), nil
+	case geomPropIsEmpty:
+		return newEvalBool(geometry.IsEmpty(g)), nil
+	case geomPropEnvelope:
+		return newEvalGeometry(geometry.Envelope(g)), nil
+	case geomPropX, geomPropY:
+		p, ok := g.(geometry.Point)
+		if !ok {
+			return nil, errGISInvalidData(call.Method)
+		}
+		if len(args) > 1 {
+			f, _ := evalToFloat(args[1])
+			if call.property == geomPropX {
+				p.X = f.f
+			} else {
+				p.Y = f.f
+			}
+			return newEvalGeometry(p), nil
+		}
+		if call.property == geomPropX {
+			return newEvalFloat(p.X), nil
+		}
+		return newEvalFloat(p.Y), nil
+	case geomPropStartPoint, geomPropEndPoint, geomPropPointN, geomPropNumPoints:
+		ls, ok := g.(geometry.LineString)
+		if !ok {
+			return nil, nil
+		}
+		switch call.property {
+		case geomPropStartPoint:
+			return newEvalGeometry(ls[0]), nil
+		case geomPropEndPoint:
+			return newEvalGeometry(ls[len(ls)-1]), nil
+		case geomPropPointN:
+			p, ok := geomAt(ls, args[1])
+			if !ok {
+				return nil, nil
+			}
+			return newEvalGeometry(p), nil
+		default:
+			return newEvalInt64(int64(len(ls))), nil
+		}
+	case geomPropIsClosed:
+		switch g.(type) {
+		case geometry.LineString, geometry.MultiLineString:
+			return newEvalBool(geometry.IsClosed(g)), nil
+		}
+		return nil, nil
+	case geomPropLength:
+		length, ok := geometry.Length(g)
+		if !ok {
+			return nil, errGISInvalidData(call.Method)
+		}
+		return newEvalFloat(length), nil
+	case geomPropArea:
+		area, ok := geometry.Area(g)
+		if !ok {
+			return nil, errGISInvalidData(call.Method)
+		}
+		return newEvalFloat(area), nil
+	case geomPropExteriorRing, geomPropInteriorRingN, geomPropNumInteriorRings:
+		poly, ok := g.(geometry.Polygon)
+		if !ok {
+			return nil, nil
+		}
+		switch call.property {
+		case geomPropExteriorRing:
+			return newEvalGeometry(poly[0]), nil
+		case geomPropInteriorRingN:
+			ring, ok := geomAt(poly[1:], args[1])
+			if !ok {
+				return nil, nil
+			}
+			return newEvalGeometry(ring), nil
+		default:
+			return newEvalInt64(int64(len(poly) - 1)), nil
+		}
+	case geomPropGeometryN:
+		sub, ok := geomAt(geometry.Geometries(g), args[1])
+		if !ok {
+			return nil, nil
+		}
+		return newEvalGeometry(sub), nil
+	case geomPropNumGeometries:
+		return newEvalInt64(int64(len(geometry.Geometries(g)))), nil
+	default:
+		return nil, vterrors.Errorf(vtrpcpb.Code_INTERNAL, "unexpected geometry property %d", call.property)
+	}
+}
+
+func (call *builtinGeomProperty) compile(c *compiler) (ctype, error) {
+	var ct ctype
+	switch call.property {
+	case geomPropGeometryType:
+		ct = ctype{Type: sqltypes.VarChar, Col: typedCoercionCollation(sqltypes.VarChar, call.collate), Flag: flagNullable}
+	case geomPropDimension, geomPropNumPoints, geomPropNumInteriorRings, geomPropNumGeometries:
+		ct = ctypeSpatialI
+	case geomPropIsEmpty, geomPropIsClosed:
+		ct = ctypeSpatialB
+	case geomPropLength, geomPropArea:
+		ct = ctypeSpatialF
+	case geomPropX, geomPropY:
+		ct = ctypeSpatialF
+		if len(call.Arguments) > 1 {
+			ct = ctypeGeometry
+		}
+	default:
+		ct = ctypeGeometry
+	}
+	return c.compileFn_spatial(&call.CallExpr, call.spatial, ct)
+}
+
+func (call *builtinSpatialRelation) eval(env *ExpressionEnv) (eval, error) {
+	return call.evalSpatial(env, call.spatial)
+}
+
+func (call *builtinSpatialRelation) spatial(args []eval) (eval, error) {
+	g1, err := intoGeometry(call.Method, args[0])
+	if err != nil {
+		return nil, err
+	}
+	g2, err := intoGeometry(call.Method, args[1])
+	if err != nil {
+		return nil, err
+	}
+	if geometry.IsEmpty(g1) || geometry.IsEmpty(g2) {
+		return nil, nil
+	}
+
+	var result bool
+	switch call.relation {
+	case spatialContains:
+		result, err = geometry.Contains(g1, g2)
+	case spatialWithin:
+		result, err = geometry.Contains(g2, g1)
+	case spatialIntersects:
+		result = geometry.Intersects(g1, g2)
+	case spatialDisjoint:
+		result = !geometry.Intersects(g1, g2)
+	}
+	if err != nil {
+		return nil, vterrors.Errorf(vtrpcpb.Code_UNIMPLEMENTED, "%s: %v are not supported", call.Method, err)
+	}
+	return newEvalBool(result), nil
+}
+
+func (call *builtinSpatialRelation) compile(c *compiler) (ctype, error) {
+	return c.compileFn_spatial(&call.CallExpr, call.spatial, ctypeSpatialB)
+}
+
+func (call *builtinSTDistance) eval(env *ExpressionEnv) (eval, error) {
+	return call.evalSpatial(env, call.spatial)
+}
+
+func (call *builtinSTDistance) spatial(args []eval) (eval, error) {
+	g1, err := intoGeometry(call.Method, args[0])
+	if err != nil {
+		return nil, err
+	}
+	g2, err := intoGeometry(call.Method, args[1])
+	if err != nil {
+		return nil, err
+	}
+	dist, ok := geometry.Distance(g1, g2)
+	if !ok {
+		return nil, nil
+	}
+	return newEvalFloat(dist), nil
+}
+
+func (call *builtinSTDistance) compile(c *compiler) (ctype, error) {
+	return c.compileFn_spatial(&call.CallExpr, call.spatial, ctypeSpatialF)
+}
@@ -18,6 +18,7 @@ package evalengine
 
 import (
 	"math"
+	"strings"
 	"time"
 
 	"vitess.io/vitess/go/hack"
@@ -25,6 +26,8 @@ import (
 	"vitess.io/vitess/go/mysql/datetime"
 	"vitess.io/vitess/go/mysql/decimal"
 	"vitess.io/vitess/go/sqltypes"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+	"vitess.io/vitess/go/vt/vterrors"
 )
 
 var SystemTime = time.Now
@@ -179,6 +182,40 @@ type (
 		unit    datetime.IntervalType
 		collate collations.ID
 	}
+
+	builtinStrToDate struct {
+		CallExpr
+		typ  sqltypes.Type
+		prec uint8
+	}
+
+	builtinTimestampDiff struct {
+		CallExpr
+		unit datetime.IntervalType
+	}
+
+	builtinDateDiff struct {
+		CallExpr
+	}
+
+	builtinDayName struct {
+		CallExpr
+		collate collations.ID
+	}
+
+	builtinPeriodAdd struct {
+		CallExpr
+	}
+
+	builtinPeriodDiff struct {
+		CallExpr
+	}
+
+	builtinGetFormat struct {
+		CallExpr
+		typ     sqltypes.Type
+		collate collations.ID
+	}
 )
 
 var _ IR = (*builtinNow)(nil)
@@ -213,6 +250,13 @@ var _ IR = (*builtinWeekDay)(nil)
 var _ IR = (*builtinWeekOfYear)(nil)
 var _ IR = (*builtinYear)(nil)
 var _ IR = (*builtinYearWeek)(nil)
+var _ IR = (*builtinStrToDate)(nil)
+var _ IR = (*builtinTimestampDiff)(nil)
+var _ IR = (*builtinDateDiff)(nil)
+var _ IR = (*builtinDayName)(nil)
+var _ IR = (*builtinPeriodAdd)(nil)
+var _ IR = (*builtinPeriodDiff)(nil)
+var _ IR = (*builtinGetFormat)(nil)
 
 func (call *builtinNow) eval(env *ExpressionEnv) (eval, error) {
 	now := env.time(call.utc)
@@ -2028,3 +2072,424 @@ func (call *builtinDateMath) compile(c *compiler) (ctype, error) {
 	}
 	return ret, nil
 }
+
+// strToDateType returns the type and precision of the values returned by STR_TO_DATE
+// with a constant format, which depend on the parts of the value that the format parses.
+func strToDateType(format string) (sqltypes.Type, uint8) {
+	date, time, frac := datetime.StrToDateParts(format)
+	switch {
+	case date && frac:
+		return sqltypes.Datetime, datetime.DefaultPrecision
+	case frac:
+		return sqltypes.Time, datetime.DefaultPrecision
+	case date && time:
+		return sqltypes.Datetime, 0
+	case time:
+		return sqltypes.Time, 0
+	default:
+		return sqltypes.Date, 0
+	}
+}
+
+func strToDate(str, format *evalBytes, typ sqltypes.Type, prec uint8, allowZero bool) *evalTemporal {
+	dt, ok := datetime.StrToDate(str.string(), format.string())
+	if !ok {
+		return nil
+	}
+	if typ == sqltypes.Time {
+		return newEvalTime(dt.TimeWithDays(), int(prec))
+	}
+	if !allowZero && (dt.Date.Year() == 0 || dt.Date.Month() == 0 || dt.Date.Day() == 0) {
+		return nil
+	}
+	if typ == sqltypes.Date {
+		return newEvalDate(dt.Date, true)
+	}
+	return newEvalDateTime(dt, int(prec), true)
+}
+
+func (call *builtinStrToDate) eval(env *ExpressionEnv) (eval, error) {
+	str, format, err := call.arg2(env)
+	if err != nil {
+		return nil, err
+	}
+	if str == nil || format == nil {
+		return nil, nil
+	}
+	if t := strToDate(evalToBinary(str), evalToBinary(format), call.typ, call.prec, env.sqlmode.AllowZeroDate()); t != nil {
+		return t, nil
+	}
+	return nil, nil
+}
+
+func (call *builtinStrToDate) compile(c *compiler) (ctype, error) {
+	str, err := call.Arguments[0].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	skip1 := c.compileNullCheck1(str)
+
+	switch str.Type {
+	case sqltypes.VarChar, sqltypes.VarBinary:
+	default:
+		c.asm.Convert_xb(1, sqltypes.VarBinary, nil)
+	}
+
+	format, err := call.Arguments[1].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	skip2 := c.compileNullCheck1r(format)
+
+	switch format.Type {
+	case sqltypes.VarChar, sqltypes.VarBinary:
+	default:
+		c.asm.Convert_xb(1, sqltypes.VarBinary, nil)
+	}
+
+	c.asm.Fn_STR_TO_DATE(call.typ, call.prec, c.sqlmode.AllowZeroDate())
+	c.asm.jumpDestination(skip1, skip2)
+	return ctype{Type: call.typ, Size: int32(call.prec), Col: collationBinary, Flag: flagNullable}, nil
+}
+
+func (call *builtinTimestampDiff) eval(env *ExpressionEnv) (eval, error) {
+	arg1, arg2, err := call.arg2(env)
+	if err != nil {
+		return nil, err
+	}
+	if arg1 == nil || arg2 == nil {
+		return nil, nil
+	}
+	dt1 := evalToDateTime(arg1, datetime.DefaultPrecision, env.now, false)
+	dt2 := evalToDateTime(arg2, datetime.DefaultPrecision, env.now, false)
+	if dt1 == nil || dt2 == nil || dt1.isZero() || dt2.isZero() {
+		return nil, nil
+	}
+	return newEvalInt64(datetime.TimestampDiff(dt1.dt, dt2.dt, call.unit)), nil
+}
+
+func (call *builtinTimestampDiff) compile(c *compiler) (ctype, error) {
+	arg1, err := call.Arguments[0].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	skip1 := c.compileNullCheck1(arg1)
+
+	switch arg1.Type {
+	case sqltypes.Datetime, sqltypes.Date:
+	default:
+		c.asm.Convert_xDT(1, datetime.DefaultPrecision, false)
+	}
+
+	arg2, err := call.Arguments[1].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	skip2 := c.compileNullCheck1r(arg2)
+
+	switch arg2.Type {
+	case sqltypes.Datetime, sqltypes.Date:
+	default:
+		c.asm.Convert_xDT(1, datetime.DefaultPrecision, false)
+	}
+
+	c.asm.Fn_TIMESTAMPDIFF(call.unit)
+	c.asm.jumpDestination(skip1, skip2)
+	return ctype{Type: sqltypes.Int64, Col: collationNumeric, Flag: flagNullable}, nil
+}
+
+func (call *builtinDateDiff) eval(env *ExpressionEnv) (eval, error) {
+	arg1, arg2, err := call.arg2(env)
+	if err != nil {
+		return nil, err
+	}
+	if arg1 == nil || arg2 == nil {
+		return nil, nil
+	}
+	d1 := evalToDate(arg1, env.now, false)
+	d2 := evalToDate(arg2, env.now, false)
+	if d1 == nil || d2 == nil {
+		return nil, nil
+	}
+	return newEvalInt64(dateDiff(d1.dt.Date, d2.dt.Date)), nil
+}
+
+func dateDiff(d1, d2 datetime.Date) int64 {
+	return int64(datetime.MysqlDayNumber(d1.Year(), d1.Month(), d1.Day()) - datetime.MysqlDayNumber(d2.Year(), d2.Month(), d2.Day()))
+}
+
+func (call *builtinDateDiff) compile(c *compiler) (ctype, error) {
+	arg1, err := call.Arguments[0].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	skip1 := c.compileNullCheck1(arg1)
+
+	switch arg1.Type {
+	case sqltypes.Date, sqltypes.Datetime:
+	default:
+		c.asm.Convert_xD(1, false)
+	}
+
+	arg2, err := call.Arguments[1].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	skip2 := c.compileNullCheck1r(arg2)
+
+	switch arg2.Type {
+	case sqltypes.Date, sqltypes.Datetime:
+	default:
+		c.asm.Convert_xD(1, false)
+	}
+
+	c.asm.Fn_DATEDIFF()
+	c.asm.jumpDestination(skip1, skip2)
+	return ctype{Type: sqltypes.Int64, Col: collationNumeric, Flag: flagNullable}, nil
+}
+
+func (b *builtinDayName) eval(env *ExpressionEnv) (eval, error) {
+	date, err := b.arg1(env)
+	if err != nil {
+		return nil, err
+	}
+	if date == nil {
+		return nil, nil
+	}
+	d := evalToDate(date, env.now, false)
+	if d == nil {
+		return nil, nil
+	}
+	return newEvalText(hack.StringBytes(d.dt.Date.Weekday().String()), typedCoercionCollation(sqltypes.VarChar, b.collate)), nil
+}
+
+func (call *builtinDayName) compile(c *compiler) (ctype, error) {
+	arg, err := call.Arguments[0].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	skip := c.compileNullCheck1(arg)
+
+	switch arg.Type {
+	case sqltypes.Date, sqltypes.Datetime:
+	default:
+		c.asm.Convert_xD(1, false)
+	}
+	col := typedCoercionCollation(sqltypes.VarChar, call.collate)
+	c.asm.Fn_DAYNAME(col)
+	c.asm.jumpDestination(skip)
+	return ctype{Type: sqltypes.VarChar, Col: col, Flag: arg.Flag | flagNullable}, nil
+}
+
+func errIncorrectArguments(fn string) error {
+	return vterrors.NewErrorf(vtrpcpb.Code_INVALID_ARGUMENT, vterrors.WrongArguments, "Incorrect arguments to %s", fn)
+}
+
+// validPeriod returns whether p is a period in the YYMM or YYYYMM formats
+func validPeriod(p int64) bool {
+	return p > 0 && p%100 > 0 && p%100 <= 12
+}
+
+func periodToMonths(p int64) int64 {
+	year := p / 100
+	if year < 100 {
+		if year < 70 {
+			year += 2000
+		} else {
+			year += 1900
+		}
+	}
+	return year*12 + p%100 - 1
+}
+
+func monthsToPeriod(m int64) int64 {
+	if m <= 0 {
+		return 0
+	}
+	year := m / 12
+	if year < 100 {
+		if year < 70 {
+			year += 2000
+		} else {
+			year += 1900
+		}
+	}
+	return year*100 + m%12 + 1
+}
+
+func periodAdd(p, n int64) (int64, error) {
+	if !validPeriod(p) {
+		return 0, errIncorrectArguments("period_add")
+	}
+	return monthsToPeriod(periodToMonths(p) + n), nil
+}
+
+func periodDiff(p1, p2 int64) (int64, error) {
+	if !validPeriod(p1) || !validPeriod(p2) {
+		return 0, errIncorrectArguments("period_diff")
+	}
+	return periodToMonths(p1) - periodToMonths(p2), nil
+}
+
+func (call *builtinPeriodAdd) eval(env *ExpressionEnv) (eval, error) {
+	p, n, err := call.arg2(env)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil || n == nil {
+		return nil, nil
+	}
+	res, err := periodAdd(evalToInt64(p).i, evalToInt64(n).i)
+	if err != nil {
+		return nil, err
+	}
+	return newEvalInt64(res), nil
+}
+
+func (call *builtinPeriodAdd) compile(c *compiler) (ctype, error) {
+	p, err := call.Arguments[0].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	n, err := call.Arguments[1].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	skip := c.compileNullCheck2(p, n)
+
+	switch p.Type {
+	case sqltypes.Int64:
+	default:
+		c.asm.Convert_xi(2)
+	}
+
+	switch n.Type {
+	case sqltypes.Int64:
+	default:
+		c.asm.Convert_xi(1)
+	}
+
+	c.asm.Fn_PERIOD_ADD()
+	c.asm.jumpDestination(skip)
+	return ctype{Type: sqltypes.Int64, Col: collationNumeric, Flag: nullableFlags(p.Flag | n.Flag)}, nil
+}
+
+func (call *builtinPeriodDiff) eval(env *ExpressionEnv) (eval, error) {
+	p1, p2, err := call.arg2(env)
+	if err != nil {
+		return nil, err
+	}
+	if p1 == nil || p2 == nil {
+		return nil, nil
+	}
+	res, err := periodDiff(evalToInt64(p1).i, evalToInt64(p2).i)
+	if err != nil {
+		return nil, err
+	}
+	return newEvalInt64(res), nil
+}
+
+func (call *builtinPeriodDiff) compile(c *compiler) (ctype, error) {
+	p1, err := call.Arguments[0].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	p2, err := call.Arguments[1].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	skip := c.compileNullCheck2(p1, p2)
+
+	switch p1.Type {
+	case sqltypes.Int64:
+	default:
+		c.asm.Convert_xi(2)
+	}
+
+	switch p2.Type {
+	case sqltypes.Int64:
+	default:
+		c.asm.Convert_xi(1)
+	}
+
+	c.asm.Fn_PERIOD_DIFF()
+	c.asm.jumpDestination(skip)
+	return ctype{Type: sqltypes.Int64, Col: collationNumeric, Flag: nullableFlags(p1.Flag | p2.Flag)}, nil
+}
+
+// getFormat returns the format string of GET_FORMAT for a temporal type and a locale,
+// which can be used with DATE_FORMAT and STR_TO_DATE.
+func getFormat(typ sqltypes.Type, locale string) (string, bool) {
+	var formats [5]string
+	switch typ {
+	case sqltypes.Date:
+		formats = [...]string{"%m.%d.%Y", "%Y-%m-%d", "%Y-%m-%d", "%d.%m.%Y", "%Y%m%d"}
+	case sqltypes.Datetime:
+		formats = [...]string{"%Y-%m-%d %H.%i.%s", "%Y-%m-%d %H:%i:%s", "%Y-%m-%d %H:%i:%s", "%Y-%m-%d %H.%i.%s", "%Y%m%d%H%i%s"}
+	case sqltypes.Time:
+		formats = [...]string{"%h:%i:%s %p", "%H:%i:%s", "%H:%i:%s", "%H.%i.%s", "%H%i%s"}
+	default:
+		return "", false
+	}
+
+	switch strings.ToUpper(locale) {
+	case "USA":
+		return formats[0], true
+	case "JIS":
+		return formats[1], true
+	case "ISO":
+		return formats[2], true
+	case "EUR":
+		return formats[3], true
+	case "INTERNAL":
+		return formats[4], true
+	default:
+		return "", false
+	}
+}
+
+func (call *builtinGetFormat) eval(env *ExpressionEnv) (eval, error) {
+	locale, err := call.arg1(env)
+	if err != nil {
+		return nil, err
+	}
+	if locale == nil {
+		return nil, nil
+	}
+	f, ok := getFormat(call.typ, evalToBinary(locale).string())
+	if !ok {
+		return nil, nil
+	}
+	return newEvalText(hack.StringBytes(f), typedCoercionCollation(sqltypes.VarChar, call.collate)), nil
+}
+
+func (call *builtinGetFormat) compile(c *compiler) (ctype, error) {
+	arg, err := call.Arguments[0].compile(c)
+	if err != nil {
+		return ctype{}, err
+	}
+
+	skip := c.compileNullCheck1(arg)
+
+	switch arg.Type {
+	case sqltypes.VarChar, sqltypes.VarBinary:
+	default:
+		c.asm.Convert_xb(1, sqltypes.VarBinary, nil)
+	}
+
+	col := typedCoercionCollation(sqltypes.VarChar, call.collate)
+	c.asm.Fn_GET_FORMAT(call.typ, col)
+	c.asm.jumpDestination(skip)
+	return ctype{Type: sqltypes.VarChar, Col: col, Flag: flagNullable}, nil
+}
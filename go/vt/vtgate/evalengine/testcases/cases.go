@@ -33,6 +33,11 @@ var Cases = []TestCase{
 	{Run: JSONPathOperations},
 	{Run: JSONArray},
 	{Run: JSONObject},
+	{Run: JSONModification},
+	{Run: JSONMerge},
+	{Run: FnSpatialConstructors},
+	{Run: FnSpatialProperties},
+	{Run: FnSpatialRelations},
 	{Run: CharsetConversionOperators},
 	{Run: CaseExprWithPredicate},
 	{Run: CaseExprWithValue},
@@ -155,6 +160,13 @@ var Cases = []TestCase{
 	{Run: FnWeekOfYear},
 	{Run: FnYear},
 	{Run: FnYearWeek},
+	{Run: FnStrToDate},
+	{Run: FnTimestampDiff},
+	{Run: FnDateDiff},
+	{Run: FnDayName},
+	{Run: FnPeriodAdd},
+	{Run: FnPeriodDiff},
+	{Run: FnGetFormat},
 	{Run: FnInetAton},
 	{Run: FnInetNtoa},
 	{Run: FnInet6Aton},
@@ -212,6 +224,153 @@ func JSONObject(yield Query) {
 	yield("JSON_OBJECT()", nil)
 }
 
+func JSONModification(yield Query) {
+	for _, obj := range inputJSONObjects {
+		for _, fn := range []string{"JSON_SET", "JSON_INSERT", "JSON_REPLACE", "JSON_ARRAY_APPEND"} {
+			for _, path1 := range inputJSONPaths {
+				yield(fmt.Sprintf("%s('%s', '%s', 1)", fn, obj, path1), nil)
+				yield(fmt.Sprintf("%s('%s', '%s', 'foo', '%s.x', NULL)", fn, obj, path1, path1), nil)
+			}
+		}
+		for _, path1 := range inputJSONPaths {
+			yield(fmt.Sprintf("JSON_REMOVE('%s', '%s')", obj, path1), nil)
+			for _, path2 := range inputJSONPaths {
+				yield(fmt.Sprintf("JSON_REMOVE('%s', '%s', '%s')", obj, path1, path2), nil)
+			}
+		}
+	}
+}
+
+func JSONMerge(yield Query) {
+	docs := []string{"NULL", "'1'", "'[]'", "'{}'", `'{"a": null}'`}
+	for _, obj := range inputJSONObjects {
+		docs = append(docs, "'"+obj+"'")
+	}
+	for _, fn := range []string{"JSON_MERGE_PATCH", "JSON_MERGE_PRESERVE"} {
+		for _, a := range docs {
+			for _, b := range docs {
+				yield(fmt.Sprintf("%s(%s, %s)", fn, a, b), nil)
+			}
+		}
+		yield(fmt.Sprintf("%s(NULL, '{}', '1')", fn), nil)
+	}
+}
+
+func FnSpatialConstructors(yield Query) {
+	for _, wkt := range inputGeometries {
+		yield(fmt.Sprintf("ST_AsText(ST_GeomFromText('%s'))", wkt), nil)
+		yield(fmt.Sprintf("ST_AsText(ST_GeomFromWKB(ST_AsBinary(ST_GeomFromText('%s'))))", wkt), nil)
+		yield(fmt.Sprintf("HEX(ST_AsBinary(ST_GeomFromText('%s')))", wkt), nil)
+	}
+
+	mysqlDocSamples := []string{
+		`ST_AsText(ST_PointFromText('POINT(1 1)'))`,
+		`ST_AsText(ST_PointFromText('LINESTRING(0 0,1 1)'))`,
+		`ST_AsText(ST_LineFromText('LINESTRING(0 0,1 1)'))`,
+		`ST_AsText(ST_PolyFromText('POLYGON((0 0,1 0,1 1,0 0))'))`,
+		`ST_AsText(ST_MPointFromText('MULTIPOINT(1 1,2 2)'))`,
+		`ST_AsText(ST_GeomCollFromText('GEOMETRYCOLLECTION(POINT(1 1))'))`,
+		`ST_AsText(ST_GeomFromText('POINT(1 1)', 0))`,
+		`ST_AsText(ST_GeomFromText('POINT(1)'))`,
+		`ST_AsText(ST_GeomFromText('POLYGON((0 0,1 0,1 1))'))`,
+		`ST_AsText(ST_GeomFromText(NULL))`,
+		`ST_AsText(ST_GeomFromWKB(0x0101000000000000000000F03F0000000000000040))`,
+		`ST_AsText(ST_GeomFromWKB(0x00000000013FF00000000000004000000000000000))`,
+		`ST_AsText(ST_GeomFromWKB(0x0101))`,
+		`ST_AsText(Point(1, 2))`,
+		`ST_AsText(Point('1.5', 2))`,
+		`ST_AsText(Point(NULL, 2))`,
+		`ST_AsText(LineString(Point(0, 0), Point(1, 1)))`,
+		`ST_AsText(LineString(Point(0, 0)))`,
+		`ST_AsText(Polygon(LineString(Point(0, 0), Point(1, 0), Point(1, 1), Point(0, 0))))`,
+		`ST_AsText(Polygon(LineString(Point(0, 0), Point(1, 0), Point(1, 1))))`,
+		`ST_AsText(MultiPoint(Point(0, 0), Point(1, 1)))`,
+		`ST_AsText(MultiLineString(LineString(Point(0, 0), Point(1, 1))))`,
+		`ST_AsText(MultiPolygon(Polygon(LineString(Point(0, 0), Point(1, 0), Point(1, 1), Point(0, 0)))))`,
+		`ST_AsText(GeometryCollection(Point(1, 1), LineString(Point(0, 0), Point(1, 1))))`,
+		`ST_AsText(GeometryCollection())`,
+		`ST_AsText(LineString(1, 2))`,
+		`ST_AsText('foobar')`,
+		`ST_SRID(Point(1, 1))`,
+		`ST_SRID(0xE61000000101000000000000000000F03F0000000000000040)`,
+		`ST_AsText(ST_SRID(Point(1, 1), 0))`,
+	}
+
+	for _, q := range mysqlDocSamples {
+		yield(q, nil)
+	}
+}
+
+func FnSpatialProperties(yield Query) {
+	for _, wkt := range inputGeometries {
+		g := fmt.Sprintf("ST_GeomFromText('%s')", wkt)
+		yield(fmt.Sprintf("ST_GeometryType(%s)", g), nil)
+		yield(fmt.Sprintf("ST_Dimension(%s)", g), nil)
+		yield(fmt.Sprintf("ST_IsEmpty(%s)", g), nil)
+		yield(fmt.Sprintf("ST_AsText(ST_Envelope(%s))", g), nil)
+		yield(fmt.Sprintf("ST_AsText(ST_StartPoint(%s))", g), nil)
+		yield(fmt.Sprintf("ST_AsText(ST_EndPoint(%s))", g), nil)
+		yield(fmt.Sprintf("ST_NumPoints(%s)", g), nil)
+		yield(fmt.Sprintf("ST_IsClosed(%s)", g), nil)
+		yield(fmt.Sprintf("ST_AsText(ST_ExteriorRing(%s))", g), nil)
+		yield(fmt.Sprintf("ST_NumInteriorRings(%s)", g), nil)
+		yield(fmt.Sprintf("ST_NumGeometries(%s)", g), nil)
+		for _, n := range []string{"0", "1", "2", "3"} {
+			yield(fmt.Sprintf("ST_AsText(ST_PointN(%s, %s))", g, n), nil)
+			yield(fmt.Sprintf("ST_AsText(ST_InteriorRingN(%s, %s))", g, n), nil)
+			yield(fmt.Sprintf("ST_AsText(ST_GeometryN(%s, %s))", g, n), nil)
+		}
+	}
+
+	mysqlDocSamples := []string{
+		`ST_X(Point(1, 2))`,
+		`ST_Y(Point(1, 2))`,
+		`ST_AsText(ST_X(Point(1, 2), 10))`,
+		`ST_AsText(ST_Y(Point(1, 2), 10.5))`,
+		`ST_X(ST_GeomFromText('LINESTRING(0 0,1 1)'))`,
+		`ST_Length(ST_GeomFromText('LINESTRING(0 0,3 4,3 10)'))`,
+		`ST_Length(ST_GeomFromText('MULTILINESTRING((0 0,3 4),(0 0,0 1))'))`,
+		`ST_Length(Point(1, 1))`,
+		`ST_Area(ST_GeomFromText('POLYGON((0 0,10 0,10 10,0 10,0 0),(4 4,6 4,6 6,4 6,4 4))'))`,
+		`ST_Area(ST_GeomFromText('MULTIPOLYGON(((0 0,0 2,2 2,2 0,0 0)),((5 5,6 5,6 6,5 5)))'))`,
+		`ST_Area(Point(1, 1))`,
+	}
+
+	for _, q := range mysqlDocSamples {
+		yield(q, nil)
+	}
+}
+
+func FnSpatialRelations(yield Query) {
+	geometries := []string{
+		`POINT(5 5)`,
+		`POINT(0 5)`,
+		`POINT(15 5)`,
+		`LINESTRING(1 1,9 9)`,
+		`LINESTRING(-5 5,15 5)`,
+		`POLYGON((0 0,10 0,10 10,0 10,0 0))`,
+		`POLYGON((0 0,10 0,10 10,0 10,0 0),(4 4,6 4,6 6,4 6,4 4))`,
+		`POLYGON((1 1,9 1,9 9,1 1))`,
+		`MULTIPOINT((5 5),(20 20))`,
+		`GEOMETRYCOLLECTION EMPTY`,
+	}
+
+	for _, a := range geometries {
+		for _, b := range geometries {
+			ga := fmt.Sprintf("ST_GeomFromText('%s')", a)
+			gb := fmt.Sprintf("ST_GeomFromText('%s')", b)
+			yield(fmt.Sprintf("ST_Contains(%s, %s)", ga, gb), nil)
+			yield(fmt.Sprintf("ST_Within(%s, %s)", ga, gb), nil)
+			yield(fmt.Sprintf("ST_Intersects(%s, %s)", ga, gb), nil)
+			yield(fmt.Sprintf("ST_Disjoint(%s, %s)", ga, gb), nil)
+			yield(fmt.Sprintf("ST_Distance(%s, %s)", ga, gb), nil)
+		}
+	}
+
+	yield("ST_Distance(Point(0, 0), NULL)", nil)
+	yield("ST_Contains(NULL, Point(0, 0))", nil)
+}
+
 func CharsetConversionOperators(yield Query) {
 	var introducers = []string{
 		"", "_latin1", "_utf8mb4", "_utf8", "_binary",
@@ -2220,6 +2379,171 @@ func FnYearWeek(yield Query) {
 	}
 }
 
+func FnStrToDate(yield Query) {
+	mysqlDocSamples := []string{
+		`STR_TO_DATE('01,5,2013','%d,%m,%Y')`,
+		`STR_TO_DATE('May 1, 2013','%M %d,%Y')`,
+		`STR_TO_DATE('a09:30:17','a%h:%i:%s')`,
+		`STR_TO_DATE('a09:30:17','%h:%i:%s')`,
+		`STR_TO_DATE('09:30:17a','%h:%i:%s')`,
+		`STR_TO_DATE('abc','abc')`,
+		`STR_TO_DATE('9','%m')`,
+		`STR_TO_DATE('9','%s')`,
+		`STR_TO_DATE('00/00/0000', '%m/%d/%Y')`,
+		`STR_TO_DATE('04/31/2004', '%m/%d/%Y')`,
+		`STR_TO_DATE('200442 Monday', '%X%V %W')`,
+		`STR_TO_DATE('2013-05-01 10:20:30.123456', '%Y-%m-%d %H:%i:%s.%f')`,
+		`STR_TO_DATE('10:20:30.5', '%H:%i:%s.%f')`,
+		`STR_TO_DATE('10:20:30 PM', '%r')`,
+		`STR_TO_DATE('3 10:20', '%d %H:%i')`,
+		`STR_TO_DATE('2013-05-01', NULL)`,
+		`STR_TO_DATE(NULL, '%Y')`,
+		`STR_TO_DATE(20130501, '%Y%m%d')`,
+	}
+
+	for _, q := range mysqlDocSamples {
+		yield(q, nil)
+	}
+
+	values := []string{
+		`'2024-03-15 10:20:30.25'`,
+		`'15/3/24 10:20 pm'`,
+		`'Friday, March 15th, 2024'`,
+		`'fri mar 15 2024'`,
+		`'2024 060'`,
+		`'2024 10 5'`,
+		`'10:20:30'`,
+		`'pokemon trainers'`,
+		`''`,
+	}
+	formats := []string{
+		`'%Y-%m-%d %H:%i:%s.%f'`,
+		`'%Y-%m-%d %T'`,
+		`'%Y-%m-%d'`,
+		`'%d/%c/%y %h:%i %p'`,
+		`'%W, %M %D, %Y'`,
+		`'%a %b %e %Y'`,
+		`'%Y %j'`,
+		`'%x %v %w'`,
+		`'%X %V %w'`,
+		`'%Y %U %w'`,
+		`'%T'`,
+		`'%H:%i:%s.%f'`,
+		`'%@ %#'`,
+	}
+
+	for _, v := range values {
+		for _, f := range formats {
+			yield(fmt.Sprintf("STR_TO_DATE(%s, %s)", v, f), nil)
+		}
+	}
+
+	for _, d := range inputConversions {
+		yield(fmt.Sprintf("STR_TO_DATE(%s, '%%Y-%%m-%%d %%H:%%i:%%s')", d), nil)
+	}
+}
+
+func FnTimestampDiff(yield Query) {
+	mysqlDocSamples := []string{
+		`TIMESTAMPDIFF(MONTH,'2003-02-01','2003-05-01')`,
+		`TIMESTAMPDIFF(YEAR,'2002-05-01','2001-01-01')`,
+		`TIMESTAMPDIFF(MINUTE,'2003-02-01','2003-05-01 12:05:55')`,
+	}
+
+	for _, q := range mysqlDocSamples {
+		yield(q, nil)
+	}
+
+	dates := []string{
+		`DATE'2024-01-31'`,
+		`TIMESTAMP'2024-02-29 10:20:30'`,
+		`TIMESTAMP'2023-02-28 10:20:30.999999'`,
+		`'2020-12-31 23:59:59.5'`,
+		`'2024-03-31'`,
+		`20250101`,
+		`TIME'10:20:30'`,
+		`'0000-00-00'`,
+		`'pokemon trainers'`,
+		`NULL`,
+	}
+	units := []string{"MICROSECOND", "SECOND", "MINUTE", "HOUR", "DAY", "WEEK", "MONTH", "QUARTER", "YEAR"}
+
+	for _, u := range units {
+		for _, d1 := range dates {
+			for _, d2 := range dates {
+				yield(fmt.Sprintf("TIMESTAMPDIFF(%s, %s, %s)", u, d1, d2), nil)
+			}
+		}
+	}
+}
+
+func FnDateDiff(yield Query) {
+	mysqlDocSamples := []string{
+		`DATEDIFF('2007-12-31 23:59:59','2007-12-30')`,
+		`DATEDIFF('2010-11-30 23:59:59','2010-12-31')`,
+	}
+
+	for _, q := range mysqlDocSamples {
+		yield(q, nil)
+	}
+
+	for _, d1 := range inputConversions {
+		for _, d2 := range []string{`DATE'2024-03-15'`, `'1999-12-31 23:59:59'`, `0`, `NULL`} {
+			yield(fmt.Sprintf("DATEDIFF(%s, %s)", d1, d2), nil)
+			yield(fmt.Sprintf("DATEDIFF(%s, %s)", d2, d1), nil)
+		}
+	}
+}
+
+func FnDayName(yield Query) {
+	for _, d := range inputConversions {
+		yield(fmt.Sprintf("DAYNAME(%s)", d), nil)
+	}
+
+	dates := []string{
+		`DATE'2024-03-15'`,
+		`DATE'0000-01-01'`,
+		`TIMESTAMP'2024-03-17 10:20:30'`,
+		`'2007-02-03'`,
+		`'0000-00-00'`,
+		`950501`,
+	}
+
+	for _, d := range dates {
+		yield(fmt.Sprintf("DAYNAME(%s)", d), nil)
+	}
+}
+
+var inputPeriods = []string{
+	"202403", "2403", "7001", "6912", "199912", "1", "12", "9912", "200013", "202400", "0", "-202403", "'202403'", "202403.6", "NULL",
+}
+
+func FnPeriodAdd(yield Query) {
+	for _, p := range inputPeriods {
+		for _, n := range []string{"0", "1", "-1", "11", "12", "-25", "1200", "'2'", "2.5", "NULL"} {
+			yield(fmt.Sprintf("PERIOD_ADD(%s, %s)", p, n), nil)
+		}
+	}
+}
+
+func FnPeriodDiff(yield Query) {
+	for _, p1 := range inputPeriods {
+		for _, p2 := range inputPeriods {
+			yield(fmt.Sprintf("PERIOD_DIFF(%s, %s)", p1, p2), nil)
+		}
+	}
+}
+
+func FnGetFormat(yield Query) {
+	for _, t := range []string{"DATE", "DATETIME", "TIME", "TIMESTAMP"} {
+		for _, f := range []string{`'USA'`, `'JIS'`, `'ISO'`, `'EUR'`, `'INTERNAL'`, `'usa'`, `'foo'`, `1`, `NULL`} {
+			yield(fmt.Sprintf("GET_FORMAT(%s, %s)", t, f), nil)
+		}
+	}
+	yield("DATE_FORMAT('2003-10-03',GET_FORMAT(DATE,'EUR'))", nil)
+	yield("STR_TO_DATE('10.31.2003',GET_FORMAT(DATE,'USA'))", nil)
+}
+
 func FnInetAton(yield Query) {
 	for _, d := range ipInputs {
 		yield(fmt.Sprintf("INET_ATON(%s)", d), nil)
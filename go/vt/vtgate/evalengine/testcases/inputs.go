@@ -33,6 +33,20 @@ var inputJSONObjects = []string{
 	`[10, 20, [30, 40]]`,
 }
 
+var inputGeometries = []string{
+	`POINT(1 2)`,
+	`POINT(-1.5 0.25)`,
+	`LINESTRING(0 0,3 4,3 10)`,
+	`LINESTRING(0 0,10 0,10 10,0 0)`,
+	`POLYGON((0 0,10 0,10 10,0 10,0 0))`,
+	`POLYGON((0 0,10 0,10 10,0 10,0 0),(4 4,6 4,6 6,4 6,4 4))`,
+	`MULTIPOINT((1 1),(5 5))`,
+	`MULTILINESTRING((0 0,1 1),(2 2,3 3))`,
+	`MULTIPOLYGON(((0 0,1 0,1 1,0 0)),((5 5,6 5,6 6,5 5)))`,
+	`GEOMETRYCOLLECTION(POINT(1 1),LINESTRING(0 0,1 1))`,
+	`GEOMETRYCOLLECTION EMPTY`,
+}
+
 var inputJSONPaths = []string{
 	"$**.b", "$.c", "$.b[1].c", "$.b[1].c", "$.b[1]", "$[0][0]", "$**[0]", "$.a[0]",
 	"$[0].a[0]", "$**.a", "$[0][0][0].a", "$[*].b", "$[*].a", `$[1].b[0]`, `$[2][2]`,
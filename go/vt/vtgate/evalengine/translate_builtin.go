@@ -21,6 +21,10 @@ import (
 	"strings"
 
 	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/mysql/datetime"
+	"vitess.io/vitess/go/mysql/geometry"
+	"vitess.io/vitess/go/mysql/json"
+	"vitess.io/vitess/go/sqltypes"
 	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
 	"vitess.io/vitess/go/vt/sqlparser"
 	"vitess.io/vitess/go/vt/vterrors"
@@ -45,6 +49,10 @@ func (ast *astCompiler) translateFuncArgs(fnargs []sqlparser.Expr) ([]IR, error)
 }
 
 func (ast *astCompiler) translateFuncExpr(fn *sqlparser.FuncExpr) (IR, error) {
+	if fn.Name.Lowered() == "get_format" {
+		return ast.translateGetFormat(fn)
+	}
+
 	var args TupleExpr
 	for _, expr := range fn.Exprs {
 		convertedExpr, err := ast.translateExpr(expr)
@@ -528,6 +536,35 @@ func (ast *astCompiler) translateFuncExpr(fn *sqlparser.FuncExpr) (IR, error) {
 		default:
 			return nil, argError(method)
 		}
+	case "str_to_date":
+		if len(args) != 2 {
+			return nil, argError(method)
+		}
+		typ, prec := sqltypes.Datetime, uint8(datetime.DefaultPrecision)
+		if lit, ok := args[1].(*Literal); ok && lit.inner != nil {
+			typ, prec = strToDateType(evalToBinary(lit.inner).string())
+		}
+		return &builtinStrToDate{CallExpr: call, typ: typ, prec: prec}, nil
+	case "datediff":
+		if len(args) != 2 {
+			return nil, argError(method)
+		}
+		return &builtinDateDiff{CallExpr: call}, nil
+	case "dayname":
+		if len(args) != 1 {
+			return nil, argError(method)
+		}
+		return &builtinDayName{CallExpr: call, collate: ast.cfg.Collation}, nil
+	case "period_add":
+		if len(args) != 2 {
+			return nil, argError(method)
+		}
+		return &builtinPeriodAdd{CallExpr: call}, nil
+	case "period_diff":
+		if len(args) != 2 {
+			return nil, argError(method)
+		}
+		return &builtinPeriodDiff{CallExpr: call}, nil
 	case "inet_aton":
 		if len(args) != 1 {
 			return nil, argError(method)
@@ -648,11 +685,64 @@ func (ast *astCompiler) translateFuncExpr(fn *sqlparser.FuncExpr) (IR, error) {
 			return nil, argError(method)
 		}
 		return &builtinReplace{CallExpr: call, collate: ast.cfg.Collation}, nil
+	case "geometrycollection", "geomcollection":
+		return &builtinGeomConstructor{CallExpr: call, geomType: geometry.TypeGeometryCollection}, nil
+	case "st_srid":
+		switch len(args) {
+		case 1, 2:
+			return &builtinGeomSRID{CallExpr: call}, nil
+		default:
+			return nil, argError(method)
+		}
+	case "st_contains", "st_within", "st_intersects", "st_disjoint":
+		if len(args) != 2 {
+			return nil, argError(method)
+		}
+		return &builtinSpatialRelation{CallExpr: call, relation: spatialRelations[method]}, nil
+	case "st_distance":
+		if len(args) != 2 {
+			return nil, translateExprNotSupported(fn)
+		}
+		return &builtinSTDistance{CallExpr: call}, nil
 	default:
 		return nil, translateExprNotSupported(fn)
 	}
 }
 
+// translateGetFormat translates GET_FORMAT, where the first argument is the name
+// of a temporal type that is parsed as a column name
+func (ast *astCompiler) translateGetFormat(fn *sqlparser.FuncExpr) (IR, error) {
+	if len(fn.Exprs) != 2 {
+		return nil, argError("get_format")
+	}
+	col, ok := fn.Exprs[0].(*sqlparser.ColName)
+	if !ok || !col.Qualifier.IsEmpty() {
+		return nil, translateExprNotSupported(fn)
+	}
+
+	var typ sqltypes.Type
+	switch col.Name.Lowered() {
+	case "date":
+		typ = sqltypes.Date
+	case "datetime", "timestamp":
+		typ = sqltypes.Datetime
+	case "time":
+		typ = sqltypes.Time
+	default:
+		return nil, translateExprNotSupported(fn)
+	}
+
+	arg, err := ast.translateExpr(fn.Exprs[1])
+	if err != nil {
+		return nil, err
+	}
+	return &builtinGetFormat{
+		CallExpr: CallExpr{Arguments: []IR{arg}, Method: "get_format"},
+		typ:      typ,
+		collate:  ast.cfg.Collation,
+	}, nil
+}
+
 func (ast *astCompiler) translateCallable(call sqlparser.Callable) (IR, error) {
 	switch call := call.(type) {
 	case *sqlparser.FuncExpr:
@@ -768,6 +858,63 @@ func (ast *astCompiler) translateCallable(call sqlparser.Callable) (IR, error) {
 			Method:    "JSON_KEYS",
 		}}, nil
 
+	case *sqlparser.JSONValueModifierExpr:
+		var transform json.Transformation
+		var method string
+		switch call.Type {
+		case sqlparser.JSONSetType:
+			transform, method = json.Set, "JSON_SET"
+		case sqlparser.JSONInsertType:
+			transform, method = json.Insert, "JSON_INSERT"
+		case sqlparser.JSONReplaceType:
+			transform, method = json.Replace, "JSON_REPLACE"
+		case sqlparser.JSONArrayAppendType:
+			transform, method = json.ArrayAppend, "JSON_ARRAY_APPEND"
+		default:
+			return nil, translateExprNotSupported(call)
+		}
+		exprs := []sqlparser.Expr{call.JSONDoc}
+		for _, param := range call.Params {
+			exprs = append(exprs, param.Key, param.Value)
+		}
+		args, err := ast.translateFuncArgs(exprs)
+		if err != nil {
+			return nil, err
+		}
+		return &builtinJSONModify{
+			CallExpr:  CallExpr{Arguments: args, Method: method},
+			transform: transform,
+		}, nil
+
+	case *sqlparser.JSONRemoveExpr:
+		args, err := ast.translateFuncArgs(append([]sqlparser.Expr{call.JSONDoc}, call.PathList...))
+		if err != nil {
+			return nil, err
+		}
+		return &builtinJSONRemove{CallExpr: CallExpr{
+			Arguments: args,
+			Method:    "JSON_REMOVE",
+		}}, nil
+
+	case *sqlparser.JSONValueMergeExpr:
+		args, err := ast.translateFuncArgs(append([]sqlparser.Expr{call.JSONDoc}, call.JSONDocList...))
+		if err != nil {
+			return nil, err
+		}
+		var method string
+		switch call.Type {
+		case sqlparser.JSONMergeType:
+			method = "JSON_MERGE"
+		case sqlparser.JSONMergePatchType:
+			method = "JSON_MERGE_PATCH"
+		case sqlparser.JSONMergePreserveType:
+			method = "JSON_MERGE_PRESERVE"
+		}
+		return &builtinJSONMerge{
+			CallExpr: CallExpr{Arguments: args, Method: method},
+			patch:    call.Type == sqlparser.JSONMergePatchType,
+		}, nil
+
 	case *sqlparser.CurTimeFuncExpr:
 		if call.Fsp > 6 {
 			return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "Too-big precision %d specified for '%s'. Maximum is 6.", call.Fsp, call.Name.String())
@@ -889,6 +1036,25 @@ func (ast *astCompiler) translateCallable(call sqlparser.Callable) (IR, error) {
 			collate:  ast.cfg.Collation,
 		}, nil
 
+	case *sqlparser.TimestampDiffExpr:
+		var err error
+		args := make([]IR, 2)
+
+		args[0], err = ast.translateExpr(call.Expr1)
+		if err != nil {
+			return nil, err
+		}
+		args[1], err = ast.translateExpr(call.Expr2)
+		if err != nil {
+			return nil, err
+		}
+
+		cexpr := CallExpr{Arguments: args, Method: "TIMESTAMPDIFF"}
+		return &builtinTimestampDiff{
+			CallExpr: cexpr,
+			unit:     call.Unit,
+		}, nil
+
 	case *sqlparser.RegexpLikeExpr:
 		input, err := ast.translateExpr(call.Expr)
 		if err != nil {
@@ -1105,6 +1271,12 @@ func (ast *astCompiler) translateCallable(call sqlparser.Callable) (IR, error) {
 			CallExpr: cexpr,
 			collate:  coll,
 		}, nil
+	case *sqlparser.PointExpr, *sqlparser.LineStringExpr, *sqlparser.PolygonExpr,
+		*sqlparser.MultiPointExpr, *sqlparser.MultiLinestringExpr, *sqlparser.MultiPolygonExpr,
+		*sqlparser.GeomFromTextExpr, *sqlparser.GeomFromWKBExpr, *sqlparser.GeomFormatExpr,
+		*sqlparser.GeomPropertyFuncExpr, *sqlparser.PointPropertyFuncExpr, *sqlparser.LinestrPropertyFuncExpr,
+		*sqlparser.PolygonPropertyFuncExpr, *sqlparser.GeomCollPropertyFuncExpr:
+		return ast.translateSpatialCallable(call)
 	default:
 		return nil, translateExprNotSupported(call)
 	}
@@ -1202,3 +1374,196 @@ func builtinIfRewrite(args []IR) (IR, error) {
 		Else: args[2],
 	}, nil
 }
+
+var spatialRelations = map[string]spatialRelation{
+	"st_contains":   spatialContains,
+	"st_within":     spatialWithin,
+	"st_intersects": spatialIntersects,
+	"st_disjoint":   spatialDisjoint,
+}
+
+var geomFromTextMethods = map[sqlparser.GeomFromWktType]struct {
+	method   string
+	geomType geometry.Type
+}{
+	sqlparser.GeometryFromText:           {"st_geomfromtext", 0},
+	sqlparser.GeometryCollectionFromText: {"st_geomcollfromtext", geometry.TypeGeometryCollection},
+	sqlparser.PointFromText:              {"st_pointfromtext", geometry.TypePoint},
+	sqlparser.LineStringFromText:         {"st_linefromtext", geometry.TypeLineString},
+	sqlparser.PolygonFromText:            {"st_polyfromtext", geometry.TypePolygon},
+	sqlparser.MultiPointFromText:         {"st_mpointfromtext", geometry.TypeMultiPoint},
+	sqlparser.MultiPolygonFromText:       {"st_mpolyfromtext", geometry.TypeMultiPolygon},
+	sqlparser.MultiLinestringFromText:    {"st_mlinefromtext", geometry.TypeMultiLineString},
+}
+
+var geomFromWKBMethods = map[sqlparser.GeomFromWkbType]struct {
+	method   string
+	geomType geometry.Type
+}{
+	sqlparser.GeometryFromWKB:           {"st_geomfromwkb", 0},
+	sqlparser.GeometryCollectionFromWKB: {"st_geomcollfromwkb", geometry.TypeGeometryCollection},
+	sqlparser.PointFromWKB:              {"st_pointfromwkb", geometry.TypePoint},
+	sqlparser.LineStringFromWKB:         {"st_linefromwkb", geometry.TypeLineString},
+	sqlparser.PolygonFromWKB:            {"st_polyfromwkb", geometry.TypePolygon},
+	sqlparser.MultiPointFromWKB:         {"st_mpointfromwkb", geometry.TypeMultiPoint},
+	sqlparser.MultiPolygonFromWKB:       {"st_mpolyfromwkb", geometry.TypeMultiPolygon},
+	sqlparser.MultiLinestringFromWKB:    {"st_mlinefromwkb", geometry.TypeMultiLineString},
+}
+
+// translateSpatialCallable translates the spatial functions that have their own AST nodes.
+// Only geometries in the Cartesian plane are supported, so the functions that take an axis
+// order or a unit, and the functions that only apply to geographic coordinates, are not.
+func (ast *astCompiler) translateSpatialCallable(call sqlparser.Callable) (IR, error) {
+	constructor := func(method string, geomType geometry.Type, exprs sqlparser.Exprs) (IR, error) {
+		args, err := ast.translateFuncArgs(exprs)
+		if err != nil {
+			return nil, err
+		}
+		return &builtinGeomConstructor{CallExpr: CallExpr{Arguments: args, Method: method}, geomType: geomType}, nil
+	}
+	property := func(method string, prop geomProperty, exprs ...sqlparser.Expr) (IR, error) {
+		var args []sqlparser.Expr
+		for _, expr := range exprs {
+			if expr != nil {
+				args = append(args, expr)
+			}
+		}
+		targs, err := ast.translateFuncArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		return &builtinGeomProperty{
+			CallExpr: CallExpr{Arguments: targs, Method: method},
+			property: prop,
+			collate:  ast.cfg.Collation,
+		}, nil
+	}
+
+	switch call := call.(type) {
+	case *sqlparser.PointExpr:
+		return constructor("point", geometry.TypePoint, sqlparser.Exprs{call.XCordinate, call.YCordinate})
+	case *sqlparser.LineStringExpr:
+		return constructor("linestring", geometry.TypeLineString, call.PointParams)
+	case *sqlparser.PolygonExpr:
+		return constructor("polygon", geometry.TypePolygon, call.LinestringParams)
+	case *sqlparser.MultiPointExpr:
+		return constructor("multipoint", geometry.TypeMultiPoint, call.PointParams)
+	case *sqlparser.MultiLinestringExpr:
+		return constructor("multilinestring", geometry.TypeMultiLineString, call.LinestringParams)
+	case *sqlparser.MultiPolygonExpr:
+		return constructor("multipolygon", geometry.TypeMultiPolygon, call.PolygonParams)
+
+	case *sqlparser.GeomFromTextExpr:
+		if call.AxisOrderOpt != nil {
+			return nil, translateExprNotSupported(call)
+		}
+		args, err := ast.translateFuncArgs(sqlparser.Exprs{call.WktText})
+		if err != nil {
+			return nil, err
+		}
+		if call.Srid != nil {
+			srid, err := ast.translateExpr(call.Srid)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, srid)
+		}
+		fn := geomFromTextMethods[call.Type]
+		return &builtinGeomFromText{CallExpr: CallExpr{Arguments: args, Method: fn.method}, geomType: fn.geomType}, nil
+
+	case *sqlparser.GeomFromWKBExpr:
+		if call.AxisOrderOpt != nil {
+			return nil, translateExprNotSupported(call)
+		}
+		args, err := ast.translateFuncArgs(sqlparser.Exprs{call.WkbBlob})
+		if err != nil {
+			return nil, err
+		}
+		if call.Srid != nil {
+			srid, err := ast.translateExpr(call.Srid)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, srid)
+		}
+		fn := geomFromWKBMethods[call.Type]
+		return &builtinGeomFromWKB{CallExpr: CallExpr{Arguments: args, Method: fn.method}, geomType: fn.geomType}, nil
+
+	case *sqlparser.GeomFormatExpr:
+		if call.AxisOrderOpt != nil {
+			return nil, translateExprNotSupported(call)
+		}
+		args, err := ast.translateFuncArgs(sqlparser.Exprs{call.Geom})
+		if err != nil {
+			return nil, err
+		}
+		method := "st_astext"
+		if call.FormatType == sqlparser.BinaryFormat {
+			method = "st_asbinary"
+		}
+		return &builtinGeomFormat{
+			CallExpr: CallExpr{Arguments: args, Method: method},
+			binary:   call.FormatType == sqlparser.BinaryFormat,
+			collate:  ast.cfg.Collation,
+		}, nil
+
+	case *sqlparser.GeomPropertyFuncExpr:
+		switch call.Property {
+		case sqlparser.IsEmpty:
+			return property("st_isempty", geomPropIsEmpty, call.Geom)
+		case sqlparser.Dimension:
+			return property("st_dimension", geomPropDimension, call.Geom)
+		case sqlparser.GeometryType:
+			return property("st_geometrytype", geomPropGeometryType, call.Geom)
+		case sqlparser.Envelope:
+			return property("st_envelope", geomPropEnvelope, call.Geom)
+		}
+
+	case *sqlparser.PointPropertyFuncExpr:
+		switch call.Property {
+		case sqlparser.XCordinate:
+			return property("st_x", geomPropX, call.Point, call.ValueToSet)
+		case sqlparser.YCordinate:
+			return property("st_y", geomPropY, call.Point, call.ValueToSet)
+		}
+
+	case *sqlparser.LinestrPropertyFuncExpr:
+		switch call.Property {
+		case sqlparser.EndPoint:
+			return property("st_endpoint", geomPropEndPoint, call.Linestring)
+		case sqlparser.IsClosed:
+			return property("st_isclosed", geomPropIsClosed, call.Linestring)
+		case sqlparser.Length:
+			if call.PropertyDefArg == nil {
+				return property("st_length", geomPropLength, call.Linestring)
+			}
+		case sqlparser.NumPoints:
+			return property("st_numpoints", geomPropNumPoints, call.Linestring)
+		case sqlparser.PointN:
+			return property("st_pointn", geomPropPointN, call.Linestring, call.PropertyDefArg)
+		case sqlparser.StartPoint:
+			return property("st_startpoint", geomPropStartPoint, call.Linestring)
+		}
+
+	case *sqlparser.PolygonPropertyFuncExpr:
+		switch call.Property {
+		case sqlparser.Area:
+			return property("st_area", geomPropArea, call.Polygon)
+		case sqlparser.ExteriorRing:
+			return property("st_exteriorring", geomPropExteriorRing, call.Polygon)
+		case sqlparser.InteriorRingN:
+			return property("st_interiorringn", geomPropInteriorRingN, call.Polygon, call.PropertyDefArg)
+		case sqlparser.NumInteriorRings:
+			return property("st_numinteriorrings", geomPropNumInteriorRings, call.Polygon)
+		}
+
+	case *sqlparser.GeomCollPropertyFuncExpr:
+		switch call.Property {
+		case sqlparser.GeometryN:
+			return property("st_geometryn", geomPropGeometryN, call.GeomColl, call.PropertyDefArg)
+		case sqlparser.NumGeometries:
+			return property("st_numgeometries", geomPropNumGeometries, call.GeomColl)
+		}
+	}
+	return nil, translateExprNotSupported(call)
+}
@@ -46,6 +46,7 @@ import (
 	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
 	querypb "vitess.io/vitess/go/vt/proto/query"
 	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vschemapb "vitess.io/vitess/go/vt/proto/vschema"
 	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
 	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
 	"vitess.io/vitess/go/vt/servenv"
@@ -60,6 +61,7 @@ import (
 	"vitess.io/vitess/go/vt/vtgate/logstats"
 	"vitess.io/vitess/go/vt/vtgate/planbuilder"
 	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
+	"vitess.io/vitess/go/vt/vtgate/queryrules"
 	"vitess.io/vitess/go/vt/vtgate/vindexes"
 	"vitess.io/vitess/go/vt/vtgate/vschemaacl"
 	"vitess.io/vitess/go/vt/vtgate/vtgateservice"
@@ -114,6 +116,9 @@ type Executor struct {
 	plans *PlanCache
 	epoch atomic.Uint32
 
+	// warmingPlans is set while the plan cache is warmed from a snapshot
+	warmingPlans atomic.Bool
+
 	normalize       bool
 	warnShardedOnly bool
 
@@ -128,6 +133,25 @@ type Executor struct {
 
 	warmingReadsPercent int
 	warmingReadsChannel chan bool
+
+	// resultCache caches the results of the read-only queries that opted in, when enabled.
+	resultCache            *resultCache
+	resultCacheInvalidator *resultCacheInvalidator
+
+	// lookupCachesByTable are the caches of the lookup vindexes of the vschema, by keyspace and table of their lookup table.
+	lookupCachesByTable map[string]map[string][]*vindexes.LookupCache
+	// lookupCacheInvalidator invalidates the caches of the lookup vindexes with a VStream, when enabled.
+	lookupCacheInvalidator *lookupCacheInvalidator
+
+	// queryQuotas admits the queries by the quotas of their user and workload, when set.
+	queryQuotas *queryQuotas
+
+	// queryRules are the compiled query rules of the vschema.
+	queryRules       *queryrules.Rules
+	queryRulesSource *vschemapb.QueryRules
+	// queryRulesErr is the error of the query rules of the vschema, when
+	// they are invalid and the previous ones are kept.
+	queryRulesErr error
 }
 
 var executorOnce sync.Once
@@ -177,6 +201,9 @@ func NewExecutor(
 		warmingReadsPercent: warmingReadsPercent,
 		warmingReadsChannel: make(chan bool, warmingReadsConcurrency),
 	}
+	if resultCacheMemory > 0 {
+		e.resultCache = newResultCache(resultCacheMemory)
+	}
 
 	vschemaacl.Init()
 	// we subscribe to update from the VSchemaManager
@@ -199,6 +226,18 @@ func NewExecutor(
 		stats.NewGaugeFunc("QueryPlanCacheCapacity", "Query plan cache capacity", func() int64 {
 			return int64(e.plans.MaxCapacity())
 		})
+		stats.NewGaugeFunc("ResultCacheLength", "Result cache length", func() int64 {
+			if e.resultCache == nil {
+				return 0
+			}
+			return int64(e.resultCache.entries.Len())
+		})
+		stats.NewGaugeFunc("ResultCacheSize", "Result cache size", func() int64 {
+			if e.resultCache == nil {
+				return 0
+			}
+			return int64(e.resultCache.entries.UsedCapacity())
+		})
 		stats.NewCounterFunc("QueryPlanCacheEvictions", "Query plan cache evictions", func() int64 {
 			return e.plans.Metrics.Evicted()
 		})
@@ -456,6 +495,8 @@ func (e *Executor) addNeededBindVars(vcursor *vcursorImpl, bindVarNeeds *sqlpars
 			bindVars[key] = sqltypes.BoolBindVariable(session.Autocommit)
 		case sysvars.QueryTimeout.Name:
 			bindVars[key] = sqltypes.Int64BindVariable(session.GetQueryTimeout())
+		case sysvars.MaxReplicaLag.Name:
+			bindVars[key] = sqltypes.StringBindVariable(session.GetMaxReplicaLag().String())
 		case sysvars.ClientFoundRows.Name:
 			var v bool
 			ifOptionsExist(session, func(options *querypb.ExecuteOptions) {
@@ -500,6 +541,12 @@ func (e *Executor) addNeededBindVars(vcursor *vcursorImpl, bindVarNeeds *sqlpars
 				v = raw.ReadAfterWriteGtid
 			})
 			bindVars[key] = sqltypes.StringBindVariable(v)
+		case sysvars.SessionToken.Name:
+			var v string
+			ifReadAfterWriteExist(session, func(raw *vtgatepb.ReadAfterWrite) {
+				v = raw.SessionToken
+			})
+			bindVars[key] = sqltypes.StringBindVariable(v)
 		case sysvars.ReadAfterWriteTimeOut.Name:
 			var v float64
 			ifReadAfterWriteExist(session, func(raw *vtgatepb.ReadAfterWrite) {
@@ -592,14 +639,31 @@ func (e *Executor) handleCommit(ctx context.Context, safeSession *SafeSession, l
 	logStats.ShardQueries = uint64(len(safeSession.ShardSessions))
 	e.updateQueryCounts("Commit", "", "", int64(logStats.ShardQueries))
 
-	err := e.txConn.Commit(ctx, safeSession)
+	err := e.commit(ctx, safeSession)
 	logStats.CommitTime = time.Since(execStart)
 	return &sqltypes.Result{}, err
 }
 
 // Commit commits the existing transactions
 func (e *Executor) Commit(ctx context.Context, safeSession *SafeSession) error {
-	return e.txConn.Commit(ctx, safeSession)
+	return e.commit(ctx, safeSession)
+}
+
+// commit commits the existing transactions, and then invalidates again the
+// cached results of the tables and the cached lookup ids they changed. They
+// are invalidated even if the commit failed, since it may have succeeded on
+// some of the shards.
+func (e *Executor) commit(ctx context.Context, safeSession *SafeSession) error {
+	invalidations := safeSession.GetResultCacheInvalidations()
+	lookupInvalidations := safeSession.GetLookupCacheInvalidations()
+	err := e.txConn.Commit(ctx, safeSession)
+	if e.resultCache != nil && len(invalidations) > 0 {
+		e.resultCache.invalidateTables(invalidations)
+	}
+	if len(lookupInvalidations) > 0 {
+		invalidateLookupCaches(e.VSchema(), lookupInvalidations)
+	}
+	return err
 }
 
 func (e *Executor) handleRollback(ctx context.Context, safeSession *SafeSession, logStats *logstats.LogStats) (*sqltypes.Result, error) {
@@ -1042,13 +1106,30 @@ func (e *Executor) VSchema() *vindexes.VSchema {
 
 // SaveVSchema updates the vschema and stats
 func (e *Executor) SaveVSchema(vschema *vindexes.VSchema, stats *VSchemaStats) {
+	// The invalidation streams stopped by the new vschema are waited for
+	// once the lock is released, so that a slow stream does not block the
+	// readers of the vschema.
+	var waitStopped []func()
+	defer func() {
+		for _, wait := range waitStopped {
+			wait()
+		}
+	}()
 	e.mu.Lock()
 	defer e.mu.Unlock()
 	if vschema != nil {
 		e.vschema = vschema
 	}
 	e.vschemaStats = stats
+	e.updateQueryRules(vschema)
 	e.ClearPlans()
+	if e.resultCacheInvalidator != nil {
+		waitStopped = append(waitStopped, e.resultCacheInvalidator.update(e.vschema))
+	}
+	e.lookupCachesByTable = lookupCaches(e.vschema)
+	if e.lookupCacheInvalidator != nil {
+		waitStopped = append(waitStopped, e.lookupCacheInvalidator.update(e.lookupCachesByTable))
+	}
 
 	if vschemaCounters != nil {
 		vschemaCounters.Add("Reload", 1)
@@ -1106,6 +1187,28 @@ func (e *Executor) getPlan(
 		return nil, err
 	}
 	vcursor.SetPriority(priority)
+	maxMemory, ok, err := sqlparser.GetMaxMemoryFromStatement(stmt)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		vcursor.SetMaxMemory(maxMemory)
+	}
+	resultCacheTTL, ok, err := sqlparser.GetResultCacheTTLFromStatement(stmt)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		vcursor.SetResultCacheTTL(resultCacheTTL)
+	}
+	vcursor.resultCacheStmt = stmt
+	maxReplicaLag, ok, err := sqlparser.GetMaxReplicaLagFromStatement(stmt)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		vcursor.SetMaxReplicaLagFromComments(maxReplicaLag)
+	}
 
 	setVarComment, err := prepareSetVarComment(vcursor, stmt)
 	if err != nil {
@@ -1188,7 +1291,11 @@ func (e *Executor) cacheAndBuildStatement(
 		var plan *engine.Plan
 		var err error
 		plan, logStats.CachedPlan, err = e.plans.GetOrLoad(planKey, e.epoch.Load(), func() (*engine.Plan, error) {
-			return e.buildStatement(ctx, vcursor, query, stmt, reservedVars, bindVarNeeds)
+			plan, err := e.buildStatement(ctx, vcursor, query, stmt, reservedVars, bindVarNeeds)
+			if plan != nil {
+				plan.Session = vcursor.planSession()
+			}
+			return plan, err
 		})
 		return plan, err
 	}
@@ -1601,6 +1708,9 @@ func (e *Executor) Close() {
 	}
 	topo.Close()
 	e.plans.Close()
+	if e.resultCache != nil {
+		e.resultCache.close()
+	}
 }
 
 func (e *Executor) environment() *vtenv.Environment {
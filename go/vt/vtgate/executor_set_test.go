@@ -263,6 +263,15 @@ func TestExecutorSet(t *testing.T) {
 	}, {
 		in:  "set @@query_timeout = 50, query_timeout = 75",
 		out: &vtgatepb.Session{Autocommit: true, QueryTimeout: 75},
+	}, {
+		in:  "set @@max_replica_lag = '5s'",
+		out: &vtgatepb.Session{Autocommit: true, MaxReplicaLag: 5000},
+	}, {
+		in:  "set max_replica_lag = '1m30s', max_replica_lag = '0'",
+		out: &vtgatepb.Session{Autocommit: true},
+	}, {
+		in:  "set @@max_replica_lag = '5'",
+		err: "invalid max_replica_lag: 5",
 	}}
 	for i, tcase := range testcases {
 		t.Run(fmt.Sprintf("%d-%s", i, tcase.in), func(t *testing.T) {
@@ -44,6 +44,7 @@ import (
 	"vitess.io/vitess/go/vt/discovery"
 	"vitess.io/vitess/go/vt/sqlparser"
 	"vitess.io/vitess/go/vt/topo"
+	"vitess.io/vitess/go/vt/vterrors"
 	"vitess.io/vitess/go/vt/vtgate/buffer"
 	"vitess.io/vitess/go/vt/vtgate/engine"
 	"vitess.io/vitess/go/vt/vtgate/logstats"
@@ -120,6 +121,95 @@ func TestExecutorMaxMemoryRowsExceeded(t *testing.T) {
 	}
 }
 
+func TestExecutorMaxQueryMemory(t *testing.T) {
+	executor, _, _, _, ctx := createExecutorEnv(t)
+
+	save := maxQueryMemory
+	maxQueryMemory = 100
+	defer func() { maxQueryMemory = save }()
+
+	logChan := executor.queryLogger.Subscribe("Test")
+	defer executor.queryLogger.Unsubscribe(logChan)
+
+	testCases := []struct {
+		query string
+		err   string
+	}{
+		{"select col from user group by col", "VT08001: query aborted: memory used in vtgate exceeded the limit of 100 bytes"},
+		{"select /*vt+ MAX_MEMORY=0 */ col from user group by col", ""},
+		{"select /*vt+ MAX_MEMORY=1000000 */ col from user group by col", ""},
+		{"select /*vt+ MAX_MEMORY=10 */ col from user group by col", "VT08001: query aborted: memory used in vtgate exceeded the limit of 10 bytes"},
+		{"select /*vt+ MAX_MEMORY=-1 */ col from user group by col", "Invalid max memory value specified in query"},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.query, func(t *testing.T) {
+			_, err := executorExec(ctx, executor, &vtgatepb.Session{TargetString: "@primary"}, test.query, nil)
+			logStats := getQueryLog(logChan)
+			require.NotNil(t, logStats)
+			if test.err == "" {
+				require.NoError(t, err)
+				assert.Empty(t, logStats.ResourceLimit)
+				return
+			}
+			require.EqualError(t, err, test.err)
+			if vterrors.Code(err) == vtrpcpb.Code_RESOURCE_EXHAUSTED {
+				assert.Equal(t, "memory", logStats.ResourceLimit)
+			}
+		})
+	}
+}
+
+func TestExecutorMaxQueryFetchedRows(t *testing.T) {
+	executor, sbc1, sbc2, _, ctx := createExecutorEnv(t)
+
+	save := maxQueryFetchedRows
+	maxQueryFetchedRows = 5
+	defer func() { maxQueryFetchedRows = save }()
+
+	logChan := executor.queryLogger.Subscribe("Test")
+	defer executor.queryLogger.Unsubscribe(logChan)
+
+	result := sqltypes.MakeTestResult(sqltypes.MakeTestFields("id", "int64"), "1", "2", "3")
+	testCases := []struct {
+		query string
+		err   string
+	}{
+		{"select id from user where id = 1", ""},
+		{"select id from user", "VT08002: query aborted: rows fetched from tablets exceeded the limit of 5 rows"},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.query, func(t *testing.T) {
+			sbc1.SetResults([]*sqltypes.Result{result})
+			sbc2.SetResults([]*sqltypes.Result{result})
+			_, err := executorExec(ctx, executor, &vtgatepb.Session{TargetString: "@primary"}, test.query, nil)
+			logStats := getQueryLog(logChan)
+			require.NotNil(t, logStats)
+			if test.err == "" {
+				require.NoError(t, err)
+				assert.Empty(t, logStats.ResourceLimit)
+			} else {
+				require.EqualError(t, err, test.err)
+				assert.Equal(t, "fetched_rows", logStats.ResourceLimit)
+			}
+
+			sbc1.SetResults([]*sqltypes.Result{result})
+			sbc2.SetResults([]*sqltypes.Result{result})
+			_, err = executorStream(ctx, executor, test.query)
+			logStats = getQueryLog(logChan)
+			require.NotNil(t, logStats)
+			if test.err == "" {
+				require.NoError(t, err)
+				assert.Empty(t, logStats.ResourceLimit)
+			} else {
+				require.ErrorContains(t, err, test.err)
+				assert.Equal(t, "fetched_rows", logStats.ResourceLimit)
+			}
+		})
+	}
+}
+
 func TestExecutorTransactionsNoAutoCommit(t *testing.T) {
 	executor, _, _, sbclookup, ctx := createExecutorEnv(t)
 
@@ -52,6 +52,9 @@ type LogStats struct {
 	SessionUUID    string
 	CachedPlan     bool
 	ActiveKeyspace string // ActiveKeyspace is the selected keyspace `use ks`
+	// ResourceLimit is the per-query resource limit the query exceeded,
+	// i.e. "memory" or "fetched_rows", if any.
+	ResourceLimit string
 }
 
 // NewLogStats constructs a new LogStats with supplied Method and ctx
@@ -177,6 +180,8 @@ func (stats *LogStats) Logf(w io.Writer, params url.Values) error {
 	log.Strings(stats.TablesUsed)
 	log.Key("ActiveKeyspace")
 	log.String(stats.ActiveKeyspace)
+	log.Key("ResourceLimit")
+	log.String(stats.ResourceLimit)
 
 	return log.Flush(w)
 }
@@ -0,0 +1,348 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/engine"
+	"vitess.io/vitess/go/vt/vtgate/vindexes"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
+)
+
+// The lookup vindexes with a cache_size cache the rows of their lookup
+// query in vtgate, and invalidate them when vtgate changes the lookup
+// table. With --lookup-vindex-cache-vstream-invalidation, the cached ids
+// are also invalidated as soon as their rows change on the primary
+// tablets, which covers the changes made by the other vtgates, and the
+// changes committed after a lookup read the previous rows.
+
+// lookupCacheInvalidator invalidates the cached ids of the lookup vindexes
+// as their rows change in the lookup tables, with one VStream per keyspace
+// of the lookup tables.
+type lookupCacheInvalidator struct {
+	vstreamer resultCacheVStreamer
+
+	mu      sync.Mutex
+	closed  bool
+	streams map[string]*lookupCacheStream
+}
+
+type lookupCacheStream struct {
+	tables []string
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu sync.Mutex
+	// caches are the caches of the lookup vindexes, by lookup table. They
+	// are replaced when the VSchema changes.
+	caches map[string][]*vindexes.LookupCache
+}
+
+func newLookupCacheInvalidator(vstreamer resultCacheVStreamer) *lookupCacheInvalidator {
+	return &lookupCacheInvalidator{
+		vstreamer: vstreamer,
+		streams:   make(map[string]*lookupCacheStream),
+	}
+}
+
+// lookupCaches returns the caches of the lookup vindexes of the VSchema,
+// by keyspace and table of their lookup table.
+func lookupCaches(vschema *vindexes.VSchema) map[string]map[string][]*vindexes.LookupCache {
+	caches := make(map[string]map[string][]*vindexes.LookupCache)
+	if vschema == nil {
+		return caches
+	}
+	for _, ks := range vschema.Keyspaces {
+		for name, vindex := range ks.Vindexes {
+			lc, ok := vindex.(vindexes.LookupCaching)
+			if !ok || lc.LookupCache() == nil {
+				continue
+			}
+			cache := lc.LookupCache()
+			keyspace, table, ok := strings.Cut(cache.Table(), ".")
+			if !ok {
+				table = keyspace
+				t, err := vschema.FindTable("", table)
+				if err != nil {
+					log.Warningf("Cannot find the lookup table of lookup vindex %s to invalidate its cache: %v", name, err)
+					continue
+				}
+				keyspace = t.Keyspace.Name
+			}
+			if caches[keyspace] == nil {
+				caches[keyspace] = make(map[string][]*vindexes.LookupCache)
+			}
+			caches[keyspace][table] = append(caches[keyspace][table], cache)
+		}
+	}
+	return caches
+}
+
+// invalidateLookupCaches invalidates the ids changed by a transaction in the
+// caches of the lookup vindexes of the VSchema, once it is committed.
+func invalidateLookupCaches(vschema *vindexes.VSchema, invalidations []*vtgatepb.LookupCacheInvalidation) {
+	if vschema == nil {
+		return
+	}
+	for _, ks := range vschema.Keyspaces {
+		for _, vindex := range ks.Vindexes {
+			lc, ok := vindex.(vindexes.LookupCaching)
+			if !ok || lc.LookupCache() == nil {
+				continue
+			}
+			cache := lc.LookupCache()
+			for _, invalidation := range invalidations {
+				if cache.Table() != invalidation.Table || cache.Column() != invalidation.Column {
+					continue
+				}
+				if len(invalidation.Ids) == 0 {
+					cache.InvalidateAll()
+					continue
+				}
+				ids := make([]sqltypes.Value, 0, len(invalidation.Ids))
+				for _, id := range invalidation.Ids {
+					ids = append(ids, sqltypes.ProtoToValue(id))
+				}
+				cache.Invalidate(ids)
+			}
+		}
+	}
+}
+
+// invalidateLookupCachesOfTables invalidates all the cached ids of the lookup
+// vindexes whose lookup table is changed by the plan itself, rather than by
+// the lookup vindexes maintaining it. When the plan is executed in a
+// transaction, the lookup tables are also recorded in the session, to be
+// invalidated again when it commits.
+func (e *Executor) invalidateLookupCachesOfTables(safeSession *SafeSession, plan *engine.Plan) {
+	switch plan.Type {
+	case sqlparser.StmtInsert, sqlparser.StmtReplace, sqlparser.StmtUpdate, sqlparser.StmtDelete, sqlparser.StmtDDL:
+	default:
+		return
+	}
+	e.mu.Lock()
+	caches := e.lookupCachesByTable
+	e.mu.Unlock()
+	for _, table := range plan.TablesUsed {
+		keyspace, name, _ := strings.Cut(table, ".")
+		for _, cache := range caches[keyspace][name] {
+			cache.InvalidateAll()
+			if safeSession.InTransaction() {
+				safeSession.AddLookupCacheInvalidation(cache.Table(), cache.Column(), nil)
+			}
+		}
+	}
+}
+
+// update starts streaming the row events of the lookup tables of the
+// caches of the lookup vindexes, and stops streaming the ones of the
+// tables that no longer have one. It returns a function that waits for
+// the stopped streams to end, which can be called once the locks of the
+// caller are released.
+func (lci *lookupCacheInvalidator) update(caches map[string]map[string][]*vindexes.LookupCache) (wait func()) {
+	lci.mu.Lock()
+	defer lci.mu.Unlock()
+	if lci.closed {
+		return func() {}
+	}
+	var stopped []*lookupCacheStream
+	for keyspace, stream := range lci.streams {
+		if !slices.Equal(stream.tables, lookupTables(caches[keyspace])) {
+			stream.cancel()
+			stopped = append(stopped, stream)
+			delete(lci.streams, keyspace)
+			continue
+		}
+		stream.setCaches(caches[keyspace])
+	}
+	for keyspace, tables := range caches {
+		if _, ok := lci.streams[keyspace]; ok {
+			continue
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		stream := &lookupCacheStream{
+			tables: lookupTables(tables),
+			cancel: cancel,
+			done:   make(chan struct{}),
+			caches: tables,
+		}
+		lci.streams[keyspace] = stream
+		go lci.stream(ctx, keyspace, stream)
+	}
+	return func() {
+		for _, stream := range stopped {
+			<-stream.done
+		}
+	}
+}
+
+// lookupTables returns the sorted lookup tables of the caches.
+func lookupTables(caches map[string][]*vindexes.LookupCache) []string {
+	tables := make([]string, 0, len(caches))
+	for table := range caches {
+		tables = append(tables, table)
+	}
+	return sortedTables(tables)
+}
+
+// stream invalidates the ids of the row events of the lookup tables of
+// the stream, until the stream is stopped. The stream is restarted when
+// it fails.
+func (lci *lookupCacheInvalidator) stream(ctx context.Context, keyspace string, stream *lookupCacheStream) {
+	defer close(stream.done)
+
+	filter := &binlogdatapb.Filter{}
+	for _, table := range stream.tables {
+		filter.Rules = append(filter.Rules, &binlogdatapb.Rule{Match: table})
+	}
+	for {
+		started := false
+		fields := make(map[string][]*querypb.Field)
+		err := lci.vstreamer.VStream(ctx, topodatapb.TabletType_PRIMARY, &binlogdatapb.VGtid{
+			ShardGtids: []*binlogdatapb.ShardGtid{{Keyspace: keyspace, Gtid: "current"}},
+		}, filter, &vtgatepb.VStreamFlags{}, func(events []*binlogdatapb.VEvent) error {
+			if !started {
+				// The rows changed before the stream started were missed.
+				started = true
+				stream.invalidateAll()
+			}
+			for _, event := range events {
+				switch event.Type {
+				case binlogdatapb.VEventType_FIELD:
+					fields[unqualifiedTable(event.FieldEvent.TableName)] = event.FieldEvent.Fields
+				case binlogdatapb.VEventType_ROW:
+					table := unqualifiedTable(event.RowEvent.TableName)
+					stream.invalidateRows(table, fields[table], event.RowEvent.RowChanges)
+				case binlogdatapb.VEventType_DDL:
+					stream.invalidateAll()
+				}
+			}
+			return nil
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		log.Warningf("Lookup vindex cache invalidation stream of keyspace %s failed, restarting it in %v: %v", keyspace, resultCacheStreamRetryDelay, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(resultCacheStreamRetryDelay):
+		}
+	}
+}
+
+// unqualifiedTable returns the table name without the keyspace the
+// VStream qualifies it with.
+func unqualifiedTable(name string) string {
+	if _, table, ok := strings.Cut(name, "."); ok {
+		return table
+	}
+	return name
+}
+
+func (stream *lookupCacheStream) setCaches(caches map[string][]*vindexes.LookupCache) {
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
+	stream.caches = caches
+}
+
+func (stream *lookupCacheStream) tableCaches(table string) []*vindexes.LookupCache {
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
+	return stream.caches[table]
+}
+
+// invalidateRows invalidates the ids of the rows before and after they
+// changed, or all the ids of the table if the column of the ids is not
+// in its fields.
+func (stream *lookupCacheStream) invalidateRows(table string, fields []*querypb.Field, changes []*binlogdatapb.RowChange) {
+	for _, cache := range stream.tableCaches(table) {
+		column := slices.IndexFunc(fields, func(field *querypb.Field) bool {
+			return strings.EqualFold(field.Name, cache.Column())
+		})
+		if column < 0 {
+			cache.InvalidateAll()
+			continue
+		}
+		cache.SetColumnField(fields[column])
+		var ids []sqltypes.Value
+		for _, change := range changes {
+			for _, row := range []*querypb.Row{change.Before, change.After} {
+				if row == nil {
+					continue
+				}
+				if values := sqltypes.MakeRowTrusted(fields, row); column < len(values) {
+					ids = append(ids, values[column])
+				}
+			}
+		}
+		cache.Invalidate(ids)
+	}
+}
+
+func (stream *lookupCacheStream) invalidateAll() {
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
+	for _, caches := range stream.caches {
+		for _, cache := range caches {
+			cache.InvalidateAll()
+		}
+	}
+}
+
+func (stream *lookupCacheStream) stop() {
+	stream.cancel()
+	<-stream.done
+}
+
+// close stops all the streams.
+func (lci *lookupCacheInvalidator) close() {
+	lci.mu.Lock()
+	defer lci.mu.Unlock()
+	lci.closed = true
+	for keyspace, stream := range lci.streams {
+		stream.stop()
+		delete(lci.streams, keyspace)
+	}
+}
+
+// startLookupCacheInvalidation starts invalidating the cached ids of the
+// lookup vindexes as soon as their rows change in the lookup tables. It
+// returns a function that stops it.
+func (e *Executor) startLookupCacheInvalidation(vstreamer resultCacheVStreamer) func() {
+	lci := newLookupCacheInvalidator(vstreamer)
+
+	e.mu.Lock()
+	e.lookupCacheInvalidator = lci
+	caches := e.lookupCachesByTable
+	e.mu.Unlock()
+
+	lci.update(caches)
+	return lci.close
+}
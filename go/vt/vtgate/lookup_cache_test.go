@@ -0,0 +1,204 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/engine"
+	"vitess.io/vitess/go/vt/vtgate/vindexes"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
+)
+
+func TestLookupCacheInvalidator(t *testing.T) {
+	vstreamer := &fakeResultCacheVStreamer{
+		streams: make(map[string]func(events []*binlogdatapb.VEvent) error),
+		filters: make(map[string]*binlogdatapb.Filter),
+	}
+	lci := newLookupCacheInvalidator(vstreamer)
+	defer lci.close()
+
+	createLookup := func(table string, params map[string]string) vindexes.Vindex {
+		m := map[string]string{"table": table, "from": "fromc", "to": "toc"}
+		for k, v := range params {
+			m[k] = v
+		}
+		vindex, err := vindexes.CreateVindex("lookup_unique", table, m)
+		require.NoError(t, err)
+		return vindex
+	}
+	cached := createLookup("lookup_ks.t1", map[string]string{"cache_size": "10"})
+	vschema := &vindexes.VSchema{Keyspaces: map[string]*vindexes.KeyspaceSchema{
+		"ks": {Vindexes: map[string]vindexes.Vindex{
+			"cached":     cached,
+			"not_cached": createLookup("lookup_ks.t2", nil),
+		}},
+	}}
+	lci.update(lookupCaches(vschema))
+
+	cache := cached.(vindexes.LookupCaching).LookupCache()
+	fields := sqltypes.MakeTestFields("toc|fromc", "varbinary|int64")
+	cache.SetColumnField(fields[1])
+	fill := func(ids ...sqltypes.Value) {
+		_, err := cache.Fetch(ids, func(ids []sqltypes.Value) ([]*sqltypes.Result, error) {
+			results := make([]*sqltypes.Result, len(ids))
+			for i := range ids {
+				results[i] = &sqltypes.Result{}
+			}
+			return results, nil
+		})
+		require.NoError(t, err)
+	}
+
+	// The first events invalidate all the cached ids, since the changes
+	// made before the stream started were missed.
+	fill(sqltypes.NewInt64(1), sqltypes.NewInt64(2), sqltypes.NewInt64(3))
+	vstreamer.send(t, "lookup_ks", &binlogdatapb.VEvent{Type: binlogdatapb.VEventType_BEGIN})
+	assert.Zero(t, cache.Len())
+
+	vstreamer.mu.Lock()
+	assert.Equal(t, []*binlogdatapb.Rule{{Match: "t1"}}, vstreamer.filters["lookup_ks"].Rules)
+	vstreamer.mu.Unlock()
+
+	// The ids of the rows before and after they changed are invalidated.
+	fill(sqltypes.NewInt64(1), sqltypes.NewInt64(2), sqltypes.NewInt64(3))
+	row := func(values ...sqltypes.Value) *querypb.Row {
+		return sqltypes.RowToProto3(values)
+	}
+	vstreamer.send(t, "lookup_ks",
+		&binlogdatapb.VEvent{Type: binlogdatapb.VEventType_FIELD, FieldEvent: &binlogdatapb.FieldEvent{TableName: "lookup_ks.t1", Fields: fields}},
+		&binlogdatapb.VEvent{Type: binlogdatapb.VEventType_ROW, RowEvent: &binlogdatapb.RowEvent{TableName: "lookup_ks.t1", RowChanges: []*binlogdatapb.RowChange{{
+			Before: row(sqltypes.NewVarBinary("\x16k@\xb4J\xbaK\xd6"), sqltypes.NewInt64(1)),
+			After:  row(sqltypes.NewVarBinary("\x16k@\xb4J\xbaK\xd6"), sqltypes.NewInt64(2)),
+		}}}},
+		&binlogdatapb.VEvent{Type: binlogdatapb.VEventType_COMMIT},
+	)
+	assert.Equal(t, 1, cache.Len())
+
+	// The stream is stopped once no lookup vindex has a cache. The update
+	// does not wait for the stopped stream to end, the returned function
+	// does.
+	hold := make(chan struct{})
+	vstreamer.mu.Lock()
+	vstreamer.hold = hold
+	vstreamer.mu.Unlock()
+	vschema.Keyspaces["ks"].Vindexes["cached"] = createLookup("lookup_ks.t1", nil)
+	wait := lci.update(lookupCaches(vschema))
+	vstreamer.mu.Lock()
+	assert.Contains(t, vstreamer.streams, "lookup_ks")
+	vstreamer.mu.Unlock()
+	close(hold)
+	wait()
+	vstreamer.mu.Lock()
+	assert.Empty(t, vstreamer.streams)
+	vstreamer.mu.Unlock()
+}
+
+func TestInvalidateLookupCaches(t *testing.T) {
+	vindex, err := vindexes.CreateVindex("lookup_unique", "lkp", map[string]string{"table": "lookup_ks.t1", "from": "fromc", "to": "toc", "cache_size": "10"})
+	require.NoError(t, err)
+	vschema := &vindexes.VSchema{Keyspaces: map[string]*vindexes.KeyspaceSchema{
+		"ks": {Vindexes: map[string]vindexes.Vindex{"lkp": vindex}},
+	}}
+	cache := vindex.(vindexes.LookupCaching).LookupCache()
+	cache.SetColumnField(sqltypes.MakeTestFields("fromc", "int64")[0])
+	fill := func() {
+		_, err := cache.Fetch([]sqltypes.Value{sqltypes.NewInt64(1), sqltypes.NewInt64(2), sqltypes.NewInt64(3)}, func(ids []sqltypes.Value) ([]*sqltypes.Result, error) {
+			results := make([]*sqltypes.Result, len(ids))
+			for i := range ids {
+				results[i] = &sqltypes.Result{}
+			}
+			return results, nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 3, cache.Len())
+	}
+
+	// The ids changed by the transaction are invalidated when it commits.
+	session := NewSafeSession(&vtgatepb.Session{InTransaction: true})
+	session.AddLookupCacheInvalidation("lookup_ks.t1", "fromc", []sqltypes.Value{sqltypes.NewInt64(1)})
+	session.AddLookupCacheInvalidation("lookup_ks.t1", "fromc", []sqltypes.Value{sqltypes.NewInt64(2)})
+	session.AddLookupCacheInvalidation("lookup_ks.t2", "fromc", []sqltypes.Value{sqltypes.NewInt64(3)})
+	fill()
+	invalidateLookupCaches(vschema, session.GetLookupCacheInvalidations())
+	assert.Equal(t, 1, cache.Len())
+
+	// All the ids are invalidated once the transaction changed too many.
+	ids := make([]sqltypes.Value, maxLookupCacheInvalidationIds+1)
+	for i := range ids {
+		ids[i] = sqltypes.NewInt64(int64(i + 10))
+	}
+	session.AddLookupCacheInvalidation("lookup_ks.t1", "fromc", ids)
+	fill()
+	invalidateLookupCaches(vschema, session.GetLookupCacheInvalidations())
+	assert.Zero(t, cache.Len())
+
+	session.ResetTx()
+	assert.Empty(t, session.GetLookupCacheInvalidations())
+}
+
+func TestInvalidateLookupCachesOfTables(t *testing.T) {
+	vindex, err := vindexes.CreateVindex("lookup_unique", "lkp", map[string]string{"table": "lookup_ks.t1", "from": "fromc", "to": "toc", "cache_size": "10"})
+	require.NoError(t, err)
+	vschema := &vindexes.VSchema{Keyspaces: map[string]*vindexes.KeyspaceSchema{
+		"ks": {Vindexes: map[string]vindexes.Vindex{"lkp": vindex}},
+	}}
+	e := &Executor{lookupCachesByTable: lookupCaches(vschema)}
+	cache := vindex.(vindexes.LookupCaching).LookupCache()
+	cache.SetColumnField(sqltypes.MakeTestFields("fromc", "int64")[0])
+	fill := func() {
+		_, err := cache.Fetch([]sqltypes.Value{sqltypes.NewInt64(1), sqltypes.NewInt64(2)}, func(ids []sqltypes.Value) ([]*sqltypes.Result, error) {
+			results := make([]*sqltypes.Result, len(ids))
+			for i := range ids {
+				results[i] = &sqltypes.Result{}
+			}
+			return results, nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 2, cache.Len())
+	}
+
+	// Reading the lookup table, or changing another table, keeps the cached ids.
+	session := NewSafeSession(&vtgatepb.Session{})
+	fill()
+	e.invalidateLookupCachesOfTables(session, &engine.Plan{Type: sqlparser.StmtSelect, TablesUsed: []string{"lookup_ks.t1"}})
+	e.invalidateLookupCachesOfTables(session, &engine.Plan{Type: sqlparser.StmtDelete, TablesUsed: []string{"lookup_ks.t2"}})
+	assert.Equal(t, 2, cache.Len())
+
+	// Changing the lookup table directly invalidates all the cached ids.
+	e.invalidateLookupCachesOfTables(session, &engine.Plan{Type: sqlparser.StmtDelete, TablesUsed: []string{"lookup_ks.t1"}})
+	assert.Zero(t, cache.Len())
+	assert.Empty(t, session.GetLookupCacheInvalidations())
+
+	// In a transaction, they are invalidated again when it commits, along with
+	// the ids the lookup vindex changed before.
+	session = NewSafeSession(&vtgatepb.Session{InTransaction: true})
+	session.AddLookupCacheInvalidation("lookup_ks.t1", "fromc", []sqltypes.Value{sqltypes.NewInt64(1)})
+	e.invalidateLookupCachesOfTables(session, &engine.Plan{Type: sqlparser.StmtUpdate, TablesUsed: []string{"lookup_ks.t1"}})
+	session.AddLookupCacheInvalidation("lookup_ks.t1", "fromc", []sqltypes.Value{sqltypes.NewInt64(1)})
+	fill()
+	invalidateLookupCaches(vschema, session.GetLookupCacheInvalidations())
+	assert.Zero(t, cache.Len())
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"context"
+	"time"
+
+	"vitess.io/vitess/go/stats"
+	"vitess.io/vitess/go/vt/discovery"
+	"vitess.io/vitess/go/vt/vterrors"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+// The max_replica_lag of a query, set with the max_replica_lag session variable
+// or the MAX_REPLICA_LAG comment directive, bounds the staleness of its reads:
+// the TabletGateway only sends it to the replicas whose replication lag is at
+// most max_replica_lag. When none of them is, the query goes to the primary,
+// or fails if --max-replica-lag-fallback-to-primary is false.
+
+var (
+	// maxReplicaLagFallbackToPrimary sends the queries no replica is fresh enough for to the primary.
+	maxReplicaLagFallbackToPrimary = true
+
+	maxReplicaLagFallbacks = stats.NewCountersWithMultiLabels("MaxReplicaLagFallbacks", "Queries sent to the primary because no replica was within their max_replica_lag", []string{"Keyspace", "Shard"})
+)
+
+type maxReplicaLagKey struct{}
+
+// withMaxReplicaLag returns a context bounding the replication lag of the
+// replicas the queries executed with it are sent to. 0 does not bound it.
+func withMaxReplicaLag(ctx context.Context, maxReplicaLag time.Duration) context.Context {
+	if maxReplicaLag <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, maxReplicaLagKey{}, maxReplicaLag)
+}
+
+func maxReplicaLagFromContext(ctx context.Context) (time.Duration, bool) {
+	maxReplicaLag, ok := ctx.Value(maxReplicaLagKey{}).(time.Duration)
+	return maxReplicaLag, ok
+}
+
+// maxReplicaLag returns the max_replica_lag of the query. The comment directive
+// has priority over the session variable.
+func (vc *vcursorImpl) maxReplicaLag() time.Duration {
+	if vc.maxReplicaLagSet {
+		return vc.maxReplicaLagFromComments
+	}
+	return vc.safeSession.GetMaxReplicaLag()
+}
+
+// replicationLagWithin returns true if the replication lag of the tablet is at
+// most maxReplicaLag. The tablets report their lag in whole seconds, rounded
+// down, so it can be up to a second more than reported.
+func replicationLagWithin(th *discovery.TabletHealth, maxReplicaLag time.Duration) bool {
+	lag := time.Duration(th.Stats.GetReplicationLagSeconds()) * time.Second
+	return lag+time.Second <= maxReplicaLag
+}
+
+// boundedStalenessTablets returns the tablets of the target whose replication
+// lag is at most maxReplicaLag, and the target to execute the query with. When
+// none of them is, including when the target has no healthy tablet, it returns
+// the tablets of the primary and its target instead.
+func (gw *TabletGateway) boundedStalenessTablets(target *querypb.Target, tablets []*discovery.TabletHealth, maxReplicaLag time.Duration) (*querypb.Target, []*discovery.TabletHealth, error) {
+	var fresh []*discovery.TabletHealth
+	for _, th := range tablets {
+		if replicationLagWithin(th, maxReplicaLag) {
+			fresh = append(fresh, th)
+		}
+	}
+	if len(fresh) > 0 {
+		return target, fresh, nil
+	}
+	if !maxReplicaLagFallbackToPrimary {
+		return nil, nil, vterrors.Errorf(vtrpcpb.Code_UNAVAILABLE, "no tablet with a replication lag of at most %v available for '%s'", maxReplicaLag, target.String())
+	}
+
+	maxReplicaLagFallbacks.Add([]string{target.Keyspace, target.Shard}, 1)
+	primary := target.CloneVT()
+	primary.TabletType = topodatapb.TabletType_PRIMARY
+	return primary, gw.hc.GetHealthyTabletStats(primary), nil
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/vt/discovery"
+	"vitess.io/vitess/go/vt/vttablet/sandboxconn"
+
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
+)
+
+func TestExecutorMaxReplicaLag(t *testing.T) {
+	var primary, replica *sandboxconn.SandboxConn
+	executor, ctx := createExecutorEnvCallback(t, func(shard, ks string, tabletType topodatapb.TabletType, conn *sandboxconn.SandboxConn) {
+		if ks == KsTestUnsharded {
+			if tabletType == topodatapb.TabletType_PRIMARY {
+				primary = conn
+			} else {
+				replica = conn
+			}
+		}
+	})
+	hc := executor.resolver.scatterConn.gateway.hc.(*discovery.FakeHealthCheck)
+	th, err := hc.GetTabletHealthByAlias(replica.Tablet().Alias)
+	require.NoError(t, err)
+	th.Stats.ReplicationLagSeconds = 10
+
+	session := &vtgatepb.Session{TargetString: "@replica", Autocommit: true}
+	_, err = executorExec(ctx, executor, session, "set max_replica_lag = '30s'", nil)
+	require.NoError(t, err)
+	_, err = executorExec(ctx, executor, session, "select id from simple", nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, replica.ExecCount.Load())
+	assert.EqualValues(t, 0, primary.ExecCount.Load())
+
+	// The replica lags too much for the session.
+	_, err = executorExec(ctx, executor, session, "set max_replica_lag = '5s'", nil)
+	require.NoError(t, err)
+	_, err = executorExec(ctx, executor, session, "select id from simple", nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, replica.ExecCount.Load())
+	assert.EqualValues(t, 1, primary.ExecCount.Load())
+
+	// The comment directive overrides the session.
+	_, err = executorExec(ctx, executor, session, "select /*vt+ MAX_REPLICA_LAG=0 */ id from simple", nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, replica.ExecCount.Load())
+	_, err = executorExec(ctx, executor, &vtgatepb.Session{TargetString: "@replica", Autocommit: true}, "select /*vt+ MAX_REPLICA_LAG=1s */ id from simple", nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, primary.ExecCount.Load())
+
+	_, err = executorExec(ctx, executor, session, "select /*vt+ MAX_REPLICA_LAG=1 */ id from simple", nil)
+	require.ErrorContains(t, err, "Invalid max replica lag value specified in query")
+
+	result, err := executorExec(ctx, executor, session, "select @@max_replica_lag", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "5s", result.Rows[0][0].ToString())
+}
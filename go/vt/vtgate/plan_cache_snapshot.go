@@ -0,0 +1,316 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/vt/log"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/engine"
+	"vitess.io/vitess/go/vt/vtgate/logstats"
+)
+
+// The plan cache only lives in memory, so a restarted vtgate has to plan
+// all of its queries again. To avoid that, the hottest plans of the cache
+// can be saved periodically to a snapshot file. The snapshot holds what
+// makes the cache key of a plan, i.e. the normalized query, the target and
+// the collation of the session, and the session settings the planner read,
+// and the queries are planned again from it when vtgate starts, before it
+// reports itself healthy.
+
+// planCacheSnapshot is the content of a plan cache snapshot file.
+type planCacheSnapshot struct {
+	Plans []*planCacheSnapshotEntry
+}
+
+// planCacheSnapshotEntry is a cached plan, as saved in a snapshot.
+type planCacheSnapshotEntry struct {
+	// PlanSession is the state of the session the plan was cached for.
+	engine.PlanSession
+	// Query is the normalized query of the plan.
+	Query string
+	// BindVarNeeds are the bind variables found while rewriting the
+	// original query, which can't be found again in the normalized one.
+	BindVarNeeds *sqlparser.BindVarNeeds `json:",omitempty"`
+	// ExecCount is the number of times the plan was executed.
+	ExecCount uint64
+}
+
+// planCacheSnapshot returns the size most executed plans of the cache.
+func (e *Executor) planCacheSnapshot(size int) *planCacheSnapshot {
+	var entries []*planCacheSnapshotEntry
+	e.ForEachPlan(func(plan *engine.Plan) bool {
+		if plan.Session == nil {
+			return true
+		}
+		entries = append(entries, &planCacheSnapshotEntry{
+			PlanSession:  *plan.Session,
+			Query:        plan.Original,
+			BindVarNeeds: plan.BindVarNeeds,
+			ExecCount:    atomic.LoadUint64(&plan.ExecCount),
+		})
+		return true
+	})
+	slices.SortStableFunc(entries, func(a, b *planCacheSnapshotEntry) int {
+		switch {
+		case a.ExecCount > b.ExecCount:
+			return -1
+		case a.ExecCount < b.ExecCount:
+			return 1
+		}
+		return 0
+	})
+	if len(entries) > size {
+		entries = entries[:size]
+	}
+	return &planCacheSnapshot{Plans: entries}
+}
+
+// writePlanCacheSnapshot writes the snapshot to the given file. The file
+// is replaced atomically, so a vtgate starting while it is written still
+// reads the previous snapshot.
+func writePlanCacheSnapshot(path string, snapshot *planCacheSnapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// readPlanCacheSnapshot reads the snapshot from the given file.
+func readPlanCacheSnapshot(path string) (*planCacheSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &planCacheSnapshot{}
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// errNotNormalized is returned when warming the plan of a query that was
+// not normalized, as its cache key can't be computed from the snapshot.
+var errNotNormalized = errors.New("the query is not normalized")
+
+// argumentTypes matches the bind variables of a normalized query, and the
+// comments their types are written in.
+var argumentTypes = regexp.MustCompile(`:(\w+) /\* (\w+)(?:\((\d+)(?:,(\d+))?\))? \*/`)
+
+// restoreArgumentTypes sets the types of the bind variables of stmt from
+// the normalized query it was parsed from, as the parser ignores them.
+func restoreArgumentTypes(stmt sqlparser.Statement, query string) {
+	types := make(map[string]sqlparser.Argument)
+	for _, match := range argumentTypes.FindAllStringSubmatch(query, -1) {
+		typ, ok := querypb.Type_value[match[2]]
+		if !ok {
+			continue
+		}
+		size, _ := strconv.ParseInt(match[3], 10, 32)
+		scale, _ := strconv.ParseInt(match[4], 10, 32)
+		types[match[1]] = sqlparser.Argument{Type: querypb.Type(typ), Size: int32(size), Scale: int32(scale)}
+	}
+	if len(types) == 0 {
+		return
+	}
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if arg, ok := node.(*sqlparser.Argument); ok {
+			if typed, ok := types[arg.Name]; ok {
+				arg.Type, arg.Size, arg.Scale = typed.Type, typed.Size, typed.Scale
+			}
+		}
+		return true, nil
+	}, stmt)
+}
+
+// warmPlanCache plans the queries of the snapshot, and adds their plans
+// to the cache. It returns the number of queries planned.
+func (e *Executor) warmPlanCache(ctx context.Context, snapshot *planCacheSnapshot) int {
+	planned := 0
+	for _, entry := range snapshot.Plans {
+		if ctx.Err() != nil {
+			break
+		}
+		err := e.warmPlan(ctx, entry)
+		if err == errNotNormalized {
+			continue
+		}
+		if err != nil {
+			log.Warningf("Unable to plan %q from the plan cache snapshot: %v", entry.Query, err)
+			continue
+		}
+		planned++
+	}
+	return planned
+}
+
+func (e *Executor) warmPlan(ctx context.Context, entry *planCacheSnapshotEntry) error {
+	session := &vtgatepb.Session{TargetString: entry.Target}
+	if entry.PlannerVersion != querypb.ExecuteOptions_DEFAULT_PLANNER {
+		session.Options = &querypb.ExecuteOptions{PlannerVersion: entry.PlannerVersion}
+	}
+	safeSession := NewSafeSession(session)
+	logStats := logstats.NewLogStats(ctx, "PlanCacheWarmup", entry.Query, "", nil)
+	vcursor, err := newVCursorImpl(safeSession, sqlparser.MarginComments{}, e, logStats, e.vm, e.VSchema(), e.resolver.resolver, e.serv, e.warnShardedOnly, e.pv)
+	if err != nil {
+		return err
+	}
+	// The collation of the connections may not be known yet while vtgate
+	// starts, so the one the plan was cached with is used.
+	if entry.Collation != "" {
+		collation := e.env.CollationEnv().LookupByName(entry.Collation)
+		if collation == collations.Unknown {
+			return fmt.Errorf("unknown collation %s", entry.Collation)
+		}
+		vcursor.collation = collation
+	}
+
+	stmt, reservedVars, err := parseAndValidateQuery(entry.Query, e.env.Parser())
+	if err != nil {
+		return err
+	}
+	// The query was normalized before it was saved, so it is the one the
+	// cache key is computed from, and it has already been rewritten.
+	restoreArgumentTypes(stmt, entry.Query)
+	if sqlparser.String(stmt) != entry.Query {
+		return errNotNormalized
+	}
+	vcursor.fkChecksState = entry.ForeignKeyChecks
+
+	bindVarNeeds := entry.BindVarNeeds
+	if bindVarNeeds == nil {
+		bindVarNeeds = &sqlparser.BindVarNeeds{}
+	}
+
+	planKey := e.hashPlan(ctx, vcursor, entry.Query)
+	_, _, err = e.plans.GetOrLoad(planKey, e.epoch.Load(), func() (*engine.Plan, error) {
+		plan, err := e.buildStatement(ctx, vcursor, entry.Query, stmt, reservedVars, bindVarNeeds)
+		if plan != nil {
+			plan.Session = vcursor.planSession()
+		}
+		return plan, err
+	})
+	return err
+}
+
+// waitForVSchema waits until the first vschema is loaded, and returns
+// false if the context is done first.
+func (e *Executor) waitForVSchema(ctx context.Context) bool {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for e.VSchema() == nil {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+	return true
+}
+
+// WarmingPlanCache returns true while the plan cache is being warmed
+// from a snapshot.
+func (e *Executor) WarmingPlanCache() bool {
+	return e.warmingPlans.Load()
+}
+
+// startPlanCacheSnapshots warms the plan cache from the snapshot file, and
+// then saves the hottest plans of the cache to it every interval. The
+// returned function stops the snapshots, after saving a last one.
+func (e *Executor) startPlanCacheSnapshots(path string, interval time.Duration, size int, warmupTimeout time.Duration) (stop func()) {
+	e.warmingPlans.Store(true)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		e.warmPlanCacheFromFile(path, warmupTimeout)
+		e.warmingPlans.Store(false)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				e.savePlanCacheSnapshot(path, size)
+				return
+			case <-ticker.C:
+				e.savePlanCacheSnapshot(path, size)
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+func (e *Executor) warmPlanCacheFromFile(path string, timeout time.Duration) {
+	snapshot, err := readPlanCacheSnapshot(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Warningf("Unable to read the plan cache snapshot %s: %v", path, err)
+		}
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if !e.waitForVSchema(ctx) {
+		log.Warningf("No vschema loaded after %v, not warming the plan cache", timeout)
+		return
+	}
+
+	start := time.Now()
+	// The cache is cleared whenever the vschema changes, which is likely
+	// to happen while vtgate starts. The queries are then planned again.
+	for ctx.Err() == nil {
+		epoch := e.epoch.Load()
+		planned := e.warmPlanCache(ctx, snapshot)
+		if e.epoch.Load() == epoch {
+			log.Infof("Warmed the plan cache with %d/%d plans of %s in %v", planned, len(snapshot.Plans), path, time.Since(start))
+			return
+		}
+	}
+	log.Warningf("Timed out warming the plan cache after %v", timeout)
+}
+
+func (e *Executor) savePlanCacheSnapshot(path string, size int) {
+	if err := writePlanCacheSnapshot(path, e.planCacheSnapshot(size)); err != nil {
+		log.Warningf("Unable to write the plan cache snapshot %s: %v", path, err)
+	}
+}
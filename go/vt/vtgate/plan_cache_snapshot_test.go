@@ -0,0 +1,192 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
+	"vitess.io/vitess/go/vt/vtgate/engine"
+)
+
+func findPlan(e *Executor, target, query string) *engine.Plan {
+	var plan *engine.Plan
+	e.ForEachPlan(func(p *engine.Plan) bool {
+		if p.Session.Target == target && p.Original == query {
+			plan = p
+			return false
+		}
+		return true
+	})
+	return plan
+}
+
+func TestPlanCacheSnapshot(t *testing.T) {
+	queries := []struct {
+		target, sql, normalized string
+		count                   int
+	}{{
+		target:     "@primary",
+		sql:        "select id from user where id = 1",
+		normalized: "select id from `user` where id = :id /* INT64 */",
+		count:      3,
+	}, {
+		target:     "@primary",
+		sql:        "select @foo from user where id = 1",
+		normalized: "select :__vtudvfoo as `@foo` from `user` where id = :id /* INT64 */",
+		count:      2,
+	}, {
+		target:     KsTestUnsharded,
+		sql:        "select id from music_user_map where id = 'a'",
+		normalized: "select id from music_user_map where id = :id /* VARCHAR */",
+		count:      1,
+	}}
+	path := filepath.Join(t.TempDir(), "plans.json")
+
+	t.Run("save", func(t *testing.T) {
+		executor, _, _, _, ctx := createExecutorEnv(t)
+		executor.normalize = true
+		for _, q := range queries {
+			for range q.count {
+				_, err := executorExec(ctx, executor, &vtgatepb.Session{TargetString: q.target}, q.sql, nil)
+				require.NoError(t, err)
+			}
+		}
+
+		snapshot := executor.planCacheSnapshot(10)
+		require.Len(t, snapshot.Plans, len(queries))
+		for i, q := range queries {
+			entry := snapshot.Plans[i]
+			assert.Equal(t, q.target, entry.Target)
+			assert.Equal(t, q.normalized, entry.Query)
+			assert.EqualValues(t, q.count, entry.ExecCount)
+		}
+		assert.Equal(t, []string{"foo"}, snapshot.Plans[1].BindVarNeeds.NeedUserDefinedVariables)
+		assert.Len(t, executor.planCacheSnapshot(2).Plans, 2)
+
+		require.NoError(t, writePlanCacheSnapshot(path, snapshot))
+	})
+
+	// A restarted vtgate plans the queries again, with the same cache keys.
+	t.Run("warm", func(t *testing.T) {
+		snapshot, err := readPlanCacheSnapshot(path)
+		require.NoError(t, err)
+		require.Len(t, snapshot.Plans, len(queries))
+
+		executor, _, _, _, ctx := createExecutorEnv(t)
+		executor.normalize = true
+		assert.Equal(t, len(queries), executor.warmPlanCache(ctx, snapshot))
+		for _, q := range queries {
+			plan := findPlan(executor, q.target, q.normalized)
+			require.NotNil(t, plan, q.normalized)
+			assert.Zero(t, plan.ExecCount)
+
+			_, err := executorExec(ctx, executor, &vtgatepb.Session{TargetString: q.target}, q.sql, nil)
+			require.NoError(t, err)
+			assert.EqualValues(t, 1, plan.ExecCount, "the warmed plan was not used for %s", q.sql)
+		}
+		assert.Equal(t, []string{"foo"}, findPlan(executor, "@primary", queries[1].normalized).BindVarNeeds.NeedUserDefinedVariables)
+
+		// Queries that were not normalized can't be planned again.
+		planned := executor.warmPlanCache(ctx, &planCacheSnapshot{Plans: []*planCacheSnapshotEntry{{
+			PlanSession: engine.PlanSession{Target: "@primary"},
+			Query:       "SELECT id FROM user",
+		}}})
+		assert.Zero(t, planned)
+	})
+}
+
+func TestPlanCacheSnapshotWarmup(t *testing.T) {
+	executor, _, _, _, _ := createExecutorEnv(t)
+	path := filepath.Join(t.TempDir(), "plans.json")
+	require.NoError(t, writePlanCacheSnapshot(path, &planCacheSnapshot{Plans: []*planCacheSnapshotEntry{{
+		PlanSession: engine.PlanSession{Target: "@primary"},
+		Query:       "select id from `user` where id = :id /* INT64 */",
+		ExecCount:   10,
+	}}}))
+
+	vtg := &VTGate{executor: executor}
+	stop := executor.startPlanCacheSnapshots(path, time.Hour, 10, 10*time.Second)
+	require.Eventually(t, func() bool {
+		return vtg.IsHealthy() == nil
+	}, 10*time.Second, 10*time.Millisecond)
+	require.NotNil(t, findPlan(executor, "@primary", "select id from `user` where id = :id /* INT64 */"))
+
+	// A last snapshot is saved when stopping.
+	require.NoError(t, writePlanCacheSnapshot(path, &planCacheSnapshot{}))
+	stop()
+	snapshot, err := readPlanCacheSnapshot(path)
+	require.NoError(t, err)
+	require.Len(t, snapshot.Plans, 1)
+	assert.Equal(t, "select id from `user` where id = :id /* INT64 */", snapshot.Plans[0].Query)
+}
+
+func TestPlanCacheSnapshotSession(t *testing.T) {
+	session := &vtgatepb.Session{
+		TargetString:    "@primary",
+		Options:         &querypb.ExecuteOptions{PlannerVersion: querypb.ExecuteOptions_Gen4Left2Right},
+		SystemVariables: map[string]string{"foreign_key_checks": "0"},
+	}
+	var snapshot *planCacheSnapshot
+
+	t.Run("save", func(t *testing.T) {
+		executor, _, _, _, ctx := createExecutorEnv(t)
+		executor.normalize = true
+		_, err := executorExec(ctx, executor, session, "select id from user where id = 1", nil)
+		require.NoError(t, err)
+
+		snapshot = executor.planCacheSnapshot(10)
+		require.Len(t, snapshot.Plans, 1)
+		entry := snapshot.Plans[0]
+		assert.Equal(t, "@primary", entry.Target)
+		assert.Equal(t, executor.env.CollationEnv().LookupName(executor.env.CollationEnv().DefaultConnectionCharset()), entry.Collation)
+		assert.Equal(t, querypb.ExecuteOptions_Gen4Left2Right, entry.PlannerVersion)
+		require.NotNil(t, entry.ForeignKeyChecks)
+		assert.False(t, *entry.ForeignKeyChecks)
+	})
+
+	// The plan is built again with the same session state, so that it has the
+	// same cache key.
+	t.Run("warm", func(t *testing.T) {
+		require.NotNil(t, snapshot)
+		entry := snapshot.Plans[0]
+		executor, _, _, _, ctx := createExecutorEnv(t)
+		executor.normalize = true
+		assert.Equal(t, 1, executor.warmPlanCache(ctx, snapshot))
+		plan := findPlan(executor, "@primary", entry.Query)
+		require.NotNil(t, plan)
+		assert.Equal(t, entry.PlanSession, *plan.Session)
+		_, err := executorExec(ctx, executor, session, "select id from user where id = 1", nil)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, plan.ExecCount, "the warmed plan was not used")
+
+		// The plans are warmed with the collation they were cached with.
+		entry.Collation = "utf8mb4_general_ci"
+		executor.ClearPlans()
+		assert.Equal(t, 1, executor.warmPlanCache(ctx, snapshot))
+		assert.Equal(t, "utf8mb4_general_ci", findPlan(executor, "@primary", entry.Query).Session.Collation)
+
+		entry.Collation = "no_such_collation"
+		assert.Zero(t, executor.warmPlanCache(ctx, snapshot))
+	})
+}
@@ -23,6 +23,7 @@ import (
 	"time"
 
 	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/callerid"
 	"vitess.io/vitess/go/vt/log"
 	"vitess.io/vitess/go/vt/sqlparser"
 	"vitess.io/vitess/go/vt/vterrors"
@@ -31,6 +32,7 @@ import (
 	"vitess.io/vitess/go/vt/vtgate/vtgateservice"
 
 	querypb "vitess.io/vitess/go/vt/proto/query"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
 	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
 )
 
@@ -85,8 +87,27 @@ func (e *Executor) newExecute(
 		return err
 	}
 
+	if !exemptFromQueryQuotas(safeSession, stmt) {
+		release, err := e.queryQuotas.admit(ctx, callerid.GetUsername(callerid.ImmediateCallerIDFromContext(ctx)), workloadName(safeSession, stmt))
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+
+	vs := e.VSchema()
+	applied, err := e.applyQueryRules(safeSession, vs, stmt, bindVars)
+	if err != nil {
+		return err
+	}
+	defer applied.release()
+	if applied.stmt != stmt {
+		stmt = applied.stmt
+		query = sqlparser.String(stmt)
+		reservedVars = sqlparser.NewReservedVars("vtg", sqlparser.GetBindvars(stmt))
+	}
+
 	var (
-		vs                 = e.VSchema()
 		lastVSchemaCreated = vs.GetCreated()
 		result             *sqltypes.Result
 		plan               *engine.Plan
@@ -115,6 +136,9 @@ func (e *Executor) newExecute(
 		if err != nil {
 			return err
 		}
+		if applied.tabletType != topodatapb.TabletType_UNKNOWN {
+			vcursor.tabletType = applied.tabletType
+		}
 
 		// 3: Create a plan for the query.
 		// If we are retrying, it is likely that the routing rules have changed and hence we need to
@@ -156,14 +180,24 @@ func (e *Executor) newExecute(
 		}
 
 		// 5: Execute the plan.
+		// The queries of a transaction stay on the tablets it started on,
+		// so only the queries outside of them bound the replication lag.
+		execCtx := ctx
+		if !safeSession.InTransaction() {
+			execCtx = withMaxReplicaLag(ctx, vcursor.maxReplicaLag())
+		}
 		if plan.Instructions.NeedsTransaction() {
-			err = e.insideTransaction(ctx, safeSession, logStats,
+			err = e.insideTransaction(execCtx, safeSession, logStats,
 				func() error {
-					return execPlan(ctx, plan, vcursor, bindVars, execStart)
+					return execPlan(execCtx, plan, vcursor, bindVars, execStart)
 				})
 		} else {
-			err = execPlan(ctx, plan, vcursor, bindVars, execStart)
+			err = execPlan(execCtx, plan, vcursor, bindVars, execStart)
 		}
+		// The tables are invalidated even if the plan failed, since it may
+		// have changed some of their rows before it did.
+		e.invalidateResultCache(safeSession, plan)
+		e.invalidateLookupCachesOfTables(safeSession, plan)
 
 		if err == nil || safeSession.InTransaction() {
 			return err
@@ -299,7 +333,7 @@ func (e *Executor) insideTransaction(ctx context.Context, safeSession *SafeSessi
 
 	if mustCommit {
 		commitStart := time.Now()
-		if err := e.txConn.Commit(ctx, safeSession); err != nil {
+		if err := e.commit(ctx, safeSession); err != nil {
 			return err
 		}
 		logStats.CommitTime = time.Since(commitStart)
@@ -317,6 +351,10 @@ func (e *Executor) executePlan(
 	execStart time.Time,
 ) (*sqltypes.Result, error) {
 
+	if ttl := e.resultCacheTTL(safeSession, plan, vcursor); ttl > 0 {
+		return e.executeCachedPlan(ctx, safeSession, plan, vcursor, bindVars, logStats, execStart, ttl)
+	}
+
 	// 4: Execute!
 	qr, err := vcursor.ExecutePrimitive(ctx, plan.Instructions, bindVars, true)
 
@@ -330,6 +368,38 @@ func (e *Executor) executePlan(
 	return qr, nil
 }
 
+// executeCachedPlan returns the cached result of the read-only plan, or
+// executes it and caches its result for the given ttl.
+func (e *Executor) executeCachedPlan(
+	ctx context.Context,
+	safeSession *SafeSession,
+	plan *engine.Plan,
+	vcursor *vcursorImpl,
+	bindVars map[string]*querypb.BindVariable,
+	logStats *logstats.LogStats,
+	execStart time.Time,
+	ttl time.Duration,
+) (*sqltypes.Result, error) {
+	key := e.resultCacheKey(ctx, plan, vcursor, bindVars)
+	if qr, ok := e.resultCache.get(key); ok {
+		e.setLogStats(logStats, plan, vcursor, execStart, nil, qr)
+		return qr, nil
+	}
+
+	generations := e.resultCache.generations(plan.TablesUsed)
+	qr, err := vcursor.ExecutePrimitive(ctx, plan.Instructions, bindVars, true)
+	e.setLogStats(logStats, plan, vcursor, execStart, err, qr)
+	if err != nil {
+		return nil, err
+	}
+
+	// The warnings of the query are not cached, so its result is not either.
+	if len(safeSession.GetWarnings()) == 0 {
+		e.resultCache.set(key, qr, ttl, plan.TablesUsed, generations)
+	}
+	return qr, nil
+}
+
 // rollbackExecIfNeeded rollbacks the partial execution if earlier it was detected that it needs partial query execution to be rolled back.
 func (e *Executor) rollbackExecIfNeeded(ctx context.Context, safeSession *SafeSession, bindVars map[string]*querypb.BindVariable, logStats *logstats.LogStats, err error) error {
 	if safeSession.InTransaction() && safeSession.IsRollbackSet() {
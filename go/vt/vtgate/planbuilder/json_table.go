@@ -0,0 +1,235 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package planbuilder
+
+import (
+	"fmt"
+	"strings"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/mysql/json"
+	"vitess.io/vitess/go/sqltypes"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/engine"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/operators"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
+)
+
+func transformJSONTable(ctx *plancontext.PlanningContext, op *operators.JSONTable) (logicalPlan, error) {
+	cfg := &evalengine.Config{
+		Collation:   ctx.SemTable.Collation,
+		ResolveType: ctx.SemTable.TypeForExpr,
+		Environment: ctx.VSchema.Environment(),
+	}
+	doc, err := evalengine.Translate(&sqlparser.CastExpr{
+		Expr: op.Expr.Expr,
+		Type: &sqlparser.ConvertType{Type: "JSON"},
+	}, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &jsonTableBuilder{
+		cfg: &evalengine.Config{
+			Collation: ctx.SemTable.Collation,
+			ResolveType: func(sqlparser.Expr) (evalengine.Type, bool) {
+				return evalengine.NewType(sqltypes.TypeJSON, collations.CollationBinaryID), true
+			},
+			Environment: ctx.VSchema.Environment(),
+		},
+	}
+	path, err := b.path(op.Expr.Filter, op.Expr.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	prim := &engine.JSONTable{
+		Doc:   doc,
+		Path:  path,
+		Width: len(b.columns),
+	}
+	for _, col := range op.Columns {
+		offset := b.offset(col.Name.String())
+		if offset < 0 {
+			return nil, vterrors.VT13001(fmt.Sprintf("column %s not found in json_table", sqlparser.String(col)))
+		}
+		typ, _ := ctx.SemTable.TypeForExpr(col)
+		prim.Cols = append(prim.Cols, offset)
+		prim.Fields = append(prim.Fields, typ.ToField(col.Name.String()))
+	}
+	return &primitiveWrapper{prim: prim}, nil
+}
+
+// jsonTableBuilder builds the paths and columns of a JSON_TABLE primitive
+type jsonTableBuilder struct {
+	cfg     *evalengine.Config
+	columns []*engine.JSONTableColumn
+}
+
+func (b *jsonTableBuilder) offset(name string) int {
+	for _, col := range b.columns {
+		if strings.EqualFold(col.Name, name) {
+			return col.Offset
+		}
+	}
+	return -1
+}
+
+func (b *jsonTableBuilder) path(expr sqlparser.Expr, columns []*sqlparser.JtColumnDefinition) (*engine.JSONTablePath, error) {
+	jp, err := jsonTablePath(expr)
+	if err != nil {
+		return nil, err
+	}
+	path := &engine.JSONTablePath{Path: jp}
+	for _, col := range columns {
+		switch {
+		case col.JtOrdinal != nil:
+			path.Columns = append(path.Columns, b.add(&engine.JSONTableColumn{
+				Name: col.JtOrdinal.Name.String(),
+				Kind: engine.JSONTableOrdinality,
+				Type: sqltypes.Uint32,
+			}))
+		case col.JtPath != nil:
+			column, err := b.column(col.JtPath)
+			if err != nil {
+				return nil, err
+			}
+			path.Columns = append(path.Columns, b.add(column))
+		case col.JtNestedPath != nil:
+			nested, err := b.path(col.JtNestedPath.Path, col.JtNestedPath.Columns)
+			if err != nil {
+				return nil, err
+			}
+			path.Nested = append(path.Nested, nested)
+		}
+	}
+	return path, nil
+}
+
+func (b *jsonTableBuilder) add(col *engine.JSONTableColumn) *engine.JSONTableColumn {
+	col.Offset = len(b.columns)
+	b.columns = append(b.columns, col)
+	return col
+}
+
+func (b *jsonTableBuilder) column(def *sqlparser.JtPathColDef) (*engine.JSONTableColumn, error) {
+	jp, err := jsonTablePath(def.Path)
+	if err != nil {
+		return nil, err
+	}
+	col := &engine.JSONTableColumn{
+		Name: def.Name.String(),
+		Kind: engine.JSONTableValue,
+		Type: def.Type.SQLType(),
+		Path: jp,
+	}
+	if def.JtColExists {
+		col.Kind = engine.JSONTableExists
+		return col, nil
+	}
+
+	castType, err := jsonTableCastType(def.Type)
+	if err != nil {
+		return nil, err
+	}
+	if castType != nil {
+		col.Cast, err = evalengine.Translate(&sqlparser.CastExpr{
+			Expr: &sqlparser.JSONUnquoteExpr{JSONValue: sqlparser.NewOffset(0, nil)},
+			Type: castType,
+		}, b.cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if col.OnEmpty, err = jsonTableResponse(col.Name, def.EmptyOnResponse); err != nil {
+		return nil, err
+	}
+	if col.OnError, err = jsonTableResponse(col.Name, def.ErrorOnResponse); err != nil {
+		return nil, err
+	}
+	return col, nil
+}
+
+func jsonTablePath(expr sqlparser.Expr) (*json.Path, error) {
+	lit, ok := expr.(*sqlparser.Literal)
+	if !ok || lit.Type != sqlparser.StrVal {
+		return nil, vterrors.VT12001(fmt.Sprintf("non-constant path in json_table: %s", sqlparser.String(expr)))
+	}
+	var p json.PathParser
+	jp, err := p.ParseBytes(lit.Bytes())
+	if err != nil {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "Invalid JSON path expression in json_table: %v", err)
+	}
+	return jp, nil
+}
+
+func jsonTableResponse(column string, resp *sqlparser.JtOnResponse) (engine.JSONTableResponse, error) {
+	if resp == nil {
+		return engine.JSONTableResponse{}, nil
+	}
+	switch resp.ResponseType {
+	case sqlparser.ErrorJSONType:
+		return engine.JSONTableResponse{Error: true}, nil
+	case sqlparser.DefaultJSONType:
+		lit, ok := resp.Expr.(*sqlparser.Literal)
+		if !ok || lit.Type != sqlparser.StrVal {
+			return engine.JSONTableResponse{}, vterrors.VT12001(fmt.Sprintf("non-constant default value for json_table column '%s'", column))
+		}
+		var p json.Parser
+		value, err := p.ParseBytes(lit.Bytes())
+		if err != nil {
+			return engine.JSONTableResponse{}, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "Invalid default value for JSON_TABLE column '%s'", column)
+		}
+		return engine.JSONTableResponse{Default: value}, nil
+	default:
+		return engine.JSONTableResponse{}, nil
+	}
+}
+
+// jsonTableCastType returns the type used to convert the values of a JSON_TABLE column,
+// or nil for JSON columns, which return the values as they are found in the document.
+func jsonTableCastType(ct *sqlparser.ColumnType) (*sqlparser.ConvertType, error) {
+	typ := ct.SQLType()
+	switch {
+	case typ == sqltypes.TypeJSON:
+		return nil, nil
+	case sqltypes.IsSigned(typ):
+		return &sqlparser.ConvertType{Type: "SIGNED"}, nil
+	case sqltypes.IsUnsigned(typ):
+		return &sqlparser.ConvertType{Type: "UNSIGNED"}, nil
+	case sqltypes.IsDecimal(typ):
+		return &sqlparser.ConvertType{Type: "DECIMAL", Length: ct.Length, Scale: ct.Scale}, nil
+	case sqltypes.IsFloat(typ):
+		return &sqlparser.ConvertType{Type: "DOUBLE"}, nil
+	case sqltypes.IsText(typ):
+		return &sqlparser.ConvertType{Type: "CHAR", Charset: ct.Charset}, nil
+	case sqltypes.IsBinary(typ):
+		return &sqlparser.ConvertType{Type: "BINARY"}, nil
+	case typ == sqltypes.Date:
+		return &sqlparser.ConvertType{Type: "DATE"}, nil
+	case typ == sqltypes.Datetime, typ == sqltypes.Timestamp:
+		return &sqlparser.ConvertType{Type: "DATETIME", Length: ct.Length}, nil
+	case typ == sqltypes.Time:
+		return &sqlparser.ConvertType{Type: "TIME", Length: ct.Length}, nil
+	default:
+		return nil, vterrors.VT12001(fmt.Sprintf("column type %s in json_table", ct.Type))
+	}
+}
@@ -47,6 +47,8 @@ func transformToLogicalPlan(ctx *plancontext.PlanningContext, op operators.Opera
 		return transformUnionPlan(ctx, op)
 	case *operators.Vindex:
 		return transformVindexPlan(ctx, op)
+	case *operators.JSONTable:
+		return transformJSONTable(ctx, op)
 	case *operators.SubQuery:
 		return transformSubQuery(ctx, op)
 	case *operators.Filter:
@@ -77,6 +79,10 @@ func transformToLogicalPlan(ctx *plancontext.PlanningContext, op operators.Opera
 		return transformSequential(ctx, op)
 	case *operators.DMLWithInput:
 		return transformDMLWithInput(ctx, op)
+	case *operators.Window:
+		return transformWindow(ctx, op)
+	case *operators.RecurseCTE:
+		return transformRecurseCTE(ctx, op)
 	}
 
 	return nil, vterrors.VT13001(fmt.Sprintf("unknown type encountered: %T (transformToLogicalPlan)", op))
@@ -241,6 +247,39 @@ func transformSubQuery(ctx *plancontext.PlanningContext, op *operators.SubQuery)
 		return newUncorrelatedSubquery(op.FilterType, op.SubqueryValueName, op.HasValuesName, inner, outer), nil
 	}
 
+	if op.CorrelatedPredicate != nil {
+		return &correlatedSubquery{
+			subquery: inner,
+			outer:    outer,
+			eSubquery: &engine.CorrelatedSubquery{
+				Opcode:         op.FilterType,
+				SubqueryResult: op.SubqueryValueName,
+				HasValues:      op.HasValuesName,
+				Vars:           op.Vars,
+				Predicate:      op.CorrelatedPredicateWithOffsets,
+				ASTPredicate:   op.CorrelatedPredicate,
+			},
+		}, nil
+	}
+
+	if op.CorrelatedProjection {
+		columns := op.GetColumns(ctx)
+		return &correlatedSubquery{
+			subquery: inner,
+			outer:    outer,
+			eSubquery: &engine.CorrelatedSubquery{
+				Opcode:         op.FilterType,
+				SubqueryResult: op.SubqueryValueName,
+				HasValues:      op.HasValuesName,
+				Vars:           op.Vars,
+				Cols:           op.Columns,
+				Exprs:          op.ProjExprsOffsets,
+				ASTExprs:       op.ProjExprs,
+				ColNames:       slice.Map(columns, func(from *sqlparser.AliasedExpr) string { return from.ColumnName() }),
+			},
+		}, nil
+	}
+
 	lhsCols := op.OuterExpressionsNeeded(ctx, op.Outer)
 	return newSemiJoin(outer, inner, op.Vars, lhsCols), nil
 }
@@ -293,6 +332,9 @@ func transformAggregator(ctx *plancontext.PlanningContext, op *operators.Aggrega
 		aggrParam.OrigOpcode = aggr.OriginalOpCode
 		aggrParam.WCol = aggr.WSOffset
 		aggrParam.Type = aggr.GetTypeCollation(ctx)
+		if err := setAggregationArguments(ctx, aggrParam, aggr); err != nil {
+			return nil, err
+		}
 		oa.aggregates = append(oa.aggregates, aggrParam)
 	}
 	for _, groupBy := range op.Grouping {
@@ -310,6 +352,54 @@ func transformAggregator(ctx *plancontext.PlanningContext, op *operators.Aggrega
 	return oa, nil
 }
 
+// setAggregationArguments sets the parameters of aggregations that are evaluated using all their arguments,
+// and the separator of GROUP_CONCAT
+func setAggregationArguments(ctx *plancontext.PlanningContext, aggrParam *engine.AggregateParams, aggr operators.Aggr) error {
+	if aggr.Func == nil {
+		return nil
+	}
+	collationEnv := ctx.VSchema.Environment().CollationEnv()
+	args := aggr.Func.GetArgs()
+	for idx, offset := range aggr.ArgOffsets {
+		typ, _ := ctx.SemTable.TypeForExpr(args[idx])
+		aggrParam.Args = append(aggrParam.Args, engine.CheckCol{
+			Col:          offset,
+			Type:         typ,
+			CollationEnv: collationEnv,
+		})
+	}
+
+	gc, ok := aggr.Func.(*sqlparser.GroupConcatExpr)
+	if !ok {
+		return nil
+	}
+	if gc.Limit != nil {
+		return vterrors.VT12001(fmt.Sprintf("LIMIT in a GROUP_CONCAT that is evaluated at the vtgate level: %s", sqlparser.String(gc)))
+	}
+	if gc.Separator != "" {
+		separator, err := sqltypes.DecodeStringSQL(gc.Separator)
+		if err != nil {
+			return err
+		}
+		aggrParam.Separator = &separator
+	}
+	if aggr.ArgOffsets == nil {
+		return nil
+	}
+	aggrParam.Distinct = gc.Distinct
+	for idx, order := range gc.OrderBy {
+		typ, _ := ctx.SemTable.TypeForExpr(order.Expr)
+		aggrParam.OrderBy = append(aggrParam.OrderBy, evalengine.OrderByParams{
+			Col:             aggr.OrderOffsets[idx],
+			WeightStringCol: aggr.OrderWSOffsets[idx],
+			Desc:            order.Direction == sqlparser.DescOrder,
+			Type:            typ,
+			CollationEnv:    collationEnv,
+		})
+	}
+	return nil
+}
+
 func transformDistinct(ctx *plancontext.PlanningContext, op *operators.Distinct) (logicalPlan, error) {
 	src, err := transformToLogicalPlan(ctx, op.Source)
 	if err != nil {
@@ -350,6 +440,69 @@ func createMemorySort(ctx *plancontext.PlanningContext, src logicalPlan, orderin
 	return ms, nil
 }
 
+func transformWindow(ctx *plancontext.PlanningContext, op *operators.Window) (logicalPlan, error) {
+	src, err := transformToLogicalPlan(ctx, op.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	prim := &engine.Window{}
+	for idx, expr := range op.Spec.PartitionClause {
+		typ, _ := ctx.SemTable.TypeForExpr(expr)
+		prim.PartitionBy = append(prim.PartitionBy, evalengine.OrderByParams{
+			Col:             op.PartitionOffsets[idx],
+			WeightStringCol: op.PartitionWSOffsets[idx],
+			Type:            typ,
+			CollationEnv:    ctx.VSchema.Environment().CollationEnv(),
+		})
+	}
+	for idx, order := range op.Spec.OrderClause {
+		typ, _ := ctx.SemTable.TypeForExpr(order.Expr)
+		prim.OrderBy = append(prim.OrderBy, evalengine.OrderByParams{
+			Col:             op.OrderOffsets[idx],
+			WeightStringCol: op.OrderWSOffsets[idx],
+			Desc:            order.Direction == sqlparser.DescOrder,
+			Type:            typ,
+			CollationEnv:    ctx.VSchema.Environment().CollationEnv(),
+		})
+	}
+
+	cfg := &evalengine.Config{
+		Collation:   ctx.SemTable.Collation,
+		Environment: ctx.VSchema.Environment(),
+	}
+	for _, f := range op.Funcs {
+		wf := &engine.WindowFunc{
+			Opcode:       f.OpCode,
+			Aggregate:    f.Aggregate,
+			Col:          f.ColOffset,
+			Expr:         f.Original,
+			CollationEnv: ctx.VSchema.Environment().CollationEnv(),
+		}
+		if f.Arg != nil {
+			wf.Type, _ = ctx.SemTable.TypeForExpr(f.Arg)
+		}
+		if f.N != nil {
+			wf.N, err = evalengine.Translate(f.N, cfg)
+			if err != nil {
+				return nil, vterrors.Wrap(err, "unexpected expression in window function")
+			}
+		}
+		if f.Default != nil {
+			wf.Default, err = evalengine.Translate(f.Default, cfg)
+			if err != nil {
+				return nil, vterrors.Wrap(err, "unexpected expression in window function")
+			}
+		}
+		prim.Functions = append(prim.Functions, wf)
+	}
+
+	return &window{
+		logicalPlanCommon: newBuilderCommon(src),
+		eWindow:           prim,
+	}, nil
+}
+
 func transformProjection(ctx *plancontext.PlanningContext, op *operators.Projection) (logicalPlan, error) {
 	src, err := transformToLogicalPlan(ctx, op.Source)
 	if err != nil {
@@ -711,10 +864,18 @@ func buildUpdateLogicalPlan(
 	_ = updateSelectedVindexPredicate(rb.Routing)
 	edml := createDMLPrimitive(ctx, rb, hints, upd.Target.VTable, generateQuery(stmt), vindexes, vQuery)
 
-	return &primitiveWrapper{prim: &engine.Update{
+	eupd := &engine.Update{
 		DML:                 edml,
 		ChangedVindexValues: upd.ChangedVindexValues,
-	}}, nil
+	}
+	if upd.MoveSelect != nil {
+		eupd.MoveSelect = sqlparser.String(upd.MoveSelect)
+		eupd.MoveDelete = sqlparser.String(upd.MoveDelete)
+		for _, col := range upd.MoveColumns {
+			eupd.MoveColumns = append(eupd.MoveColumns, col.String())
+		}
+	}
+	return &primitiveWrapper{prim: eupd}, nil
 }
 
 func buildDeleteLogicalPlan(ctx *plancontext.PlanningContext, rb *operators.Route, dmlOp operators.Operator, stmt *sqlparser.Delete, hints *queryHints) (logicalPlan, error) {
@@ -837,6 +998,22 @@ func transformUnionPlan(ctx *plancontext.PlanningContext, op *operators.Union) (
 
 }
 
+func transformRecurseCTE(ctx *plancontext.PlanningContext, op *operators.RecurseCTE) (logicalPlan, error) {
+	seed, err := transformToLogicalPlan(ctx, op.Seed)
+	if err != nil {
+		return nil, err
+	}
+	term, err := transformToLogicalPlan(ctx, op.Term)
+	if err != nil {
+		return nil, err
+	}
+	return &recurseCTE{
+		Seed: seed,
+		Term: term,
+		Vars: op.Def.Vars,
+	}, nil
+}
+
 func transformLimit(ctx *plancontext.PlanningContext, op *operators.Limit) (logicalPlan, error) {
 	plan, err := transformToLogicalPlan(ctx, op.Source)
 	if err != nil {
@@ -277,7 +277,9 @@ func buildJoin(stmt FromStatement, otherStmt FromStatement, onCondition sqlparse
 func (qb *queryBuilder) sortTables() {
 	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (kontinue bool, err error) {
 		sel, isSel := node.(*sqlparser.Select)
-		if !isSel {
+		if !isSel || hasLateralDerivedTable(sel) {
+			// lateral derived tables can only use the tables that come before them,
+			// so we have to keep the tables in the order they were added
 			return true, nil
 		}
 		ts := &tableSorter{
@@ -290,6 +292,17 @@ func (qb *queryBuilder) sortTables() {
 
 }
 
+func hasLateralDerivedTable(sel *sqlparser.Select) bool {
+	return slices.ContainsFunc(sel.From, func(expr sqlparser.TableExpr) bool {
+		ate, ok := expr.(*sqlparser.AliasedTableExpr)
+		if !ok {
+			return false
+		}
+		dt, ok := ate.Expr.(*sqlparser.DerivedTable)
+		return ok && dt.Lateral
+	})
+}
+
 type tableSorter struct {
 	sel *sqlparser.Select
 	tbl *semantics.SemTable
@@ -385,6 +398,8 @@ func buildQuery(op Operator, qb *queryBuilder) {
 		buildAggregation(op, qb)
 	case *Union:
 		buildUnion(op, qb)
+	case *RecurseCTE:
+		buildRecurseCTE(op, qb)
 	case *Distinct:
 		buildQuery(op.Source, qb)
 		qb.asSelectStatement().MakeDistinct()
@@ -533,6 +548,11 @@ func buildApplyJoin(op *ApplyJoin, qb *queryBuilder) {
 
 	qbR := &queryBuilder{ctx: qb.ctx}
 	buildQuery(op.RHS, qbR)
+	if vars, _ := lateralDependencies(qb.ctx, op.LHS, op.RHS); len(vars) > 0 {
+		// the lateral derived tables on the RHS are sent together with the LHS,
+		// so they can use the columns directly instead of the arguments
+		restoreLateralColumns(qbR.stmt, vars)
+	}
 	qb.joinWith(qbR, pred, op.JoinType)
 }
 
@@ -552,6 +572,71 @@ func buildUnion(op *Union, qb *queryBuilder) {
 	}
 }
 
+// buildRecurseCTE builds a recursive common table expression that is sent to a single shard.
+// The columns of the previous iteration that the recursive part reads through arguments
+// are read from the common table expression again.
+func buildRecurseCTE(op *RecurseCTE, qb *queryBuilder) {
+	buildQuery(op.Seed, qb)
+	qbR := &queryBuilder{ctx: qb.ctx}
+	buildQuery(op.Term, qbR)
+	term, ok := qbR.stmt.(*sqlparser.Select)
+	if !ok {
+		panic(vterrors.VT13001(fmt.Sprintf("unexpected recursive part of a common table expression: %T", qbR.stmt)))
+	}
+
+	name := sqlparser.NewIdentifierCS(op.Def.Name)
+	alias := sqlparser.TableName{Name: sqlparser.NewIdentifierCS(op.Def.Alias)}
+	_ = sqlparser.Rewrite(term, func(cursor *sqlparser.Cursor) bool {
+		arg, ok := cursor.Node().(*sqlparser.Argument)
+		if !ok {
+			return true
+		}
+		if idx, found := op.Def.Vars[arg.Name]; found {
+			cursor.Replace(sqlparser.NewColNameWithQualifier(op.Def.Columns[idx].String(), alias))
+		}
+		return true
+	}, nil)
+
+	cteTable := &sqlparser.AliasedTableExpr{Expr: sqlparser.TableName{Name: name}}
+	if op.Def.Alias != op.Def.Name {
+		cteTable.As = alias.Name
+	}
+	if isDualTable(term.From) {
+		// the early rewriter used dual when the CTE was the only table of the recursive part
+		term.From = nil
+	}
+	term.From = append(term.From, cteTable)
+
+	columns := make(sqlparser.SelectExprs, 0, len(op.Def.Columns))
+	for _, col := range op.Def.Columns {
+		columns = append(columns, aeWrap(sqlparser.NewColName(col.String())))
+	}
+	qb.stmt = &sqlparser.Select{
+		With: &sqlparser.With{
+			Recursive: true,
+			CTEs: []*sqlparser.CommonTableExpr{{
+				ID:       name,
+				Columns:  op.Def.Columns,
+				Subquery: &sqlparser.Subquery{Select: &sqlparser.Union{Left: qb.asSelectStatement(), Right: term}},
+			}},
+		},
+		SelectExprs: columns,
+		From:        sqlparser.TableExprs{&sqlparser.AliasedTableExpr{Expr: sqlparser.TableName{Name: name}}},
+	}
+}
+
+func isDualTable(from sqlparser.TableExprs) bool {
+	if len(from) != 1 {
+		return false
+	}
+	ate, ok := from[0].(*sqlparser.AliasedTableExpr)
+	if !ok {
+		return false
+	}
+	tbl, ok := ate.Expr.(sqlparser.TableName)
+	return ok && tbl.Name.String() == "dual" && ate.As.IsEmpty()
+}
+
 func buildFilter(op *Filter, qb *queryBuilder) {
 	buildQuery(op.Source, qb)
 
@@ -569,6 +654,10 @@ func buildDerived(op *Horizon, qb *queryBuilder) {
 	qb.stmt = nil
 	switch sel := stmt.(type) {
 	case *sqlparser.Select:
+		if _, isRecursive := op.Source.(*RecurseCTE); isRecursive {
+			buildDerivedRecurseCTE(op, qb, sel)
+			return
+		}
 		buildDerivedSelect(op, qb, sel)
 		return
 	case *sqlparser.Union:
@@ -593,6 +682,14 @@ func buildDerivedUnion(op *Horizon, qb *queryBuilder, union *sqlparser.Union) {
 	}, nil, op.ColumnAliases)
 }
 
+// buildDerivedRecurseCTE adds the recursive common table expression as a derived table.
+// The derived table has its own WITH RECURSIVE clause, since the CTE is only used in this derived table.
+func buildDerivedRecurseCTE(op *Horizon, qb *queryBuilder, sel *sqlparser.Select) {
+	qb.addTableExpr(op.Alias, op.Alias, TableID(op), &sqlparser.DerivedTable{
+		Select: sel,
+	}, nil, op.ColumnAliases)
+}
+
 func buildDerivedSelect(op *Horizon, qb *queryBuilder, sel *sqlparser.Select) {
 	opQuery, ok := op.Query.(*sqlparser.Select)
 	if !ok {
@@ -27,13 +27,6 @@ import (
 	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
 )
 
-func errDistinctAggrWithMultiExpr(f sqlparser.AggrFunc) {
-	if f == nil {
-		panic(vterrors.VT12001("distinct aggregation function with multiple expressions"))
-	}
-	panic(vterrors.VT12001(fmt.Sprintf("distinct aggregation function with multiple expressions '%s'", sqlparser.String(f))))
-}
-
 func tryPushAggregator(ctx *plancontext.PlanningContext, aggregator *Aggregator) (output Operator, applyResult *ApplyResult) {
 	if aggregator.Pushed {
 		return aggregator, NoRewrite
@@ -55,6 +48,12 @@ func tryPushAggregator(ctx *plancontext.PlanningContext, aggregator *Aggregator)
 		return splitAvgAggregations(ctx, aggregator)
 	}
 
+	// a GROUP_CONCAT with ORDER BY or LIMIT needs to see all the rows of a group,
+	// so it can't be split into aggregations that are pushed down
+	if needsAllRows(aggregator.Aggregations) {
+		return aggregator, NoRewrite
+	}
+
 	switch src := aggregator.Source.(type) {
 	case *Route:
 		// if we have a single sharded route, we can push it down
@@ -169,7 +168,7 @@ func pushAggregationThroughRoute(
 
 // pushAggregations splits aggregations between the original aggregator and the one we are pushing down
 func pushAggregations(ctx *plancontext.PlanningContext, aggregator *Aggregator, aggrBelowRoute *Aggregator) {
-	canPushDistinctAggr, distinctExprs := checkIfWeCanPush(ctx, aggregator)
+	canPushDistinctAggr, distinctExpr := checkIfWeCanPush(ctx, aggregator)
 
 	distinctAggrGroupByAdded := false
 
@@ -180,20 +179,23 @@ func pushAggregations(ctx *plancontext.PlanningContext, aggregator *Aggregator,
 			continue
 		}
 
-		if len(distinctExprs) != 1 {
-			errDistinctAggrWithMultiExpr(aggr.Func)
+		if distinctExpr == nil {
+			// The duplicates will be removed at the vtgate level by hashing the arguments,
+			// so we only need the distinct values of all the arguments from the shards
+			aggregator.Aggregations[i].ArgOffsets = pushDistinctArguments(ctx, aggregator, aggrBelowRoute, aggr)
+			continue
 		}
 
 		// We handle a distinct aggregation by turning it into a group by and
 		// doing the aggregating on the vtgate level instead
-		aeDistinctExpr := aeWrap(distinctExprs[0])
+		aeDistinctExpr := aeWrap(distinctExpr)
 		aggrBelowRoute.Columns[aggr.ColOffset] = aeDistinctExpr
 
 		// We handle a distinct aggregation by turning it into a group by and
 		// doing the aggregating on the vtgate level instead
 		// Adding to group by can be done only once even though there are multiple distinct aggregation with same expression.
 		if !distinctAggrGroupByAdded {
-			groupBy := NewGroupBy(distinctExprs[0])
+			groupBy := NewGroupBy(distinctExpr)
 			groupBy.ColOffset = aggr.ColOffset
 			aggrBelowRoute.Grouping = append(aggrBelowRoute.Grouping, groupBy)
 			distinctAggrGroupByAdded = true
@@ -201,14 +203,48 @@ func pushAggregations(ctx *plancontext.PlanningContext, aggregator *Aggregator,
 	}
 
 	if !canPushDistinctAggr {
-		aggregator.DistinctExpr = distinctExprs[0]
+		aggregator.DistinctExpr = distinctExpr
 	}
 }
 
-func checkIfWeCanPush(ctx *plancontext.PlanningContext, aggregator *Aggregator) (bool, sqlparser.Exprs) {
+// pushDistinctArguments adds the arguments of a distinct aggregation as grouping columns to the aggregator below the route.
+// The first argument replaces the aggregation column, and the others are added as new columns to both aggregators.
+// It returns the offsets of all the arguments.
+func pushDistinctArguments(ctx *plancontext.PlanningContext, aggregator, aggrBelowRoute *Aggregator, aggr Aggr) []int {
+	args := aggr.Func.GetArgs()
+	aggrBelowRoute.Columns[aggr.ColOffset] = aeWrap(args[0])
+	offsets := []int{aggr.ColOffset}
+	for _, arg := range args[1:] {
+		offset, found := canReuseColumn(ctx, aggrBelowRoute.Columns, arg, extractExpr)
+		if !found {
+			offset = len(aggrBelowRoute.Columns)
+			aggrBelowRoute.Columns = append(aggrBelowRoute.Columns, aeWrap(arg))
+			aggregator.Columns = append(aggregator.Columns, aeWrap(arg))
+		}
+		offsets = append(offsets, offset)
+	}
+
+	for idx, arg := range args {
+		grouped := slices.ContainsFunc(aggrBelowRoute.Grouping, func(gb GroupBy) bool {
+			return ctx.SemTable.EqualsExprWithDeps(gb.Inner, arg)
+		})
+		if grouped {
+			continue
+		}
+		groupBy := NewGroupBy(arg)
+		groupBy.ColOffset = offsets[idx]
+		aggrBelowRoute.Grouping = append(aggrBelowRoute.Grouping, groupBy)
+	}
+	return offsets
+}
+
+// checkIfWeCanPush returns true if all the distinct aggregations can be pushed down, because their arguments have unique vindexes.
+// If they can't, and all of them use the same single expression, it is returned so the input of the aggregator
+// can be ordered by it. Otherwise, a nil expression is returned, and the duplicates are removed by hashing the arguments.
+func checkIfWeCanPush(ctx *plancontext.PlanningContext, aggregator *Aggregator) (bool, sqlparser.Expr) {
 	canPush := true
 	var distinctExprs sqlparser.Exprs
-	var differentExpr *sqlparser.AliasedExpr
+	hashed := false
 
 	for _, aggr := range aggregator.Aggregations {
 		if !aggr.Distinct {
@@ -226,22 +262,22 @@ func checkIfWeCanPush(ctx *plancontext.PlanningContext, aggregator *Aggregator)
 		if !hasUniqVindex {
 			canPush = false
 		}
+		if aggr.OpCode == opcode.AggregateGroupConcat || len(args) != 1 {
+			hashed = true
+		}
 		if len(distinctExprs) == 0 {
 			distinctExprs = args
+			continue
 		}
-		for idx, expr := range distinctExprs {
-			if !ctx.SemTable.EqualsExpr(expr, args[idx]) {
-				differentExpr = aggr.Original
-				break
-			}
+		if !ctx.SemTable.EqualsExpr(distinctExprs[0], args[0]) {
+			hashed = true
 		}
 	}
 
-	if !canPush && differentExpr != nil {
-		panic(vterrors.VT12001(fmt.Sprintf("only one DISTINCT aggregation is allowed in a SELECT: %s", sqlparser.String(differentExpr))))
+	if canPush || hashed {
+		return canPush, nil
 	}
-
-	return canPush, distinctExprs
+	return canPush, distinctExprs[0]
 }
 
 func pushAggregationThroughFilter(
@@ -546,15 +582,13 @@ func splitAggrColumnsToLeftAndRight(
 		outerJoin:   leftJoin,
 	}
 
-	canPushDistinctAggr, distinctExprs := checkIfWeCanPush(ctx, aggregator)
+	canPushDistinctAggr, distinctExpr := checkIfWeCanPush(ctx, aggregator)
 
 	// Distinct aggregation cannot be pushed down in the join.
 	// We keep node of the distinct aggregation expression to be used later for ordering.
+	// If there is none, the duplicates will be removed by hashing the arguments.
 	if !canPushDistinctAggr {
-		if len(distinctExprs) != 1 {
-			errDistinctAggrWithMultiExpr(nil)
-		}
-		aggregator.DistinctExpr = distinctExprs[0]
+		aggregator.DistinctExpr = distinctExpr
 		return nil, errAbortAggrPushing
 	}
 
@@ -597,6 +631,17 @@ func initColReUse(size int) []int {
 
 func extractExpr(expr *sqlparser.AliasedExpr) sqlparser.Expr { return expr.Expr }
 
+// needsAllRows returns true if any of the aggregations is a GROUP_CONCAT with ORDER BY or LIMIT
+func needsAllRows(aggrs []Aggr) bool {
+	for _, aggr := range aggrs {
+		gc, ok := aggr.Func.(*sqlparser.GroupConcatExpr)
+		if ok && (len(gc.OrderBy) > 0 || gc.Limit != nil) {
+			return true
+		}
+	}
+	return false
+}
+
 func needAvgBreaking(aggrs []Aggr) bool {
 	for _, aggr := range aggrs {
 		if aggr.OpCode == opcode.AggregateAvg {
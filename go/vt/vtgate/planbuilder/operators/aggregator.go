@@ -358,7 +358,7 @@ func (a *Aggregator) planOffsets(ctx *plancontext.PlanningContext) Operator {
 	}
 
 	for idx, aggr := range a.Aggregations {
-		if !aggr.NeedsWeightString(ctx) {
+		if aggr.ArgOffsets != nil || !aggr.NeedsWeightString(ctx) {
 			continue
 		}
 		arg := aggr.getPushColumn()
@@ -368,6 +368,8 @@ func (a *Aggregator) planOffsets(ctx *plancontext.PlanningContext) Operator {
 	return nil
 }
 
+// getPushColumn returns the column the aggregation needs from its input.
+// The other arguments of aggregations that are evaluated using all their arguments are added by pushArguments.
 func (aggr Aggr) getPushColumn() sqlparser.Expr {
 	switch aggr.OpCode {
 	case opcode.AggregateAnyValue:
@@ -375,18 +377,58 @@ func (aggr Aggr) getPushColumn() sqlparser.Expr {
 	case opcode.AggregateCountStar:
 		return sqlparser.NewIntLiteral("1")
 	case opcode.AggregateGroupConcat:
-		if len(aggr.Func.GetArgs()) > 1 {
-			panic(vterrors.VT12001("group_concat with more than 1 column"))
-		}
 		return aggr.Func.GetArg()
 	default:
-		if len(aggr.Func.GetArgs()) > 1 {
+		if len(aggr.Func.GetArgs()) > 1 && !aggr.Distinct {
 			panic(vterrors.VT03001(sqlparser.String(aggr.Func)))
 		}
 		return aggr.Func.GetArg()
 	}
 }
 
+// needsArguments returns true if the aggregation has to be evaluated using all its arguments,
+// when the aggregator gets the rows of its input instead of aggregations that have been pushed down.
+func (a *Aggregator) needsArguments(aggr Aggr) bool {
+	switch {
+	case aggr.OpCode == opcode.AggregateGroupConcat:
+		gc := aggr.Func.(*sqlparser.GroupConcatExpr)
+		return len(gc.Exprs) > 1 || gc.Distinct || len(gc.OrderBy) > 0
+	case aggr.OpCode.IsDistinct():
+		// without a distinct expression to order the input by, the duplicates are removed by hashing the arguments
+		return a.DistinctExpr == nil
+	default:
+		return false
+	}
+}
+
+// pushArguments adds the columns needed by the aggregations that are evaluated using all their arguments
+func (a *Aggregator) pushArguments(ctx *plancontext.PlanningContext) {
+	for idx, aggr := range a.Aggregations {
+		if !a.needsArguments(aggr) {
+			continue
+		}
+		args := aggr.Func.GetArgs()
+		offsets := []int{aggr.ColOffset}
+		for _, arg := range args[1:] {
+			offsets = append(offsets, a.internalAddColumn(ctx, aeWrap(arg), false))
+		}
+		a.Aggregations[idx].ArgOffsets = offsets
+
+		gc, ok := aggr.Func.(*sqlparser.GroupConcatExpr)
+		if !ok {
+			continue
+		}
+		for _, order := range gc.OrderBy {
+			a.Aggregations[idx].OrderOffsets = append(a.Aggregations[idx].OrderOffsets, a.internalAddColumn(ctx, aeWrap(order.Expr), false))
+			wsOffset := -1
+			if ctx.SemTable.NeedsWeightString(order.Expr) {
+				wsOffset = a.internalAddColumn(ctx, aeWrap(weightStringFor(order.Expr)), false)
+			}
+			a.Aggregations[idx].OrderWSOffsets = append(a.Aggregations[idx].OrderWSOffsets, wsOffset)
+		}
+	}
+}
+
 func (a *Aggregator) planOffsetsNotPushed(ctx *plancontext.PlanningContext) {
 	a.Source = newAliasedProjection(a.Source)
 	// we need to keep things in the column order, so we can't iterate over the aggregations or groupings
@@ -403,6 +445,7 @@ func (a *Aggregator) planOffsetsNotPushed(ctx *plancontext.PlanningContext) {
 		}
 	}
 
+	a.pushArguments(ctx)
 	a.pushRemainingGroupingColumnsAndWeightStrings(ctx)
 }
 
@@ -460,7 +503,7 @@ func (a *Aggregator) pushRemainingGroupingColumnsAndWeightStrings(ctx *planconte
 		a.Grouping[idx].WSOffset = offset
 	}
 	for idx, aggr := range a.Aggregations {
-		if aggr.WSOffset != -1 || !aggr.NeedsWeightString(ctx) {
+		if aggr.WSOffset != -1 || aggr.ArgOffsets != nil || !aggr.NeedsWeightString(ctx) {
 			continue
 		}
 
@@ -18,6 +18,7 @@ package operators
 
 import (
 	"fmt"
+	"slices"
 
 	"vitess.io/vitess/go/vt/sqlparser"
 	"vitess.io/vitess/go/vt/vterrors"
@@ -150,6 +151,10 @@ func createOperatorFromUnion(ctx *plancontext.PlanningContext, node *sqlparser.U
 	}
 	opLHS := translateQueryToOp(ctx, node.Left)
 	opRHS := translateQueryToOp(ctx, node.Right)
+	if cte, ok := ctx.SemTable.RecursiveCTEs[node]; ok {
+		recurse := newRecurseCTE(opLHS, opRHS, cte, slices.Clone(ctx.SemTable.SelectExprs(node)))
+		return newHorizon(recurse, node)
+	}
 	lexprs := ctx.SemTable.SelectExprs(node.Left)
 	rexprs := ctx.SemTable.SelectExprs(node.Right)
 
@@ -216,6 +221,8 @@ func getOperatorFromTableExpr(ctx *plancontext.PlanningContext, tableExpr sqlpar
 		return getOperatorFromJoinTableExpr(ctx, tableExpr)
 	case *sqlparser.ParenTableExpr:
 		return crossJoin(ctx, tableExpr.Exprs)
+	case *sqlparser.JSONTableExpr:
+		return newJSONTable(ctx, tableExpr)
 	default:
 		panic(vterrors.VT13001(fmt.Sprintf("unable to use: %T table type", tableExpr)))
 	}
@@ -269,13 +276,24 @@ func getOperatorFromAliasedTableExpr(ctx *plancontext.PlanningContext, tableExpr
 			tbl.Select.SetOrderBy(nil)
 		}
 
-		inner := translateQueryToOp(ctx, tbl.Select)
+		stmt := tbl.Select
+		var lateralVars []BindVarExpr
+		var lateralPredicates []sqlparser.Expr
+		if tbl.Lateral {
+			stmt, lateralVars, lateralPredicates = rewriteLateralDerivedTable(ctx, stmt)
+		}
+
+		inner := translateQueryToOp(ctx, stmt)
 		if horizon, ok := inner.(*Horizon); ok {
 			horizon.TableId = &tableID
 			horizon.Alias = tableExpr.As.String()
 			horizon.ColumnAliases = tableExpr.Columns
-			qp := CreateQPFromSelectStatement(ctx, tbl.Select)
+			qp := CreateQPFromSelectStatement(ctx, stmt)
 			horizon.QP = qp
+			horizon.LateralVars = lateralVars
+			horizon.LateralPredicates = lateralPredicates
+		} else if len(lateralVars) > 0 {
+			panic(vterrors.VT12001(fmt.Sprintf("lateral derived table: %s", sqlparser.String(tbl))))
 		}
 
 		return inner
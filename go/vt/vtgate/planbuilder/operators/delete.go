@@ -69,7 +69,7 @@ func createOperatorFromDelete(ctx *plancontext.PlanningContext, deleteStmt *sqlp
 	// We check if delete with input plan is required. DML with input planning is generally
 	// slower, because it does a selection and then creates a delete statement wherein we have to
 	// list all the primary key values.
-	if deleteWithInputPlanningRequired(childFks, deleteStmt) {
+	if deleteWithInputPlanningRequired(ctx, childFks, deleteStmt) {
 		return createDeleteWithInputOp(ctx, deleteStmt)
 	}
 
@@ -97,10 +97,13 @@ func createOperatorFromDelete(ctx *plancontext.PlanningContext, deleteStmt *sqlp
 	return createFkCascadeOpForDelete(ctx, op, delClone, childFks, vTbl)
 }
 
-func deleteWithInputPlanningRequired(childFks []vindexes.ChildFKInfo, deleteStmt *sqlparser.Delete) bool {
+func deleteWithInputPlanningRequired(ctx *plancontext.PlanningContext, childFks []vindexes.ChildFKInfo, deleteStmt *sqlparser.Delete) bool {
 	if len(deleteStmt.Targets) > 1 {
 		return true
 	}
+	if deleteStmt.Limit != nil && limitRequiresDMLWithInput(ctx, deleteStmt.Where, deleteStmt.OrderBy, nil) {
+		return true
+	}
 	// If there are no foreign keys, we don't need to use delete with input.
 	if len(childFks) == 0 {
 		return false
@@ -122,8 +125,9 @@ func createDeleteWithInputOp(ctx *plancontext.PlanningContext, del *sqlparser.De
 	del.OrderBy = nil
 
 	selectStmt := &sqlparser.Select{
-		From:    delClone.TableExprs,
-		Where:   delClone.Where,
+		From: delClone.TableExprs,
+		// the original WHERE clause is used, so subqueries in it can still be found in the semantic table
+		Where:   del.Where,
 		OrderBy: delClone.OrderBy,
 		Limit:   delClone.Limit,
 		Lock:    sqlparser.ForUpdateLock,
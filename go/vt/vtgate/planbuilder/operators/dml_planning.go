@@ -18,10 +18,12 @@ package operators
 
 import (
 	"fmt"
+	"slices"
 	"sort"
 
 	"vitess.io/vitess/go/vt/sqlparser"
 	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
 	"vitess.io/vitess/go/vt/vtgate/semantics"
 	"vitess.io/vitess/go/vt/vtgate/vindexes"
 )
@@ -84,6 +86,45 @@ func shortDesc(target TargetTable, ovq *sqlparser.Select) string {
 	return fmt.Sprintf("%s.%s%s", target.VTable.Keyspace.Name, target.VTable.Name.String(), ovqString)
 }
 
+// limitRequiresDMLWithInput returns true when an UPDATE or DELETE with a LIMIT on a sharded table
+// has to select the primary keys of the rows to change before changing them. The selection uses
+// the ORDER BY and LIMIT of the DML, so it can be merge-sorted and limited across the shards.
+// Subqueries in the WHERE clause have to be evaluated before the LIMIT is applied, and when a vindex
+// column is changed without an ORDER BY, the owned vindex query and the DML could pick different rows.
+func limitRequiresDMLWithInput(ctx *plancontext.PlanningContext, where *sqlparser.Where, orderBy sqlparser.OrderBy, exprs sqlparser.UpdateExprs) bool {
+	ti, err := ctx.SemTable.TableInfoFor(ctx.SemTable.Targets)
+	if err != nil {
+		return false
+	}
+	vTbl := ti.GetVindexTable()
+	if vTbl == nil || !vTbl.Keyspace.Sharded || len(vTbl.PrimaryKey) == 0 {
+		// without a primary key, we have no way of selecting the rows to change
+		return false
+	}
+	if where != nil && containsSubquery(where.Expr) {
+		return true
+	}
+	if len(orderBy) > 0 {
+		return false
+	}
+	for _, ue := range exprs {
+		for _, cv := range vTbl.ColumnVindexes {
+			if slices.ContainsFunc(cv.Columns, ue.Name.Name.Equal) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsSubquery(expr sqlparser.Expr) (found bool) {
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		_, found = node.(*sqlparser.Subquery)
+		return !found, nil
+	}, expr)
+	return
+}
+
 // getVindexInformation returns the vindex and VindexPlusPredicates for the DML,
 // If it cannot find a unique vindex match, it returns an error.
 func getVindexInformation(id semantics.TableSet, table *vindexes.Table) *vindexes.ColumnVindex {
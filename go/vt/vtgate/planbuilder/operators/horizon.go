@@ -49,6 +49,13 @@ type Horizon struct {
 	// Columns needed to feed other plans
 	Columns       []*sqlparser.ColName
 	ColumnsOffset []int
+
+	// LateralVars contains the columns a lateral derived table uses from the tables before it in the FROM clause.
+	// In Query, these columns have been replaced by the arguments they are bound to.
+	LateralVars []BindVarExpr
+	// LateralPredicates are the predicates of a lateral derived table that use columns from the tables before it,
+	// in their original form. They are used to check if the derived table can be merged with these tables.
+	LateralPredicates []sqlparser.Expr
 }
 
 func newHorizon(src Operator, query sqlparser.SelectStatement) *Horizon {
@@ -62,6 +69,8 @@ func (h *Horizon) Clone(inputs []Operator) Operator {
 	klone.ColumnAliases = sqlparser.CloneColumns(h.ColumnAliases)
 	klone.Columns = slices.Clone(h.Columns)
 	klone.ColumnsOffset = slices.Clone(h.ColumnsOffset)
+	klone.LateralVars = slices.Clone(h.LateralVars)
+	klone.LateralPredicates = slices.Clone(h.LateralPredicates)
 	klone.QP = h.QP
 	return &klone
 }
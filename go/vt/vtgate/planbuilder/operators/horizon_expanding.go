@@ -83,6 +83,11 @@ func expandSelectHorizon(ctx *plancontext.PlanningContext, horizon *Horizon, sel
 		extracted = append(extracted, "Projection")
 	}
 
+	if windowFuncs := findWindowFuncs(ctx, qp); len(windowFuncs) > 0 && !canEvaluateWindowsInRoute(ctx, horizon.src(), windowFuncs) {
+		op = expandWindowFuncs(ctx, op, qp, windowFuncs)
+		extracted = append(extracted, "Window")
+	}
+
 	if qp.NeedsDistinct() {
 		op = &Distinct{
 			Required: true,
@@ -113,6 +118,28 @@ func expandSelectHorizon(ctx *plancontext.PlanningContext, horizon *Horizon, sel
 	return op, Rewrote(fmt.Sprintf("expand SELECT horizon into (%s)", strings.Join(extracted, ", ")))
 }
 
+// expandWindowFuncs places the Window operators between the projection and its input,
+// so the projection can read the results of the window functions as offsets from its input
+func expandWindowFuncs(ctx *plancontext.PlanningContext, op Operator, qp *QueryProjection, windowFuncs []sqlparser.Expr) Operator {
+	if qp.NeedsAggregation() {
+		panic(vterrors.VT12001("window functions with aggregation on a sharded keyspace"))
+	}
+	proj, ok := op.(*Projection)
+	if !ok {
+		panic(vterrors.VT13001(fmt.Sprintf("expected a projection, got %T", op)))
+	}
+	if _, err := proj.GetAliasedProjections(); err != nil {
+		panic(vterrors.VT12001("window functions with '*' expressions on a sharded keyspace"))
+	}
+	proj.Source = createWindows(ctx, proj.Source, windowFuncs)
+	return proj
+}
+
+func canEvaluateWindowsInRoute(ctx *plancontext.PlanningContext, src Operator, windowFuncs []sqlparser.Expr) bool {
+	_, isRoute := src.(*Route)
+	return isRoute && canPushWindowsToRoute(ctx, windowFuncs)
+}
+
 func expandOrderBy(ctx *plancontext.PlanningContext, op Operator, qp *QueryProjection) Operator {
 	proj := newAliasedProjection(op)
 	var newOrder []OrderBy
@@ -0,0 +1,129 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operators
+
+import (
+	"fmt"
+	"slices"
+
+	"vitess.io/vitess/go/slice"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
+	"vitess.io/vitess/go/vt/vtgate/semantics"
+)
+
+// JSONTable is used for a JSON_TABLE expression in the FROM clause. The rows of the JSON_TABLE
+// are produced at the vtgate, so it can be joined with tables from any keyspace or shard.
+// When the document uses columns from the tables before it, the JSON_TABLE is evaluated
+// once for every row of these tables, as the RHS of an ApplyJoin.
+type JSONTable struct {
+	TableID semantics.TableSet
+
+	// Expr is the JSON_TABLE expression, where the columns coming from other tables have been replaced by arguments
+	Expr *sqlparser.JSONTableExpr
+
+	// LateralVars contains the columns the document uses from the tables before it in the FROM clause
+	LateralVars []BindVarExpr
+
+	Columns []*sqlparser.ColName
+
+	noInputs
+}
+
+func newJSONTable(ctx *plancontext.PlanningContext, node *sqlparser.JSONTableExpr) *JSONTable {
+	tableID := ctx.SemTable.TableSetForJSONTable(node)
+	expr, vars := rewriteOuterColumns(ctx, node, tableID)
+	return &JSONTable{
+		TableID:     tableID,
+		Expr:        expr.(*sqlparser.JSONTableExpr),
+		LateralVars: vars,
+	}
+}
+
+// introducesTableID implements the tableIDIntroducer interface
+func (jt *JSONTable) introducesTableID() semantics.TableSet {
+	return jt.TableID
+}
+
+// Clone implements the Operator interface
+func (jt *JSONTable) Clone([]Operator) Operator {
+	klone := *jt
+	klone.LateralVars = slices.Clone(jt.LateralVars)
+	klone.Columns = slices.Clone(jt.Columns)
+	return &klone
+}
+
+// AddPredicate implements the Operator interface. The predicates are evaluated at the vtgate.
+func (jt *JSONTable) AddPredicate(_ *plancontext.PlanningContext, expr sqlparser.Expr) Operator {
+	return newFilter(jt, expr)
+}
+
+// AddColumn implements the Operator interface. Only columns of the JSON_TABLE can be added,
+// other expressions have to be evaluated by a projection on top of it.
+func (jt *JSONTable) AddColumn(ctx *plancontext.PlanningContext, reuse bool, _ bool, ae *sqlparser.AliasedExpr) int {
+	if reuse {
+		offset := jt.FindCol(ctx, ae.Expr, true)
+		if offset > -1 {
+			return offset
+		}
+	}
+	if _, ok := ae.Expr.(*sqlparser.ColName); !ok {
+		panic(vterrors.VT12001(fmt.Sprintf("expression '%s' on a json_table column", sqlparser.String(ae.Expr))))
+	}
+
+	return addColumn(ctx, jt, ae.Expr)
+}
+
+func (*JSONTable) AddWSColumn(*plancontext.PlanningContext, int, bool) int {
+	panic(vterrors.VT13001("did not expect this method to be called"))
+}
+
+func (jt *JSONTable) FindCol(ctx *plancontext.PlanningContext, expr sqlparser.Expr, _ bool) int {
+	for idx, col := range jt.Columns {
+		if ctx.SemTable.EqualsExprWithDeps(expr, col) {
+			return idx
+		}
+	}
+	return -1
+}
+
+func (jt *JSONTable) GetColumns(*plancontext.PlanningContext) []*sqlparser.AliasedExpr {
+	return slice.Map(jt.Columns, colNameToExpr)
+}
+
+func (jt *JSONTable) GetSelectExprs(ctx *plancontext.PlanningContext) sqlparser.SelectExprs {
+	return transformColumnsToSelectExprs(ctx, jt)
+}
+
+func (jt *JSONTable) GetOrdering(*plancontext.PlanningContext) []OrderBy {
+	return nil
+}
+
+// GetColNames implements the ColNameColumns interface
+func (jt *JSONTable) GetColNames() []*sqlparser.ColName {
+	return jt.Columns
+}
+
+// AddCol implements the ColNameColumns interface
+func (jt *JSONTable) AddCol(col *sqlparser.ColName) {
+	jt.Columns = append(jt.Columns, col)
+}
+
+func (jt *JSONTable) ShortDescription() string {
+	return fmt.Sprintf("%s AS %s", sqlparser.String(jt.Expr.Expr), jt.Expr.Alias.String())
+}
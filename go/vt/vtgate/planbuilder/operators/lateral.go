@@ -0,0 +1,223 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operators
+
+import (
+	"slices"
+
+	"vitess.io/vitess/go/slice"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
+	"vitess.io/vitess/go/vt/vtgate/semantics"
+)
+
+// rewriteLateralDerivedTable replaces the columns a lateral derived table uses from the tables before it
+// in the FROM clause with arguments. The rewritten query can be sent to the tablets on its own, once
+// for every row of the tables before it, as the RHS of an ApplyJoin.
+// It returns the rewritten query, the columns that have to be bound to the arguments, and the predicates
+// using the outer columns in their original form, which are used to check if the derived table can be
+// merged with the tables it depends on.
+func rewriteLateralDerivedTable(ctx *plancontext.PlanningContext, stmt sqlparser.SelectStatement) (sqlparser.SelectStatement, []BindVarExpr, []sqlparser.Expr) {
+	inner := semantics.EmptyTableSet()
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if ate, ok := node.(*sqlparser.AliasedTableExpr); ok {
+			inner = inner.Merge(ctx.SemTable.TableSetFor(ate))
+		}
+		return true, nil
+	}, stmt)
+
+	result, vars := rewriteOuterColumns(ctx, stmt, inner)
+
+	var predicates []sqlparser.Expr
+	if sel, ok := stmt.(*sqlparser.Select); ok && sel.Where != nil {
+		predicates = slice.Filter(sqlparser.SplitAndExpression(nil, sel.Where.Expr), func(expr sqlparser.Expr) bool {
+			return !ctx.SemTable.RecursiveDeps(expr).IsSolvedBy(inner)
+		})
+	}
+	return result.(sqlparser.SelectStatement), vars, predicates
+}
+
+// rewriteOuterColumns replaces the columns of node that don't come from the inner tables with arguments,
+// and returns the rewritten node together with the columns that have to be bound to the arguments.
+func rewriteOuterColumns(ctx *plancontext.PlanningContext, node sqlparser.SQLNode, inner semantics.TableSet) (sqlparser.SQLNode, []BindVarExpr) {
+	isOuter := func(col *sqlparser.ColName) bool {
+		deps := ctx.SemTable.RecursiveDeps(col)
+		return !deps.IsEmpty() && !deps.IsOverlapping(inner)
+	}
+
+	var vars []BindVarExpr
+	result := sqlparser.CopyOnRewrite(node, nil, func(cursor *sqlparser.CopyOnWriteCursor) {
+		col, ok := cursor.Node().(*sqlparser.ColName)
+		if !ok || !isOuter(col) {
+			return
+		}
+		name := ctx.GetReservedArgumentFor(col)
+		if !slices.ContainsFunc(vars, func(bve BindVarExpr) bool { return bve.Name == name }) {
+			vars = append(vars, BindVarExpr{Name: name, Expr: col})
+		}
+		cursor.Replace(sqlparser.NewArgument(name))
+	}, func(before, after sqlparser.SQLNode) {
+		if _, isExpr := before.(sqlparser.Expr); !isExpr {
+			ctx.SemTable.CopySemanticInfo(before, after)
+		}
+	})
+	return result, vars
+}
+
+// lateralDependencies returns the columns that lateral derived tables and JSON_TABLE expressions on the rhs
+// need from the lhs, together with the predicates that can be used to check if the two sides can be merged
+func lateralDependencies(ctx *plancontext.PlanningContext, lhs, rhs Operator) (vars []BindVarExpr, predicates []sqlparser.Expr) {
+	lhsID := TableID(lhs)
+	_ = Visit(rhs, func(op Operator) error {
+		if jt, ok := op.(*JSONTable); ok {
+			for _, bve := range jt.LateralVars {
+				if ctx.SemTable.RecursiveDeps(bve.Expr).IsSolvedBy(lhsID) &&
+					!slices.ContainsFunc(vars, func(other BindVarExpr) bool { return other.Name == bve.Name }) {
+					vars = append(vars, bve)
+				}
+			}
+			return nil
+		}
+		horizon, ok := op.(*Horizon)
+		if !ok {
+			return nil
+		}
+		found := false
+		for _, bve := range horizon.LateralVars {
+			if !ctx.SemTable.RecursiveDeps(bve.Expr).IsSolvedBy(lhsID) {
+				continue
+			}
+			found = true
+			if !slices.ContainsFunc(vars, func(other BindVarExpr) bool { return other.Name == bve.Name }) {
+				vars = append(vars, bve)
+			}
+		}
+		if found {
+			predicates = append(predicates, horizon.LateralPredicates...)
+		}
+		return nil
+	})
+	return
+}
+
+// mergeOrJoinLateral plans the join between a lhs and a rhs that contains lateral derived tables depending on it.
+// If the two sides can be merged into a single route, the derived table is sent as a lateral derived table to
+// the tablets. Otherwise, we use an ApplyJoin that binds the outer columns for every row of the lhs.
+// The sides of the join can't be switched, since the rhs needs the values coming from the lhs.
+func mergeOrJoinLateral(
+	ctx *plancontext.PlanningContext,
+	lhs, rhs Operator,
+	joinPredicates []sqlparser.Expr,
+	joinType sqlparser.JoinType,
+	vars []BindVarExpr,
+	lateralPredicates []sqlparser.Expr,
+) (Operator, *ApplyResult) {
+	m := &lateralJoinMerger{
+		joinMerger: joinMerger{predicates: joinPredicates, joinType: joinType},
+		vars:       vars,
+	}
+	mergePredicates := append(slices.Clone(joinPredicates), lateralPredicates...)
+	newPlan := mergeJoinInputs(ctx, lhs, rhs, mergePredicates, m)
+	if newPlan != nil {
+		return newPlan, Rewrote("merge lateral derived table into single route")
+	}
+
+	join := NewApplyJoin(ctx, Clone(lhs), Clone(rhs), nil, joinType)
+	for _, bve := range vars {
+		if !join.isColNameMovedFromL2R(bve.Name) {
+			join.ExtraLHSVars = append(join.ExtraLHSVars, bve)
+		}
+	}
+	newOp := pushJoinPredicates(ctx, joinPredicates, join)
+	return newOp, Rewrote("logical join to applyJoin for lateral derived table")
+}
+
+// lateralJoinMerger merges a lhs with a rhs that contains lateral derived tables.
+// The predicates on the rhs that use the arguments bound from the lhs
+// can't be used for routing once the two sides are merged.
+type lateralJoinMerger struct {
+	joinMerger
+	vars []BindVarExpr
+}
+
+var _ merger = (*lateralJoinMerger)(nil)
+
+func (lm *lateralJoinMerger) mergeShardedRouting(ctx *plancontext.PlanningContext, r1, r2 *ShardedRouting, op1, op2 *Route) *Route {
+	return lm.merge(ctx, op1, op2, mergeShardedRouting(r1, r2))
+}
+
+func (lm *lateralJoinMerger) merge(ctx *plancontext.PlanningContext, op1, op2 *Route, r Routing) *Route {
+	return lm.joinMerger.merge(ctx, op1, op2, lm.removeLateralPredicates(ctx, r))
+}
+
+func (lm *lateralJoinMerger) removeLateralPredicates(ctx *plancontext.PlanningContext, r Routing) Routing {
+	tr, ok := r.(*ShardedRouting)
+	if !ok {
+		return r
+	}
+	predicates := slice.Filter(tr.SeenPredicates, func(expr sqlparser.Expr) bool {
+		return !usesLateralVars(expr, lm.vars)
+	})
+	if len(predicates) == len(tr.SeenPredicates) {
+		return r
+	}
+	tr = tr.Clone().(*ShardedRouting)
+	tr.SeenPredicates = predicates
+	return tr.resetRoutingLogic(ctx)
+}
+
+func usesLateralVars(node sqlparser.SQLNode, vars []BindVarExpr) (found bool) {
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		arg, ok := node.(*sqlparser.Argument)
+		if ok && slices.ContainsFunc(vars, func(bve BindVarExpr) bool { return bve.Name == arg.Name }) {
+			found = true
+		}
+		return !found, nil
+	}, node)
+	return
+}
+
+// restoreLateralColumns is used when a lateral derived table has been merged with the tables it depends on.
+// The arguments are replaced with the columns they stand for, and the derived tables using them are marked as LATERAL.
+func restoreLateralColumns(stmt sqlparser.SQLNode, vars []BindVarExpr) {
+	replaced := 0
+	var seen []int
+	_ = sqlparser.Rewrite(stmt, func(cursor *sqlparser.Cursor) bool {
+		switch node := cursor.Node().(type) {
+		case *sqlparser.DerivedTable:
+			seen = append(seen, replaced)
+		case *sqlparser.Argument:
+			idx := slices.IndexFunc(vars, func(bve BindVarExpr) bool { return bve.Name == node.Name })
+			if idx >= 0 {
+				cursor.Replace(vars[idx].Expr)
+				replaced++
+			}
+		}
+		return true
+	}, func(cursor *sqlparser.Cursor) bool {
+		dt, ok := cursor.Node().(*sqlparser.DerivedTable)
+		if !ok {
+			return true
+		}
+		before := seen[len(seen)-1]
+		seen = seen[:len(seen)-1]
+		if replaced > before {
+			dt.Lateral = true
+		}
+		return true
+	})
+}
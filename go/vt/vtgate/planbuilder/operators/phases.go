@@ -284,16 +284,24 @@ func addGroupByOnRHSOfJoin(root Operator) Operator {
 }
 
 func addLiteralGroupingToRHS(in *ApplyJoin) (Operator, *ApplyResult) {
-	_ = Visit(in.RHS, func(op Operator) error {
-		aggr, isAggr := op.(*Aggregator)
-		if !isAggr {
-			return nil
+	var visit func(op Operator)
+	visit = func(op Operator) {
+		if aggr, isAggr := op.(*Aggregator); isAggr {
+			if aggr.Original && aggr.DT != nil {
+				// this is the aggregation of a derived table, like a lateral derived table evaluated
+				// for every row of the LHS. A scalar aggregation has to return one row even when
+				// there is no input, so neither it nor what was pushed below it can be grouped
+				return
+			}
+			if len(aggr.Grouping) == 0 {
+				gb := sqlparser.NewIntLiteral(".0")
+				aggr.Grouping = append(aggr.Grouping, NewGroupBy(gb))
+			}
 		}
-		if len(aggr.Grouping) == 0 {
-			gb := sqlparser.NewIntLiteral(".0")
-			aggr.Grouping = append(aggr.Grouping, NewGroupBy(gb))
+		for _, input := range op.Inputs() {
+			visit(input)
 		}
-		return nil
-	})
+	}
+	visit(in.RHS)
 	return in, NoRewrite
 }
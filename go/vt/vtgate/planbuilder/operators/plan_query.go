@@ -147,6 +147,16 @@ func tryTruncateColumnsAt(op Operator, truncateAt int) bool {
 	case *Limit:
 		return tryTruncateColumnsAt(op.Source, truncateAt)
 	case *SubQuery:
+		if op.CorrelatedProjection {
+			// the subquery maps its columns explicitly, so it can drop the ones that are not needed
+			op.Columns = op.Columns[:truncateAt]
+			op.columns = op.columns[:truncateAt]
+			return true
+		}
+		if op.CorrelatedPredicate != nil {
+			// the predicate evaluated at the vtgate level can use any of the outer columns
+			return false
+		}
 		for _, offset := range op.Vars {
 			if offset >= truncateAt {
 				return false
@@ -579,6 +579,26 @@ func (p *Projection) needsEvaluation(ctx *plancontext.PlanningContext, e sqlpars
 	return false
 }
 
+// correlatedProjectionSource returns the correlated projection subquery producing the columns of op,
+// looking through the operators that pass the columns of their input through unchanged
+func correlatedProjectionSource(op Operator) *SubQuery {
+	for {
+		switch src := op.(type) {
+		case *SubQuery:
+			if src.CorrelatedProjection {
+				return src
+			}
+			return nil
+		case *Ordering:
+			op = src.Source
+		case *Limit:
+			op = src.Source
+		default:
+			return nil
+		}
+	}
+}
+
 func (p *Projection) planOffsets(ctx *plancontext.PlanningContext) Operator {
 	ap, err := p.GetAliasedProjections()
 	if err != nil {
@@ -594,6 +614,14 @@ func (p *Projection) planOffsets(ctx *plancontext.PlanningContext) Operator {
 			continue
 		}
 
+		if sq := correlatedProjectionSource(p.Source); sq != nil && sq.usesSubquery(pe.EvalExpr) {
+			// the expression is evaluated by the correlated subquery below us, under the name of this column
+			offset := sq.AddColumn(ctx, true, false, &sqlparser.AliasedExpr{Expr: pe.EvalExpr, As: pe.Original.As})
+			pe.EvalExpr = sqlparser.NewOffset(offset, pe.EvalExpr)
+			pe.Info = Offset(offset)
+			continue
+		}
+
 		// first step is to replace the expressions we expect to get from our input with the offsets for these
 		rewritten := useOffsets(ctx, pe.EvalExpr, p)
 		pe.EvalExpr = rewritten
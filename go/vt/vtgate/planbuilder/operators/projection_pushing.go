@@ -96,6 +96,11 @@ func tryPushProjection(
 			return p, NoRewrite
 		}
 		return pushProjectionInVindex(ctx, p, src)
+	case *JSONTable:
+		if !p.canPush(ctx) {
+			return p, NoRewrite
+		}
+		return pushProjectionInJSONTable(ctx, p, src)
 	case *SubQueryContainer:
 		if !p.canPush(ctx) {
 			return p, NoRewrite
@@ -126,7 +131,8 @@ func pushProjectionToOuter(ctx *plancontext.PlanningContext, p *Projection, sq *
 		return p, NoRewrite
 	}
 
-	if !reachedPhase(ctx, subquerySettling) {
+	if !reachedPhase(ctx, subquerySettling) || sq.CorrelatedProjection {
+		// the expressions using a correlated projection subquery are evaluated by it, above the outer query
 		return p, NoRewrite
 	}
 
@@ -166,6 +172,23 @@ func pushProjectionInVindex(
 	return src, Rewrote("push projection into vindex")
 }
 
+// pushProjectionInJSONTable removes a projection that only selects columns of the JSON_TABLE below it
+func pushProjectionInJSONTable(ctx *plancontext.PlanningContext, p *Projection, src *JSONTable) (Operator, *ApplyResult) {
+	ap, err := p.GetAliasedProjections()
+	if err != nil {
+		return p, NoRewrite
+	}
+	for _, pe := range ap {
+		if _, isCol := pe.EvalExpr.(*sqlparser.ColName); !isCol || !pe.isSameInAndOut(ctx) {
+			return p, NoRewrite
+		}
+	}
+	for _, pe := range ap {
+		src.AddColumn(ctx, true, false, aeWrap(pe.EvalExpr))
+	}
+	return src, Rewrote("push projection into json_table")
+}
+
 func pushProjectionToOuterContainer(ctx *plancontext.PlanningContext, p *Projection, src *SubQueryContainer) (Operator, *ApplyResult) {
 	ap, err := p.GetAliasedProjections()
 	if err != nil {
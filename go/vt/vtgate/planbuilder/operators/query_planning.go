@@ -88,6 +88,8 @@ func runRewriters(ctx *plancontext.PlanningContext, root Operator) Operator {
 			return tryPushDistinct(in)
 		case *Union:
 			return tryPushUnion(ctx, in)
+		case *RecurseCTE:
+			return tryMergeRecurseCTE(ctx, in)
 		case *SubQueryContainer:
 			return pushOrMergeSubQueryContainer(ctx, in)
 		case *QueryGraph:
@@ -206,7 +208,8 @@ func pushOrExpandHorizon(ctx *plancontext.PlanningContext, in *Horizon) (Operato
 		!needsOrdering &&
 		!qp.NeedsAggregation() &&
 		!in.selectStatement().IsDistinct() &&
-		in.selectStatement().GetLimit() == nil
+		in.selectStatement().GetLimit() == nil &&
+		(!ctx.SemTable.QuerySignature.Window || canPushWindowsToRoute(ctx, findWindowFuncs(ctx, qp)))
 
 	if canPush {
 		return Swap(in, rb, "push horizon into route")
@@ -248,6 +251,12 @@ func setUpperLimit(in *Limit) (Operator, *ApplyResult) {
 		case *Join, *ApplyJoin, *SubQueryContainer, *SubQuery:
 			// we can't push limits down on either side
 			return SkipChildren
+		case *Window:
+			// window functions need to see all the rows of their partitions
+			return SkipChildren
+		case *RecurseCTE:
+			// the recursive part needs all the rows of the previous iteration
+			return SkipChildren
 		case *Route:
 			newSrc := &Limit{
 				Source: op.Source,
@@ -302,7 +311,7 @@ func tryPushOrdering(ctx *plancontext.PlanningContext, in *Ordering) (Operator,
 		outerTableID := TableID(src.Outer)
 		for _, order := range in.Order {
 			deps := ctx.SemTable.RecursiveDeps(order.Inner.Expr)
-			if !deps.IsSolvedBy(outerTableID) {
+			if !deps.IsSolvedBy(outerTableID) || src.usesCorrelatedProjection(order.Inner.Expr) {
 				return in, NoRewrite
 			}
 		}
@@ -312,7 +321,7 @@ func tryPushOrdering(ctx *plancontext.PlanningContext, in *Ordering) (Operator,
 		outerTableID := TableID(src.Outer)
 		for _, order := range in.Order {
 			deps := ctx.SemTable.RecursiveDeps(order.Inner.Expr)
-			if !deps.IsSolvedBy(outerTableID) {
+			if !deps.IsSolvedBy(outerTableID) || (src.CorrelatedProjection && src.usesSubquery(order.Inner.Expr)) {
 				return in, NoRewrite
 			}
 		}
@@ -444,7 +453,7 @@ func tryPushFilter(ctx *plancontext.PlanningContext, in *Filter) (Operator, *App
 		outerTableID := TableID(src.Outer)
 		for _, pred := range in.Predicates {
 			deps := ctx.SemTable.RecursiveDeps(pred)
-			if !deps.IsSolvedBy(outerTableID) {
+			if !deps.IsSolvedBy(outerTableID) || (src.CorrelatedProjection && src.usesSubquery(pred)) {
 				return in, NoRewrite
 			}
 		}
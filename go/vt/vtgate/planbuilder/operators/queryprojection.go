@@ -86,6 +86,13 @@ type (
 		ColOffset int
 		WSOffset  int
 
+		// ArgOffsets are only set for aggregations that are evaluated at the vtgate level using all their arguments,
+		// like COUNT(DISTINCT a, b) or GROUP_CONCAT(a, b ORDER BY c). OrderOffsets and OrderWSOffsets point to the
+		// ORDER BY expressions of a GROUP_CONCAT and their weight strings.
+		ArgOffsets     []int
+		OrderOffsets   []int
+		OrderWSOffsets []int
+
 		SubQueryExpression []*SubQuery
 	}
 )
@@ -220,6 +227,10 @@ func ContainsAggr(ctx *plancontext.PlanningContext, e sqlparser.SQLNode) (hasAgg
 			// so we don't need to worry about aggregation in the original
 			return false, nil
 		case sqlparser.AggrFunc:
+			if sqlparser.GetOverClause(node.(sqlparser.Expr)) != nil {
+				// aggregations over a window are window functions, and don't group rows
+				return true, nil
+			}
 			hasAggr = true
 			return false, io.EOF
 		case *sqlparser.Subquery:
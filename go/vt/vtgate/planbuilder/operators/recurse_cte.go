@@ -0,0 +1,248 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operators
+
+import (
+	"fmt"
+	"slices"
+
+	"vitess.io/vitess/go/slice"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/engine"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
+	"vitess.io/vitess/go/vt/vtgate/semantics"
+)
+
+// RecurseCTE is used to evaluate a recursive common table expression at the vtgate level.
+// The Seed is evaluated once, and the Term is evaluated for every row produced by the
+// previous iteration, with the Vars bound to the columns of that row.
+// When both inputs are sent to the same shard, the RecurseCTE is merged into a single
+// route, and the recursive common table expression is sent to that shard as is.
+type RecurseCTE struct {
+	Seed, Term Operator
+
+	// Def holds the information the semantic analysis collected about the common table expression
+	Def *semantics.RecursiveCTE
+
+	columns sqlparser.SelectExprs
+}
+
+var _ Operator = (*RecurseCTE)(nil)
+
+func newRecurseCTE(seed, term Operator, def *semantics.RecursiveCTE, columns sqlparser.SelectExprs) *RecurseCTE {
+	return &RecurseCTE{
+		Seed:    seed,
+		Term:    term,
+		Def:     def,
+		columns: columns,
+	}
+}
+
+// Clone implements the Operator interface
+func (r *RecurseCTE) Clone(inputs []Operator) Operator {
+	newOp := *r
+	newOp.Seed = inputs[0]
+	newOp.Term = inputs[1]
+	newOp.columns = slices.Clone(r.columns)
+	return &newOp
+}
+
+// Inputs implements the Operator interface
+func (r *RecurseCTE) Inputs() []Operator {
+	return []Operator{r.Seed, r.Term}
+}
+
+// SetInputs implements the Operator interface
+func (r *RecurseCTE) SetInputs(operators []Operator) {
+	r.Seed = operators[0]
+	r.Term = operators[1]
+}
+
+// AddPredicate implements the Operator interface.
+// Predicates can't be pushed into the seed or the recursive part,
+// since that would change the rows the next iteration is based on.
+func (r *RecurseCTE) AddPredicate(_ *plancontext.PlanningContext, expr sqlparser.Expr) Operator {
+	return newFilter(r, expr)
+}
+
+func (r *RecurseCTE) AddColumn(ctx *plancontext.PlanningContext, reuse bool, _ bool, expr *sqlparser.AliasedExpr) int {
+	if reuse {
+		offset := r.FindCol(ctx, expr.Expr, false)
+		if offset >= 0 {
+			return offset
+		}
+	}
+	switch e := expr.Expr.(type) {
+	case *sqlparser.ColName:
+		offset := r.columnOffset(ctx, e)
+		if offset == -1 {
+			panic(vterrors.VT13001(fmt.Sprintf("could not find the column '%s' on the recursive CTE", sqlparser.String(e))))
+		}
+		return offset
+	case *sqlparser.WeightStringFuncExpr:
+		argIdx := r.columnOffset(ctx, e.Expr)
+		if argIdx == -1 {
+			panic(vterrors.VT13001(fmt.Sprintf("could not find the argument to the weight_string function: %s", sqlparser.String(e.Expr))))
+		}
+		return r.AddWSColumn(ctx, argIdx, false)
+	case *sqlparser.Literal, *sqlparser.Argument:
+		return r.addToSources(func(src Operator) int {
+			return src.AddColumn(ctx, true, false, expr)
+		})
+	default:
+		panic(vterrors.VT13001(fmt.Sprintf("only weight_string function is expected - got %s", sqlparser.String(expr))))
+	}
+}
+
+func (r *RecurseCTE) AddWSColumn(ctx *plancontext.PlanningContext, offset int, _ bool) int {
+	return r.addToSources(func(src Operator) int {
+		return src.AddWSColumn(ctx, offset, false)
+	})
+}
+
+// columnOffset returns the offset of the given expression. The columns of the CTE
+// are named by the column list of the CTE, or by the seed if there is no column list.
+func (r *RecurseCTE) columnOffset(ctx *plancontext.PlanningContext, expr sqlparser.Expr) int {
+	if col, ok := expr.(*sqlparser.ColName); ok {
+		offset := slices.IndexFunc(r.Def.Columns, func(name sqlparser.IdentifierCI) bool {
+			return name.Equal(col.Name)
+		})
+		if offset >= 0 {
+			return offset
+		}
+	}
+	return r.FindCol(ctx, expr, false)
+}
+
+// addToSources adds a column to both the seed and the term, and makes sure the offsets line up
+func (r *RecurseCTE) addToSources(f func(src Operator) int) int {
+	seedOffset := f(r.Seed)
+	termOffset := f(r.Term)
+	if seedOffset != termOffset {
+		panic(vterrors.VT12001("column offsets did not line up for the recursive CTE"))
+	}
+	return seedOffset
+}
+
+func (r *RecurseCTE) FindCol(ctx *plancontext.PlanningContext, expr sqlparser.Expr, _ bool) int {
+	for idx, col := range r.GetColumns(ctx) {
+		if ctx.SemTable.EqualsExprWithDeps(expr, col.Expr) {
+			return idx
+		}
+	}
+	return -1
+}
+
+func (r *RecurseCTE) GetColumns(ctx *plancontext.PlanningContext) []*sqlparser.AliasedExpr {
+	columns := slice.Map(r.GetSelectExprs(ctx), func(from sqlparser.SelectExpr) *sqlparser.AliasedExpr {
+		expr, ok := from.(*sqlparser.AliasedExpr)
+		if !ok {
+			panic(vterrors.VT09015())
+		}
+		return expr
+	})
+	return columns
+}
+
+func (r *RecurseCTE) GetSelectExprs(ctx *plancontext.PlanningContext) sqlparser.SelectExprs {
+	// the columns added to the inputs after the CTE was planned, like weight_string,
+	// are named by the seed, since it decides the names and the types of the result
+	seed := r.Seed.GetSelectExprs(ctx)
+	for len(seed) > len(r.columns) {
+		r.columns = append(r.columns, seed[len(r.columns)])
+	}
+	return r.columns
+}
+
+func (r *RecurseCTE) ShortDescription() string {
+	return r.Def.Name
+}
+
+func (r *RecurseCTE) GetOrdering(*plancontext.PlanningContext) []OrderBy {
+	return nil
+}
+
+// tryMergeRecurseCTE merges the seed and the recursive part into a single route when both
+// of them are sent to the same shard, so the whole recursion can be evaluated by MySQL.
+func tryMergeRecurseCTE(ctx *plancontext.PlanningContext, op *RecurseCTE) (Operator, *ApplyResult) {
+	if len(op.Seed.GetSelectExprs(ctx)) != len(op.Def.Columns) {
+		return op, NoRewrite
+	}
+	seedRoute, termRoute, routingA, routingB, a, b, sameKeyspace := prepareInputRoutes(op.Seed, op.Term)
+	if seedRoute == nil {
+		return op, NoRewrite
+	}
+
+	var routing Routing
+	switch {
+	case b == dual || (b == anyShard && sameKeyspace):
+		routing = routingA
+	case a == dual || (a == anyShard && sameKeyspace):
+		routing = routingB
+	case a == sharded && b == sharded && sameKeyspace:
+		routing = op.sameShardRouting(ctx, routingA.(*ShardedRouting), routingB.(*ShardedRouting))
+	}
+	if routing == nil || !op.isSingleShard(routing) {
+		return op, NoRewrite
+	}
+
+	op.Seed = seedRoute.Source
+	op.Term = termRoute.Source
+	return &Route{
+		Source:     op,
+		MergedWith: []*Route{termRoute},
+		Routing:    routing,
+	}, Rewrote("merge recursive CTE inputs into a single route")
+}
+
+// sameShardRouting returns the routing of the seed if both sharded routings are sent to the same shard
+func (r *RecurseCTE) sameShardRouting(ctx *plancontext.PlanningContext, a, b *ShardedRouting) Routing {
+	if a.RouteOpCode != engine.EqualUnique || b.RouteOpCode != engine.EqualUnique {
+		return nil
+	}
+	if a.SelectedVindex() != b.SelectedVindex() || !gen4ValuesEqual(ctx, a.VindexExpressions(), b.VindexExpressions()) {
+		return nil
+	}
+	return a
+}
+
+// isSingleShard returns true if the routing sends the query to a single shard,
+// and the shard doesn't depend on the rows produced by the previous iteration
+func (r *RecurseCTE) isSingleShard(routing Routing) bool {
+	switch routing := routing.(type) {
+	case *ShardedRouting:
+		return routing.RouteOpCode == engine.EqualUnique && !slices.ContainsFunc(routing.VindexExpressions(), r.usesPreviousRow)
+	default:
+		opCode := routing.OpCode()
+		return opCode == engine.Unsharded || opCode == engine.Reference
+	}
+}
+
+// usesPreviousRow returns true if the expression uses the columns of the previous iteration
+func (r *RecurseCTE) usesPreviousRow(expr sqlparser.Expr) bool {
+	found := false
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if arg, ok := node.(*sqlparser.Argument); ok {
+			if _, ok := r.Def.Vars[arg.Name]; ok {
+				found = true
+			}
+		}
+		return !found, nil
+	}, expr)
+	return found
+}
@@ -622,6 +622,10 @@ func addMultipleColumnsToInput(
 ) {
 	switch op := operator.(type) {
 	case *SubQuery:
+		if op.CorrelatedProjection {
+			// the subquery maps its own columns
+			return "", op, false, nil
+		}
 		derivedName, src, added, offset := addMultipleColumnsToInput(ctx, op.Outer, reuse, addToGroupBy, exprs)
 		if added {
 			op.Outer = src
@@ -701,6 +705,10 @@ func (r *Route) AddWSColumn(ctx *plancontext.PlanningContext, offset int, _ bool
 func addWSColumnToInput(ctx *plancontext.PlanningContext, source Operator, offset int) (bool, int) {
 	switch op := source.(type) {
 	case *SubQuery:
+		if op.CorrelatedProjection {
+			// the subquery maps its own columns
+			return false, -1
+		}
 		return addWSColumnToInput(ctx, op.Outer, offset)
 	case *Distinct:
 		return addWSColumnToInput(ctx, op.Source, offset)
@@ -288,6 +288,10 @@ func requiresSwitchingSides(ctx *plancontext.PlanningContext, op Operator) (requ
 }
 
 func mergeOrJoin(ctx *plancontext.PlanningContext, lhs, rhs Operator, joinPredicates []sqlparser.Expr, joinType sqlparser.JoinType) (Operator, *ApplyResult) {
+	if vars, predicates := lateralDependencies(ctx, lhs, rhs); len(vars) > 0 {
+		return mergeOrJoinLateral(ctx, lhs, rhs, joinPredicates, joinType, vars, predicates)
+	}
+
 	newPlan := mergeJoinInputs(ctx, lhs, rhs, joinPredicates, newJoinMerge(joinPredicates, joinType))
 	if newPlan != nil {
 		return newPlan, Rewrote("merge routes into single operator")
@@ -23,7 +23,6 @@ import (
 	"vitess.io/vitess/go/mysql/collations"
 	"vitess.io/vitess/go/slice"
 	"vitess.io/vitess/go/vt/sqlparser"
-	"vitess.io/vitess/go/vt/vterrors"
 	"vitess.io/vitess/go/vt/vtgate/engine"
 	"vitess.io/vitess/go/vt/vtgate/evalengine"
 	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
@@ -230,6 +229,10 @@ func (tr *ShardedRouting) searchForNewVindexes(ctx *plancontext.PlanningContext,
 	case *sqlparser.IsExpr:
 		found := tr.planIsExpr(ctx, node)
 		newVindexFound = newVindexFound || found
+
+	case *sqlparser.BetweenExpr:
+		found := tr.planBetween(ctx, node)
+		newVindexFound = newVindexFound || found
 	}
 
 	return nil, newVindexFound
@@ -246,7 +249,9 @@ func (tr *ShardedRouting) planComparison(ctx *plancontext.PlanningContext, cmp *
 	case sqlparser.LikeOp:
 		found := tr.planLikeOp(ctx, cmp)
 		return nil, found
-
+	case sqlparser.LessThanOp, sqlparser.LessEqualOp, sqlparser.GreaterThanOp, sqlparser.GreaterEqualOp:
+		found := tr.planRangeOp(ctx, cmp)
+		return nil, found
 	}
 	return nil, false
 }
@@ -325,6 +330,112 @@ func (tr *ShardedRouting) planLikeOp(ctx *plancontext.PlanningContext, node *sql
 	return tr.haveMatchingVindex(ctx, node, vdValue, column, val, selectEqual, vdx)
 }
 
+// planRangeOp plans the comparisons bounding a column from one side, like 'col < 10',
+// with the vindexes that can map a range of values to a keyspace range.
+func (tr *ShardedRouting) planRangeOp(ctx *plancontext.PlanningContext, cmp *sqlparser.ComparisonExpr) bool {
+	op := cmp.Operator
+	column, ok := cmp.Left.(*sqlparser.ColName)
+	vdValue := cmp.Right
+	if !ok {
+		column, ok = cmp.Right.(*sqlparser.ColName)
+		if !ok {
+			return false
+		}
+		vdValue = cmp.Left
+		// '10 > col' bounds the column like 'col < 10'
+		switch op {
+		case sqlparser.LessThanOp:
+			op = sqlparser.GreaterThanOp
+		case sqlparser.LessEqualOp:
+			op = sqlparser.GreaterEqualOp
+		case sqlparser.GreaterThanOp:
+			op = sqlparser.LessThanOp
+		case sqlparser.GreaterEqualOp:
+			op = sqlparser.LessEqualOp
+		}
+	}
+	val := makeEvalEngineExpr(ctx, vdValue)
+	if val == nil {
+		return false
+	}
+
+	// The ranges include their bounds, so that 'col < 10' routes like 'col <= 10'.
+	if op == sqlparser.GreaterThanOp || op == sqlparser.GreaterEqualOp {
+		return tr.haveMatchingRangeVindex(ctx, cmp, column, val, nil, vdValue)
+	}
+	return tr.haveMatchingRangeVindex(ctx, cmp, column, nil, val, vdValue)
+}
+
+func (tr *ShardedRouting) planBetween(ctx *plancontext.PlanningContext, node *sqlparser.BetweenExpr) bool {
+	column, ok := node.Left.(*sqlparser.ColName)
+	if !ok || !node.IsBetween {
+		return false
+	}
+	from := makeEvalEngineExpr(ctx, node.From)
+	to := makeEvalEngineExpr(ctx, node.To)
+	if from == nil || to == nil {
+		return false
+	}
+	return tr.haveMatchingRangeVindex(ctx, node, column, from, to, node.From, node.To)
+}
+
+// haveMatchingRangeVindex adds the Range options of the range from start to end
+// to the RangeMapper vindexes of the column. A nil start or end leaves the range
+// open on that side, and is then bounded by the Range options found before, if any.
+func (tr *ShardedRouting) haveMatchingRangeVindex(
+	ctx *plancontext.PlanningContext,
+	node sqlparser.Expr,
+	column *sqlparser.ColName,
+	start, end evalengine.Expr,
+	valueExprs ...sqlparser.Expr,
+) bool {
+	newVindexFound := false
+	for _, v := range tr.VindexPreds {
+		if !ctx.SemTable.DirectDeps(column).IsSolvedBy(v.TableID) {
+			continue
+		}
+		if _, ok := v.ColVindex.Vindex.(vindexes.RangeMapper); !ok || !column.Name.Equal(v.ColVindex.Columns[0]) {
+			continue
+		}
+
+		newOption := func(start, end evalengine.Expr, predicates, valueExprs []sqlparser.Expr) *VindexOption {
+			values := []evalengine.Expr{start, end}
+			for i, value := range values {
+				if value == nil {
+					values[i] = evalengine.NullExpr
+				}
+			}
+			return &VindexOption{
+				Values:      values,
+				ValueExprs:  valueExprs,
+				Predicates:  predicates,
+				OpCode:      engine.Range,
+				FoundVindex: v.ColVindex.Vindex,
+				Cost:        costFor(v.ColVindex, engine.Range),
+				Ready:       true,
+			}
+		}
+
+		// The ranges open on the other side get bounded by this one.
+		var bounded []*VindexOption
+		for _, option := range v.Options {
+			if option.OpCode != engine.Range {
+				continue
+			}
+			switch {
+			case start == nil && option.Values[1] == evalengine.NullExpr:
+				bounded = append(bounded, newOption(option.Values[0], end, append(slices.Clone(option.Predicates), node), append(slices.Clone(option.ValueExprs), valueExprs...)))
+			case end == nil && option.Values[0] == evalengine.NullExpr:
+				bounded = append(bounded, newOption(start, option.Values[1], append(slices.Clone(option.Predicates), node), append(slices.Clone(option.ValueExprs), valueExprs...)))
+			}
+		}
+		v.Options = append(v.Options, newOption(start, end, []sqlparser.Expr{node}, valueExprs))
+		v.Options = append(v.Options, bounded...)
+		newVindexFound = true
+	}
+	return newVindexFound
+}
+
 func (tr *ShardedRouting) Cost() int {
 	switch tr.RouteOpCode {
 	case engine.EqualUnique:
@@ -335,6 +446,8 @@ func (tr *ShardedRouting) Cost() int {
 		return 10
 	case engine.MultiEqual:
 		return 10
+	case engine.Range:
+		return 15
 	case engine.Scatter:
 		return 20
 	default:
@@ -661,7 +774,7 @@ func tryMergeJoinShardedRouting(
 		// can merge via join predicates instead.
 		fallthrough
 
-	case engine.Scatter, engine.IN, engine.None:
+	case engine.Scatter, engine.IN, engine.Range, engine.None:
 		if len(joinPredicates) == 0 {
 			// If we are doing two Scatters, we have to make sure that the
 			// joins are on the correct vindex to allow them to be merged
@@ -670,7 +783,9 @@ func tryMergeJoinShardedRouting(
 		}
 
 		if !sameKeyspace {
-			panic(vterrors.VT12001("cross-shard correlated subquery"))
+			// the join predicates can't keep the rows on the same shard
+			// when the routes go to different keyspaces
+			return nil
 		}
 
 		canMerge := canMergeOnFilters(ctx, routeA, routeB, joinPredicates)
@@ -20,11 +20,14 @@ import (
 	"fmt"
 	"maps"
 	"slices"
+	"strings"
 
 	"vitess.io/vitess/go/slice"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
 	"vitess.io/vitess/go/vt/sqlparser"
 	"vitess.io/vitess/go/vt/vterrors"
 	"vitess.io/vitess/go/vt/vtgate/engine/opcode"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
 	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
 	"vitess.io/vitess/go/vt/vtgate/semantics"
 )
@@ -53,6 +56,23 @@ type SubQuery struct {
 	// We use this information to fail the planning if we are unable to merge the subquery with a route.
 	correlated bool
 
+	// CorrelatedPredicate is set for correlated filtering subqueries that could not be merged with the outer query
+	// and are not EXISTS subqueries. The predicate is evaluated at the vtgate level for every row of the outer query,
+	// using the result of running the subquery for that row.
+	CorrelatedPredicate            sqlparser.Expr
+	CorrelatedPredicateWithOffsets evalengine.Expr
+
+	// CorrelatedProjection is set for correlated subqueries in the select list that could not be merged with the
+	// outer query. The SubQuery then returns the columns of the outer query along with the expressions using the
+	// subquery, which are evaluated at the vtgate level for every row of the outer query.
+	CorrelatedProjection bool
+	columns              []*sqlparser.AliasedExpr
+	// Columns maps the returned columns to the ones of the outer query (negative values)
+	// or to the ProjExprs evaluated at the vtgate level (positive values), the same way ApplyJoin does it.
+	Columns          []int
+	ProjExprs        []sqlparser.Expr
+	ProjExprsOffsets []evalengine.Expr
+
 	IsProjection bool
 }
 
@@ -68,9 +88,34 @@ func (sq *SubQuery) planOffsets(ctx *plancontext.PlanningContext) Operator {
 			sq.Vars[lhsExpr.Name] = offset
 		}
 	}
+	if sq.CorrelatedPredicate != nil {
+		sq.CorrelatedPredicateWithOffsets = sq.translateWithOffsets(ctx, sq.CorrelatedPredicate)
+	}
+	for _, expr := range sq.ProjExprs {
+		sq.ProjExprsOffsets = append(sq.ProjExprsOffsets, sq.translateWithOffsets(ctx, expr))
+	}
 	return nil
 }
 
+// translateWithOffsets translates an expression evaluated at the vtgate level for every
+// row of the outer query, using the offsets of the columns it needs from the outer query.
+func (sq *SubQuery) translateWithOffsets(ctx *plancontext.PlanningContext, expr sqlparser.Expr) evalengine.Expr {
+	cfg := &evalengine.Config{
+		ResolveType: ctx.SemTable.TypeForExpr,
+		Collation:   ctx.SemTable.Collation,
+		Environment: ctx.VSchema.Environment(),
+	}
+	rewritten := useOffsets(ctx, expr, sq)
+	eexpr, err := evalengine.Translate(rewritten, cfg)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), evalengine.ErrTranslateExprNotSupported) {
+			panic(vterrors.Errorf(vtrpcpb.Code_UNIMPLEMENTED, "%s: %s", evalengine.ErrTranslateExprNotSupported, sqlparser.String(expr)))
+		}
+		panic(err)
+	}
+	return eexpr
+}
+
 func (sq *SubQuery) OuterExpressionsNeeded(ctx *plancontext.PlanningContext, outer Operator) (result []*sqlparser.ColName) {
 	joinColumns, err := sq.GetJoinColumns(ctx, outer)
 	if err != nil {
@@ -125,6 +170,9 @@ func (sq *SubQuery) Clone(inputs []Operator) Operator {
 	klone.JoinColumns = slices.Clone(sq.JoinColumns)
 	klone.Vars = maps.Clone(sq.Vars)
 	klone.Predicates = sqlparser.CloneExprs(sq.Predicates)
+	klone.columns = slices.Clone(sq.columns)
+	klone.Columns = slices.Clone(sq.Columns)
+	klone.ProjExprs = slices.Clone(sq.ProjExprs)
 	return &klone
 }
 
@@ -176,25 +224,162 @@ func (sq *SubQuery) AddPredicate(ctx *plancontext.PlanningContext, expr sqlparse
 }
 
 func (sq *SubQuery) AddColumn(ctx *plancontext.PlanningContext, reuseExisting bool, addToGroupBy bool, exprs *sqlparser.AliasedExpr) int {
-	return sq.Outer.AddColumn(ctx, reuseExisting, addToGroupBy, exprs)
+	if !sq.CorrelatedProjection {
+		return sq.Outer.AddColumn(ctx, reuseExisting, addToGroupBy, exprs)
+	}
+	if expr := sq.valueExpr(ctx, exprs.Expr); expr != exprs.Expr {
+		exprs = &sqlparser.AliasedExpr{Expr: expr, As: exprs.As}
+	}
+	if reuseExisting {
+		if offset := sq.findCol(ctx, exprs.Expr); offset >= 0 {
+			return offset
+		}
+	}
+	if sq.usesSubquery(exprs.Expr) {
+		sq.ProjExprs = append(sq.ProjExprs, sq.rewriteProjExpr(ctx, exprs.Expr))
+		sq.Columns = append(sq.Columns, len(sq.ProjExprs))
+		if exprs.As.IsEmpty() {
+			// MySQL names the column after the expression, with the subquery in it
+			exprs = &sqlparser.AliasedExpr{Expr: exprs.Expr, As: sqlparser.NewIdentifierCI(sqlparser.String(sq.originalExpr(exprs.Expr)))}
+		}
+	} else {
+		offset := sq.Outer.AddColumn(ctx, reuseExisting, addToGroupBy, exprs)
+		sq.Columns = append(sq.Columns, ToLeftOffset(offset))
+	}
+	sq.columns = append(sq.columns, exprs)
+	return len(sq.columns) - 1
 }
 
 func (sq *SubQuery) AddWSColumn(ctx *plancontext.PlanningContext, offset int, underRoute bool) int {
-	return sq.Outer.AddWSColumn(ctx, offset, underRoute)
+	if !sq.CorrelatedProjection {
+		return sq.Outer.AddWSColumn(ctx, offset, underRoute)
+	}
+	if len(sq.columns) <= offset {
+		panic(vterrors.VT13001("offset out of range"))
+	}
+	return sq.AddColumn(ctx, true, false, aeWrap(weightStringFor(sq.columns[offset].Expr)))
 }
 
 func (sq *SubQuery) FindCol(ctx *plancontext.PlanningContext, expr sqlparser.Expr, underRoute bool) int {
-	return sq.Outer.FindCol(ctx, expr, underRoute)
+	if !sq.CorrelatedProjection {
+		return sq.Outer.FindCol(ctx, expr, underRoute)
+	}
+	expr = sq.valueExpr(ctx, expr)
+	if offset := sq.findCol(ctx, expr); offset >= 0 {
+		return offset
+	}
+	if sq.usesSubquery(expr) {
+		// the expressions using the subquery can only be evaluated here, so we add them
+		// instead of letting the operator above fetch the columns they use from us
+		return sq.AddColumn(ctx, false, false, aeWrap(expr))
+	}
+	return -1
+}
+
+func (sq *SubQuery) findCol(ctx *plancontext.PlanningContext, expr sqlparser.Expr) int {
+	for offset, col := range sq.columns {
+		if ctx.SemTable.EqualsExprWithDeps(col.Expr, expr) {
+			return offset
+		}
+	}
+	return -1
 }
 
 func (sq *SubQuery) GetColumns(ctx *plancontext.PlanningContext) []*sqlparser.AliasedExpr {
+	if sq.CorrelatedProjection {
+		return sq.columns
+	}
 	return sq.Outer.GetColumns(ctx)
 }
 
 func (sq *SubQuery) GetSelectExprs(ctx *plancontext.PlanningContext) sqlparser.SelectExprs {
+	if sq.CorrelatedProjection {
+		return transformColumnsToSelectExprs(ctx, sq)
+	}
 	return sq.Outer.GetSelectExprs(ctx)
 }
 
+// usesSubquery returns whether the expression uses the value of this subquery
+func (sq *SubQuery) usesSubquery(expr sqlparser.Expr) bool {
+	found := false
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch node := node.(type) {
+		case *sqlparser.ColName:
+			found = found || (node.Qualifier.IsEmpty() && node.Name.String() == sq.ArgName)
+		case *sqlparser.Argument:
+			found = found || node.Name == sq.ArgName || (sq.HasValuesName != "" && node.Name == sq.HasValuesName)
+		case sqlparser.ListArg:
+			found = found || string(node) == sq.ArgName
+		}
+		return !found, nil
+	}, expr)
+	return found
+}
+
+// valueExpr replaces the subquery itself with the column standing for its value. It is the
+// inverse of originalExpr, used when the columns are asked for using the original query
+func (sq *SubQuery) valueExpr(ctx *plancontext.PlanningContext, expr sqlparser.Expr) sqlparser.Expr {
+	return sqlparser.CopyOnRewrite(expr, nil, func(cursor *sqlparser.CopyOnWriteCursor) {
+		var subq *sqlparser.Subquery
+		switch node := cursor.Node().(type) {
+		case *sqlparser.ExistsExpr:
+			if sq.FilterType == opcode.PulloutExists {
+				subq = node.Subquery
+			}
+		case *sqlparser.Subquery:
+			if sq.FilterType != opcode.PulloutExists {
+				subq = node
+			}
+		}
+		if subq != nil && ctx.SemTable.ASTEquals().Expr(subq, sq.originalSubquery) {
+			cursor.Replace(sqlparser.NewColName(sq.ArgName))
+		}
+	}, nil).(sqlparser.Expr)
+}
+
+// originalExpr replaces the column standing for the subquery with the subquery itself
+func (sq *SubQuery) originalExpr(expr sqlparser.Expr) sqlparser.Expr {
+	return sqlparser.CopyOnRewrite(expr, nil, func(cursor *sqlparser.CopyOnWriteCursor) {
+		col, ok := cursor.Node().(*sqlparser.ColName)
+		if !ok || !col.Qualifier.IsEmpty() || col.Name.String() != sq.ArgName {
+			return
+		}
+		if sq.FilterType == opcode.PulloutExists {
+			cursor.Replace(&sqlparser.ExistsExpr{Subquery: sq.originalSubquery})
+		} else {
+			cursor.Replace(sq.originalSubquery)
+		}
+	}, nil).(sqlparser.Expr)
+}
+
+// rewriteProjExpr rewrites an expression using a correlated projection subquery to use
+// the arguments that hold its result, checking that it has values for IN and NOT IN.
+func (sq *SubQuery) rewriteProjExpr(ctx *plancontext.PlanningContext, expr sqlparser.Expr) sqlparser.Expr {
+	expr = rewriteColNameToArgument(ctx, sqlparser.CloneExpr(expr), SubQueryExpression{sq}, sq)
+	if !sq.FilterType.NeedsListArg() {
+		return expr
+	}
+	if sq.HasValuesName == "" {
+		sq.HasValuesName = ctx.ReservedVars.ReserveHasValuesSubQuery()
+	}
+	return sqlparser.CopyOnRewrite(expr, nil, func(cursor *sqlparser.CopyOnWriteCursor) {
+		cmp, ok := cursor.Node().(*sqlparser.ComparisonExpr)
+		if !ok {
+			return
+		}
+		if arg, ok := cmp.Right.(sqlparser.ListArg); !ok || string(arg) != sq.ArgName {
+			return
+		}
+		hasValues := sqlparser.NewArgument(sq.HasValuesName)
+		switch cmp.Operator {
+		case sqlparser.InOp:
+			cursor.Replace(&sqlparser.AndExpr{Left: hasValues, Right: cmp})
+		case sqlparser.NotInOp:
+			cursor.Replace(&sqlparser.OrExpr{Left: sqlparser.NewNotExpr(hasValues), Right: cmp})
+		}
+	}, nil).(sqlparser.Expr)
+}
+
 // GetMergePredicates returns the predicates that we can use to try to merge this subquery with the outer query.
 func (sq *SubQuery) GetMergePredicates() []sqlparser.Expr {
 	if sq.OuterPredicate != nil {
@@ -207,13 +392,10 @@ func (sq *SubQuery) settle(ctx *plancontext.PlanningContext, outer Operator) Ope
 	if !sq.TopLevel {
 		panic(subqueryNotAtTopErr)
 	}
-	if sq.correlated && sq.FilterType != opcode.PulloutExists {
-		panic(correlatedSubqueryErr)
-	}
 	if sq.IsProjection {
-		if len(sq.GetMergePredicates()) > 0 {
+		if sq.correlated || len(sq.GetMergePredicates()) > 0 {
 			// this means that we have a correlated subquery on our hands
-			panic(correlatedSubqueryErr)
+			sq.settleCorrelatedProjection(ctx, outer)
 		}
 		sq.SubqueryValueName = sq.ArgName
 		return outer
@@ -221,16 +403,55 @@ func (sq *SubQuery) settle(ctx *plancontext.PlanningContext, outer Operator) Ope
 	return sq.settleFilter(ctx, outer)
 }
 
-var correlatedSubqueryErr = vterrors.VT12001("correlated subquery is only supported for EXISTS")
+// settleCorrelatedProjection prepares a correlated subquery in the select list to be executed once
+// per row of the outer query. The SubQuery starts by returning the columns of the outer query, and
+// the expressions using the subquery are added to it when the operators above plan their offsets.
+func (sq *SubQuery) settleCorrelatedProjection(ctx *plancontext.PlanningContext, outer Operator) {
+	if len(sq.Predicates) == 0 {
+		// the subquery refers to the outer query outside of the predicates we can send as arguments,
+		// e.g. in an aggregation that MySQL would evaluate as part of the outer query
+		panic(correlatedSubqueryErr)
+	}
+	sq.checkPredicatesScope(ctx, outer)
+	sq.CorrelatedProjection = true
+	sq.columns = slices.Clone(outer.GetColumns(ctx))
+	sq.Columns = make([]int, len(sq.columns))
+	for i := range sq.Columns {
+		sq.Columns[i] = ToLeftOffset(i)
+	}
+}
+
+// checkPredicatesScope checks that the predicates of a subquery executed once per outer row
+// only use the tables of the outer query and of the subquery itself.
+func (sq *SubQuery) checkPredicatesScope(ctx *plancontext.PlanningContext, outer Operator) {
+	subqID := TableID(sq.Subquery)
+	tableIDs := TableID(outer).Merge(subqID)
+	for _, pred := range sq.Predicates {
+		if !ctx.SemTable.RecursiveDeps(pred).IsSolvedBy(tableIDs) {
+			panic(correlatedOuterScopeErr)
+		}
+		_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+			if aggr, ok := node.(sqlparser.AggrFunc); ok && !ctx.SemTable.RecursiveDeps(aggr).IsOverlapping(subqID) {
+				// MySQL evaluates the aggregations of outer columns as part of the outer query
+				panic(correlatedSubqueryErr)
+			}
+			return true, nil
+		}, pred)
+	}
+}
+
+var correlatedSubqueryErr = vterrors.VT12001("correlated subquery using the outer query in an aggregation or outside of its predicates")
+var correlatedOuterScopeErr = vterrors.VT12001("correlated subquery can only reference its immediate outer query")
 var subqueryNotAtTopErr = vterrors.VT12001("unmergable subquery can not be inside complex expression")
 
 func (sq *SubQuery) settleFilter(ctx *plancontext.PlanningContext, outer Operator) Operator {
-	if len(sq.Predicates) > 0 {
-		if sq.FilterType != opcode.PulloutExists {
-			panic(correlatedSubqueryErr)
-		}
+	if len(sq.Predicates) > 0 && sq.FilterType == opcode.PulloutExists {
 		return outer
 	}
+	if len(sq.Predicates) == 0 && sq.correlated {
+		// the subquery refers to the outer query outside of the predicates we can send as arguments
+		panic(correlatedSubqueryErr)
+	}
 
 	hasValuesArg := func() string {
 		s := ctx.ReservedVars.ReserveVariable(string(sqlparser.HasValueSubQueryBaseName))
@@ -273,6 +494,13 @@ func (sq *SubQuery) settleFilter(ctx *plancontext.PlanningContext, outer Operato
 		predicates = append(predicates, rhsPred)
 		sq.SubqueryValueName = sq.ArgName
 	}
+	if len(sq.Predicates) > 0 {
+		// the subquery has to be evaluated once per outer row, so the predicate
+		// using it has to be evaluated at the vtgate level instead of in the outer query
+		sq.checkPredicatesScope(ctx, outer)
+		sq.CorrelatedPredicate = sqlparser.AndExpressions(predicates...)
+		return outer
+	}
 	return newFilter(outer, predicates...)
 }
 
@@ -94,3 +94,15 @@ func (sqc *SubQueryContainer) GetColumns(ctx *plancontext.PlanningContext) []*sq
 func (sqc *SubQueryContainer) GetSelectExprs(ctx *plancontext.PlanningContext) sqlparser.SelectExprs {
 	return sqc.Outer.GetSelectExprs(ctx)
 }
+
+// usesCorrelatedProjection returns true if the expression uses the value of a correlated subquery
+// in the select list. Such a value is only known once the subquery has run, so the expression
+// can't be sent to the outer side
+func (sqc *SubQueryContainer) usesCorrelatedProjection(expr sqlparser.Expr) bool {
+	for _, sq := range sqc.Inner {
+		if sq.IsProjection && (sq.correlated || len(sq.Predicates) > 0) && sq.usesSubquery(expr) {
+			return true
+		}
+	}
+	return false
+}
@@ -46,6 +46,12 @@ type (
 		// On merging this information will be lost, so subquery merge is blocked.
 		SubQueriesArgOnChangedVindex []string
 
+		// MoveSelect and MoveDelete are used to move the rows to their new shard
+		// when the primary vindex columns are updated, MoveColumns are the columns returned by MoveSelect
+		MoveSelect  *sqlparser.Select
+		MoveDelete  *sqlparser.Delete
+		MoveColumns sqlparser.Columns
+
 		VerifyAll bool
 
 		noColumns
@@ -136,6 +142,9 @@ func updateWithInputPlanningRequired(
 	if isMultiTargetUpdate(ctx, updateStmt) {
 		return true
 	}
+	if updateStmt.Limit != nil && limitRequiresDMLWithInput(ctx, updateStmt.Where, updateStmt.OrderBy, updateStmt.Exprs) {
+		return true
+	}
 	// If there are no foreign keys, we don't need to use delete with input.
 	if len(childFks) == 0 && len(parentFks) == 0 {
 		return false
@@ -168,8 +177,9 @@ func createUpdateWithInputOp(ctx *plancontext.PlanningContext, upd *sqlparser.Up
 	updOps = sortDmlOps(updOps)
 
 	selectStmt := &sqlparser.Select{
-		From:    updClone.TableExprs,
-		Where:   updClone.Where,
+		From: updClone.TableExprs,
+		// the original WHERE clause is used, so subqueries in it can still be found in the semantic table
+		Where:   upd.Where,
 		OrderBy: updClone.OrderBy,
 		Limit:   updClone.Limit,
 		Lock:    sqlparser.ForUpdateLock,
@@ -331,6 +341,7 @@ func createUpdateOperator(ctx *plancontext.PlanningContext, updStmt *sqlparser.U
 	}
 
 	cvv, ovq, subQueriesArgOnChangedVindex := getUpdateVindexInformation(ctx, updStmt, targetTbl, assignments)
+	moveSelect, moveDelete, moveColumns := buildMoveQueries(targetTbl.VTable, cvv, assignments)
 
 	updOp := &Update{
 		DMLCommon: &DMLCommon{
@@ -342,6 +353,9 @@ func createUpdateOperator(ctx *plancontext.PlanningContext, updStmt *sqlparser.U
 		Assignments:                  assignments,
 		ChangedVindexValues:          cvv,
 		SubQueriesArgOnChangedVindex: subQueriesArgOnChangedVindex,
+		MoveSelect:                   moveSelect,
+		MoveDelete:                   moveDelete,
+		MoveColumns:                  moveColumns,
 		VerifyAll:                    ctx.VerifyAllFKs,
 	}
 
@@ -1009,10 +1023,10 @@ func buildChangedVindexesValues(
 			// Vindex not changing, continue
 			continue
 		}
-		if i == 0 {
+		if i == 0 && !table.AllowPrimaryVindexUpdate {
 			panic(vterrors.VT12001(fmt.Sprintf("you cannot UPDATE primary vindex columns; invalid update on vindex: %v", vindex.Name)))
 		}
-		if _, ok := vindex.Vindex.(vindexes.Lookup); !ok {
+		if _, ok := vindex.Vindex.(vindexes.Lookup); !ok && i > 0 {
 			panic(vterrors.VT12001(fmt.Sprintf("you can only UPDATE lookup vindexes; invalid update on vindex: %v", vindex.Name)))
 		}
 
@@ -1037,6 +1051,62 @@ func buildChangedVindexesValues(
 	return changedVindexes, ovq, subQueriesArgOnChangedVindex
 }
 
+// buildMoveQueries builds the queries used to move the rows to their new shard when the primary vindex
+// columns are updated. The select finds the updated rows using the new values of the primary vindex columns,
+// and the delete removes the rows that have to be moved, using the values of the primary vindex columns.
+// The select returns the columns of the table that can be inserted, so its rows can be inserted on the new shard
+// as they are; this needs the table to have authoritative columns.
+func buildMoveQueries(table *vindexes.Table, changedVindexes map[string]*engine.VindexValues, assignments []SetExpr) (*sqlparser.Select, *sqlparser.Delete, sqlparser.Columns) {
+	if len(table.ColumnVindexes) == 0 {
+		return nil, nil, nil
+	}
+	primary := table.ColumnVindexes[0]
+	if _, ok := changedVindexes[primary.Name]; !ok {
+		return nil, nil, nil
+	}
+	if !table.ColumnListAuthoritative {
+		panic(vterrors.VT09015())
+	}
+	var selExprs sqlparser.SelectExprs
+	var moveColumns sqlparser.Columns
+	for _, col := range table.Columns {
+		if col.Generated {
+			continue
+		}
+		selExprs = append(selExprs, aeWrap(sqlparser.NewColName(col.Name.String())))
+		moveColumns = append(moveColumns, col.Name)
+	}
+
+	tblName := sqlparser.TableExprs{sqlparser.NewAliasedTableExpr(sqlparser.NewTableName(table.Name.String()), "")}
+	var predicates []sqlparser.Expr
+	var cols sqlparser.ValTuple
+	for _, col := range primary.Columns {
+		colName := sqlparser.NewColName(col.String())
+		cols = append(cols, colName)
+		for _, assignment := range assignments {
+			if col.Equal(assignment.Name.Name) {
+				predicates = append(predicates, sqlparser.NewComparisonExpr(sqlparser.EqualOp, colName, assignment.Expr.EvalExpr, nil))
+			}
+		}
+	}
+	moveSelect := &sqlparser.Select{
+		SelectExprs: selExprs,
+		From:        tblName,
+		Where:       sqlparser.NewWhere(sqlparser.WhereClause, sqlparser.AndExpressions(predicates...)),
+		Lock:        sqlparser.ForUpdateLock,
+	}
+
+	var lhs sqlparser.Expr = cols
+	if len(cols) == 1 {
+		lhs = cols[0]
+	}
+	moveDelete := &sqlparser.Delete{
+		TableExprs: tblName,
+		Where:      sqlparser.NewWhere(sqlparser.WhereClause, sqlparser.NewComparisonExpr(sqlparser.InOp, lhs, sqlparser.ListArg(engine.DmlVals), nil)),
+	}
+	return moveSelect, moveDelete, moveColumns
+}
+
 func initialQuery(ksidCols []sqlparser.IdentifierCI, table *vindexes.Table) (sqlparser.SelectExprs, int) {
 	var selExprs sqlparser.SelectExprs
 	offset := 0
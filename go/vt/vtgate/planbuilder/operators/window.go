@@ -0,0 +1,339 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operators
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"vitess.io/vitess/go/slice"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/engine/opcode"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
+)
+
+type (
+	// Window evaluates window functions that share the same window specification at the vtgate level.
+	// The input has to be sorted by the PARTITION BY expressions followed by the ORDER BY expressions
+	// of the specification. The operator produces the results of the window functions first,
+	// followed by all the columns of the input.
+	Window struct {
+		Source Operator
+		Spec   *sqlparser.WindowSpecification
+		Funcs  []WindowFunc
+
+		// These are filled in during offset planning
+		PartitionOffsets   []int
+		PartitionWSOffsets []int
+		OrderOffsets       []int
+		OrderWSOffsets     []int
+	}
+
+	// WindowFunc is a single window function evaluated by the Window operator
+	WindowFunc struct {
+		// Original is the window function as it was written in the query
+		Original sqlparser.Expr
+
+		OpCode opcode.WindowOpcode
+		// Aggregate is set when OpCode is WindowAggregate
+		Aggregate opcode.AggregateOpcode
+
+		// Arg is the argument of the function, N and Default are the optional
+		// extra arguments of LAG/LEAD, NTILE and NTH_VALUE
+		Arg, N, Default sqlparser.Expr
+
+		// ColOffset is the offset of Arg in the input. It's filled in during offset planning
+		ColOffset int
+	}
+)
+
+func newWindowFunc(expr sqlparser.Expr) WindowFunc {
+	wf := WindowFunc{Original: expr, ColOffset: -1}
+	switch node := expr.(type) {
+	case *sqlparser.ArgumentLessWindowExpr:
+		switch node.Type {
+		case sqlparser.RowNumberExprType:
+			wf.OpCode = opcode.WindowRowNumber
+		case sqlparser.RankExprType:
+			wf.OpCode = opcode.WindowRank
+		case sqlparser.DenseRankExprType:
+			wf.OpCode = opcode.WindowDenseRank
+		case sqlparser.PercentRankExprType:
+			wf.OpCode = opcode.WindowPercentRank
+		case sqlparser.CumeDistExprType:
+			wf.OpCode = opcode.WindowCumeDist
+		}
+	case *sqlparser.NtileExpr:
+		wf.OpCode = opcode.WindowNtile
+		wf.N = node.N
+	case *sqlparser.LagLeadExpr:
+		if node.NullTreatmentClause != nil {
+			panic(vterrors.VT12001(fmt.Sprintf("null treatment in window function: %s", sqlparser.String(expr))))
+		}
+		wf.OpCode = opcode.WindowLag
+		if node.Type == sqlparser.LeadExprType {
+			wf.OpCode = opcode.WindowLead
+		}
+		wf.Arg, wf.N, wf.Default = node.Expr, node.N, node.Default
+	case *sqlparser.FirstOrLastValueExpr:
+		if node.NullTreatmentClause != nil {
+			panic(vterrors.VT12001(fmt.Sprintf("null treatment in window function: %s", sqlparser.String(expr))))
+		}
+		wf.OpCode = opcode.WindowFirstValue
+		if node.Type == sqlparser.LastValueExprType {
+			wf.OpCode = opcode.WindowLastValue
+		}
+		wf.Arg = node.Expr
+	case *sqlparser.NTHValueExpr:
+		if node.NullTreatmentClause != nil || node.FromFirstLastClause != nil {
+			panic(vterrors.VT12001(fmt.Sprintf("null treatment in window function: %s", sqlparser.String(expr))))
+		}
+		wf.OpCode = opcode.WindowNthValue
+		wf.Arg, wf.N = node.Expr, node.N
+	case sqlparser.AggrFunc:
+		wf.OpCode = opcode.WindowAggregate
+		wf.Aggregate = opcode.SupportedAggregates[node.AggrName()]
+		switch wf.Aggregate {
+		case opcode.AggregateCount, opcode.AggregateSum, opcode.AggregateMin, opcode.AggregateMax:
+			wf.Arg = node.GetArg()
+		default:
+			panic(vterrors.VT12001(fmt.Sprintf("aggregation over a window on a sharded keyspace: %s", sqlparser.String(expr))))
+		}
+		if _, isStar := node.(*sqlparser.CountStar); isStar {
+			wf.Aggregate = opcode.AggregateCountStar
+			wf.Arg = nil
+		}
+	default:
+		panic(vterrors.VT13001(fmt.Sprintf("unexpected window function: %s", sqlparser.String(expr))))
+	}
+	return wf
+}
+
+// createWindows creates the Window operators needed to evaluate the window functions of the query.
+// Window functions sharing the same window specification are evaluated by the same operator,
+// on top of an Ordering that sorts the input by the partition and order expressions of the specification.
+func createWindows(ctx *plancontext.PlanningContext, src Operator, windowFuncs []sqlparser.Expr) Operator {
+	var windows []*Window
+outer:
+	for _, expr := range windowFuncs {
+		spec := windowSpecification(expr)
+		for _, w := range windows {
+			if sqlparser.Equals.RefOfWindowSpecification(w.Spec, spec) {
+				w.Funcs = append(w.Funcs, newWindowFunc(expr))
+				continue outer
+			}
+		}
+		windows = append(windows, &Window{
+			Spec:  spec,
+			Funcs: []WindowFunc{newWindowFunc(expr)},
+		})
+	}
+
+	for _, w := range windows {
+		var order []OrderBy
+		for _, by := range w.Spec.PartitionClause {
+			order = append(order, OrderBy{
+				Inner:          &sqlparser.Order{Expr: by, Direction: sqlparser.AscOrder},
+				SimplifiedExpr: by,
+			})
+		}
+		for _, by := range w.Spec.OrderClause {
+			order = append(order, OrderBy{
+				Inner:          by,
+				SimplifiedExpr: by.Expr,
+			})
+		}
+		if len(order) > 0 {
+			src = &Ordering{
+				Source: src,
+				Order:  order,
+			}
+		}
+		w.Source = src
+		src = w
+	}
+	return src
+}
+
+// windowSpecification returns the window specification of a window function,
+// failing for the window features we can't evaluate on the vtgate
+func windowSpecification(expr sqlparser.Expr) *sqlparser.WindowSpecification {
+	over := sqlparser.GetOverClause(expr)
+	if over == nil {
+		panic(vterrors.VT13001(fmt.Sprintf("expected a window function: %s", sqlparser.String(expr))))
+	}
+	if !over.WindowName.IsEmpty() || over.WindowSpec == nil || !over.WindowSpec.Name.IsEmpty() {
+		panic(vterrors.VT12001("named window on a sharded keyspace"))
+	}
+	if over.WindowSpec.FrameClause != nil {
+		panic(vterrors.VT12001("window frame clause on a sharded keyspace"))
+	}
+	return over.WindowSpec
+}
+
+// findWindowFuncs returns all the window functions used by the query, without duplicates
+func findWindowFuncs(ctx *plancontext.PlanningContext, qp *QueryProjection) []sqlparser.Expr {
+	var windowFuncs []sqlparser.Expr
+	visit := func(node sqlparser.SQLNode) (bool, error) {
+		switch node := node.(type) {
+		case *sqlparser.Subquery:
+			return false, nil
+		case sqlparser.Expr:
+			if sqlparser.GetOverClause(node) == nil {
+				return true, nil
+			}
+			if !slices.ContainsFunc(windowFuncs, func(e sqlparser.Expr) bool {
+				return ctx.SemTable.EqualsExprWithDeps(e, node)
+			}) {
+				windowFuncs = append(windowFuncs, node)
+			}
+			return false, nil
+		}
+		return true, nil
+	}
+	for _, expr := range qp.SelectExprs {
+		_ = sqlparser.Walk(visit, expr.Col)
+	}
+	for _, order := range qp.OrderExprs {
+		_ = sqlparser.Walk(visit, order.SimplifiedExpr)
+	}
+	return windowFuncs
+}
+
+// canPushWindowsToRoute returns true if every row of each window partition is guaranteed to come from the same shard
+func canPushWindowsToRoute(ctx *plancontext.PlanningContext, windowFuncs []sqlparser.Expr) bool {
+	for _, expr := range windowFuncs {
+		over := sqlparser.GetOverClause(expr)
+		if over == nil || over.WindowSpec == nil {
+			return false
+		}
+		if !slices.ContainsFunc(over.WindowSpec.PartitionClause, func(e sqlparser.Expr) bool {
+			return exprHasUniqueVindex(ctx, e)
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
+func (w *Window) Clone(inputs []Operator) Operator {
+	return &Window{
+		Source:             inputs[0],
+		Spec:               w.Spec,
+		Funcs:              slices.Clone(w.Funcs),
+		PartitionOffsets:   slices.Clone(w.PartitionOffsets),
+		PartitionWSOffsets: slices.Clone(w.PartitionWSOffsets),
+		OrderOffsets:       slices.Clone(w.OrderOffsets),
+		OrderWSOffsets:     slices.Clone(w.OrderWSOffsets),
+	}
+}
+
+func (w *Window) Inputs() []Operator {
+	return []Operator{w.Source}
+}
+
+func (w *Window) SetInputs(operators []Operator) {
+	w.Source = operators[0]
+}
+
+func (w *Window) AddPredicate(ctx *plancontext.PlanningContext, expr sqlparser.Expr) Operator {
+	// predicates can't be evaluated before the window functions, since they would change the partitions
+	return newFilter(w, expr)
+}
+
+func (w *Window) AddColumn(ctx *plancontext.PlanningContext, reuse bool, gb bool, expr *sqlparser.AliasedExpr) int {
+	if offset := w.findFunc(ctx, expr.Expr); offset >= 0 {
+		return offset
+	}
+	return w.Source.AddColumn(ctx, reuse, gb, expr) + len(w.Funcs)
+}
+
+func (w *Window) AddWSColumn(ctx *plancontext.PlanningContext, offset int, underRoute bool) int {
+	if offset < len(w.Funcs) {
+		panic(vterrors.VT12001(fmt.Sprintf("weight_string of a window function: %s", sqlparser.String(w.Funcs[offset].Original))))
+	}
+	return w.Source.AddWSColumn(ctx, offset-len(w.Funcs), underRoute) + len(w.Funcs)
+}
+
+func (w *Window) FindCol(ctx *plancontext.PlanningContext, expr sqlparser.Expr, underRoute bool) int {
+	if offset := w.findFunc(ctx, expr); offset >= 0 {
+		return offset
+	}
+	offset := w.Source.FindCol(ctx, expr, underRoute)
+	if offset < 0 {
+		return offset
+	}
+	return offset + len(w.Funcs)
+}
+
+func (w *Window) findFunc(ctx *plancontext.PlanningContext, expr sqlparser.Expr) int {
+	return slices.IndexFunc(w.Funcs, func(f WindowFunc) bool {
+		return ctx.SemTable.EqualsExprWithDeps(f.Original, expr)
+	})
+}
+
+func (w *Window) GetColumns(ctx *plancontext.PlanningContext) []*sqlparser.AliasedExpr {
+	cols := slice.Map(w.Funcs, func(f WindowFunc) *sqlparser.AliasedExpr {
+		return aeWrap(f.Original)
+	})
+	return append(cols, w.Source.GetColumns(ctx)...)
+}
+
+func (w *Window) GetSelectExprs(ctx *plancontext.PlanningContext) sqlparser.SelectExprs {
+	return transformColumnsToSelectExprs(ctx, w)
+}
+
+func (w *Window) GetOrdering(ctx *plancontext.PlanningContext) []OrderBy {
+	return w.Source.GetOrdering(ctx)
+}
+
+func (w *Window) planOffsets(ctx *plancontext.PlanningContext) Operator {
+	addColumn := func(expr sqlparser.Expr) (int, int) {
+		offset := w.Source.AddColumn(ctx, true, false, aeWrap(expr))
+		if !ctx.SemTable.NeedsWeightString(expr) {
+			return offset, -1
+		}
+		return offset, w.Source.AddColumn(ctx, true, false, aeWrap(&sqlparser.WeightStringFuncExpr{Expr: expr}))
+	}
+
+	for _, expr := range w.Spec.PartitionClause {
+		offset, wsOffset := addColumn(expr)
+		w.PartitionOffsets = append(w.PartitionOffsets, offset)
+		w.PartitionWSOffsets = append(w.PartitionWSOffsets, wsOffset)
+	}
+	for _, order := range w.Spec.OrderClause {
+		offset, wsOffset := addColumn(order.Expr)
+		w.OrderOffsets = append(w.OrderOffsets, offset)
+		w.OrderWSOffsets = append(w.OrderWSOffsets, wsOffset)
+	}
+	for i, f := range w.Funcs {
+		if f.Arg == nil {
+			continue
+		}
+		w.Funcs[i].ColOffset = w.Source.AddColumn(ctx, true, false, aeWrap(f.Arg))
+	}
+	return nil
+}
+
+func (w *Window) ShortDescription() string {
+	funcs := slice.Map(w.Funcs, func(f WindowFunc) string {
+		return sqlparser.String(f.Original)
+	})
+	return strings.Join(funcs, ", ")
+}
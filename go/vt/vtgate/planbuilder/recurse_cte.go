@@ -0,0 +1,44 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package planbuilder
+
+import (
+	"vitess.io/vitess/go/vt/vtgate/engine"
+)
+
+var _ logicalPlan = (*recurseCTE)(nil)
+
+// recurseCTE is used to build a RecurseCTE primitive.
+// It evaluates a recursive common table expression
+// that can't be sent to a single shard.
+type recurseCTE struct {
+	// Seed and Term are the non-recursive and the recursive parts of the CTE
+	Seed, Term logicalPlan
+
+	// Vars are the columns of the previous iteration that are sent to the Term
+	// the number is the offset on the result, and the string is the bind variable name used in the Term
+	Vars map[string]int
+}
+
+// Primitive implements the logicalPlan interface
+func (r *recurseCTE) Primitive() engine.Primitive {
+	return &engine.RecurseCTE{
+		Seed: r.Seed.Primitive(),
+		Term: r.Term.Primitive(),
+		Vars: r.Vars,
+	}
+}
@@ -77,6 +77,8 @@ var (
 	mysqlDefaultWorkload     int32
 
 	mysqlServerFlushDelay = 100 * time.Millisecond
+
+	mysqlServerCompressionAlgorithms string
 )
 
 func registerPluginFlags(fs *pflag.FlagSet) {
@@ -101,6 +103,7 @@ func registerPluginFlags(fs *pflag.FlagSet) {
 	fs.BoolVar(&mysqlConnBufferPooling, "mysql-server-pool-conn-read-buffers", mysqlConnBufferPooling, "If set, the server will pool incoming connection read buffers")
 	fs.DurationVar(&mysqlKeepAlivePeriod, "mysql-server-keepalive-period", mysqlKeepAlivePeriod, "TCP period between keep-alives")
 	fs.DurationVar(&mysqlServerFlushDelay, "mysql_server_flush_delay", mysqlServerFlushDelay, "Delay after which buffered response will be flushed to the client.")
+	fs.StringVar(&mysqlServerCompressionAlgorithms, "mysql-server-compression-algorithms", mysqlServerCompressionAlgorithms, "Comma separated list of the protocol compression algorithms the MySQL server accepts from clients on its TCP listener. Options: zlib, zstd. Clients can always connect uncompressed.")
 	fs.StringVar(&mysqlDefaultWorkloadName, "mysql_default_workload", mysqlDefaultWorkloadName, "Default session workload (OLTP, OLAP, DBA)")
 }
 
@@ -147,6 +150,14 @@ func (vh *vtgateHandler) ComResetConnection(c *mysql.Conn) {
 	}
 }
 
+// ComChangeUser is part of the mysql.Handler interface. The session of the
+// previous user is closed, and the new user starts with a fresh one.
+func (vh *vtgateHandler) ComChangeUser(c *mysql.Conn) {
+	vh.ComResetConnection(c)
+	c.ClientData = nil
+	fillInTxStatusFlags(c, vh.session(c))
+}
+
 func (vh *vtgateHandler) ConnectionClosed(c *mysql.Conn) {
 	// Rollback if there is an ongoing transaction. Ignore error.
 	defer func() {
@@ -256,13 +267,29 @@ func (vh *vtgateHandler) ComQuery(c *mysql.Conn, query string, callback func(*sq
 		fillInTxStatusFlags(c, session)
 		return nil
 	}
+	token := session.GetReadAfterWrite().GetSessionToken()
 	session, result, err := vh.vtg.Execute(ctx, vh, session, query, make(map[string]*querypb.BindVariable))
 
 	if err := sqlerror.NewSQLErrorFromError(err); err != nil {
 		return err
 	}
 	fillInTxStatusFlags(c, session)
-	return callback(result)
+	return callback(withSessionToken(c, session, token, result))
+}
+
+// withSessionToken returns the result with the session token of the session
+// as the GTIDs of its session state changes, if the query changed the token
+// and the session tracks it with session_track_gtids.
+func withSessionToken(c *mysql.Conn, session *vtgatepb.Session, token string, result *sqltypes.Result) *sqltypes.Result {
+	c.StatusFlags &^= mysql.ServerSessionStateChanged
+	raw := session.GetReadAfterWrite()
+	if !raw.GetSessionTrackGtids() || raw.GetSessionToken() == token || c.Capabilities&mysql.CapabilityClientSessionTrack == 0 {
+		return result
+	}
+	c.StatusFlags |= mysql.ServerSessionStateChanged
+	withToken := *result
+	withToken.SessionStateChanges = raw.SessionToken
+	return &withToken
 }
 
 func fillInTxStatusFlags(c *mysql.Conn, session *vtgatepb.Session) {
@@ -371,6 +398,52 @@ func (vh *vtgateHandler) ComStmtExecute(c *mysql.Conn, prepare *mysql.PrepareDat
 	return callback(qr)
 }
 
+// ComStmtExecuteCursor is part of the mysql.Handler interface. The results
+// of statements executed with a cursor are always streamed, whatever the
+// workload of the session, so the client can fetch large result sets in
+// batches.
+func (vh *vtgateHandler) ComStmtExecuteCursor(c *mysql.Conn, prepare *mysql.PrepareData, callback func(*sqltypes.Result) error) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.UpdateCancelCtx(cancel)
+
+	if mysqlQueryTimeout != 0 {
+		ctx, cancel = context.WithTimeout(ctx, mysqlQueryTimeout)
+		defer cancel()
+	}
+
+	ctx = callinfo.MysqlCallInfo(ctx, c)
+
+	// Fill in the ImmediateCallerID with the UserData returned by
+	// the AuthServer plugin for that user. If nothing was
+	// returned, use the User. This lets the plugin map a MySQL
+	// user used for authentication to a Vitess User used for
+	// Table ACLs and Vitess authentication in general.
+	im := c.UserData.Get()
+	ef := callerid.NewEffectiveCallerID(
+		c.User,                  /* principal: who */
+		c.RemoteAddr().String(), /* component: running client process */
+		"VTGate MySQL Connector" /* subcomponent: part of the client */)
+	ctx = callerid.NewContext(ctx, ef, im)
+
+	session := vh.session(c)
+	if !session.InTransaction {
+		vh.busyConnections.Add(1)
+	}
+	defer func() {
+		if !session.InTransaction {
+			vh.busyConnections.Add(-1)
+		}
+	}()
+
+	// This runs in its own go routine while the client fetches the rows,
+	// so the status flags of the connection are left alone.
+	_, err := vh.vtg.StreamExecute(ctx, vh, session, prepare.PrepareStmt, prepare.BindVars, callback)
+	if err != nil {
+		return sqlerror.NewSQLErrorFromError(err)
+	}
+	return nil
+}
+
 func (vh *vtgateHandler) WarningCount(c *mysql.Conn) uint16 {
 	return uint16(len(vh.session(c).GetWarnings()))
 }
@@ -539,6 +612,9 @@ func initMySQLProtocol(vtgate *VTGate) *mysqlServer {
 		if err != nil {
 			log.Exitf("mysql.NewListener failed: %v", err)
 		}
+		if err := srv.tcpListener.SetCompressionAlgorithms(mysqlServerCompressionAlgorithms); err != nil {
+			log.Exitf("mysql.NewListener failed: %v", err)
+		}
 		if mysqlSslCert != "" && mysqlSslKey != "" {
 			tlsVersion, err := vttls.TLSVersionToNumber(mysqlTLSMinVersion)
 			if err != nil {
@@ -36,6 +36,7 @@ import (
 	"vitess.io/vitess/go/test/utils"
 	"vitess.io/vitess/go/trace"
 	querypb "vitess.io/vitess/go/vt/proto/query"
+	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
 	"vitess.io/vitess/go/vt/tlstest"
 	"vitess.io/vitess/go/vt/vtenv"
 )
@@ -267,6 +268,26 @@ func TestDefaultWorkloadOLAP(t *testing.T) {
 	}
 }
 
+func TestWithSessionToken(t *testing.T) {
+	c := &mysql.Conn{Capabilities: mysql.CapabilityClientSessionTrack}
+	session := &vtgatepb.Session{ReadAfterWrite: &vtgatepb.ReadAfterWrite{SessionToken: "token2"}}
+	result := &sqltypes.Result{RowsAffected: 1}
+
+	// The session does not track its token.
+	assert.Same(t, result, withSessionToken(c, session, "token1", result))
+	assert.Zero(t, c.StatusFlags&mysql.ServerSessionStateChanged)
+
+	session.ReadAfterWrite.SessionTrackGtids = true
+	withToken := withSessionToken(c, session, "token1", result)
+	assert.Equal(t, &sqltypes.Result{RowsAffected: 1, SessionStateChanges: "token2"}, withToken)
+	assert.NotZero(t, c.StatusFlags&mysql.ServerSessionStateChanged)
+	assert.Empty(t, result.SessionStateChanges)
+
+	// The token did not change.
+	assert.Same(t, result, withSessionToken(c, session, "token2", result))
+	assert.Zero(t, c.StatusFlags&mysql.ServerSessionStateChanged)
+}
+
 func TestInitTLSConfigWithoutServerCA(t *testing.T) {
 	testInitTLSConfig(t, false)
 }
@@ -422,3 +443,53 @@ func TestGracefulShutdownWithTransaction(t *testing.T) {
 
 	require.True(t, mysqlConn.IsMarkedForClose())
 }
+
+func TestComChangeUser(t *testing.T) {
+	executor, _, _, _, _ := createExecutorEnv(t)
+	vh := newVtgateHandler(&VTGate{executor: executor, timings: timings, rowsReturned: rowsReturned, rowsAffected: rowsAffected})
+
+	listener, err := mysql.NewListener("tcp", "127.0.0.1:", mysql.NewAuthServerNone(), &testHandler{}, 0, 0, false, false, 0, 0)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	mysqlConn := mysql.GetTestServerConn(listener)
+	mysqlConn.UserData = &mysql.StaticUserData{}
+
+	err = vh.ComQuery(mysqlConn, "BEGIN", func(result *sqltypes.Result) error {
+		return nil
+	})
+	require.NoError(t, err)
+	session := vh.session(mysqlConn)
+	require.True(t, session.InTransaction)
+	require.NotZero(t, mysqlConn.StatusFlags&mysql.ServerStatusInTrans)
+
+	// The new user starts with a new session, out of the transaction.
+	vh.ComChangeUser(mysqlConn)
+	assert.NotSame(t, session, vh.session(mysqlConn))
+	assert.False(t, vh.session(mysqlConn).InTransaction)
+	assert.Zero(t, mysqlConn.StatusFlags&mysql.ServerStatusInTrans)
+	assert.NotZero(t, mysqlConn.StatusFlags&mysql.ServerStatusAutocommit)
+}
+
+func TestComStmtExecuteCursor(t *testing.T) {
+	executor, _, _, _, _ := createExecutorEnv(t)
+	vh := newVtgateHandler(&VTGate{executor: executor, timings: timings, rowsReturned: rowsReturned, rowsAffected: rowsAffected})
+
+	listener, err := mysql.NewListener("tcp", "127.0.0.1:", mysql.NewAuthServerNone(), &testHandler{}, 0, 0, false, false, 0, 0)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	mysqlConn := mysql.GetTestServerConn(listener)
+	mysqlConn.UserData = &mysql.StaticUserData{}
+
+	// The results are streamed, even with the OLTP workload.
+	mysqlDefaultWorkload = int32(querypb.ExecuteOptions_OLTP)
+	var results []*sqltypes.Result
+	err = vh.ComStmtExecuteCursor(mysqlConn, &mysql.PrepareData{PrepareStmt: "select id from user where id = 1"}, func(result *sqltypes.Result) error {
+		results = append(results, result)
+		return nil
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	assert.NotEmpty(t, results[0].Fields)
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/logstats"
+)
+
+const (
+	resourceLimitMemory      = "memory"
+	resourceLimitFetchedRows = "fetched_rows"
+)
+
+// queryBudget keeps track of the resources used by a query in vtgate, and
+// aborts the query once it uses more than it is allowed to: the memory
+// held by the primitives evaluated in vtgate, and the rows fetched from
+// the tablets. A limit of 0 means no limit.
+type queryBudget struct {
+	maxMemory      int64
+	maxFetchedRows int64
+
+	memory      atomic.Int64
+	fetchedRows atomic.Int64
+
+	logStats *logstats.LogStats
+	exceeded sync.Once
+}
+
+func newQueryBudget(logStats *logstats.LogStats) *queryBudget {
+	return &queryBudget{
+		maxMemory:      maxQueryMemory,
+		maxFetchedRows: int64(maxQueryFetchedRows),
+		logStats:       logStats,
+	}
+}
+
+// reserveMemory adds size bytes to the memory used by the query, and
+// returns an error if it is now more than allowed.
+func (qb *queryBudget) reserveMemory(size int64) error {
+	if qb == nil {
+		return nil
+	}
+	memory := qb.memory.Add(size)
+	if qb.maxMemory > 0 && memory > qb.maxMemory {
+		qb.recordExceeded(resourceLimitMemory)
+		return vterrors.VT08001(qb.maxMemory)
+	}
+	return nil
+}
+
+// releaseMemory removes size bytes from the memory used by the query.
+func (qb *queryBudget) releaseMemory(size int64) {
+	if qb == nil {
+		return
+	}
+	qb.memory.Add(-size)
+}
+
+// addFetchedRows adds rows to the rows fetched by the query, and returns
+// an error if they are now more than allowed.
+func (qb *queryBudget) addFetchedRows(rows int) error {
+	if qb == nil {
+		return nil
+	}
+	fetched := qb.fetchedRows.Add(int64(rows))
+	if qb.maxFetchedRows > 0 && fetched > qb.maxFetchedRows {
+		qb.recordExceeded(resourceLimitFetchedRows)
+		return vterrors.VT08002(qb.maxFetchedRows)
+	}
+	return nil
+}
+
+// recordExceeded records the first limit the query exceeded in its logstats.
+func (qb *queryBudget) recordExceeded(limit string) {
+	qb.exceeded.Do(func() {
+		if qb.logStats != nil {
+			qb.logStats.ResourceLimit = limit
+		}
+	})
+}
@@ -0,0 +1,430 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"vitess.io/vitess/go/cache"
+	"vitess.io/vitess/go/stats"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vterrors"
+
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+const (
+	quotaKindUser     = "user"
+	quotaKindWorkload = "workload"
+
+	// maxDefaultQueryQuotas is the number of users, and of workloads, whose
+	// default quota is kept. The least recently used ones are evicted past it.
+	maxDefaultQueryQuotas = 10000
+
+	// defaultQueryQuotaLabel is the name the metrics count the default quotas
+	// under, so that they do not get a label per user or workload.
+	defaultQueryQuotaLabel = "(default)"
+)
+
+var (
+	queryQuotaWaits      = stats.NewCountersWithMultiLabels("QueryQuotaWaits", "Queries that waited for the quota of their user or workload", []string{"Kind", "Name"})
+	queryQuotaRejections = stats.NewCountersWithMultiLabels("QueryQuotaRejections", "Queries rejected because they exceeded the quota of their user or workload", []string{"Kind", "Name"})
+)
+
+// QueryQuota limits the queries of a user or a workload.
+type QueryQuota struct {
+	// MaxConcurrency is the maximum number of queries executing concurrently,
+	// 0 for no limit.
+	MaxConcurrency int `json:"max_concurrency,omitempty"`
+	// MaxQPS is the maximum number of queries per second, 0 for no limit.
+	MaxQPS float64 `json:"max_qps,omitempty"`
+	// MaxQueueSize is the maximum number of queries waiting for one of the
+	// MaxConcurrency slots or for the MaxQPS rate, 0 to reject the queries
+	// right away.
+	MaxQueueSize int `json:"max_queue_size,omitempty"`
+}
+
+// queryQuotasConfig is the content of --query-quotas-file.
+type queryQuotasConfig struct {
+	// DefaultUser is the quota of each user without one in Users.
+	DefaultUser *QueryQuota            `json:"default_user,omitempty"`
+	Users       map[string]*QueryQuota `json:"users,omitempty"`
+	// DefaultWorkload is the quota of each workload without one in
+	// Workloads.
+	DefaultWorkload *QueryQuota            `json:"default_workload,omitempty"`
+	Workloads       map[string]*QueryQuota `json:"workloads,omitempty"`
+}
+
+// queryQuotas admits the queries vtgate executes, by the quotas of their
+// caller and of their workload, as set by the WORKLOAD_NAME comment directive.
+type queryQuotas struct {
+	config       queryQuotasConfig
+	queueTimeout time.Duration
+
+	mu sync.Mutex
+	// users and workloads are the quotas of the users and workloads of the
+	// config.
+	users     map[string]*queryQuota
+	workloads map[string]*queryQuota
+	// defaultUsers and defaultWorkloads are the default quotas of the
+	// other users and workloads, of which only the maxDefaultQueryQuotas
+	// most recently used are kept.
+	defaultUsers     *cache.LRUCache[*queryQuota]
+	defaultWorkloads *cache.LRUCache[*queryQuota]
+}
+
+// queryQuota is the state of the quota of one user or workload.
+type queryQuota struct {
+	kind, name string
+	// label is the name of the quota in the metrics.
+	label  string
+	config QueryQuota
+
+	// slots holds a value for each executing query, when the quota has a
+	// max concurrency.
+	slots   chan struct{}
+	limiter *rate.Limiter
+	queued  atomic.Int64
+
+	admitted atomic.Int64
+	waits    atomic.Int64
+	rejected atomic.Int64
+}
+
+// loadQueryQuotas reads the query quotas from the JSON file.
+func loadQueryQuotas(path string, queueTimeout time.Duration) (*queryQuotas, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var config queryQuotasConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, vterrors.Wrapf(err, "invalid query quotas file %s", path)
+	}
+	return newQueryQuotas(config, queueTimeout), nil
+}
+
+func newQueryQuotas(config queryQuotasConfig, queueTimeout time.Duration) *queryQuotas {
+	return &queryQuotas{
+		config:           config,
+		queueTimeout:     queueTimeout,
+		users:            make(map[string]*queryQuota),
+		workloads:        make(map[string]*queryQuota),
+		defaultUsers:     cache.NewLRUCache[*queryQuota](maxDefaultQueryQuotas),
+		defaultWorkloads: cache.NewLRUCache[*queryQuota](maxDefaultQueryQuotas),
+	}
+}
+
+func newQueryQuota(kind, name string, config QueryQuota) *queryQuota {
+	qq := &queryQuota{kind: kind, name: name, label: name, config: config}
+	if config.MaxConcurrency > 0 {
+		qq.slots = make(chan struct{}, config.MaxConcurrency)
+	}
+	if config.MaxQPS > 0 {
+		burst := int(config.MaxQPS)
+		if burst < 1 {
+			burst = 1
+		}
+		qq.limiter = rate.NewLimiter(rate.Limit(config.MaxQPS), burst)
+	}
+	return qq
+}
+
+// quota returns the quota of the user or workload, or nil if it has none.
+// Nothing is kept for the users and workloads without a quota.
+func (qqs *queryQuotas) quota(kind, name string) *queryQuota {
+	quotas, defaults, configs, defaultConfig := qqs.users, qqs.defaultUsers, qqs.config.Users, qqs.config.DefaultUser
+	if kind == quotaKindWorkload {
+		quotas, defaults, configs, defaultConfig = qqs.workloads, qqs.defaultWorkloads, qqs.config.Workloads, qqs.config.DefaultWorkload
+	}
+
+	if config, ok := configs[name]; ok {
+		if config == nil {
+			return nil
+		}
+		qqs.mu.Lock()
+		defer qqs.mu.Unlock()
+		qq, ok := quotas[name]
+		if !ok {
+			qq = newQueryQuota(kind, name, *config)
+			quotas[name] = qq
+		}
+		return qq
+	}
+	if defaultConfig == nil {
+		return nil
+	}
+	// An evicted default quota starts over the next time it is used, while
+	// the queries it admitted still hold its slots.
+	qqs.mu.Lock()
+	defer qqs.mu.Unlock()
+	qq, ok := defaults.Get(name)
+	if !ok {
+		qq = newQueryQuota(kind, name, *defaultConfig)
+		qq.label = defaultQueryQuotaLabel
+		defaults.Set(name, qq)
+	}
+	return qq
+}
+
+// admit waits until the quotas of the user and of the workload let the query
+// execute, or returns an error if they do not within the queue timeout. The
+// release function must be called once the query is done.
+func (qqs *queryQuotas) admit(ctx context.Context, user, workload string) (release func(), err error) {
+	if qqs == nil {
+		return func() {}, nil
+	}
+	releaseUser, err := qqs.quota(quotaKindUser, user).admit(ctx, qqs.queueTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if workload == "" {
+		return releaseUser, nil
+	}
+	releaseWorkload, err := qqs.quota(quotaKindWorkload, workload).admit(ctx, qqs.queueTimeout)
+	if err != nil {
+		releaseUser()
+		return nil, err
+	}
+	return func() {
+		releaseWorkload()
+		releaseUser()
+	}, nil
+}
+
+// exemptFromQueryQuotas returns whether the query is executed without being
+// admitted by the quotas: the transaction control statements, and the queries
+// of a transaction already open on the shards, so that a transaction that
+// holds locks is never stuck while its next statements wait for the quota.
+// The first query of a transaction, after a BEGIN or in a session without
+// autocommit, is still admitted by the quotas, so that they cannot be avoided
+// by running the queries in transactions.
+func exemptFromQueryQuotas(safeSession *SafeSession, stmt sqlparser.Statement) bool {
+	switch stmt.(type) {
+	case *sqlparser.Begin, *sqlparser.Commit, *sqlparser.Rollback, *sqlparser.SRollback, *sqlparser.Savepoint, *sqlparser.Release:
+		return true
+	}
+	return safeSession.isTxOpen()
+}
+
+// workloadName returns the workload of the query, as set by its WORKLOAD_NAME
+// comment directive or by an earlier query of the session.
+func workloadName(safeSession *SafeSession, stmt sqlparser.Statement) string {
+	if name := sqlparser.GetWorkloadNameFromStatement(stmt); name != "" {
+		return name
+	}
+	return safeSession.GetOptions().GetWorkloadName()
+}
+
+func (qq *queryQuota) admit(ctx context.Context, queueTimeout time.Duration) (release func(), err error) {
+	if qq == nil {
+		return func() {}, nil
+	}
+	// The concurrency is checked first, so that the queries it rejects do
+	// not use up the rate of the quota. The query waits for both within the
+	// queue timeout.
+	deadline := time.Now().Add(queueTimeout)
+	release = func() {}
+	if qq.slots != nil {
+		if err := qq.acquire(ctx, queueTimeout); err != nil {
+			return nil, err
+		}
+		release = func() { <-qq.slots }
+	}
+	if qq.limiter != nil {
+		if err := qq.waitRate(ctx, time.Until(deadline)); err != nil {
+			release()
+			return nil, err
+		}
+	}
+	qq.admitted.Add(1)
+	return release, nil
+}
+
+// waitRate waits for the rate limiter, unless the wait is longer than the
+// queue timeout or the queue is full.
+func (qq *queryQuota) waitRate(ctx context.Context, queueTimeout time.Duration) error {
+	r := qq.limiter.Reserve()
+	delay := r.Delay()
+	if delay == 0 {
+		return nil
+	}
+	if delay > queueTimeout {
+		r.Cancel()
+		return qq.reject(fmt.Sprintf("%v queries per second", qq.config.MaxQPS))
+	}
+	if qq.queued.Add(1) > int64(qq.config.MaxQueueSize) {
+		qq.queued.Add(-1)
+		r.Cancel()
+		return qq.reject(fmt.Sprintf("%v queries per second", qq.config.MaxQPS))
+	}
+	defer qq.queued.Add(-1)
+	qq.wait()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		r.Cancel()
+		return ctx.Err()
+	}
+}
+
+// acquire takes one of the concurrency slots, waiting in the queue if they
+// are all taken.
+func (qq *queryQuota) acquire(ctx context.Context, queueTimeout time.Duration) error {
+	select {
+	case qq.slots <- struct{}{}:
+		return nil
+	default:
+	}
+	if qq.queued.Add(1) > int64(qq.config.MaxQueueSize) {
+		qq.queued.Add(-1)
+		return qq.reject(fmt.Sprintf("%d concurrent queries", qq.config.MaxConcurrency))
+	}
+	defer qq.queued.Add(-1)
+	qq.wait()
+
+	timer := time.NewTimer(queueTimeout)
+	defer timer.Stop()
+	select {
+	case qq.slots <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return qq.reject(fmt.Sprintf("%d concurrent queries", qq.config.MaxConcurrency))
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (qq *queryQuota) wait() {
+	qq.waits.Add(1)
+	queryQuotaWaits.Add([]string{qq.kind, qq.label}, 1)
+}
+
+func (qq *queryQuota) reject(limit string) error {
+	qq.rejected.Add(1)
+	queryQuotaRejections.Add([]string{qq.kind, qq.label}, 1)
+	return vterrors.Errorf(vtrpcpb.Code_RESOURCE_EXHAUSTED, "query quota of %s %s exceeded: %s", qq.kind, qq.name, limit)
+}
+
+// QueryQuotaStatus is the status of the quota of a user or workload.
+type QueryQuotaStatus struct {
+	Kind           string
+	Name           string
+	MaxConcurrency int
+	MaxQPS         float64
+	MaxQueueSize   int
+	Concurrency    int
+	Queued         int64
+	Admitted       int64
+	Waits          int64
+	Rejected       int64
+}
+
+// status returns the status of the quotas of the users and workloads that
+// executed queries.
+func (qqs *queryQuotas) status() []*QueryQuotaStatus {
+	if qqs == nil {
+		return nil
+	}
+	qqs.mu.Lock()
+	defer qqs.mu.Unlock()
+	var quotas []*queryQuota
+	for _, qq := range qqs.users {
+		quotas = append(quotas, qq)
+	}
+	for _, qq := range qqs.workloads {
+		quotas = append(quotas, qq)
+	}
+	for _, defaults := range []*cache.LRUCache[*queryQuota]{qqs.defaultUsers, qqs.defaultWorkloads} {
+		for _, item := range defaults.Items() {
+			quotas = append(quotas, item.Value)
+		}
+	}
+	status := make([]*QueryQuotaStatus, 0, len(quotas))
+	for _, qq := range quotas {
+		status = append(status, &QueryQuotaStatus{
+			Kind:           qq.kind,
+			Name:           qq.name,
+			MaxConcurrency: qq.config.MaxConcurrency,
+			MaxQPS:         qq.config.MaxQPS,
+			MaxQueueSize:   qq.config.MaxQueueSize,
+			Concurrency:    len(qq.slots),
+			Queued:         qq.queued.Load(),
+			Admitted:       qq.admitted.Load(),
+			Waits:          qq.waits.Load(),
+			Rejected:       qq.rejected.Load(),
+		})
+	}
+	sort.Slice(status, func(i, j int) bool {
+		if status[i].Kind != status[j].Kind {
+			return status[i].Kind < status[j].Kind
+		}
+		return status[i].Name < status[j].Name
+	})
+	return status
+}
+
+// QueryQuotasTemplate is the HTML template to display the QueryQuotaStatus.
+const QueryQuotasTemplate = `
+<style>
+  table {
+    border-collapse: collapse;
+  }
+  td, th {
+    border: 1px solid #999;
+    padding: 0.2rem;
+  }
+</style>
+{{if not .}}No queries were admitted by a quota of <i>--query-quotas-file</i>.{{else}}<table>
+  <tr>
+    <th>Kind</th>
+    <th>Name</th>
+    <th>Max Concurrency</th>
+    <th>Max QPS</th>
+    <th>Max Queue Size</th>
+    <th>Concurrency</th>
+    <th>Queued</th>
+    <th>Admitted</th>
+    <th>Waits</th>
+    <th>Rejected</th>
+  </tr>
+{{range $i, $qq := .}}  <tr>
+    <td>{{$qq.Kind}}</td>
+    <td>{{$qq.Name}}</td>
+    <td>{{$qq.MaxConcurrency}}</td>
+    <td>{{$qq.MaxQPS}}</td>
+    <td>{{$qq.MaxQueueSize}}</td>
+    <td>{{$qq.Concurrency}}</td>
+    <td>{{$qq.Queued}}</td>
+    <td>{{$qq.Admitted}}</td>
+    <td>{{$qq.Waits}}</td>
+    <td>{{if $qq.Rejected}}<span style="color:red">{{$qq.Rejected}}</span>{{else}}0{{end}}</td>
+  </tr>{{end}}
+</table>{{end}}
+`
@@ -0,0 +1,273 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/cache"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/callerid"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
+)
+
+func TestQueryQuotasConcurrency(t *testing.T) {
+	qqs := newQueryQuotas(queryQuotasConfig{
+		Users: map[string]*QueryQuota{"app": {MaxConcurrency: 1, MaxQueueSize: 1}},
+	}, 100*time.Millisecond)
+	ctx := context.Background()
+
+	release1, err := qqs.admit(ctx, "app", "")
+	require.NoError(t, err)
+
+	// The second query waits in the queue until the first one is done.
+	admitted := make(chan func())
+	go func() {
+		release, err := qqs.admit(ctx, "app", "")
+		assert.NoError(t, err)
+		admitted <- release
+	}()
+	require.Eventually(t, func() bool {
+		return qqs.quota(quotaKindUser, "app").queued.Load() == 1
+	}, time.Second, time.Millisecond)
+
+	// The queue is full.
+	_, err = qqs.admit(ctx, "app", "")
+	require.EqualError(t, err, "query quota of user app exceeded: 1 concurrent queries")
+
+	release1()
+	release2 := <-admitted
+
+	// The queued queries are rejected after the queue timeout.
+	_, err = qqs.admit(ctx, "app", "")
+	require.EqualError(t, err, "query quota of user app exceeded: 1 concurrent queries")
+	release2()
+
+	// The other users have no quota.
+	for i := 0; i < 3; i++ {
+		_, err = qqs.admit(ctx, "other", "")
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, []*QueryQuotaStatus{{
+		Kind:           quotaKindUser,
+		Name:           "app",
+		MaxConcurrency: 1,
+		MaxQueueSize:   1,
+		Admitted:       2,
+		Waits:          2,
+		Rejected:       2,
+	}}, qqs.status())
+}
+
+func TestQueryQuotasQPS(t *testing.T) {
+	qqs := newQueryQuotas(queryQuotasConfig{
+		DefaultUser: &QueryQuota{MaxQPS: 1, MaxQueueSize: 1},
+	}, 0)
+	ctx := context.Background()
+
+	// Each user has its own quota.
+	_, err := qqs.admit(ctx, "app1", "")
+	require.NoError(t, err)
+	_, err = qqs.admit(ctx, "app2", "")
+	require.NoError(t, err)
+
+	_, err = qqs.admit(ctx, "app1", "")
+	require.EqualError(t, err, "query quota of user app1 exceeded: 1 queries per second")
+
+	// The queries wait for the rate limiter up to the queue timeout.
+	qqs.queueTimeout = 5 * time.Second
+	_, err = qqs.admit(ctx, "app2", "")
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, qqs.quota(quotaKindUser, "app2").waits.Load())
+
+	// The queries waiting for the rate limiter count against the queue size.
+	qq := qqs.quota(quotaKindUser, "app2")
+	qq.queued.Add(1)
+	_, err = qqs.admit(ctx, "app2", "")
+	require.EqualError(t, err, "query quota of user app2 exceeded: 1 queries per second")
+	qq.queued.Add(-1)
+}
+
+func TestQueryQuotasQPSAndConcurrency(t *testing.T) {
+	qqs := newQueryQuotas(queryQuotasConfig{
+		DefaultUser: &QueryQuota{MaxQPS: 1, MaxConcurrency: 1},
+	}, 0)
+	ctx := context.Background()
+
+	// The queries rejected for their concurrency do not use up the rate of
+	// the quota.
+	qq := qqs.quota(quotaKindUser, "app1")
+	qq.slots <- struct{}{}
+	_, err := qqs.admit(ctx, "app1", "")
+	require.EqualError(t, err, "query quota of user app1 exceeded: 1 concurrent queries")
+	<-qq.slots
+	release, err := qqs.admit(ctx, "app1", "")
+	require.NoError(t, err)
+	release()
+
+	// The slot is given back when the rate rejects the query.
+	_, err = qqs.admit(ctx, "app1", "")
+	require.EqualError(t, err, "query quota of user app1 exceeded: 1 queries per second")
+	assert.Empty(t, qq.slots)
+}
+
+func TestQueryQuotasWorkload(t *testing.T) {
+	qqs := newQueryQuotas(queryQuotasConfig{
+		DefaultUser:     &QueryQuota{MaxConcurrency: 1},
+		DefaultWorkload: &QueryQuota{MaxConcurrency: 1},
+	}, 0)
+	ctx := context.Background()
+
+	release, err := qqs.admit(ctx, "app1", "reports")
+	require.NoError(t, err)
+
+	// The slot of the user is given back when the workload rejects the
+	// query.
+	_, err = qqs.admit(ctx, "app2", "reports")
+	require.EqualError(t, err, "query quota of workload reports exceeded: 1 concurrent queries")
+	_, err = qqs.admit(ctx, "app2", "batch")
+	require.NoError(t, err)
+
+	release()
+	_, err = qqs.admit(ctx, "app1", "reports")
+	require.NoError(t, err)
+}
+
+func TestQueryQuotasKept(t *testing.T) {
+	qqs := newQueryQuotas(queryQuotasConfig{
+		Users:           map[string]*QueryQuota{"app": {MaxConcurrency: 1}},
+		DefaultWorkload: &QueryQuota{MaxConcurrency: 1},
+	}, 0)
+	qqs.defaultWorkloads = cache.NewLRUCache[*queryQuota](2)
+	ctx := context.Background()
+
+	// Nothing is kept for the users without a quota, and only the most
+	// recently used default quotas are kept.
+	for i := 0; i < 5; i++ {
+		release, err := qqs.admit(ctx, fmt.Sprintf("user%d", i), fmt.Sprintf("workload%d", i))
+		require.NoError(t, err)
+		release()
+	}
+	release, err := qqs.admit(ctx, "app", "")
+	require.NoError(t, err)
+	release()
+
+	assert.Len(t, qqs.users, 1)
+	assert.Zero(t, qqs.defaultUsers.Len())
+	var names []string
+	for _, status := range qqs.status() {
+		names = append(names, status.Kind+" "+status.Name)
+	}
+	assert.Equal(t, []string{"user app", "workload workload3", "workload workload4"}, names)
+
+	// The metrics count all the default quotas under the same name.
+	rejections := queryQuotaRejections.Counts()["workload."+defaultQueryQuotaLabel]
+	for _, workload := range []string{"workload3", "workload4"} {
+		_, err = qqs.quota(quotaKindWorkload, workload).admit(ctx, 0)
+		require.NoError(t, err)
+		_, err = qqs.quota(quotaKindWorkload, workload).admit(ctx, 0)
+		require.Error(t, err)
+	}
+	assert.EqualValues(t, rejections+2, queryQuotaRejections.Counts()["workload."+defaultQueryQuotaLabel])
+}
+
+func TestLoadQueryQuotas(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quotas.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"default_user": {"max_concurrency": 10},
+		"users": {"batch": {"max_concurrency": 2, "max_qps": 5, "max_queue_size": 10}},
+		"workloads": {"reports": {"max_qps": 0.5}}
+	}`), 0o600))
+
+	qqs, err := loadQueryQuotas(path, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, queryQuotasConfig{
+		DefaultUser: &QueryQuota{MaxConcurrency: 10},
+		Users:       map[string]*QueryQuota{"batch": {MaxConcurrency: 2, MaxQPS: 5, MaxQueueSize: 10}},
+		Workloads:   map[string]*QueryQuota{"reports": {MaxQPS: 0.5}},
+	}, qqs.config)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"users": []}`), 0o600))
+	_, err = loadQueryQuotas(path, time.Second)
+	assert.ErrorContains(t, err, "invalid query quotas file")
+}
+
+func TestExecutorQueryQuotas(t *testing.T) {
+	executor, _, _, _, ctx := createExecutorEnv(t)
+	executor.queryQuotas = newQueryQuotas(queryQuotasConfig{
+		Users:     map[string]*QueryQuota{"batch": {MaxQPS: 0.001}, "tx": {MaxQPS: 0.001}, "noautocommit": {MaxQPS: 0.001}},
+		Workloads: map[string]*QueryQuota{"reports": {MaxQPS: 0.001}},
+	}, 0)
+	batchCtx := callerid.NewContext(ctx, nil, &querypb.VTGateCallerID{Username: "batch"})
+	appCtx := callerid.NewContext(ctx, nil, &querypb.VTGateCallerID{Username: "app"})
+	session := &vtgatepb.Session{TargetString: "@primary", Autocommit: true}
+
+	_, err := executorExec(batchCtx, executor, session, "select id from user where id = 1", nil)
+	require.NoError(t, err)
+	_, err = executorExec(batchCtx, executor, session, "select id from user where id = 1", nil)
+	require.EqualError(t, err, "query quota of user batch exceeded: 0.001 queries per second")
+
+	_, err = executorExec(appCtx, executor, session, "select /*vt+ WORKLOAD_NAME=reports */ id from user where id = 1", nil)
+	require.NoError(t, err)
+	_, err = executorExec(appCtx, executor, session, "select /*vt+ WORKLOAD_NAME=reports */ id from user where id = 1", nil)
+	require.EqualError(t, err, "query quota of workload reports exceeded: 0.001 queries per second")
+
+	// The streaming queries are limited too.
+	err = executor.StreamExecute(appCtx, nil, "TestExecutorQueryQuotas", NewSafeSession(session), "select /*vt+ WORKLOAD_NAME=reports */ id from user where id = 1", nil, func(*sqltypes.Result) error { return nil })
+	require.EqualError(t, err, "query quota of workload reports exceeded: 0.001 queries per second")
+
+	// The workload is kept by the session.
+	_, err = executorExec(appCtx, executor, session, "select id from user where id = 1", nil)
+	require.EqualError(t, err, "query quota of workload reports exceeded: 0.001 queries per second")
+	_, err = executorExec(appCtx, executor, &vtgatepb.Session{TargetString: "@primary", Autocommit: true}, "select id from user where id = 1", nil)
+	require.NoError(t, err)
+
+	// The transaction control statements and the queries of a transaction
+	// open on the shards are not limited, but the first query of a
+	// transaction is.
+	txCtx := callerid.NewContext(ctx, nil, &querypb.VTGateCallerID{Username: "tx"})
+	session = &vtgatepb.Session{TargetString: "@primary", Autocommit: true}
+	for _, query := range []string{"begin", "select id from user where id = 1", "update user set a = 1 where id = 1", "savepoint a", "rollback to a", "commit", "rollback", "begin"} {
+		_, err = executorExec(txCtx, executor, session, query, nil)
+		require.NoError(t, err, query)
+	}
+	_, err = executorExec(txCtx, executor, session, "select id from user where id = 1", nil)
+	require.EqualError(t, err, "query quota of user tx exceeded: 0.001 queries per second")
+	_, err = executorExec(txCtx, executor, session, "rollback", nil)
+	require.NoError(t, err)
+
+	// The sessions without autocommit are limited the same way.
+	noAutocommitCtx := callerid.NewContext(ctx, nil, &querypb.VTGateCallerID{Username: "noautocommit"})
+	session = &vtgatepb.Session{TargetString: "@primary"}
+	for _, query := range []string{"select id from user where id = 1", "update user set a = 1 where id = 1", "commit"} {
+		_, err = executorExec(noAutocommitCtx, executor, session, query, nil)
+		require.NoError(t, err, query)
+	}
+	_, err = executorExec(noAutocommitCtx, executor, session, "select id from user where id = 1", nil)
+	require.EqualError(t, err, "query quota of user noautocommit exceeded: 0.001 queries per second")
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"google.golang.org/protobuf/proto"
+
+	"vitess.io/vitess/go/stats"
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/queryrules"
+	"vitess.io/vitess/go/vt/vtgate/vindexes"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vschemapb "vitess.io/vitess/go/vt/proto/vschema"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+var queryRuleMatches = stats.NewCountersWithMultiLabels("QueryRuleMatches", "Queries matched by the vtgate query rules", []string{"Rule", "Action"})
+
+// appliedQueryRule is the outcome of applying the query rules to a query.
+type appliedQueryRule struct {
+	// stmt is the statement to execute, which the rewrite action replaces.
+	stmt sqlparser.Statement
+	// tabletType is the tablet type the reroute action sends the query to,
+	// or UNKNOWN to keep the one of the session.
+	tabletType topodatapb.TabletType
+	// release releases the concurrency slot taken by the throttle action.
+	release func()
+}
+
+// updateQueryRules compiles the query rules of the vschema, unless they did not
+// change, so that the matches and the concurrency of the rules are kept
+// across the vschema updates. Invalid rules do not replace the previous ones,
+// so that a mistake does not turn off the rules protecting the keyspaces.
+// It must be called with e.mu held.
+func (e *Executor) updateQueryRules(vschema *vindexes.VSchema) {
+	if vschema == nil || proto.Equal(vschema.QueryRules, e.queryRulesSource) {
+		return
+	}
+	e.queryRulesSource = vschema.QueryRules
+	rules, err := queryrules.Build(e.env.Parser(), vschema.QueryRules)
+	if err != nil {
+		log.Errorf("Keeping the previous query rules, since the ones of the vschema are invalid: %v", err)
+		if vschemaCounters != nil {
+			vschemaCounters.Add("QueryRulesError", 1)
+		}
+		e.queryRulesErr = err
+		return
+	}
+	e.queryRules = rules
+	e.queryRulesErr = nil
+}
+
+// QueryRules returns the query rules vtgate applies to the queries.
+func (e *Executor) QueryRules() *queryrules.Rules {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.queryRules
+}
+
+// QueryRulesError returns the error of the query rules of the vschema, if
+// they are invalid and the previous ones are applied instead.
+func (e *Executor) QueryRulesError() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.queryRulesErr
+}
+
+// applyQueryRules matches the query against the query rules, and applies the
+// action of the first rule it matches.
+func (e *Executor) applyQueryRules(safeSession *SafeSession, vschema *vindexes.VSchema, stmt sqlparser.Statement, bindVars map[string]*querypb.BindVariable) (*appliedQueryRule, error) {
+	applied := &appliedQueryRule{stmt: stmt, release: func() {}}
+	rules := e.QueryRules()
+	if rules == nil {
+		return applied, nil
+	}
+
+	keyspace, _, _, _ := parseDestinationTarget(safeSession.TargetString, vschema)
+	q := queryrules.NewQuery(stmt, keyspace)
+	rule, err := rules.Match(q)
+	if err != nil || rule == nil {
+		return applied, err
+	}
+	queryRuleMatches.Add([]string{rule.Name(), rule.Action().String()}, 1)
+
+	switch rule.Action() {
+	case vschemapb.QueryRule_reject:
+		reason := rule.Description()
+		if reason == "" {
+			reason = rule.Name()
+		}
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "disallowed due to rule: %s", reason)
+	case vschemapb.QueryRule_rewrite:
+		applied.stmt, err = rule.Rewrite(q, bindVars)
+		if err != nil {
+			return nil, err
+		}
+	case vschemapb.QueryRule_reroute:
+		// Only the reads outside of a transaction can go to another tablet type.
+		if sqlparser.ASTToStatementType(stmt) == sqlparser.StmtSelect && !safeSession.InTransaction() && !safeSession.InReservedConn() {
+			applied.tabletType = rule.TabletType()
+		}
+	case vschemapb.QueryRule_throttle:
+		release, ok := rule.Acquire()
+		if !ok {
+			return nil, vterrors.Errorf(vtrpcpb.Code_RESOURCE_EXHAUSTED, "too many concurrent queries for rule %s: the maximum is %d", rule.Name(), rule.MaxConcurrency())
+		}
+		applied.release = release
+	}
+	return applied, nil
+}
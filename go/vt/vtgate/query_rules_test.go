@@ -0,0 +1,149 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/vttablet/sandboxconn"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vschemapb "vitess.io/vitess/go/vt/proto/vschema"
+	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
+)
+
+func TestExecutorQueryRules(t *testing.T) {
+	var primary, replica *sandboxconn.SandboxConn
+	executor, ctx := createExecutorEnvCallback(t, func(shard, ks string, tabletType topodatapb.TabletType, conn *sandboxconn.SandboxConn) {
+		if ks == KsTestUnsharded {
+			if tabletType == topodatapb.TabletType_PRIMARY {
+				primary = conn
+			} else {
+				replica = conn
+			}
+		}
+	})
+
+	vschema := executor.VSchema()
+	vschema.QueryRules = &vschemapb.QueryRules{Rules: []*vschemapb.QueryRule{{
+		Name:           "no_deletes",
+		Description:    "deletes on simple are not allowed",
+		StatementTypes: []string{"delete"},
+		Tables:         []string{"simple"},
+		Action:         vschemapb.QueryRule_reject,
+	}, {
+		Name:        "limit",
+		Fingerprint: "select id from simple where id = 1",
+		Action:      vschemapb.QueryRule_rewrite,
+		Replacement: "select id from simple where id = :id limit 10",
+	}, {
+		Name:        "reports",
+		Fingerprint: "select count(*) from simple",
+		Action:      vschemapb.QueryRule_reroute,
+		TabletType:  topodatapb.TabletType_REPLICA,
+	}, {
+		Name:           "throttle",
+		Fingerprint:    "select 1 from simple",
+		Action:         vschemapb.QueryRule_throttle,
+		MaxConcurrency: 1,
+	}}}
+	executor.SaveVSchema(vschema, executor.vschemaStats)
+	session := &vtgatepb.Session{TargetString: "@primary", Autocommit: true}
+
+	_, err := executorExec(ctx, executor, session, "delete from simple where id = 1", nil)
+	require.EqualError(t, err, "disallowed due to rule: deletes on simple are not allowed")
+
+	_, err = executorExec(ctx, executor, session, "select id from simple where id = 5", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []*querypb.BoundQuery{{
+		Sql:           "select id from `simple` where id = :id limit 10",
+		BindVariables: map[string]*querypb.BindVariable{"id": sqltypes.Int64BindVariable(5)},
+	}}, primary.Queries)
+	primary.Queries = nil
+
+	_, err = executorExec(ctx, executor, session, "select count(*) from simple", nil)
+	require.NoError(t, err)
+	assert.Empty(t, primary.Queries)
+	assert.Len(t, replica.Queries, 1)
+
+	// The reads in a transaction stay on the primary.
+	_, err = executorExec(ctx, executor, session, "begin", nil)
+	require.NoError(t, err)
+	_, err = executorExec(ctx, executor, session, "select count(*) from simple", nil)
+	require.NoError(t, err)
+	assert.Len(t, primary.Queries, 1)
+	assert.Len(t, replica.Queries, 1)
+	_, err = executorExec(ctx, executor, session, "rollback", nil)
+	require.NoError(t, err)
+
+	release, ok := executor.QueryRules().All()[3].Acquire()
+	require.True(t, ok)
+	_, err = executorExec(ctx, executor, session, "select 1 from simple", nil)
+	require.EqualError(t, err, "too many concurrent queries for rule throttle: the maximum is 1")
+	release()
+	_, err = executorExec(ctx, executor, session, "select 1 from simple", nil)
+	require.NoError(t, err)
+
+	// The rules are kept across the vschema updates that do not change them.
+	executor.SaveVSchema(vschema, executor.vschemaStats)
+	rules := executor.QueryRules().All()
+	assert.EqualValues(t, 1, rules[0].Matches())
+	assert.EqualValues(t, 2, rules[2].Matches())
+	assert.EqualValues(t, 2, rules[3].Matches())
+
+	resp := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/queryz", nil)
+	queryzHandler(executor, resp, req)
+	body, _ := io.ReadAll(resp.Body)
+	checkQueryzHasPlan(t, []string{
+		`<tr>`,
+		`<td>reports</td>`,
+		`<td></td>`,
+		`<td>reroute</td>`,
+		`<td>2</td>`,
+		`<td>0</td>`,
+		`</tr>`,
+	}, nil, body)
+
+	// Invalid rules do not replace the previous ones.
+	invalid := vschema.QueryRules.CloneVT()
+	invalid.Rules = append(invalid.Rules, &vschemapb.QueryRule{Name: "invalid", Action: vschemapb.QueryRule_throttle})
+	vschema.QueryRules = invalid
+	executor.SaveVSchema(vschema, executor.vschemaStats)
+	assert.Equal(t, rules, executor.QueryRules().All())
+	require.EqualError(t, executor.QueryRulesError(), "invalid query rule invalid: the throttle action needs a positive max_concurrency")
+	_, err = executorExec(ctx, executor, session, "delete from simple where id = 1", nil)
+	require.EqualError(t, err, "disallowed due to rule: deletes on simple are not allowed")
+
+	resp = httptest.NewRecorder()
+	queryzHandler(executor, resp, req)
+	body, _ = io.ReadAll(resp.Body)
+	assert.Contains(t, string(body), "the previous ones are still applied: invalid query rule invalid: the throttle action needs a positive max_concurrency")
+
+	vschema.QueryRules = nil
+	executor.SaveVSchema(vschema, executor.vschemaStats)
+	assert.Empty(t, executor.QueryRules().All())
+	assert.NoError(t, executor.QueryRulesError())
+}
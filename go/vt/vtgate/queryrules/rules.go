@@ -0,0 +1,381 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package queryrules compiles the vtgate query rules stored in the topo, and
+// matches the queries executed by vtgate against them.
+package queryrules
+
+import (
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vterrors"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vschemapb "vitess.io/vitess/go/vt/proto/vschema"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+// bindVarPrefix is the prefix of the bind variables the literals of the
+// queries are replaced with, which is the one vtgate normalizes the queries
+// with.
+const bindVarPrefix = "vtg"
+
+// Rules are the compiled query rules. A nil Rules matches no query.
+type Rules struct {
+	rules []*Rule
+}
+
+// Rule is a compiled query rule.
+type Rule struct {
+	source *vschemapb.QueryRule
+
+	fingerprint string
+	// fingerprintType is the statement type of the fingerprint, which the
+	// queries are compared with before they are normalized.
+	fingerprintType sqlparser.StatementType
+	query           *regexp.Regexp
+	statementTypes  []sqlparser.StatementType
+	tables          []sqlparser.TableName
+	replacement     sqlparser.Statement
+
+	matches     atomic.Int64
+	concurrency atomic.Int64
+}
+
+// Build compiles the query rules, and returns an error if one of them is
+// invalid.
+func Build(parser *sqlparser.Parser, source *vschemapb.QueryRules) (*Rules, error) {
+	qrs := &Rules{}
+	names := make(map[string]bool)
+	for i, source := range source.GetRules() {
+		if source.Name == "" {
+			return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "query rule %d has no name", i)
+		}
+		if names[source.Name] {
+			return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "duplicate query rule: %s", source.Name)
+		}
+		names[source.Name] = true
+
+		qr, err := buildRule(parser, source)
+		if err != nil {
+			return nil, vterrors.Wrapf(err, "invalid query rule %s", source.Name)
+		}
+		qrs.rules = append(qrs.rules, qr)
+	}
+	return qrs, nil
+}
+
+func buildRule(parser *sqlparser.Parser, source *vschemapb.QueryRule) (*Rule, error) {
+	qr := &Rule{source: source.CloneVT()}
+
+	if source.Fingerprint != "" {
+		stmt, _, err := parser.Parse2(source.Fingerprint)
+		if err != nil {
+			return nil, err
+		}
+		qr.fingerprint, _, err = fingerprint(stmt)
+		if err != nil {
+			return nil, err
+		}
+		qr.fingerprintType = sqlparser.ASTToStatementType(stmt)
+	}
+	if source.Query != "" {
+		query, err := regexp.Compile(source.Query)
+		if err != nil {
+			return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "invalid query regular expression: %v", err)
+		}
+		qr.query = query
+	}
+	for _, name := range source.StatementTypes {
+		statementType, ok := statementTypes[strings.ToUpper(name)]
+		if !ok {
+			return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "unknown statement type: %s", name)
+		}
+		qr.statementTypes = append(qr.statementTypes, statementType)
+	}
+	for _, name := range source.Tables {
+		keyspace, table, ok := strings.Cut(name, ".")
+		if !ok {
+			keyspace, table = "", keyspace
+		}
+		if table == "" {
+			return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "invalid table: %s", name)
+		}
+		qr.tables = append(qr.tables, sqlparser.NewTableNameWithQualifier(table, keyspace))
+	}
+
+	switch source.Action {
+	case vschemapb.QueryRule_reject:
+	case vschemapb.QueryRule_rewrite:
+		if source.Replacement == "" {
+			return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "the rewrite action needs a replacement")
+		}
+		replacement, err := parser.Parse(source.Replacement)
+		if err != nil {
+			return nil, err
+		}
+		qr.replacement = replacement
+	case vschemapb.QueryRule_reroute:
+		switch source.TabletType {
+		case topodatapb.TabletType_PRIMARY, topodatapb.TabletType_REPLICA, topodatapb.TabletType_RDONLY:
+		default:
+			return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "the reroute action cannot route to %v tablets", source.TabletType)
+		}
+	case vschemapb.QueryRule_throttle:
+		if source.MaxConcurrency <= 0 {
+			return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "the throttle action needs a positive max_concurrency")
+		}
+	default:
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "invalid action: %v", source.Action)
+	}
+	return qr, nil
+}
+
+// statementTypes are the statement types by name.
+var statementTypes = func() map[string]sqlparser.StatementType {
+	m := make(map[string]sqlparser.StatementType)
+	for st := sqlparser.StmtSelect; st <= sqlparser.StmtKill; st++ {
+		m[st.String()] = st
+	}
+	return m
+}()
+
+// fingerprint returns the normalized SQL of the statement, without its
+// comments and with its literals replaced by bind variables, and the values
+// of these bind variables.
+func fingerprint(stmt sqlparser.Statement) (string, map[string]*querypb.BindVariable, error) {
+	stmt = sqlparser.CloneStatement(stmt)
+	if commented, ok := stmt.(sqlparser.Commented); ok {
+		commented.SetComments(nil)
+	}
+	bindVars := make(map[string]*querypb.BindVariable)
+	reservedVars := sqlparser.NewReservedVars(bindVarPrefix, sqlparser.GetBindvars(stmt))
+	if err := sqlparser.Normalize(stmt, reservedVars, bindVars); err != nil {
+		return "", nil, err
+	}
+	// The types of the literals are left out, so that the fingerprints match
+	// whatever the values of the literals are.
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if arg, ok := node.(*sqlparser.Argument); ok {
+			arg.Type, arg.Size, arg.Scale = -1, 0, 0
+		}
+		return true, nil
+	}, stmt)
+	return sqlparser.String(stmt), bindVars, nil
+}
+
+// Query is a query matched against the rules.
+type Query struct {
+	stmt     sqlparser.Statement
+	keyspace string
+
+	normalized  bool
+	fingerprint string
+	bindVars    map[string]*querypb.BindVariable
+}
+
+// NewQuery returns the query of the statement, executed against the
+// keyspace, which qualifies the tables of the statement that are not.
+func NewQuery(stmt sqlparser.Statement, keyspace string) *Query {
+	return &Query{stmt: stmt, keyspace: keyspace}
+}
+
+// normalize computes the fingerprint of the query, if it was not already.
+func (q *Query) normalize() error {
+	if q.normalized {
+		return nil
+	}
+	var err error
+	q.fingerprint, q.bindVars, err = fingerprint(q.stmt)
+	if err != nil {
+		return err
+	}
+	q.normalized = true
+	return nil
+}
+
+// Match returns the first rule the query matches, or nil if none.
+func (qrs *Rules) Match(q *Query) (*Rule, error) {
+	if qrs == nil {
+		return nil, nil
+	}
+	for _, qr := range qrs.rules {
+		matched, err := qr.match(q)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			qr.matches.Add(1)
+			return qr, nil
+		}
+	}
+	return nil, nil
+}
+
+// All returns all the rules, in the order they are matched in.
+func (qrs *Rules) All() []*Rule {
+	if qrs == nil {
+		return nil
+	}
+	return qrs.rules
+}
+
+// match returns true if the query matches the rule. The criteria that do not
+// need the fingerprint of the query are checked first, so that the queries
+// are only normalized when a rule may match them by their fingerprint.
+func (qr *Rule) match(q *Query) (bool, error) {
+	if len(qr.statementTypes) > 0 {
+		statementType := sqlparser.ASTToStatementType(q.stmt)
+		found := false
+		for _, st := range qr.statementTypes {
+			if st == statementType {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false, nil
+		}
+	}
+	if len(qr.tables) > 0 && !qr.matchTables(q) {
+		return false, nil
+	}
+	if qr.fingerprint != "" && qr.fingerprintType != sqlparser.ASTToStatementType(q.stmt) {
+		return false, nil
+	}
+	if qr.fingerprint == "" && qr.query == nil {
+		return true, nil
+	}
+	if err := q.normalize(); err != nil {
+		return false, err
+	}
+	if qr.fingerprint != "" && qr.fingerprint != q.fingerprint {
+		return false, nil
+	}
+	if qr.query != nil && !qr.query.MatchString(q.fingerprint) {
+		return false, nil
+	}
+	return true, nil
+}
+
+// matchTables returns true if the query is on one of the tables of the rule.
+func (qr *Rule) matchTables(q *Query) bool {
+	matches := func(name sqlparser.TableName) bool {
+		keyspace := name.Qualifier.String()
+		if keyspace == "" {
+			keyspace = q.keyspace
+		}
+		for _, table := range qr.tables {
+			if table.Name.String() == name.Name.String() && (table.Qualifier.IsEmpty() || table.Qualifier.String() == keyspace) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ddl, ok := q.stmt.(sqlparser.DDLStatement); ok {
+		for _, name := range ddl.AffectedTables() {
+			if matches(name) {
+				return true
+			}
+		}
+		return false
+	}
+	found := false
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if found {
+			return false, nil
+		}
+		if aliased, ok := node.(*sqlparser.AliasedTableExpr); ok {
+			if name, ok := aliased.Expr.(sqlparser.TableName); ok && matches(name) {
+				found = true
+				return false, nil
+			}
+		}
+		return true, nil
+	}, q.stmt)
+	return found
+}
+
+// Name returns the name of the rule.
+func (qr *Rule) Name() string {
+	return qr.source.Name
+}
+
+// Description returns the description of the rule.
+func (qr *Rule) Description() string {
+	return qr.source.Description
+}
+
+// Action returns the action of the rule.
+func (qr *Rule) Action() vschemapb.QueryRule_Action {
+	return qr.source.Action
+}
+
+// TabletType returns the tablet type the reroute action sends the queries to.
+func (qr *Rule) TabletType() topodatapb.TabletType {
+	return qr.source.TabletType
+}
+
+// MaxConcurrency returns how many queries the throttle action lets execute
+// concurrently.
+func (qr *Rule) MaxConcurrency() int64 {
+	return qr.source.MaxConcurrency
+}
+
+// Matches returns how many queries matched the rule.
+func (qr *Rule) Matches() int64 {
+	return qr.matches.Load()
+}
+
+// Concurrency returns how many queries the throttle action currently lets
+// execute.
+func (qr *Rule) Concurrency() int64 {
+	return qr.concurrency.Load()
+}
+
+// Acquire lets one more query execute for the throttle action, if fewer than
+// its max concurrency are. It returns false otherwise, and the query must
+// be rejected. The release function must be called once the query is done.
+func (qr *Rule) Acquire() (release func(), ok bool) {
+	if qr.concurrency.Add(1) > qr.source.MaxConcurrency {
+		qr.concurrency.Add(-1)
+		return nil, false
+	}
+	return func() { qr.concurrency.Add(-1) }, true
+}
+
+// Rewrite returns the statement the rewrite action replaces the query with,
+// and adds the values of the bind variables it uses to bindVars.
+func (qr *Rule) Rewrite(q *Query, bindVars map[string]*querypb.BindVariable) (sqlparser.Statement, error) {
+	if err := q.normalize(); err != nil {
+		return nil, err
+	}
+	stmt := sqlparser.CloneStatement(qr.replacement)
+	for name := range sqlparser.GetBindvars(stmt) {
+		if _, ok := bindVars[name]; ok {
+			continue
+		}
+		if bv, ok := q.bindVars[name]; ok {
+			bindVars[name] = bv
+		}
+	}
+	return stmt, nil
+}
@@ -0,0 +1,219 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queryrules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/sqlparser"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vschemapb "vitess.io/vitess/go/vt/proto/vschema"
+)
+
+func TestBuildErrors(t *testing.T) {
+	tests := []struct {
+		rule *vschemapb.QueryRule
+		err  string
+	}{{
+		rule: &vschemapb.QueryRule{Action: vschemapb.QueryRule_reject},
+		err:  "query rule 0 has no name",
+	}, {
+		rule: &vschemapb.QueryRule{Name: "r"},
+		err:  "invalid query rule r: invalid action: unspecified",
+	}, {
+		rule: &vschemapb.QueryRule{Name: "r", Action: vschemapb.QueryRule_reject, Fingerprint: "selec"},
+		err:  "invalid query rule r: syntax error at position 6 near 'selec'",
+	}, {
+		rule: &vschemapb.QueryRule{Name: "r", Action: vschemapb.QueryRule_reject, Query: "("},
+		err:  "invalid query rule r: invalid query regular expression: error parsing regexp: missing closing ): `(`",
+	}, {
+		rule: &vschemapb.QueryRule{Name: "r", Action: vschemapb.QueryRule_reject, StatementTypes: []string{"selects"}},
+		err:  "invalid query rule r: unknown statement type: selects",
+	}, {
+		rule: &vschemapb.QueryRule{Name: "r", Action: vschemapb.QueryRule_reject, Tables: []string{"ks."}},
+		err:  "invalid query rule r: invalid table: ks.",
+	}, {
+		rule: &vschemapb.QueryRule{Name: "r", Action: vschemapb.QueryRule_rewrite},
+		err:  "invalid query rule r: the rewrite action needs a replacement",
+	}, {
+		rule: &vschemapb.QueryRule{Name: "r", Action: vschemapb.QueryRule_reroute},
+		err:  "invalid query rule r: the reroute action cannot route to UNKNOWN tablets",
+	}, {
+		rule: &vschemapb.QueryRule{Name: "r", Action: vschemapb.QueryRule_throttle},
+		err:  "invalid query rule r: the throttle action needs a positive max_concurrency",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.err, func(t *testing.T) {
+			_, err := Build(sqlparser.NewTestParser(), &vschemapb.QueryRules{Rules: []*vschemapb.QueryRule{tt.rule}})
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+
+	_, err := Build(sqlparser.NewTestParser(), &vschemapb.QueryRules{Rules: []*vschemapb.QueryRule{
+		{Name: "r", Action: vschemapb.QueryRule_reject},
+		{Name: "r", Action: vschemapb.QueryRule_reject},
+	}})
+	assert.EqualError(t, err, "duplicate query rule: r")
+}
+
+func TestMatch(t *testing.T) {
+	parser := sqlparser.NewTestParser()
+	qrs, err := Build(parser, &vschemapb.QueryRules{Rules: []*vschemapb.QueryRule{{
+		Name:        "fingerprint",
+		Fingerprint: "select * from user where id = 1",
+		Action:      vschemapb.QueryRule_reject,
+	}, {
+		Name:   "query",
+		Query:  `from music where .* like`,
+		Action: vschemapb.QueryRule_reject,
+	}, {
+		Name:           "writes",
+		StatementTypes: []string{"update", "delete"},
+		Tables:         []string{"ks.user_extra"},
+		Action:         vschemapb.QueryRule_reject,
+	}, {
+		Name:   "ddl",
+		Tables: []string{"orders"},
+		Action: vschemapb.QueryRule_reject,
+	}}})
+	require.NoError(t, err)
+
+	tests := []struct {
+		query    string
+		keyspace string
+		rule     string
+	}{
+		{query: "select * from user where id = 3", rule: "fingerprint"},
+		{query: "select /* comment */ * from user where id = 'foo'", rule: "fingerprint"},
+		{query: "select * from user where id = :id", rule: "fingerprint"},
+		{query: "select * from user where id = 3 and name = 'x'", rule: ""},
+		{query: "select id from music where name like 'a%'", rule: "query"},
+		{query: "select id from music where name = 'a'", rule: ""},
+		{query: "update user_extra set a = 1", keyspace: "ks", rule: "writes"},
+		{query: "update ks.user_extra set a = 1", rule: "writes"},
+		{query: "update user_extra set a = 1", keyspace: "other", rule: ""},
+		{query: "select * from ks.user_extra", rule: ""},
+		{query: "delete from user_extra as ue where ue.id = 1", keyspace: "ks", rule: "writes"},
+		{query: "select * from orders join user on orders.uid = user.id", rule: "ddl"},
+		{query: "alter table orders add column x int", rule: "ddl"},
+		{query: "select * from (select 1 from dual) as orders", rule: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			stmt, err := parser.Parse(tt.query)
+			require.NoError(t, err)
+			qr, err := qrs.Match(NewQuery(stmt, tt.keyspace))
+			require.NoError(t, err)
+			if tt.rule == "" {
+				assert.Nil(t, qr)
+				return
+			}
+			require.NotNil(t, qr)
+			assert.Equal(t, tt.rule, qr.Name())
+		})
+	}
+	assert.EqualValues(t, 3, qrs.All()[0].Matches())
+
+	// The queries are only normalized when a rule may match their fingerprint.
+	qrs, err = Build(parser, &vschemapb.QueryRules{Rules: []*vschemapb.QueryRule{{
+		Name:        "fingerprint",
+		Fingerprint: "select * from user where id = 1",
+		Action:      vschemapb.QueryRule_reject,
+	}, {
+		Name:           "writes",
+		StatementTypes: []string{"update"},
+		Action:         vschemapb.QueryRule_reject,
+	}}})
+	require.NoError(t, err)
+	for query, normalized := range map[string]bool{
+		"select * from user where id = 3": true,
+		"select * from music":             true,
+		"update user set a = 1":           false,
+		"delete from user where id = 1":   false,
+	} {
+		stmt, err := parser.Parse(query)
+		require.NoError(t, err)
+		q := NewQuery(stmt, "")
+		_, err = qrs.Match(q)
+		require.NoError(t, err)
+		assert.Equal(t, normalized, q.normalized, query)
+	}
+
+	var nilRules *Rules
+	qr, err := nilRules.Match(NewQuery(&sqlparser.Select{}, ""))
+	assert.NoError(t, err)
+	assert.Nil(t, qr)
+}
+
+func TestRewrite(t *testing.T) {
+	parser := sqlparser.NewTestParser()
+	qrs, err := Build(parser, &vschemapb.QueryRules{Rules: []*vschemapb.QueryRule{{
+		Name:        "limit",
+		Fingerprint: "select * from user where name = 'x'",
+		Action:      vschemapb.QueryRule_rewrite,
+		Replacement: "select * from user use index (name_idx) where name = :name limit 100",
+	}}})
+	require.NoError(t, err)
+
+	stmt, err := parser.Parse("select * from user where name = 'alice'")
+	require.NoError(t, err)
+	q := NewQuery(stmt, "")
+	qr, err := qrs.Match(q)
+	require.NoError(t, err)
+	require.NotNil(t, qr)
+
+	bindVars := map[string]*querypb.BindVariable{}
+	rewritten, err := qr.Rewrite(q, bindVars)
+	require.NoError(t, err)
+	assert.Equal(t, "select * from `user` use index (name_idx) where `name` = :name limit 100", sqlparser.String(rewritten))
+	assert.Equal(t, map[string]*querypb.BindVariable{"name": sqltypes.StringBindVariable("alice")}, bindVars)
+}
+
+func TestAcquire(t *testing.T) {
+	qrs, err := Build(sqlparser.NewTestParser(), &vschemapb.QueryRules{Rules: []*vschemapb.QueryRule{{
+		Name:           "throttle",
+		Action:         vschemapb.QueryRule_throttle,
+		MaxConcurrency: 2,
+	}, {
+		Name:       "reroute",
+		Action:     vschemapb.QueryRule_reroute,
+		TabletType: topodatapb.TabletType_REPLICA,
+	}}})
+	require.NoError(t, err)
+	qr := qrs.All()[0]
+
+	release1, ok := qr.Acquire()
+	require.True(t, ok)
+	release2, ok := qr.Acquire()
+	require.True(t, ok)
+	_, ok = qr.Acquire()
+	require.False(t, ok)
+	assert.EqualValues(t, 2, qr.Concurrency())
+
+	release1()
+	release3, ok := qr.Acquire()
+	require.True(t, ok)
+	release2()
+	release3()
+	assert.EqualValues(t, 0, qr.Concurrency())
+}
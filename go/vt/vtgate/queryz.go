@@ -64,6 +64,32 @@ var (
 			<td>{{.ErrorsPQ}}</td>
 		</tr>
 	`))
+	queryRulesHeader = []byte(`</table>
+<table class="gridtable">
+	<thead>
+		<tr>
+			<th>Query Rule</th>
+			<th>Description</th>
+			<th>Action</th>
+			<th>Matches</th>
+			<th>Concurrency</th>
+		</tr>
+        </thead>
+	`)
+	queryRulesTmpl = template.Must(template.New("example").Parse(`
+		<tr>
+			<td>{{.Name}}</td>
+			<td>{{.Description}}</td>
+			<td>{{.Action}}</td>
+			<td>{{.Matches}}</td>
+			<td>{{.Concurrency}}</td>
+		</tr>
+	`))
+	queryRulesErrorTmpl = template.Must(template.New("example").Parse(`
+		<tr>
+			<td colspan="5">The query rules of the vschema are invalid, the previous ones are still applied: {{.}}</td>
+		</tr>
+	`))
 )
 
 // queryzRow is used for rendering query stats
@@ -168,4 +194,21 @@ func queryzHandler(e *Executor, w http.ResponseWriter, r *http.Request) {
 			log.Errorf("queryz: couldn't execute template: %v", err)
 		}
 	}
+
+	rules := e.QueryRules().All()
+	rulesErr := e.QueryRulesError()
+	if len(rules) == 0 && rulesErr == nil {
+		return
+	}
+	w.Write(queryRulesHeader)
+	if rulesErr != nil {
+		if err := queryRulesErrorTmpl.Execute(w, rulesErr.Error()); err != nil {
+			log.Errorf("queryz: couldn't execute template: %v", err)
+		}
+	}
+	for _, rule := range rules {
+		if err := queryRulesTmpl.Execute(w, rule); err != nil {
+			log.Errorf("queryz: couldn't execute template: %v", err)
+		}
+	}
 }
@@ -0,0 +1,529 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"vitess.io/vitess/go/cache/theine"
+	"vitess.io/vitess/go/hack"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/stats"
+	"vitess.io/vitess/go/vt/callerid"
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/engine"
+	"vitess.io/vitess/go/vt/vtgate/vindexes"
+	"vitess.io/vitess/go/vt/vthash"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
+)
+
+// The result cache holds the results of the read-only queries executed
+// outside of a transaction, for the tables that enable it in their VSchema
+// with a result_cache_ttl, or for the queries that enable it with a
+// RESULT_CACHE_TTL comment directive. The results are keyed by the
+// normalized query, its bind variables and everything else the plan of the
+// query depends on, like the target and the caller.
+//
+// A cached result is served until its ttl expires, or until one of the
+// tables it was read from is invalidated. The tables are invalidated when
+// vtgate executes a DML or DDL on them, when the schema tracker sees their
+// schema change, and with --result-cache-vstream-invalidation, as soon as
+// their rows change on the primary tablets.
+
+var resultCacheOperations = stats.NewCountersWithSingleLabel("ResultCacheOperations", "Result cache operations", "Operation")
+
+const (
+	resultCacheHit          = "Hit"
+	resultCacheMiss         = "Miss"
+	resultCacheInvalidation = "Invalidation"
+)
+
+// resultCacheStreamRetryDelay is how long an invalidation stream waits
+// before it is restarted after it fails.
+var resultCacheStreamRetryDelay = 5 * time.Second
+
+type resultCacheKey = theine.HashKey256
+
+type resultCacheEntry struct {
+	result  *sqltypes.Result
+	expires time.Time
+	// tables are the keyspace qualified tables the result was read from,
+	// and generations their generations when the query was executed.
+	tables      []string
+	generations []uint64
+}
+
+// CachedSize returns the memory used by the entry, which is how much of
+// the result cache capacity it uses.
+func (entry *resultCacheEntry) CachedSize(alloc bool) int64 {
+	var size int64
+	if alloc {
+		size += int64(80)
+	}
+	size += entry.result.CachedSize(true)
+	for _, table := range entry.tables {
+		size += hack.RuntimeAllocSize(int64(len(table))) + 16
+	}
+	size += hack.RuntimeAllocSize(int64(cap(entry.generations)) * 8)
+	return size
+}
+
+type resultCache struct {
+	entries *theine.Store[resultCacheKey, *resultCacheEntry]
+
+	mu sync.Mutex
+	// keyspaces and tables are the generations of the keyspaces and of
+	// the keyspace qualified tables. They are bumped when the keyspace or
+	// the table is invalidated, so that the results read from the table
+	// when it had an older generation are no longer served.
+	keyspaces map[string]uint64
+	tables    map[string]uint64
+}
+
+func newResultCache(memory int64) *resultCache {
+	return &resultCache{
+		// The doorkeeper is disabled since the results are only cached
+		// for the tables and queries that opted in.
+		entries:   theine.NewStore[resultCacheKey, *resultCacheEntry](memory, false),
+		keyspaces: make(map[string]uint64),
+		tables:    make(map[string]uint64),
+	}
+}
+
+// generations returns the current generations of the tables.
+func (rc *resultCache) generations(tables []string) []uint64 {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	generations := make([]uint64, len(tables))
+	for i, table := range tables {
+		generations[i] = rc.generationLocked(table)
+	}
+	return generations
+}
+
+// generationLocked returns the generation of the table, which also
+// changes when its whole keyspace is invalidated.
+func (rc *resultCache) generationLocked(table string) uint64 {
+	keyspace, _, _ := strings.Cut(table, ".")
+	return rc.keyspaces[keyspace] + rc.tables[table]
+}
+
+// get returns the cached result for the key, if it did not expire and
+// none of the tables it was read from were invalidated since.
+func (rc *resultCache) get(key resultCacheKey) (*sqltypes.Result, bool) {
+	entry, ok := rc.entries.Get(key, 0)
+	if !ok {
+		resultCacheOperations.Add(resultCacheMiss, 1)
+		return nil, false
+	}
+	if time.Now().After(entry.expires) || !slices.Equal(entry.generations, rc.generations(entry.tables)) {
+		rc.entries.Delete(key)
+		resultCacheOperations.Add(resultCacheMiss, 1)
+		return nil, false
+	}
+	resultCacheOperations.Add(resultCacheHit, 1)
+	return entry.result.ShallowCopy(), true
+}
+
+// set caches the result for the key, for the given ttl. The generations
+// are the ones of the tables before the query was executed, so that the
+// result is not served if a table was invalidated while it was executed.
+func (rc *resultCache) set(key resultCacheKey, result *sqltypes.Result, ttl time.Duration, tables []string, generations []uint64) {
+	rc.entries.Set(key, &resultCacheEntry{
+		result:      result.Copy(),
+		expires:     time.Now().Add(ttl),
+		tables:      tables,
+		generations: generations,
+	}, 0, 0)
+}
+
+// invalidateTables invalidates the results read from the keyspace
+// qualified tables.
+func (rc *resultCache) invalidateTables(tables []string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	for _, table := range tables {
+		rc.tables[table]++
+	}
+	resultCacheOperations.Add(resultCacheInvalidation, int64(len(tables)))
+}
+
+// invalidateKeyspace invalidates the results read from the tables of
+// the keyspace.
+func (rc *resultCache) invalidateKeyspace(keyspace string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.keyspaces[keyspace]++
+	resultCacheOperations.Add(resultCacheInvalidation, 1)
+}
+
+func (rc *resultCache) close() {
+	rc.entries.Close()
+}
+
+// resultCacheTTL returns how long the result of the plan can be cached,
+// or 0 if it cannot be cached. The result of a query is cached when it is
+// a SELECT executed outside of a transaction or reserved connection, that
+// does not call a non-deterministic function, and either its
+// RESULT_CACHE_TTL comment directive is set, or all the tables it reads
+// from have a result cache ttl in the VSchema. The lowest ttl of the
+// tables is then used.
+func (e *Executor) resultCacheTTL(safeSession *SafeSession, plan *engine.Plan, vcursor *vcursorImpl) time.Duration {
+	if e.resultCache == nil || plan.Type != sqlparser.StmtSelect || safeSession.InTransaction() || safeSession.InReservedConn() {
+		return 0
+	}
+	var ttl time.Duration
+	if vcursor.resultCacheTTLSet {
+		ttl = vcursor.resultCacheTTL
+	} else {
+		if len(plan.TablesUsed) == 0 {
+			return 0
+		}
+		for _, name := range plan.TablesUsed {
+			table := findResultCacheTable(vcursor.vschema, name)
+			if table == nil || table.ResultCacheTTL == 0 {
+				return 0
+			}
+			if ttl == 0 || table.ResultCacheTTL < ttl {
+				ttl = table.ResultCacheTTL
+			}
+		}
+	}
+	if ttl > 0 && hasNonDeterministicFunction(vcursor.resultCacheStmt) {
+		return 0
+	}
+	return ttl
+}
+
+// nonDeterministicFunctions are the functions, besides the ones returning
+// the current date and time, that do not always return the same result
+// for the same arguments and rows.
+var nonDeterministicFunctions = map[string]bool{
+	"benchmark":         true,
+	"connection_id":     true,
+	"curdate":           true,
+	"current_date":      true,
+	"current_role":      true,
+	"current_user":      true,
+	"found_rows":        true,
+	"get_lock":          true,
+	"is_free_lock":      true,
+	"is_used_lock":      true,
+	"last_insert_id":    true,
+	"rand":              true,
+	"random_bytes":      true,
+	"release_all_locks": true,
+	"release_lock":      true,
+	"row_count":         true,
+	"session_user":      true,
+	"sleep":             true,
+	"system_user":       true,
+	"user":              true,
+	"utc_date":          true,
+	"uuid":              true,
+	"uuid_short":        true,
+}
+
+// hasNonDeterministicFunction returns whether the statement calls a
+// non-deterministic function, like now() or rand(), whose result would
+// be cached with the one of the query.
+func hasNonDeterministicFunction(stmt sqlparser.Statement) bool {
+	if stmt == nil {
+		return false
+	}
+	found := false
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch node := node.(type) {
+		case *sqlparser.CurTimeFuncExpr:
+			found = true
+		case *sqlparser.FuncExpr:
+			name := node.Name.Lowered()
+			// unix_timestamp() returns the current time when it has no argument.
+			if nonDeterministicFunctions[name] || (name == "unix_timestamp" && len(node.Exprs) == 0) {
+				found = true
+			}
+		}
+		return !found, nil
+	}, stmt)
+	return found
+}
+
+// findResultCacheTable returns the VSchema table of the keyspace qualified
+// table name, or nil if there is none.
+func findResultCacheTable(vschema *vindexes.VSchema, name string) *vindexes.Table {
+	keyspace, table, ok := strings.Cut(name, ".")
+	if !ok || vschema == nil {
+		return nil
+	}
+	ks := vschema.Keyspaces[keyspace]
+	if ks == nil {
+		return nil
+	}
+	return ks.Tables[table]
+}
+
+// resultCacheKey returns the key the result of the plan is cached with.
+func (e *Executor) resultCacheKey(ctx context.Context, plan *engine.Plan, vcursor *vcursorImpl, bindVars map[string]*querypb.BindVariable) resultCacheKey {
+	hasher := vthash.New256()
+	vcursor.keyForPlan(ctx, plan.Original, hasher)
+
+	// The tablets check the table ACLs of the caller, so the results of
+	// different callers are cached separately.
+	_, _ = hasher.WriteString("+Caller:")
+	_, _ = hasher.WriteString(callerid.GetPrincipal(callerid.EffectiveCallerIDFromContext(ctx)))
+	_, _ = hasher.WriteString("+")
+	_, _ = hasher.WriteString(callerid.GetUsername(callerid.ImmediateCallerIDFromContext(ctx)))
+
+	// The system variables set in the session, like sql_mode or time_zone,
+	// are applied without reserving a connection and may change the result.
+	_, _ = hasher.WriteString("+SystemVariables:")
+	sysVars := make([]string, 0)
+	vcursor.safeSession.GetSystemVariables(func(k, v string) {
+		sysVars = append(sysVars, k+"="+v)
+	})
+	slices.Sort(sysVars)
+	for _, sysVar := range sysVars {
+		_, _ = hasher.WriteString(sysVar)
+		_, _ = hasher.WriteString(";")
+	}
+
+	_, _ = hasher.WriteString("+BindVars:")
+	names := make([]string, 0, len(bindVars))
+	for name := range bindVars {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		buf, _ := bindVars[name].MarshalVT()
+		_, _ = hasher.WriteString(name)
+		_, _ = hasher.WriteString("=")
+		_, _ = hasher.Write(buf)
+		_, _ = hasher.WriteString(";")
+	}
+
+	var key resultCacheKey
+	hasher.Sum(key[:0])
+	return key
+}
+
+// invalidateResultCache invalidates the cached results of the tables
+// changed by the plan. When the plan is executed in a transaction, the
+// tables are also recorded in the session, to be invalidated again when
+// it commits: the results read from them until then are the ones before
+// the changes, and are cached with their new generation.
+func (e *Executor) invalidateResultCache(safeSession *SafeSession, plan *engine.Plan) {
+	if e.resultCache == nil {
+		return
+	}
+	switch plan.Type {
+	case sqlparser.StmtInsert, sqlparser.StmtReplace, sqlparser.StmtUpdate, sqlparser.StmtDelete, sqlparser.StmtDDL:
+		e.resultCache.invalidateTables(plan.TablesUsed)
+		if safeSession.InTransaction() {
+			safeSession.AddResultCacheInvalidations(plan.TablesUsed)
+		}
+	}
+}
+
+// InvalidateResultCache invalidates the cached results of the tables of
+// the keyspace, or of all its tables if tables is nil. It is called by
+// the schema tracker when the schema of the tables changes.
+func (e *Executor) InvalidateResultCache(keyspace string, tables []string) {
+	if e.resultCache == nil {
+		return
+	}
+	if tables == nil {
+		e.resultCache.invalidateKeyspace(keyspace)
+		return
+	}
+	qualified := make([]string, 0, len(tables))
+	for _, table := range tables {
+		qualified = append(qualified, keyspace+"."+table)
+	}
+	e.resultCache.invalidateTables(qualified)
+}
+
+// resultCacheVStreamer streams the changes of the rows of tables.
+type resultCacheVStreamer interface {
+	VStream(ctx context.Context, tabletType topodatapb.TabletType, vgtid *binlogdatapb.VGtid, filter *binlogdatapb.Filter, flags *vtgatepb.VStreamFlags, send func(events []*binlogdatapb.VEvent) error) error
+}
+
+// resultCacheInvalidator invalidates the cached results of the tables with
+// a result cache ttl in the VSchema as soon as their rows change, with one
+// VStream per keyspace.
+type resultCacheInvalidator struct {
+	cache     *resultCache
+	vstreamer resultCacheVStreamer
+
+	mu      sync.Mutex
+	closed  bool
+	streams map[string]*resultCacheStream
+}
+
+type resultCacheStream struct {
+	tables []string
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newResultCacheInvalidator(cache *resultCache, vstreamer resultCacheVStreamer) *resultCacheInvalidator {
+	return &resultCacheInvalidator{
+		cache:     cache,
+		vstreamer: vstreamer,
+		streams:   make(map[string]*resultCacheStream),
+	}
+}
+
+// update starts streaming the row events of the tables of the VSchema
+// with a result cache ttl, and stops streaming the ones of the tables
+// that no longer have one. It returns a function that waits for the
+// stopped streams to end, which can be called once the locks of the
+// caller are released.
+func (rci *resultCacheInvalidator) update(vschema *vindexes.VSchema) (wait func()) {
+	cached := make(map[string][]string)
+	if vschema != nil {
+		for keyspace, ks := range vschema.Keyspaces {
+			for name, table := range ks.Tables {
+				if table.ResultCacheTTL > 0 {
+					cached[keyspace] = append(cached[keyspace], name)
+				}
+			}
+		}
+	}
+
+	rci.mu.Lock()
+	defer rci.mu.Unlock()
+	if rci.closed {
+		return func() {}
+	}
+	var stopped []*resultCacheStream
+	for keyspace, stream := range rci.streams {
+		if !slices.Equal(stream.tables, sortedTables(cached[keyspace])) {
+			stream.cancel()
+			stopped = append(stopped, stream)
+			delete(rci.streams, keyspace)
+		}
+	}
+	for keyspace, tables := range cached {
+		if _, ok := rci.streams[keyspace]; ok {
+			continue
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		stream := &resultCacheStream{tables: sortedTables(tables), cancel: cancel, done: make(chan struct{})}
+		rci.streams[keyspace] = stream
+		go rci.stream(ctx, keyspace, stream)
+	}
+	return func() {
+		for _, stream := range stopped {
+			<-stream.done
+		}
+	}
+}
+
+func sortedTables(tables []string) []string {
+	slices.Sort(tables)
+	return tables
+}
+
+// stream invalidates the tables of the stream as their row events are
+// streamed, until the stream is stopped. The stream is restarted when it
+// fails.
+func (rci *resultCacheInvalidator) stream(ctx context.Context, keyspace string, stream *resultCacheStream) {
+	defer close(stream.done)
+
+	filter := &binlogdatapb.Filter{}
+	for _, table := range stream.tables {
+		filter.Rules = append(filter.Rules, &binlogdatapb.Rule{Match: table})
+	}
+	for {
+		started := false
+		err := rci.vstreamer.VStream(ctx, topodatapb.TabletType_PRIMARY, &binlogdatapb.VGtid{
+			ShardGtids: []*binlogdatapb.ShardGtid{{Keyspace: keyspace, Gtid: "current"}},
+		}, filter, &vtgatepb.VStreamFlags{}, func(events []*binlogdatapb.VEvent) error {
+			if !started {
+				// The rows changed before the stream started were missed.
+				started = true
+				rci.cache.invalidateKeyspace(keyspace)
+			}
+			var tables []string
+			for _, event := range events {
+				switch event.Type {
+				case binlogdatapb.VEventType_ROW:
+					// The table names are qualified with their keyspace by the VStream.
+					tables = append(tables, event.RowEvent.TableName)
+				case binlogdatapb.VEventType_DDL:
+					rci.cache.invalidateKeyspace(keyspace)
+				}
+			}
+			if len(tables) > 0 {
+				rci.cache.invalidateTables(tables)
+			}
+			return nil
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		log.Warningf("Result cache invalidation stream of keyspace %s failed, restarting it in %v: %v", keyspace, resultCacheStreamRetryDelay, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(resultCacheStreamRetryDelay):
+		}
+	}
+}
+
+func (stream *resultCacheStream) stop() {
+	stream.cancel()
+	<-stream.done
+}
+
+// close stops all the streams.
+func (rci *resultCacheInvalidator) close() {
+	rci.mu.Lock()
+	defer rci.mu.Unlock()
+	rci.closed = true
+	for keyspace, stream := range rci.streams {
+		stream.stop()
+		delete(rci.streams, keyspace)
+	}
+}
+
+// startResultCacheInvalidation starts invalidating the cached results of
+// the tables with a result cache ttl in the VSchema as soon as their rows
+// change. It returns a function that stops it.
+func (e *Executor) startResultCacheInvalidation(vstreamer resultCacheVStreamer) func() {
+	if e.resultCache == nil {
+		return func() {}
+	}
+	rci := newResultCacheInvalidator(e.resultCache, vstreamer)
+
+	e.mu.Lock()
+	e.resultCacheInvalidator = rci
+	vschema := e.vschema
+	e.mu.Unlock()
+
+	rci.update(vschema)
+	return rci.close
+}
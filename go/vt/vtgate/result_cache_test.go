@@ -0,0 +1,320 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/vtgate/vindexes"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+func TestExecutorResultCache(t *testing.T) {
+	executor, _, _, sbclookup, ctx := createExecutorEnv(t)
+	executor.resultCache = newResultCache(1 << 20)
+
+	result := sqltypes.MakeTestResult(sqltypes.MakeTestFields("id", "int64"), "1")
+	results := make([]*sqltypes.Result, 40)
+	for i := range results {
+		results[i] = result
+	}
+	sbclookup.SetResults(results)
+	session := &vtgatepb.Session{TargetString: "@primary", Autocommit: true}
+
+	execCount := sbclookup.ExecCount.Load()
+	exec := func(sql string, bv map[string]*querypb.BindVariable, cached bool) {
+		t.Helper()
+		qr, err := executorExec(ctx, executor, session, sql, bv)
+		require.NoError(t, err)
+		assert.Equal(t, result.Rows, qr.Rows)
+		if !cached {
+			execCount++
+		}
+		assert.Equal(t, execCount, sbclookup.ExecCount.Load(), "cached: %v", cached)
+	}
+
+	// The tables do not enable the result cache.
+	exec("select id from simple", nil, false)
+	exec("select id from simple", nil, false)
+
+	// The comment directive enables it.
+	query := "select /*vt+ RESULT_CACHE_TTL=1m */ id from simple where id = :id"
+	exec(query, map[string]*querypb.BindVariable{"id": sqltypes.Int64BindVariable(1)}, false)
+	exec(query, map[string]*querypb.BindVariable{"id": sqltypes.Int64BindVariable(1)}, true)
+	exec(query, map[string]*querypb.BindVariable{"id": sqltypes.Int64BindVariable(2)}, false)
+
+	// DMLs executed by vtgate invalidate the results of their tables.
+	_, err := executorExec(ctx, executor, session, "update simple set id = 3 where id = 1", nil)
+	require.NoError(t, err)
+	execCount++
+	exec(query, map[string]*querypb.BindVariable{"id": sqltypes.Int64BindVariable(1)}, false)
+	exec(query, map[string]*querypb.BindVariable{"id": sqltypes.Int64BindVariable(1)}, true)
+
+	// So do the schema changes seen by the schema tracker.
+	executor.InvalidateResultCache(KsTestUnsharded, []string{"simple"})
+	exec(query, map[string]*querypb.BindVariable{"id": sqltypes.Int64BindVariable(1)}, false)
+
+	// The VSchema of the table enables it.
+	executor.VSchema().Keyspaces[KsTestUnsharded].Tables["simple"].ResultCacheTTL = time.Minute
+	exec("select id from simple", nil, false)
+	exec("select id from simple", nil, true)
+	exec("select /*vt+ RESULT_CACHE_TTL=0 */ id from simple", nil, false)
+	exec("select /*vt+ RESULT_CACHE_TTL=0 */ id from simple", nil, false)
+
+	// The queries calling non-deterministic functions are not cached.
+	exec("select id from simple where id < rand()", nil, false)
+	exec("select id from simple where id < rand()", nil, false)
+	exec("select id, now() from simple", nil, false)
+	exec("select id, now() from simple", nil, false)
+	exec("select /*vt+ RESULT_CACHE_TTL=1m */ id from simple where id < unix_timestamp()", nil, false)
+	exec("select /*vt+ RESULT_CACHE_TTL=1m */ id from simple where id < unix_timestamp()", nil, false)
+	exec("select rand(), length(id) from simple", nil, false)
+	exec("select rand(), length(id) from simple", nil, false)
+	exec("select now(), abs(id) from simple", nil, false)
+	exec("select now(), abs(id) from simple", nil, false)
+	exec("select abs(id), uuid() from simple", nil, false)
+	exec("select abs(id), uuid() from simple", nil, false)
+
+	// The sessions with different system variables do not share results.
+	exec("select id from simple where id > 1", nil, false)
+	exec("select id from simple where id > 1", nil, true)
+	session.SystemVariables = map[string]string{"time_zone": "'+01:00'"}
+	exec("select id from simple where id > 1", nil, false)
+	exec("select id from simple where id > 1", nil, true)
+	session.SystemVariables = map[string]string{"time_zone": "'+02:00'"}
+	exec("select id from simple where id > 1", nil, false)
+	session.SystemVariables = nil
+	exec("select id from simple where id > 1", nil, true)
+
+	// The results are not cached in a transaction.
+	session.InTransaction = true
+	session.ShardSessions = []*vtgatepb.Session_ShardSession{{
+		Target:        &querypb.Target{Keyspace: KsTestUnsharded, Shard: "0", TabletType: topodatapb.TabletType_PRIMARY},
+		TransactionId: 1,
+		TabletAlias:   sbclookup.Tablet().Alias,
+	}}
+	exec("select id from simple where 1 = 1", nil, false)
+	exec("select id from simple where 1 = 1", nil, false)
+}
+
+func TestExecutorResultCacheTransaction(t *testing.T) {
+	executor, _, _, sbclookup, ctx := createExecutorEnv(t)
+	executor.resultCache = newResultCache(1 << 20)
+	executor.VSchema().Keyspaces[KsTestUnsharded].Tables["simple"].ResultCacheTTL = time.Minute
+
+	result := sqltypes.MakeTestResult(sqltypes.MakeTestFields("id", "int64"), "1")
+	results := make([]*sqltypes.Result, 20)
+	for i := range results {
+		results[i] = result
+	}
+	sbclookup.SetResults(results)
+	session := &vtgatepb.Session{TargetString: "@primary", Autocommit: true}
+	txSession := &vtgatepb.Session{TargetString: "@primary", Autocommit: true}
+
+	execCount := sbclookup.ExecCount.Load()
+	exec := func(cached bool) {
+		t.Helper()
+		_, err := executorExec(ctx, executor, session, "select id from simple", nil)
+		require.NoError(t, err)
+		if !cached {
+			execCount++
+		}
+		assert.Equal(t, execCount, sbclookup.ExecCount.Load(), "cached: %v", cached)
+	}
+	execTx := func(sql string) error {
+		t.Helper()
+		_, err := executorExec(ctx, executor, txSession, sql, nil)
+		execCount = sbclookup.ExecCount.Load()
+		return err
+	}
+
+	exec(false)
+	exec(true)
+
+	// The results read outside of the transaction before it commits are
+	// the ones before its changes, so they are invalidated when it commits.
+	require.NoError(t, execTx("begin"))
+	require.NoError(t, execTx("update simple set id = 3 where id = 1"))
+	assert.Equal(t, []string{KsTestUnsharded + ".simple"}, txSession.ResultCacheInvalidations)
+	exec(false)
+	exec(true)
+	require.NoError(t, execTx("commit"))
+	assert.Empty(t, txSession.ResultCacheInvalidations)
+	exec(false)
+	exec(true)
+
+	// The changes of a rolled back transaction are not invalidated again.
+	require.NoError(t, execTx("begin"))
+	require.NoError(t, execTx("update simple set id = 3 where id = 1"))
+	exec(false)
+	require.NoError(t, execTx("rollback"))
+	assert.Empty(t, txSession.ResultCacheInvalidations)
+	exec(true)
+
+	// The DMLs that fail also invalidate the results of their tables, since
+	// they may have changed some rows before they did.
+	sbclookup.MustFailCodes[vtrpcpb.Code_INVALID_ARGUMENT] = 1
+	require.Error(t, execTx("update simple set id = 3 where id = 1"))
+	exec(false)
+}
+
+func TestResultCacheExpiry(t *testing.T) {
+	rc := newResultCache(1 << 20)
+	defer rc.close()
+
+	result := sqltypes.MakeTestResult(sqltypes.MakeTestFields("id", "int64"), "1")
+	tables := []string{"ks.t1", "ks.t2"}
+
+	rc.set(resultCacheKey{1}, result, time.Minute, tables, rc.generations(tables))
+	rc.set(resultCacheKey{2}, result, time.Millisecond, tables, rc.generations(tables))
+	time.Sleep(10 * time.Millisecond)
+
+	qr, ok := rc.get(resultCacheKey{1})
+	require.True(t, ok)
+	assert.Equal(t, result.Rows, qr.Rows)
+	_, ok = rc.get(resultCacheKey{2})
+	assert.False(t, ok)
+
+	// The results read before a table was invalidated are not cached.
+	generations := rc.generations([]string{"ks.t3"})
+	rc.invalidateTables([]string{"ks.t3"})
+	rc.set(resultCacheKey{3}, result, time.Minute, []string{"ks.t3"}, generations)
+	_, ok = rc.get(resultCacheKey{3})
+	assert.False(t, ok)
+
+	rc.invalidateKeyspace("other")
+	_, ok = rc.get(resultCacheKey{1})
+	assert.True(t, ok)
+	rc.invalidateKeyspace("ks")
+	_, ok = rc.get(resultCacheKey{1})
+	assert.False(t, ok)
+}
+
+type fakeResultCacheVStreamer struct {
+	mu      sync.Mutex
+	streams map[string]func(events []*binlogdatapb.VEvent) error
+	filters map[string]*binlogdatapb.Filter
+	// hold, when set, delays the end of the canceled streams until it is
+	// closed.
+	hold chan struct{}
+}
+
+func (vs *fakeResultCacheVStreamer) VStream(ctx context.Context, tabletType topodatapb.TabletType, vgtid *binlogdatapb.VGtid, filter *binlogdatapb.Filter, flags *vtgatepb.VStreamFlags, send func(events []*binlogdatapb.VEvent) error) error {
+	keyspace := vgtid.ShardGtids[0].Keyspace
+	vs.mu.Lock()
+	vs.streams[keyspace] = send
+	vs.filters[keyspace] = filter
+	vs.mu.Unlock()
+
+	<-ctx.Done()
+
+	vs.mu.Lock()
+	hold := vs.hold
+	vs.mu.Unlock()
+	if hold != nil {
+		<-hold
+	}
+
+	vs.mu.Lock()
+	delete(vs.streams, keyspace)
+	vs.mu.Unlock()
+	return ctx.Err()
+}
+
+func (vs *fakeResultCacheVStreamer) send(t *testing.T, keyspace string, events ...*binlogdatapb.VEvent) {
+	var send func(events []*binlogdatapb.VEvent) error
+	require.Eventually(t, func() bool {
+		vs.mu.Lock()
+		defer vs.mu.Unlock()
+		send = vs.streams[keyspace]
+		return send != nil
+	}, 5*time.Second, time.Millisecond)
+	require.NoError(t, send(events))
+}
+
+func TestResultCacheInvalidator(t *testing.T) {
+	rc := newResultCache(1 << 20)
+	defer rc.close()
+	vstreamer := &fakeResultCacheVStreamer{
+		streams: make(map[string]func(events []*binlogdatapb.VEvent) error),
+		filters: make(map[string]*binlogdatapb.Filter),
+	}
+	rci := newResultCacheInvalidator(rc, vstreamer)
+	defer rci.close()
+
+	vschema := &vindexes.VSchema{Keyspaces: map[string]*vindexes.KeyspaceSchema{
+		"ks": {Tables: map[string]*vindexes.Table{
+			"t1": {ResultCacheTTL: time.Minute},
+			"t2": {ResultCacheTTL: time.Second},
+			"t3": {},
+		}},
+		"other": {Tables: map[string]*vindexes.Table{
+			"t1": {},
+		}},
+	}}
+	rci.update(vschema)
+
+	// The first events invalidate the keyspace, since the changes made
+	// before the stream started were missed.
+	tables := []string{"ks.t1", "ks.t2"}
+	generations := rc.generations(tables)
+	vstreamer.send(t, "ks", &binlogdatapb.VEvent{Type: binlogdatapb.VEventType_BEGIN})
+	assert.Equal(t, []uint64{generations[0] + 1, generations[1] + 1}, rc.generations(tables))
+
+	vstreamer.mu.Lock()
+	assert.Equal(t, []*binlogdatapb.Rule{{Match: "t1"}, {Match: "t2"}}, vstreamer.filters["ks"].Rules)
+	assert.NotContains(t, vstreamer.streams, "other")
+	vstreamer.mu.Unlock()
+
+	generations = rc.generations(tables)
+	vstreamer.send(t, "ks",
+		&binlogdatapb.VEvent{Type: binlogdatapb.VEventType_ROW, RowEvent: &binlogdatapb.RowEvent{TableName: "ks.t2"}},
+		&binlogdatapb.VEvent{Type: binlogdatapb.VEventType_COMMIT},
+	)
+	assert.Equal(t, []uint64{generations[0], generations[1] + 1}, rc.generations(tables))
+
+	// The stream is stopped once no table of the keyspace enables the
+	// result cache. The update does not wait for the stopped stream to
+	// end, the returned function does.
+	hold := make(chan struct{})
+	vstreamer.mu.Lock()
+	vstreamer.hold = hold
+	vstreamer.mu.Unlock()
+	vschema.Keyspaces["ks"].Tables["t1"].ResultCacheTTL = 0
+	vschema.Keyspaces["ks"].Tables["t2"].ResultCacheTTL = 0
+	wait := rci.update(vschema)
+	vstreamer.mu.Lock()
+	assert.Contains(t, vstreamer.streams, "ks")
+	vstreamer.mu.Unlock()
+	close(hold)
+	wait()
+	vstreamer.mu.Lock()
+	assert.Empty(t, vstreamer.streams)
+	vstreamer.mu.Unlock()
+}
@@ -18,6 +18,7 @@ package vtgate
 
 import (
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -26,6 +27,7 @@ import (
 	"google.golang.org/protobuf/proto"
 
 	"vitess.io/vitess/go/mysql/datetime"
+	"vitess.io/vitess/go/sqltypes"
 
 	"vitess.io/vitess/go/vt/sqlparser"
 	"vitess.io/vitess/go/vt/srvtopo"
@@ -198,6 +200,8 @@ func (session *SafeSession) resetCommonLocked() {
 	session.Session.InTransaction = false
 	session.commitOrder = vtgatepb.CommitOrder_NORMAL
 	session.Savepoints = nil
+	session.ResultCacheInvalidations = nil
+	session.LookupCacheInvalidations = nil
 	if session.Options != nil {
 		session.Options.TransactionAccessMode = nil
 	}
@@ -217,6 +221,82 @@ func (session *SafeSession) GetQueryTimeout() int64 {
 	return session.QueryTimeout
 }
 
+// SetMaxReplicaLag sets the maximum replication lag of the replicas the session reads from
+func (session *SafeSession) SetMaxReplicaLag(maxReplicaLag time.Duration) {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	session.MaxReplicaLag = maxReplicaLag.Milliseconds()
+}
+
+// GetMaxReplicaLag gets the maximum replication lag of the replicas the session reads from
+func (session *SafeSession) GetMaxReplicaLag() time.Duration {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	return time.Duration(session.MaxReplicaLag) * time.Millisecond
+}
+
+// AddResultCacheInvalidations records the tables changed by the transaction,
+// whose cached results are invalidated again when it commits.
+func (session *SafeSession) AddResultCacheInvalidations(tables []string) {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	for _, table := range tables {
+		if !slices.Contains(session.ResultCacheInvalidations, table) {
+			session.ResultCacheInvalidations = append(session.ResultCacheInvalidations, table)
+		}
+	}
+}
+
+// GetResultCacheInvalidations returns the tables changed by the transaction.
+func (session *SafeSession) GetResultCacheInvalidations() []string {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	return session.ResultCacheInvalidations
+}
+
+// maxLookupCacheInvalidationIds is the number of ids recorded per lookup table
+// in the session, after which all of its ids are invalidated at commit.
+const maxLookupCacheInvalidationIds = 1000
+
+// AddLookupCacheInvalidation records the ids changed by the transaction in the
+// lookup table, which are invalidated again in its cache when it commits. All
+// the ids of the lookup table are invalidated when ids is nil.
+func (session *SafeSession) AddLookupCacheInvalidation(table, column string, ids []sqltypes.Value) {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	var invalidation *vtgatepb.LookupCacheInvalidation
+	for _, inv := range session.LookupCacheInvalidations {
+		if inv.Table == table && inv.Column == column {
+			invalidation = inv
+			break
+		}
+	}
+	if invalidation == nil {
+		invalidation = &vtgatepb.LookupCacheInvalidation{Table: table, Column: column}
+		session.LookupCacheInvalidations = append(session.LookupCacheInvalidations, invalidation)
+	} else if len(invalidation.Ids) == 0 {
+		// all the ids are already invalidated
+		return
+	}
+	if ids == nil {
+		invalidation.Ids = nil
+		return
+	}
+	for _, id := range ids {
+		invalidation.Ids = append(invalidation.Ids, sqltypes.ValueToProto(id))
+	}
+	if len(invalidation.Ids) > maxLookupCacheInvalidationIds {
+		invalidation.Ids = nil
+	}
+}
+
+// GetLookupCacheInvalidations returns the lookup ids changed by the transaction.
+func (session *SafeSession) GetLookupCacheInvalidations() []*vtgatepb.LookupCacheInvalidation {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	return session.LookupCacheInvalidations
+}
+
 // SavePoints returns the save points of the session. It's safe to use concurrently
 func (session *SafeSession) SavePoints() []string {
 	session.mu.Lock()
@@ -782,6 +862,16 @@ func (session *SafeSession) SetReadAfterWriteGTID(vtgtid string) {
 	session.ReadAfterWrite.ReadAfterWriteGtid = vtgtid
 }
 
+// SetSessionToken set the SessionToken setting.
+func (session *SafeSession) SetSessionToken(token string) {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	if session.ReadAfterWrite == nil {
+		session.ReadAfterWrite = &vtgatepb.ReadAfterWrite{}
+	}
+	session.ReadAfterWrite.SessionToken = token
+}
+
 // SetReadAfterWriteTimeout set the ReadAfterWriteTimeout setting.
 func (session *SafeSession) SetReadAfterWriteTimeout(timeout float64) {
 	session.mu.Lock()
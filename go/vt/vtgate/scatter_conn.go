@@ -178,7 +178,10 @@ func (stc *ScatterConn) ExecuteMultiShard(
 			reservedID := info.reservedID
 
 			if session != nil && session.Session != nil {
-				opts = session.Session.Options
+				opts, err = session.executeOptions(rs.Target)
+				if err != nil {
+					return nil, err
+				}
 			}
 
 			if autocommit {
@@ -257,6 +260,9 @@ func (stc *ScatterConn) ExecuteMultiShard(
 			if err != nil {
 				return newInfo, err
 			}
+			if autocommit {
+				session.updateSessionToken(rs.Target, innerqr.SessionStateChanges)
+			}
 			mu.Lock()
 			defer mu.Unlock()
 
@@ -376,7 +382,10 @@ func (stc *ScatterConn) StreamExecuteMulti(
 			reservedID := info.reservedID
 
 			if session != nil && session.Session != nil {
-				opts = session.Session.Options
+				opts, err = session.executeOptions(rs.Target)
+				if err != nil {
+					return nil, err
+				}
 			}
 
 			if autocommit {
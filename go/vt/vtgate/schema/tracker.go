@@ -49,6 +49,8 @@ type (
 		udfs   map[keyspaceStr][]string
 		ctx    context.Context
 		signal func() // a function that we'll call whenever we have new schema data
+		// tablesChanged is called with the tables and views whose schema changed
+		tablesChanged func(keyspace string, tables []string)
 
 		// map of keyspace currently tracked
 		tracked      map[keyspaceStr]*updateController
@@ -230,6 +232,7 @@ func (t *Tracker) initKeyspace(th *discovery.TabletHealth) error {
 		log.Warningf("Unable to add the %s keyspace to the schema tracker: %v", th.Target.Keyspace, err)
 		return err
 	}
+	t.notifyTablesChanged(th.Target.Keyspace, nil)
 	return nil
 }
 
@@ -314,11 +317,17 @@ func (t *Tracker) updateSchema(th *discovery.TabletHealth) bool {
 	if !success {
 		return false
 	}
+	if len(th.Stats.TableSchemaChanged) > 0 {
+		t.notifyTablesChanged(th.Target.Keyspace, th.Stats.TableSchemaChanged)
+	}
 
 	// there is view definition change in the tablet
 	if th.Stats.ViewSchemaChanged != nil {
 		success = t.updatedViewSchema(th)
 	}
+	if success && len(th.Stats.ViewSchemaChanged) > 0 {
+		t.notifyTablesChanged(th.Target.Keyspace, th.Stats.ViewSchemaChanged)
+	}
 
 	if !success || !th.Stats.UdfsChanged {
 		return success
@@ -389,6 +398,7 @@ func getColumns(tblSpec *sqlparser.TableSpec) []vindexes.Column {
 				Scale:         int32(scale),
 				Nullable:      nullable,
 				Values:        column.Type.EnumValues,
+				Generated:     column.Type.Options.As != nil,
 			})
 	}
 	return cols
@@ -475,6 +485,23 @@ func (t *Tracker) RegisterSignalReceiver(f func()) {
 	t.signal = f
 }
 
+// RegisterTablesChangedReceiver allows a function to register to be called with the tables and views
+// of a keyspace whose schema changed. The tables are nil when the schema of the whole keyspace was loaded.
+func (t *Tracker) RegisterTablesChangedReceiver(f func(keyspace string, tables []string)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tablesChanged = f
+}
+
+func (t *Tracker) notifyTablesChanged(keyspace string, tables []string) {
+	t.mu.Lock()
+	f := t.tablesChanged
+	t.mu.Unlock()
+	if f != nil {
+		f(keyspace, tables)
+	}
+}
+
 // AddNewKeyspace adds keyspace to the tracker.
 func (t *Tracker) AddNewKeyspace(conn queryservice.QueryService, target *querypb.Target) error {
 	updateController := t.newUpdateController()
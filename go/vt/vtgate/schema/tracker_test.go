@@ -94,6 +94,16 @@ func TestTrackingUnHealthyTablet(t *testing.T) {
 	tracker.RegisterSignalReceiver(func() {
 		wg.Done()
 	})
+	var (
+		mu      sync.Mutex
+		changed [][]string
+	)
+	tracker.RegisterTablesChangedReceiver(func(ks string, tables []string) {
+		assert.Equal(t, keyspace, ks)
+		mu.Lock()
+		defer mu.Unlock()
+		changed = append(changed, tables)
+	})
 
 	tcases := []struct {
 		name          string
@@ -200,7 +210,7 @@ func TestTableTracking(t *testing.T) {
 			tbl("t3", "create table t3(id datetime primary key)"),
 		),
 		tables(
-			tbl("t4", "create table t4(name varchar(50) primary key)"),
+			tbl("t4", "create table t4(name varchar(50) primary key, name_len int as (length(name)))"),
 		),
 	}
 
@@ -232,7 +242,7 @@ func TestTableTracking(t *testing.T) {
 			"t1": {{Name: sqlparser.NewIdentifierCI("id"), Type: querypb.Type_INT64, CollationName: "binary", Nullable: true}, {Name: sqlparser.NewIdentifierCI("name"), Type: querypb.Type_VARCHAR, Size: 50, Nullable: true}, {Name: sqlparser.NewIdentifierCI("email"), Type: querypb.Type_VARCHAR, Size: 50, Nullable: false, Default: &sqlparser.Literal{Val: "a@b.com"}}},
 			"T1": {{Name: sqlparser.NewIdentifierCI("id"), Type: querypb.Type_VARCHAR, Size: 50, Nullable: true}, {Name: sqlparser.NewIdentifierCI("name"), Type: querypb.Type_VARCHAR, Size: 50, Nullable: true}},
 			"t3": {{Name: sqlparser.NewIdentifierCI("id"), Type: querypb.Type_DATETIME, CollationName: "binary", Size: 0, Nullable: true}},
-			"t4": {{Name: sqlparser.NewIdentifierCI("name"), Type: querypb.Type_VARCHAR, Size: 50, Nullable: true}},
+			"t4": {{Name: sqlparser.NewIdentifierCI("name"), Type: querypb.Type_VARCHAR, Size: 50, Nullable: true}, {Name: sqlparser.NewIdentifierCI("name_len"), Type: querypb.Type_INT32, CollationName: "binary", Nullable: true, Generated: true}},
 		},
 	}}
 
@@ -479,6 +489,16 @@ func testTracker(t *testing.T, enableUDFs bool, schemaDefResult []sandboxconn.Sc
 	tracker.RegisterSignalReceiver(func() {
 		wg.Done()
 	})
+	var (
+		mu      sync.Mutex
+		changed [][]string
+	)
+	tracker.RegisterTablesChangedReceiver(func(ks string, tables []string) {
+		assert.Equal(t, keyspace, ks)
+		mu.Lock()
+		defer mu.Unlock()
+		changed = append(changed, tables)
+	})
 
 	target := &querypb.Target{Cell: cell, Keyspace: keyspace, Shard: "-80", TabletType: topodatapb.TabletType_PRIMARY}
 	tablet := &topodatapb.Tablet{Keyspace: target.Keyspace, Shard: target.Shard, Type: target.TabletType}
@@ -504,6 +524,22 @@ func testTracker(t *testing.T, enableUDFs bool, schemaDefResult []sandboxconn.Sc
 			require.False(t, waitTimeout(&wg, time.Second), "schema was updated but received no signal")
 			require.EqualValues(t, count+initialLoadCount, sbc.GetSchemaCount.Load())
 
+			// the whole keyspace is loaded the first time, and then only the updated tables and views.
+			expChanged := [][]string{nil}
+			if count > 0 {
+				expChanged = nil
+				if len(tcase.updTbl) > 0 {
+					expChanged = append(expChanged, tcase.updTbl)
+				}
+				if len(tcase.updView) > 0 {
+					expChanged = append(expChanged, tcase.updView)
+				}
+			}
+			mu.Lock()
+			assert.Equal(t, expChanged, changed, "mismatch changed tables")
+			changed = nil
+			mu.Unlock()
+
 			_, keyspacePresent := tracker.tracked[target.Keyspace]
 			require.Equal(t, true, keyspacePresent)
 
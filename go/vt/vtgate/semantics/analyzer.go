@@ -76,6 +76,7 @@ func (a *analyzer) lateInit() {
 		expandedColumns: map[sqlparser.TableName][]*sqlparser.ColName{},
 		env:             a.si.Environment(),
 		aliasMapCache:   map[*sqlparser.Select]map[string]exprContainer{},
+		recursiveCTEs:   map[*sqlparser.Union]*RecursiveCTE{},
 		reAnalyze:       a.reAnalyze,
 		tables:          a.tables,
 		aggrUDFs:        a.si.GetAggregateUDFs(),
@@ -181,6 +182,7 @@ func (a *analyzer) newSemTable(
 		ColumnEqualities:          map[columnName][]sqlparser.Expr{},
 		Collation:                 coll,
 		ExpandedColumns:           a.rewriter.expandedColumns,
+		RecursiveCTEs:             a.rewriter.recursiveCTEs,
 		columns:                   columns,
 		StatementIDs:              a.scoper.statementIDs,
 		QuerySignature:            a.sig,
@@ -453,7 +455,13 @@ func (a *analyzer) noteQuerySignature(node sqlparser.SQLNode) {
 			a.sig.Aggregation = true
 		}
 	case sqlparser.AggrFunc:
+		if sqlparser.GetOverClause(node) != nil {
+			a.sig.Window = true
+			break
+		}
 		a.sig.Aggregation = true
+	case *sqlparser.OverClause:
+		a.sig.Window = true
 	case *sqlparser.Delete, *sqlparser.Update, *sqlparser.Insert:
 		a.sig.DML = true
 	}
@@ -872,9 +872,6 @@ func TestInvalidQueries(t *testing.T) {
 	}, {
 		sql:  "select is_free_lock('xyz') from user",
 		serr: "is_free_lock('xyz') allowed only with dual",
-	}, {
-		sql: "SELECT * FROM JSON_TABLE('[ {\"c1\": null} ]','$[*]' COLUMNS( c1 INT PATH '$.c1' ERROR ON ERROR )) as jt",
-		err: &JSONTablesError{},
 	}, {
 		sql:             "select does_not_exist from t1",
 		notUnshardedErr: "column 'does_not_exist' not found in table 't1'",
@@ -888,8 +885,17 @@ func TestInvalidQueries(t *testing.T) {
 		sql:  "select 1 from t1 where (id, id) in (select 1, 2, 3)",
 		serr: "Operand should contain 2 column(s)",
 	}, {
-		sql:  "WITH RECURSIVE cte (n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM cte WHERE n < 5) SELECT * FROM cte",
-		serr: "VT12001: unsupported: recursive common table expression",
+		sql:  "WITH RECURSIVE cte (n) AS (SELECT 1 UNION SELECT n + 1 FROM cte WHERE n < 5) SELECT * FROM cte",
+		serr: "VT12001: unsupported: recursive common table expression using UNION DISTINCT",
+	}, {
+		sql:  "WITH RECURSIVE cte (n) AS (SELECT 1 UNION ALL SELECT max(n) + 1 FROM cte WHERE n < 5) SELECT * FROM cte",
+		serr: "VT12001: unsupported: DISTINCT, GROUP BY, HAVING, ORDER BY, LIMIT or aggregation in the recursive part of a common table expression",
+	}, {
+		sql:  "WITH RECURSIVE cte AS (SELECT id FROM t1 UNION ALL SELECT t1.id FROM t1 LEFT JOIN cte ON t1.col = cte.id) SELECT * FROM cte",
+		serr: "VT12001: unsupported: outer join with a recursive common table expression",
+	}, {
+		sql:  "WITH RECURSIVE cte AS (SELECT id FROM t1 UNION ALL SELECT t1.id FROM t1 JOIN cte ON t1.col = id) SELECT * FROM cte",
+		serr: "VT12001: unsupported: unqualified column 'id' in the recursive part of a common table expression",
 	}, {
 		sql:  "with x as (select 1), x as (select 1) select * from x",
 		serr: "VT03013: not unique table/alias: 'x'",
@@ -107,6 +107,34 @@ func TestScopingWDerivedTables(t *testing.T) {
 			query:         "select uu.count from (select count(*) as `count` from t1) uu",
 			directDeps:    TS1,
 			recursiveDeps: TS0,
+		}, {
+			query:         "select t.col from user as u, lateral (select t1.col from t1 where t1.id = u.id) as t",
+			directDeps:    TS2,
+			recursiveDeps: TS1,
+		}, {
+			query:         "select t.uid from user as u join lateral (select u.id as uid from t1) as t",
+			directDeps:    TS2,
+			recursiveDeps: TS1,
+		}, {
+			query:        "select t.col from (select t1.col from t1 where t1.id = u.id) as t, user as u",
+			errorMessage: "column 'u.id' not found",
+		}, {
+			query:        "select t.col from lateral (select t1.col from t1 where t1.id = u.id) as t, user as u",
+			errorMessage: "column 'u.id' not found",
+		}, {
+			query:         "select jt.a from user as u, json_table(u.doc, '$[*]' columns (a int path '$.a')) as jt",
+			directDeps:    TS1,
+			recursiveDeps: TS1,
+		}, {
+			query:         "select jt.b from user as u join json_table(u.doc, '$[*]' columns (a int path '$.a', nested path '$.b[*]' columns (b int path '$'))) as jt on true",
+			directDeps:    TS1,
+			recursiveDeps: TS1,
+		}, {
+			query:        "select jt.a from json_table(u.doc, '$[*]' columns (a int path '$.a')) as jt, user as u",
+			errorMessage: "column 'u.doc' not found",
+		}, {
+			query:        "select jt.c from user as u, json_table(u.doc, '$[*]' columns (a int path '$.a')) as jt",
+			errorMessage: "column 'jt.c' not found",
 		}}
 	for _, query := range queries {
 		t.Run(query.query, func(t *testing.T) {
@@ -18,6 +18,7 @@ package semantics
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
 
 	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
@@ -35,6 +36,7 @@ type earlyRewriter struct {
 	expandedColumns map[sqlparser.TableName][]*sqlparser.ColName
 	env             *vtenv.Environment
 	aliasMapCache   map[*sqlparser.Select]map[string]exprContainer
+	recursiveCTEs   map[*sqlparser.Union]*RecursiveCTE
 	tables          *tableCollector
 
 	// reAnalyze is used when we are running in the late stage, after the other parts of semantic analysis
@@ -141,6 +143,11 @@ func (r *earlyRewriter) handleAliasedTable(node *sqlparser.AliasedTableExpr) err
 func (r *earlyRewriter) handleWith(node *sqlparser.With) error {
 	scope := r.scoper.currentScope()
 	for _, cte := range node.CTEs {
+		if node.Recursive && refersToTable(cte.Subquery.Select, cte.ID.String()) {
+			if err := r.rewriteRecursiveCTE(cte); err != nil {
+				return err
+			}
+		}
 		err := scope.addCTE(cte)
 		if err != nil {
 			return err
@@ -150,6 +157,189 @@ func (r *earlyRewriter) handleWith(node *sqlparser.With) error {
 	return nil
 }
 
+// rewriteRecursiveCTE rewrites a recursive common table expression so that the recursive part no longer
+// refers to the CTE. The CTE table is removed from the FROM clause of the recursive part, and the columns
+// read from it are replaced by arguments that are bound to the rows produced by the previous iteration.
+// The resulting UNION ALL is recorded so the planner can evaluate it at the vtgate level, unless both
+// parts end up on the same shard, in which case the planner sends the recursive CTE to that shard as is.
+func (r *earlyRewriter) rewriteRecursiveCTE(cte *sqlparser.CommonTableExpr) error {
+	name := cte.ID.String()
+	union, ok := cte.Subquery.Select.(*sqlparser.Union)
+	if !ok {
+		return vterrors.VT12001("recursive common table expression without UNION ALL")
+	}
+	if union.Distinct {
+		return vterrors.VT12001("recursive common table expression using UNION DISTINCT")
+	}
+	if union.OrderBy != nil || union.Limit != nil {
+		return vterrors.VT12001("ORDER BY or LIMIT in a recursive common table expression")
+	}
+	if refersToTable(union.Left, name) {
+		return vterrors.VT12001("recursive common table expression with more than one recursive part")
+	}
+	term, ok := union.Right.(*sqlparser.Select)
+	if !ok {
+		return vterrors.VT12001("recursive part of a common table expression that is not a simple SELECT")
+	}
+	if term.Distinct || term.GroupBy != nil || term.Having != nil || term.OrderBy != nil || term.Limit != nil ||
+		term.With != nil || sqlparser.ContainsAggregation(term.SelectExprs) {
+		return vterrors.VT12001("DISTINCT, GROUP BY, HAVING, ORDER BY, LIMIT or aggregation in the recursive part of a common table expression")
+	}
+
+	columns := cte.Columns
+	if len(columns) == 0 {
+		for _, expr := range sqlparser.GetFirstSelect(union.Left).SelectExprs {
+			ae, ok := expr.(*sqlparser.AliasedExpr)
+			if !ok {
+				return vterrors.VT12001("'*' expression in the seed of a recursive common table expression")
+			}
+			columns = append(columns, sqlparser.NewIdentifierCI(ae.ColumnName()))
+		}
+	}
+
+	var from sqlparser.TableExprs
+	var cteTable *sqlparser.AliasedTableExpr
+	var predicates []sqlparser.Expr
+	for _, expr := range term.From {
+		rest, found, pred, err := extractRecursiveTable(expr, name)
+		if err != nil {
+			return err
+		}
+		if found != nil {
+			if cteTable != nil {
+				return vterrors.VT12001("recursive common table expression referenced more than once in its recursive part")
+			}
+			cteTable = found
+			predicates = append(predicates, pred)
+		}
+		if rest != nil {
+			from = append(from, rest)
+		}
+	}
+	if cteTable == nil {
+		return vterrors.VT12001("recursive common table expression referenced in a subquery or derived table")
+	}
+	otherTables := len(from) > 0
+	if !otherTables {
+		from = sqlparser.TableExprs{&sqlparser.AliasedTableExpr{Expr: sqlparser.NewTableName("dual")}}
+	}
+	term.From = from
+	if pred := sqlparser.AndExpressions(predicates...); pred != nil {
+		term.AddWhere(pred)
+	}
+
+	alias := name
+	if !cteTable.As.IsEmpty() {
+		alias = cteTable.As.String()
+	}
+	id := len(r.recursiveCTEs) + 1
+	vars := map[string]int{}
+	var err error
+	_ = sqlparser.Rewrite(term, nil, func(cursor *sqlparser.Cursor) bool {
+		col, ok := cursor.Node().(*sqlparser.ColName)
+		if !ok {
+			return true
+		}
+		qualified := col.Qualifier.NonEmpty()
+		if qualified && (col.Qualifier.Qualifier.NotEmpty() || col.Qualifier.Name.String() != alias) {
+			return true
+		}
+		idx := slices.IndexFunc(columns, func(c sqlparser.IdentifierCI) bool {
+			return c.Equal(col.Name)
+		})
+		switch {
+		case idx < 0 && qualified:
+			err = vterrors.VT03014(sqlparser.String(col), "recursive common table expression")
+			return false
+		case idx < 0:
+			return true
+		case !qualified && otherTables:
+			err = vterrors.VT12001(fmt.Sprintf("unqualified column '%s' in the recursive part of a common table expression", sqlparser.String(col)))
+			return false
+		}
+		argName := fmt.Sprintf("__recurse%d_%d", id, idx)
+		vars[argName] = idx
+		cursor.Replace(sqlparser.NewArgument(argName))
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	if refersToTable(term, name) {
+		return vterrors.VT12001("recursive common table expression referenced in a subquery or derived table")
+	}
+
+	r.recursiveCTEs[union] = &RecursiveCTE{
+		Name:    name,
+		Alias:   alias,
+		Columns: columns,
+		Vars:    vars,
+	}
+	return nil
+}
+
+// extractRecursiveTable removes the recursive CTE table from the given table expression.
+// It returns what is left of the table expression, the removed table and the join predicate
+// that has to be moved to the WHERE clause of the recursive part.
+func extractRecursiveTable(expr sqlparser.TableExpr, name string) (sqlparser.TableExpr, *sqlparser.AliasedTableExpr, sqlparser.Expr, error) {
+	switch expr := expr.(type) {
+	case *sqlparser.AliasedTableExpr:
+		tbl, ok := expr.Expr.(sqlparser.TableName)
+		if ok && tbl.Qualifier.IsEmpty() && tbl.Name.String() == name {
+			return nil, expr, nil, nil
+		}
+	case *sqlparser.JoinTableExpr:
+		lhs, lFound, lPred, err := extractRecursiveTable(expr.LeftExpr, name)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		rhs, rFound, rPred, err := extractRecursiveTable(expr.RightExpr, name)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if lFound == nil && rFound == nil {
+			return expr, nil, nil, nil
+		}
+		if lFound != nil && rFound != nil {
+			return nil, nil, nil, vterrors.VT12001("recursive common table expression referenced more than once in its recursive part")
+		}
+		if expr.Join != sqlparser.NormalJoinType && expr.Join != sqlparser.StraightJoinType {
+			return nil, nil, nil, vterrors.VT12001("outer join with a recursive common table expression")
+		}
+		if expr.Condition != nil && len(expr.Condition.Using) > 0 {
+			return nil, nil, nil, vterrors.VT12001("join with a recursive common table expression using USING")
+		}
+		found, pred := lFound, lPred
+		if rFound != nil {
+			found, pred = rFound, rPred
+		}
+		if expr.Condition != nil {
+			pred = sqlparser.AndExpressions(pred, expr.Condition.On)
+		}
+		switch {
+		case lhs == nil:
+			return rhs, found, pred, nil
+		case rhs == nil:
+			return lhs, found, pred, nil
+		}
+		expr.LeftExpr, expr.RightExpr = lhs, rhs
+		return expr, found, pred, nil
+	}
+	return expr, nil, nil, nil
+}
+
+// refersToTable returns true if the given node uses the unqualified table name
+func refersToTable(node sqlparser.SQLNode, name string) (found bool) {
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		tbl, ok := node.(sqlparser.TableName)
+		if ok && tbl.Qualifier.IsEmpty() && tbl.Name.String() == name {
+			found = true
+		}
+		return !found, nil
+	}, node)
+	return
+}
+
 func rewriteNotExpr(cursor *sqlparser.Cursor, node *sqlparser.NotExpr) {
 	cmp, ok := node.Expr.(*sqlparser.ComparisonExpr)
 	if !ok {
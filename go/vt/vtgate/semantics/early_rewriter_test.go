@@ -928,6 +928,38 @@ func TestCTEToDerivedTableRewrite(t *testing.T) {
 	}
 }
 
+// TestRecursiveCTERewrite checks that the recursive part of a recursive CTE no longer refers to the CTE
+func TestRecursiveCTERewrite(t *testing.T) {
+	cDB := "db"
+	tcases := []struct {
+		sql     string
+		expSQL  string
+		expVars map[string]int
+	}{{
+		sql:     "with recursive x(n) as (select 1 union all select n + 1 from x where n < 5) select * from x",
+		expSQL:  "select n from (select 1 from dual union all select :__recurse1_0 + 1 from dual where :__recurse1_0 < 5) as x(n)",
+		expVars: map[string]int{"__recurse1_0": 0},
+	}, {
+		sql:     "with recursive x as (select id, col from t1 where id = 1 union all select t1.id, t1.col from t1 join x as p on t1.col = p.id) select id from x",
+		expSQL:  "select id from (select id, col from t1 where id = 1 union all select t1.id, t1.col from t1 where t1.col = :__recurse1_0) as x",
+		expVars: map[string]int{"__recurse1_0": 0},
+	}}
+	for _, tcase := range tcases {
+		t.Run(tcase.sql, func(t *testing.T) {
+			ast, err := sqlparser.NewTestParser().Parse(tcase.sql)
+			require.NoError(t, err)
+			st, err := Analyze(ast, cDB, fakeSchemaInfo())
+			require.NoError(t, err)
+			require.Equal(t, tcase.expSQL, sqlparser.String(ast))
+			require.Len(t, st.RecursiveCTEs, 1)
+			for _, cte := range st.RecursiveCTEs {
+				require.Equal(t, "x", cte.Name)
+				require.Equal(t, tcase.expVars, cte.Vars)
+			}
+		})
+	}
+}
+
 // TestDeleteTargetTableRewrite checks that delete target rewrite is done correctly.
 func TestDeleteTargetTableRewrite(t *testing.T) {
 	cDB := "db"
@@ -0,0 +1,179 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package semantics
+
+import (
+	"strings"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+	"vitess.io/vitess/go/vt/vtgate/vindexes"
+)
+
+// JSONTable contains the information about a JSON_TABLE expression in the FROM clause.
+// The columns of the table are the columns defined in the COLUMNS clause, with the
+// columns of the NESTED PATH clauses flattened in the order they are defined.
+type JSONTable struct {
+	// ASTNode is the JSON_TABLE expression this table comes from
+	ASTNode *sqlparser.JSONTableExpr
+
+	// aliased is used to identify the table, since JSON_TABLE expressions
+	// are not wrapped in an AliasedTableExpr
+	aliased *sqlparser.AliasedTableExpr
+	columns []ColumnInfo
+}
+
+var _ TableInfo = (*JSONTable)(nil)
+
+func newJSONTable(node *sqlparser.JSONTableExpr, collationEnv *collations.Environment) (*JSONTable, error) {
+	jt := &JSONTable{
+		ASTNode: node,
+		aliased: &sqlparser.AliasedTableExpr{Expr: sqlparser.NewTableName(node.Alias.String())},
+	}
+	if err := jt.addColumns(node.Columns, collationEnv); err != nil {
+		return nil, err
+	}
+	return jt, nil
+}
+
+func (jt *JSONTable) addColumns(columns []*sqlparser.JtColumnDefinition, collationEnv *collations.Environment) error {
+	for _, col := range columns {
+		var info ColumnInfo
+		switch {
+		case col.JtOrdinal != nil:
+			info = ColumnInfo{
+				Name: col.JtOrdinal.Name.String(),
+				Type: evalengine.NewType(sqltypes.Uint32, collations.CollationBinaryID),
+			}
+		case col.JtPath != nil:
+			info = ColumnInfo{
+				Name: col.JtPath.Name.String(),
+				Type: JSONTableColumnType(col.JtPath.Type, collationEnv),
+			}
+		case col.JtNestedPath != nil:
+			if err := jt.addColumns(col.JtNestedPath.Columns, collationEnv); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, other := range jt.columns {
+			if strings.EqualFold(other.Name, info.Name) {
+				return vterrors.NewErrorf(vtrpcpb.Code_INVALID_ARGUMENT, vterrors.DupFieldName, "Duplicate column name '%s'", info.Name)
+			}
+		}
+		jt.columns = append(jt.columns, info)
+	}
+	return nil
+}
+
+// JSONTableColumnType returns the type of a column defined in a JSON_TABLE expression
+func JSONTableColumnType(ct *sqlparser.ColumnType, collationEnv *collations.Environment) evalengine.Type {
+	typ := ct.SQLType()
+	var collation collations.ID
+	switch {
+	case sqltypes.IsText(typ) && ct.Charset.Name != "":
+		collation = collationEnv.DefaultCollationForCharset(strings.ToLower(ct.Charset.Name))
+	case sqltypes.IsText(typ):
+		collation = collationEnv.DefaultConnectionCharset()
+	default:
+		collation = collations.CollationForType(typ, collationEnv.DefaultConnectionCharset())
+	}
+	var size, scale int32
+	if ct.Length != nil {
+		size = int32(*ct.Length)
+	}
+	if ct.Scale != nil {
+		scale = int32(*ct.Scale)
+	}
+	return evalengine.NewTypeEx(typ, collation, true, size, scale, nil)
+}
+
+// dependencies implements the TableInfo interface
+func (jt *JSONTable) dependencies(colName string, org originable) (dependencies, error) {
+	ts := org.tableSetFor(jt.aliased)
+	for _, info := range jt.columns {
+		if strings.EqualFold(info.Name, colName) {
+			return createCertain(ts, ts, info.Type), nil
+		}
+	}
+	return &nothing{}, nil
+}
+
+// getTableSet implements the TableInfo interface
+func (jt *JSONTable) getTableSet(org originable) TableSet {
+	return org.tableSetFor(jt.aliased)
+}
+
+// getExprFor implements the TableInfo interface
+func (jt *JSONTable) getExprFor(s string) (sqlparser.Expr, error) {
+	return nil, vterrors.Errorf(vtrpcpb.Code_INTERNAL, "Unknown column '%s' in 'field list'", s)
+}
+
+// IsInfSchema implements the TableInfo interface
+func (jt *JSONTable) IsInfSchema() bool {
+	return false
+}
+
+// matches implements the TableInfo interface
+func (jt *JSONTable) matches(name sqlparser.TableName) bool {
+	return jt.ASTNode.Alias.String() == name.Name.String() && name.Qualifier.IsEmpty()
+}
+
+// authoritative implements the TableInfo interface
+func (jt *JSONTable) authoritative() bool {
+	return true
+}
+
+// Name implements the TableInfo interface
+func (jt *JSONTable) Name() (sqlparser.TableName, error) {
+	return sqlparser.NewTableName(jt.ASTNode.Alias.String()), nil
+}
+
+// GetAliasedTableExpr implements the TableInfo interface
+func (jt *JSONTable) GetAliasedTableExpr() *sqlparser.AliasedTableExpr {
+	return jt.aliased
+}
+
+// canShortCut implements the TableInfo interface. A JSON_TABLE can be sent
+// to any keyspace together with the rest of the query.
+func (jt *JSONTable) canShortCut() shortCut {
+	return canShortCut
+}
+
+// GetVindexTable implements the TableInfo interface
+func (jt *JSONTable) GetVindexTable() *vindexes.Table {
+	return nil
+}
+
+// getColumns implements the TableInfo interface
+func (jt *JSONTable) getColumns(bool) []ColumnInfo {
+	return jt.columns
+}
+
+// TableSetForJSONTable returns the TableSet of the given JSON_TABLE expression
+func (st *SemTable) TableSetForJSONTable(node *sqlparser.JSONTableExpr) TableSet {
+	for idx, t := range st.Tables {
+		if jt, ok := t.(*JSONTable); ok && jt.ASTNode == node {
+			return SingleTableSet(idx)
+		}
+	}
+	return EmptyTableSet()
+}
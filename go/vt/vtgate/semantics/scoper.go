@@ -169,6 +169,10 @@ func (s *scoper) enterJoinScope(cursor *sqlparser.Cursor) {
 		// To create this special context, we will find the parent scope of the select statement involved.
 		currScope := s.currentScope()
 		stmtScope := currScope.findParentScopeOfStatement()
+		if isLateral(cursor.Node()) {
+			// a lateral derived table can also see the tables that come before it in the FROM clause
+			stmtScope = currScope
+		}
 		nScope := newScope(stmtScope)
 		if stmtScope == nil {
 			// TODO: this feels hacky. revisit with a better plan
@@ -179,6 +183,19 @@ func (s *scoper) enterJoinScope(cursor *sqlparser.Cursor) {
 	}
 }
 
+func isLateral(node sqlparser.SQLNode) bool {
+	switch node := node.(type) {
+	case *sqlparser.AliasedTableExpr:
+		dt, ok := node.Expr.(*sqlparser.DerivedTable)
+		return ok && dt.Lateral
+	case *sqlparser.JSONTableExpr:
+		// the document of a JSON_TABLE can use the tables that come before it, just like a lateral derived table
+		return true
+	default:
+		return false
+	}
+}
+
 func (s *scoper) pushSelectScope(node *sqlparser.Select) {
 	currScope := newScope(s.currentScope())
 	currScope.stmtScope = true
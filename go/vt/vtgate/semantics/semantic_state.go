@@ -83,6 +83,7 @@ type (
 		HashJoin    bool
 		SubQueries  bool
 		Union       bool
+		Window      bool
 	}
 
 	// SemTable contains semantic analysis information about the query.
@@ -129,6 +130,10 @@ type (
 		// The columns were added because of the use of `*` in the query
 		ExpandedColumns map[sqlparser.TableName][]*sqlparser.ColName
 
+		// RecursiveCTEs contains the recursive common table expressions,
+		// keyed by the UNION ALL of the seed and the recursive part that the early rewriter created.
+		RecursiveCTEs map[*sqlparser.Union]*RecursiveCTE
+
 		columns map[*sqlparser.Union]sqlparser.SelectExprs
 
 		comparator *sqlparser.Comparator
@@ -147,6 +152,19 @@ type (
 		collEnv                   *collations.Environment
 	}
 
+	// RecursiveCTE contains the information needed to evaluate a recursive common table expression.
+	RecursiveCTE struct {
+		// Name is the name of the common table expression.
+		Name string
+		// Alias is the name the recursive part uses to refer to the common table expression.
+		Alias string
+		// Columns are the names of the columns produced by the common table expression.
+		Columns sqlparser.Columns
+		// Vars maps the arguments that replaced the references to the common table expression
+		// in the recursive part to the offsets of the columns they are read from.
+		Vars map[string]int
+	}
+
 	columnName struct {
 		Table      TableSet
 		ColumnName string
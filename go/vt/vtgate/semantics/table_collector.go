@@ -113,6 +113,8 @@ func (tc *tableCollector) up(cursor *sqlparser.Cursor) error {
 	switch node := cursor.Node().(type) {
 	case *sqlparser.AliasedTableExpr:
 		return tc.visitAliasedTableExpr(node)
+	case *sqlparser.JSONTableExpr:
+		return tc.visitJSONTable(node)
 	case *sqlparser.Union:
 		return tc.visitUnion(node)
 	case *sqlparser.RowAlias:
@@ -136,6 +138,16 @@ func (tc *tableCollector) visitAliasedTableExpr(node *sqlparser.AliasedTableExpr
 	return nil
 }
 
+func (tc *tableCollector) visitJSONTable(node *sqlparser.JSONTableExpr) error {
+	tableInfo, err := newJSONTable(node, tc.org.collationEnv())
+	if err != nil {
+		return err
+	}
+	tc.Tables = append(tc.Tables, tableInfo)
+	scope := tc.scoper.currentScope()
+	return scope.addTable(tableInfo)
+}
+
 func (tc *tableCollector) visitUnion(union *sqlparser.Union) error {
 	firstSelect := sqlparser.GetFirstSelect(union)
 	expanded, selectExprs := getColumnNames(firstSelect.SelectExprs)
@@ -341,9 +353,9 @@ func getTableInfo(node *sqlparser.AliasedTableExpr, t sqlparser.TableName, si Sc
 func (tc *tableCollector) handleDerivedTable(node *sqlparser.AliasedTableExpr, t *sqlparser.DerivedTable) error {
 	switch sel := t.Select.(type) {
 	case *sqlparser.Select:
-		return tc.addSelectDerivedTable(sel, node, node.Columns, node.As)
+		return tc.addSelectDerivedTable(sel, node, node.Columns, node.As, t.Lateral)
 	case *sqlparser.Union:
-		return tc.addUnionDerivedTable(sel, node, node.Columns, node.As)
+		return tc.addUnionDerivedTable(sel, node, node.Columns, node.As, t.Lateral)
 	default:
 		return vterrors.VT13001("[BUG] %T in a derived table", sel)
 	}
@@ -354,6 +366,7 @@ func (tc *tableCollector) addSelectDerivedTable(
 	tableExpr *sqlparser.AliasedTableExpr,
 	columns sqlparser.Columns,
 	alias sqlparser.IdentifierCS,
+	lateral bool,
 ) error {
 	tables := tc.scoper.wScope[sel]
 	size := len(sel.SelectExprs)
@@ -368,6 +381,9 @@ func (tc *tableCollector) addSelectDerivedTable(
 		}
 		_, deps[i], types[i] = tc.org.depsForExpr(ae.Expr)
 	}
+	if lateral {
+		deps = tc.lateralDeps(sel, deps)
+	}
 
 	tableInfo := createDerivedTableForExpressions(sel.SelectExprs, columns, tables.tables, tc.org, expanded, deps, types)
 	if err := tableInfo.checkForDuplicates(); err != nil {
@@ -382,15 +398,19 @@ func (tc *tableCollector) addSelectDerivedTable(
 	return scope.addTable(tableInfo)
 }
 
-func (tc *tableCollector) addUnionDerivedTable(union *sqlparser.Union, node *sqlparser.AliasedTableExpr, columns sqlparser.Columns, alias sqlparser.IdentifierCS) error {
+func (tc *tableCollector) addUnionDerivedTable(union *sqlparser.Union, node *sqlparser.AliasedTableExpr, columns sqlparser.Columns, alias sqlparser.IdentifierCS, lateral bool) error {
 	firstSelect := sqlparser.GetFirstSelect(union)
 	tables := tc.scoper.wScope[firstSelect]
 	info, found := tc.unionInfo[union]
 	if !found {
 		return vterrors.VT13001("information about union is not available")
 	}
+	deps := info.recursive
+	if lateral && deps != nil {
+		deps = tc.lateralDeps(union, deps)
+	}
 
-	tableInfo := createDerivedTableForExpressions(info.exprs, columns, tables.tables, tc.org, info.isAuthoritative, info.recursive, info.types)
+	tableInfo := createDerivedTableForExpressions(info.exprs, columns, tables.tables, tc.org, info.isAuthoritative, deps, info.types)
 	if err := tableInfo.checkForDuplicates(); err != nil {
 		return err
 	}
@@ -402,6 +422,31 @@ func (tc *tableCollector) addUnionDerivedTable(union *sqlparser.Union, node *sql
 	return scope.addTable(tableInfo)
 }
 
+// lateralDeps returns the dependencies of the columns of a lateral derived table. Columns using values from
+// the tables before the derived table in the FROM clause are still produced by the derived table, so they
+// depend on the tables of the derived table instead.
+func (tc *tableCollector) lateralDeps(stmt sqlparser.SelectStatement, deps []TableSet) []TableSet {
+	inner := EmptyTableSet()
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if ate, ok := node.(*sqlparser.AliasedTableExpr); ok {
+			if _, isTable := ate.Expr.(sqlparser.TableName); isTable {
+				inner = inner.Merge(tc.org.tableSetFor(ate))
+			}
+		}
+		return true, nil
+	}, stmt)
+
+	result := make([]TableSet, len(deps))
+	for i, dep := range deps {
+		if dep.IsSolvedBy(inner) {
+			result[i] = dep
+		} else {
+			result[i] = inner
+		}
+	}
+	return result
+}
+
 func newVindexTable(t sqlparser.IdentifierCS) *vindexes.Table {
 	vindexCols := []vindexes.Column{
 		{Name: sqlparser.NewIdentifierCI("id"), Type: querypb.Type_VARBINARY},
@@ -18,6 +18,7 @@ package semantics
 
 import (
 	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
 	"vitess.io/vitess/go/vt/sqlparser"
 	"vitess.io/vitess/go/vt/vtgate/engine/opcode"
 	"vitess.io/vitess/go/vt/vtgate/evalengine"
@@ -61,10 +62,31 @@ func (t *typer) up(cursor *sqlparser.Cursor) error {
 			}
 		}
 		t.m[node] = code.ResolveType(inputType, t.collationEnv)
+	case *sqlparser.ArgumentLessWindowExpr:
+		typ := sqltypes.Uint64
+		if node.Type == sqlparser.PercentRankExprType || node.Type == sqlparser.CumeDistExprType {
+			typ = sqltypes.Float64
+		}
+		t.m[node] = evalengine.NewType(typ, collations.CollationBinaryID)
+	case *sqlparser.NtileExpr:
+		t.m[node] = evalengine.NewType(sqltypes.Uint64, collations.CollationBinaryID)
+	case *sqlparser.LagLeadExpr:
+		t.setTypeFromArg(node, node.Expr)
+	case *sqlparser.FirstOrLastValueExpr:
+		t.setTypeFromArg(node, node.Expr)
+	case *sqlparser.NTHValueExpr:
+		t.setTypeFromArg(node, node.Expr)
 	}
 	return nil
 }
 
+// setTypeFromArg is used for window functions that return values of their argument
+func (t *typer) setTypeFromArg(node, arg sqlparser.Expr) {
+	if tt, ok := t.m[arg]; ok {
+		t.m[node] = evalengine.NewTypeEx(tt.Type(), tt.Collation(), true, tt.Size(), tt.Scale(), tt.Values())
+	}
+}
+
 func (t *typer) setTypeFor(node *sqlparser.ColName, typ evalengine.Type) {
 	t.m[node] = typ
 }
@@ -0,0 +1,180 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"vitess.io/vitess/go/mysql/replication"
+	"vitess.io/vitess/go/vt/vterrors"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+// A session token lets the reads on replicas see the writes committed before
+// by the session, or by another session, possibly on another vtgate, that the
+// token was copied from. It holds the GTIDs of the writes on the primary of
+// each shard, as the base64 encoding of a VGtid.
+//
+// The sessions with session_track_gtids set ask the primaries to return the
+// GTIDs of their commits and autocommitted writes, and add them to their
+// session token. The sessions with a session token send the positions of its
+// shards to the replicas, which wait to reach them before they execute the
+// queries.
+
+func decodeSessionToken(token string) (*binlogdatapb.VGtid, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "invalid session token %s: %v", token, err)
+	}
+	vgtid := &binlogdatapb.VGtid{}
+	if err := vgtid.UnmarshalVT(data); err != nil {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "invalid session token %s: %v", token, err)
+	}
+	return vgtid, nil
+}
+
+func encodeSessionToken(vgtid *binlogdatapb.VGtid) string {
+	data, _ := vgtid.MarshalVT()
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// executeOptions returns the options of the queries the session executes on
+// the target. The queries on a primary return the GTIDs of their commits if
+// the session tracks them, and the queries on a replica wait for the position
+// of its shard in the session token of the session.
+func (session *SafeSession) executeOptions(target *querypb.Target) (*querypb.ExecuteOptions, error) {
+	options := session.Session.Options
+	session.mu.Lock()
+	track := session.ReadAfterWrite.GetSessionTrackGtids()
+	token := session.ReadAfterWrite.GetSessionToken()
+	session.mu.Unlock()
+
+	if target.GetTabletType() == topodatapb.TabletType_PRIMARY {
+		if !track {
+			return options, nil
+		}
+		options = options.CloneVT()
+		if options == nil {
+			options = &querypb.ExecuteOptions{}
+		}
+		options.SessionTrackGtids = true
+		return options, nil
+	}
+	if token == "" {
+		return options, nil
+	}
+
+	vgtid, err := decodeSessionToken(token)
+	if err != nil {
+		return nil, err
+	}
+	for _, sgtid := range vgtid.ShardGtids {
+		if sgtid.Keyspace == target.Keyspace && sgtid.Shard == target.Shard {
+			options = options.CloneVT()
+			if options == nil {
+				options = &querypb.ExecuteOptions{}
+			}
+			options.WaitForPosition = sgtid.Gtid
+			return options, nil
+		}
+	}
+	return options, nil
+}
+
+// updateSessionToken adds the GTIDs the primary of the target returned in the
+// session state changes of a commit to the session token of the session, if
+// the session tracks them with session_track_gtids.
+func (session *SafeSession) updateSessionToken(target *querypb.Target, sessionStateChanges string) {
+	session.mu.Lock()
+	track := session.ReadAfterWrite.GetSessionTrackGtids()
+	session.mu.Unlock()
+	if !track || target.GetTabletType() != topodatapb.TabletType_PRIMARY || sessionStateChanges == "" {
+		return
+	}
+
+	if err := session.addSessionTokenGtids(target, sessionStateChanges); err != nil {
+		// The writes are committed, so the session only gets a warning
+		// that its token does not track them.
+		session.RecordWarning(&querypb.QueryWarning{
+			Message: fmt.Sprintf("the session token does not track the writes to %s/%s: %v", target.Keyspace, target.Shard, err),
+		})
+		warnings.Add("SessionToken", 1)
+	}
+}
+
+// warnUntrackedCommit warns the session, if it tracks its session token, that
+// the token does not track the writes of its 2PC commit, since the prepared
+// transactions do not return their GTIDs.
+func (session *SafeSession) warnUntrackedCommit() {
+	session.mu.Lock()
+	track := session.ReadAfterWrite.GetSessionTrackGtids()
+	session.mu.Unlock()
+	if !track {
+		return
+	}
+	session.RecordWarning(&querypb.QueryWarning{
+		Message: "the session token does not track the writes of the 2PC commit",
+	})
+	warnings.Add("SessionToken", 1)
+}
+
+func (session *SafeSession) addSessionTokenGtids(target *querypb.Target, gtids string) error {
+	pos, err := replication.ParsePosition(replication.Mysql56FlavorID, gtids)
+	if err != nil {
+		return err
+	}
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	if session.ReadAfterWrite == nil {
+		session.ReadAfterWrite = &vtgatepb.ReadAfterWrite{}
+	}
+
+	vgtid := &binlogdatapb.VGtid{}
+	if token := session.ReadAfterWrite.SessionToken; token != "" {
+		if vgtid, err = decodeSessionToken(token); err != nil {
+			return err
+		}
+	}
+	for _, sgtid := range vgtid.ShardGtids {
+		if sgtid.Keyspace == target.Keyspace && sgtid.Shard == target.Shard {
+			prev, err := replication.DecodePosition(sgtid.Gtid)
+			if err != nil {
+				return err
+			}
+			if !prev.IsZero() {
+				pos.GTIDSet = prev.GTIDSet.Union(pos.GTIDSet)
+			}
+			sgtid.Gtid = replication.EncodePosition(pos)
+			session.ReadAfterWrite.SessionToken = encodeSessionToken(vgtid)
+			return nil
+		}
+	}
+	vgtid.ShardGtids = append(vgtid.ShardGtids, &binlogdatapb.ShardGtid{
+		Keyspace: target.Keyspace,
+		Shard:    target.Shard,
+		Gtid:     replication.EncodePosition(pos),
+	})
+	session.ReadAfterWrite.SessionToken = encodeSessionToken(vgtid)
+	return nil
+}
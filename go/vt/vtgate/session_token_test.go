@@ -0,0 +1,97 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/vttablet/sandboxconn"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
+)
+
+func TestExecutorSessionToken(t *testing.T) {
+	var primary, replica *sandboxconn.SandboxConn
+	executor, ctx := createExecutorEnvCallback(t, func(shard, ks string, tabletType topodatapb.TabletType, conn *sandboxconn.SandboxConn) {
+		if ks == KsTestUnsharded {
+			if tabletType == topodatapb.TabletType_PRIMARY {
+				primary = conn
+			} else {
+				replica = conn
+			}
+		}
+	})
+
+	session := &vtgatepb.Session{TargetString: "@primary", Autocommit: true}
+	_, err := executorExec(ctx, executor, session, "set session_track_gtids = own_gtid", nil)
+	require.NoError(t, err)
+
+	// The autocommitted writes add the GTIDs the primary returns to the
+	// session token.
+	primary.SetResults([]*sqltypes.Result{{RowsAffected: 1, SessionStateChanges: "3e11fa47-71ca-11e1-9e33-c80aa9429562:5"}})
+	_, err = executorExec(ctx, executor, session, "insert into simple(id) values (1)", nil)
+	require.NoError(t, err)
+	assert.True(t, primary.Options[len(primary.Options)-1].GetSessionTrackGtids())
+	assert.Empty(t, session.ReadAfterWrite.ReadAfterWriteGtid)
+	vgtid, err := decodeSessionToken(session.ReadAfterWrite.SessionToken)
+	require.NoError(t, err)
+	assert.Equal(t, []*binlogdatapb.ShardGtid{{
+		Keyspace: KsTestUnsharded,
+		Shard:    "0",
+		Gtid:     "MySQL56/3e11fa47-71ca-11e1-9e33-c80aa9429562:5",
+	}}, vgtid.ShardGtids)
+
+	// So do the commits.
+	_, err = executorExec(ctx, executor, session, "begin", nil)
+	require.NoError(t, err)
+	_, err = executorExec(ctx, executor, session, "update simple set id = 2 where id = 1", nil)
+	require.NoError(t, err)
+	primary.CommitSessionStateChanges = "3e11fa47-71ca-11e1-9e33-c80aa9429562:6"
+	_, err = executorExec(ctx, executor, session, "commit", nil)
+	require.NoError(t, err)
+	token := session.ReadAfterWrite.SessionToken
+	vgtid, err = decodeSessionToken(token)
+	require.NoError(t, err)
+	require.Len(t, vgtid.ShardGtids, 1)
+	assert.Equal(t, "MySQL56/3e11fa47-71ca-11e1-9e33-c80aa9429562:5-6", vgtid.ShardGtids[0].Gtid)
+
+	// Another session with the token reads the writes on the replicas.
+	other := &vtgatepb.Session{TargetString: "@replica", ReadAfterWrite: &vtgatepb.ReadAfterWrite{SessionToken: token}}
+	_, err = executorExec(ctx, executor, other, "select id from simple", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "MySQL56/3e11fa47-71ca-11e1-9e33-c80aa9429562:5-6", replica.Options[len(replica.Options)-1].GetWaitForPosition())
+
+	// The sessions that do not track the GTIDs keep their token, and do not
+	// ask the primaries for them.
+	primary.SetResults([]*sqltypes.Result{{RowsAffected: 1, SessionStateChanges: "3e11fa47-71ca-11e1-9e33-c80aa9429562:7"}})
+	untracked := &vtgatepb.Session{TargetString: "@primary", Autocommit: true, ReadAfterWrite: &vtgatepb.ReadAfterWrite{SessionToken: token}}
+	_, err = executorExec(ctx, executor, untracked, "insert into simple(id) values (3)", nil)
+	require.NoError(t, err)
+	assert.False(t, primary.Options[len(primary.Options)-1].GetSessionTrackGtids())
+	assert.Equal(t, token, untracked.ReadAfterWrite.SessionToken)
+
+	_, err = executorExec(ctx, executor, other, "set session_token = 'not a token'", nil)
+	require.NoError(t, err)
+	_, err = executorExec(ctx, executor, other, "select id from simple", nil)
+	require.ErrorContains(t, err, "invalid session token not a token")
+}
@@ -62,6 +62,7 @@ func init() {
 		fs.StringVar(&CellsToWatch, "cells_to_watch", "", "comma-separated list of cells for watching tablets")
 		fs.DurationVar(&initialTabletTimeout, "gateway_initial_tablet_timeout", 30*time.Second, "At startup, the tabletGateway will wait up to this duration to get at least one tablet per keyspace/shard/tablet type")
 		fs.IntVar(&retryCount, "retry-count", 2, "retry count")
+		fs.BoolVar(&maxReplicaLagFallbackToPrimary, "max-replica-lag-fallback-to-primary", maxReplicaLagFallbackToPrimary, "Send the queries to the primary when no replica is within their max_replica_lag, instead of failing them")
 	})
 }
 
@@ -279,6 +280,13 @@ func (gw *TabletGateway) withRetry(ctx context.Context, target *querypb.Target,
 		}
 
 		tablets := gw.hc.GetHealthyTabletStats(target)
+		tabletTarget := target
+		if maxReplicaLag, ok := maxReplicaLagFromContext(ctx); ok && target.TabletType != topodatapb.TabletType_PRIMARY {
+			tabletTarget, tablets, err = gw.boundedStalenessTablets(target, tablets, maxReplicaLag)
+			if err != nil {
+				break
+			}
+		}
 		if len(tablets) == 0 {
 			// if we have a keyspace event watcher, check if the reason why our primary is not available is that it's currently being resharded
 			// or if a reparent operation is in progress.
@@ -336,8 +344,8 @@ func (gw *TabletGateway) withRetry(ctx context.Context, target *querypb.Target,
 
 		startTime := time.Now()
 		var canRetry bool
-		canRetry, err = inner(ctx, target, th.Conn)
-		gw.updateStats(target, startTime, err)
+		canRetry, err = inner(ctx, tabletTarget, th.Conn)
+		gw.updateStats(tabletTarget, startTime, err)
 		if canRetry {
 			invalidTablets[topoproto.TabletAliasString(tabletLastUsed.Alias)] = true
 			continue
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -34,6 +35,7 @@ import (
 	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
 	"vitess.io/vitess/go/vt/topo"
 	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vttablet/sandboxconn"
 )
 
 func TestTabletGatewayExecute(t *testing.T) {
@@ -177,6 +179,81 @@ func TestTabletGatewayReplicaTransactionError(t *testing.T) {
 	verifyContainsError(t, err, "query service can only be used for non-transactional queries on replicas", vtrpcpb.Code_INTERNAL)
 }
 
+func TestTabletGatewayMaxReplicaLag(t *testing.T) {
+	ctx := utils.LeakCheckContext(t)
+
+	keyspace := "ks"
+	shard := "0"
+	host := "1.1.1.1"
+	target := &querypb.Target{
+		Keyspace:   keyspace,
+		Shard:      shard,
+		TabletType: topodatapb.TabletType_REPLICA,
+	}
+	hc := discovery.NewFakeHealthCheck(nil)
+	ts := &fakeTopoServer{}
+	tg := NewTabletGateway(ctx, hc, ts, "cell")
+	defer tg.Close(ctx)
+
+	primary := hc.AddTestTablet("cell", host, 1001, keyspace, shard, topodatapb.TabletType_PRIMARY, true, 10, nil)
+	fresh := hc.AddTestTablet("cell", host, 1002, keyspace, shard, topodatapb.TabletType_REPLICA, true, 10, nil)
+	stale := hc.AddTestTablet("cell", host, 1003, keyspace, shard, topodatapb.TabletType_REPLICA, true, 10, nil)
+	setLag := func(sc *sandboxconn.SandboxConn, lag uint32) {
+		th, err := hc.GetTabletHealthByAlias(sc.Tablet().Alias)
+		require.NoError(t, err)
+		th.Stats.ReplicationLagSeconds = lag
+	}
+	setLag(fresh, 2)
+	setLag(stale, 30)
+
+	// The queries only go to the replicas within their max replica lag.
+	lagCtx := withMaxReplicaLag(ctx, 5*time.Second)
+	for i := 0; i < 10; i++ {
+		_, err := tg.Execute(lagCtx, target, "query", nil, 0, 0, nil)
+		require.NoError(t, err)
+	}
+	assert.EqualValues(t, 10, fresh.ExecCount.Load())
+	assert.EqualValues(t, 0, stale.ExecCount.Load())
+	assert.EqualValues(t, 0, primary.ExecCount.Load())
+
+	// They fall back to the primary when no replica is.
+	setLag(fresh, 10)
+	_, err := tg.Execute(lagCtx, target, "query", nil, 0, 0, nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, primary.ExecCount.Load())
+	assert.EqualValues(t, 1, maxReplicaLagFallbacks.Counts()[keyspace+"."+shard])
+
+	// The lags are reported in whole seconds, rounded down, so a lag of 4
+	// seconds may be more than 4.5s.
+	setLag(fresh, 4)
+	_, err = tg.Execute(withMaxReplicaLag(ctx, 4500*time.Millisecond), target, "query", nil, 0, 0, nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, primary.ExecCount.Load())
+	_, err = tg.Execute(lagCtx, target, "query", nil, 0, 0, nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 11, fresh.ExecCount.Load())
+
+	// They also fall back to the primary when the shard has no healthy replica.
+	otherPrimary := hc.AddTestTablet("cell", host, 1004, keyspace, "1", topodatapb.TabletType_PRIMARY, true, 10, nil)
+	otherTarget := &querypb.Target{Keyspace: keyspace, Shard: "1", TabletType: topodatapb.TabletType_REPLICA}
+	_, err = tg.Execute(lagCtx, otherTarget, "query", nil, 0, 0, nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, otherPrimary.ExecCount.Load())
+	assert.EqualValues(t, 1, maxReplicaLagFallbacks.Counts()[keyspace+".1"])
+
+	// Or fail, without the fallback.
+	setLag(fresh, 10)
+	maxReplicaLagFallbackToPrimary = false
+	defer func() { maxReplicaLagFallbackToPrimary = true }()
+	_, err = tg.Execute(lagCtx, target, "query", nil, 0, 0, nil)
+	verifyContainsError(t, err, "no tablet with a replication lag of at most 5s available for", vtrpcpb.Code_UNAVAILABLE)
+
+	// The queries without a max replica lag go to any replica.
+	_, err = tg.Execute(ctx, target, "query", nil, 0, 0, nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 12, fresh.ExecCount.Load()+stale.ExecCount.Load())
+}
+
 func testTabletGatewayGeneric(t *testing.T, ctx context.Context, f func(ctx context.Context, tg *TabletGateway, target *querypb.Target) error) {
 	t.Helper()
 	keyspace := "ks"
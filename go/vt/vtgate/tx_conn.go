@@ -111,7 +111,7 @@ func (txc *TxConn) queryService(ctx context.Context, alias *topodatapb.TabletAli
 	return txc.tabletGateway.QueryServiceByAlias(ctx, alias, nil)
 }
 
-func (txc *TxConn) commitShard(ctx context.Context, s *vtgatepb.Session_ShardSession, logging *executeLogger) error {
+func (txc *TxConn) commitShard(ctx context.Context, session *SafeSession, s *vtgatepb.Session_ShardSession, logging *executeLogger) error {
 	if s.TransactionId == 0 {
 		return nil
 	}
@@ -121,25 +121,29 @@ func (txc *TxConn) commitShard(ctx context.Context, s *vtgatepb.Session_ShardSes
 	if err != nil {
 		return err
 	}
-	reservedID, err := qs.Commit(ctx, s.Target, s.TransactionId)
+	state, err := qs.Commit(ctx, s.Target, s.TransactionId)
 	if err != nil {
 		return err
 	}
 	s.TransactionId = 0
-	s.ReservedId = reservedID
+	s.ReservedId = state.ReservedID
 	logging.log(nil, s.Target, nil, "commit", false, nil)
+	session.updateSessionToken(s.Target, state.SessionStateChanges)
 	return nil
 }
 
 func (txc *TxConn) commitNormal(ctx context.Context, session *SafeSession) error {
-	if err := txc.runSessions(ctx, session.PreSessions, session.logging, txc.commitShard); err != nil {
+	commitShard := func(ctx context.Context, s *vtgatepb.Session_ShardSession, logging *executeLogger) error {
+		return txc.commitShard(ctx, session, s, logging)
+	}
+	if err := txc.runSessions(ctx, session.PreSessions, session.logging, commitShard); err != nil {
 		_ = txc.Release(ctx, session)
 		return err
 	}
 
 	// Retain backward compatibility on commit order for the normal session.
 	for i, shardSession := range session.ShardSessions {
-		if err := txc.commitShard(ctx, shardSession, session.logging); err != nil {
+		if err := txc.commitShard(ctx, session, shardSession, session.logging); err != nil {
 			if i > 0 {
 				nShards := i
 				elipsis := false
@@ -165,7 +169,7 @@ func (txc *TxConn) commitNormal(ctx context.Context, session *SafeSession) error
 		}
 	}
 
-	if err := txc.runSessions(ctx, session.PostSessions, session.logging, txc.commitShard); err != nil {
+	if err := txc.runSessions(ctx, session.PostSessions, session.logging, commitShard); err != nil {
 		// If last commit fails, there will be nothing to rollback.
 		session.RecordWarning(&querypb.QueryWarning{Message: fmt.Sprintf("post-operation transaction had an error: %v", err)})
 		// With reserved connection we should release them.
@@ -225,6 +229,7 @@ func (txc *TxConn) commit2PC(ctx context.Context, session *SafeSession) error {
 	if err != nil {
 		return err
 	}
+	session.warnUntrackedCommit()
 
 	return txc.tabletGateway.ConcludeTransaction(ctx, mmShard.Target, dtid)
 }
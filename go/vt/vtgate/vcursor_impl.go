@@ -124,6 +124,22 @@ type vcursorImpl struct {
 
 	warmingReadsPercent int
 	warmingReadsChannel chan bool
+
+	// budget keeps track of the resources used by the query.
+	budget *queryBudget
+
+	// resultCacheTTL overrides the result cache ttl of the tables used
+	// by the query when resultCacheTTLSet is true.
+	resultCacheTTL    time.Duration
+	resultCacheTTLSet bool
+	// resultCacheStmt is the statement of the query, which is not cached
+	// when it calls a non-deterministic function.
+	resultCacheStmt sqlparser.Statement
+
+	// maxReplicaLagFromComments overrides the max_replica_lag of the
+	// session when maxReplicaLagSet is true.
+	maxReplicaLagFromComments time.Duration
+	maxReplicaLagSet          bool
 }
 
 // newVcursorImpl creates a vcursorImpl. Before creating this object, you have to separate out any marginComments that came with
@@ -191,6 +207,7 @@ func newVCursorImpl(
 		pv:                  pv,
 		warmingReadsPercent: warmingReadsPct,
 		warmingReadsChannel: warmingReadsChan,
+		budget:              newQueryBudget(logStats),
 	}, nil
 }
 
@@ -235,11 +252,58 @@ func (vc *vcursorImpl) ExceedsMaxMemoryRows(numRows int) bool {
 	return !vc.ignoreMaxMemoryRows && numRows > maxMemoryRows
 }
 
+// CTEMaxRecursionDepth returns the cteMaxRecursionDepth flag value.
+func (vc *vcursorImpl) CTEMaxRecursionDepth() int {
+	return cteMaxRecursionDepth
+}
+
 // SetIgnoreMaxMemoryRows sets the ignoreMaxMemoryRows value.
 func (vc *vcursorImpl) SetIgnoreMaxMemoryRows(ignoreMaxMemoryRows bool) {
 	vc.ignoreMaxMemoryRows = ignoreMaxMemoryRows
 }
 
+// SetMaxMemory overrides the max-query-memory flag value for the query.
+func (vc *vcursorImpl) SetMaxMemory(maxMemory int64) {
+	vc.budget.maxMemory = maxMemory
+}
+
+// SetMaxReplicaLagFromComments overrides the max_replica_lag of the session for the query.
+func (vc *vcursorImpl) SetMaxReplicaLagFromComments(maxReplicaLag time.Duration) {
+	vc.maxReplicaLagFromComments = maxReplicaLag
+	vc.maxReplicaLagSet = true
+}
+
+// SetResultCacheTTL overrides how long the result of the query is cached.
+func (vc *vcursorImpl) SetResultCacheTTL(ttl time.Duration) {
+	vc.resultCacheTTL = ttl
+	vc.resultCacheTTLSet = true
+}
+
+// ReserveMemory is part of the engine.VCursor interface.
+func (vc *vcursorImpl) ReserveMemory(size int64) error {
+	return vc.budget.reserveMemory(size)
+}
+
+// ReleaseMemory is part of the engine.VCursor interface.
+func (vc *vcursorImpl) ReleaseMemory(size int64) {
+	vc.budget.releaseMemory(size)
+}
+
+// SpillThreshold is part of the engine.VCursor interface.
+func (vc *vcursorImpl) SpillThreshold() int64 {
+	return spillThreshold
+}
+
+// SpillDirectory is part of the engine.VCursor interface.
+func (vc *vcursorImpl) SpillDirectory() string {
+	return spillDirectory
+}
+
+// SpillNonStreaming is part of the engine.VCursor interface.
+func (vc *vcursorImpl) SpillNonStreaming() bool {
+	return spillNonStreaming
+}
+
 // RecordWarning stores the given warning in the current session
 func (vc *vcursorImpl) RecordWarning(warning *querypb.QueryWarning) {
 	vc.safeSession.RecordWarning(warning)
@@ -579,6 +643,11 @@ func (vc *vcursorImpl) ExecuteMultiShard(ctx context.Context, primitive engine.P
 
 	qr, errs := vc.executor.ExecuteMultiShard(ctx, primitive, rss, commentedShardQueries(queries, vc.marginComments), vc.safeSession, canAutocommit, vc.ignoreMaxMemoryRows)
 	vc.setRollbackOnPartialExecIfRequired(len(errs) != len(rss), rollbackOnError)
+	if qr != nil {
+		if err := vc.budget.addFetchedRows(len(qr.Rows)); err != nil {
+			return nil, []error{err}
+		}
+	}
 
 	return qr, errs
 }
@@ -592,7 +661,12 @@ func (vc *vcursorImpl) StreamExecuteMulti(ctx context.Context, primitive engine.
 		return []error{err}
 	}
 
-	errs := vc.executor.StreamExecuteMulti(ctx, primitive, vc.marginComments.Leading+query+vc.marginComments.Trailing, rss, bindVars, vc.safeSession, autocommit, callback)
+	errs := vc.executor.StreamExecuteMulti(ctx, primitive, vc.marginComments.Leading+query+vc.marginComments.Trailing, rss, bindVars, vc.safeSession, autocommit, func(reply *sqltypes.Result) error {
+		if err := vc.budget.addFetchedRows(len(reply.Rows)); err != nil {
+			return err
+		}
+		return callback(reply)
+	})
 	vc.setRollbackOnPartialExecIfRequired(len(errs) != len(rss), rollbackOnError)
 
 	return errs
@@ -616,7 +690,13 @@ func (vc *vcursorImpl) ExecuteStandalone(ctx context.Context, primitive engine.P
 	// The autocommit flag is always set to false because we currently don't
 	// execute DMLs through ExecuteStandalone.
 	qr, errs := vc.executor.ExecuteMultiShard(ctx, primitive, rss, bqs, NewAutocommitSession(vc.safeSession.Session), false /* autocommit */, vc.ignoreMaxMemoryRows)
-	return qr, vterrors.Aggregate(errs)
+	if err := vterrors.Aggregate(errs); err != nil {
+		return nil, err
+	}
+	if err := vc.budget.addFetchedRows(len(qr.Rows)); err != nil {
+		return nil, err
+	}
+	return qr, nil
 }
 
 // ExecuteKeyspaceID is part of the engine.VCursor interface.
@@ -665,6 +745,11 @@ func (vc *vcursorImpl) LookupRowLockShardSession() vtgatepb.CommitOrder {
 	return vtgatepb.CommitOrder_PRE
 }
 
+// AddLookupCacheInvalidation is part of the vindexes.VCursor interface.
+func (vc *vcursorImpl) AddLookupCacheInvalidation(table, column string, ids []sqltypes.Value) {
+	vc.safeSession.AddLookupCacheInvalidation(table, column, ids)
+}
+
 // AutocommitApproval is part of the engine.VCursor interface.
 func (vc *vcursorImpl) AutocommitApproval() bool {
 	return vc.safeSession.AutocommitApproval()
@@ -844,6 +929,11 @@ func (vc *vcursorImpl) SetQueryTimeout(maxExecutionTime int64) {
 	vc.safeSession.QueryTimeout = maxExecutionTime
 }
 
+// SetMaxReplicaLag implements the SessionActions interface
+func (vc *vcursorImpl) SetMaxReplicaLag(maxReplicaLag time.Duration) {
+	vc.safeSession.SetMaxReplicaLag(maxReplicaLag)
+}
+
 // GetQueryTimeout implements the SessionActions interface
 // The priority of adding query timeouts -
 // 1. Query timeout comment directive.
@@ -965,6 +1055,11 @@ func (vc *vcursorImpl) SetReadAfterWriteGTID(vtgtid string) {
 	vc.safeSession.SetReadAfterWriteGTID(vtgtid)
 }
 
+// SetSessionToken implements the SessionActions interface
+func (vc *vcursorImpl) SetSessionToken(token string) {
+	vc.safeSession.SetSessionToken(token)
+}
+
 // SetReadAfterWriteTimeout implements the SessionActions interface
 func (vc *vcursorImpl) SetReadAfterWriteTimeout(timeout float64) {
 	vc.safeSession.SetReadAfterWriteTimeout(timeout)
@@ -1125,6 +1220,17 @@ func (vc *vcursorImpl) keyForPlan(ctx context.Context, query string, buf io.Stri
 	_, _ = buf.WriteString(query)
 }
 
+// planSession returns the state of the session that keyForPlan and the
+// planner read, so that the plan can be built again for the same key.
+func (vc *vcursorImpl) planSession() *engine.PlanSession {
+	return &engine.PlanSession{
+		Target:           vc.safeSession.TargetString,
+		Collation:        vc.Environment().CollationEnv().LookupName(vc.collation),
+		PlannerVersion:   vc.safeSession.GetOptions().GetPlannerVersion(),
+		ForeignKeyChecks: vc.fkChecksState,
+	}
+}
+
 func (vc *vcursorImpl) GetKeyspace() string {
 	return vc.keyspace
 }
@@ -1278,6 +1384,7 @@ func (vc *vcursorImpl) cloneWithAutocommitSession() *vcursorImpl {
 		topoServer:      vc.topoServer,
 		warnShardedOnly: vc.warnShardedOnly,
 		pv:              vc.pv,
+		budget:          vc.budget,
 	}
 }
 
@@ -35,6 +35,7 @@ const (
 
 var (
 	_ ParamValidating = (*CFC)(nil)
+	_ RangeMapper     = (*CFC)(nil)
 
 	cfcParams = []string{
 		cfcParamHash,
@@ -53,9 +54,9 @@ var (
 // this vindex maps the full key, i.e. (s1, s2, ... sN) to a
 // `key.DestinationKeyspaceID` and the prefix of it, i.e. (s1, s2, ... sj)(j<N)
 // to a `key.DestinationKeyRange`. Note that the prefix to key range mapping is
-// only active in 'LIKE' expression. When a column with CFC defined appears in
-// other expressions, e.g. =, !=, IN etc, it behaves exactly as other
-// functional unique vindexes.
+// only active in 'LIKE' and range expressions, e.g. BETWEEN, < and >. When a
+// column with CFC defined appears in other expressions, e.g. =, !=, IN etc, it
+// behaves exactly as other functional unique vindexes.
 //
 // This provides the capability to model hierarchical data models. If we
 // consider the prefix as the 'parent' key and the full key as the 'child' key,
@@ -274,6 +275,58 @@ func (vind *CFC) Map(_ context.Context, _ VCursor, ids []sqltypes.Value) ([]key.
 	return out, nil
 }
 
+// MapRange implements the RangeMapper interface. Like in 'LIKE' expressions,
+// the ids compare as bytes. Without hash, the keyspace ids are the ids, so the
+// range maps to the keyspace range between its bounds. With hash, it maps to the
+// keyspace range of the components its bounds have in common, if any.
+func (vind *CFC) MapRange(_ context.Context, _ VCursor, start, end sqltypes.Value) (key.Destination, error) {
+	var bounds [2][]byte
+	for i, bound := range []sqltypes.Value{start, end} {
+		if bound.IsNull() {
+			continue
+		}
+		// Numbers don't compare as bytes.
+		if !bound.IsQuoted() {
+			return key.DestinationAllShards{}, nil
+		}
+		b, err := bound.ToBytes()
+		if err != nil {
+			return nil, err
+		}
+		bounds[i] = b
+	}
+	if !start.IsNull() && !end.IsNull() && bytes.Compare(bounds[0], bounds[1]) > 0 {
+		return key.DestinationNone{}, nil
+	}
+
+	if vind.hash == nil {
+		keyRange := &topodatapb.KeyRange{Start: bounds[0]}
+		if !end.IsNull() {
+			// All the ids prefixed by the end are after it, but they are fewer
+			// than the ids that would be routed to without an end.
+			keyRange.End = addOne(bytes.Clone(bounds[1]))
+		}
+		return key.DestinationKeyRange{KeyRange: keyRange}, nil
+	}
+
+	if start.IsNull() || end.IsNull() {
+		return key.DestinationAllShards{}, nil
+	}
+	n := 0
+	for n < len(bounds[0]) && n < len(bounds[1]) && bounds[0][n] == bounds[1][n] {
+		n++
+	}
+	// The last component is only known when the bounds are equal.
+	if last := vind.offsets[len(vind.offsets)-1]; n > last && n < max(len(bounds[0]), len(bounds[1])) {
+		n = last
+	}
+	begin, err := vind.computeKsid(bounds[0][:n], true)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeyRangeFromPrefix(begin), nil
+}
+
 // PrefixVindex switches the vindex to prefix mode
 func (vind *CFC) PrefixVindex() SingleColumn {
 	return vind.prefixCFC
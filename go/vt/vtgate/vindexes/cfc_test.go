@@ -458,6 +458,114 @@ func TestCFCPrefixQueryMapNoHash(t *testing.T) {
 	}
 }
 
+func TestCFCMapRange(t *testing.T) {
+	cfc := makeCFC(t, map[string]string{"hash": "md5", "offsets": "[3,5]"})
+
+	cases := []struct {
+		testName   string
+		start, end sqltypes.Value
+		dest       key.Destination
+	}{
+		{
+			testName: "same components",
+			start:    sqltypes.NewVarBinary("abcdea"),
+			end:      sqltypes.NewVarBinary("abcdez"),
+			dest:     NewKeyRangeFromPrefix(expectedHash([][]byte{{'a', 'b', 'c'}, {'d', 'e'}})),
+		},
+		{
+			testName: "same first component",
+			start:    sqltypes.NewVarBinary("abcdef"),
+			end:      sqltypes.NewVarBinary("abcxyz"),
+			dest:     NewKeyRangeFromPrefix(expectedHash([][]byte{{'a', 'b', 'c'}})),
+		},
+		{
+			testName: "partial last component",
+			start:    sqltypes.NewVarBinary("abcdefg"),
+			end:      sqltypes.NewVarBinary("abcdefh"),
+			dest:     NewKeyRangeFromPrefix(expectedHash([][]byte{{'a', 'b', 'c'}, {'d', 'e'}})),
+		},
+		{
+			testName: "equal bounds",
+			start:    sqltypes.NewVarBinary("abcdef"),
+			end:      sqltypes.NewVarBinary("abcdef"),
+			dest:     NewKeyRangeFromPrefix(expectedHash([][]byte{{'a', 'b', 'c'}, {'d', 'e'}, {'f'}})),
+		},
+		{
+			testName: "different first component",
+			start:    sqltypes.NewVarBinary("abcdef"),
+			end:      sqltypes.NewVarBinary("abzdef"),
+			dest:     key.DestinationAllShards{},
+		},
+		{
+			testName: "open range",
+			start:    sqltypes.NewVarBinary("abcdef"),
+			end:      sqltypes.NULL,
+			dest:     key.DestinationAllShards{},
+		},
+		{
+			testName: "empty range",
+			start:    sqltypes.NewVarBinary("abz"),
+			end:      sqltypes.NewVarBinary("abc"),
+			dest:     key.DestinationNone{},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.testName, func(t *testing.T) {
+			dest, err := cfc.MapRange(context.Background(), nil, tc.start, tc.end)
+			require.NoError(t, err)
+			assert.EqualValues(t, tc.dest, dest)
+		})
+	}
+}
+
+func TestCFCMapRangeNoHash(t *testing.T) {
+	cfc := makeCFC(t, nil)
+
+	cases := []struct {
+		testName   string
+		start, end sqltypes.Value
+		dest       key.Destination
+	}{
+		{
+			testName: "closed range",
+			start:    sqltypes.NewVarBinary("\x03\x7b"),
+			end:      sqltypes.NewVarBinary("\x05\xff"),
+			dest:     key.DestinationKeyRange{KeyRange: &topodatapb.KeyRange{Start: []byte{3, 123}, End: []byte{6, 0}}},
+		},
+		{
+			testName: "open start",
+			start:    sqltypes.NULL,
+			end:      sqltypes.NewVarBinary("\x05"),
+			dest:     key.DestinationKeyRange{KeyRange: &topodatapb.KeyRange{End: []byte{6}}},
+		},
+		{
+			testName: "open end",
+			start:    sqltypes.NewVarChar("\x03"),
+			end:      sqltypes.NULL,
+			dest:     key.DestinationKeyRange{KeyRange: &topodatapb.KeyRange{Start: []byte{3}}},
+		},
+		{
+			testName: "end overflow",
+			start:    sqltypes.NewVarBinary("\x03"),
+			end:      sqltypes.NewVarBinary("\xff\xff"),
+			dest:     key.DestinationKeyRange{KeyRange: &topodatapb.KeyRange{Start: []byte{3}}},
+		},
+		{
+			testName: "numeric bound",
+			start:    sqltypes.NewInt64(3),
+			end:      sqltypes.NewVarBinary("\x05"),
+			dest:     key.DestinationAllShards{},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.testName, func(t *testing.T) {
+			dest, err := cfc.MapRange(context.Background(), nil, tc.start, tc.end)
+			require.NoError(t, err)
+			assert.EqualValues(t, tc.dest, dest)
+		})
+	}
+}
+
 func TestCFCFindPrefixEscape(t *testing.T) {
 	cases := []struct {
 		str, prefix string
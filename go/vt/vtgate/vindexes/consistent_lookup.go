@@ -45,11 +45,13 @@ var (
 	_ WantOwnerInfo   = (*ConsistentLookupUnique)(nil)
 	_ LookupPlanable  = (*ConsistentLookupUnique)(nil)
 	_ ParamValidating = (*ConsistentLookupUnique)(nil)
+	_ LookupCaching   = (*ConsistentLookupUnique)(nil)
 	_ SingleColumn    = (*ConsistentLookup)(nil)
 	_ Lookup          = (*ConsistentLookup)(nil)
 	_ WantOwnerInfo   = (*ConsistentLookup)(nil)
 	_ LookupPlanable  = (*ConsistentLookup)(nil)
 	_ ParamValidating = (*ConsistentLookup)(nil)
+	_ LookupCaching   = (*ConsistentLookup)(nil)
 
 	consistentLookupParams = append(
 		append(make([]string, 0), lookupInternalParams...),
@@ -493,6 +495,11 @@ func (lu *clCommon) IsBackfilling() bool {
 	return lu.writeOnly
 }
 
+// LookupCache implements the LookupCaching interface
+func (lu *clCommon) LookupCache() *LookupCache {
+	return lu.lkp.cache
+}
+
 // UnknownParams implements the ParamValidating interface.
 func (lu *ConsistentLookupUnique) UnknownParams() []string {
 	return lu.unknownParams
@@ -556,10 +556,17 @@ func (vc *loggingVCursor) LookupRowLockShardSession() vtgatepb.CommitOrder {
 	return vtgatepb.CommitOrder_PRE
 }
 
+func (vc *loggingVCursor) AddLookupCacheInvalidation(table, column string, ids []sqltypes.Value) {
+}
+
 func (vc *loggingVCursor) InTransactionAndIsDML() bool {
 	return false
 }
 
+func (vc *loggingVCursor) InTransaction() bool {
+	return false
+}
+
 func (vc *loggingVCursor) ConnCollation() collations.ID {
 	return vc.Environment().CollationEnv().DefaultConnectionCharset()
 }
@@ -37,10 +37,12 @@ var (
 	_ Lookup          = (*LookupUnique)(nil)
 	_ LookupPlanable  = (*LookupUnique)(nil)
 	_ ParamValidating = (*LookupUnique)(nil)
+	_ LookupCaching   = (*LookupUnique)(nil)
 	_ SingleColumn    = (*LookupNonUnique)(nil)
 	_ Lookup          = (*LookupNonUnique)(nil)
 	_ LookupPlanable  = (*LookupNonUnique)(nil)
 	_ ParamValidating = (*LookupNonUnique)(nil)
+	_ LookupCaching   = (*LookupNonUnique)(nil)
 
 	lookupParams = append(
 		append(make([]string, 0), lookupCommonParams...),
@@ -76,6 +78,11 @@ func (ln *LookupNonUnique) AutoCommitEnabled() bool {
 	return ln.lkp.Autocommit
 }
 
+// LookupCache implements the LookupCaching interface
+func (ln *LookupNonUnique) LookupCache() *LookupCache {
+	return ln.lkp.cache
+}
+
 // String returns the name of the vindex.
 func (ln *LookupNonUnique) String() string {
 	return ln.name
@@ -269,6 +276,11 @@ func (lu *LookupUnique) AutoCommitEnabled() bool {
 	return lu.lkp.Autocommit
 }
 
+// LookupCache implements the LookupCaching interface
+func (lu *LookupUnique) LookupCache() *LookupCache {
+	return lu.lkp.cache
+}
+
 // newLookupUnique creates a LookupUnique vindex.
 // The supplied map has the following required fields:
 //
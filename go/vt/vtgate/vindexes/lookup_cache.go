@@ -0,0 +1,233 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vindexes
+
+import (
+	"hash/fnv"
+	"sync/atomic"
+	"time"
+
+	"vitess.io/vitess/go/cache"
+	"vitess.io/vitess/go/hack"
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/stats"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
+)
+
+var lookupCacheOperations = stats.NewCountersWithSingleLabel("LookupVindexCacheOperations", "Lookup vindex cache operations", "Operation")
+
+const (
+	lookupCacheHit          = "Hit"
+	lookupCacheMiss         = "Miss"
+	lookupCacheInvalidation = "Invalidation"
+
+	defaultLookupCacheTTL = 30 * time.Second
+
+	// lookupCacheBuckets is the number of buckets of ids that have their own
+	// generation.
+	lookupCacheBuckets = 256
+)
+
+// LookupCache caches the rows the lookup query of a lookup vindex returns
+// for its ids, with a bounded number of ids and a ttl. The ids are cached
+// by their weight string in the type and collation of the column of the
+// lookup table, so that the ids the column compares as equal share their
+// rows. The ids are invalidated when vtgate changes their rows in the
+// lookup table, or when something else invalidates them, like a VStream
+// of the lookup table.
+//
+// Nothing is cached until the type of the column is known, from the fields
+// of the lookup query or of a VStream of the lookup table.
+//
+// The lookups done in a transaction never use the cache, so that they see
+// the changes of the transaction. The changes made outside of vtgate, or
+// by another vtgate, are seen once the cached ids expire, unless they are
+// invalidated by a VStream. So are the changes of a transaction when the
+// ids are looked up again before it commits.
+type LookupCache struct {
+	table  string
+	column string
+	ttl    time.Duration
+
+	columnType atomic.Pointer[lookupCacheColumnType]
+	entries    *cache.LRUCache[*lookupCacheEntry]
+	// generations are bumped by the invalidations of the ids of their
+	// bucket, so that the rows fetched while the ids were invalidated are
+	// not cached.
+	generations [lookupCacheBuckets]atomic.Uint64
+}
+
+type lookupCacheColumnType struct {
+	typ       sqltypes.Type
+	collation collations.ID
+}
+
+type lookupCacheEntry struct {
+	rows    [][]sqltypes.Value
+	expires time.Time
+}
+
+func newLookupCache(table, column string, size int64, ttl time.Duration) *LookupCache {
+	return &LookupCache{
+		table:   table,
+		column:  column,
+		ttl:     ttl,
+		entries: cache.NewLRUCache[*lookupCacheEntry](size),
+	}
+}
+
+// Table returns the lookup table, which can be qualified by its keyspace.
+func (lc *LookupCache) Table() string {
+	return lc.table
+}
+
+// Column returns the column of the lookup table the ids are looked up by.
+func (lc *LookupCache) Column() string {
+	return lc.column
+}
+
+// SetColumnField sets the type and collation of the column of the lookup
+// table from its field. All the ids are invalidated when they change.
+func (lc *LookupCache) SetColumnField(field *querypb.Field) {
+	columnType := &lookupCacheColumnType{typ: field.Type, collation: collations.ID(field.Charset)}
+	if current := lc.columnType.Load(); current != nil && *current == *columnType {
+		return
+	}
+	lc.columnType.Store(columnType)
+	lc.InvalidateAll()
+}
+
+// key returns the key of the id in the cache, which is false for the NULL
+// ids and for the ids that cannot be keyed before the column type is known.
+func (lc *LookupCache) key(id sqltypes.Value) (string, bool) {
+	columnType := lc.columnType.Load()
+	if columnType == nil || id.IsNull() {
+		return "", false
+	}
+	weight, _, err := evalengine.WeightString(nil, id, columnType.typ, columnType.collation, 0, 0, nil, 0)
+	if err != nil {
+		return "", false
+	}
+	return hack.String(weight), true
+}
+
+func lookupCacheBucket(key string) int {
+	h := fnv.New32a()
+	_, _ = h.Write(hack.StringBytes(key))
+	return int(h.Sum32() % lookupCacheBuckets)
+}
+
+// Fetch returns the results of the lookups of the ids, in the order of the
+// ids. The ids that are not cached are looked up with fetch, which returns
+// their results in the same order, and are then cached.
+func (lc *LookupCache) Fetch(ids []sqltypes.Value, fetch func(ids []sqltypes.Value) ([]*sqltypes.Result, error)) ([]*sqltypes.Result, error) {
+	now := time.Now()
+
+	type miss struct {
+		index      int
+		key        string
+		cacheable  bool
+		generation uint64
+	}
+	results := make([]*sqltypes.Result, len(ids))
+	var missed []miss
+	var misses []sqltypes.Value
+	for i, id := range ids {
+		key, ok := lc.key(id)
+		if ok {
+			if rows, ok := lc.get(key, now); ok {
+				results[i] = &sqltypes.Result{Rows: rows}
+				continue
+			}
+		}
+		m := miss{index: i, key: key, cacheable: ok}
+		if ok {
+			m.generation = lc.generations[lookupCacheBucket(key)].Load()
+		}
+		missed = append(missed, m)
+		misses = append(misses, id)
+	}
+	lookupCacheOperations.Add(lookupCacheHit, int64(len(ids)-len(misses)))
+	if len(misses) == 0 {
+		return results, nil
+	}
+	lookupCacheOperations.Add(lookupCacheMiss, int64(len(misses)))
+
+	fetched, err := fetch(misses)
+	if err != nil {
+		return nil, err
+	}
+	expires := time.Now().Add(lc.ttl)
+	for i, result := range fetched {
+		m := missed[i]
+		results[m.index] = result
+		if m.cacheable && lc.generations[lookupCacheBucket(m.key)].Load() == m.generation {
+			lc.entries.Set(m.key, &lookupCacheEntry{rows: result.Rows, expires: expires})
+		}
+	}
+	return results, nil
+}
+
+func (lc *LookupCache) get(key string, now time.Time) ([][]sqltypes.Value, bool) {
+	entry, ok := lc.entries.Get(key)
+	if !ok {
+		return nil, false
+	}
+	if now.After(entry.expires) {
+		lc.entries.Delete(key)
+		return nil, false
+	}
+	return entry.rows, true
+}
+
+// Invalidate removes the ids from the cache.
+func (lc *LookupCache) Invalidate(ids []sqltypes.Value) {
+	for _, id := range ids {
+		if id.IsNull() {
+			continue
+		}
+		key, ok := lc.key(id)
+		if !ok {
+			// The bucket of the id is not known.
+			lc.InvalidateAll()
+			return
+		}
+		lc.generations[lookupCacheBucket(key)].Add(1)
+		lc.entries.Delete(key)
+	}
+	lookupCacheOperations.Add(lookupCacheInvalidation, int64(len(ids)))
+}
+
+// InvalidateAll removes all the ids from the cache.
+func (lc *LookupCache) InvalidateAll() {
+	for i := range lc.generations {
+		lc.generations[i].Add(1)
+	}
+	items := lc.entries.Items()
+	for _, item := range items {
+		lc.entries.Delete(item.Key)
+	}
+	lookupCacheOperations.Add(lookupCacheInvalidation, int64(len(items)))
+}
+
+// Len returns the number of cached ids.
+func (lc *LookupCache) Len() int {
+	return lc.entries.Len()
+}
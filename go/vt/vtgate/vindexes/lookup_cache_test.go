@@ -0,0 +1,169 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vindexes
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
+)
+
+// fetchLookupRows returns a fetch function that maps each id to a row with
+// the id as keyspace id, and records the ids it fetched.
+func fetchLookupRows(fetched *[]sqltypes.Value) func(ids []sqltypes.Value) ([]*sqltypes.Result, error) {
+	return func(ids []sqltypes.Value) ([]*sqltypes.Result, error) {
+		*fetched = append(*fetched, ids...)
+		results := make([]*sqltypes.Result, 0, len(ids))
+		for _, id := range ids {
+			results = append(results, &sqltypes.Result{Rows: [][]sqltypes.Value{{id}}})
+		}
+		return results, nil
+	}
+}
+
+// cached returns whether the id is cached.
+func cached(lc *LookupCache, id sqltypes.Value, now time.Time) bool {
+	key, ok := lc.key(id)
+	if !ok {
+		return false
+	}
+	_, ok = lc.get(key, now)
+	return ok
+}
+
+func TestLookupCacheFetch(t *testing.T) {
+	lc := newLookupCache("ks.t", "fromc", 2, time.Minute)
+	var fetched []sqltypes.Value
+
+	// Nothing is cached before the type of the column is known.
+	_, err := lc.Fetch([]sqltypes.Value{sqltypes.NewInt64(1)}, fetchLookupRows(&fetched))
+	require.NoError(t, err)
+	assert.Zero(t, lc.Len())
+	lc.SetColumnField(&querypb.Field{Name: "fromc", Type: sqltypes.Int64, Charset: collations.CollationBinaryID})
+	fetched = nil
+
+	ids := []sqltypes.Value{sqltypes.NewInt64(1), sqltypes.NULL, sqltypes.NewInt64(2)}
+	results, err := lc.Fetch(ids, fetchLookupRows(&fetched))
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	for i, id := range ids {
+		assert.Equal(t, [][]sqltypes.Value{{id}}, results[i].Rows)
+	}
+	assert.Equal(t, ids, fetched)
+	// The NULL ids are not cached.
+	assert.Equal(t, 2, lc.Len())
+
+	fetched = nil
+	results, err = lc.Fetch([]sqltypes.Value{sqltypes.NewInt64(2), sqltypes.NewInt64(3)}, fetchLookupRows(&fetched))
+	require.NoError(t, err)
+	assert.Equal(t, [][]sqltypes.Value{{sqltypes.NewInt64(2)}}, results[0].Rows)
+	assert.Equal(t, [][]sqltypes.Value{{sqltypes.NewInt64(3)}}, results[1].Rows)
+	assert.Equal(t, []sqltypes.Value{sqltypes.NewInt64(3)}, fetched)
+	// The least recently used id was evicted.
+	assert.Equal(t, 2, lc.Len())
+	assert.False(t, cached(lc, sqltypes.NewInt64(1), time.Now()))
+
+	lc.Invalidate([]sqltypes.Value{sqltypes.NewInt64(2)})
+	assert.False(t, cached(lc, sqltypes.NewInt64(2), time.Now()))
+	lc.InvalidateAll()
+	assert.Zero(t, lc.Len())
+
+	_, err = lc.Fetch([]sqltypes.Value{sqltypes.NewInt64(4)}, func(ids []sqltypes.Value) ([]*sqltypes.Result, error) {
+		return nil, errors.New("lookup failed")
+	})
+	require.EqualError(t, err, "lookup failed")
+	assert.Zero(t, lc.Len())
+}
+
+func TestLookupCacheExpiry(t *testing.T) {
+	lc := newLookupCache("t", "fromc", 10, 10*time.Millisecond)
+	lc.SetColumnField(&querypb.Field{Name: "fromc", Type: sqltypes.VarBinary, Charset: collations.CollationBinaryID})
+	var fetched []sqltypes.Value
+
+	_, err := lc.Fetch([]sqltypes.Value{sqltypes.NewVarChar("a")}, fetchLookupRows(&fetched))
+	require.NoError(t, err)
+	assert.True(t, cached(lc, sqltypes.NewVarChar("a"), time.Now()))
+	assert.False(t, cached(lc, sqltypes.NewVarChar("a"), time.Now().Add(time.Second)))
+}
+
+func TestLookupCacheCollation(t *testing.T) {
+	lc := newLookupCache("t", "fromc", 10, time.Minute)
+	lc.SetColumnField(&querypb.Field{Name: "fromc", Type: sqltypes.VarChar, Charset: uint32(collations.MySQL8().DefaultConnectionCharset())})
+	var fetched []sqltypes.Value
+
+	// The ids the collation of the column compares as equal are cached
+	// once, and are invalidated together.
+	_, err := lc.Fetch([]sqltypes.Value{sqltypes.NewVarChar("Foo")}, fetchLookupRows(&fetched))
+	require.NoError(t, err)
+	assert.True(t, cached(lc, sqltypes.NewVarChar("foo"), time.Now()))
+	assert.False(t, cached(lc, sqltypes.NewVarChar("bar"), time.Now()))
+	lc.Invalidate([]sqltypes.Value{sqltypes.NewVarChar("fOO")})
+	assert.Zero(t, lc.Len())
+
+	// The ids are keyed again when the column changes.
+	_, err = lc.Fetch([]sqltypes.Value{sqltypes.NewVarChar("Foo")}, fetchLookupRows(&fetched))
+	require.NoError(t, err)
+	lc.SetColumnField(&querypb.Field{Name: "fromc", Type: sqltypes.VarBinary, Charset: collations.CollationBinaryID})
+	assert.Zero(t, lc.Len())
+	assert.False(t, cached(lc, sqltypes.NewVarChar("foo"), time.Now()))
+}
+
+func TestLookupCacheInvalidatedWhileFetching(t *testing.T) {
+	lc := newLookupCache("t", "fromc", 10, time.Minute)
+	lc.SetColumnField(&querypb.Field{Name: "fromc", Type: sqltypes.Int64, Charset: collations.CollationBinaryID})
+
+	// The rows fetched while the ids were invalidated are not cached,
+	// since they may be the rows before they changed.
+	results, err := lc.Fetch([]sqltypes.Value{sqltypes.NewInt64(1)}, func(ids []sqltypes.Value) ([]*sqltypes.Result, error) {
+		lc.Invalidate(ids)
+		return []*sqltypes.Result{{Rows: [][]sqltypes.Value{{sqltypes.NewInt64(1)}}}}, nil
+	})
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+	assert.Zero(t, lc.Len())
+}
+
+func TestLookupCacheOtherIdsInvalidatedWhileFetching(t *testing.T) {
+	lc := newLookupCache("t", "fromc", 10, time.Minute)
+	lc.SetColumnField(&querypb.Field{Name: "fromc", Type: sqltypes.Int64, Charset: collations.CollationBinaryID})
+	// other is an id of another bucket than the one of the fetched id.
+	fetchedKey, _ := lc.key(sqltypes.NewInt64(1))
+	var other sqltypes.Value
+	for i := int64(2); ; i++ {
+		other = sqltypes.NewInt64(i)
+		if otherKey, _ := lc.key(other); lookupCacheBucket(otherKey) != lookupCacheBucket(fetchedKey) {
+			break
+		}
+	}
+
+	// The invalidations of the other ids do not keep the fetched rows from
+	// being cached.
+	_, err := lc.Fetch([]sqltypes.Value{sqltypes.NewInt64(1)}, func(ids []sqltypes.Value) ([]*sqltypes.Result, error) {
+		lc.Invalidate([]sqltypes.Value{other})
+		return []*sqltypes.Result{{Rows: [][]sqltypes.Value{{sqltypes.NewInt64(1)}}}}, nil
+	})
+	require.NoError(t, err)
+	assert.True(t, cached(lc, sqltypes.NewInt64(1), time.Now()))
+}
@@ -37,10 +37,12 @@ var (
 	_ Lookup          = (*LookupHash)(nil)
 	_ LookupPlanable  = (*LookupHash)(nil)
 	_ ParamValidating = (*LookupHash)(nil)
+	_ LookupCaching   = (*LookupHash)(nil)
 	_ SingleColumn    = (*LookupHashUnique)(nil)
 	_ Lookup          = (*LookupHashUnique)(nil)
 	_ LookupPlanable  = (*LookupHashUnique)(nil)
 	_ ParamValidating = (*LookupHashUnique)(nil)
+	_ LookupCaching   = (*LookupHashUnique)(nil)
 
 	lookupHashParams = append(
 		append(make([]string, 0), lookupCommonParams...),
@@ -189,6 +191,11 @@ func (lh *LookupHash) AutoCommitEnabled() bool {
 	return lh.lkp.Autocommit
 }
 
+// LookupCache implements the LookupCaching interface
+func (lh *LookupHash) LookupCache() *LookupCache {
+	return lh.lkp.cache
+}
+
 // GetCommitOrder implements the LookupPlanable interface
 func (lh *LookupHash) GetCommitOrder() vtgatepb.CommitOrder {
 	return vtgatepb.CommitOrder_NORMAL
@@ -434,6 +441,11 @@ func (lhu *LookupHashUnique) AutoCommitEnabled() bool {
 	return lhu.lkp.Autocommit
 }
 
+// LookupCache implements the LookupCaching interface
+func (lhu *LookupHashUnique) LookupCache() *LookupCache {
+	return lhu.lkp.cache
+}
+
 func (lhu *LookupHashUnique) Query() (selQuery string, arguments []string) {
 	return lhu.lkp.query()
 }
@@ -23,6 +23,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"vitess.io/vitess/go/sqltypes"
 	querypb "vitess.io/vitess/go/vt/proto/query"
@@ -46,6 +47,8 @@ const (
 	lookupInternalParamIgnoreNulls = "ignore_nulls"
 	lookupInternalParamBatchLookup = "batch_lookup"
 	lookupInternalParamReadLock    = "read_lock"
+	lookupInternalParamCacheSize   = "cache_size"
+	lookupInternalParamCacheTTL    = "cache_ttl"
 )
 
 var (
@@ -71,6 +74,8 @@ var (
 		lookupInternalParamIgnoreNulls,
 		lookupInternalParamBatchLookup,
 		lookupInternalParamReadLock,
+		lookupInternalParamCacheSize,
+		lookupInternalParamCacheTTL,
 	}
 )
 
@@ -86,6 +91,8 @@ type lookupInternal struct {
 	BatchLookup             bool     `json:"batch_lookup,omitempty"`
 	ReadLock                string   `json:"read_lock,omitempty"`
 	sel, selTxDml, ver, del string   // sel: map query, ver: verify query, del: delete query
+	// cache caches the rows of the map query when cache_size is set.
+	cache *LookupCache
 }
 
 func (lkp *lookupInternal) Init(lookupQueryParams map[string]string, autocommit, upsert, multiShardAutocommit bool) error {
@@ -113,6 +120,10 @@ func (lkp *lookupInternal) Init(lookupQueryParams map[string]string, autocommit,
 		lkp.ReadLock = readLock
 	}
 
+	if err := lkp.initCache(lookupQueryParams); err != nil {
+		return err
+	}
+
 	lkp.Autocommit = autocommit
 	lkp.Upsert = upsert
 	if multiShardAutocommit {
@@ -138,11 +149,44 @@ func (lkp *lookupInternal) Init(lookupQueryParams map[string]string, autocommit,
 	return nil
 }
 
-// Lookup performs a lookup for the ids.
+// initCache creates the cache of the map query rows if cache_size is set.
+func (lkp *lookupInternal) initCache(lookupQueryParams map[string]string) error {
+	size, ok := lookupQueryParams[lookupInternalParamCacheSize]
+	if !ok {
+		return nil
+	}
+	cacheSize, err := strconv.ParseInt(size, 10, 64)
+	if err != nil || cacheSize < 0 {
+		return vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "invalid %s value: %s", lookupInternalParamCacheSize, size)
+	}
+	cacheTTL := defaultLookupCacheTTL
+	if ttl, ok := lookupQueryParams[lookupInternalParamCacheTTL]; ok {
+		cacheTTL, err = time.ParseDuration(ttl)
+		if err != nil || cacheTTL <= 0 {
+			return vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "invalid %s value: %s", lookupInternalParamCacheTTL, ttl)
+		}
+	}
+	if cacheSize > 0 {
+		lkp.cache = newLookupCache(lkp.Table, lkp.FromColumns[0], cacheSize, cacheTTL)
+	}
+	return nil
+}
+
+// Lookup performs a lookup for the ids. The lookups done outside of a
+// transaction use the cache, if any.
 func (lkp *lookupInternal) Lookup(ctx context.Context, vcursor VCursor, ids []sqltypes.Value, co vtgatepb.CommitOrder) ([]*sqltypes.Result, error) {
 	if vcursor == nil {
 		return nil, vterrors.VT13001("cannot perform lookup: no vcursor provided")
 	}
+	if lkp.cache != nil && !vcursor.InTransaction() {
+		return lkp.cache.Fetch(ids, func(ids []sqltypes.Value) ([]*sqltypes.Result, error) {
+			return lkp.lookup(ctx, vcursor, ids, co)
+		})
+	}
+	return lkp.lookup(ctx, vcursor, ids, co)
+}
+
+func (lkp *lookupInternal) lookup(ctx context.Context, vcursor VCursor, ids []sqltypes.Value, co vtgatepb.CommitOrder) ([]*sqltypes.Result, error) {
 	results := make([]*sqltypes.Result, 0, len(ids))
 	if lkp.Autocommit {
 		co = vtgatepb.CommitOrder_AUTOCOMMIT
@@ -166,6 +210,7 @@ func (lkp *lookupInternal) Lookup(ctx context.Context, vcursor VCursor, ids []sq
 		if err != nil {
 			return nil, vterrors.Wrap(err, "lookup.Map")
 		}
+		lkp.setCacheColumn(result)
 		resultMap := make(map[string][][]sqltypes.Value)
 		for _, row := range result.Rows {
 			resultMap[row[0].ToString()] = append(resultMap[row[0].ToString()], []sqltypes.Value{row[1]})
@@ -191,6 +236,7 @@ func (lkp *lookupInternal) Lookup(ctx context.Context, vcursor VCursor, ids []sq
 			if err != nil {
 				return nil, vterrors.Wrap(err, "lookup.Map")
 			}
+			lkp.setCacheColumn(result)
 			rows := make([][]sqltypes.Value, 0, len(result.Rows))
 			for _, row := range result.Rows {
 				rows = append(rows, []sqltypes.Value{row[1]})
@@ -203,6 +249,14 @@ func (lkp *lookupInternal) Lookup(ctx context.Context, vcursor VCursor, ids []sq
 	return results, nil
 }
 
+// setCacheColumn sets the type of the column of the cached ids from the
+// fields of the result of the lookup query.
+func (lkp *lookupInternal) setCacheColumn(result *sqltypes.Result) {
+	if lkp.cache != nil && len(result.Fields) > 0 {
+		lkp.cache.SetColumnField(result.Fields[0])
+	}
+}
+
 // Verify returns true if ids map to values.
 func (lkp *lookupInternal) Verify(ctx context.Context, vcursor VCursor, ids, values []sqltypes.Value) ([]bool, error) {
 	co := vtgatepb.CommitOrder_NORMAL
@@ -305,6 +359,9 @@ nextRow:
 	if len(trimmedRowsCols[0]) != len(lkp.FromColumns) {
 		return vterrors.VT03030(lkp.FromColumns, len(trimmedRowsCols[0]))
 	}
+	ids := lkp.cachedIds(trimmedRowsCols)
+	lkp.invalidateCache(ids)
+	defer lkp.invalidateCacheAfterWrite(vcursor, ids, co)
 	sort.Sort(&sorter{rowsColValues: trimmedRowsCols, toValues: trimmedToValues})
 
 	insStmt := "insert"
@@ -381,6 +438,9 @@ func (lkp *lookupInternal) Delete(ctx context.Context, vcursor VCursor, rowsColV
 	if len(rowsColValues[0]) != len(lkp.FromColumns) {
 		return vterrors.VT03030(lkp.FromColumns, len(rowsColValues[0]))
 	}
+	ids := lkp.cachedIds(rowsColValues)
+	lkp.invalidateCache(ids)
+	defer lkp.invalidateCacheAfterWrite(vcursor, ids, co)
 	for _, column := range rowsColValues {
 		bindVars := make(map[string]*querypb.BindVariable, len(rowsColValues))
 		for colIdx, columnValue := range column {
@@ -420,6 +480,43 @@ func (lkp *lookupInternal) query() (selQuery string, arguments []string) {
 	return lkp.sel, lkp.FromColumns
 }
 
+// cachedIds returns the ids of the rows in the cache, if any. The ids are
+// the values of the first from column, which the map query uses.
+func (lkp *lookupInternal) cachedIds(rowsColValues [][]sqltypes.Value) []sqltypes.Value {
+	if lkp.cache == nil {
+		return nil
+	}
+	ids := make([]sqltypes.Value, 0, len(rowsColValues))
+	for _, row := range rowsColValues {
+		ids = append(ids, row[0])
+	}
+	return ids
+}
+
+// invalidateCache removes the ids from the cache before they are written.
+func (lkp *lookupInternal) invalidateCache(ids []sqltypes.Value) {
+	if lkp.cache == nil {
+		return
+	}
+	lkp.cache.Invalidate(ids)
+}
+
+// invalidateCacheAfterWrite invalidates the ids again once they are written,
+// whether the write failed or not, since a concurrent map may have cached
+// them from the lookup table in between. When the write is part of a
+// transaction, the ids are invalidated when it commits instead, as they
+// are not visible to the other sessions until then.
+func (lkp *lookupInternal) invalidateCacheAfterWrite(vcursor VCursor, ids []sqltypes.Value, co vtgatepb.CommitOrder) {
+	if lkp.cache == nil {
+		return
+	}
+	if co == vtgatepb.CommitOrder_AUTOCOMMIT || !vcursor.InTransaction() {
+		lkp.cache.Invalidate(ids)
+		return
+	}
+	vcursor.AddLookupCacheInvalidation(lkp.cache.Table(), lkp.cache.Column(), ids)
+}
+
 type commonConfig struct {
 	autocommit           bool
 	multiShardAutocommit bool
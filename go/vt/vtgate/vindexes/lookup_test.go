@@ -45,23 +45,33 @@ import (
 var _ VCursor = (*vcursor)(nil)
 
 type vcursor struct {
-	mustFail    bool
-	numRows     int
-	result      *sqltypes.Result
-	queries     []*querypb.BoundQuery
-	autocommits int
-	pre, post   int
-	keys        []sqltypes.Value
+	mustFail      bool
+	inTransaction bool
+	numRows       int
+	result        *sqltypes.Result
+	queries       []*querypb.BoundQuery
+	autocommits   int
+	pre, post     int
+	keys          []sqltypes.Value
+	invalidations []sqltypes.Value
 }
 
 func (vc *vcursor) LookupRowLockShardSession() vtgatepb.CommitOrder {
 	panic("implement me")
 }
 
+func (vc *vcursor) AddLookupCacheInvalidation(table, column string, ids []sqltypes.Value) {
+	vc.invalidations = append(vc.invalidations, ids...)
+}
+
 func (vc *vcursor) InTransactionAndIsDML() bool {
 	return false
 }
 
+func (vc *vcursor) InTransaction() bool {
+	return vc.inTransaction
+}
+
 func (vc *vcursor) Execute(ctx context.Context, method string, query string, bindvars map[string]*querypb.BindVariable, rollbackOnError bool, co vtgatepb.CommitOrder) (*sqltypes.Result, error) {
 	switch co {
 	case vtgatepb.CommitOrder_PRE:
@@ -293,6 +303,24 @@ func testLookupCreateVindexInternalCases(t *testing.T, testCaseF func(string, ma
 			vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "ignore_nulls value must be 'true' or 'false': 'hello'"),
 			nil,
 		),
+		testCaseF(
+			"cache_size and cache_ttl",
+			map[string]string{"cache_size": "1000", "cache_ttl": "1m"},
+			nil,
+			nil,
+		),
+		testCaseF(
+			"cache_size reject not int",
+			map[string]string{"cache_size": "hello"},
+			vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "invalid cache_size value: hello"),
+			nil,
+		),
+		testCaseF(
+			"cache_ttl reject not duration",
+			map[string]string{"cache_size": "1000", "cache_ttl": "0s"},
+			vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "invalid cache_ttl value: 0s"),
+			nil,
+		),
 		testCaseF(
 			"write_only true",
 			map[string]string{"write_only": "true"},
@@ -411,6 +439,78 @@ func TestLookupNonUniqueMap(t *testing.T) {
 	require.EqualError(t, err, "lookup.Map: execute failed")
 }
 
+func TestLookupNonUniqueMapCache(t *testing.T) {
+	vindex, err := CreateVindex("lookup", "lookup", map[string]string{
+		"table":      "t",
+		"from":       "fromc",
+		"to":         "toc",
+		"cache_size": "10",
+	})
+	require.NoError(t, err)
+	lnu := vindex.(SingleColumn)
+	require.NotNil(t, vindex.(LookupCaching).LookupCache())
+	vc := &vcursor{numRows: 2}
+
+	want := []key.Destination{
+		key.DestinationKeyspaceIDs([][]byte{[]byte("1"), []byte("2")}),
+		key.DestinationKeyspaceIDs([][]byte{[]byte("1"), []byte("2")}),
+	}
+	// The first lookup only learns the type of the column from the fields of
+	// its result, which the ids are then cached by.
+	got, err := lnu.Map(context.Background(), vc, []sqltypes.Value{sqltypes.NewInt64(1), sqltypes.NewInt64(2)})
+	require.NoError(t, err)
+	utils.MustMatch(t, want, got)
+	require.Len(t, vc.queries, 1)
+	got, err = lnu.Map(context.Background(), vc, []sqltypes.Value{sqltypes.NewInt64(1), sqltypes.NewInt64(2)})
+	require.NoError(t, err)
+	utils.MustMatch(t, want, got)
+	require.Len(t, vc.queries, 2)
+
+	// The ids are now cached, including the ones equal to them in the type
+	// of the column.
+	got, err = lnu.Map(context.Background(), vc, []sqltypes.Value{sqltypes.NewInt64(1), sqltypes.NewVarChar("2")})
+	require.NoError(t, err)
+	utils.MustMatch(t, want, got)
+	require.Len(t, vc.queries, 2)
+
+	// Only the ids that are not cached are looked up.
+	vc.keys = []sqltypes.Value{sqltypes.NewInt64(3), sqltypes.NewInt64(3)}
+	got, err = lnu.Map(context.Background(), vc, []sqltypes.Value{sqltypes.NewInt64(1), sqltypes.NewInt64(3)})
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	require.Len(t, vc.queries, 3)
+	vars, err := sqltypes.BuildBindVariable([]any{sqltypes.NewInt64(3)})
+	require.NoError(t, err)
+	utils.MustMatch(t, map[string]*querypb.BindVariable{"fromc": vars}, vc.queries[2].BindVariables)
+	vc.keys = nil
+
+	// The lookups in a transaction do not use the cache.
+	vc.inTransaction = true
+	_, err = lnu.Map(context.Background(), vc, []sqltypes.Value{sqltypes.NewInt64(1)})
+	require.NoError(t, err)
+	require.Len(t, vc.queries, 4)
+	vc.inTransaction = false
+
+	// Changing the lookup rows of the ids invalidates them.
+	err = lnu.(Lookup).Update(context.Background(), vc, []sqltypes.Value{sqltypes.NewInt64(1)}, []byte("test"), []sqltypes.Value{sqltypes.NewInt64(2)})
+	require.NoError(t, err)
+	require.Len(t, vc.queries, 6)
+	_, err = lnu.Map(context.Background(), vc, []sqltypes.Value{sqltypes.NewInt64(1), sqltypes.NewInt64(2)})
+	require.NoError(t, err)
+	require.Len(t, vc.queries, 7)
+	vars, err = sqltypes.BuildBindVariable([]any{sqltypes.NewInt64(1), sqltypes.NewInt64(2)})
+	require.NoError(t, err)
+	utils.MustMatch(t, map[string]*querypb.BindVariable{"fromc": vars}, vc.queries[6].BindVariables)
+	assert.Empty(t, vc.invalidations)
+
+	// The ids changed in a transaction are also invalidated when it commits,
+	// since they can be cached again from the lookup table until then.
+	vc.inTransaction = true
+	err = lnu.(Lookup).Update(context.Background(), vc, []sqltypes.Value{sqltypes.NewInt64(1)}, []byte("test"), []sqltypes.Value{sqltypes.NewInt64(2)})
+	require.NoError(t, err)
+	utils.MustMatch(t, []sqltypes.Value{sqltypes.NewInt64(1), sqltypes.NewInt64(2)}, vc.invalidations)
+}
+
 func TestLookupNonUniqueMapAutocommit(t *testing.T) {
 	vindex, err := CreateVindex("lookup", "lookup", map[string]string{
 		"table":      "t",
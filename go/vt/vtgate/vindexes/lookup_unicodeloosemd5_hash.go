@@ -36,9 +36,11 @@ var (
 	_ SingleColumn    = (*LookupUnicodeLooseMD5Hash)(nil)
 	_ Lookup          = (*LookupUnicodeLooseMD5Hash)(nil)
 	_ ParamValidating = (*LookupUnicodeLooseMD5Hash)(nil)
+	_ LookupCaching   = (*LookupUnicodeLooseMD5Hash)(nil)
 	_ SingleColumn    = (*LookupUnicodeLooseMD5HashUnique)(nil)
 	_ Lookup          = (*LookupUnicodeLooseMD5HashUnique)(nil)
 	_ ParamValidating = (*LookupUnicodeLooseMD5HashUnique)(nil)
+	_ LookupCaching   = (*LookupUnicodeLooseMD5HashUnique)(nil)
 
 	lookupUnicodeLooseMD5HashParams = append(
 		append(make([]string, 0), lookupCommonParams...),
@@ -167,6 +169,11 @@ func (lh *LookupUnicodeLooseMD5Hash) AutoCommitEnabled() bool {
 	return lh.lkp.Autocommit
 }
 
+// LookupCache implements the LookupCaching interface
+func (lh *LookupUnicodeLooseMD5Hash) LookupCache() *LookupCache {
+	return lh.lkp.cache
+}
+
 // Verify returns true if ids maps to ksids.
 func (lh *LookupUnicodeLooseMD5Hash) Verify(ctx context.Context, vcursor VCursor, ids []sqltypes.Value, ksids [][]byte) ([]bool, error) {
 	if lh.writeOnly {
@@ -347,6 +354,11 @@ func (lhu *LookupUnicodeLooseMD5HashUnique) AutoCommitEnabled() bool {
 	return lhu.lkp.Autocommit
 }
 
+// LookupCache implements the LookupCaching interface
+func (lhu *LookupUnicodeLooseMD5HashUnique) LookupCache() *LookupCache {
+	return lhu.lkp.cache
+}
+
 // Verify returns true if ids maps to ksids.
 func (lhu *LookupUnicodeLooseMD5HashUnique) Verify(ctx context.Context, vcursor VCursor, ids []sqltypes.Value, ksids [][]byte) ([]bool, error) {
 	if lhu.writeOnly {
@@ -24,12 +24,15 @@ import (
 
 	"vitess.io/vitess/go/sqltypes"
 	"vitess.io/vitess/go/vt/key"
+
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
 )
 
 var (
 	_ SingleColumn    = (*Numeric)(nil)
 	_ Reversible      = (*Numeric)(nil)
 	_ Hashing         = (*Numeric)(nil)
+	_ RangeMapper     = (*Numeric)(nil)
 	_ ParamValidating = (*Numeric)(nil)
 )
 
@@ -95,6 +98,31 @@ func (vind *Numeric) Map(ctx context.Context, vcursor VCursor, ids []sqltypes.Va
 	return out, nil
 }
 
+// MapRange implements the RangeMapper interface. The keyspace ids keep the
+// order of the unsigned ids, but the negative ids map after the positive ones,
+// so a range with a negative or non-integral bound maps to all the shards.
+func (vind *Numeric) MapRange(ctx context.Context, vcursor VCursor, start, end sqltypes.Value) (key.Destination, error) {
+	keyRange := &topodatapb.KeyRange{}
+	if !start.IsNull() {
+		ksid, err := vind.Hash(start)
+		if err != nil {
+			return key.DestinationAllShards{}, nil
+		}
+		keyRange.Start = ksid
+	}
+	if !end.IsNull() {
+		ksid, err := vind.Hash(end)
+		if err != nil {
+			return key.DestinationAllShards{}, nil
+		}
+		if bytes.Compare(keyRange.Start, ksid) > 0 {
+			return key.DestinationNone{}, nil
+		}
+		keyRange.End = addOne(ksid)
+	}
+	return key.DestinationKeyRange{KeyRange: keyRange}, nil
+}
+
 // ReverseMap returns the associated ids for the ksids.
 func (*Numeric) ReverseMap(_ VCursor, ksids [][]byte) ([]sqltypes.Value, error) {
 	var reverseIds = make([]sqltypes.Value, len(ksids))
@@ -25,6 +25,8 @@ import (
 
 	"vitess.io/vitess/go/sqltypes"
 	"vitess.io/vitess/go/vt/key"
+
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
 )
 
 var numeric SingleColumn
@@ -130,6 +132,57 @@ func TestNumericVerify(t *testing.T) {
 	require.EqualError(t, err, "cannot parse uint64 from \"aa\"")
 }
 
+func TestNumericMapRange(t *testing.T) {
+	tests := []struct {
+		start, end sqltypes.Value
+		want       key.Destination
+	}{{
+		start: sqltypes.NewInt64(1),
+		end:   sqltypes.NewInt64(0x1ff),
+		want: key.DestinationKeyRange{KeyRange: &topodatapb.KeyRange{
+			Start: []byte("\x00\x00\x00\x00\x00\x00\x00\x01"),
+			End:   []byte("\x00\x00\x00\x00\x00\x00\x02\x00"),
+		}},
+	}, {
+		start: sqltypes.NULL,
+		end:   sqltypes.NewInt64(1),
+		want: key.DestinationKeyRange{KeyRange: &topodatapb.KeyRange{
+			End: []byte("\x00\x00\x00\x00\x00\x00\x00\x02"),
+		}},
+	}, {
+		start: sqltypes.NewUint64(1 << 63),
+		end:   sqltypes.NULL,
+		want: key.DestinationKeyRange{KeyRange: &topodatapb.KeyRange{
+			Start: []byte("\x80\x00\x00\x00\x00\x00\x00\x00"),
+		}},
+	}, {
+		start: sqltypes.NewInt64(1),
+		end:   sqltypes.NewUint64(1<<64 - 1),
+		want: key.DestinationKeyRange{KeyRange: &topodatapb.KeyRange{
+			Start: []byte("\x00\x00\x00\x00\x00\x00\x00\x01"),
+		}},
+	}, {
+		start: sqltypes.NewInt64(2),
+		end:   sqltypes.NewInt64(1),
+		want:  key.DestinationNone{},
+	}, {
+		start: sqltypes.NewInt64(-1),
+		end:   sqltypes.NewInt64(1),
+		want:  key.DestinationAllShards{},
+	}, {
+		start: sqltypes.NewInt64(1),
+		end:   sqltypes.NewFloat64(1.1),
+		want:  key.DestinationAllShards{},
+	}}
+	for _, tc := range tests {
+		t.Run(tc.start.String()+"-"+tc.end.String(), func(t *testing.T) {
+			got, err := numeric.(RangeMapper).MapRange(context.Background(), nil, tc.start, tc.end)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
 func TestNumericReverseMap(t *testing.T) {
 	got, err := numeric.(Reversible).ReverseMap(nil, [][]byte{[]byte("\x00\x00\x00\x00\x00\x00\x00\x01")})
 	require.NoError(t, err)
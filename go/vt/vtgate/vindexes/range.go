@@ -0,0 +1,287 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vindexes
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strconv"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/key"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+const (
+	rangeParamSplitPoints = "split_points"
+	rangeParamType        = "type"
+	rangeParamCollation   = "collation"
+
+	rangeTypeInt64     = "int64"
+	rangeTypeVarbinary = "varbinary"
+	rangeTypeVarchar   = "varchar"
+)
+
+var (
+	_ SingleColumn    = (*Range)(nil)
+	_ Hashing         = (*Range)(nil)
+	_ RangeMapper     = (*Range)(nil)
+	_ ParamValidating = (*Range)(nil)
+
+	rangeParams = []string{
+		rangeParamSplitPoints,
+		rangeParamType,
+		rangeParamCollation,
+	}
+
+	// rangeFirstKeyspaceID is the keyspace id of the ids below the first split point.
+	rangeFirstKeyspaceID = []byte{0}
+)
+
+// Range is an ordered vindex. Its split points divide the ids into
+// ranges, and map all the ids of a range to the same keyspace id, so that
+// a range of ids maps to a range of keyspace ids, and the range predicates
+// on the column only go to the shards with the overlapping keyspace ids.
+//
+// The split_points param is a JSON object mapping each split point, the
+// smallest id of its range, to the hexadecimal keyspace id of the range, e.g.
+// {"1000": "40", "2000": "80", "3000": "c0"}. The ids below the first split
+// point map to the keyspace id 00. The keyspace ids must grow with the split
+// points. The type param sets how the ids compare: as int64, the default, as
+// varbinary, byte by byte like the binary collation, or as varchar, in the
+// collation set by the collation param, e.g. utf8mb4_0900_ai_ci. The column
+// must compare its values the same way, or the range predicates on it can
+// miss the ids of the other shards: a varchar column with a case insensitive
+// collation must use the varchar type with its collation, not varbinary.
+//
+// Adding a split point inside of a range only changes the keyspace ids of
+// the ids from it to the next split point. They can then be moved to a new
+// shard, without moving the other ids.
+type Range struct {
+	name      string
+	varbinary bool
+	// collation is the collation of the varchar ids, and collations.Unknown
+	// for the other types.
+	collation     collations.ID
+	splitPoints   []rangeSplitPoint
+	unknownParams []string
+}
+
+type rangeSplitPoint struct {
+	// id is the comparable encoding of the split point.
+	id         []byte
+	keyspaceID []byte
+}
+
+func init() {
+	Register("range", newRange)
+}
+
+// newRange creates a Range vindex.
+func newRange(name string, params map[string]string) (Vindex, error) {
+	vind := &Range{
+		name:          name,
+		unknownParams: FindUnknownParams(params, rangeParams),
+	}
+
+	collation, hasCollation := params[rangeParamCollation]
+	switch typ := params[rangeParamType]; typ {
+	case "", rangeTypeInt64, rangeTypeVarbinary:
+		if hasCollation {
+			return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "Range: the `collation` param is only supported with the %s type", rangeTypeVarchar)
+		}
+		vind.varbinary = typ == rangeTypeVarbinary
+	case rangeTypeVarchar:
+		if !hasCollation {
+			return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "Range: could not find the `collation` param of the %s type in vschema", rangeTypeVarchar)
+		}
+		vind.collation = collations.MySQL8().LookupByName(collation)
+		if vind.collation == collations.Unknown {
+			return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "Range: unknown collation %q", collation)
+		}
+	default:
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "Range: invalid type %q, must be %s, %s or %s", typ, rangeTypeInt64, rangeTypeVarbinary, rangeTypeVarchar)
+	}
+
+	splitPoints, ok := params[rangeParamSplitPoints]
+	if !ok {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "Range: could not find the `split_points` param in vschema")
+	}
+	var points map[string]string
+	if err := json.Unmarshal([]byte(splitPoints), &points); err != nil {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "Range: invalid split_points: %v", err)
+	}
+	for value, ksid := range points {
+		id, err := vind.encode(sqltypes.NewVarChar(value))
+		if err != nil {
+			return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "Range: invalid split point %q: %v", value, err)
+		}
+		keyspaceID, err := hex.DecodeString(ksid)
+		if err != nil {
+			return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "Range: invalid keyspace id %q of split point %q: %v", ksid, value, err)
+		}
+		vind.splitPoints = append(vind.splitPoints, rangeSplitPoint{id: id, keyspaceID: keyspaceID})
+	}
+	sort.Slice(vind.splitPoints, func(i, j int) bool {
+		return bytes.Compare(vind.splitPoints[i].id, vind.splitPoints[j].id) < 0
+	})
+
+	prev := rangeFirstKeyspaceID
+	for _, point := range vind.splitPoints {
+		if bytes.Compare(point.keyspaceID, prev) <= 0 {
+			return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "Range: the keyspace id %x of a split point must be greater than the keyspace id %x of the previous one", point.keyspaceID, prev)
+		}
+		prev = point.keyspaceID
+	}
+	return vind, nil
+}
+
+// String returns the name of the vindex.
+func (vind *Range) String() string {
+	return vind.name
+}
+
+// Cost returns the cost of this vindex as 1.
+func (*Range) Cost() int {
+	return 1
+}
+
+// IsUnique returns true since the Vindex is unique.
+func (*Range) IsUnique() bool {
+	return true
+}
+
+// NeedsVCursor satisfies the Vindex interface.
+func (*Range) NeedsVCursor() bool {
+	return false
+}
+
+// Verify returns true if ids maps to ksids.
+func (vind *Range) Verify(ctx context.Context, vcursor VCursor, ids []sqltypes.Value, ksids [][]byte) ([]bool, error) {
+	out := make([]bool, 0, len(ids))
+	for i, id := range ids {
+		ksid, err := vind.Hash(id)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, bytes.Equal(ksid, ksids[i]))
+	}
+	return out, nil
+}
+
+// Map can map ids to key.Destination objects.
+func (vind *Range) Map(ctx context.Context, vcursor VCursor, ids []sqltypes.Value) ([]key.Destination, error) {
+	out := make([]key.Destination, 0, len(ids))
+	for _, id := range ids {
+		ksid, err := vind.Hash(id)
+		if err != nil {
+			out = append(out, key.DestinationNone{})
+			continue
+		}
+		out = append(out, key.DestinationKeyspaceID(ksid))
+	}
+	return out, nil
+}
+
+// MapRange implements the RangeMapper interface.
+func (vind *Range) MapRange(ctx context.Context, vcursor VCursor, start, end sqltypes.Value) (key.Destination, error) {
+	keyRange := &topodatapb.KeyRange{}
+	first, last := 0, len(vind.splitPoints)
+	if !start.IsNull() {
+		id, err := vind.encode(start)
+		if err != nil {
+			return key.DestinationAllShards{}, nil
+		}
+		first = vind.rangeOf(id)
+		keyRange.Start = vind.keyspaceID(first)
+	}
+	if !end.IsNull() {
+		id, err := vind.encode(end)
+		if err != nil {
+			return key.DestinationAllShards{}, nil
+		}
+		last = vind.rangeOf(id)
+		if last < len(vind.splitPoints) {
+			keyRange.End = vind.keyspaceID(last + 1)
+		}
+	}
+	if first > last {
+		return key.DestinationNone{}, nil
+	}
+	return key.DestinationKeyRange{KeyRange: keyRange}, nil
+}
+
+// UnknownParams implements the ParamValidating interface.
+func (vind *Range) UnknownParams() []string {
+	return vind.unknownParams
+}
+
+// Hash returns the keyspace id of the range of the id.
+func (vind *Range) Hash(id sqltypes.Value) ([]byte, error) {
+	encoded, err := vind.encode(id)
+	if err != nil {
+		return nil, err
+	}
+	return vind.keyspaceID(vind.rangeOf(encoded)), nil
+}
+
+// rangeOf returns the index of the range of the encoded id: 0 for the ids
+// below the first split point, and i for the ids from the i-th split point.
+func (vind *Range) rangeOf(id []byte) int {
+	return sort.Search(len(vind.splitPoints), func(i int) bool {
+		return bytes.Compare(vind.splitPoints[i].id, id) > 0
+	})
+}
+
+func (vind *Range) keyspaceID(rng int) []byte {
+	if rng == 0 {
+		return rangeFirstKeyspaceID
+	}
+	return vind.splitPoints[rng-1].keyspaceID
+}
+
+// encode returns an encoding of the id whose byte order is the order of the ids.
+// The varchar ids are encoded by their weight string in the collation, so that
+// the ids the collation compares as equal have the same encoding.
+func (vind *Range) encode(id sqltypes.Value) ([]byte, error) {
+	if id.IsNull() {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "Range: cannot map a null id")
+	}
+	if vind.varbinary {
+		return id.ToBytes()
+	}
+	if vind.collation != collations.Unknown {
+		weight, _, err := evalengine.WeightString(nil, id, sqltypes.VarChar, vind.collation, 0, 0, nil, 0)
+		return weight, err
+	}
+	num, err := strconv.ParseInt(id.ToString(), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	var encoded [8]byte
+	binary.BigEndian.PutUint64(encoded[:], uint64(num)^(1<<63))
+	return encoded[:], nil
+}
@@ -0,0 +1,324 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vindexes
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/key"
+	"vitess.io/vitess/go/vt/vterrors"
+
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+func createRangeVindex(t *testing.T, params map[string]string) *Range {
+	t.Helper()
+	vindex, err := CreateVindex("range", "range", params)
+	require.NoError(t, err)
+	return vindex.(*Range)
+}
+
+func rangeCreateVindexTestCase(
+	testName string,
+	vindexParams map[string]string,
+	expectErr error,
+	expectUnknownParams []string,
+) createVindexTestCase {
+	return createVindexTestCase{
+		testName: testName,
+
+		vindexType:   "range",
+		vindexName:   "range",
+		vindexParams: vindexParams,
+
+		expectCost:          1,
+		expectErr:           expectErr,
+		expectIsUnique:      true,
+		expectNeedsVCursor:  false,
+		expectString:        "range",
+		expectUnknownParams: expectUnknownParams,
+	}
+}
+
+func TestRangeCreateVindex(t *testing.T) {
+	cases := []createVindexTestCase{
+		rangeCreateVindexTestCase(
+			"split_points required",
+			nil,
+			vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "Range: could not find the `split_points` param in vschema"),
+			nil,
+		),
+		rangeCreateVindexTestCase(
+			"split_points ok",
+			map[string]string{
+				"split_points": `{"10": "40", "20": "80"}`,
+			},
+			nil,
+			nil,
+		),
+		rangeCreateVindexTestCase(
+			"split_points must be valid json",
+			map[string]string{
+				"split_points": "{]",
+			},
+			errors.New("Range: invalid split_points: invalid character ']' looking for beginning of object key string"),
+			nil,
+		),
+		rangeCreateVindexTestCase(
+			"split points must be ints",
+			map[string]string{
+				"split_points": `{"a": "40"}`,
+			},
+			errors.New(`Range: invalid split point "a": strconv.ParseInt: parsing "a": invalid syntax`),
+			nil,
+		),
+		rangeCreateVindexTestCase(
+			"keyspace ids must be hex",
+			map[string]string{
+				"split_points": `{"10": "4g"}`,
+			},
+			errors.New(`Range: invalid keyspace id "4g" of split point "10": encoding/hex: invalid byte: U+0067 'g'`),
+			nil,
+		),
+		rangeCreateVindexTestCase(
+			"keyspace ids must grow with the split points",
+			map[string]string{
+				"split_points": `{"10": "80", "20": "40"}`,
+			},
+			errors.New("Range: the keyspace id 40 of a split point must be greater than the keyspace id 80 of the previous one"),
+			nil,
+		),
+		rangeCreateVindexTestCase(
+			"varbinary type ok",
+			map[string]string{
+				"split_points": `{"m": "80"}`,
+				"type":         "varbinary",
+			},
+			nil,
+			nil,
+		),
+		rangeCreateVindexTestCase(
+			"varchar type ok",
+			map[string]string{
+				"split_points": `{"m": "80"}`,
+				"type":         "varchar",
+				"collation":    "utf8mb4_0900_ai_ci",
+			},
+			nil,
+			nil,
+		),
+		rangeCreateVindexTestCase(
+			"varchar type requires a collation",
+			map[string]string{
+				"split_points": `{"m": "80"}`,
+				"type":         "varchar",
+			},
+			errors.New("Range: could not find the `collation` param of the varchar type in vschema"),
+			nil,
+		),
+		rangeCreateVindexTestCase(
+			"collation must be known",
+			map[string]string{
+				"split_points": `{"m": "80"}`,
+				"type":         "varchar",
+				"collation":    "utf8mb4_nope",
+			},
+			errors.New(`Range: unknown collation "utf8mb4_nope"`),
+			nil,
+		),
+		rangeCreateVindexTestCase(
+			"collation only with varchar type",
+			map[string]string{
+				"split_points": `{"m": "80"}`,
+				"type":         "varbinary",
+				"collation":    "utf8mb4_0900_ai_ci",
+			},
+			errors.New("Range: the `collation` param is only supported with the varchar type"),
+			nil,
+		),
+		rangeCreateVindexTestCase(
+			"type must be valid",
+			map[string]string{
+				"split_points": `{}`,
+				"type":         "float",
+			},
+			errors.New(`Range: invalid type "float", must be int64, varbinary or varchar`),
+			nil,
+		),
+		rangeCreateVindexTestCase(
+			"unknown params",
+			map[string]string{
+				"split_points": `{}`,
+				"hello":        "world",
+			},
+			nil,
+			[]string{"hello"},
+		),
+	}
+
+	testCreateVindexes(t, cases)
+}
+
+func TestRangeMap(t *testing.T) {
+	vind := createRangeVindex(t, map[string]string{
+		"split_points": `{"-10": "40", "20": "80", "1000": "c0"}`,
+	})
+	got, err := vind.Map(context.Background(), nil, []sqltypes.Value{
+		sqltypes.NewInt64(-100),
+		sqltypes.NewInt64(-10),
+		sqltypes.NewInt64(19),
+		sqltypes.NewInt64(20),
+		sqltypes.NewInt64(999),
+		sqltypes.NewInt64(1 << 40),
+		sqltypes.NewVarChar("abc"),
+		sqltypes.NULL,
+	})
+	require.NoError(t, err)
+	want := []key.Destination{
+		key.DestinationKeyspaceID([]byte{0x00}),
+		key.DestinationKeyspaceID([]byte{0x40}),
+		key.DestinationKeyspaceID([]byte{0x40}),
+		key.DestinationKeyspaceID([]byte{0x80}),
+		key.DestinationKeyspaceID([]byte{0x80}),
+		key.DestinationKeyspaceID([]byte{0xc0}),
+		key.DestinationNone{},
+		key.DestinationNone{},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestRangeMapVarbinary(t *testing.T) {
+	vind := createRangeVindex(t, map[string]string{
+		"split_points": `{"2024-01-01": "80", "2024-07-01": "c0"}`,
+		"type":         "varbinary",
+	})
+	got, err := vind.Map(context.Background(), nil, []sqltypes.Value{
+		sqltypes.NewVarChar("2023-12-31"),
+		sqltypes.NewVarChar("2024-01-01"),
+		sqltypes.NewVarChar("2024-06-30 23:59:59"),
+		sqltypes.NewVarChar("2025-01-01"),
+	})
+	require.NoError(t, err)
+	want := []key.Destination{
+		key.DestinationKeyspaceID([]byte{0x00}),
+		key.DestinationKeyspaceID([]byte{0x80}),
+		key.DestinationKeyspaceID([]byte{0x80}),
+		key.DestinationKeyspaceID([]byte{0xc0}),
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestRangeMapVarchar(t *testing.T) {
+	vind := createRangeVindex(t, map[string]string{
+		"split_points": `{"M": "80"}`,
+		"type":         "varchar",
+		"collation":    "utf8mb4_0900_ai_ci",
+	})
+	// The ids compare in the collation, so the lowercase ids sort with the
+	// uppercase ones instead of after all of them.
+	got, err := vind.Map(context.Background(), nil, []sqltypes.Value{
+		sqltypes.NewVarChar("b"),
+		sqltypes.NewVarChar("L"),
+		sqltypes.NewVarChar("m"),
+		sqltypes.NewVarChar("M"),
+		sqltypes.NewVarChar("z"),
+	})
+	require.NoError(t, err)
+	want := []key.Destination{
+		key.DestinationKeyspaceID([]byte{0x00}),
+		key.DestinationKeyspaceID([]byte{0x00}),
+		key.DestinationKeyspaceID([]byte{0x80}),
+		key.DestinationKeyspaceID([]byte{0x80}),
+		key.DestinationKeyspaceID([]byte{0x80}),
+	}
+	assert.Equal(t, want, got)
+
+	dest, err := vind.MapRange(context.Background(), nil, sqltypes.NewVarChar("a"), sqltypes.NewVarChar("l"))
+	require.NoError(t, err)
+	assert.Equal(t, key.DestinationKeyRange{KeyRange: &topodatapb.KeyRange{Start: []byte{0x00}, End: []byte{0x80}}}, dest)
+}
+
+func TestRangeVerify(t *testing.T) {
+	vind := createRangeVindex(t, map[string]string{
+		"split_points": `{"10": "40", "20": "80"}`,
+	})
+	got, err := vind.Verify(context.Background(), nil,
+		[]sqltypes.Value{sqltypes.NewInt64(5), sqltypes.NewInt64(15), sqltypes.NewInt64(25)},
+		[][]byte{{0x00}, {0x80}, {0x80}},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, false, true}, got)
+
+	_, err = vind.Verify(context.Background(), nil, []sqltypes.Value{sqltypes.NewVarChar("abc")}, [][]byte{{0x00}})
+	require.ErrorContains(t, err, "invalid syntax")
+}
+
+func TestRangeMapRange(t *testing.T) {
+	vind := createRangeVindex(t, map[string]string{
+		"split_points": `{"10": "40", "20": "80", "30": "c0"}`,
+	})
+	tests := []struct {
+		start, end sqltypes.Value
+		want       key.Destination
+	}{{
+		start: sqltypes.NewInt64(12),
+		end:   sqltypes.NewInt64(25),
+		want:  key.DestinationKeyRange{KeyRange: &topodatapb.KeyRange{Start: []byte{0x40}, End: []byte{0xc0}}},
+	}, {
+		start: sqltypes.NewInt64(12),
+		end:   sqltypes.NewInt64(18),
+		want:  key.DestinationKeyRange{KeyRange: &topodatapb.KeyRange{Start: []byte{0x40}, End: []byte{0x80}}},
+	}, {
+		start: sqltypes.NULL,
+		end:   sqltypes.NewInt64(5),
+		want:  key.DestinationKeyRange{KeyRange: &topodatapb.KeyRange{End: []byte{0x40}}},
+	}, {
+		start: sqltypes.NewInt64(25),
+		end:   sqltypes.NULL,
+		want:  key.DestinationKeyRange{KeyRange: &topodatapb.KeyRange{Start: []byte{0x80}}},
+	}, {
+		start: sqltypes.NewInt64(15),
+		end:   sqltypes.NewInt64(35),
+		want:  key.DestinationKeyRange{KeyRange: &topodatapb.KeyRange{Start: []byte{0x40}}},
+	}, {
+		start: sqltypes.NULL,
+		end:   sqltypes.NULL,
+		want:  key.DestinationKeyRange{KeyRange: &topodatapb.KeyRange{}},
+	}, {
+		start: sqltypes.NewInt64(25),
+		end:   sqltypes.NewInt64(5),
+		want:  key.DestinationNone{},
+	}, {
+		start: sqltypes.NewVarChar("abc"),
+		end:   sqltypes.NewInt64(5),
+		want:  key.DestinationAllShards{},
+	}}
+	for _, tc := range tests {
+		t.Run(tc.start.String()+"-"+tc.end.String(), func(t *testing.T) {
+			got, err := vind.MapRange(context.Background(), nil, tc.start, tc.end)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
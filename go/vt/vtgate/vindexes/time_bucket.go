@@ -0,0 +1,194 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vindexes
+
+import (
+	"context"
+	"time"
+
+	"vitess.io/vitess/go/mysql/datetime"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/key"
+	"vitess.io/vitess/go/vt/vterrors"
+
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+const (
+	paramBucket = "bucket"
+
+	bucketDay   = "day"
+	bucketWeek  = "week"
+	bucketMonth = "month"
+
+	defaultTimeBucketColumnCount = "2"
+)
+
+var (
+	_ MultiColumn = (*TimeBucket)(nil)
+	_ RangeMapper = (*TimeBucket)(nil)
+	_ Hashing     = (*timeBucketHash)(nil)
+)
+
+// TimeBucket is a multicol vindex whose first column is a DATETIME, TIMESTAMP
+// or DATE column. The first bytes of its keyspace ids are the number of the
+// day, week or month of the time since 1970-01-01, and the next bytes are
+// the hashes of the other columns, like for multicol. So the rows of a time
+// period go to the same shards, and a range of times maps to a keyspace range.
+//
+// The bucket param sets the time period: day, the default, week, which starts
+// on Mondays, or month. The column_count, column_bytes and column_vindex params
+// are the multicol ones, where column_count defaults to 2 and column_vindex only
+// lists the vindexes of the columns after the time column, e.g.
+//
+//	"vindexes": {
+//	  "events_by_day": {
+//	    "type": "time_bucket",
+//	    "params": {
+//	      "bucket": "day",
+//	      "column_bytes": "2,6",
+//	      "column_vindex": "xxhash"
+//	    }
+//	  }
+//	}
+//
+// The times before 1970-01-01 map to the first bucket, and the times after the
+// last bucket the column bytes can hold map to the last one.
+type TimeBucket struct {
+	*MultiCol
+	timeHash *timeBucketHash
+}
+
+// timeBucketHash maps the times to their big-endian bucket number, on the
+// column bytes of the time column.
+type timeBucketHash struct {
+	bucket string
+	width  int
+}
+
+// newTimeBucket creates a new TimeBucket.
+func newTimeBucket(name string, m map[string]string) (Vindex, error) {
+	timeHash := &timeBucketHash{bucket: bucketDay}
+	params := make(map[string]string, len(m)+1)
+	for k, v := range m {
+		if k == paramBucket {
+			continue
+		}
+		params[k] = v
+	}
+	switch bucket := m[paramBucket]; bucket {
+	case "":
+	case bucketDay, bucketWeek, bucketMonth:
+		timeHash.bucket = bucket
+	default:
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "invalid bucket %q to time_bucket vindex %s, must be %s, %s or %s", bucket, name, bucketDay, bucketWeek, bucketMonth)
+	}
+	if _, ok := params[paramColumnCount]; !ok {
+		params[paramColumnCount] = defaultTimeBucketColumnCount
+	}
+	// The time column's vindex is replaced below.
+	params[paramColumnVindex] = "," + params[paramColumnVindex]
+
+	vindex, err := newMultiCol(name, params)
+	if err != nil {
+		return nil, err
+	}
+	multiCol := vindex.(*MultiCol)
+	timeHash.width = multiCol.columnBytes[0]
+	multiCol.columnVdx[0] = timeHash
+	return &TimeBucket{
+		MultiCol: multiCol,
+		timeHash: timeHash,
+	}, nil
+}
+
+// MapRange implements the RangeMapper interface for the times of the first column.
+func (tb *TimeBucket) MapRange(_ context.Context, _ VCursor, start, end sqltypes.Value) (key.Destination, error) {
+	keyRange := &topodatapb.KeyRange{}
+	if !start.IsNull() {
+		ksid, err := tb.timeHash.Hash(start)
+		if err != nil {
+			return key.DestinationAllShards{}, nil
+		}
+		keyRange.Start = ksid
+	}
+	if !end.IsNull() {
+		ksid, err := tb.timeHash.Hash(end)
+		if err != nil {
+			return key.DestinationAllShards{}, nil
+		}
+		if !start.IsNull() && key.Compare(keyRange.Start, ksid) > 0 {
+			return key.DestinationNone{}, nil
+		}
+		keyRange.End = addOne(ksid)
+	}
+	return key.DestinationKeyRange{KeyRange: keyRange}, nil
+}
+
+// Hash returns the bucket number of the time.
+func (th *timeBucketHash) Hash(id sqltypes.Value) ([]byte, error) {
+	date, ok := parseTimeBucketDate(id)
+	if !ok {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "time_bucket: cannot parse %s as a time", id.String())
+	}
+
+	var bucket int64
+	switch th.bucket {
+	case bucketMonth:
+		bucket = int64(date.Year()-1970)*12 + int64(date.Month()-1)
+	default:
+		days := time.Date(date.Year(), time.Month(date.Month()), date.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400
+		bucket = days
+		if th.bucket == bucketWeek {
+			// 1970-01-01 is a Thursday, so the first week started 3 days before.
+			bucket = (days + 3) / 7
+		}
+	}
+
+	ksid := make([]byte, th.width)
+	if bucket < 0 {
+		return ksid, nil
+	}
+	for i := th.width - 1; i >= 0; i-- {
+		ksid[i] = byte(bucket)
+		bucket >>= 8
+	}
+	if bucket > 0 {
+		// The bucket doesn't fit in the column bytes.
+		for i := range ksid {
+			ksid[i] = 0xff
+		}
+	}
+	return ksid, nil
+}
+
+func parseTimeBucketDate(id sqltypes.Value) (datetime.Date, bool) {
+	if id.IsNull() {
+		return datetime.Date{}, false
+	}
+	s := id.ToString()
+	if dt, _, ok := datetime.ParseDateTime(s, -1); ok {
+		return dt.Date, !dt.Date.IsZero()
+	}
+	d, ok := datetime.ParseDate(s)
+	return d, ok && !d.IsZero()
+}
+
+func init() {
+	Register("time_bucket", newTimeBucket)
+}
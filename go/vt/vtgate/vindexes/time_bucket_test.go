@@ -0,0 +1,215 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vindexes
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/key"
+
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+)
+
+func timeBucketCreateVindexTestCase(
+	testName string,
+	vindexParams map[string]string,
+	expectErr error,
+) createVindexTestCase {
+	return createVindexTestCase{
+		testName: testName,
+
+		vindexType:   "time_bucket",
+		vindexName:   "time_bucket",
+		vindexParams: vindexParams,
+
+		expectCost:         2,
+		expectErr:          expectErr,
+		expectIsUnique:     true,
+		expectNeedsVCursor: false,
+		expectString:       "time_bucket",
+	}
+}
+
+func TestTimeBucketCreateVindex(t *testing.T) {
+	cases := []createVindexTestCase{
+		timeBucketCreateVindexTestCase(
+			"no params",
+			nil,
+			nil,
+		),
+		timeBucketCreateVindexTestCase(
+			"all params",
+			map[string]string{
+				"bucket":        "month",
+				"column_count":  "2",
+				"column_bytes":  "2,6",
+				"column_vindex": "xxhash",
+			},
+			nil,
+		),
+		timeBucketCreateVindexTestCase(
+			"invalid bucket",
+			map[string]string{
+				"bucket": "year",
+			},
+			errors.New(`invalid bucket "year" to time_bucket vindex time_bucket, must be day, week or month`),
+		),
+		timeBucketCreateVindexTestCase(
+			"too many column vindexes",
+			map[string]string{
+				"column_vindex": "hash,hash",
+			},
+			errors.New("number of vindex function provided are more than column count in the parameter 'column_vindex'"),
+		),
+	}
+
+	testCreateVindexes(t, cases)
+}
+
+func TestTimeBucketMap(t *testing.T) {
+	tests := []struct {
+		bucket string
+		ids    [][]sqltypes.Value
+		want   []key.Destination
+	}{{
+		bucket: "day",
+		ids: [][]sqltypes.Value{{
+			sqltypes.NewDatetime("2024-01-15 10:20:30"), sqltypes.NewInt64(1),
+		}, {
+			sqltypes.NewVarChar("2024-01-15"), sqltypes.NewInt64(1),
+		}, {
+			sqltypes.NewDate("1969-12-31"), sqltypes.NewInt64(1),
+		}, {
+			// only the time column provided, partial column for key range mapping.
+			sqltypes.NewTimestamp("2024-01-15 23:59:59"),
+		}, {
+			sqltypes.NewVarChar("not a time"), sqltypes.NewInt64(1),
+		}},
+		want: []key.Destination{
+			key.DestinationKeyspaceID("\x4d\x19\x16\x6b\x40\xb4\x4a\xba"),
+			key.DestinationKeyspaceID("\x4d\x19\x16\x6b\x40\xb4\x4a\xba"),
+			key.DestinationKeyspaceID("\x00\x00\x16\x6b\x40\xb4\x4a\xba"),
+			key.DestinationKeyRange{KeyRange: &topodatapb.KeyRange{Start: []byte("\x4d\x19"), End: []byte("\x4d\x1a")}},
+			key.DestinationNone{},
+		},
+	}, {
+		bucket: "week",
+		ids: [][]sqltypes.Value{{
+			// a Sunday
+			sqltypes.NewDate("2024-01-14"), sqltypes.NewInt64(1),
+		}, {
+			// a Monday
+			sqltypes.NewDate("2024-01-15"), sqltypes.NewInt64(1),
+		}},
+		want: []key.Destination{
+			key.DestinationKeyspaceID("\x0b\x03\x16\x6b\x40\xb4\x4a\xba"),
+			key.DestinationKeyspaceID("\x0b\x04\x16\x6b\x40\xb4\x4a\xba"),
+		},
+	}, {
+		bucket: "month",
+		ids: [][]sqltypes.Value{{
+			sqltypes.NewDate("2024-01-31"), sqltypes.NewInt64(1),
+		}, {
+			sqltypes.NewDate("2024-02-01"), sqltypes.NewInt64(1),
+		}},
+		want: []key.Destination{
+			key.DestinationKeyspaceID("\x02\x88\x16\x6b\x40\xb4\x4a\xba"),
+			key.DestinationKeyspaceID("\x02\x89\x16\x6b\x40\xb4\x4a\xba"),
+		},
+	}}
+	for _, tc := range tests {
+		t.Run(tc.bucket, func(t *testing.T) {
+			vindex, err := CreateVindex("time_bucket", "time_bucket", map[string]string{
+				"bucket":       tc.bucket,
+				"column_bytes": "2,6",
+			})
+			require.NoError(t, err)
+			got, err := vindex.(MultiColumn).Map(context.Background(), nil, tc.ids)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestTimeBucketVerify(t *testing.T) {
+	vindex, err := CreateVindex("time_bucket", "time_bucket", map[string]string{
+		"column_bytes": "2,6",
+	})
+	require.NoError(t, err)
+	got, err := vindex.(MultiColumn).Verify(context.Background(), nil, [][]sqltypes.Value{{
+		sqltypes.NewDatetime("2024-01-15 10:20:30"), sqltypes.NewInt64(1),
+	}, {
+		sqltypes.NewDatetime("2024-01-16 10:20:30"), sqltypes.NewInt64(1),
+	}}, [][]byte{
+		[]byte("\x4d\x19\x16\x6b\x40\xb4\x4a\xba"),
+		[]byte("\x4d\x19\x16\x6b\x40\xb4\x4a\xba"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, false}, got)
+}
+
+func TestTimeBucketMapRange(t *testing.T) {
+	vindex, err := CreateVindex("time_bucket", "time_bucket", map[string]string{
+		"column_bytes": "2,6",
+	})
+	require.NoError(t, err)
+	tb := vindex.(*TimeBucket)
+
+	tests := []struct {
+		name       string
+		start, end sqltypes.Value
+		want       key.Destination
+	}{{
+		name:  "closed range",
+		start: sqltypes.NewVarChar("2024-01-15 00:00:00"),
+		end:   sqltypes.NewVarChar("2024-01-16 23:59:59"),
+		want:  key.DestinationKeyRange{KeyRange: &topodatapb.KeyRange{Start: []byte("\x4d\x19"), End: []byte("\x4d\x1b")}},
+	}, {
+		name:  "open start",
+		start: sqltypes.NULL,
+		end:   sqltypes.NewDate("2024-01-15"),
+		want:  key.DestinationKeyRange{KeyRange: &topodatapb.KeyRange{End: []byte("\x4d\x1a")}},
+	}, {
+		name:  "open end",
+		start: sqltypes.NewDate("2024-01-15"),
+		end:   sqltypes.NULL,
+		want:  key.DestinationKeyRange{KeyRange: &topodatapb.KeyRange{Start: []byte("\x4d\x19")}},
+	}, {
+		name:  "empty range",
+		start: sqltypes.NewDate("2024-01-16"),
+		end:   sqltypes.NewDate("2024-01-15"),
+		want:  key.DestinationNone{},
+	}, {
+		name:  "not a time",
+		start: sqltypes.NewInt64(1),
+		end:   sqltypes.NewDate("2024-01-15"),
+		want:  key.DestinationAllShards{},
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tb.MapRange(context.Background(), nil, tc.start, tc.end)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
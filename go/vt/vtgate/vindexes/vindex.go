@@ -43,7 +43,11 @@ type (
 		Execute(ctx context.Context, method string, query string, bindvars map[string]*querypb.BindVariable, rollbackOnError bool, co vtgatepb.CommitOrder) (*sqltypes.Result, error)
 		ExecuteKeyspaceID(ctx context.Context, keyspace string, ksid []byte, query string, bindVars map[string]*querypb.BindVariable, rollbackOnError, autocommit bool) (*sqltypes.Result, error)
 		InTransactionAndIsDML() bool
+		InTransaction() bool
 		LookupRowLockShardSession() vtgatepb.CommitOrder
+		// AddLookupCacheInvalidation records the ids written to the lookup table
+		// in the transaction, to invalidate them in its cache when it commits.
+		AddLookupCacheInvalidation(table, column string, ids []sqltypes.Value)
 		ConnCollation() collations.ID
 		Environment() *vtenv.Environment
 	}
@@ -138,6 +142,18 @@ type (
 		PrefixVindex() SingleColumn
 	}
 
+	// A RangeMapper vindex is one that preserves the order of the ids in the
+	// keyspace ids, so that a range of ids maps to a keyspace range. It's being
+	// used to reduce the fan out for range expressions, like 'BETWEEN', '<' and '>'.
+	// The ids of a MultiColumn vindex are the values of its first column.
+	RangeMapper interface {
+		Vindex
+		// MapRange returns the destination of the ids from start to end,
+		// both included. A null start or end leaves the range open on
+		// that side.
+		MapRange(ctx context.Context, vcursor VCursor, start, end sqltypes.Value) (key.Destination, error)
+	}
+
 	// A Lookup vindex is one that needs to lookup
 	// a previously stored map to compute the keyspace
 	// id from an id. This means that the creation of
@@ -167,6 +183,13 @@ type (
 		AutoCommitEnabled() bool
 	}
 
+	// LookupCaching is implemented by the lookup vindexes that can cache the
+	// rows of their lookup query, with the cache_size and cache_ttl params.
+	LookupCaching interface {
+		// LookupCache returns the cache of the vindex, or nil if it has none.
+		LookupCache() *LookupCache
+	}
+
 	// LookupBackfill interfaces all lookup vindexes that can backfill rows, such as LookupUnique.
 	LookupBackfill interface {
 		IsBackfilling() bool
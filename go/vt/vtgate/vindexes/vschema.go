@@ -75,6 +75,7 @@ type VSchema struct {
 	Keyspaces            map[string]*KeyspaceSchema `json:"keyspaces"`
 	ShardRoutingRules    map[string]string          `json:"shard_routing_rules"`
 	KeyspaceRoutingRules map[string]string          `json:"keyspace_routing_rules"`
+	QueryRules           *vschemapb.QueryRules      `json:"query_rules,omitempty"`
 	// created is the time when the VSchema object was created. Used to detect if a cached
 	// copy of the vschema is stale.
 	created time.Time
@@ -111,6 +112,12 @@ type Table struct {
 	Columns                 []Column               `json:"columns,omitempty"`
 	Pinned                  []byte                 `json:"pinned,omitempty"`
 	ColumnListAuthoritative bool                   `json:"column_list_authoritative,omitempty"`
+	// AllowPrimaryVindexUpdate is set when the primary vindex columns can be updated.
+	// The rows that no longer map to their shard are then moved to their new shard.
+	AllowPrimaryVindexUpdate bool `json:"allow_primary_vindex_update,omitempty"`
+	// ResultCacheTTL is how long vtgate caches the results of the read-only queries
+	// on the table. Results are not cached when it is 0.
+	ResultCacheTTL time.Duration `json:"result_cache_ttl,omitempty"`
 	// ReferencedBy is an inverse mapping of tables in other keyspaces that
 	// reference this table via Source.
 	//
@@ -199,6 +206,8 @@ type Column struct {
 	Nullable  bool  `json:"nullable,omitempty"`
 	// Values contains the list of values for enum and set types.
 	Values []string `json:"values,omitempty"`
+	// Generated marks this as a column whose value is generated by MySQL, so it cannot be inserted.
+	Generated bool `json:"generated,omitempty"`
 }
 
 // MarshalJSON returns a JSON representation of Column.
@@ -212,6 +221,7 @@ func (col *Column) MarshalJSON() ([]byte, error) {
 		Scale     int32    `json:"scale,omitempty"`
 		Nullable  bool     `json:"nullable,omitempty"`
 		Values    []string `json:"values,omitempty"`
+		Generated bool     `json:"generated,omitempty"`
 	}{
 		Name:      col.Name.String(),
 		Type:      querypb.Type_name[int32(col.Type)],
@@ -220,6 +230,7 @@ func (col *Column) MarshalJSON() ([]byte, error) {
 		Scale:     col.Scale,
 		Nullable:  col.Nullable,
 		Values:    col.Values,
+		Generated: col.Generated,
 	}
 	if col.Default != nil {
 		cj.Default = sqlparser.String(col.Default)
@@ -339,6 +350,7 @@ func BuildVSchema(source *vschemapb.SrvVSchema, parser *sqlparser.Parser) (vsche
 	buildRoutingRule(source, vschema, parser)
 	buildShardRoutingRule(source, vschema)
 	buildKeyspaceRoutingRule(source, vschema)
+	vschema.QueryRules = source.QueryRules
 	// Resolve auto-increments after routing rules are built since sequence tables also obey routing rules.
 	resolveAutoIncrement(source, vschema, parser)
 	return vschema
@@ -606,9 +618,10 @@ func buildTables(ks *vschemapb.Keyspace, vschema *VSchema, ksvschema *KeyspaceSc
 	}
 	for tname, table := range ks.Tables {
 		t := &Table{
-			Name:                    sqlparser.NewIdentifierCS(tname),
-			Keyspace:                keyspace,
-			ColumnListAuthoritative: table.ColumnListAuthoritative,
+			Name:                     sqlparser.NewIdentifierCS(tname),
+			Keyspace:                 keyspace,
+			ColumnListAuthoritative:  table.ColumnListAuthoritative,
+			AllowPrimaryVindexUpdate: table.AllowPrimaryVindexUpdate,
 		}
 		switch table.Type {
 		case "":
@@ -655,6 +668,18 @@ func buildTables(ks *vschemapb.Keyspace, vschema *VSchema, ksvschema *KeyspaceSc
 			}
 			t.Pinned = decoded
 		}
+		if table.ResultCacheTtl != "" {
+			ttl, err := time.ParseDuration(table.ResultCacheTtl)
+			if err != nil || ttl < 0 {
+				return vterrors.Errorf(
+					vtrpcpb.Code_INVALID_ARGUMENT,
+					"invalid result cache ttl %q for table: %s",
+					table.ResultCacheTtl,
+					tname,
+				)
+			}
+			t.ResultCacheTTL = ttl
+		}
 
 		// If keyspace is sharded, then any table that's not a reference or pinned must have vindexes.
 		if keyspace.Sharded && t.Type != TypeReference && table.Pinned == "" && len(table.ColumnVindexes) == 0 {
@@ -701,6 +726,7 @@ func buildTables(ks *vschemapb.Keyspace, vschema *VSchema, ksvschema *KeyspaceSc
 				Scale:         col.Scale,
 				Nullable:      nullable,
 				Values:        col.Values,
+				Generated:     col.Generated,
 			})
 		}
 
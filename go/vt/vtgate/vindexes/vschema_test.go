@@ -24,6 +24,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -580,6 +581,67 @@ func TestVSchemaColumnListAuthoritative(t *testing.T) {
 	assertColumn(t, t1.Columns[1], "c2", sqltypes.VarChar)
 }
 
+func TestVSchemaAllowPrimaryVindexUpdate(t *testing.T) {
+	good := vschemapb.SrvVSchema{
+		Keyspaces: map[string]*vschemapb.Keyspace{
+			"sharded": {
+				Sharded: true,
+				Vindexes: map[string]*vschemapb.Vindex{
+					"stfu1": {
+						Type: "stfu"}},
+				Tables: map[string]*vschemapb.Table{
+					"t1": {
+						ColumnVindexes: []*vschemapb.ColumnVindex{{
+							Column: "c1",
+							Name:   "stfu1"}},
+						AllowPrimaryVindexUpdate: true},
+					"t2": {
+						ColumnVindexes: []*vschemapb.ColumnVindex{{
+							Column: "c1",
+							Name:   "stfu1"}}}}}}}
+
+	got := BuildVSchema(&good, sqlparser.NewTestParser())
+
+	t1, err := got.FindTable("sharded", "t1")
+	require.NoError(t, err)
+	assert.True(t, t1.AllowPrimaryVindexUpdate)
+	t2, err := got.FindTable("sharded", "t2")
+	require.NoError(t, err)
+	assert.False(t, t2.AllowPrimaryVindexUpdate)
+}
+
+func TestVSchemaResultCacheTTL(t *testing.T) {
+	good := vschemapb.SrvVSchema{
+		Keyspaces: map[string]*vschemapb.Keyspace{
+			"unsharded": {
+				Tables: map[string]*vschemapb.Table{
+					"t1": {
+						ResultCacheTtl: "1m30s"},
+					"t2": {}}}}}
+
+	got := BuildVSchema(&good, sqlparser.NewTestParser())
+	require.NoError(t, got.Keyspaces["unsharded"].Error)
+
+	t1, err := got.FindTable("unsharded", "t1")
+	require.NoError(t, err)
+	assert.Equal(t, 90*time.Second, t1.ResultCacheTTL)
+	t2, err := got.FindTable("unsharded", "t2")
+	require.NoError(t, err)
+	assert.Zero(t, t2.ResultCacheTTL)
+
+	for _, ttl := range []string{"30", "-1s", "a minute"} {
+		bad := vschemapb.SrvVSchema{
+			Keyspaces: map[string]*vschemapb.Keyspace{
+				"unsharded": {
+					Tables: map[string]*vschemapb.Table{
+						"t1": {
+							ResultCacheTtl: ttl}}}}}
+
+		got := BuildVSchema(&bad, sqlparser.NewTestParser())
+		assert.EqualError(t, got.Keyspaces["unsharded"].Error, fmt.Sprintf("invalid result cache ttl %q for table: t1", ttl))
+	}
+}
+
 func TestVSchemaColumnsFail(t *testing.T) {
 	good := vschemapb.SrvVSchema{
 		Keyspaces: map[string]*vschemapb.Keyspace{
@@ -73,6 +73,15 @@ var (
 	maxPayloadSize  int
 	warnPayloadSize int
 
+	cteMaxRecursionDepth = 1000
+
+	maxQueryMemory      int64
+	maxQueryFetchedRows int
+
+	spillThreshold    int64
+	spillDirectory    string
+	spillNonStreaming bool
+
 	noScatter          bool
 	enableShardRouting bool
 
@@ -117,6 +126,23 @@ var (
 	warmingReadsPercent      = 0
 	warmingReadsQueryTimeout = 5 * time.Second
 	warmingReadsConcurrency  = 500
+
+	// plan cache snapshot flags
+	planCacheSnapshotFile     string
+	planCacheSnapshotInterval = time.Minute
+	planCacheSnapshotSize     = 1000
+	planCacheWarmupTimeout    = 30 * time.Second
+
+	// result cache flags
+	resultCacheMemory              int64
+	resultCacheVStreamInvalidation bool
+
+	// lookup vindex cache flags
+	lookupVindexCacheVStreamInvalidation bool
+
+	// query quota flags
+	queryQuotasFile        string
+	queryQuotaQueueTimeout = time.Second
 )
 
 func registerFlags(fs *pflag.FlagSet) {
@@ -127,6 +153,12 @@ func registerFlags(fs *pflag.FlagSet) {
 	fs.IntVar(&streamBufferSize, "stream_buffer_size", streamBufferSize, "the number of bytes sent from vtgate for each stream call. It's recommended to keep this value in sync with vttablet's query-server-config-stream-buffer-size.")
 	fs.Int64Var(&queryPlanCacheMemory, "gate_query_cache_memory", queryPlanCacheMemory, "gate server query cache size in bytes, maximum amount of memory to be cached. vtgate analyzes every incoming query and generate a query plan, these plans are being cached in a lru cache. This config controls the capacity of the lru cache.")
 	fs.IntVar(&maxMemoryRows, "max_memory_rows", maxMemoryRows, "Maximum number of rows that will be held in memory for intermediate results as well as the final result.")
+	fs.Int64Var(&maxQueryMemory, "max-query-memory", maxQueryMemory, "Maximum memory in bytes a query can use in vtgate to hold the rows of intermediate results, e.g. to sort, join, aggregate or deduplicate them. A query going over it is aborted. Can be overridden by comment directive (MAX_MEMORY). 0 means no limit")
+	fs.IntVar(&maxQueryFetchedRows, "max-query-fetched-rows", maxQueryFetchedRows, "Maximum number of rows a query can fetch from the tablets. A query going over it is aborted. 0 means no limit")
+	fs.Int64Var(&spillThreshold, "spill-to-disk-threshold", spillThreshold, "Memory in bytes a sort, hash join or distinct in vtgate can hold before it spills its rows to temporary files. 0 means rows are never spilled")
+	fs.StringVar(&spillDirectory, "spill-to-disk-dir", spillDirectory, "Directory rows are spilled to by --spill-to-disk-threshold. Defaults to the temporary directory of the system")
+	fs.BoolVar(&spillNonStreaming, "spill-to-disk-non-streaming", spillNonStreaming, "Also spill the rows of the queries that are not streamed, e.g. without workload = 'olap', by streaming the input of their sorts, hash joins, distincts and aggregations. Otherwise only the streamed queries spill")
+	fs.IntVar(&cteMaxRecursionDepth, "cte-max-recursion-depth", cteMaxRecursionDepth, "Maximum number of iterations a recursive common table expression evaluated by vtgate is allowed to run for.")
 	fs.IntVar(&warnMemoryRows, "warn_memory_rows", warnMemoryRows, "Warning threshold for in-memory results. A row count higher than this amount will cause the VtGateWarnings.ResultsExceeded counter to be incremented.")
 	fs.StringVar(&defaultDDLStrategy, "ddl_strategy", defaultDDLStrategy, "Set default strategy for DDL statements. Override with @@ddl_strategy session variable")
 	fs.StringVar(&dbDDLPlugin, "dbddl_plugin", dbDDLPlugin, "controls how to handle CREATE/DROP DATABASE. use it if you are using your own database provisioning service")
@@ -154,6 +186,15 @@ func registerFlags(fs *pflag.FlagSet) {
 	fs.IntVar(&warmingReadsPercent, "warming-reads-percent", 0, "Percentage of reads on the primary to forward to replicas. Useful for keeping buffer pools warm")
 	fs.IntVar(&warmingReadsConcurrency, "warming-reads-concurrency", 500, "Number of concurrent warming reads allowed")
 	fs.DurationVar(&warmingReadsQueryTimeout, "warming-reads-query-timeout", 5*time.Second, "Timeout of warming read queries")
+	fs.StringVar(&planCacheSnapshotFile, "plan-cache-snapshot-file", planCacheSnapshotFile, "Local file the most executed plans of the query plan cache are periodically saved to, and planned again from when vtgate starts, before it reports itself healthy")
+	fs.DurationVar(&planCacheSnapshotInterval, "plan-cache-snapshot-interval", planCacheSnapshotInterval, "How often the query plan cache is saved to --plan-cache-snapshot-file")
+	fs.IntVar(&planCacheSnapshotSize, "plan-cache-snapshot-size", planCacheSnapshotSize, "Maximum number of plans saved to --plan-cache-snapshot-file")
+	fs.DurationVar(&planCacheWarmupTimeout, "plan-cache-warmup-timeout", planCacheWarmupTimeout, "Maximum time spent planning the queries of --plan-cache-snapshot-file when vtgate starts")
+	fs.Int64Var(&resultCacheMemory, "result-cache-memory", resultCacheMemory, "Maximum memory in bytes used to cache the results of the read-only queries on the tables with a result_cache_ttl in their VSchema, or with a RESULT_CACHE_TTL comment directive. 0 disables the result cache")
+	fs.BoolVar(&resultCacheVStreamInvalidation, "result-cache-vstream-invalidation", resultCacheVStreamInvalidation, "Invalidate the cached results of the tables with a result_cache_ttl in their VSchema as soon as their rows change, by streaming their row events from the primary tablets")
+	fs.BoolVar(&lookupVindexCacheVStreamInvalidation, "lookup-vindex-cache-vstream-invalidation", lookupVindexCacheVStreamInvalidation, "Invalidate the cached ids of the lookup vindexes with a cache_size as soon as their rows change in the lookup tables, by streaming their row events from the primary tablets")
+	fs.StringVar(&queryQuotasFile, "query-quotas-file", queryQuotasFile, "JSON file with the maximum concurrency, queries per second and queue size of the queries of each user, and of each workload set by the WORKLOAD_NAME comment directive")
+	fs.DurationVar(&queryQuotaQueueTimeout, "query-quota-queue-timeout", queryQuotaQueueTimeout, "Maximum time a query waits for the quotas of --query-quotas-file to let it execute before it is rejected")
 }
 
 func init() {
@@ -188,7 +229,7 @@ var (
 	// Error counters should be global so they can be set from anywhere
 	errorCounts = stats.NewCountersWithMultiLabels("VtgateApiErrorCounts", "Vtgate API error counts per error type", []string{"Operation", "Keyspace", "DbType", "Code"})
 
-	warnings = stats.NewCountersWithSingleLabel("VtGateWarnings", "Vtgate warnings", "type", "IgnoredSet", "NonAtomicCommit", "ResultsExceeded", "WarnPayloadSizeExceeded", "WarnUnshardedOnly")
+	warnings = stats.NewCountersWithSingleLabel("VtGateWarnings", "Vtgate warnings", "type", "IgnoredSet", "NonAtomicCommit", "ResultsExceeded", "SessionToken", "WarnPayloadSizeExceeded", "WarnUnshardedOnly")
 
 	vstreamSkewDelayCount = stats.NewCounter("VStreamEventsDelayedBySkewAlignment",
 		"Number of events that had to wait because the skew across shards was too high")
@@ -330,13 +371,37 @@ func Init(
 		log.Fatalf("error initializing query logger: %v", err)
 	}
 
+	if queryQuotasFile != "" {
+		qqs, err := loadQueryQuotas(queryQuotasFile, queryQuotaQueueTimeout)
+		if err != nil {
+			log.Fatalf("error loading the query quotas: %v", err)
+		}
+		executor.queryQuotas = qqs
+	}
+
 	// connect the schema tracker with the vschema manager
 	if enableSchemaChangeSignal {
 		st.RegisterSignalReceiver(executor.vm.Rebuild)
+		st.RegisterTablesChangedReceiver(executor.InvalidateResultCache)
 	}
 
 	// TODO: call serv.WatchSrvVSchema here
 
+	if planCacheSnapshotFile != "" {
+		stopSnapshots := executor.startPlanCacheSnapshots(planCacheSnapshotFile, planCacheSnapshotInterval, planCacheSnapshotSize, planCacheWarmupTimeout)
+		servenv.OnTermSync(stopSnapshots)
+	}
+
+	if resultCacheVStreamInvalidation {
+		stopInvalidation := executor.startResultCacheInvalidation(vsm)
+		servenv.OnTermSync(stopInvalidation)
+	}
+
+	if lookupVindexCacheVStreamInvalidation {
+		stopInvalidation := executor.startLookupCacheInvalidation(vsm)
+		servenv.OnTermSync(stopInvalidation)
+	}
+
 	vtgateInst := newVTGate(executor, resolver, vsm, tc, gw)
 	_ = stats.NewRates("QPSByOperation", stats.CounterForDimension(vtgateInst.timings, "Operation"), 15, 1*time.Minute)
 	_ = stats.NewRates("QPSByKeyspace", stats.CounterForDimension(vtgateInst.timings, "Keyspace"), 15, 1*time.Minute)
@@ -434,6 +499,9 @@ func (vtg *VTGate) registerDebugHealthHandler() {
 // IsHealthy returns nil if server is healthy.
 // Otherwise, it returns an error indicating the reason.
 func (vtg *VTGate) IsHealthy() error {
+	if vtg.executor.WarmingPlanCache() {
+		return errors.New("warming up the query plan cache")
+	}
 	return nil
 }
 
@@ -592,6 +660,12 @@ func (vtg *VTGate) VSchemaStats() *VSchemaStats {
 	return vtg.executor.VSchemaStats()
 }
 
+// QueryQuotasStatus returns the status of the query quotas of the users and
+// workloads that executed queries.
+func (vtg *VTGate) QueryQuotasStatus() []*QueryQuotaStatus {
+	return vtg.executor.queryQuotas.status()
+}
+
 func truncateErrorStrings(data map[string]any, parser *sqlparser.Parser) map[string]any {
 	ret := map[string]any{}
 	if terseErrors {
@@ -115,8 +115,8 @@ func (client *QueryClient) Begin(clientFoundRows bool) error {
 // Commit commits the current transaction.
 func (client *QueryClient) Commit() error {
 	defer func() { client.transactionID = 0 }()
-	rID, err := client.server.Commit(client.ctx, client.target, client.transactionID)
-	client.reservedID = rID
+	state, err := client.server.Commit(client.ctx, client.target, client.transactionID)
+	client.reservedID = state.ReservedID
 	if err != nil {
 		return err
 	}
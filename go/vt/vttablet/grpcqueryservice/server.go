@@ -98,11 +98,14 @@ func (q *query) Commit(ctx context.Context, request *querypb.CommitRequest) (res
 		request.EffectiveCallerId,
 		request.ImmediateCallerId,
 	)
-	rID, err := q.server.Commit(ctx, request.Target, request.TransactionId)
+	state, err := q.server.Commit(ctx, request.Target, request.TransactionId)
 	if err != nil {
 		return nil, vterrors.ToGRPC(err)
 	}
-	return &querypb.CommitResponse{ReservedId: rID}, nil
+	return &querypb.CommitResponse{
+		ReservedId:          state.ReservedID,
+		SessionStateChanges: state.SessionStateChanges,
+	}, nil
 }
 
 // Rollback is part of the queryservice.QueryServer interface
@@ -221,11 +221,11 @@ func (conn *gRPCQueryClient) Begin(ctx context.Context, target *querypb.Target,
 }
 
 // Commit commits the ongoing transaction.
-func (conn *gRPCQueryClient) Commit(ctx context.Context, target *querypb.Target, transactionID int64) (int64, error) {
+func (conn *gRPCQueryClient) Commit(ctx context.Context, target *querypb.Target, transactionID int64) (queryservice.CommitState, error) {
 	conn.mu.RLock()
 	defer conn.mu.RUnlock()
 	if conn.cc == nil {
-		return 0, tabletconn.ConnClosed
+		return queryservice.CommitState{}, tabletconn.ConnClosed
 	}
 
 	req := &querypb.CommitRequest{
@@ -236,9 +236,9 @@ func (conn *gRPCQueryClient) Commit(ctx context.Context, target *querypb.Target,
 	}
 	resp, err := conn.c.Commit(ctx, req)
 	if err != nil {
-		return 0, tabletconn.ErrorFromGRPC(err)
+		return queryservice.CommitState{}, tabletconn.ErrorFromGRPC(err)
 	}
-	return resp.ReservedId, nil
+	return queryservice.CommitState{ReservedID: resp.ReservedId, SessionStateChanges: resp.SessionStateChanges}, nil
 }
 
 // Rollback rolls back the ongoing transaction.
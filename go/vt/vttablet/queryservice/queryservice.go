@@ -44,7 +44,7 @@ type QueryService interface {
 	Begin(ctx context.Context, target *querypb.Target, options *querypb.ExecuteOptions) (TransactionState, error)
 
 	// Commit commits the current transaction
-	Commit(ctx context.Context, target *querypb.Target, transactionID int64) (int64, error)
+	Commit(ctx context.Context, target *querypb.Target, transactionID int64) (CommitState, error)
 
 	// Rollback aborts the current transaction
 	Rollback(ctx context.Context, target *querypb.Target, transactionID int64) (int64, error)
@@ -134,6 +134,11 @@ type TransactionState struct {
 	SessionStateChanges string
 }
 
+type CommitState struct {
+	ReservedID          int64
+	SessionStateChanges string
+}
+
 type ReservedState struct {
 	ReservedID  int64
 	TabletAlias *topodatapb.TabletAlias
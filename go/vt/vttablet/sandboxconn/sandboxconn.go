@@ -102,6 +102,10 @@ type SandboxConn struct {
 	// ReadTransactionResults is used for returning results for ReadTransaction.
 	ReadTransactionResults []*querypb.TransactionMetadata
 
+	// CommitSessionStateChanges is returned by Commit as the session state
+	// changes of the commit.
+	CommitSessionStateChanges string
+
 	MessageIDs []*querypb.Value
 
 	// vstream expectations.
@@ -315,14 +319,14 @@ func (sbc *SandboxConn) begin(ctx context.Context, target *querypb.Target, preQu
 }
 
 // Commit is part of the QueryService interface.
-func (sbc *SandboxConn) Commit(ctx context.Context, target *querypb.Target, transactionID int64) (int64, error) {
+func (sbc *SandboxConn) Commit(ctx context.Context, target *querypb.Target, transactionID int64) (queryservice.CommitState, error) {
 	sbc.panicIfNeeded()
 	sbc.CommitCount.Add(1)
 	reservedID := sbc.getTxReservedID(transactionID)
 	if reservedID != 0 {
 		reservedID = sbc.ReserveID.Add(1)
 	}
-	return reservedID, sbc.getError()
+	return queryservice.CommitState{ReservedID: reservedID, SessionStateChanges: sbc.CommitSessionStateChanges}, sbc.getError()
 }
 
 // Rollback is part of the QueryService interface.
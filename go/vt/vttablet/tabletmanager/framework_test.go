@@ -211,8 +211,8 @@ func (ftc *fakeTabletConn) Begin(ctx context.Context, target *querypb.Target, op
 }
 
 // fakeTabletConn implements the QueryService interface.
-func (ftc *fakeTabletConn) Commit(ctx context.Context, target *querypb.Target, transactionID int64) (int64, error) {
-	return 0, nil
+func (ftc *fakeTabletConn) Commit(ctx context.Context, target *querypb.Target, transactionID int64) (queryservice.CommitState, error) {
+	return queryservice.CommitState{}, nil
 }
 
 // fakeTabletConn implements the QueryService interface.
@@ -18,6 +18,7 @@ package vdiff
 
 import (
 	"context"
+	"math"
 
 	"vitess.io/vitess/go/mysql/collations"
 	"vitess.io/vitess/go/sqltypes"
@@ -25,8 +26,13 @@ import (
 	"vitess.io/vitess/go/vt/vtgate/engine"
 )
 
+// sortSpillThreshold is the memory the source rows vdiff sorts can use
+// before they are spilled to disk. See tablePlan.sortSource.
+const sortSpillThreshold = 64 * 1024 * 1024
+
 // contextVCursor satisfies VCursor, but only implements Context().
-// MergeSort only requires Context to be implemented.
+// MergeSort only requires Context to be implemented, and MemorySort
+// and OrderedAggregate the memory limits.
 type contextVCursor struct {
 	engine.VCursor
 	ctx context.Context
@@ -43,3 +49,29 @@ func (vc *contextVCursor) ExecutePrimitive(ctx context.Context, primitive engine
 func (vc *contextVCursor) StreamExecutePrimitive(ctx context.Context, primitive engine.Primitive, bindVars map[string]*querypb.BindVariable, wantfields bool, callback func(*sqltypes.Result) error) error {
 	return primitive.TryStreamExecute(ctx, vc, bindVars, wantfields, callback)
 }
+
+func (vc *contextVCursor) MaxMemoryRows() int {
+	return math.MaxInt
+}
+
+func (vc *contextVCursor) ExceedsMaxMemoryRows(int) bool {
+	return false
+}
+
+func (vc *contextVCursor) ReserveMemory(int64) error {
+	return nil
+}
+
+func (vc *contextVCursor) ReleaseMemory(int64) {}
+
+func (vc *contextVCursor) SpillThreshold() int64 {
+	return sortSpillThreshold
+}
+
+func (vc *contextVCursor) SpillDirectory() string {
+	return ""
+}
+
+func (vc *contextVCursor) SpillNonStreaming() bool {
+	return false
+}
@@ -363,7 +363,14 @@ func (td *tableDiffer) startSourceDataStreams(ctx context.Context) error {
 	if err := td.forEachSource(func(source *migrationSource) error {
 		gtidch := make(chan string, 1)
 		source.result = make(chan *sqltypes.Result, 1)
-		go td.streamOneShard(ctx, source.shardStreamer, td.tablePlan.sourceQuery, td.lastPK, gtidch)
+		lastPK := td.lastPK
+		if td.tablePlan.sortSource {
+			// The last primary key of the target is not one of the sources, so the
+			// sources stream all their rows again, and the diff skips the rows
+			// it already compared.
+			lastPK = nil
+		}
+		go td.streamOneShard(ctx, source.shardStreamer, td.tablePlan.sourceQuery, lastPK, gtidch)
 
 		gtid, ok := <-gtidch
 		if !ok {
@@ -467,7 +474,16 @@ func (td *tableDiffer) setupRowSorters() {
 	for shard, source := range td.wd.ct.sources {
 		sources[shard] = source.shardStreamer
 	}
-	td.sourcePrimitive = newMergeSorter(sources, td.tablePlan.comparePKs, td.wd.collationEnv)
+	sourceMerger := newMergeSorter(sources, td.tablePlan.comparePKs, td.wd.collationEnv)
+	td.sourcePrimitive = sourceMerger
+	if td.tablePlan.sortSource {
+		// The sources don't stream their rows in the order of the primary key of
+		// the target, so the merged rows have to be sorted again.
+		td.sourcePrimitive = &engine.MemorySort{
+			OrderBy: sourceMerger.OrderBy,
+			Input:   sourceMerger,
+		}
+	}
 
 	// Create a merge sorter for the target.
 	targets := make(map[string]*shardStreamer)
@@ -475,8 +491,9 @@ func (td *tableDiffer) setupRowSorters() {
 	td.targetPrimitive = newMergeSorter(targets, td.tablePlan.comparePKs, td.wd.collationEnv)
 
 	// If there were aggregate expressions, we have to re-aggregate
-	// the results, which engine.OrderedAggregate can do.
-	if len(td.tablePlan.aggregates) != 0 {
+	// the results, which engine.OrderedAggregate can do. It also
+	// deduplicates the sorted source rows that the filter groups.
+	if len(td.tablePlan.aggregates) != 0 || td.tablePlan.sortSource {
 		td.sourcePrimitive = &engine.OrderedAggregate{
 			Aggregates:   td.tablePlan.aggregates,
 			GroupByKeys:  pkColsToGroupByParams(td.tablePlan.pkCols, td.wd.collationEnv),
@@ -527,6 +544,7 @@ func (td *tableDiffer) diff(ctx context.Context, rowsToCompare int64, debug, onl
 
 	sourceExecutor := newPrimitiveExecutor(ctx, td.sourcePrimitive, "source")
 	targetExecutor := newPrimitiveExecutor(ctx, td.targetPrimitive, "target")
+	lastPKRow := td.lastPKRow()
 	var sourceRow, lastProcessedRow, targetRow []sqltypes.Value
 	advanceSource := true
 	advanceTarget := true
@@ -566,7 +584,7 @@ func (td *tableDiffer) diff(ctx context.Context, rowsToCompare int64, debug, onl
 			return dr, nil
 		}
 		if advanceSource {
-			sourceRow, err = sourceExecutor.next()
+			sourceRow, err = td.nextSourceRow(sourceExecutor, lastPKRow)
 			if err != nil {
 				log.Error(err)
 				return nil, err
@@ -827,6 +845,37 @@ func updateTableMismatch(dbClient binlogplayer.DBClient, vdiffID int64, table st
 	return nil
 }
 
+// lastPKRow returns a row with the last primary key of the target the previous runs
+// compared, when the sources stream all their rows again. See startSourceDataStreams.
+func (td *tableDiffer) lastPKRow() []sqltypes.Value {
+	if !td.tablePlan.sortSource || td.lastPK == nil || len(td.lastPK.Rows) != 1 {
+		return nil
+	}
+	lastPK := sqltypes.Proto3ToResult(td.lastPK).Rows[0]
+	row := make([]sqltypes.Value, len(td.tablePlan.compareCols))
+	for i, colIndex := range td.tablePlan.pkCols {
+		row[colIndex] = lastPK[i]
+	}
+	return row
+}
+
+// nextSourceRow returns the next source row, skipping the rows up to the given last primary key.
+func (td *tableDiffer) nextSourceRow(sourceExecutor *primitiveExecutor, lastPKRow []sqltypes.Value) ([]sqltypes.Value, error) {
+	for {
+		row, err := sourceExecutor.next()
+		if err != nil || row == nil || lastPKRow == nil {
+			return row, err
+		}
+		c, err := td.compare(row, lastPKRow, td.tablePlan.comparePKs, false)
+		if err != nil {
+			return nil, err
+		}
+		if c > 0 {
+			return row, nil
+		}
+	}
+}
+
 func (td *tableDiffer) lastPKFromRow(row []sqltypes.Value) ([]byte, error) {
 	pkColCnt := len(td.tablePlan.pkCols)
 	pkFields := make([]*querypb.Field, pkColCnt)
@@ -0,0 +1,134 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vdiff
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/stats"
+	"vitess.io/vitess/go/vt/binlog/binlogplayer"
+	"vitess.io/vitess/go/vt/sqlparser"
+
+	tabletmanagerdatapb "vitess.io/vitess/go/vt/proto/tabletmanagerdata"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+)
+
+// TestDiffLookupVindex diffs the lookup table that the workflow created by LookupVindex create
+// backfills. Its filter selects the keyspace_id() of the owner rows, so the sources stream the
+// owner rows in the order of the primary key of the owner table, and stream the rows the filter
+// groups more than once.
+func TestDiffLookupVindex(t *testing.T) {
+	ctx := context.Background()
+	lookupFields := sqltypes.MakeTestFields("c1|keyspace_id", "int64|varbinary")
+	lookupTable := &tabletmanagerdatapb.TableDefinition{
+		Name:              "lookup",
+		Columns:           []string{"c1", "keyspace_id"},
+		PrimaryKeyColumns: []string{"c1", "keyspace_id"},
+		Fields:            lookupFields,
+	}
+	rowsAffected := &sqltypes.Result{RowsAffected: 1}
+
+	newTableDiffer := func(t *testing.T, dbClient *binlogplayer.MockDBClient) *tableDiffer {
+		ct := &controller{
+			id: 1,
+			vde: &Engine{
+				parser:     sqlparser.NewTestParser(),
+				thisTablet: &topodatapb.Tablet{Alias: &topodatapb.TabletAlias{Cell: "zone1", Uid: 100}},
+			},
+			dbClientFactory: func() binlogplayer.DBClient { return dbClient },
+			done:            make(chan struct{}),
+			sources: map[string]*migrationSource{
+				"-80": {shardStreamer: &shardStreamer{shard: "-80"}},
+				"80-": {shardStreamer: &shardStreamer{shard: "80-"}},
+			},
+			targetShardStreamer:   &shardStreamer{shard: "0"},
+			TableDiffRowCounts:    stats.NewCountersWithSingleLabel("", "", "Rows"),
+			TableDiffPhaseTimings: stats.NewTimings("", "", "", "TablePhase"),
+		}
+		wd := &workflowDiffer{
+			ct:           ct,
+			opts:         &tabletmanagerdatapb.VDiffOptions{CoreOptions: &tabletmanagerdatapb.VDiffCoreOptions{}},
+			collationEnv: collations.MySQL8(),
+		}
+		td := &tableDiffer{
+			wd:          wd,
+			table:       lookupTable,
+			sourceQuery: "select c1 as c1, keyspace_id() as keyspace_id from t1 where c1 is not null group by c1, keyspace_id",
+		}
+
+		dbClient.ExpectRequest("select column_name as column_name, collation_name as collation_name from information_schema.columns where table_schema='vttest' and table_name='lookup' and column_name in ('c1', 'keyspace_id')",
+			sqltypes.MakeTestResult(sqltypes.MakeTestFields("column_name|collation_name", "varchar|varchar"), "c1|binary", "keyspace_id|binary"), nil)
+		tp, err := td.buildTablePlan(dbClient, "vttest", wd.collationEnv)
+		require.NoError(t, err)
+		require.True(t, tp.sortSource)
+		require.Equal(t, "select c1 as c1, keyspace_id() as keyspace_id from t1 where c1 is not null group by c1, keyspace_id order by c1 asc, keyspace_id asc", tp.sourceQuery)
+		return td
+	}
+	stream := func(streamer *shardStreamer, rows ...string) {
+		streamer.result = make(chan *sqltypes.Result, 1)
+		streamer.result <- sqltypes.MakeTestResult(lookupFields, rows...)
+		close(streamer.result)
+	}
+
+	t.Run("all rows", func(t *testing.T) {
+		dbClient := binlogplayer.NewMockDBClient(t)
+		td := newTableDiffer(t, dbClient)
+		td.setupRowSorters()
+		stream(td.wd.ct.sources["-80"].shardStreamer, "3|ks1", "1|ks1", "3|ks1")
+		stream(td.wd.ct.sources["80-"].shardStreamer, "2|ks2", "1|ks2")
+		stream(td.wd.ct.targetShardStreamer, "1|ks1", "1|ks2", "2|ks2", "3|ks1")
+
+		dbClient.ExpectRequestRE("select vdt.lastpk as lastpk, vdt.mismatch as mismatch, vdt.report as report.*",
+			sqltypes.MakeTestResult(sqltypes.MakeTestFields("lastpk|mismatch|report", "varbinary|int64|json"), "|0|{}"), nil)
+		dbClient.ExpectRequestRE("update _vt.vdiff_table set rows_compared = 4, lastpk = .*", rowsAffected, nil)
+		dr, err := td.diff(ctx, 100, false, false, 100, 0, nil)
+		require.NoError(t, err)
+		require.EqualValues(t, 4, dr.ProcessedRows)
+		require.EqualValues(t, 4, dr.MatchingRows)
+		require.Zero(t, dr.MismatchedRows)
+		require.Zero(t, dr.ExtraRowsSource)
+		require.Zero(t, dr.ExtraRowsTarget)
+		dbClient.Wait()
+	})
+
+	t.Run("resumed", func(t *testing.T) {
+		dbClient := binlogplayer.NewMockDBClient(t)
+		td := newTableDiffer(t, dbClient)
+		// The target resumes from the last primary key, while the sources
+		// stream all their rows again.
+		td.lastPK = sqltypes.ResultToProto3(sqltypes.MakeTestResult(lookupFields, "1|ks2"))
+		td.setupRowSorters()
+		stream(td.wd.ct.sources["-80"].shardStreamer, "3|ks1", "1|ks1", "3|ks1")
+		stream(td.wd.ct.sources["80-"].shardStreamer, "2|ks2", "1|ks2")
+		stream(td.wd.ct.targetShardStreamer, "2|ks2", "3|ks1")
+
+		dbClient.ExpectRequestRE("select vdt.lastpk as lastpk, vdt.mismatch as mismatch, vdt.report as report.*",
+			sqltypes.MakeTestResult(sqltypes.MakeTestFields("lastpk|mismatch|report", "varbinary|int64|json"), "|0|{}"), nil)
+		dbClient.ExpectRequestRE("update _vt.vdiff_table set rows_compared = 2, lastpk = .*", rowsAffected, nil)
+		dr, err := td.diff(ctx, 100, false, false, 100, 0, nil)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, dr.MatchingRows)
+		require.Zero(t, dr.ExtraRowsSource)
+		require.Zero(t, dr.ExtraRowsTarget)
+		dbClient.Wait()
+	})
+}
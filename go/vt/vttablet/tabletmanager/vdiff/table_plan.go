@@ -59,6 +59,12 @@ type tablePlan struct {
 	table      *tabletmanagerdatapb.TableDefinition
 	orderBy    sqlparser.OrderBy
 	aggregates []*engine.AggregateParams
+
+	// sortSource is set when the filter selects the keyspace_id() of the source rows,
+	// like the filters of the lookup vindex workflows do. The sources then stream their
+	// rows in the order of their own primary key, without grouping them, so vdiff has
+	// to sort the source rows and deduplicate them on the primary key of the target.
+	sortSource bool
 }
 
 func (td *tableDiffer) buildTablePlan(dbClient binlogplayer.DBClient, dbName string, collationEnv *collations.Environment) (*tablePlan, error) {
@@ -99,6 +105,9 @@ func (td *tableDiffer) buildTablePlan(dbClient binlogplayer.DBClient, dbName str
 			} else {
 				targetCol = &sqlparser.ColName{Name: selExpr.As}
 			}
+			if fn, ok := selExpr.Expr.(*sqlparser.FuncExpr); ok && fn.Name.EqualString("keyspace_id") {
+				tp.sortSource = true
+			}
 			// If the input was "select a as b", then source will use "a" and target will use "b".
 			sourceSelect.SelectExprs = append(sourceSelect.SelectExprs, selExpr)
 			targetSelect.SelectExprs = append(targetSelect.SelectExprs, &sqlparser.AliasedExpr{Expr: targetCol})
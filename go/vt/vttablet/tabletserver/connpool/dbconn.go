@@ -25,6 +25,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"vitess.io/vitess/go/mysql/replication"
 	"vitess.io/vitess/go/mysql/sqlerror"
 	"vitess.io/vitess/go/pools/smartconnpool"
 	"vitess.io/vitess/go/sqltypes"
@@ -547,6 +548,12 @@ func (dbc *Conn) ID() int64 {
 	return dbc.conn.ID()
 }
 
+// ExecutedPosition returns the replication position of the transactions MySQL
+// executed.
+func (dbc *Conn) ExecutedPosition() (replication.Position, error) {
+	return dbc.conn.PrimaryPosition()
+}
+
 // BaseShowTables returns a query that shows tables
 func (dbc *Conn) BaseShowTables() string {
 	return dbc.conn.BaseShowTables()
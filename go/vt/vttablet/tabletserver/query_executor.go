@@ -269,9 +269,13 @@ func (qre *QueryExecutor) execAsTransaction(f func(conn *StatefulConnection) (*s
 	}
 
 	defer qre.logStats.AddRewrittenSQL("commit", time.Now())
-	if _, err := qre.tsv.te.txPool.Commit(qre.ctx, conn); err != nil {
+	_, sessionStateChanges, err := qre.tsv.te.txPool.Commit(qre.ctx, conn)
+	if err != nil {
 		return nil, err
 	}
+	if sessionStateChanges != "" {
+		result.SessionStateChanges = sessionStateChanges
+	}
 	return result, nil
 }
 
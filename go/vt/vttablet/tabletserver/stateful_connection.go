@@ -50,6 +50,10 @@ type StatefulConnection struct {
 	enforceTimeout bool
 	timeout        time.Duration
 	expiryTime     time.Time
+
+	// trackingOwnGtid is set while session_track_gtids = OWN_GTID is set on
+	// the connection for its transaction.
+	trackingOwnGtid bool
 }
 
 // Properties contains meta information about the connection
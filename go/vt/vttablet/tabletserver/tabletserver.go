@@ -33,6 +33,7 @@ import (
 	"time"
 
 	"vitess.io/vitess/go/acl"
+	"vitess.io/vitess/go/mysql/replication"
 	"vitess.io/vitess/go/mysql/sqlerror"
 	"vitess.io/vitess/go/pools/smartconnpool"
 	"vitess.io/vitess/go/sqltypes"
@@ -578,7 +579,7 @@ func (tsv *TabletServer) resolveTargetType(ctx context.Context, target *querypb.
 }
 
 // Commit commits the specified transaction.
-func (tsv *TabletServer) Commit(ctx context.Context, target *querypb.Target, transactionID int64) (newReservedID int64, err error) {
+func (tsv *TabletServer) Commit(ctx context.Context, target *querypb.Target, transactionID int64) (state queryservice.CommitState, err error) {
 	err = tsv.execRequest(
 		ctx, tsv.loadQueryTimeout(),
 		"Commit", "commit", nil,
@@ -588,8 +589,8 @@ func (tsv *TabletServer) Commit(ctx context.Context, target *querypb.Target, tra
 			logStats.TransactionID = transactionID
 
 			var commitSQL string
-			newReservedID, commitSQL, err = tsv.te.Commit(ctx, transactionID)
-			if newReservedID > 0 {
+			state.ReservedID, commitSQL, state.SessionStateChanges, err = tsv.te.Commit(ctx, transactionID)
+			if state.ReservedID > 0 {
 				// commit executed on old reserved id.
 				logStats.ReservedID = transactionID
 			}
@@ -610,7 +611,7 @@ func (tsv *TabletServer) Commit(ctx context.Context, target *querypb.Target, tra
 			return err
 		},
 	)
-	return newReservedID, err
+	return state, err
 }
 
 // Rollback rollsback the specified transaction.
@@ -1554,6 +1555,9 @@ func (tsv *TabletServer) execRequest(
 		tsv.sm.EndRequest()
 	}()
 
+	if err = tsv.waitForPosition(ctx, target, options); err != nil {
+		return tsv.convertAndLogError(ctx, sql, bindVariables, err, logStats)
+	}
 	err = exec(ctx, logStats)
 	if err != nil {
 		return tsv.convertAndLogError(ctx, sql, bindVariables, err, logStats)
@@ -1561,6 +1565,71 @@ func (tsv *TabletServer) execRequest(
 	return nil
 }
 
+// waitForPositionInterval is how often waitForPosition first checks the
+// position of the replica. The interval doubles after each check, up to
+// waitForPositionMaxInterval.
+var (
+	waitForPositionInterval    = 10 * time.Millisecond
+	waitForPositionMaxInterval = 500 * time.Millisecond
+)
+
+// waitForPosition waits until a replica reaches the wait_for_position of the
+// options, which vtgate sets for the reads that must see the writes tracked by
+// a session token. It polls the position of the replica instead of waiting
+// for it in MySQL, so that the waiting requests do not hold the connections
+// of the pool the queries need. When the replica does not reach the position
+// within the wait for position timeout, it returns a retryable error, so that
+// vtgate can send the query to another tablet.
+func (tsv *TabletServer) waitForPosition(ctx context.Context, target *querypb.Target, options *querypb.ExecuteOptions) error {
+	if options.GetWaitForPosition() == "" || target == nil || target.TabletType == topodatapb.TabletType_PRIMARY {
+		return nil
+	}
+	pos, err := replication.DecodePosition(options.WaitForPosition)
+	if err != nil {
+		return vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "invalid wait_for_position %s: %v", options.WaitForPosition, err)
+	}
+	defer tsv.stats.WaitTimings.Record("WaitForPosition", time.Now())
+	timeout := tsv.config.WaitForPositionTimeout
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	interval := waitForPositionInterval
+	for {
+		reached, err := tsv.reachedPosition(ctx, pos)
+		if err != nil {
+			return err
+		}
+		if reached {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return vterrors.Errorf(vtrpcpb.Code_DEADLINE_EXCEEDED, "timed out waiting for position %v", pos)
+		case <-expired:
+			return vterrors.Errorf(vtrpcpb.Code_UNAVAILABLE, "replica did not reach position %v within %v", pos, timeout)
+		case <-time.After(interval):
+		}
+		interval = min(2*interval, waitForPositionMaxInterval)
+	}
+}
+
+// reachedPosition returns whether the replica executed all the GTIDs of pos.
+func (tsv *TabletServer) reachedPosition(ctx context.Context, pos replication.Position) (bool, error) {
+	conn, err := tsv.qe.conns.Get(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Recycle()
+	current, err := conn.Conn.ExecutedPosition()
+	if err != nil {
+		return false, err
+	}
+	return current.AtLeast(pos), nil
+}
+
 func (tsv *TabletServer) handlePanicAndSendLogStats(
 	sql string,
 	bindVariables map[string]*querypb.BindVariable,
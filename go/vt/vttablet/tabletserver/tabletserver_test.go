@@ -774,8 +774,9 @@ func TestTabletServerReserveAndBeginCommit(t *testing.T) {
 	require.Error(t, err)
 
 	// commit
-	newRID, err := tsv.Commit(ctx, &target, state.TransactionID)
+	commitState, err := tsv.Commit(ctx, &target, state.TransactionID)
 	require.NoError(t, err)
+	newRID := commitState.ReservedID
 	assert.NotEqual(t, state.ReservedID, newRID)
 	rID := newRID
 
@@ -843,6 +844,63 @@ func TestTabletServerStreamExecute(t *testing.T) {
 	}
 }
 
+func TestTabletServerWaitForPosition(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	db, tsv := setupTabletServerTest(t, ctx, "")
+	defer tsv.StopService()
+	defer db.Close()
+
+	executeSQL := "select * from test_table limit 1000"
+	db.AddQuery(executeSQL, &sqltypes.Result{})
+	gtidExecuted := func(gtids string) {
+		db.AddQuery("SELECT @@global.gtid_executed", sqltypes.MakeTestResult(sqltypes.MakeTestFields("gtid_executed", "varchar"), gtids))
+	}
+	gtidExecuted("3e11fa47-71ca-11e1-9e33-c80aa9429562:1-3")
+	options := &querypb.ExecuteOptions{WaitForPosition: "MySQL56/3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5"}
+
+	// The primary does not wait.
+	target := querypb.Target{TabletType: topodatapb.TabletType_PRIMARY}
+	_, err := tsv.Execute(ctx, &target, executeSQL, nil, 0, 0, options)
+	require.NoError(t, err)
+	assert.NotContains(t, db.QueryLog(), "gtid_executed")
+
+	// A replica polls its position until it reaches the one to wait for,
+	// without holding a connection of the pool meanwhile.
+	err = tsv.SetServingType(topodatapb.TabletType_REPLICA, time.Time{}, true, "")
+	require.NoError(t, err)
+	target = querypb.Target{TabletType: topodatapb.TabletType_REPLICA}
+	db.ResetQueryLog()
+	done := make(chan error)
+	go func() {
+		_, err := tsv.Execute(ctx, &target, executeSQL, nil, 0, 0, options)
+		done <- err
+	}()
+	require.Eventually(t, func() bool {
+		return strings.Contains(db.QueryLog(), "gtid_executed") && tsv.qe.conns.InUse() == 0
+	}, 5*time.Second, time.Millisecond)
+	gtidExecuted("3e11fa47-71ca-11e1-9e33-c80aa9429562:1-7")
+	require.NoError(t, <-done)
+
+	// The wait stops with the request.
+	gtidExecuted("3e11fa47-71ca-11e1-9e33-c80aa9429562:1-3")
+	timeoutCtx, timeoutCancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer timeoutCancel()
+	_, err = tsv.Execute(timeoutCtx, &target, executeSQL, nil, 0, 0, options)
+	assert.ErrorContains(t, err, "timed out waiting for position")
+
+	// The wait is bounded by the wait for position timeout, with an error
+	// that lets vtgate retry on another tablet.
+	tsv.config.WaitForPositionTimeout = 50 * time.Millisecond
+	_, err = tsv.Execute(ctx, &target, executeSQL, nil, 0, 0, options)
+	assert.ErrorContains(t, err, "replica did not reach position 3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5 within 50ms")
+	assert.Equal(t, vtrpcpb.Code_UNAVAILABLE, vterrors.Code(err))
+
+	options.WaitForPosition = "bad position"
+	_, err = tsv.Execute(ctx, &target, executeSQL, nil, 0, 0, options)
+	assert.ErrorContains(t, err, "invalid wait_for_position bad position")
+}
+
 func TestTabletServerStreamExecuteComments(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -43,6 +43,9 @@ const (
 	txLogInterval  = 1 * time.Minute
 	beginWithCSRO  = "start transaction with consistent snapshot, read only"
 	trackGtidQuery = "set session session_track_gtids = START_GTID"
+
+	trackOwnGtidQuery   = "set session session_track_gtids = OWN_GTID"
+	untrackOwnGtidQuery = "set session session_track_gtids = default"
 )
 
 var txIsolations = map[querypb.ExecuteOptions_TransactionIsolation]string{
@@ -179,23 +182,28 @@ func (tp *TxPool) GetAndLock(connID tx.ConnID, reason string) (*StatefulConnecti
 	return conn, nil
 }
 
-// Commit commits the transaction on the connection.
-func (tp *TxPool) Commit(ctx context.Context, txConn *StatefulConnection) (string, error) {
+// Commit commits the transaction on the connection. It also returns the
+// session state changes of the commit, which have the GTID of the transaction
+// if it was begun with the session_track_gtids option.
+func (tp *TxPool) Commit(ctx context.Context, txConn *StatefulConnection) (string, string, error) {
 	if !txConn.IsInTransaction() {
-		return "", vterrors.New(vtrpcpb.Code_INTERNAL, "not in a transaction")
+		return "", "", vterrors.New(vtrpcpb.Code_INTERNAL, "not in a transaction")
 	}
 	span, ctx := trace.NewSpan(ctx, "TxPool.Commit")
 	defer span.Finish()
 	defer tp.txComplete(txConn, tx.TxCommit)
 	if txConn.TxProperties().Autocommit {
-		return "", nil
+		untrackOwnGtid(ctx, txConn)
+		return "", "", nil
 	}
 
-	if _, err := txConn.Exec(ctx, "commit", 1, false); err != nil {
+	qr, err := txConn.Exec(ctx, "commit", 1, false)
+	if err != nil {
 		txConn.Close()
-		return "", err
+		return "", "", err
 	}
-	return "commit", nil
+	untrackOwnGtid(ctx, txConn)
+	return "commit", qr.SessionStateChanges, nil
 }
 
 // RollbackAndRelease rolls back the transaction on the specified connection, and releases the connection when done
@@ -215,6 +223,7 @@ func (tp *TxPool) Rollback(ctx context.Context, txConn *StatefulConnection) erro
 		return nil
 	}
 	if txConn.TxProperties().Autocommit {
+		untrackOwnGtid(ctx, txConn)
 		tp.txComplete(txConn, tx.TxCommit)
 		return nil
 	}
@@ -223,6 +232,7 @@ func (tp *TxPool) Rollback(ctx context.Context, txConn *StatefulConnection) erro
 		txConn.Close()
 		return err
 	}
+	untrackOwnGtid(ctx, txConn)
 	return nil
 }
 
@@ -316,10 +326,22 @@ func createTransaction(
 		}
 	case querypb.ExecuteOptions_AUTOCOMMIT:
 		autocommitTransaction = true
+		beginQueries, err = trackOwnGtid(ctx, conn, options)
+		if err != nil {
+			return "", false, "", err
+		}
 	case querypb.ExecuteOptions_REPEATABLE_READ, querypb.ExecuteOptions_READ_COMMITTED, querypb.ExecuteOptions_READ_UNCOMMITTED,
 		querypb.ExecuteOptions_SERIALIZABLE, querypb.ExecuteOptions_DEFAULT:
-		isolationLevel := txIsolations[options.GetTransactionIsolation()]
 		var execSQL string
+		execSQL, err = trackOwnGtid(ctx, conn, options)
+		if err != nil {
+			return "", false, "", err
+		}
+		if execSQL != "" {
+			beginQueries = execSQL + "; "
+		}
+
+		isolationLevel := txIsolations[options.GetTransactionIsolation()]
 		if isolationLevel != "" {
 			execSQL, err = setIsolationLevel(ctx, conn, isolationLevel)
 			if err != nil {
@@ -424,6 +446,34 @@ func handleConsistentSnapshotCase(ctx context.Context, conn *StatefulConnection)
 	return
 }
 
+// trackOwnGtid makes MySQL return the GTID of the transactions it commits on
+// the connection in their session state changes, if the options ask for it.
+func trackOwnGtid(ctx context.Context, conn *StatefulConnection, options *querypb.ExecuteOptions) (string, error) {
+	if !options.GetSessionTrackGtids() {
+		return "", nil
+	}
+	conn.trackingOwnGtid = true
+	if _, err := conn.execWithRetry(ctx, trackOwnGtidQuery, 1, false); err != nil {
+		return "", err
+	}
+	return trackOwnGtidQuery, nil
+}
+
+// untrackOwnGtid sets session_track_gtids back to its default once the
+// transaction that tracked its GTID is over, so that the connection does not
+// keep tracking it for the next users of the pool. The connection is closed
+// if it cannot be reset.
+func untrackOwnGtid(ctx context.Context, conn *StatefulConnection) {
+	if !conn.trackingOwnGtid {
+		return
+	}
+	conn.trackingOwnGtid = false
+	if _, err := conn.Exec(ctx, untrackOwnGtidQuery, 1, false); err != nil {
+		log.Warningf("Failed to reset session_track_gtids, closing the connection: %v", err)
+		conn.Close()
+	}
+}
+
 func startTransaction(ctx context.Context, conn *StatefulConnection, transaction string) (string, string, error) {
 	sessionStateChanges, err := conn.execWithRetry(ctx, transaction, 1, false)
 	if err != nil {
@@ -19,6 +19,7 @@ package tabletserver
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -64,11 +65,11 @@ func TestTxPoolExecuteCommit(t *testing.T) {
 	conn3, err := txPool.GetAndLock(id, "")
 	require.NoError(t, err)
 
-	_, err = txPool.Commit(ctx, conn3)
+	_, _, err = txPool.Commit(ctx, conn3)
 	require.NoError(t, err)
 
 	// try committing again. this should fail
-	_, err = txPool.Commit(ctx, conn)
+	_, _, err = txPool.Commit(ctx, conn)
 	require.EqualError(t, err, "not in a transaction")
 
 	// wrap everything up and assert
@@ -135,7 +136,7 @@ func TestTxPoolRollbackNonBusy(t *testing.T) {
 	txPool.Shutdown(ctx)
 
 	// committing tx1 should not be an issue
-	_, err = txPool.Commit(ctx, conn1)
+	_, _, err = txPool.Commit(ctx, conn1)
 	require.NoError(t, err)
 
 	// Trying to get back to conn2 should not work since the transaction has been rolled back
@@ -179,7 +180,7 @@ func TestTxPoolAutocommit(t *testing.T) {
 	query := "select 3"
 	conn1.Exec(ctx, query, 1, false)
 
-	_, err = txPool.Commit(ctx, conn1)
+	_, _, err = txPool.Commit(ctx, conn1)
 	require.NoError(t, err)
 	conn1.Release(tx.TxCommit)
 
@@ -327,6 +328,48 @@ func TestTxPoolWaitTimeoutError(t *testing.T) {
 	require.True(t, conn.TxProperties().LogToFile)
 }
 
+func TestTxPoolUntrackOwnGtid(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	db, txPool, _, closer := setup(t)
+	defer closer()
+	options := &querypb.ExecuteOptions{SessionTrackGtids: true}
+
+	// session_track_gtids is reset once the transaction commits or rolls back.
+	db.ResetQueryLog()
+	conn, _, _, err := txPool.Begin(ctx, options, false, 0, nil, nil)
+	require.NoError(t, err)
+	_, _, err = txPool.Commit(ctx, conn)
+	require.NoError(t, err)
+	conn.Release(tx.TxCommit)
+	require.True(t, strings.HasSuffix(db.QueryLog(), "set session session_track_gtids = own_gtid;begin;commit;set session session_track_gtids = default"), db.QueryLog())
+
+	db.ResetQueryLog()
+	conn, _, _, err = txPool.Begin(ctx, options, false, 0, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, txPool.Rollback(ctx, conn))
+	conn.Release(tx.TxRollback)
+	require.True(t, strings.HasSuffix(db.QueryLog(), "set session session_track_gtids = own_gtid;begin;rollback;set session session_track_gtids = default"), db.QueryLog())
+
+	// It is not reset on the connections that did not track the GTIDs.
+	db.ResetQueryLog()
+	conn, _, _, err = txPool.Begin(ctx, &querypb.ExecuteOptions{}, false, 0, nil, nil)
+	require.NoError(t, err)
+	_, _, err = txPool.Commit(ctx, conn)
+	require.NoError(t, err)
+	conn.Release(tx.TxCommit)
+	require.NotContains(t, db.QueryLog(), "session_track_gtids")
+
+	// The connection is closed if it cannot be reset.
+	db.AddRejectedQuery("set session session_track_gtids = default", errRejected)
+	conn, _, _, err = txPool.Begin(ctx, options, false, 0, nil, nil)
+	require.NoError(t, err)
+	_, _, err = txPool.Commit(ctx, conn)
+	require.NoError(t, err)
+	require.True(t, conn.IsClosed())
+	conn.Release(tx.TxCommit)
+}
+
 func TestTxPoolRollbackFailIsPassedThrough(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -381,7 +424,7 @@ func TestTxPoolGetConnRecentlyRemovedTransaction(t *testing.T) {
 
 	conn1, _, _, _ = txPool.Begin(ctx, &querypb.ExecuteOptions{}, false, 0, nil, nil)
 	id = conn1.ReservedID()
-	_, err := txPool.Commit(ctx, conn1)
+	_, _, err := txPool.Commit(ctx, conn1)
 	require.NoError(t, err)
 
 	conn1.Releasef("transaction committed")
@@ -670,9 +713,10 @@ func TestTxPoolBeginStatements(t *testing.T) {
 	defer closer()
 
 	testCases := []struct {
-		txIsolationLevel querypb.ExecuteOptions_TransactionIsolation
-		txAccessModes    []querypb.ExecuteOptions_TransactionAccessMode
-		readOnly         bool
+		txIsolationLevel  querypb.ExecuteOptions_TransactionIsolation
+		txAccessModes     []querypb.ExecuteOptions_TransactionAccessMode
+		readOnly          bool
+		sessionTrackGtids bool
 
 		expBeginSQL string
 		expErr      string
@@ -774,16 +818,29 @@ func TestTxPoolBeginStatements(t *testing.T) {
 		},
 		readOnly:    true,
 		expBeginSQL: "set transaction isolation level repeatable read; start transaction with consistent snapshot, read only",
+	}, {
+		txIsolationLevel:  querypb.ExecuteOptions_DEFAULT,
+		sessionTrackGtids: true,
+		expBeginSQL:       "set session session_track_gtids = OWN_GTID; begin",
+	}, {
+		txIsolationLevel:  querypb.ExecuteOptions_READ_COMMITTED,
+		sessionTrackGtids: true,
+		expBeginSQL:       "set session session_track_gtids = OWN_GTID; set transaction isolation level read committed; begin",
+	}, {
+		txIsolationLevel:  querypb.ExecuteOptions_AUTOCOMMIT,
+		sessionTrackGtids: true,
+		expBeginSQL:       "set session session_track_gtids = OWN_GTID",
 	}}
 
 	for _, tc := range testCases {
-		t.Run(fmt.Sprintf("%v:%v:readOnly:%v", tc.txIsolationLevel, tc.txAccessModes, tc.readOnly), func(t *testing.T) {
+		t.Run(fmt.Sprintf("%v:%v:readOnly:%v:sessionTrackGtids:%v", tc.txIsolationLevel, tc.txAccessModes, tc.readOnly, tc.sessionTrackGtids), func(t *testing.T) {
 			ctx, cancel := context.WithCancel(context.Background())
 			defer cancel()
 
 			options := &querypb.ExecuteOptions{
 				TransactionIsolation:  tc.txIsolationLevel,
 				TransactionAccessMode: tc.txAccessModes,
+				SessionTrackGtids:     tc.sessionTrackGtids,
 			}
 			conn, beginSQL, _, err := txPool.Begin(ctx, options, tc.readOnly, 0, nil, nil)
 			if tc.expErr != "" {